@@ -0,0 +1,622 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/a11y"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/browser"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/contact"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/golden"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/parity"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/pdfa"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/visual"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// newBrowser starts headless Chrome for the site's browser checks, as
+// browser.NewForTest does for the packages' own tests
+func newBrowser(t *testing.T) *browser.Browser {
+	t.Helper()
+	return browser.NewForTest(t)
+}
+
+// BrowserTestSuite checks the site as a browser renders it, after scripts
+// have run. It serves the built public/ locally, or targets
+// OSYRAA_BROWSER_URL, e.g. a preview deployment.
+type BrowserTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	browser *browser.Browser
+	baseURL *url.URL
+	site    *crawl.Result
+}
+
+// SetupSuite starts the browser and the site under test
+func (suite *BrowserTestSuite) SetupSuite() {
+	t := suite.T()
+	suite.ctx = suiteContext(t)
+
+	target := os.Getenv("OSYRAA_BROWSER_URL")
+	if target == "" {
+		public := publicDir(t)
+		if _, err := os.Stat(public); err != nil {
+			t.Skipf("OSYRAA_PUBLIC_DIR %s isn't a built site: %v", public, err)
+		}
+		srv := httptest.NewServer(http.FileServer(http.Dir(public)))
+		t.Cleanup(srv.Close)
+		target = srv.URL + "/"
+	}
+	var err error
+	suite.baseURL, err = url.Parse(target)
+	require.NoError(t, err, "OSYRAA_BROWSER_URL should be a URL")
+	suite.browser = newBrowser(t)
+}
+
+// HandleStats records a span per test once the suite is done
+func (suite *BrowserTestSuite) HandleStats(_ string, stats *suiteStats) {
+	traceTests(suite.ctx, stats)
+}
+
+// pages crawls the site under test once and returns its HTML pages
+func (suite *BrowserTestSuite) pages() []*crawl.Page {
+	t := suite.T()
+	if suite.site == nil {
+		ctx, cancel := context.WithTimeout(suite.ctx, settings.Timeouts.Crawl)
+		defer cancel()
+		site, err := newCrawler(t, crawl.NewHTTPFetcher(nil)).Run(ctx, suite.baseURL)
+		require.NoError(t, err, "Crawling the site under test should succeed")
+		suite.site = site
+	}
+	return suite.site.HTML()
+}
+
+// TestRenderedSections loads the home page, waits for scripts, fonts and
+// the network to settle, and checks the rendered DOM rather than the
+// markup on disk
+func (suite *BrowserTestSuite) TestRenderedSections() {
+	t := suite.T()
+
+	tab, cancel := suite.browser.NewTab()
+	defer cancel()
+	ctx, stop := context.WithTimeout(tab, time.Minute)
+	defer stop()
+	doc, err := browser.Render(ctx, suite.baseURL.String())
+	require.NoError(t, err, "The home page should render")
+
+	assert.NoError(t, doc.Select("h1").TextEquals(settings.Author), "Rendered page should show the author name")
+	assert.NoError(t, doc.Select("header .contact-info a[href^='mailto:']").Exists(), "Rendered page should show contact details")
+	assert.Equal(t, settings.Sections, doc.Select("main h2").Texts(), "Rendered page should show every section in order")
+	for _, section := range settings.Sections {
+		assert.NoError(t, doc.XPath("//h2[normalize-space()='"+section+"']/following-sibling::*[1]").Exists(),
+			"Section %s should have content after its heading", section)
+	}
+}
+
+// keyPages are screenshotted and compared with their baselines, and
+// their markup with its snapshots
+var keyPages = []struct{ Name, Path string }{
+	{"home", "/"},
+}
+
+// visualBaselines are the reference screenshots committed in
+// testdata/visual. OSYRAA_BASELINES moves them and
+// OSYRAA_UPDATE_BASELINES=1 replaces them with the current rendering, to
+// accept an intended change or add a page. A page without one is skipped
+// with the command that records it, since screenshots depend on the
+// fonts and Chrome of the machine that takes them.
+func visualBaselines() visual.Baselines {
+	dir := os.Getenv("OSYRAA_BASELINES")
+	if dir == "" {
+		dir = filepath.Join("testdata", "visual")
+	}
+	return visual.Baselines{Dir: dir, Update: os.Getenv("OSYRAA_UPDATE_BASELINES") == "1"}
+}
+
+// TestVisualRegression screenshots each key page at desktop size and fails
+// when more than OSYRAA_VISUAL_TOLERANCE (a fraction, default 0.001) of the
+// pixels differ perceptually from the baseline. The baseline, the new
+// screenshot and an annotated diff go into the report.
+func (suite *BrowserTestSuite) TestVisualRegression() {
+	t := suite.T()
+
+	tolerance := visual.DefaultTolerance
+	if v := os.Getenv("OSYRAA_VISUAL_TOLERANCE"); v != "" {
+		var err error
+		tolerance, err = strconv.ParseFloat(v, 64)
+		require.NoError(t, err, "OSYRAA_VISUAL_TOLERANCE should be a fraction such as 0.001")
+	}
+	baselines := visualBaselines()
+
+	for _, page := range keyPages {
+		tab, cancel := suite.browser.NewTab()
+		ctx, stop := context.WithTimeout(tab, time.Minute)
+		shot, err := browser.Screenshot(ctx, suite.baseURL.JoinPath(page.Path).String(), browser.Desktop)
+		stop()
+		cancel()
+		require.NoError(t, err, "Screenshotting %s should succeed", page.Path)
+
+		name := page.Name + "-" + browser.Desktop.Name
+		res, err := baselines.Check(name, shot, visual.DefaultThreshold)
+		if errors.Is(err, golden.ErrMissing) {
+			t.Skipf("%s has no baseline; record it with OSYRAA_UPDATE_BASELINES=1 go test -run TestBrowserSuite/TestVisualRegression and commit %s", name, filepath.Join(baselines.Dir, name+".png"))
+		}
+		require.NoError(t, err, "Comparing %s with its baseline should succeed", name)
+		if res.Updated {
+			t.Logf("Stored baseline %s.png in %s", name, baselines.Dir)
+			continue
+		}
+
+		section := report.Section{Title: "Visual regression: " + name, Status: report.Pass, Summary: res.Diff.String()}
+		if !res.Diff.Within(tolerance) {
+			t.Errorf("%s changed visually: %s", name, res.Diff)
+			section.Status = report.Fail
+			for _, img := range []struct {
+				suffix string
+				image  image.Image
+			}{{"baseline", res.Baseline}, {"actual", res.Actual}, {"diff", res.Diff.Image}} {
+				abs, rel, err := harnessReport.ArtifactPath(filepath.Join("visual", name+"-"+img.suffix+".png"))
+				require.NoError(t, err)
+				require.NoError(t, visual.WritePNG(abs, img.image), "Failed to save %s", rel)
+				section.Images = append(section.Images, report.Image{Caption: name + " " + img.suffix, Path: rel})
+			}
+		}
+		harnessReport.Add(section)
+	}
+}
+
+// TestBreakpoints renders every HTML page at phone, tablet and desktop
+// widths. No page may scroll horizontally, and the header and nav links
+// must stay visible (or behind a shown menu toggle), on-screen and big
+// enough to tap. Screenshots of the home page form a gallery in the report.
+func (suite *BrowserTestSuite) TestBreakpoints() {
+	t := suite.T()
+
+	gallery := report.Section{Title: "Breakpoints", Status: report.Pass}
+	var problems []string
+	for _, vp := range browser.Breakpoints {
+		for _, page := range suite.pages() {
+			tab, cancel := suite.browser.NewTab()
+			ctx, stop := context.WithTimeout(tab, time.Minute)
+			layout, err := browser.MeasureLayout(ctx, page.URL.String(), vp)
+			var shot []byte
+			if err == nil && page.URL.Path == suite.baseURL.Path {
+				shot, err = browser.Screenshot(ctx, page.URL.String(), vp)
+			}
+			stop()
+			cancel()
+			require.NoError(t, err, "Rendering %s at %s should succeed", page.URL.Path, vp.Name)
+
+			for _, p := range layout.Problems() {
+				problems = append(problems, page.URL.Path+" "+p)
+			}
+			if shot != nil {
+				abs, rel, err := harnessReport.ArtifactPath(filepath.Join("breakpoints", vp.Name+".png"))
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(abs, shot, 0o644), "Failed to save %s", rel)
+				gallery.Images = append(gallery.Images, report.Image{
+					Caption: fmt.Sprintf("%s (%dx%d)", vp.Name, vp.Width, vp.Height), Path: rel})
+			}
+		}
+	}
+
+	for _, p := range problems {
+		t.Errorf("Responsive layout: %s", p)
+	}
+	gallery.Summary = fmt.Sprintf("%d pages at %d viewports, %d problems", len(suite.pages()), len(browser.Breakpoints), len(problems))
+	if len(problems) > 0 {
+		gallery.Status = report.Fail
+	}
+	harnessReport.Add(gallery)
+}
+
+// TestKeyboardNavigation walks every page with Tab alone at each
+// breakpoint, since a menu collapsed on phones or a focus style lost at one
+// width is missed by a desktop mouse audit. The home page's tab order is
+// tabulated in the report.
+func (suite *BrowserTestSuite) TestKeyboardNavigation() {
+	t := suite.T()
+
+	section := report.Section{Title: "Keyboard navigation", Status: report.Pass,
+		Table: &report.Table{Header: []string{"Viewport", "#", "Element", "Visible", "Focus indicator"}}}
+	var problems []string
+	for _, vp := range browser.Breakpoints {
+		for _, page := range suite.pages() {
+			tab, cancel := suite.browser.NewTab()
+			ctx, stop := context.WithTimeout(tab, 2*time.Minute)
+			audit, err := browser.AuditKeyboard(ctx, page.URL.String(), vp)
+			stop()
+			cancel()
+			require.NoError(t, err, "Tabbing through %s at %s should succeed", page.URL.Path, vp.Name)
+
+			for _, p := range audit.Problems() {
+				problems = append(problems, page.URL.Path+" "+p)
+			}
+			if page.URL.Path != suite.baseURL.Path {
+				continue
+			}
+			for i, s := range audit.Stops {
+				if s.Revisit {
+					break
+				}
+				section.Table.Rows = append(section.Table.Rows, []string{vp.Name, strconv.Itoa(i + 1), s.String(),
+					strconv.FormatBool(s.InView), strconv.FormatBool(s.Indicator)})
+			}
+		}
+	}
+
+	for _, p := range problems {
+		t.Errorf("Keyboard: %s", p)
+	}
+	section.Summary = fmt.Sprintf("%d pages at %d viewports, %d problems", len(suite.pages()), len(browser.Breakpoints), len(problems))
+	if len(problems) > 0 {
+		section.Status = report.Fail
+	}
+	harnessReport.Add(section)
+}
+
+// TestInteractiveElements operates every menu toggle, <details> and theme
+// switch at each breakpoint, by mouse and by keyboard, and checks the ARIA
+// state and the governed content change together. Nothing else exercises
+// the theme's scripts.
+func (suite *BrowserTestSuite) TestInteractiveElements() {
+	t := suite.T()
+
+	operated := 0
+	for _, vp := range browser.Breakpoints {
+		for _, page := range suite.pages() {
+			tab, cancel := suite.browser.NewTab()
+			ctx, stop := context.WithTimeout(tab, 2*time.Minute)
+			results, err := browser.Interact(ctx, page.URL.String(), vp)
+			stop()
+			cancel()
+			require.NoError(t, err, "Operating controls on %s at %s should succeed", page.URL.Path, vp.Name)
+
+			operated += len(results)
+			for _, r := range results {
+				for _, p := range r.Problems {
+					t.Errorf("%s at %s: %s %s", page.URL.Path, vp.Name, r.Control, p)
+				}
+			}
+		}
+	}
+	if operated == 0 {
+		t.Log("No menu toggles, collapsible sections or theme switches found")
+	}
+}
+
+// TestConsoleErrors loads every page and fails on console errors, uncaught
+// exceptions and failed or blocked loads, such as a 404 font or a script
+// stopped by CSP, which static checks of the markup cannot see
+func (suite *BrowserTestSuite) TestConsoleErrors() {
+	t := suite.T()
+
+	for _, page := range suite.pages() {
+		tab, cancel := suite.browser.NewTab()
+		ctx, stop := context.WithTimeout(tab, time.Minute)
+		console, err := browser.WatchConsole(ctx)
+		if err == nil {
+			err = chromedp.Run(ctx,
+				chromedp.Navigate(page.URL.String()),
+				chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil),
+				browser.WaitRendered(),
+			)
+		}
+		stop()
+		cancel()
+		require.NoError(t, err, "Loading %s in the browser should succeed", page.URL.Path)
+
+		for _, m := range console.Messages() {
+			switch m.Level {
+			case "error":
+				t.Errorf("%s: %s", page.URL.Path, m)
+			case "warning":
+				t.Logf("%s: %s", page.URL.Path, m)
+			}
+		}
+	}
+}
+
+// TestWebFontsLoad renders each page and checks every web font it needs
+// loaded and the text is drawn with it, not a system fallback
+func (suite *BrowserTestSuite) TestWebFontsLoad() {
+	t := suite.T()
+
+	for _, page := range suite.pages() {
+		tab, cancel := suite.browser.NewTab()
+		ctx, stop := context.WithTimeout(tab, time.Minute)
+		loaded, err := browser.LoadFonts(ctx, page.URL.String())
+		stop()
+		cancel()
+		require.NoError(t, err, "Loading %s in the browser should succeed", page.URL.Path)
+
+		if len(loaded.Faces) == 0 {
+			t.Logf("%s: no web fonts", page.URL.Path)
+		}
+		for _, p := range loaded.Problems() {
+			t.Errorf("%s: %s", page.URL.Path, p)
+		}
+	}
+}
+
+// TestNavigationJourney clicks every same-origin link and section anchor
+// on the home page, the way a visitor would, and checks each lands on the
+// right content rather than an error page or the not-found fallback
+func (suite *BrowserTestSuite) TestNavigationJourney() {
+	t := suite.T()
+
+	tab, cancel := suite.browser.NewTab()
+	defer cancel()
+	ctx, stop := context.WithTimeout(tab, 5*time.Minute)
+	defer stop()
+	steps, err := browser.Journey(ctx, suite.baseURL.String())
+	require.NoError(t, err, "The navigation journey should complete")
+
+	if len(steps) == 0 {
+		t.Log("The home page has no same-origin links or section anchors to follow")
+		return
+	}
+	section := report.Section{Title: "Navigation journey", Status: report.Pass,
+		Summary: fmt.Sprintf("%d links followed from the home page", len(steps)),
+		Table:   &report.Table{Header: []string{"Link", "Href", "Landed", "Status", "Problem"}}}
+	for _, s := range steps {
+		if s.Problem != "" {
+			t.Errorf("Navigation: %s", s.Problem)
+			section.Status = report.Fail
+		}
+		section.Table.Rows = append(section.Table.Rows,
+			[]string{s.Link.Text, s.Link.Href, s.URL, strconv.FormatInt(s.Status, 10), s.Problem})
+	}
+	harnessReport.Add(section)
+}
+
+// TestPrintToPDF prints the home page to Letter-size PDF the way a
+// recruiter would. It must fit in OSYRAA_PDF_MAX_PAGES pages (default 5),
+// nothing may be cut off at the paper's width, and the PDF's outline must
+// carry the author and every resume section. The PDF goes into the report.
+func (suite *BrowserTestSuite) TestPrintToPDF() {
+	t := suite.T()
+
+	maxPages := 5
+	if v := os.Getenv("OSYRAA_PDF_MAX_PAGES"); v != "" {
+		var err error
+		maxPages, err = strconv.Atoi(v)
+		require.NoError(t, err, "OSYRAA_PDF_MAX_PAGES should be a number")
+	}
+
+	tab, cancel := suite.browser.NewTab()
+	defer cancel()
+	ctx, stop := context.WithTimeout(tab, time.Minute)
+	defer stop()
+	printed, err := browser.PrintPDF(ctx, suite.baseURL.String())
+	require.NoError(t, err, "Printing the home page should succeed")
+
+	abs, rel, err := harnessReport.ArtifactPath(filepath.Join("print", "resume.pdf"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(abs, printed.PDF, 0o644), "Failed to save %s", rel)
+
+	assert.LessOrEqual(t, printed.Pages, maxPages, "The printed resume should fit in %d pages", maxPages)
+	for _, el := range printed.Clipped {
+		t.Errorf("Clipped in print: %s", el)
+	}
+	for _, h := range printed.Hidden {
+		t.Errorf("Heading %q is hidden in print", h)
+	}
+	assert.Subset(t, printed.Headings, append([]string{settings.Author}, settings.Sections...),
+		"The PDF outline should list the author and every section")
+
+	status := report.Pass
+	if t.Failed() {
+		status = report.Fail
+	}
+	harnessReport.Add(report.Section{Title: "Print to PDF", Status: status,
+		Summary: fmt.Sprintf("%d pages, %d outline entries; saved as %s", printed.Pages, len(printed.Headings), rel)})
+}
+
+// TestContactInPrint checks the printed PDF shows the same email, phone
+// and social handles as the rendered page, so a recruiter working from the
+// printout reaches the same person
+func (suite *BrowserTestSuite) TestContactInPrint() {
+	t := suite.T()
+
+	tab, cancel := suite.browser.NewTab()
+	defer cancel()
+	ctx, stop := context.WithTimeout(tab, time.Minute)
+	defer stop()
+	doc, err := browser.Render(ctx, suite.baseURL.String())
+	require.NoError(t, err, "The home page should render")
+	printed, err := browser.PrintPDF(ctx, suite.baseURL.String())
+	require.NoError(t, err, "Printing the home page should succeed")
+
+	found, err := pageContacts(doc)
+	require.NoError(t, err, "The home page's JSON-LD should parse")
+	inPDF := contact.FromText("PDF", printed.Text)
+	assert.NotEmpty(t, inPDF, "The printed resume should show contact details")
+	for _, p := range contact.Compare(append(found, inPDF...)) {
+		t.Error(p)
+	}
+}
+
+// TestPDFArchival checks the printed resume against PDF/A-2b, so it
+// survives applicant tracking systems and long-term storage: no
+// encryption, embedded fonts, a title and no JavaScript or attachments.
+// Chrome cannot declare PDF/A itself, so a missing PDF/A identification or
+// output intent is only a warning unless OSYRAA_PDFA_STRICT=1, for a
+// pipeline that converts the PDF afterwards.
+func (suite *BrowserTestSuite) TestPDFArchival() {
+	t := suite.T()
+	strict := os.Getenv("OSYRAA_PDFA_STRICT") == "1"
+
+	tab, cancel := suite.browser.NewTab()
+	defer cancel()
+	ctx, stop := context.WithTimeout(tab, time.Minute)
+	defer stop()
+	printed, err := browser.PrintPDF(ctx, suite.baseURL.String())
+	require.NoError(t, err, "Printing the home page should succeed")
+	problems, err := pdfa.Check(printed.PDF)
+	require.NoError(t, err, "The printed PDF should be readable")
+
+	section := report.Section{Title: "PDF/A-2b", Status: report.Pass, Summary: "The printed resume meets the PDF/A-2b checks"}
+	if len(problems) > 0 {
+		section.Table = &report.Table{Header: []string{"Requirement", "Problem"}}
+		section.Summary = fmt.Sprintf("%d PDF/A-2b requirements not met", len(problems))
+	}
+	for _, p := range problems {
+		declaration := p.Requirement == pdfa.Identification || p.Requirement == pdfa.OutputIntent
+		if declaration && !strict {
+			t.Logf("Warning: %s", p)
+			if section.Status == report.Pass {
+				section.Status = report.Warn
+			}
+		} else {
+			t.Error(p)
+			section.Status = report.Fail
+		}
+		section.Table.Rows = append(section.Table.Rows, []string{p.Requirement, p.Message})
+	}
+	harnessReport.Add(section)
+}
+
+// TestContentParity checks the rendered page, the printed PDF and the
+// served resume.json carry the same name, sections, jobs, schools,
+// certifications, skills and projects, so no format drifts from the
+// others unnoticed
+func (suite *BrowserTestSuite) TestContentParity() {
+	t := suite.T()
+
+	tab, cancel := suite.browser.NewTab()
+	defer cancel()
+	ctx, stop := context.WithTimeout(tab, time.Minute)
+	defer stop()
+	doc, err := browser.Render(ctx, suite.baseURL.String())
+	require.NoError(t, err, "The home page should render")
+	printed, err := browser.PrintPDF(ctx, suite.baseURL.String())
+	require.NoError(t, err, "Printing the home page should succeed")
+	resp, body, err := newTarget(suite.baseURL.String()).Get(ctx, "/resume.json")
+	require.NoError(t, err, "Fetching resume.json should succeed")
+	require.Equal(t, http.StatusOK, resp.StatusCode, "The site should serve resume.json")
+
+	page, err := parity.FromHTML(doc)
+	require.NoError(t, err, "The home page should hold the resume in <main>")
+	exported, err := parity.FromJSONResume(body)
+	require.NoError(t, err, "resume.json should be valid JSON")
+	// The outline holds the headings even where the text layer splits them
+	inPDF := parity.FromText("PDF", printed.Text+"\n"+strings.Join(printed.Headings, "\n"))
+
+	problems := parity.Compare(page, inPDF, exported)
+	section := report.Section{Title: "Content parity", Status: report.Pass, Summary: "HTML, PDF and JSON Resume carry the same content"}
+	if len(problems) > 0 {
+		section.Status = report.Fail
+		section.Summary = fmt.Sprintf("%d differences between formats", len(problems))
+		section.Table = &report.Table{Header: []string{"Difference"}}
+	}
+	for _, p := range problems {
+		t.Error(p)
+		section.Table.Rows = append(section.Table.Rows, []string{p})
+	}
+	harnessReport.Add(section)
+}
+
+// TestAccessibility renders every page and checks it against the WCAG 2.1
+// AA baseline: document language and title, image alternatives,
+// landmarks, heading order, names for form fields, links and buttons, and
+// text contrast. It runs with -a11y or OSYRAA_A11Y=1.
+func (suite *BrowserTestSuite) TestAccessibility() {
+	t := suite.T()
+	if !*a11yAudit {
+		t.Skip("pass -a11y or set OSYRAA_A11Y=1 to audit accessibility")
+	}
+
+	section := report.Section{Title: "Accessibility", Status: report.Pass,
+		Table: &report.Table{Header: []string{"Page", "Rule", "WCAG", "Element", "Problem"}}}
+	pages := suite.pages()
+	for _, page := range pages {
+		tab, cancel := suite.browser.NewTab()
+		ctx, stop := context.WithTimeout(tab, time.Minute)
+		doc, err := browser.Render(ctx, page.URL.String())
+		var issues []a11y.Issue
+		if err == nil {
+			issues, err = a11y.Contrast(ctx, page.URL.Path)
+		}
+		stop()
+		cancel()
+		require.NoError(t, err, "Rendering %s in the browser should succeed", page.URL.Path)
+
+		for _, issue := range append(a11y.Check(page.URL.Path, doc), issues...) {
+			t.Error(issue)
+			section.Status = report.Fail
+			section.Table.Rows = append(section.Table.Rows, []string{issue.Page, issue.Rule, a11y.Criteria[issue.Rule], issue.Element, issue.Message})
+		}
+	}
+	section.Summary = fmt.Sprintf("%d pages, %d problems", len(pages), len(section.Table.Rows))
+	harnessReport.Add(section)
+}
+
+// TestPerformanceTrace records a Chrome performance trace while each key
+// page loads, for debugging Core Web Vitals regressions. It is opt-in with
+// OSYRAA_TRACE=1; the traces open in DevTools' Performance panel, and the
+// report lists long tasks, layout shifts and the request waterfall.
+func (suite *BrowserTestSuite) TestPerformanceTrace() {
+	t := suite.T()
+	if os.Getenv("OSYRAA_TRACE") != "1" {
+		t.Skip("set OSYRAA_TRACE=1 to record performance traces")
+	}
+
+	for _, kp := range keyPages {
+		pageURL := suite.baseURL.ResolveReference(&url.URL{Path: kp.Path}).String()
+		tab, cancel := suite.browser.NewTab()
+		ctx, stop := context.WithTimeout(tab, time.Minute)
+		trace, err := browser.CaptureTrace(ctx, pageURL)
+		stop()
+		cancel()
+		require.NoError(t, err, "Tracing %s should succeed", kp.Path)
+
+		abs, rel, err := harnessReport.ArtifactPath(filepath.Join("trace", kp.Name+".json"))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(abs, trace.JSON, 0o644), "Failed to save %s", rel)
+
+		tasks := trace.LongTasks()
+		shifts := trace.LayoutShifts()
+		for _, task := range tasks {
+			t.Logf("%s: long task of %v at %v", kp.Path, task.Duration, task.Start)
+		}
+		for _, s := range shifts {
+			t.Logf("%s: layout shift of %.4f at %v", kp.Path, s.Score, s.At)
+		}
+
+		section := report.Section{Title: "Performance trace: " + kp.Path, Status: report.Pass,
+			Summary: fmt.Sprintf("%d long tasks, total blocking time %v, CLS %.3f; trace saved as %s",
+				len(tasks), browser.TotalBlockingTime(tasks), browser.CLS(shifts), rel),
+			Table: &report.Table{Header: []string{"Request", "Status", "Type", "Start", "Response", "End", "Size"}}}
+		if len(tasks) > 0 || browser.CLS(shifts) > 0.1 {
+			section.Status = report.Warn
+		}
+		for _, r := range trace.Waterfall() {
+			section.Table.Rows = append(section.Table.Rows, []string{r.URL, strconv.Itoa(r.Status), r.MimeType,
+				r.Start.Round(time.Millisecond).String(), r.Response.Round(time.Millisecond).String(),
+				r.End.Round(time.Millisecond).String(), strconv.FormatInt(r.Size, 10)})
+		}
+		harnessReport.Add(section)
+	}
+}
+
+func TestBrowserSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(BrowserTestSuite))
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseSplit parses "version=fraction" pairs separated by commas
+func parseSplit(t *testing.T, s string) map[string]float64 {
+	t.Helper()
+	split := map[string]float64{}
+	for _, pair := range strings.Split(s, ",") {
+		version, fraction, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		require.True(t, ok, "split entry %q should be version=fraction", pair)
+		f, err := strconv.ParseFloat(fraction, 64)
+		require.NoError(t, err, "split fraction for %s", version)
+		split[version] = f
+	}
+	return split
+}
+
+// TestCanarySplit samples OSYRAA_CANARY_URL during a canary rollout and
+// compares the versions served with OSYRAA_CANARY_SPLIT (for example
+// "abc123=0.9,def456=0.1"). Versions come from the build-id meta tag, or the
+// header named by OSYRAA_CANARY_HEADER.
+func TestCanarySplit(t *testing.T) {
+	requireNetwork(t)
+	url, splitSpec := os.Getenv("OSYRAA_CANARY_URL"), os.Getenv("OSYRAA_CANARY_SPLIT")
+	if url == "" || splitSpec == "" {
+		t.Skip("set OSYRAA_CANARY_URL and OSYRAA_CANARY_SPLIT to verify a canary split")
+	}
+	split := parseSplit(t, splitSpec)
+
+	samples := 200
+	if v := os.Getenv("OSYRAA_CANARY_SAMPLES"); v != "" {
+		var err error
+		samples, err = strconv.Atoi(v)
+		require.NoError(t, err, "OSYRAA_CANARY_SAMPLES should be a number")
+	}
+	var tolerance float64
+	if v := os.Getenv("OSYRAA_CANARY_TOLERANCE"); v != "" {
+		var err error
+		tolerance, err = strconv.ParseFloat(v, 64)
+		require.NoError(t, err, "OSYRAA_CANARY_TOLERANCE should be a fraction")
+	}
+	source := deploy.VersionSource(deploy.BuildIDMeta)
+	if header := os.Getenv("OSYRAA_CANARY_HEADER"); header != "" {
+		source = deploy.VersionHeader(header)
+	}
+
+	// Fresh connections so the load balancer picks a backend per request
+	target := newTarget(url)
+	transport := httpclient.NewTransport(httpclient.Current())
+	transport.DisableKeepAlives = true
+	target.Client.Transport = transport
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	counts, err := deploy.SampleVersions(ctx, target, samples, source)
+	require.NoError(t, err, "Failed to sample versions")
+	t.Logf("Observed versions: %v", counts)
+	assert.NoError(t, deploy.CheckSplit(counts, split, tolerance), "Traffic split should match the rollout")
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cdnPurger builds the purger named by OSYRAA_PURGE, or nil to only wait
+func cdnPurger(t *testing.T, cdnURL string) deploy.Purger {
+	switch name := os.Getenv("OSYRAA_PURGE"); name {
+	case "":
+		return nil
+	case "cloudfront":
+		return &deploy.CloudFrontPurger{Site: &deploy.S3Site{DistributionID: os.Getenv("OSYRAA_CLOUDFRONT_ID")}}
+	case "cloudflare":
+		return &deploy.CloudflarePurger{
+			ZoneID:  os.Getenv("OSYRAA_CLOUDFLARE_ZONE"),
+			Token:   os.Getenv("OSYRAA_CLOUDFLARE_TOKEN"),
+			BaseURL: cdnURL,
+		}
+	default:
+		t.Fatalf("unsupported OSYRAA_PURGE %q (want cloudfront or cloudflare)", name)
+		return nil
+	}
+}
+
+// TestCDNPropagation requests every file changed since the previous deploy
+// (OSYRAA_PREVIOUS_MANIFEST, else every file) from OSYRAA_CDN_URL, purges
+// stale ones when OSYRAA_PURGE is set and waits until the edge is fresh.
+// OSYRAA_MANIFEST_OUT saves this build's manifest for the next deploy.
+func TestCDNPropagation(t *testing.T) {
+	requireNetwork(t)
+	cdnURL := os.Getenv("OSYRAA_CDN_URL")
+	if cdnURL == "" {
+		t.Skip("set OSYRAA_CDN_URL to verify CDN propagation")
+	}
+	local, err := deploy.LocalTree(publicDir(t))
+	require.NoError(t, err, "Failed to hash local public/")
+	require.NotEmpty(t, local, "public/ should be built before verifying propagation")
+
+	var previous map[string]deploy.LocalFile
+	if path := os.Getenv("OSYRAA_PREVIOUS_MANIFEST"); path != "" {
+		previous, err = deploy.ReadManifest(path)
+		require.NoError(t, err, "Failed to read previous manifest")
+	}
+	changed := deploy.ChangedPaths(previous, local)
+	t.Logf("%d of %d files changed", len(changed), len(local))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+	defer cancel()
+	client := &http.Client{Timeout: 15 * time.Second}
+	report, err := deploy.VerifyPropagation(ctx, client, cdnURL, local, changed, deploy.PropagationOptions{Purger: cdnPurger(t, cdnURL)})
+	if report != nil && len(report.Stale) > 0 {
+		t.Logf("Stale at the edge: %v (purged: %v)", report.Stale, report.Purged)
+	}
+	require.NoError(t, err, "CDN should serve the new build")
+
+	if path := os.Getenv("OSYRAA_MANIFEST_OUT"); path != "" {
+		assert.NoError(t, deploy.WriteManifest(path, local), "Failed to save manifest")
+	}
+}
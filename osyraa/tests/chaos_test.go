@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"os"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/chaos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chaosTmpfs are the writable mounts of the chaos container. Its root file
+// system is read-only, as in a hardened deployment, so these are all nginx
+// can write to and filling them is the worst disk pressure it can meet.
+var chaosTmpfs = map[string]string{
+	"/var/cache/nginx": "size=16m",
+	"/var/run":         "size=1m",
+	"/tmp":             "size=16m",
+}
+
+// TestResilience injects faults into a container of its own while it is
+// under load (OSYRAA_CHAOS=1): every nginx worker is killed at once, then
+// each writable tmpfs is filled. The site must keep serving through each
+// fault and serve every request once it is over, and the container must
+// not have restarted. It runs a container of the suite's image apart from
+// the suite's own, so the faults can't affect other tests.
+func (suite *DockerTestSuite) TestResilience() {
+	t := suite.T()
+	if os.Getenv("OSYRAA_CHAOS") != "1" {
+		t.Skip("set OSYRAA_CHAOS=1 to inject faults into the container under load")
+	}
+	_, _, err := suite.client.ImageInspectWithRaw(suite.ctx, suite.imageTag)
+	require.NoError(t, err, "TestResilience needs the suite's image")
+
+	id, baseURL := suite.startScratch(&container.HostConfig{
+		ReadonlyRootfs: true,
+		Tmpfs:          chaosTmpfs,
+	})
+
+	exec := func(ctx context.Context, cmd []string) (string, error) {
+		return suite.outputIn(ctx, id, cmd)
+	}
+	before, err := chaos.NginxWorkers(suite.ctx, exec)
+	require.NoError(t, err, "Failed to list nginx workers")
+
+	// Only requests in flight on a killed worker may fail
+	scenarios := []chaos.Scenario{chaos.KillWorkers(exec, 0.9)}
+	for _, dir := range []string{"/var/cache/nginx", "/tmp"} {
+		// Static files are served without writing to disk
+		scenarios = append(scenarios, chaos.FillDisk(exec, dir, 1))
+	}
+	load := chaos.Load{URL: baseURL + "/", Client: siteClient}
+	for _, s := range scenarios {
+		report, err := chaos.Run(suite.ctx, load, s, chaos.DefaultTiming())
+		require.NoError(t, err)
+		t.Logf("%s: before %v; during %v; after %v", report.Scenario, report.Before, report.During, report.After)
+		for _, p := range report.Problems(s) {
+			t.Error(p)
+		}
+	}
+
+	after, err := chaos.NginxWorkers(suite.ctx, exec)
+	require.NoError(t, err, "Failed to list nginx workers")
+	assert.Len(t, after, len(before), "nginx should replace every killed worker")
+	for _, pid := range before {
+		assert.NotContains(t, after, pid, "Worker %d should have been killed", pid)
+	}
+	inspect, err := suite.client.ContainerInspect(suite.ctx, id)
+	require.NoError(t, err, "Failed to inspect the chaos container")
+	assert.True(t, inspect.State.Running, "The chaos container should still be running")
+	assert.Zero(t, inspect.RestartCount, "The chaos container should not have restarted")
+}
@@ -0,0 +1,108 @@
+// Command apicheck gates changes to the exported check libraries. It diffs
+// the API of ./pkg/... against the last release tag, fails on incompatible
+// changes unless they are explicitly allowed, and prints (or creates) the
+// next semantic version tag.
+//
+//	go run ./cmd/apicheck                  # compatibility gate for CI
+//	go run ./cmd/apicheck -tag             # tag the next release
+//	go run ./cmd/apicheck -allow-breaking  # accept a breaking change
+//	go run ./cmd/apicheck -base main       # compare with a branch; no version, so no -tag
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spider-2y-banana/osyraa/tests/internal/apicompat"
+)
+
+func main() {
+	base := flag.String("base", "", "git ref to compare against (default: latest release tag)")
+	allowBreaking := flag.Bool("allow-breaking", false, "do not fail on incompatible changes")
+	tag := flag.Bool("tag", false, "create an annotated tag for the next version")
+	flag.Parse()
+
+	if err := run(*base, *allowBreaking, *tag); err != nil {
+		fmt.Fprintln(os.Stderr, "apicheck:", err)
+		os.Exit(1)
+	}
+}
+
+func run(base string, allowBreaking, tag bool) error {
+	if base == "" {
+		latest, err := apicompat.LatestTag(".")
+		if err != nil {
+			return err
+		}
+		if latest == "" {
+			next := apicompat.Version{Minor: 1}
+			fmt.Printf("No previous release; first version will be %s\n", next)
+			if tag {
+				return createTag(next)
+			}
+			return nil
+		}
+		base = latest
+	}
+	// A branch or commit base is compared against, but has no version to
+	// count the next one from
+	current, versionErr := apicompat.ParseVersion(base)
+	if versionErr != nil && tag {
+		return fmt.Errorf("-tag needs -base to be a release tag to count the next version from, and %s isn't one: %w", base, versionErr)
+	}
+
+	baseDir, cleanup, err := apicompat.Checkout(".", base)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	oldMod, err := apicompat.Load(baseDir)
+	if err != nil {
+		return err
+	}
+	newMod, err := apicompat.Load(".")
+	if err != nil {
+		return err
+	}
+
+	report := apicompat.Compare(oldMod, newMod)
+	for _, msg := range report.Incompatible {
+		fmt.Println("incompatible:", msg)
+	}
+	for _, msg := range report.Compatible {
+		fmt.Println("compatible:  ", msg)
+	}
+
+	var next apicompat.Version
+	if versionErr != nil {
+		fmt.Printf("API change since %s: %s\n", base, report.Bump())
+	} else {
+		next, err = current.Next(report.Bump())
+		if err != nil {
+			fmt.Printf("API change since %s: %s\n", base, report.Bump())
+			return err
+		}
+		fmt.Printf("API change since %s: %s (next version %s)\n", base, report.Bump(), next)
+	}
+
+	if len(report.Incompatible) > 0 && !allowBreaking {
+		return fmt.Errorf("%d incompatible API change(s); rerun with -allow-breaking to accept", len(report.Incompatible))
+	}
+	if tag {
+		return createTag(next)
+	}
+	return nil
+}
+
+func createTag(v apicompat.Version) error {
+	cmd := exec.Command("git", "tag", "-a", v.Tag(), "-m", "osyraa test libraries "+v.String())
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("create tag %s: %w", v.Tag(), err)
+	}
+	fmt.Println("Tagged", v.Tag())
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/engine"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/imagebuild"
+)
+
+// runBuild builds the site image through the container engine's API, as
+// the suite does, but under a tag of your choosing and without the run
+// labels that would make `osyraa clean` remove it:
+//
+//	osyraa build                       # ../Containerfile as resume:latest
+//	osyraa build -t resume:ci -json    # print the image ID as JSON
+func runBuild(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	dir := fs.String("context", "..", "build context directory")
+	dockerfile := fs.String("f", "Containerfile", "Containerfile, relative to -context")
+	tag := fs.String("t", "resume:latest", "tag for the built image")
+	quiet := fs.Bool("q", false, "don't stream the build output")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	timeout := fs.Duration("timeout", 15*time.Minute, "limit for the build")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	rt, cli, err := engine.Detect(ctx, engine.HostEnv())
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+	fmt.Fprintln(os.Stderr, "Container engine:", rt)
+
+	buildContext, err := imagebuild.Dir(*dir, *dockerfile)
+	if err != nil {
+		return err
+	}
+	defer buildContext.Close()
+	var log io.Writer = os.Stderr
+	if *quiet {
+		log = io.Discard
+	}
+	start := time.Now()
+	result, err := imagebuild.Build(ctx, cli, buildContext, imagebuild.Options{
+		Tags:       []string{*tag},
+		Dockerfile: filepath.ToSlash(*dockerfile),
+		Log:        log,
+	})
+	if *asJSON {
+		out := struct {
+			Tag      string        `json:"tag"`
+			ImageID  string        `json:"image_id,omitempty"`
+			Passed   bool          `json:"passed"`
+			Error    string        `json:"error,omitempty"`
+			Duration time.Duration `json:"duration_ns"`
+		}{Tag: *tag, ImageID: result.ID, Passed: err == nil, Duration: time.Since(start)}
+		if err != nil {
+			out.Error = err.Error()
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(out); encErr != nil {
+			return encErr
+		}
+	} else if err == nil {
+		fmt.Printf("%s %s\n", *tag, result.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("building %s: %w", *tag, err)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/audit"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/browser"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/linkcheck"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/monitor"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/perfbudget"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/polite"
+)
+
+// reportFlags are the output flags shared by test and audit
+type reportFlags struct {
+	asJSON *bool
+	out    *string
+}
+
+func addReportFlags(fs *flag.FlagSet) reportFlags {
+	return reportFlags{
+		asJSON: fs.Bool("json", false, "write the report as JSON"),
+		out:    fs.String("o", "", "write the report to this file instead of stdout"),
+	}
+}
+
+// write prints the report and returns audit.ErrFailed if a check failed,
+// so the command exits 1
+func (f reportFlags) write(report *audit.Report) error {
+	var w io.Writer = os.Stdout
+	if *f.out != "" {
+		file, err := os.Create(*f.out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if *f.asJSON {
+		if err := report.WriteJSON(w); err != nil {
+			return err
+		}
+	} else {
+		report.WriteText(w)
+	}
+	if !report.Passed {
+		return fmt.Errorf("%w: %d of %d", audit.ErrFailed, report.Failed(), len(report.Checks))
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// runTest checks a built site directory without serving it:
+//
+//	osyraa test                               # every build check against ../public
+//	osyraa test -checks links,secrets -json   # two checks, as JSON
+func runTest(args []string) error {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	public := fs.String("public", filepath.Join("..", "public"), "built site to check")
+	checks := fs.String("checks", "", "comma-separated checks to run; default all of "+buildCheckNames())
+	allow := fs.String("secrets-allow", "", "comma-separated regexps of matches the secrets check accepts as public")
+	severity := fs.String("js-min-severity", "medium", "lowest JavaScript advisory severity that fails")
+	budgets := fs.String("budgets", filepath.Join("testdata", "budgets.yaml"), "page-weight and request budgets; empty skips the page-weight check")
+	include := fs.String("include", "", "comma-separated path globs limiting the pages crawled, e.g. /posts/**")
+	exclude := fs.String("exclude", "", "comma-separated path globs left out of the crawl, e.g. /tags/")
+	maxDepth := fs.Int("max-depth", 0, "follow page links at most this deep; 0 is no limit")
+	timeout := fs.Duration("timeout", 10*time.Minute, "limit for all checks")
+	output := addReportFlags(fs)
+	fs.Parse(args)
+
+	selected, err := audit.SelectBuildChecks(splitList(*checks))
+	if err != nil {
+		return err
+	}
+	opts := audit.BuildOptions{Scope: crawl.Scope{MaxDepth: *maxDepth}, JSMinSeverity: *severity}
+	if opts.Scope.Include, err = crawl.ParsePatterns(*include); err != nil {
+		return fmt.Errorf("-include: %w", err)
+	}
+	if opts.Scope.Exclude, err = crawl.ParsePatterns(*exclude); err != nil {
+		return fmt.Errorf("-exclude: %w", err)
+	}
+	for _, expr := range splitList(*allow) {
+		re, err := regexp.Compile(strings.TrimSpace(expr))
+		if err != nil {
+			return fmt.Errorf("-secrets-allow: %w", err)
+		}
+		opts.SecretsAllow = append(opts.SecretsAllow, re)
+	}
+	if *budgets != "" {
+		if opts.Budgets, err = perfbudget.Load(*budgets); err != nil {
+			return fmt.Errorf("-budgets: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report, err := audit.RunBuild(ctx, &audit.Build{Dir: *public, Options: opts}, selected)
+	if err != nil {
+		return err
+	}
+	return output.write(report)
+}
+
+func buildCheckNames() string {
+	names := make([]string, len(audit.BuildChecks))
+	for i, c := range audit.BuildChecks {
+		names[i] = c.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// runAudit runs the HTTP battery against a served copy of the site, or
+// with `links` checks every link in the build:
+//
+//	osyraa audit https://resume.example.com
+//	osyraa audit -checks availability,security-headers -json http://127.0.0.1:8080
+//	osyraa audit links -external -allow 'linkedin\.com'
+//	osyraa audit a11y -browser
+func runAudit(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "links":
+			return runAuditLinks(args[1:])
+		case "a11y":
+			return runAuditA11y(args[1:])
+		}
+	}
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	checks := fs.String("checks", "", "comma-separated checks to run; default the suite's battery")
+	public := fs.String("public", filepath.Join("..", "public"), "built site whose index.html the content-hash check expects")
+	maxResponse := fs.Duration("max-response-time", time.Second, "slowest acceptable home page response")
+	timeout := fs.Duration("timeout", 5*time.Minute, "limit for all checks")
+	output := addReportFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: osyraa audit [flags] <url>\n       osyraa audit links [flags]\n       osyraa audit a11y [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return usageError{errors.New("expected one URL to audit")}
+	}
+	selected := battery.Default()
+	if names := splitList(*checks); names != nil {
+		var err error
+		if selected, err = monitor.SelectChecks(names); err != nil {
+			return err
+		}
+	}
+	target := battery.NewTarget(fs.Arg(0))
+	target.Expect.MaxResponseTime = *maxResponse
+	if hasCheck(selected, "content-hash") {
+		if err := expectContent(target, *public); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	return output.write(audit.Site(ctx, target, selected))
+}
+
+// runAuditLinks checks the links on every page of the build: within the
+// site against its files, and with -external the rest over the network
+func runAuditLinks(args []string) error {
+	fs := flag.NewFlagSet("audit links", flag.ExitOnError)
+	public := fs.String("public", filepath.Join("..", "public"), "built site whose links to check")
+	config := fs.String("config", filepath.Join("..", "config.toml"), "Hugo config naming the baseURL, whose links are checked in -public")
+	external := fs.Bool("external", false, "also fetch external links, which must answer below 400")
+	concurrency := fs.Int("concurrency", 8, "external links fetched at once")
+	retries := fs.Int("retries", 2, "retries for external links that fail with 429, 5xx or a network error")
+	allow := fs.String("allow", "", "comma-separated regexps of URLs never checked")
+	timeout := fs.Duration("timeout", 10*time.Minute, "limit for the whole check")
+	output := addReportFlags(fs)
+	fs.Parse(args)
+
+	o := linkcheck.SiteOptions{Concurrency: *concurrency, Retries: *retries}
+	var err error
+	if o.BaseURL, err = configBaseURL(*config); err != nil {
+		return err
+	}
+	for _, expr := range splitList(*allow) {
+		re, err := regexp.Compile(strings.TrimSpace(expr))
+		if err != nil {
+			return fmt.Errorf("-allow: %w", err)
+		}
+		o.Allow = append(o.Allow, re)
+	}
+	if *external {
+		o.Client = &http.Client{Timeout: 20 * time.Second, Transport: polite.New(nil)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report, err := audit.Links(ctx, *public, o)
+	if err != nil {
+		return err
+	}
+	return output.write(report)
+}
+
+// runAuditA11y checks every page of the build against the WCAG 2.1 AA
+// baseline; -browser renders the pages in headless Chrome and adds the
+// contrast check
+func runAuditA11y(args []string) error {
+	fs := flag.NewFlagSet("audit a11y", flag.ExitOnError)
+	public := fs.String("public", filepath.Join("..", "public"), "built site whose pages to check")
+	inBrowser := fs.Bool("browser", false, "render pages in headless Chrome and check colour contrast")
+	chrome := fs.String("chrome", os.Getenv("OSYRAA_CHROME"), "Chrome binary for -browser; default the first found")
+	timeout := fs.Duration("timeout", 10*time.Minute, "limit for the whole check")
+	output := addReportFlags(fs)
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	var b *browser.Browser
+	if *inBrowser {
+		var err error
+		if b, err = browser.New(ctx, browser.Options{ExecPath: *chrome, RemoteURL: os.Getenv("OSYRAA_CHROME_URL")}); err != nil {
+			return err
+		}
+		defer b.Close()
+	}
+	report, err := audit.Accessibility(ctx, *public, b)
+	if err != nil {
+		return err
+	}
+	return output.write(report)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/cleanup"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/engine"
+)
+
+// runClean removes the containers, images, networks and temporary
+// directories that earlier test runs left behind:
+//
+//	osyraa clean -n            # list what would be removed
+//	osyraa clean               # remove orphans of exited or day-old runs
+//	osyraa clean -run ci-1234  # remove one run's resources
+func runClean(args []string) error {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	dryRun := fs.Bool("n", false, "list what would be removed without removing it")
+	all := fs.Bool("all", false, "remove resources of every run, including ones still running")
+	run := fs.String("run", "", "only consider this run ID")
+	olderThan := fs.Duration("older-than", 24*time.Hour, "also remove resources older than this, whatever their process; 0 disables")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	var docker cleanup.Docker
+	rt, cli, err := engine.Detect(ctx, engine.HostEnv())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Skipping containers, images and networks: %v\n", err)
+	} else {
+		defer cli.Close()
+		fmt.Println("Container engine:", rt)
+		docker = cli
+	}
+
+	// A run ID names its resources exactly, so -run needs no other reason
+	opts := cleanup.Options{OlderThan: *olderThan, All: *all || *run != "", Run: *run}
+	found, err := cleanup.Find(ctx, docker, os.TempDir(), opts)
+	if err != nil {
+		return err
+	}
+	cleanup.RemoveOrder(found)
+
+	var failed, removed, kept int
+	for _, r := range found {
+		switch {
+		case !r.Orphaned:
+			fmt.Printf("KEEP    %s: %s\n", r, r.Reason)
+			kept++
+		case *dryRun:
+			fmt.Printf("WOULD   %s: %s\n", r, r.Reason)
+		default:
+			if err := cleanup.Remove(ctx, docker, r); err != nil {
+				fmt.Printf("FAILED  %s: %v\n", r, err)
+				failed++
+				continue
+			}
+			fmt.Printf("REMOVED %s: %s\n", r, r.Reason)
+			removed++
+		}
+	}
+	if *dryRun {
+		fmt.Printf("%d resources would be removed, %d kept\n", len(found)-kept, kept)
+		return nil
+	}
+	fmt.Printf("Removed %d resources, kept %d\n", removed, kept)
+	if failed > 0 {
+		return fmt.Errorf("%d resources could not be removed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/polite"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/sitediff"
+)
+
+// errDifferent makes -exit-code fail when the sites differ
+var errDifferent = errors.New("the local build differs from production")
+
+var baseURLRe = regexp.MustCompile(`(?m)^baseURL\s*=\s*"([^"]+)"`)
+
+// configBaseURL reads baseURL from the Hugo config
+func configBaseURL(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	m := baseURLRe.FindSubmatch(data)
+	if m == nil {
+		return "", fmt.Errorf("no baseURL in %s", path)
+	}
+	return string(m[1]), nil
+}
+
+// runDiff crawls production and the local build and prints what deploying
+// the build would change:
+//
+//	osyraa diff                                # ../public against the config's baseURL
+//	osyraa diff -local http://127.0.0.1:8080   # the running container, headers included
+//	osyraa diff -json -o diff.json -exit-code
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	prod := fs.String("prod", os.Getenv("OSYRAA_PROD_URL"), "production URL; default the baseURL in -config")
+	config := fs.String("config", filepath.Join("..", "config.toml"), "Hugo config naming the production baseURL")
+	public := fs.String("public", filepath.Join("..", "public"), "local build to compare")
+	local := fs.String("local", "", "served local build to compare instead of -public, which adds response headers to the diff")
+	asJSON := fs.Bool("json", false, "write the diff as JSON")
+	out := fs.String("o", "", "write the diff to this file instead of stdout")
+	ignore := fs.String("ignore-header", "", "comma-separated headers to leave out besides the volatile ones")
+	exitCode := fs.Bool("exit-code", false, "exit 1 when the sites differ")
+	timeout := fs.Duration("timeout", 5*time.Minute, "limit for both crawls")
+	include := fs.String("include", "", "comma-separated path globs limiting the pages compared, e.g. /posts/**")
+	exclude := fs.String("exclude", "", "comma-separated path globs left out of both crawls, e.g. /tags/")
+	maxDepth := fs.Int("max-depth", 0, "follow page links at most this deep; 0 is no limit")
+	fs.Parse(args)
+
+	// Both sides are crawled within the same scope so the diff doesn't
+	// report pages left out as removed
+	scope := crawl.Scope{MaxDepth: *maxDepth}
+	var err error
+	if scope.Include, err = crawl.ParsePatterns(*include); err != nil {
+		return fmt.Errorf("-include: %w", err)
+	}
+	if scope.Exclude, err = crawl.ParsePatterns(*exclude); err != nil {
+		return fmt.Errorf("-exclude: %w", err)
+	}
+	newCrawler := func(f crawl.Fetcher) *crawl.Crawler {
+		c := crawl.New(f)
+		c.Scope = scope
+		return c
+	}
+
+	if *prod == "" {
+		if *prod, err = configBaseURL(*config); err != nil {
+			return fmt.Errorf("set -prod or OSYRAA_PROD_URL: %w", err)
+		}
+	}
+	prodURL, err := url.Parse(*prod)
+	if err != nil {
+		return fmt.Errorf("-prod: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	client := &http.Client{Timeout: 30 * time.Second, Transport: polite.New(nil)}
+
+	fmt.Fprintln(os.Stderr, "Crawling", prodURL)
+	prodSite, err := newCrawler(crawl.NewHTTPFetcher(client)).Run(ctx, prodURL)
+	if err != nil {
+		return fmt.Errorf("crawl production: %w", err)
+	}
+
+	// A build read from disk is crawled at the production URL, so both
+	// crawls see the same paths; it has no server, so no headers
+	var localSite *crawl.Result
+	opts := sitediff.Options{BeforeName: "production", AfterName: "local"}
+	if *ignore != "" {
+		opts.IgnoreHeaders = strings.Split(*ignore, ",")
+	}
+	if *local != "" {
+		localURL, err := url.Parse(*local)
+		if err != nil {
+			return fmt.Errorf("-local: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Crawling", localURL)
+		localSite, err = newCrawler(crawl.NewHTTPFetcher(client)).Run(ctx, localURL)
+		if err != nil {
+			return fmt.Errorf("crawl local site: %w", err)
+		}
+		opts.Headers = true
+	} else {
+		if _, err := os.Stat(filepath.Join(*public, "index.html")); err != nil {
+			return fmt.Errorf("build the site into %s first, or set -local: %w", *public, err)
+		}
+		fmt.Fprintln(os.Stderr, "Reading", *public)
+		localSite, err = newCrawler(crawl.NewDirFetcher(*public)).Run(ctx, prodURL)
+		if err != nil {
+			return fmt.Errorf("read local build: %w", err)
+		}
+	}
+
+	d := sitediff.Compare(sitediff.FromCrawl(prodSite), sitediff.FromCrawl(localSite), opts)
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *asJSON {
+		err = d.WriteJSON(w)
+	} else {
+		d.WriteText(w)
+	}
+	if err != nil {
+		return err
+	}
+	if *exitCode && !d.Empty() {
+		return errDifferent
+	}
+	return nil
+}
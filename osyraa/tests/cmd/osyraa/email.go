@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/notify"
+)
+
+// emailFlags configure email digests. The SMTP credentials are read only
+// from OSYRAA_SMTP_USERNAME and OSYRAA_SMTP_PASSWORD, so they stay out of
+// process listings and shell history.
+type emailFlags struct {
+	to, from, smtp *string
+}
+
+func addEmailFlags(fs *flag.FlagSet) *emailFlags {
+	return &emailFlags{
+		to:   fs.String("email", os.Getenv("OSYRAA_EMAIL_TO"), "comma-separated addresses receiving email digests; empty sends none"),
+		from: fs.String("email-from", os.Getenv("OSYRAA_EMAIL_FROM"), "sender of email digests"),
+		smtp: fs.String("smtp", os.Getenv("OSYRAA_SMTP_ADDR"), "SMTP server host:port; port 465 uses implicit TLS, others STARTTLS"),
+	}
+}
+
+// mailer is nil when no recipients are configured
+func (e *emailFlags) mailer() (*notify.Mailer, error) {
+	if *e.to == "" {
+		return nil, nil
+	}
+	var to []string
+	for _, a := range strings.Split(*e.to, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			to = append(to, a)
+		}
+	}
+	m, err := notify.NewMailer(*e.smtp, *e.from, to)
+	if err != nil {
+		return nil, err
+	}
+	m.Username, m.Password = os.Getenv("OSYRAA_SMTP_USERNAME"), os.Getenv("OSYRAA_SMTP_PASSWORD")
+	return m, nil
+}
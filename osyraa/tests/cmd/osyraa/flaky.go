@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/flaky"
+)
+
+// runFlaky reads `go test -json` output, records it in the history, and
+// fails only for failures outside the quarantine:
+//
+//	go test -json ./... | osyraa flaky
+func runFlaky(args []string) error {
+	fs := flag.NewFlagSet("flaky", flag.ExitOnError)
+	input := fs.String("input", "-", "go test -json output to read; - for stdin")
+	historyFile := fs.String("history", ".osyraa-history.jsonl", "file keeping every check's outcomes across runs")
+	quarantineFile := fs.String("quarantine", "quarantine.txt", "checks whose failures do not fail the run")
+	revision := fs.String("revision", "", "code revision tested (default: git HEAD, or none for a dirty tree)")
+	update := fs.Bool("update", false, "add newly flaky checks to the quarantine and release stable ones")
+	opts := flaky.DefaultOptions()
+	fs.IntVar(&opts.Window, "window", opts.Window, "latest outcomes per check considered")
+	fs.IntVar(&opts.Stable, "stable", opts.Stable, "passes in a row that release a check from quarantine")
+	email := addEmailFlags(fs)
+	fs.Parse(args)
+
+	mailer, err := email.mailer()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if *revision == "" {
+		*revision = gitRevision()
+	}
+	run, err := flaky.ReadGoTest(r, time.Now().UTC(), *revision)
+	if err != nil {
+		return err
+	}
+	if err := flaky.AppendHistory(*historyFile, run); err != nil {
+		return err
+	}
+	history, err := flaky.LoadHistory(*historyFile)
+	if err != nil {
+		return err
+	}
+	quarantine, err := flaky.LoadQuarantine(*quarantineFile)
+	if err != nil {
+		return err
+	}
+
+	findings := flaky.Detect(history, opts)
+	stable := flaky.Stable(history, quarantine, opts)
+	var added []string
+	for _, f := range findings {
+		if !slices.Contains(quarantine, f.Check) {
+			fmt.Printf("FLAKY       %s\n", f)
+			added = append(added, f.Check)
+		}
+	}
+	for _, check := range stable {
+		fmt.Printf("STABLE      %s passed its last %d runs and can leave quarantine\n", check, opts.Stable)
+	}
+	gating, excused := flaky.Gate(run, quarantine)
+	for _, check := range excused {
+		fmt.Printf("QUARANTINED %s failed (not gating)\n", check)
+	}
+	for _, check := range gating {
+		fmt.Printf("FAIL        %s\n", check)
+	}
+
+	if *update && (len(added) > 0 || len(stable) > 0) {
+		kept := slices.DeleteFunc(slices.Clone(quarantine), func(c string) bool { return slices.Contains(stable, c) })
+		if err := flaky.WriteQuarantine(*quarantineFile, append(kept, added...)); err != nil {
+			return err
+		}
+		fmt.Printf("Updated %s: %d added, %d released\n", *quarantineFile, len(added), len(stable))
+	}
+	if mailer != nil {
+		broke, fixed := flaky.Trend(history, run)
+		d := flakyDigest{Revision: *revision, Gating: gating, Excused: excused, Broke: broke, Fixed: fixed, Flaky: added, Stable: stable}
+		if !d.empty() {
+			subject, body := d.message()
+			if err := mailer.Send(context.Background(), subject, body); err != nil {
+				fmt.Fprintf(os.Stderr, "Sending the email digest failed: %v\n", err)
+			}
+		}
+	}
+	if len(gating) > 0 {
+		return fmt.Errorf("%d checks failed", len(gating))
+	}
+	return nil
+}
+
+// flakyDigest is the email summary of a run: its failures and how the
+// checks' outcomes moved since the previous run
+type flakyDigest struct {
+	Revision                                     string
+	Gating, Excused, Broke, Fixed, Flaky, Stable []string
+}
+
+func (d flakyDigest) empty() bool {
+	return len(d.Gating)+len(d.Excused)+len(d.Broke)+len(d.Fixed)+len(d.Flaky)+len(d.Stable) == 0
+}
+
+func (d flakyDigest) message() (subject, body string) {
+	var b strings.Builder
+	revision := d.Revision
+	if revision == "" {
+		revision = "an uncommitted tree"
+	}
+	fmt.Fprintf(&b, "Test run of %s at %s\n", revision, time.Now().UTC().Format(time.DateTime+" MST"))
+	for _, section := range []struct {
+		title  string
+		checks []string
+	}{
+		{"Failed", d.Gating},
+		{"Failed in quarantine (not gating)", d.Excused},
+		{"Newly failing (passed last run)", d.Broke},
+		{"Fixed (failed last run)", d.Fixed},
+		{"Newly flaky", d.Flaky},
+		{"Stable again, can leave quarantine", d.Stable},
+	} {
+		if len(section.checks) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n", section.title)
+		for _, check := range section.checks {
+			fmt.Fprintf(&b, "  %s\n", check)
+		}
+	}
+	subject = fmt.Sprintf("[osyraa] test run: %d failed, %d newly failing, %d fixed", len(d.Gating), len(d.Broke), len(d.Fixed))
+	return subject, b.String()
+}
+
+// gitRevision is HEAD's commit, or "" outside a clean git checkout, since
+// uncommitted changes make runs of the same commit incomparable
+func gitRevision() string {
+	if out, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output(); err != nil || len(out) > 0 {
+		return ""
+	}
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/contentlint"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/hook"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/schedule"
+)
+
+// siteOutputs are the files the home page renders besides index.html, per
+// [outputs] in config.toml, which content may link to
+var siteOutputs = []string{"/sitemap.xml", "/.well-known/security.txt", "/contact.vcf", "/resume.json"}
+
+// runHook installs, removes or runs the git hooks:
+//
+//	osyraa hook install                   # pre-commit and pre-push
+//	osyraa hook uninstall
+//	osyraa hook run -stage pre-commit     # what the hook runs
+//	osyraa hook run -all                  # every content file
+func runHook(args []string) error {
+	if len(args) == 0 {
+		return usageError{errors.New("usage: osyraa hook install|uninstall|run [flags]")}
+	}
+	switch args[0] {
+	case "install":
+		return runHookInstall(args[1:])
+	case "uninstall":
+		return runHookUninstall(args[1:])
+	case "run":
+		return runHookRun(args[1:])
+	}
+	return usageError{fmt.Errorf("unknown hook command %q; use install, uninstall or run", args[0])}
+}
+
+func runHookInstall(args []string) error {
+	fs := flag.NewFlagSet("hook install", flag.ExitOnError)
+	stages := fs.String("stages", strings.Join(hook.Stages, ","), "hooks to install")
+	force := fs.Bool("force", false, "replace hooks other tools installed, such as pre-commit's")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	repo := hook.Repo{Dir: "."}
+	module, err := moduleDir(ctx, repo)
+	if err != nil {
+		return err
+	}
+	for _, stage := range strings.Split(*stages, ",") {
+		path, err := repo.Install(ctx, strings.TrimSpace(stage), module, *force)
+		if errors.Is(err, hook.ErrForeignHook) {
+			return fmt.Errorf("%w; chain it from that hook, or pass -force to replace it", err)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println("Installed", path)
+	}
+	return nil
+}
+
+func runHookUninstall(args []string) error {
+	fs := flag.NewFlagSet("hook uninstall", flag.ExitOnError)
+	fs.Parse(args)
+
+	ctx := context.Background()
+	repo := hook.Repo{Dir: "."}
+	for _, stage := range hook.Stages {
+		removed, err := repo.Uninstall(ctx, stage)
+		if err != nil {
+			return err
+		}
+		if removed {
+			fmt.Println("Removed", stage)
+		}
+	}
+	return nil
+}
+
+// moduleDir is the tests module's directory relative to the top of the
+// work tree, with forward slashes
+func moduleDir(ctx context.Context, repo hook.Repo) (string, error) {
+	root, err := repo.Root(ctx)
+	if err != nil {
+		return "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	// Resolve links, since git reports the real path
+	if wd, err = filepath.EvalSymlinks(wd); err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, wd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// runHookRun checks the content files a commit or push changes: their
+// front matter, their Markdown and their internal links. Deleting a page
+// can break links elsewhere, so then every page's links are checked.
+func runHookRun(args []string) error {
+	fs := flag.NewFlagSet("hook run", flag.ExitOnError)
+	stage := fs.String("stage", "", "pre-commit checks staged files, pre-push the pushed commits read from stdin; empty checks uncommitted changes")
+	all := fs.Bool("all", false, "check every content file, not only changed ones")
+	site := fs.String("site", "..", "Hugo site root")
+	base := fs.String("base", "origin/HEAD", "what a newly pushed branch is compared with")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	repo := hook.Repo{Dir: "."}
+	root, err := repo.Root(ctx)
+	if err != nil {
+		return err
+	}
+	siteDir, err := filepath.Abs(*site)
+	if err != nil {
+		return err
+	}
+	if siteDir, err = filepath.EvalSymlinks(siteDir); err != nil {
+		return err
+	}
+	siteRel, err := filepath.Rel(root, siteDir)
+	if err != nil {
+		return err
+	}
+	siteRel = filepath.ToSlash(siteRel)
+	contentDir := filepath.Join(siteDir, "content")
+
+	var changes []hook.Change
+	switch *stage {
+	case "pre-commit":
+		changes, err = repo.Staged(ctx)
+	case "pre-push":
+		var refs []hook.PushRef
+		if refs, err = hook.ParsePush(os.Stdin); err == nil {
+			changes, err = repo.Pushed(ctx, refs, *base)
+		}
+		if errors.Is(err, hook.ErrUnknownBase) {
+			fmt.Fprintf(os.Stderr, "osyraa hook: %v; checking every content file\n", err)
+			*all, err = true, nil
+		}
+	case "":
+		changes, err = repo.Changed(ctx, "HEAD")
+	default:
+		return fmt.Errorf("unknown stage %q", *stage)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Content files to lint, relative to the content directory, and
+	// whether a page went away
+	var files []string
+	deleted := false
+	for _, c := range changes {
+		rel, ok := strings.CutPrefix(c.Path, siteRel+"/")
+		if siteRel == "." {
+			rel, ok = c.Path, true
+		}
+		if !ok || !contentlint.ContentFile(rel) {
+			continue
+		}
+		if c.Deleted {
+			deleted = true
+			continue
+		}
+		files = append(files, strings.TrimPrefix(rel, "content/"))
+	}
+
+	pages, err := schedule.Load(contentDir)
+	if err != nil {
+		return fmt.Errorf("read content: %w", err)
+	}
+	if *all {
+		files = files[:0]
+		for _, p := range pages {
+			files = append(files, p.File)
+		}
+	}
+	if len(files) == 0 && !deleted {
+		return nil
+	}
+	idx, err := siteIndex(siteDir, pages)
+	if err != nil {
+		return err
+	}
+
+	// The pre-commit hook checks what will be committed, which may differ
+	// from the work tree
+	read := func(file string) ([]byte, error) {
+		if *stage == "pre-commit" && !*all {
+			return repo.Show(ctx, "", path.Join(siteRel, "content", file))
+		}
+		return os.ReadFile(filepath.Join(contentDir, filepath.FromSlash(file)))
+	}
+	var problems []contentlint.Problem
+	linted := map[string]bool{}
+	for _, file := range files {
+		data, err := read(file)
+		if err != nil {
+			return err
+		}
+		linted[file] = true
+		problems = append(problems, contentlint.FrontMatter(file, data)...)
+		problems = append(problems, contentlint.Markdown(file, data)...)
+		problems = append(problems, contentlint.Links(file, data, idx)...)
+	}
+	if deleted {
+		for _, p := range pages {
+			if linted[p.File] {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(contentDir, filepath.FromSlash(p.File)))
+			if err != nil {
+				return err
+			}
+			problems = append(problems, contentlint.Links(p.File, data, idx)...)
+		}
+	}
+
+	for _, p := range problems {
+		// Paths from the top of the work tree, as git and editors show them
+		p.File = path.Join(siteRel, "content", p.File)
+		fmt.Fprintln(os.Stderr, p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems in %d content files; fix them, or skip the hook once with --no-verify", len(problems), len(files))
+	}
+	fmt.Fprintf(os.Stderr, "osyraa hook: %d content files OK\n", len(files))
+	return nil
+}
+
+// siteIndex lists what the site serves: its pages, static files and the
+// home page's other outputs
+func siteIndex(siteDir string, pages []schedule.Page) (*contentlint.Index, error) {
+	static := os.DirFS(filepath.Join(siteDir, "static"))
+	if _, err := os.Stat(filepath.Join(siteDir, "static")); err != nil {
+		static = nil
+	}
+	idx, err := contentlint.NewIndex(pages, static)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range siteOutputs {
+		idx.Add(p)
+	}
+	return idx, nil
+}
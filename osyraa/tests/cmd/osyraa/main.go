@@ -0,0 +1,84 @@
+// Command osyraa runs the site's verification harness outside `go test`.
+//
+//	osyraa build                                      # the site image through the engine API
+//	osyraa test -json                                 # check ../public without serving it
+//	osyraa audit https://resume.example.com           # the HTTP battery against a served site
+//	osyraa audit a11y -browser                        # WCAG 2.1 AA checks, contrast in headless Chrome
+//	osyraa monitor -url https://resume.example.com    # synthetic monitoring
+//	go test -json ./... | osyraa flaky                # gate on non-quarantined failures
+//	go test -json . | osyraa results -junit junit.xml # JUnit XML and a JSON summary for CI
+//	osyraa clean -n                                   # list leftovers of crashed runs
+//	osyraa diff                                       # what deploying ../public would change
+//	osyraa hook install                               # check changed content before commit and push
+//	osyraa package                                    # ../public as a checksummed public.tar.gz
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/httpclient"
+)
+
+// command is a subcommand taking its own flags
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"build":   {"build the site image through the container engine", runBuild},
+	"test":    {"check the built site directory: links, secrets, JS and more", runTest},
+	"audit":   {"run the HTTP battery against a served site, or check links or accessibility", runAudit},
+	"monitor": {"periodically check production and expose metrics", runMonitor},
+	"flaky":   {"record test outcomes, flag flaky checks and gate on the rest", runFlaky},
+	"results": {"write go test -json output as JUnit XML and a JSON summary", runResults},
+	"clean":   {"remove containers, images and temp dirs left by earlier runs", runClean},
+	"diff":    {"compare the local build with production, page by page", runDiff},
+	"hook":    {"install or run the git hooks that check changed content", runHook},
+	"package": {"pack the local build into a checksummed tarball and verify it", runPackage},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "osyraa: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	// Every command's HTTP clients share one pooled transport
+	options, err := httpclient.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "osyraa: %v\n", err)
+	}
+	httpclient.Install(options)
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "osyraa %s: %v\n", os.Args[1], err)
+		if errors.As(err, new(usageError)) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// usageError is a command line a command cannot run, which exits 2 like
+// a flag the flag package rejects
+type usageError struct{ error }
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: osyraa <command> [flags]\n\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/config"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/monitor"
+)
+
+func runMonitor(args []string) error {
+	fs := flag.NewFlagSet("monitor", flag.ExitOnError)
+	url := fs.String("url", os.Getenv("OSYRAA_MONITOR_URL"), "production URL to check")
+	interval := fs.Duration("interval", time.Minute, "time between rounds of checks")
+	checks := fs.String("checks", "availability,content-hash,cert-expiry,security-headers", "comma-separated checks to run")
+	listen := fs.String("listen", ":9090", "address serving /metrics")
+	webhook := fs.String("webhook", os.Getenv("OSYRAA_WEBHOOK_URL"), "URL receiving JSON alerts")
+	public := fs.String("public", filepath.Join("..", "public"), "built site whose index.html the content-hash check expects")
+	objective := fs.Float64("slo", 0.999, "availability objective; 0 disables SLO tracking")
+	sloWindow := fs.Duration("slo-window", 30*24*time.Hour, "rolling window the error budget covers")
+	sloState := fs.String("slo-state", "", "file persisting probe history across restarts")
+	email := addEmailFlags(fs)
+	emailEvery := fs.Duration("email-every", time.Hour, "how often to mail a digest of the alerts raised since the last one")
+	configPath := fs.String("config", config.Path(), "settings file, reloaded when it changes; flags given explicitly take precedence")
+	fs.Parse(args)
+
+	if *url == "" {
+		fs.Usage()
+		return usageError{errors.New("-url (or OSYRAA_MONITOR_URL) is required")}
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	flags := monitorFlags{set: set, checks: *checks, interval: *interval, objective: *objective, emailEvery: *emailEvery}
+
+	logger := log.New(os.Stderr, "monitor: ", log.LstdFlags)
+	watcher := &config.Watcher{Path: *configPath, Logf: logger.Printf}
+	file, err := watcher.Load()
+	if err != nil {
+		return err
+	}
+	settings, every, err := flags.overlay(file)
+	if err != nil {
+		return err
+	}
+
+	target := battery.NewTarget(*url)
+	target.Expect.MaxResponseTime = settings.MaxResponseTime
+	// A reload may turn content-hash on, so the hashes are read either way
+	hashErr := expectContent(target, *public)
+	if hashErr != nil && hasCheck(settings.Checks, "content-hash") {
+		return hashErr
+	}
+
+	m := &monitor.Monitor{
+		Target:   target,
+		Checks:   settings.Checks,
+		Interval: settings.Interval,
+		Metrics:  monitor.NewMetrics(),
+		Logger:   logger,
+	}
+	if *webhook != "" {
+		m.Alerters = append(m.Alerters, monitor.NewWebhook(*webhook))
+	}
+	mailer, err := email.mailer()
+	if err != nil {
+		return err
+	}
+	var digest *monitor.Digest
+	if mailer != nil {
+		digest = &monitor.Digest{Mailer: mailer, Target: *url}
+		m.Alerters = append(m.Alerters, digest)
+	}
+	if settings.Objective > 0 {
+		if m.SLO, err = monitor.NewSLO(settings.Objective, *sloWindow, *sloState); err != nil {
+			return err
+		}
+	}
+	watcher.Apply = func(f *config.File) error {
+		settings, next, err := flags.overlay(f)
+		if err != nil {
+			return err
+		}
+		if hashErr != nil && hasCheck(settings.Checks, "content-hash") {
+			return hashErr
+		}
+		m.Reconfigure(settings)
+		if digest != nil && next != every {
+			digest.SetInterval(next)
+			every = next
+		}
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Metrics)
+	srv := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalf("metrics server: %v", err)
+		}
+	}()
+	defer srv.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if digest != nil {
+		done := make(chan struct{})
+		go func(every time.Duration) {
+			digest.Run(ctx, every, logger.Printf)
+			close(done)
+		}(every)
+		defer func() { <-done }()
+	}
+	go watcher.Run(ctx)
+	logger.Printf("checking %s every %v, metrics on %s/metrics", *url, settings.Interval, *listen)
+	if err := m.Run(ctx); !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// monitorFlags are the monitor flags osyraa.yaml can also set
+type monitorFlags struct {
+	// set holds the flags given on the command line, which the file does
+	// not override
+	set        map[string]bool
+	checks     string
+	interval   time.Duration
+	objective  float64
+	emailEvery time.Duration
+}
+
+// overlay returns the settings in effect with f: the file's values where
+// it has them and the flag was not given, the flags' otherwise
+func (mf monitorFlags) overlay(f *config.File) (monitor.Settings, time.Duration, error) {
+	c := f.Monitor
+	names := strings.Split(mf.checks, ",")
+	if len(c.Checks) > 0 && !mf.set["checks"] {
+		names = c.Checks
+	}
+	checks, err := monitor.SelectChecks(names)
+	if err != nil {
+		return monitor.Settings{}, 0, err
+	}
+	s := monitor.Settings{
+		Checks:          checks,
+		Interval:        mf.interval,
+		MaxResponseTime: battery.DefaultExpectations().MaxResponseTime,
+		Objective:       mf.objective,
+	}
+	if c.Interval > 0 && !mf.set["interval"] {
+		s.Interval = c.Interval
+	}
+	if c.Budgets.ResponseTime > 0 {
+		s.MaxResponseTime = c.Budgets.ResponseTime
+	}
+	if c.Budgets.Availability > 0 && !mf.set["slo"] {
+		s.Objective = c.Budgets.Availability
+	}
+	every := mf.emailEvery
+	if c.EmailEvery > 0 && !mf.set["email-every"] {
+		every = c.EmailEvery
+	}
+	return s, every, nil
+}
+
+// expectContent has the content-hash check expect the home page of the
+// build in public. Without it the check would compare nothing and pass.
+func expectContent(t *battery.Target, public string) error {
+	body, err := os.ReadFile(filepath.Join(public, "index.html"))
+	if err != nil {
+		return fmt.Errorf("content-hash needs the built home page: %w; build the site, point -public at it or leave content-hash out of the checks", err)
+	}
+	t.Expect.ContentHashes = map[string]string{"/": battery.ContentHash(body)}
+	return nil
+}
+
+func hasCheck(checks []battery.Check, name string) bool {
+	for _, c := range checks {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+)
+
+// runPackage packs the local build into a gzipped tarball with its
+// SHA-256 beside it, for shipping to a CDN or object storage apart from
+// the image, and checks the tarball unpacks to the build:
+//
+//	osyraa package                          # ../public to public.tar.gz and public.tar.gz.sha256
+//	osyraa package -verify public.tar.gz    # check an existing tarball against ../public
+//
+// SOURCE_DATE_EPOCH sets the files' modification time, so the same build
+// always packs to the same bytes.
+func runPackage(args []string) error {
+	fs := flag.NewFlagSet("package", flag.ExitOnError)
+	public := fs.String("public", filepath.Join("..", "public"), "local build to package")
+	out := fs.String("o", "public.tar.gz", "tarball to write")
+	verify := fs.String("verify", "", "only verify this tarball against -public")
+	fs.Parse(args)
+
+	archive := *verify
+	if archive == "" {
+		mtime, err := deploy.SourceDateEpoch()
+		if err != nil {
+			return err
+		}
+		a, err := deploy.Package(*public, *out, mtime)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d files, %d bytes, sha256 %s\n", a.Path, a.Files, a.Size, a.SHA256)
+		archive = a.Path
+	}
+
+	diff, err := deploy.VerifyArtifact(archive, *public)
+	if err != nil {
+		return err
+	}
+	for _, p := range diff.Missing {
+		fmt.Printf("MISSING  %s\n", p)
+	}
+	for _, p := range diff.Extra {
+		fmt.Printf("EXTRA    %s\n", p)
+	}
+	for _, p := range diff.Changed {
+		fmt.Printf("CHANGED  %s\n", p)
+	}
+	if !diff.Empty() {
+		return fmt.Errorf("%s does not match %s", archive, *public)
+	}
+	fmt.Printf("%s matches %s\n", archive, *public)
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/results"
+)
+
+// runResults reads `go test -json` output, prints it as go test -v does,
+// and writes the run's results as JUnit XML and a JSON summary:
+//
+//	go test -json . | osyraa results -junit reports/junit.xml -summary reports/summary.json
+func runResults(args []string) error {
+	fs := flag.NewFlagSet("results", flag.ExitOnError)
+	input := fs.String("input", "-", "go test -json output to read; - for stdin")
+	junitFile := fs.String("junit", "", "write the run's results as JUnit XML to this file")
+	summaryFile := fs.String("summary", "", "write a JSON summary of the run's results to this file")
+	reportDir := fs.String("reports", defaultReportDir(), "directory the run wrote its HTML report and artifacts to")
+	fs.Parse(args)
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	recorder := results.NewRecorder(os.Stdout)
+	if _, err := io.Copy(recorder, r); err != nil {
+		return err
+	}
+	if err := recorder.Close(); err != nil {
+		return err
+	}
+
+	run := recorder.Run()
+	if run.ID != "" {
+		artifacts, err := runArtifacts(*reportDir, run.ID)
+		if err != nil {
+			return err
+		}
+		run.Artifacts = artifacts
+	}
+	for file, write := range map[string]func(io.Writer) error{
+		*junitFile:   run.WriteJUnit,
+		*summaryFile: run.WriteSummary,
+	} {
+		if file == "" {
+			continue
+		}
+		if err := writeResultsFile(file, write); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "results written to %s\n", file)
+	}
+	if s := run.Summary(); !s.Passed {
+		if len(s.Failures) > 0 {
+			return fmt.Errorf("%d tests failed", len(s.Failures))
+		}
+		return fmt.Errorf("the run failed outside any test")
+	}
+	return nil
+}
+
+// defaultReportDir is where the harness writes its report, as the tests
+// choose it
+func defaultReportDir() string {
+	if dir := os.Getenv("OSYRAA_REPORT_DIR"); dir != "" {
+		return dir
+	}
+	return "reports"
+}
+
+// runArtifacts lists the HTML report and the artifacts the run left in
+// dir, if any
+func runArtifacts(dir, id string) ([]string, error) {
+	var paths []string
+	page := filepath.Join(dir, "report-"+id+".html")
+	if _, err := os.Stat(page); err == nil {
+		paths = append(paths, page)
+	}
+	rel, err := (&report.Report{Dir: dir, Run: id}).Artifacts()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range rel {
+		paths = append(paths, filepath.Join(dir, a))
+	}
+	return paths, nil
+}
+
+func writeResultsFile(file string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return f.Close()
+}
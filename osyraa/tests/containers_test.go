@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/cleanup"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/engine"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// ryukOnce settles whether testcontainers-go starts its Ryuk reaper before
+// the first container. Ryuk removes the run's containers even if the test
+// binary is killed outright, where the cleanup scope can't run. It is an
+// image of its own, so offline runs go without it, as does OSYRAA_RYUK=0
+// for engines that can't run it, such as rootless Podman without a socket
+// it may mount.
+var ryukOnce sync.Once
+
+func configureRyuk() {
+	ryukOnce.Do(func() {
+		if *offline || os.Getenv("OSYRAA_RYUK") == "0" {
+			os.Setenv("TESTCONTAINERS_RYUK_DISABLED", "true")
+		}
+	})
+}
+
+// siteLogs writes a container's output to a file as it arrives
+type siteLogs struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (l *siteLogs) Accept(log testcontainers.Log) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.f.Write(log.Content)
+}
+
+// runSite starts a container of image serving the site with
+// testcontainers-go and returns once it answers on /. It is confined like
+// the deployed site (no-new-privileges), and the site port is published
+// on an ephemeral loopback port, so parallel runs don't collide; host, if
+// not nil, adjusts the host config further. platform selects an image
+// variant, empty the engine's own. The container's output goes to
+// logName under the report's artifacts when logName isn't empty. The
+// container is removed at teardown, or by Ryuk if the run dies first;
+// stop removes it sooner.
+func (suite *DockerTestSuite) runSite(image, platform, logName string, host func(*container.HostConfig)) (c testcontainers.Container, baseURL string, stop *cleanup.Step, err error) {
+	configureRyuk()
+	port := nat.Port(settings.Port)
+	req := testcontainers.ContainerRequest{
+		Image:         image,
+		ImagePlatform: platform,
+		Labels:        runLabels,
+		// ip::port publishes on an ephemeral port of the loopback only
+		ExposedPorts: []string{"127.0.0.1::" + string(port)},
+		HostConfigModifier: func(hc *container.HostConfig) {
+			if host != nil {
+				host(hc)
+			}
+			hc.SecurityOpt = append(hc.SecurityOpt, "no-new-privileges:true")
+		},
+		WaitingFor: wait.ForHTTP("/").WithPort(port).WithForcedIPv4LocalHost().
+			WithStartupTimeout(settings.Timeouts.Ready),
+	}
+	if logName != "" {
+		abs, _, err := harnessReport.ArtifactPath(filepath.Join("containers", logName))
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			return nil, "", nil, err
+		}
+		f, err := os.Create(abs)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		suite.cleanups.Add("log "+logName, func(context.Context) error { return f.Close() })
+		req.LogConsumerCfg = &testcontainers.LogConsumerConfig{Consumers: []testcontainers.LogConsumer{&siteLogs{f: f}}}
+	}
+
+	c, err = testcontainers.GenericContainer(suite.ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+		Logger:           testcontainers.TestLogger(suite.T()),
+	})
+	if c != nil {
+		// Registered even when the wait failed, since the container exists
+		id := c.GetContainerID()
+		stop = suite.cleanups.Add("container "+id[:12], func(ctx context.Context) error { return c.Terminate(ctx) })
+	}
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("start %s: %w", image, err)
+	}
+	inspect, err := suite.client.ContainerInspect(suite.ctx, c.GetContainerID())
+	if err != nil {
+		return nil, "", nil, err
+	}
+	addr, err := engine.PublishedAddr(inspect.NetworkSettings.Ports, port)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return c, "http://" + addr, stop, nil
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deployPlatform builds the platform named by OSYRAA_DEPLOY_PLATFORM
+func deployPlatform(t *testing.T) deploy.Platform {
+	switch name := os.Getenv("OSYRAA_DEPLOY_PLATFORM"); name {
+	case "cloudrun":
+		return &deploy.CloudRun{
+			Service: os.Getenv("OSYRAA_CLOUDRUN_SERVICE"),
+			Region:  os.Getenv("OSYRAA_CLOUDRUN_REGION"),
+			Project: os.Getenv("OSYRAA_CLOUDRUN_PROJECT"),
+		}
+	case "fly":
+		return &deploy.Fly{App: os.Getenv("OSYRAA_FLY_APP")}
+	default:
+		t.Fatalf("unsupported OSYRAA_DEPLOY_PLATFORM %q (want cloudrun or fly)", name)
+		return nil
+	}
+}
+
+// localContentHashes hashes the locally built pages the deployment must serve
+func localContentHashes(t *testing.T) map[string]string {
+	hashes := map[string]string{}
+	if body, err := os.ReadFile(filepath.Join(publicDir(t), "index.html")); err == nil {
+		hashes["/"] = battery.ContentHash(body)
+	}
+	return hashes
+}
+
+// TestPostDeploySmoke verifies a Cloud Run or Fly.io deployment serves the
+// tested build, optionally rolling back (OSYRAA_ROLLBACK=1) when it doesn't
+func TestPostDeploySmoke(t *testing.T) {
+	requireNetwork(t)
+	url := os.Getenv("OSYRAA_DEPLOY_URL")
+	if url == "" {
+		t.Skip("set OSYRAA_DEPLOY_URL and OSYRAA_DEPLOY_PLATFORM to smoke test a deployment")
+	}
+	platform := deployPlatform(t)
+
+	target := newTarget(url)
+	target.Expect.ContentHashes = localContentHashes(t)
+	target.Expect.MaxResponseTime = 3 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	report, err := deploy.Smoke(ctx, target, platform, deploy.SmokeOptions{
+		RollbackOnFailure: os.Getenv("OSYRAA_ROLLBACK") == "1",
+	})
+	require.NoError(t, err, "Smoke test on %s should complete", platform.Name())
+
+	for _, result := range report.Results {
+		assert.NoError(t, result.Err, "Revision %s: check %s", report.Revision, result.Check)
+	}
+	if report.RolledBack != "" {
+		t.Logf("Rolled back %s from %s to %s", platform.Name(), report.Revision, report.RolledBack)
+	}
+}
+
+// TestBlueGreenCutover verifies a blue/green cutover: the candidate named by
+// OSYRAA_CANDIDATE (blue or green, default green) must pass the battery
+// before OSYRAA_CUTOVER_CMD switches traffic, and OSYRAA_PUBLIC_URL must then
+// serve the candidate's version: its home page's content hash, or with
+// OSYRAA_CUTOVER_VERSION its build ID (build-id) or a response header
+// (header:X-Version)
+func TestBlueGreenCutover(t *testing.T) {
+	requireNetwork(t)
+	blue, green, public := os.Getenv("OSYRAA_BLUE_URL"), os.Getenv("OSYRAA_GREEN_URL"), os.Getenv("OSYRAA_PUBLIC_URL")
+	if blue == "" || green == "" || public == "" {
+		t.Skip("set OSYRAA_BLUE_URL, OSYRAA_GREEN_URL and OSYRAA_PUBLIC_URL to verify a cutover")
+	}
+	bg := deploy.BlueGreen{Candidate: newTarget(green), Live: newTarget(blue), Public: newTarget(public)}
+	switch candidate := os.Getenv("OSYRAA_CANDIDATE"); candidate {
+	case "", "green":
+	case "blue":
+		bg.Candidate, bg.Live = bg.Live, bg.Candidate
+	default:
+		t.Fatalf("unsupported OSYRAA_CANDIDATE %q (want blue or green)", candidate)
+	}
+	bg.Candidate.Expect.ContentHashes = localContentHashes(t)
+	opts := deploy.CutoverOptions{Switch: deploy.ShellHook(os.Getenv("OSYRAA_CUTOVER_CMD"))}
+	switch version := os.Getenv("OSYRAA_CUTOVER_VERSION"); {
+	case version == "":
+	case version == "build-id":
+		opts.Version = deploy.BuildIDMeta
+	case strings.HasPrefix(version, "header:"):
+		opts.Version = deploy.VersionHeader(strings.TrimPrefix(version, "header:"))
+	default:
+		t.Fatalf("unsupported OSYRAA_CUTOVER_VERSION %q (want build-id or header:<name>)", version)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	report, err := deploy.VerifyCutover(ctx, bg, opts)
+	if report != nil {
+		for _, result := range report.Results {
+			assert.NoError(t, result.Err, "Candidate check %s", result.Check)
+		}
+	}
+	require.NoError(t, err, "Public endpoint should switch to the candidate")
+	t.Logf("Public endpoint served the candidate %v after the switch", report.SwitchedAfter.Round(time.Second))
+}
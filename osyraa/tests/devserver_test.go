@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/cleanup"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/devserver"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/securitytxt"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/vcard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// DevServerTestSuite runs a reduced set of checks against `hugo server`,
+// for fast feedback while editing content: no build, no container, and the
+// pages are the ones the author has open. It starts hugo server on a free
+// port (OSYRAA_HUGO overrides the binary) or targets OSYRAA_DEV_URL, a
+// server already running, e.g. http://localhost:1313/.
+type DevServerTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	target   *battery.Target
+	site     *crawl.Result
+	cleanups *cleanup.Scope
+}
+
+// SetupSuite starts hugo server unless OSYRAA_DEV_URL names one
+func (suite *DevServerTestSuite) SetupSuite() {
+	t := suite.T()
+	suite.ctx = suiteContext(t)
+	suite.cleanups = teardown.Scope()
+
+	base := os.Getenv("OSYRAA_DEV_URL")
+	if base == "" {
+		src, err := filepath.Abs("..")
+		require.NoError(t, err)
+		opts := devserver.Options{Hugo: os.Getenv("OSYRAA_HUGO"), Source: src}
+		if os.Getenv("OSYRAA_DEV_DRAFTS") == "1" {
+			opts.Args = []string{"--buildDrafts", "--buildFuture"}
+		}
+		srv, err := devserver.Start(suite.ctx, opts)
+		if errors.Is(err, devserver.ErrNoHugo) {
+			t.Skip("install hugo, or set OSYRAA_DEV_URL to a running hugo server, to run the dev server checks")
+		}
+		require.NoError(t, err, "hugo server should start")
+		suite.cleanups.Add("hugo server", func(context.Context) error { return srv.Close() })
+		base = srv.URL
+	}
+	suite.target = newTarget(base)
+	// The dev server renders on request, so the first pages are slower
+	// than the container's
+	suite.target.Client.Timeout = 30 * time.Second
+}
+
+// TearDownSuite stops the server
+func (suite *DevServerTestSuite) TearDownSuite() {
+	if err := suite.cleanups.Close(context.Background()); err != nil {
+		suite.T().Log("Teardown:", err)
+	}
+}
+
+// crawlSite follows every internal link from the home page once
+func (suite *DevServerTestSuite) crawlSite() *crawl.Result {
+	t := suite.T()
+	if suite.site == nil {
+		start, err := url.Parse(suite.target.BaseURL + "/")
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(suite.ctx, settings.Timeouts.Crawl)
+		defer cancel()
+		site, err := newCrawler(t, crawl.NewHTTPFetcher(suite.target.Client)).Run(ctx, start)
+		require.NoError(t, err, "Crawling the dev server should succeed")
+		suite.site = site
+	}
+	return suite.site
+}
+
+// TestHomePage checks the home page is served with the resume's name and
+// every section in order
+func (suite *DevServerTestSuite) TestHomePage() {
+	t := suite.T()
+	checks := []battery.Check{
+		{Name: "endpoint", Run: battery.CheckEndpoint},
+		{Name: "content", Run: battery.CheckContent},
+	}
+	for _, result := range battery.Run(suite.ctx, suite.target, checks) {
+		assert.NoError(t, result.Err, "Check %s should pass", result.Check)
+	}
+
+	_, body, err := suite.target.Get(suite.ctx, "/")
+	require.NoError(t, err)
+	doc, err := match.ParseBytes(body)
+	require.NoError(t, err)
+	assert.Equal(t, settings.Sections, doc.Select("main h2").Texts(), "Home page should show every section in order")
+}
+
+// TestInternalLinks fails on any link to a page the server doesn't have
+func (suite *DevServerTestSuite) TestInternalLinks() {
+	t := suite.T()
+	site := suite.crawlSite()
+	for _, page := range site.Missing {
+		t.Errorf("Broken internal link: %s returned %d", page.URL.Path, page.Status)
+	}
+	t.Logf("Crawled %d pages in %v", len(site.Pages), site.Elapsed.Round(time.Millisecond))
+}
+
+// TestOutputFormats checks the non-HTML outputs still render: security.txt,
+// the vCard and the JSON Resume
+func (suite *DevServerTestSuite) TestOutputFormats() {
+	t := suite.T()
+
+	resp, body, err := suite.target.Get(suite.ctx, "/.well-known/security.txt")
+	require.NoError(t, err)
+	if assert.Equal(t, http.StatusOK, resp.StatusCode, "security.txt should be served") {
+		// Not Validate: the server's http:// baseURL makes Canonical
+		// fail its https requirement
+		f, err := securitytxt.Parse(body)
+		if assert.NoError(t, err, "security.txt should parse") {
+			assert.NotEmpty(t, f.Contact, "security.txt should have a Contact")
+			assert.True(t, f.Expires.After(time.Now()), "security.txt should not have expired")
+		}
+	}
+
+	resp, body, err = suite.target.Get(suite.ctx, "/contact.vcf")
+	require.NoError(t, err)
+	if assert.Equal(t, http.StatusOK, resp.StatusCode, "contact.vcf should be served") {
+		_, err = vcard.Parse(body)
+		assert.NoError(t, err, "contact.vcf should parse")
+	}
+
+	resp, body, err = suite.target.Get(suite.ctx, "/resume.json")
+	require.NoError(t, err)
+	if assert.Equal(t, http.StatusOK, resp.StatusCode, "resume.json should be served") {
+		assert.True(t, json.Valid(body), "resume.json should be valid JSON")
+	}
+}
+
+// HandleStats records a span per test once the suite is done
+func (suite *DevServerTestSuite) HandleStats(_ string, stats *suiteStats) {
+	traceTests(suite.ctx, stats)
+}
+
+// TestDevServerSuite runs the dev server checks; it needs hugo or a
+// running server, but no Docker
+func TestDevServerSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(DevServerTestSuite))
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dnscheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var baseURLRe = regexp.MustCompile(`(?m)^baseURL\s*=\s*"([^"]+)"`)
+
+// siteBaseURL returns the Hugo baseURL from config.toml
+func siteBaseURL(t *testing.T) *url.URL {
+	config, err := os.ReadFile(filepath.Join("..", "config.toml"))
+	require.NoError(t, err, "Failed to read config.toml")
+	m := baseURLRe.FindSubmatch(config)
+	require.NotNil(t, m, "config.toml should set baseURL")
+	u, err := url.Parse(string(m[1]))
+	require.NoError(t, err, "baseURL should be a valid URL")
+	return u
+}
+
+// siteDomains returns the host from the Hugo baseURL plus any extra domains
+// in OSYRAA_DNS_DOMAINS (comma separated)
+func siteDomains(t *testing.T) []string {
+	domains := []string{siteBaseURL(t).Hostname()}
+	for _, d := range strings.Split(os.Getenv("OSYRAA_DNS_DOMAINS"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+// TestDNSRecords verifies the site's domains resolve to the ingress
+// (OSYRAA_INGRESS_IP), that CAA records permit the CA in use
+// (OSYRAA_DNS_CA, default letsencrypt.org) and that no CNAME dangles.
+// Enable with OSYRAA_DNS=1; OSYRAA_DNS_SERVER picks the resolver.
+func TestDNSRecords(t *testing.T) {
+	requireNetwork(t)
+	if os.Getenv("OSYRAA_DNS") != "1" {
+		t.Skip("set OSYRAA_DNS=1 to verify DNS records")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	resolver := dnscheck.NewResolver(os.Getenv("OSYRAA_DNS_SERVER"))
+	domains := siteDomains(t)
+	ca := os.Getenv("OSYRAA_DNS_CA")
+	if ca == "" {
+		ca = "letsencrypt.org"
+	}
+
+	for _, domain := range domains {
+		t.Run(domain, func(t *testing.T) {
+			exp := dnscheck.Expectation{Name: domain}
+			if ip := os.Getenv("OSYRAA_INGRESS_IP"); ip != "" {
+				exp.A = []string{ip}
+			}
+			assert.NoError(t, resolver.CheckRecords(ctx, exp), "Records for %s", domain)
+			assert.NoError(t, resolver.CheckCAA(ctx, domain, ca), "CAA for %s", domain)
+		})
+	}
+
+	dangling, err := resolver.DanglingCNAMEs(ctx, domains)
+	require.NoError(t, err, "Failed to resolve CNAME targets")
+	assert.Empty(t, dangling, "CNAMEs should not point at deprovisioned hosts")
+}
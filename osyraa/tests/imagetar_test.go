@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/imagetar"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImageRoundTrip follows the image down the air-gapped path
+// (OSYRAA_IMAGE_ROUNDTRIP=1): it is saved to a tarball, the archive is
+// verified against the image's digests, the image is removed and loaded
+// back from the file, and the loaded image must have the same ID and pass
+// the HTTP battery. The image can't be removed while a container uses it,
+// so the suite's container is removed first and started again from the
+// loaded image; run it alone with make test-image-roundtrip.
+func (suite *DockerTestSuite) TestImageRoundTrip() {
+	t := suite.T()
+	if os.Getenv("OSYRAA_IMAGE_ROUNDTRIP") != "1" {
+		t.Skip("set OSYRAA_IMAGE_ROUNDTRIP=1 to save, remove and reload the image")
+	}
+	tag := suite.imageTag
+	built, _, err := suite.client.ImageInspectWithRaw(suite.ctx, tag)
+	require.NoError(t, err, "TestImageRoundTrip needs the suite's image")
+	users, err := suite.client.ContainerList(suite.ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("ancestor", tag)),
+	})
+	require.NoError(t, err, "Failed to list containers")
+	users = slices.DeleteFunc(users, func(c types.Container) bool { return c.ID == suite.containerID })
+	if len(users) > 0 {
+		t.Skipf("%d containers use %s; run with make test-image-roundtrip", len(users), tag)
+	}
+	require.NoError(t, suite.siteStop.Run(suite.ctx), "Failed to remove the suite's container")
+
+	tarball := filepath.Join(t.TempDir(), "resume.tar")
+	ctx, span := tracing.Start(suite.ctx, "image save")
+	err = saveImage(ctx, suite.client, tag, tarball)
+	tracing.End(span, err)
+	require.NoError(t, err, "Failed to save the image")
+
+	f, err := os.Open(tarball)
+	require.NoError(t, err)
+	archive, err := imagetar.Verify(f)
+	f.Close()
+	require.NoError(t, err, "The saved archive should be intact")
+	assert.Equal(t, built.ID, archive.ID, "The archive should hold the built image")
+	assert.Contains(t, archive.RepoTags, tag, "The archive should carry the image's tag")
+	t.Logf("Saved %s: %d layers, %d MB", tag, archive.Layers, archive.Size/1024/1024)
+
+	// By ID, so every tag of the image goes too
+	_, err = suite.client.ImageRemove(suite.ctx, built.ID, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+	require.NoError(t, err, "Failed to remove the image")
+	_, _, err = suite.client.ImageInspectWithRaw(suite.ctx, built.ID)
+	require.True(t, client.IsErrNotFound(err), "The image should be gone before it is loaded back, got %v", err)
+
+	ctx, span = tracing.Start(suite.ctx, "image load")
+	err = loadImage(ctx, suite.client, tarball)
+	tracing.End(span, err)
+	require.NoError(t, err, "Failed to load the saved image")
+	loaded, _, err := suite.client.ImageInspectWithRaw(suite.ctx, tag)
+	require.NoError(t, err, "The loaded image should have its tag")
+	assert.Equal(t, built.ID, loaded.ID, "The loaded image should be the one saved")
+
+	require.NoError(t, suite.startSite("site-reloaded.log"), "The loaded image should start and serve the site")
+	target := newTarget(suite.siteURL)
+	for _, result := range battery.Run(suite.ctx, target, battery.Default()) {
+		assert.NoError(t, result.Err, "Check %s should pass on the loaded image", result.Check)
+	}
+}
+
+// saveImage writes tag's image to a tarball as docker save would
+func saveImage(ctx context.Context, c *client.Client, tag, file string) error {
+	rc, err := c.ImageSave(ctx, []string{tag})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err := f.ReadFrom(rc); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadImage loads a tarball into the daemon as docker load would
+func loadImage(ctx context.Context, c *client.Client, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	resp, err := c.ImageLoad(ctx, f, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = imagetar.LoadResult(resp.Body)
+	return err
+}
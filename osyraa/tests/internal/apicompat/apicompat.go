@@ -0,0 +1,228 @@
+// Package apicompat compares the exported API of the check libraries under
+// pkg/ between two revisions and derives the semantic version bump required
+// to release the change without breaking downstream importers.
+package apicompat
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/apidiff"
+	"golang.org/x/tools/go/packages"
+)
+
+// Patterns selects the packages covered by the compatibility guarantee.
+// Commands and internal packages are deliberately excluded.
+var Patterns = []string{"./pkg/..."}
+
+// TagPrefix is prepended to version tags because the module lives in a
+// subdirectory of the repository
+const TagPrefix = "osyraa/tests/"
+
+// Bump is the kind of version increment a change requires
+type Bump int
+
+const (
+	Patch Bump = iota
+	Minor
+	Major
+)
+
+func (b Bump) String() string {
+	switch b {
+	case Major:
+		return "major"
+	case Minor:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// Report is the result of comparing two revisions of the module
+type Report struct {
+	Module       string
+	Incompatible []string
+	Compatible   []string
+}
+
+// Bump returns the smallest version increment that covers the report
+func (r Report) Bump() Bump {
+	switch {
+	case len(r.Incompatible) > 0:
+		return Major
+	case len(r.Compatible) > 0:
+		return Minor
+	default:
+		return Patch
+	}
+}
+
+// Load type-checks the packages matching Patterns in the module at dir
+func Load(dir string) (*apidiff.Module, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, Patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("load packages in %s: %w", dir, err)
+	}
+
+	mod := &apidiff.Module{}
+	var errs []string
+	for _, p := range pkgs {
+		for _, e := range p.Errors {
+			errs = append(errs, e.Error())
+		}
+		if p.Module != nil {
+			mod.Path = p.Module.Path
+		}
+		if p.Types != nil {
+			mod.Packages = append(mod.Packages, p.Types)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("type-check %s:\n  %s", dir, strings.Join(errs, "\n  "))
+	}
+	sort.Slice(mod.Packages, func(i, j int) bool {
+		return mod.Packages[i].Path() < mod.Packages[j].Path()
+	})
+	return mod, nil
+}
+
+// Compare diffs two loaded revisions of the module
+func Compare(old, new *apidiff.Module) Report {
+	return fromChanges(new.Path, apidiff.ModuleChanges(old, new))
+}
+
+// ComparePackages diffs two versions of a single package
+func ComparePackages(old, new *types.Package) Report {
+	return fromChanges(new.Path(), apidiff.Changes(old, new))
+}
+
+func fromChanges(module string, changes apidiff.Report) Report {
+	r := Report{Module: module}
+	for _, c := range changes.Changes {
+		if c.Compatible {
+			r.Compatible = append(r.Compatible, c.Message)
+		} else {
+			r.Incompatible = append(r.Incompatible, c.Message)
+		}
+	}
+	return r
+}
+
+// Version is a parsed vMAJOR.MINOR.PATCH tag
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses a version with or without TagPrefix
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, TagPrefix), "v")
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+	}
+	return Version{nums[0], nums[1], nums[2]}, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Tag returns the repository tag for v
+func (v Version) Tag() string {
+	return TagPrefix + v.String()
+}
+
+// ErrMajorVersion is returned by Next for a breaking change from v1 on
+var ErrMajorVersion = errors.New("a breaking change needs a new major version")
+
+// Next returns the version following v for a change of kind b. Before v1
+// breaking changes only bump the minor version, per Go module conventions;
+// crossing to v2 would change the import path and is left to a human, so
+// Next returns ErrMajorVersion instead.
+func (v Version) Next(b Bump) (Version, error) {
+	switch {
+	case b == Major && v.Major == 0:
+		return Version{0, v.Minor + 1, 0}, nil
+	case b == Major:
+		return Version{}, fmt.Errorf("%w: v%d needs a /v%[2]d module path, which is left to a human", ErrMajorVersion, v.Major+1)
+	case b == Minor:
+		return Version{v.Major, v.Minor + 1, 0}, nil
+	default:
+		return Version{v.Major, v.Minor, v.Patch + 1}, nil
+	}
+}
+
+// LatestTag returns the newest release tag reachable from HEAD, or an empty
+// string when the module has never been tagged
+func LatestTag(repoDir string) (string, error) {
+	out, err := git(repoDir, "tag", "--list", TagPrefix+"v*", "--merged", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	var latest string
+	var best Version
+	for _, tag := range strings.Fields(out) {
+		v, err := ParseVersion(tag)
+		if err != nil {
+			continue
+		}
+		if latest == "" || v.Major > best.Major ||
+			(v.Major == best.Major && (v.Minor > best.Minor || (v.Minor == best.Minor && v.Patch > best.Patch))) {
+			latest, best = tag, v
+		}
+	}
+	return latest, nil
+}
+
+// Checkout materialises ref in a temporary worktree and returns the module
+// directory inside it along with a cleanup function
+func Checkout(moduleDir, ref string) (string, func(), error) {
+	prefix, err := git(moduleDir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", nil, err
+	}
+	tmp, err := os.MkdirTemp("", "apicompat-")
+	if err != nil {
+		return "", nil, err
+	}
+	worktree := filepath.Join(tmp, "base")
+	if _, err := git(moduleDir, "worktree", "add", "--detach", worktree, ref); err != nil {
+		os.RemoveAll(tmp)
+		return "", nil, err
+	}
+	cleanup := func() {
+		git(moduleDir, "worktree", "remove", "--force", worktree)
+		os.RemoveAll(tmp)
+	}
+	return filepath.Join(worktree, filepath.FromSlash(strings.TrimSpace(prefix))), cleanup, nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, out)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,65 @@
+package apicompat
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func typeCheck(t *testing.T, src string) *types.Package {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	require.NoError(t, err)
+	pkg, err := (&types.Config{Importer: importer.Default()}).Check("example.com/p", fset, []*ast.File{f}, nil)
+	require.NoError(t, err)
+	return pkg
+}
+
+func TestComparePackages(t *testing.T) {
+	old := typeCheck(t, `package p; func Check(url string) error { return nil }`)
+
+	added := typeCheck(t, `package p; func Check(url string) error { return nil }; func Audit() {}`)
+	r := ComparePackages(old, added)
+	assert.Empty(t, r.Incompatible)
+	assert.NotEmpty(t, r.Compatible)
+	assert.Equal(t, Minor, r.Bump())
+
+	changed := typeCheck(t, `package p; func Check(url string, strict bool) error { return nil }`)
+	r = ComparePackages(old, changed)
+	assert.NotEmpty(t, r.Incompatible)
+	assert.Equal(t, Major, r.Bump())
+
+	same := typeCheck(t, `package p; func Check(u string) error { return nil }`)
+	assert.Equal(t, Patch, ComparePackages(old, same).Bump())
+}
+
+func TestVersionNext(t *testing.T) {
+	v, err := ParseVersion("osyraa/tests/v0.3.2")
+	require.NoError(t, err)
+	next := func(v Version, b Bump) Version {
+		t.Helper()
+		n, err := v.Next(b)
+		require.NoError(t, err)
+		return n
+	}
+	assert.Equal(t, "v0.3.3", next(v, Patch).String())
+	assert.Equal(t, "v0.4.0", next(v, Minor).String())
+	assert.Equal(t, "v0.4.0", next(v, Major).String(), "pre-v1 breaking changes bump minor")
+
+	v = Version{1, 2, 3}
+	assert.Equal(t, "v1.2.4", next(v, Patch).String())
+	assert.Equal(t, "osyraa/tests/v1.3.0", next(v, Minor).Tag())
+	_, err = v.Next(Major)
+	assert.ErrorIs(t, err, ErrMajorVersion, "v2 changes the import path")
+	assert.ErrorContains(t, err, "/v2")
+
+	_, err = ParseVersion("v1.2")
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/jsaudit"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShippedJSVulnerabilities fingerprints the JavaScript libraries in the
+// built public/ and fails on versions with advisories at
+// OSYRAA_JS_MIN_SEVERITY (default medium) or above. OSYRAA_RETIRE_DB points
+// at a full retire.js jsrepository.json in place of the embedded database.
+func TestShippedJSVulnerabilities(t *testing.T) {
+	public := publicDir(t)
+
+	repo := jsaudit.DefaultRepository()
+	if path := os.Getenv("OSYRAA_RETIRE_DB"); path != "" {
+		var err error
+		repo, err = jsaudit.LoadRepository(path)
+		require.NoError(t, err, "Failed to load OSYRAA_RETIRE_DB")
+	}
+	minSeverity := "medium"
+	if v := os.Getenv("OSYRAA_JS_MIN_SEVERITY"); v != "" {
+		minSeverity = strings.ToLower(v)
+	}
+	threshold, ok := jsaudit.Severities[minSeverity]
+	require.True(t, ok, "OSYRAA_JS_MIN_SEVERITY should be low, medium, high or critical")
+
+	detections, findings, err := repo.Scan(public)
+	require.NoError(t, err, "Failed to scan public/")
+	for _, d := range detections {
+		t.Logf("%s %s in %s", d.Library, d.Version, d.Location)
+	}
+
+	table := &report.Table{Header: []string{"Library", "Version", "Location", "Severity", "Advisory"}}
+	var blocking []string
+	for _, f := range findings {
+		table.Rows = append(table.Rows, []string{f.Library, f.Version, f.Location, f.Vulnerability.Severity, f.Vulnerability.ID()})
+		if jsaudit.Severities[f.Vulnerability.Severity] >= threshold {
+			blocking = append(blocking, fmt.Sprintf("%s %s (%s): %s %s", f.Library, f.Version, f.Location,
+				f.Vulnerability.ID(), f.Vulnerability.Identifiers.Summary))
+		}
+	}
+	if len(detections) > 0 {
+		section := report.Section{Title: "JavaScript libraries", Status: report.Pass,
+			Summary: fmt.Sprintf("%d libraries detected, %d advisories", len(detections), len(findings))}
+		if len(findings) > 0 {
+			section.Table = table
+			section.Status = report.Warn
+		}
+		if len(blocking) > 0 {
+			section.Status = report.Fail
+		}
+		harnessReport.Add(section)
+	}
+
+	assert.Empty(t, blocking, "Shipped JavaScript should have no advisories at %s severity or above", minSeverity)
+}
@@ -0,0 +1,261 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/cleanup"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/httpclient"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/imagebuild"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/kube"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/ready"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// KindTestSuite deploys the repository's manifests into a kind cluster and
+// runs the HTTP battery against the in-cluster service
+type KindTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	cluster   *kube.Cluster
+	forward   *kube.PortForward
+	imageTag  string
+	namespace string
+	cleanups  *cleanup.Scope
+	client    *client.Client
+}
+
+// manifestPath is the dev deployment ArgoCD syncs to the cluster
+var manifestPath = filepath.Join("..", "..", "gitops", "applications", "dev", "resume-deployment.yaml")
+
+// SetupSuite builds the image, creates the cluster and deploys the site
+func (suite *KindTestSuite) SetupSuite() {
+	t := suite.T()
+	suite.ctx = suiteContext(t)
+	suite.imageTag = imageTag()
+	suite.namespace = "resume"
+	suite.cleanups = teardown.Scope()
+
+	var err error
+	suite.client, err = newDockerClient()
+	require.NoError(t, err, "Failed to create Docker client")
+	// Registered first so it runs last, after the removals that use it
+	suite.cleanups.Add("Docker client", func(context.Context) error { return suite.client.Close() })
+	tag := suite.imageTag
+	suite.cleanups.Add("image "+tag, func(ctx context.Context) error { return removeImage(ctx, suite.client, tag) })
+	_, err = buildSiteImage(suite.ctx, suite.imageTag)
+	require.NoError(t, err, "Docker build failed")
+
+	// Named after the run, so concurrent runs get clusters of their own
+	suite.cluster, err = kube.CreateCluster(suite.ctx, kube.ClusterName("osyraa-"+runID), runLabels)
+	require.NoError(t, err, "Failed to create kind cluster")
+	cluster := suite.cluster
+	suite.cleanups.Add("kind cluster "+cluster.Name, cluster.Delete)
+
+	require.NoError(t, suite.cluster.LoadImage(suite.ctx, suite.imageTag), "Failed to load image into kind")
+
+	objs, err := kube.ReadManifests(manifestPath)
+	require.NoError(t, err, "Failed to read manifests")
+	require.Equal(t, 1, kube.SetImage(objs, "resume", suite.imageTag), "Deployment should have a resume container")
+
+	rendered, err := kube.EncodeManifests(objs)
+	require.NoError(t, err)
+	require.NoError(t, suite.cluster.Apply(suite.ctx, rendered), "Failed to apply manifests")
+
+	err = suite.cluster.RolloutStatus(suite.ctx, suite.namespace, "resume", 3*time.Minute)
+	require.NoError(t, err, "Deployment rollout should complete")
+
+	suite.forward, err = suite.cluster.PortForward(suite.ctx, suite.namespace, "svc/resume", 80)
+	require.NoError(t, err, "Failed to port-forward to the service")
+	// reforward replaces the forward, so the one open at teardown is closed
+	suite.cleanups.Add("port-forward", func(context.Context) error {
+		if suite.forward == nil {
+			return nil
+		}
+		return suite.forward.Close()
+	})
+}
+
+// TearDownSuite removes the port-forward, the cluster and the image
+func (suite *KindTestSuite) TearDownSuite() {
+	if err := suite.cleanups.Close(context.Background()); err != nil {
+		suite.T().Log("Teardown:", err)
+	}
+}
+
+// HandleStats records a span per test once the suite is done
+func (suite *KindTestSuite) HandleStats(_ string, stats *suiteStats) {
+	traceTests(suite.ctx, stats)
+}
+
+// TestHTTPBattery runs every HTTP check against the in-cluster service
+func (suite *KindTestSuite) TestHTTPBattery() {
+	t := suite.T()
+
+	target := newTarget(suite.forward.URL())
+	for _, result := range battery.Run(suite.ctx, target, battery.Default()) {
+		assert.NoError(t, result.Err, "Check %s should pass in-cluster", result.Check)
+		t.Logf("%s: %v", result.Check, result.Duration)
+	}
+}
+
+// TestIngressEndToEnd sends requests through the cluster's ingress
+// controller using the production host names, exercising the Ingress rules.
+// The controller's manifest comes from GitHub, so offline runs skip it.
+func (suite *KindTestSuite) TestIngressEndToEnd() {
+	t := suite.T()
+	requireNetwork(t)
+
+	require.NoError(t, suite.cluster.InstallIngressNginx(suite.ctx, 3*time.Minute), "Failed to install ingress-nginx")
+
+	forward, err := suite.cluster.PortForward(suite.ctx, "ingress-nginx", "svc/ingress-nginx-controller", 443)
+	require.NoError(t, err, "Failed to port-forward to the ingress controller")
+	defer forward.Close()
+
+	for _, route := range expectedRoutes {
+		// cert-manager isn't installed in kind, so the controller serves its
+		// default certificate; routing is what's under test here
+		transport := httpclient.NewTransport(httpclient.Current())
+		transport.TLSClientConfig.ServerName = route.Host
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		client := &http.Client{Timeout: 10 * time.Second, Transport: transport}
+		req, err := http.NewRequestWithContext(suite.ctx, http.MethodGet,
+			fmt.Sprintf("https://127.0.0.1:%d%s", forward.LocalPort, route.Path), nil)
+		require.NoError(t, err)
+		req.Host = route.Host
+
+		// The controller needs a moment to pick up Ingress objects created
+		// before it
+		var body []byte
+		routed := ready.Predicate{Name: route.Host + route.Path, Ready: func(ctx context.Context) (bool, error) {
+			resp, err := client.Do(req.Clone(ctx))
+			if err != nil {
+				return false, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return false, fmt.Errorf("status %d", resp.StatusCode)
+			}
+			body, err = io.ReadAll(resp.Body)
+			return err == nil, err
+		}}
+		o := readyOptions()
+		o.Timeout = time.Minute
+		require.NoError(t, ready.WaitFor(suite.ctx, routed, o), "%s%s should be routed to the site", route.Host, route.Path)
+
+		doc, err := match.ParseBytes(body)
+		require.NoError(t, err)
+		assert.NoError(t, doc.Select("h1").TextEquals(settings.Author), "Ingress should serve the resume")
+	}
+}
+
+// TestReplicasReady verifies every replica declared in the manifest is available
+func (suite *KindTestSuite) TestReplicasReady() {
+	t := suite.T()
+
+	out, err := suite.cluster.Kubectl(suite.ctx, nil, "get", "deployment", "resume", "-n", suite.namespace,
+		"-o", "jsonpath={.status.availableReplicas}/{.spec.replicas}")
+	require.NoError(t, err, "Failed to read deployment status")
+
+	var available, desired int
+	_, err = fmt.Sscanf(string(out), "%d/%d", &available, &desired)
+	require.NoError(t, err, "Unexpected deployment status %q", out)
+	assert.Equal(t, desired, available, "All replicas should be available")
+}
+
+// reforward replaces the service port-forward, which stays bound to the pod
+// it first connected to and breaks once a rollout replaces that pod
+func (suite *KindTestSuite) reforward(target *battery.Target) error {
+	if err := suite.cluster.RolloutStatus(suite.ctx, suite.namespace, "resume", 3*time.Minute); err != nil {
+		return err
+	}
+	suite.forward.Close()
+	suite.forward = nil
+	forward, err := suite.cluster.PortForward(suite.ctx, suite.namespace, "svc/resume", 80)
+	if err != nil {
+		return err
+	}
+	suite.forward = forward
+	target.BaseURL = forward.URL()
+	return nil
+}
+
+// TestRollbackDrill deploys a deliberately broken image, checks the battery
+// catches it and that the rollback (OSYRAA_ROLLBACK_CMD, default
+// "kubectl rollout undo") restores the tested build
+func (suite *KindTestSuite) TestRollbackDrill() {
+	t := suite.T()
+
+	// Scoped to the run like the tested image, and labelled with it by
+	// buildImage, so parallel runs keep their own and osyraa clean finds it
+	broken := settings.Image + ":broken-" + runID
+	suite.cleanups.Add("image "+broken, func(ctx context.Context) error { return removeImage(ctx, suite.client, broken) })
+	_, err := buildImage(suite.ctx, imagebuild.Dockerfile("FROM nginx:1.25-alpine\nRUN echo '<h1>Under construction</h1>' > /usr/share/nginx/html/index.html\n"), "Dockerfile", broken)
+	require.NoError(t, err, "Broken image build failed")
+	require.NoError(t, suite.cluster.LoadImage(suite.ctx, broken), "Failed to load broken image into kind")
+
+	// Rollback scripts talk to the test cluster, never the user's context
+	t.Setenv("KUBECONFIG", suite.cluster.Kubeconfig)
+	rollback := deploy.ShellHook(os.Getenv("OSYRAA_ROLLBACK_CMD"))
+	if rollback == nil {
+		rollback = func(ctx context.Context) error {
+			_, err := suite.cluster.Kubectl(ctx, nil, "rollout", "undo", "deployment/resume", "-n", suite.namespace)
+			return err
+		}
+	}
+
+	target := newTarget(suite.forward.URL())
+	drill := &deploy.RollbackDrill{
+		Target: target,
+		Checks: []battery.Check{
+			{Name: "endpoint", Run: battery.CheckEndpoint},
+			{Name: "content", Run: battery.CheckContent},
+		},
+		Break: func(ctx context.Context) error {
+			if _, err := suite.cluster.Kubectl(ctx, nil, "set", "image", "deployment/resume",
+				"resume="+broken, "-n", suite.namespace); err != nil {
+				return err
+			}
+			return suite.reforward(target)
+		},
+		Rollback: func(ctx context.Context) error {
+			if err := rollback(ctx); err != nil {
+				return err
+			}
+			return suite.reforward(target)
+		},
+	}
+
+	report, err := drill.Run(suite.ctx)
+	require.NoError(t, err, "Rollback drill should detect the broken image and recover")
+	for _, result := range report.Detected {
+		t.Logf("Detected by %s: %v", result.Check, result.Err)
+	}
+	t.Logf("Detected after %v, restored after %v", report.DetectedAfter.Round(time.Second), report.RestoredAfter.Round(time.Second))
+}
+
+// TestKindSuite runs only when OSYRAA_K8S=1 since it needs kind, kubectl and Docker
+func TestKindSuite(t *testing.T) {
+	if os.Getenv("OSYRAA_K8S") != "1" {
+		t.Skip("set OSYRAA_K8S=1 to run the kind deployment tests")
+	}
+	if err := kube.Available(); err != nil {
+		t.Skip(err)
+	}
+	if _, err := containerEngine(); err != nil {
+		t.Skip(err)
+	}
+	suite.Run(t, new(KindTestSuite))
+}
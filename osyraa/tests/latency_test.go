@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/latency"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMultiRegionLatency probes OSYRAA_LATENCY_URL from each vantage in
+// OSYRAA_VANTAGES (e.g. "local,eu=socks5://eu-proxy:1080,ap=ssh://probe@ap-host")
+// and adds the per-region TTFB to the report. OSYRAA_TTFB_BUDGET bounds p95.
+func TestMultiRegionLatency(t *testing.T) {
+	requireNetwork(t)
+	url, spec := os.Getenv("OSYRAA_LATENCY_URL"), os.Getenv("OSYRAA_VANTAGES")
+	if url == "" || spec == "" {
+		t.Skip("set OSYRAA_LATENCY_URL and OSYRAA_VANTAGES to probe latency from multiple regions")
+	}
+	vantages, err := latency.ParseVantages(spec)
+	require.NoError(t, err, "Invalid OSYRAA_VANTAGES")
+
+	budget := 800 * time.Millisecond
+	if v := os.Getenv("OSYRAA_TTFB_BUDGET"); v != "" {
+		budget, err = time.ParseDuration(v)
+		require.NoError(t, err, "OSYRAA_TTFB_BUDGET should be a duration")
+	}
+	samples := 10
+	if v := os.Getenv("OSYRAA_LATENCY_SAMPLES"); v != "" {
+		samples, err = strconv.Atoi(v)
+		require.NoError(t, err, "OSYRAA_LATENCY_SAMPLES should be a number")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	results, err := latency.Probe(ctx, vantages, url, samples)
+	require.NoError(t, err, "Failed to probe from every vantage")
+
+	table := &report.Table{Header: []string{"Region", "p50 TTFB", "p95 TTFB", "Errors"}}
+	for _, r := range results {
+		table.Rows = append(table.Rows, []string{r.Vantage,
+			r.Percentile(50).Round(time.Millisecond).String(),
+			r.Percentile(95).Round(time.Millisecond).String(),
+			strconv.Itoa(len(r.Errors))})
+	}
+	outliers := latency.Outliers(results, 3)
+	budgetErr := latency.CheckBudget(results, budget)
+
+	section := report.Section{Title: "Multi-region latency", Status: report.Pass, Table: table,
+		Summary: fmt.Sprintf("%d samples per region of %s, p95 budget %v", samples, url, budget)}
+	if len(outliers) > 0 {
+		section.Status = report.Warn
+		section.Summary += fmt.Sprintf("; %v over 3x the fastest region, check CDN edge caching", outliers)
+	}
+	if budgetErr != nil {
+		section.Status = report.Fail
+	}
+	harnessReport.Add(section)
+
+	assert.NoError(t, budgetErr, "Every region should meet the TTFB budget")
+	if len(outliers) > 0 {
+		t.Logf("Regions far slower than the fastest: %v", outliers)
+	}
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/linkcheck"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProfileLinks fetches the LinkedIn, GitHub and credential
+// verification links on the built home page and fails on any that are
+// dead, since a dead credential link is worse than none. Hosts that wall
+// off automated clients, as LinkedIn does, are logged as warnings to check
+// by hand, as are links robots.txt disallows. Responses are cached
+// between runs and requests are rate limited per host (see
+// externalClient). Enable with OSYRAA_LINKS=1.
+func TestProfileLinks(t *testing.T) {
+	requireNetwork(t)
+	if os.Getenv("OSYRAA_LINKS") != "1" {
+		t.Skip("set OSYRAA_LINKS=1 to check profile and credential links")
+	}
+	data, err := os.ReadFile(filepath.Join(publicDir(t), "index.html"))
+	require.NoError(t, err)
+	doc, err := match.ParseBytes(data)
+	require.NoError(t, err, "The home page should parse as HTML")
+	links := linkcheck.Find(doc)
+	if len(links) == 0 {
+		t.Skip("The home page has no profile or credential links")
+	}
+
+	client := externalClient(20 * time.Second)
+	section := report.Section{Title: "Profile links", Status: report.Pass,
+		Table: &report.Table{Header: []string{"Link", "Kind", "Outcome", "Status"}}}
+	for _, link := range links {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		r := linkcheck.Check(ctx, client, link)
+		cancel()
+
+		switch r.Outcome {
+		case linkcheck.Dead:
+			t.Errorf("Dead %s", r)
+			section.Status = report.Fail
+		case linkcheck.Blocked, linkcheck.Unreachable, linkcheck.Disallowed:
+			t.Logf("Warning: %s; check it in a browser", r)
+			if section.Status == report.Pass {
+				section.Status = report.Warn
+			}
+		}
+		section.Table.Rows = append(section.Table.Rows,
+			[]string{link.URL, link.Kind, r.Outcome, fmt.Sprint(r.Status)})
+	}
+	section.Summary = fmt.Sprintf("Checked %d profile and credential links", len(links))
+	harnessReport.Add(section)
+}
+
+// TestSiteLinks checks every link on every page of the build, orphan
+// pages included: anchors, images, stylesheets, scripts and media. Links
+// within the site must resolve to a file in the build. With
+// OSYRAA_LINKS=1, and not offline, external links must also answer below
+// 400, fetched OSYRAA_LINKS_CONCURRENCY (default 8) at a time with
+// OSYRAA_LINKS_RETRIES (default 2) retries for 429s, 5xx and network
+// errors. OSYRAA_LINKS_ALLOW lists comma-separated regexps of URLs never
+// checked.
+func TestSiteLinks(t *testing.T) {
+	dir := publicDir(t)
+	o := linkcheck.SiteOptions{BaseURL: siteBaseURL(t).String(), Concurrency: 8, Retries: 2}
+	for _, expr := range strings.Split(os.Getenv("OSYRAA_LINKS_ALLOW"), ",") {
+		if expr = strings.TrimSpace(expr); expr != "" {
+			re, err := regexp.Compile(expr)
+			require.NoError(t, err, "OSYRAA_LINKS_ALLOW should hold valid regexps")
+			o.Allow = append(o.Allow, re)
+		}
+	}
+	for name, v := range map[string]*int{"OSYRAA_LINKS_CONCURRENCY": &o.Concurrency, "OSYRAA_LINKS_RETRIES": &o.Retries} {
+		if s := os.Getenv(name); s != "" {
+			n, err := strconv.Atoi(s)
+			require.NoError(t, err, "%s should be a number", name)
+			*v = n
+		}
+	}
+	if os.Getenv("OSYRAA_LINKS") == "1" && !*offline {
+		o.Client = externalClient(20 * time.Second)
+	} else {
+		t.Log("Checking links within the site only; set OSYRAA_LINKS=1 to fetch external links too")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	result, err := linkcheck.Site(ctx, dir, o)
+	require.NoError(t, err, "Failed to read the build")
+	require.NotZero(t, result.Pages, "The build should contain HTML pages")
+
+	section := report.Section{Title: "Site links", Status: report.Pass,
+		Table: &report.Table{Header: []string{"Page", "Link", "Outcome"}}}
+	for _, b := range result.Broken {
+		t.Errorf("Broken link %s", b)
+		section.Status = report.Fail
+		section.Table.Rows = append(section.Table.Rows, []string{b.Page, b.URL, b.Reason})
+	}
+	for _, w := range result.Warnings {
+		t.Logf("Warning: %s; check it in a browser", w)
+		if section.Status == report.Pass {
+			section.Status = report.Warn
+		}
+		section.Table.Rows = append(section.Table.Rows, []string{w.Page, w.URL, w.Reason})
+	}
+	section.Summary = fmt.Sprintf("%d pages, %d internal links, %d external URLs, %d allowed; %d broken, %d warnings",
+		result.Pages, result.Internal, result.External, result.Allowed, len(result.Broken), len(result.Warnings))
+	t.Log(section.Summary)
+	harnessReport.Add(section)
+}
@@ -0,0 +1,467 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/cleanup"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/config"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dockerrun"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/engine"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/httpclient"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/imagebuild"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/netcache"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/polite"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/ready"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/runid"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/tracing"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// harnessReport collects sections from tests that produce more than
+// pass/fail; it is written to OSYRAA_REPORT_DIR (default reports/) when any
+// test added to it
+var harnessReport = report.New("Osyraa test report", reportDir())
+
+func reportDir() string {
+	if dir := os.Getenv("OSYRAA_REPORT_DIR"); dir != "" {
+		return dir
+	}
+	return "reports"
+}
+
+// runID names the resources this process creates, such as the Hugo
+// output directory and the image tag, so concurrent runs on one host
+// (parallel CI shards, or a local run beside CI) never share them. It is
+// also in the harness's log lines, the report's file name and every HTTP
+// request the tests send. OSYRAA_RUN_ID sets it, e.g. to the CI job ID.
+var runID = runid.New(os.Getenv("OSYRAA_RUN_ID"))
+
+// logf prints a harness message tagged with the run ID
+func logf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "osyraa run=%s: "+format+"\n", append([]any{runID}, args...)...)
+}
+
+// settings are the site-specific values the suites check against: the
+// defaults, then the suite section of osyraa.yaml (or the file
+// OSYRAA_CONFIG names), then OSYRAA_* variables. TestMain loads them.
+var settings = config.DefaultSuite()
+
+// readyOptions are the polling options for waits on the site under test,
+// bounded by the ready timeout setting
+func readyOptions() ready.Options {
+	o := ready.DefaultOptions()
+	o.Timeout = settings.Timeouts.Ready
+	return o
+}
+
+// newTarget is battery.NewTarget expecting the configured author and
+// bounding requests by the HTTP timeout setting
+func newTarget(baseURL string) *battery.Target {
+	target := battery.NewTarget(baseURL)
+	target.Expect.Name = settings.Author
+	target.Client.Timeout = settings.Timeouts.HTTP
+	return target
+}
+
+// imageTag is the tag this run builds the site image under
+func imageTag() string {
+	return settings.Image + ":test-" + runID
+}
+
+// containerEngine finds the Docker-compatible engine once per run and
+// exports it as DOCKER_HOST, so the Docker client and the docker, kind and
+// ZAP commands the tests run all talk to the same one
+var containerEngine = sync.OnceValues(func() (*engine.Runtime, error) {
+	rt, cli, err := engine.Detect(context.Background(), engine.HostEnv())
+	if err != nil {
+		return nil, err
+	}
+	cli.Close()
+	if os.Getenv("DOCKER_HOST") == "" {
+		os.Setenv("DOCKER_HOST", rt.Host)
+	}
+	logf("container engine %s", rt)
+	return rt, nil
+})
+
+// runLabels mark the containers, images and directories this run
+// creates, so `osyraa clean` can find them if the run never removes them
+var runLabels = cleanup.Labels(runID)
+
+// buildImage builds an image through the engine's API, labelled with
+// this run, so builds work where the engine is reachable but the docker
+// CLI isn't installed. A failed build returns an *imagebuild.BuildError
+// naming the failed step with the end of its output.
+func buildImage(ctx context.Context, buildContext io.Reader, dockerfile, tag string) (imagebuild.Result, error) {
+	return buildImageFor(ctx, buildContext, dockerfile, tag, "")
+}
+
+// buildImageFor builds for platform, such as linux/arm64, or for the
+// engine's own platform when it is empty
+func buildImageFor(ctx context.Context, buildContext io.Reader, dockerfile, tag, platform string) (imagebuild.Result, error) {
+	if _, err := containerEngine(); err != nil {
+		return imagebuild.Result{}, err
+	}
+	cli, err := newDockerClient()
+	if err != nil {
+		return imagebuild.Result{}, err
+	}
+	defer cli.Close()
+	ctx, span := tracing.Start(ctx, "image build", trace.WithAttributes(attribute.String("osyraa.image", tag)))
+	result, err := imagebuild.Build(ctx, cli, buildContext, imagebuild.Options{
+		Tags:       []string{tag},
+		Labels:     runLabels,
+		Dockerfile: dockerfile,
+		Platform:   platform,
+	})
+	tracing.End(span, err)
+	return result, err
+}
+
+// buildSiteImage builds the site image from the repository root
+func buildSiteImage(ctx context.Context, tag string) (imagebuild.Result, error) {
+	return buildSiteImageFor(ctx, tag, "")
+}
+
+// buildSiteImageFor builds the site image for platform
+func buildSiteImageFor(ctx context.Context, tag, platform string) (imagebuild.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, settings.Timeouts.Build)
+	defer cancel()
+	buildContext, err := imagebuild.Dir("..", "Containerfile")
+	if err != nil {
+		return imagebuild.Result{}, err
+	}
+	defer buildContext.Close()
+	return buildImageFor(ctx, buildContext, "Containerfile", tag, platform)
+}
+
+// site is the run's one build of the site, shared by HugoTestSuite and
+// the checks that read a built site, and made by whichever asks first
+var site struct {
+	once      sync.Once
+	publicDir string
+	output    []byte
+	err       error
+}
+
+// buildSite builds the site once per run, into a directory of the run's
+// own that is removed when the run ends, and returns its public/ and
+// Hugo's output. Concurrent runs never read each other's build.
+func buildSite(ctx context.Context) (string, []byte, error) {
+	site.once.Do(func() {
+		site.publicDir, site.output, site.err = buildSiteOnce(ctx)
+	})
+	return site.publicDir, site.output, site.err
+}
+
+func buildSiteOnce(ctx context.Context) (string, []byte, error) {
+	// Not closed by any suite: TestMain runs what is left at the end
+	cleanups := teardown.Scope()
+	docker, err := newDockerClient()
+	if err != nil {
+		return "", nil, err
+	}
+	cleanups.Add("Docker client", func(context.Context) error { return docker.Close() })
+	workDir, err := os.MkdirTemp("", "osyraa-"+runID+"-site-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanups.Add("build directory "+workDir, func(context.Context) error { return os.RemoveAll(workDir) })
+	if err := cleanup.MarkDir(workDir, runLabels); err != nil {
+		return "", nil, err
+	}
+	src, err := filepath.Abs("..")
+	if err != nil {
+		return "", nil, err
+	}
+	output, err := runHugo(ctx, docker, cleanups, "", src, workDir)
+	return filepath.Join(workDir, "public"), output, err
+}
+
+// publicDir is the built site the checks outside HugoTestSuite inspect:
+// OSYRAA_PUBLIC_DIR, to check a build made elsewhere, or the run's own
+// build. A build that fails skips the check; HugoTestSuite reports it.
+func publicDir(t *testing.T) string {
+	t.Helper()
+	if dir := os.Getenv("OSYRAA_PUBLIC_DIR"); dir != "" {
+		return dir
+	}
+	dir, _, err := buildSite(runCtx)
+	if err != nil {
+		t.Skipf("the site didn't build: %v", err)
+	}
+	return dir
+}
+
+// crawlScope bounds the crawl-based checks, for when the site is too
+// large to crawl whole on every run. OSYRAA_CRAWL_MAX_DEPTH and
+// OSYRAA_CRAWL_MAX_PAGES cap the depth and page count,
+// OSYRAA_CRAWL_INCLUDE and OSYRAA_CRAWL_EXCLUDE take comma-separated path
+// globs, and OSYRAA_CRAWL_ASSETS=skip fetches pages only.
+func crawlScope(t *testing.T) crawl.Scope {
+	t.Helper()
+	var scope crawl.Scope
+	var err error
+	for name, n := range map[string]*int{
+		"OSYRAA_CRAWL_MAX_DEPTH": &scope.MaxDepth,
+		"OSYRAA_CRAWL_MAX_PAGES": &scope.MaxPages,
+	} {
+		if v := os.Getenv(name); v != "" {
+			*n, err = strconv.Atoi(v)
+			require.NoError(t, err, "%s should be a number", name)
+		}
+	}
+	scope.Include, err = crawl.ParsePatterns(os.Getenv("OSYRAA_CRAWL_INCLUDE"))
+	require.NoError(t, err, "OSYRAA_CRAWL_INCLUDE")
+	scope.Exclude, err = crawl.ParsePatterns(os.Getenv("OSYRAA_CRAWL_EXCLUDE"))
+	require.NoError(t, err, "OSYRAA_CRAWL_EXCLUDE")
+	scope.SkipAssets = os.Getenv("OSYRAA_CRAWL_ASSETS") == "skip"
+	return scope
+}
+
+// newCrawler creates a crawler within crawlScope
+func newCrawler(t *testing.T, f crawl.Fetcher, visitors ...crawl.Visitor) *crawl.Crawler {
+	c := crawl.New(f, visitors...)
+	c.Scope = crawlScope(t)
+	return c
+}
+
+// offline skips every check that needs the internet, such as external
+// links, DNS, TLS and deployed sites, and keeps Docker from pulling images,
+// while the local build, container and content checks still run. Pass
+// -offline to the test binary (go test . -offline) or set OSYRAA_OFFLINE=1.
+var offline = flag.Bool("offline", os.Getenv("OSYRAA_OFFLINE") == "1", "skip checks that need external network access")
+
+// a11yAudit turns on the accessibility audit, which renders every page in
+// headless Chrome to measure contrast and so is slower than the other
+// browser checks. Pass -a11y to the test binary or set OSYRAA_A11Y=1.
+var a11yAudit = flag.Bool("a11y", os.Getenv("OSYRAA_A11Y") == "1", "audit every page against WCAG 2.1 AA in headless Chrome")
+
+// requireNetwork skips the test in offline mode
+func requireNetwork(t testing.TB) {
+	t.Helper()
+	if *offline {
+		t.Skip("offline: this check needs external network access")
+	}
+}
+
+// runOptions names a tool container the tests run and labels it with the
+// run's labels. Its pull policy is never in offline mode, so a missing
+// image fails at once instead of timing out on the registry, and pulls
+// missing images otherwise.
+func runOptions(name string) dockerrun.RunOptions {
+	o := dockerrun.RunOptions{Name: name, Labels: runLabels}
+	if *offline {
+		o.Pull = dockerrun.PullNever
+	}
+	return o
+}
+
+// externalCache keeps third-party responses between runs: in
+// OSYRAA_CACHE_DIR (default the user cache directory) for OSYRAA_CACHE_TTL
+// (default 24h). OSYRAA_NO_CACHE=1 turns it off, e.g. in CI where every
+// run should see the hosts as they are.
+func externalCache() *netcache.Cache {
+	if os.Getenv("OSYRAA_NO_CACHE") == "1" {
+		return nil
+	}
+	dir := os.Getenv("OSYRAA_CACHE_DIR")
+	if dir == "" {
+		dir = netcache.DefaultDir()
+	}
+	cache := netcache.New(dir)
+	if v := os.Getenv("OSYRAA_CACHE_TTL"); v != "" {
+		if ttl, err := time.ParseDuration(v); err == nil {
+			cache.TTL = ttl
+			cache.ErrorTTL = min(cache.ErrorTTL, ttl)
+		}
+	}
+	return cache
+}
+
+// politeTransport spaces out requests to each third-party host by
+// OSYRAA_HOST_INTERVAL (default 1s) and honours robots.txt unless
+// OSYRAA_IGNORE_ROBOTS=1. OSYRAA_USER_AGENT replaces the checks' own
+// User-Agent, e.g. to identify the harness with a contact URL.
+func politeTransport() *polite.Transport {
+	tr := polite.New(nil)
+	tr.UserAgent = os.Getenv("OSYRAA_USER_AGENT")
+	tr.IgnoreRobots = os.Getenv("OSYRAA_IGNORE_ROBOTS") == "1"
+	if v := os.Getenv("OSYRAA_HOST_INTERVAL"); v != "" {
+		if interval, err := time.ParseDuration(v); err == nil {
+			tr.Interval = interval
+		}
+	}
+	return tr
+}
+
+// externalClient is the HTTP client for checks against third-party hosts.
+// Repeat requests are answered from externalCache; the rest go out
+// through politeTransport.
+func externalClient(timeout time.Duration) *http.Client {
+	var transport http.RoundTripper = politeTransport()
+	if cache := externalCache(); cache != nil {
+		transport = cache.Transport(transport)
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// teardown holds every resource the run has created and not yet removed.
+// Suites register what they create as they create it and run their scope
+// in TearDownSuite, which testify reaches even when a test panics; if the
+// run is interrupted, TestMain's signal handler runs whatever is left.
+var teardown = cleanup.NewManager()
+
+// newDockerClient opens an engine client from the environment. It does
+// not connect until first used, so suites that may not need Docker can
+// hold one all the same.
+func newDockerClient() (*client.Client, error) {
+	return client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+}
+
+// removeContainer force-removes a container by name or ID; one already
+// gone is not an error
+func removeContainer(ctx context.Context, cli *client.Client, name string) error {
+	err := cli.ContainerRemove(ctx, name, container.RemoveOptions{Force: true})
+	if client.IsErrNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// removeImage force-removes an image by tag or ID; one already gone is
+// not an error
+func removeImage(ctx context.Context, cli *client.Client, tag string) error {
+	_, err := cli.ImageRemove(ctx, tag, types.ImageRemoveOptions{Force: true})
+	if client.IsErrNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// runCtx carries the span of the whole run, which suite spans nest under
+var runCtx = context.Background()
+
+// suiteContext starts a span for a suite's test function, ended when it
+// finishes, and returns the context carrying it. Suites keep it as their
+// ctx, so the requests, builds and checks they run nest under it.
+func suiteContext(t *testing.T) context.Context {
+	ctx, span := tracing.Start(runCtx, t.Name())
+	t.Cleanup(func() {
+		var err error
+		if t.Failed() {
+			err = errors.New("failed")
+		}
+		tracing.End(span, err)
+	})
+	return ctx
+}
+
+// suiteStats is testify's suite timing, named here because the suites'
+// method receivers shadow the suite package
+type suiteStats = suite.SuiteInformation
+
+// traceTests records a span per test of a suite, under the suite's span,
+// from the timings testify collects. The spans are created once the
+// tests are done, so a test's own requests nest under the suite instead.
+func traceTests(ctx context.Context, stats *suiteStats) {
+	for name, test := range stats.TestStats {
+		_, span := tracing.Start(ctx, name, trace.WithTimestamp(test.Start))
+		var err error
+		if !test.Passed {
+			err = errors.New("failed")
+		}
+		tracing.End(span, err, trace.WithTimestamp(test.End))
+	}
+}
+
+func TestMain(m *testing.M) {
+	logf("started")
+	flag.Parse()
+	var err error
+	if settings, err = config.LoadSuite(config.Path(), os.Getenv); err != nil {
+		logf("settings: %v", err)
+		os.Exit(2)
+	}
+	siteClient.Timeout = settings.Timeouts.HTTP
+	harnessReport.Run = runID
+	// Spans go to the OTLP endpoint in OTEL_EXPORTER_OTLP_ENDPOINT, if any
+	shutdown, err := tracing.Setup(context.Background(), "osyraa-tests", attribute.String("osyraa.run", runID))
+	if err != nil {
+		logf("tracing: %v", err)
+		shutdown = func(context.Context) error { return nil }
+	}
+	var runSpan trace.Span
+	runCtx, runSpan = tracing.Start(tracing.FromEnv(context.Background()), "osyraa test run",
+		trace.WithAttributes(attribute.String("osyraa.run", runID)))
+	// Every client without a transport of its own shares one connection
+	// pool, capped per host
+	httpOptions, err := httpclient.FromEnv()
+	if err != nil {
+		logf("%v", err)
+	}
+	restoreTransport := httpclient.Install(httpOptions)
+	// Requests to the site under test and to third parties carry the run
+	// ID, so they can be found in access logs
+	restore := runid.Install(runID)
+	if tracing.Enabled() {
+		http.DefaultTransport = tracing.Transport(http.DefaultTransport)
+	}
+	// An interrupted run still exports its spans, which show where it was
+	stop := teardown.HandleSignals(time.Minute, func(code int) {
+		tracing.End(runSpan, fmt.Errorf("interrupted, exit code %d", code))
+		flush, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		shutdown(flush)
+		cancel()
+		os.Exit(code)
+	})
+	code := m.Run()
+	stop()
+	// Suites run their own teardown; this catches anything registered
+	// outside one
+	if err := teardown.RunAll(context.Background()); err != nil {
+		logf("teardown: %v", err)
+	}
+	restore()
+	restoreTransport()
+	var runErr error
+	if code != 0 {
+		runErr = fmt.Errorf("exit code %d", code)
+	}
+	tracing.End(runSpan, runErr)
+	flush, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := shutdown(flush); err != nil {
+		logf("exporting spans: %v", err)
+	}
+	cancel()
+	if len(harnessReport.Sections()) > 0 {
+		path, err := harnessReport.WriteHTML()
+		if err != nil {
+			logf("writing report: %v", err)
+			code = 1
+		} else {
+			logf("report written to %s", path)
+		}
+	}
+	os.Exit(code)
+}
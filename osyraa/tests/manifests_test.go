@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitopsDir holds everything ArgoCD syncs to the cluster
+var gitopsDir = filepath.Join("..", "..", "gitops")
+
+// overlayInvariants are the per-environment rules, keyed by the
+// environment's directory name under gitops/applications. An environment
+// added there fails TestApplicationEnvironments until it has an entry.
+var overlayInvariants = map[string]kube.Invariants{
+	"dev": {MinReplicas: 1, MaxReplicas: 2, RequireLimits: true, Container: "resume"},
+}
+
+// expectedImage returns the image reference manifests must deploy, taken
+// from OSYRAA_IMAGE when the caller knows which image was just built
+func expectedImage() string {
+	return os.Getenv("OSYRAA_IMAGE")
+}
+
+// TestApplicationEnvironments renders every environment ArgoCD syncs from
+// gitops/applications, with kustomize when the environment has a
+// kustomization and as plain manifests otherwise, validates it against
+// the Kubernetes schemas and checks the environment's invariants. Schema
+// validation is a subtest of its own, skipped without kubeconform or
+// offline, since kubeconform downloads the schemas.
+func TestApplicationEnvironments(t *testing.T) {
+	envs, err := kube.Environments(filepath.Join(gitopsDir, "applications"))
+	require.NoError(t, err, "Failed to list the environments under gitops/applications")
+	require.NotEmpty(t, envs, "gitops/applications should hold an environment per directory")
+
+	for _, env := range envs {
+		env := env
+		t.Run(env.Name, func(t *testing.T) {
+			inv, ok := overlayInvariants[env.Name]
+			require.True(t, ok, "No invariants defined for environment %s; add them to overlayInvariants", env.Name)
+			if env.Kustomized {
+				if _, err := exec.LookPath("kubectl"); err != nil {
+					t.Skip("kubectl not found in PATH to build the kustomization")
+				}
+			}
+			ctx := context.Background()
+			rendered, objs, err := kube.Render(ctx, env)
+			require.NoError(t, err, "Rendering the environment should succeed")
+			require.NotEmpty(t, objs, "The environment should deploy something")
+
+			inv.Image = expectedImage()
+			assert.NoError(t, kube.CheckInvariants(objs, inv), "Environment should satisfy its invariants")
+
+			t.Run("schemas", func(t *testing.T) {
+				if !kube.SchemaValidatorAvailable() {
+					t.Skip("kubeconform not found in PATH to validate the schemas")
+				}
+				requireNetwork(t)
+				assert.NoError(t, kube.ValidateSchemas(ctx, rendered, os.Getenv("OSYRAA_K8S_VERSION")),
+					"Rendered manifests should match the Kubernetes schemas")
+			})
+		})
+	}
+}
+
+// expectedRoutes are the public hosts and paths that must reach the site
+var expectedRoutes = []kube.RouteExpectation{
+	{Host: "resume.princetonstrong.online", Path: "/", Service: "resume"},
+}
+
+// TestIngressRoutes verifies the Ingress sends the public host to the
+// resume service and that its TLS secret will actually be provisioned
+func TestIngressRoutes(t *testing.T) {
+	objs, err := kube.ReadManifestDir(gitopsDir)
+	require.NoError(t, err, "Failed to read GitOps manifests")
+
+	assert.NoError(t, kube.CheckRoutes(objs, expectedRoutes), "Ingress routing should be consistent")
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dockerrun"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/platform"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultPlatforms are the architectures the site is deployed to
+const defaultPlatforms = "linux/amd64,linux/arm64"
+
+// TestMultiArch builds the image for each platform in OSYRAA_PLATFORMS
+// (default linux/amd64,linux/arm64; opt-in with OSYRAA_MULTIARCH=1) and
+// runs the HTTP battery against a container of each. A platform that is
+// neither the engine's own nor covered by a QEMU emulator is skipped with
+// the command that registers one.
+func (suite *DockerTestSuite) TestMultiArch() {
+	t := suite.T()
+	if os.Getenv("OSYRAA_MULTIARCH") != "1" {
+		t.Skip("set OSYRAA_MULTIARCH=1 to build and test the image for each platform")
+	}
+	list := os.Getenv("OSYRAA_PLATFORMS")
+	if list == "" {
+		list = defaultPlatforms
+	}
+	platforms, err := platform.ParseList(list)
+	require.NoError(t, err, "OSYRAA_PLATFORMS")
+	info, err := suite.client.Info(suite.ctx)
+	require.NoError(t, err, "Failed to read the engine's info")
+	support := platform.Detect(suite.ctx, platform.Native(info.OSType, info.Architecture), suite.binfmt, "/proc/sys/fs/binfmt_misc")
+	t.Logf("Engine runs %s natively and %d platforms under emulation (from %s)", support.Native, len(support.Emulated), support.Source)
+
+	table := &report.Table{Header: []string{"Platform", "Runs", "Result"}}
+	section := report.Section{Title: "Multi-architecture images", Status: report.Pass, Table: table}
+	var tested, skipped []string
+	for _, p := range platforms {
+		how, ok := support.Runs(p)
+		if !ok {
+			skipped = append(skipped, p.String())
+			table.Rows = append(table.Rows, []string{p.String(), "no emulator", "skipped"})
+			suite.Run(p.String(), func() {
+				suite.T().Skipf("the engine can't run %s; register QEMU with: %s", p, platform.InstallHint(p))
+			})
+			continue
+		}
+		passed := suite.Run(p.String(), func() { suite.checkPlatform(p) })
+		result := "passed"
+		if !passed {
+			result = "failed"
+			section.Status = report.Fail
+		}
+		tested = append(tested, p.String())
+		table.Rows = append(table.Rows, []string{p.String(), how, result})
+	}
+	section.Summary = fmt.Sprintf("Built and tested %s", strings.Join(tested, ", "))
+	if len(tested) == 0 {
+		section.Summary = "No platform could be run"
+	}
+	if len(skipped) > 0 {
+		section.Summary += fmt.Sprintf("; skipped %s with no emulator", strings.Join(skipped, ", "))
+		if section.Status == report.Pass {
+			section.Status = report.Warn
+		}
+	}
+	harnessReport.Add(section)
+}
+
+// binfmt runs platform.BinfmtImage on the engine and returns its report
+func (suite *DockerTestSuite) binfmt(ctx context.Context) ([]byte, error) {
+	name := "osyraa-binfmt-" + runID
+	probe := suite.cleanups.Add("binfmt container "+name, func(ctx context.Context) error { return removeContainer(ctx, suite.client, name) })
+	defer probe.Run(context.Background())
+	// Privileged, as it mounts binfmt_misc to read it
+	return tracing.RunContainer(ctx, "binfmt", suite.client, dockerrun.Container{
+		Image: platform.BinfmtImage, Privileged: true, RunOptions: runOptions(name),
+	})
+}
+
+// checkPlatform builds the image for p, confirms it was built for and
+// runs as p, and runs the battery against a container of it
+func (suite *DockerTestSuite) checkPlatform(p platform.Platform) {
+	t := suite.T()
+	tag := suite.imageTag + "-" + p.Tag()
+	suite.cleanups.Add("image "+tag, func(ctx context.Context) error { return removeImage(ctx, suite.client, tag) })
+	_, err := buildSiteImageFor(suite.ctx, tag, p.String())
+	require.NoError(t, err, "Docker build for %s failed", p)
+
+	image, _, err := suite.client.ImageInspectWithRaw(suite.ctx, tag)
+	require.NoError(t, err, "Failed to inspect the %s image", p)
+	assert.Equal(t, p.OS, image.Os, "The image should be built for %s", p)
+	assert.Equal(t, p.Architecture, image.Architecture, "The image should be built for %s", p)
+	if p.Variant != "" {
+		assert.Equal(t, p.Variant, image.Variant, "The image should be built for %s", p)
+	}
+
+	id, baseURL := suite.startImage(tag, p.OCI(), &container.HostConfig{})
+	machine, err := suite.outputIn(suite.ctx, id, []string{"uname", "-m"})
+	require.NoError(t, err, "Failed to run uname in the %s container", p)
+	assert.Equal(t, p.Machine(), strings.TrimSpace(machine), "The container should run as %s", p)
+	for _, result := range battery.Run(suite.ctx, newTarget(baseURL), battery.Default()) {
+		assert.NoError(t, result.Err, "Check %s should pass on %s", result.Check, p)
+	}
+}
@@ -1,21 +1,63 @@
 package tests
 
 import (
+	"archive/tar"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/chromedp/chromedp"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/ats"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/browser"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/cleanup"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/compression"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/contact"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/csp"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/cspreport"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/fonts"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/golden"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/hardening"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/headerpolicy"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/htmlvalid"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/httpfuzz"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/hugobuild"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/kube"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/mixedcontent"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/parity"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/perfbudget"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/ready"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/resume"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/schedule"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/securitytxt"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/snapshot"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/sri"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/vcard"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/zap"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -24,7 +66,15 @@ import (
 // HugoTestSuite tests Hugo build functionality
 type HugoTestSuite struct {
 	suite.Suite
+	ctx context.Context
+	// workDir holds the suite's own builds, such as the time-travel ones;
+	// publicDir is the run's shared build of the site (see buildSite)
+	workDir   string
 	publicDir string
+	site      *crawl.Result
+	cleanups  *cleanup.Scope
+	// client removes the containers of a Docker-backed Hugo build
+	client *client.Client
 }
 
 // DockerTestSuite tests Docker build and container functionality
@@ -32,38 +82,280 @@ type DockerTestSuite struct {
 	suite.Suite
 	client      *client.Client
 	containerID string
-	imageTag    string
-	ctx         context.Context
+	// siteStop removes the suite's container ahead of teardown
+	siteStop  *cleanup.Step
+	imageTag  string
+	ctx       context.Context
+	startedAt time.Time
+	cleanups  *cleanup.Scope
+	// siteAddr is the loopback address the engine published the
+	// container's site port on, and siteURL the site there. The port is
+	// ephemeral, so parallel runs on one host don't collide.
+	siteAddr string
+	siteURL  string
 }
 
-// SetupSuite runs once before all Hugo tests
+// SetupSuite runs once before all Hugo tests. The build goes to a
+// directory of this run's own, so concurrent runs never share output.
 func (suite *HugoTestSuite) SetupSuite() {
-	suite.publicDir = filepath.Join("..", "public")
+	suite.ctx = suiteContext(suite.T())
+	suite.cleanups = teardown.Scope()
+	var err error
+	suite.client, err = newDockerClient()
+	require.NoError(suite.T(), err, "Failed to create Docker client")
+	// Registered first so it runs last, after the removals that use it
+	suite.cleanups.Add("Docker client", func(context.Context) error { return suite.client.Close() })
+	suite.workDir, err = os.MkdirTemp("", "osyraa-"+runID+"-")
+	require.NoError(suite.T(), err, "Failed to create the build directory")
+	workDir := suite.workDir
+	suite.cleanups.Add("build directory "+workDir, func(context.Context) error { return os.RemoveAll(workDir) })
+	require.NoError(suite.T(), cleanup.MarkDir(suite.workDir, runLabels))
+
+	// testify runs the tests in name order, so the build they all read
+	// is made here rather than by one of them. It is the run's shared
+	// build, which the checks outside the suite read too.
+	var output []byte
+	suite.publicDir, output, err = buildSite(suite.ctx)
+	require.NoError(suite.T(), err, "Hugo build failed: %s", string(output))
 }
 
 // TearDownSuite cleans up after all Hugo tests
 func (suite *HugoTestSuite) TearDownSuite() {
-	// Clean up build artifacts
-	os.RemoveAll(suite.publicDir)
-	os.RemoveAll(filepath.Join("..", "resources"))
-	os.RemoveAll(filepath.Join("..", ".hugo_build.lock"))
+	if err := suite.cleanups.Close(context.Background()); err != nil {
+		suite.T().Log("Teardown:", err)
+	}
 }
 
-// TestHugoBuild tests if Hugo can build successfully
+// HandleStats records a span per test once the suite is done
+func (suite *HugoTestSuite) HandleStats(_ string, stats *suiteStats) {
+	traceTests(suite.ctx, stats)
+}
+
+// TestHugoBuild checks the build SetupSuite made wrote the site
 func (suite *HugoTestSuite) TestHugoBuild() {
 	t := suite.T()
 
-	// Run Hugo build in Docker
-	cmd := exec.Command("docker", "run", "--rm",
-		"-v", fmt.Sprintf("%s:/src", filepath.Join("..", "..")),
-		"klakegg/hugo:0.111.3-alpine",
-		"hugo", "--minify")
+	assert.DirExists(t, suite.publicDir, "public directory should exist after build")
+	assert.FileExists(t, filepath.Join(suite.publicDir, "index.html"), "The build should render the home page")
+}
 
-	output, err := cmd.CombinedOutput()
-	require.NoError(t, err, "Hugo build failed: %s", string(output))
+// hugo builds the site at src with the configured Hugo backend, into
+// dest/public with generated resources and cache beside it, and returns
+// Hugo's output. The source is only read and no build lock is taken, so
+// parallel builds of one checkout are safe. suffix tells apart the
+// containers of one run.
+func (suite *HugoTestSuite) hugo(suffix, src, dest string, flags ...string) ([]byte, error) {
+	return runHugo(suite.ctx, suite.client, suite.cleanups, suffix, src, dest, flags...)
+}
 
-	// Verify public directory was created
-	assert.DirExists(t, suite.publicDir, "public directory should exist after build")
+// runHugo is HugoTestSuite.hugo for a caller outside the suite, which
+// registers the build's container with cleanups
+func runHugo(ctx context.Context, docker *client.Client, cleanups *cleanup.Scope, suffix, src, dest string, flags ...string) ([]byte, error) {
+	builder, err := hugoBuilder(ctx, docker, suffix)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := builder.(hugobuild.Container); ok {
+		// An interrupted run exits before the build removes its container
+		build := cleanups.Add("Hugo container "+c.Name, func(ctx context.Context) error { return removeContainer(ctx, docker, c.Name) })
+		defer build.Run(context.Background())
+	}
+	return builder.Run(ctx, hugobuild.Build{Source: src, Dest: dest, Flags: flags})
+}
+
+// hugoBuilder is the backend settings.Hugo chooses: an image (the
+// suite's Hugo image, or hugomods for the pinned version), a local hugo,
+// or the pinned release, downloaded once into the user cache directory
+// and only ever taken from there offline
+func hugoBuilder(ctx context.Context, docker *client.Client, suffix string) (hugobuild.Builder, error) {
+	h := settings.Hugo
+	switch h.Backend {
+	case hugobuild.Local:
+		return hugobuild.Binary{Path: h.Binary, Version: h.Version}, nil
+	case hugobuild.Release:
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		d := hugobuild.Download{Version: h.Version, Checksum: h.Checksum, CacheDir: filepath.Join(cacheDir, "osyraa", "hugo"), Offline: *offline}
+		binary, err := d.Install(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Hugo %s release: %w", h.Version, err)
+		}
+		return hugobuild.Binary{Path: binary, Version: h.Version}, nil
+	}
+
+	rt, err := containerEngine()
+	if err != nil {
+		return nil, fmt.Errorf("Hugo builds in a container: %w", err)
+	}
+	// Named, so an interrupted run can remove it
+	c := hugobuild.Container{Docker: docker, Image: settings.HugoImage, RunOptions: runOptions("osyraa-hugo-" + runID + suffix)}
+	if h.Backend == hugobuild.Hugomods {
+		c.Image = hugobuild.HugomodsImage(h.Version)
+	}
+	if runtime.GOOS == "linux" && !rt.Rootless {
+		// Write files as the caller so TearDownSuite can remove them.
+		// Docker Desktop maps ownership itself, and rootless engines
+		// already run as the caller.
+		c.User = fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	}
+	return c, nil
+}
+
+// crawlSite walks public/ once and caches the result for every check
+func (suite *HugoTestSuite) crawlSite() *crawl.Result {
+	t := suite.T()
+	if suite.site == nil {
+		require.DirExists(t, suite.publicDir, "public directory should exist before crawling")
+		site, err := crawl.DirScope(context.Background(), suite.publicDir, crawlScope(t))
+		require.NoError(t, err, "Crawling the generated site should succeed")
+		suite.site = site
+	}
+	return suite.site
+}
+
+// TestSiteCrawl verifies every internally referenced resource exists
+func (suite *HugoTestSuite) TestSiteCrawl() {
+	t := suite.T()
+	site := suite.crawlSite()
+
+	assert.NotEmpty(t, site.HTML(), "Crawl should reach at least one HTML page")
+	for _, page := range site.Missing {
+		t.Errorf("Missing resource %s (referenced from %s)", page.URL.Path, page.Referrer)
+	}
+	t.Logf("Crawled %d resources in %v", len(site.Pages), site.Elapsed)
+	if len(site.Skipped) > 0 {
+		t.Logf("Left out %d URLs outside the crawl scope", len(site.Skipped))
+	}
+}
+
+// TestCanonicalLinks requires internal links to use each page's canonical
+// form, with a trailing slash for pages and none for files, so no link
+// costs a redirect
+func (suite *HugoTestSuite) TestCanonicalLinks() {
+	t := suite.T()
+
+	tree, err := deploy.LocalTree(suite.publicDir)
+	require.NoError(t, err, "Failed to hash the build")
+	for _, p := range deploy.NonCanonicalLinks(suite.crawlSite().Pages, tree) {
+		t.Error(p)
+	}
+}
+
+// TestSiteArtifact packs the build as it is shipped to object storage:
+// packing twice must give the same bytes, and the tarball must match its
+// checksum and unpack to exactly public/
+func (suite *HugoTestSuite) TestSiteArtifact() {
+	t := suite.T()
+
+	dir := t.TempDir()
+	first, err := deploy.Package(suite.publicDir, filepath.Join(dir, "first.tar.gz"), time.Unix(0, 0))
+	require.NoError(t, err, "Failed to package the build")
+	second, err := deploy.Package(suite.publicDir, filepath.Join(dir, "second.tar.gz"), time.Unix(0, 0))
+	require.NoError(t, err, "Failed to package the build")
+	assert.Equal(t, first.SHA256, second.SHA256, "Packaging the same build should be reproducible")
+
+	diff, err := deploy.VerifyArtifact(first.Path, suite.publicDir)
+	require.NoError(t, err, "Failed to verify the artifact")
+	assert.Empty(t, diff.Missing, "Files of public/ missing from the artifact")
+	assert.Empty(t, diff.Extra, "Files in the artifact but not in public/")
+	assert.Empty(t, diff.Changed, "Files that differ between the artifact and public/")
+	t.Logf("public.tar.gz: %d files, %d KB", first.Files, first.Size/1024)
+}
+
+// TestSubresourceIntegrity requires every externally hosted script and
+// stylesheet to carry integrity and crossorigin attributes whose hashes
+// match what the third-party host serves
+func (suite *HugoTestSuite) TestSubresourceIntegrity() {
+	t := suite.T()
+	site := suite.crawlSite()
+
+	base := siteBaseURL(t)
+	origin := base.Scheme + "://" + base.Host
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if *offline {
+		// Hashes can't be compared with what the hosts serve, but the
+		// attributes can still be linted
+		var resources []sri.Resource
+		for _, page := range site.HTML() {
+			if page.Doc == nil {
+				continue
+			}
+			for _, r := range sri.Resources(page.Doc, page.URL) {
+				resources = append(resources, r)
+				for _, reason := range sri.Lint(r) {
+					t.Errorf("Subresource integrity: %s", sri.Problem{Resource: r, Reason: reason})
+				}
+			}
+		}
+		t.Logf("Offline: linted %d external scripts and stylesheets without fetching them", len(resources))
+		return
+	}
+	resources, problems := sri.Audit(ctx, externalClient(30*time.Second), site.HTML(), origin)
+
+	for _, p := range problems {
+		t.Errorf("Subresource integrity: %s", p)
+	}
+	t.Logf("Checked %d external scripts and stylesheets", len(resources))
+}
+
+// TestMixedContent verifies no page references http:// subresources,
+// including URLs inside stylesheets and inline styles
+func (suite *HugoTestSuite) TestMixedContent() {
+	t := suite.T()
+
+	for _, f := range mixedcontent.Static(suite.crawlSite().Pages) {
+		t.Errorf("Mixed content: %s", f)
+	}
+}
+
+// TestWebFonts checks every @font-face in the build offers a WOFF2 file
+// that exists, is subset and keeps text visible while it loads
+func (suite *HugoTestSuite) TestWebFonts() {
+	t := suite.T()
+	site := suite.crawlSite()
+
+	faces := fonts.Faces(site.Pages)
+	if len(faces) == 0 {
+		t.Log("No @font-face rules; the site uses system fonts")
+		return
+	}
+	problems, err := fonts.Check(context.Background(), crawl.NewDirFetcher(suite.publicDir), faces, fonts.Options{})
+	require.NoError(t, err, "Reading font files should succeed")
+	for _, p := range problems {
+		t.Error(p)
+	}
+	t.Logf("Checked %d font faces", len(faces))
+}
+
+// TestSecurityTxtGenerated verifies the build renders a valid RFC 9116
+// security.txt from the site params
+func (suite *HugoTestSuite) TestSecurityTxtGenerated() {
+	t := suite.T()
+
+	data, err := os.ReadFile(filepath.Join(suite.publicDir, ".well-known", "security.txt"))
+	require.NoError(t, err, "Build should generate .well-known/security.txt")
+	f, err := securitytxt.Parse(data)
+	require.NoError(t, err, "security.txt should parse")
+	warning, err := f.Validate(time.Now())
+	assert.NoError(t, err, "security.txt should satisfy RFC 9116")
+	assert.Empty(t, warning)
+}
+
+// TestVCardGenerated verifies the build renders an RFC 6350 contact card
+// from the site params and the home page links to it
+func (suite *HugoTestSuite) TestVCardGenerated() {
+	t := suite.T()
+
+	data, err := os.ReadFile(filepath.Join(suite.publicDir, "contact.vcf"))
+	require.NoError(t, err, "Build should generate contact.vcf")
+	card, err := vcard.Parse(data)
+	require.NoError(t, err, "contact.vcf should parse")
+	assert.NoError(t, card.Validate(), "contact.vcf should satisfy RFC 6350")
+	assert.Equal(t, settings.Author, card.Value("FN"), "The card should name the author")
+	assert.Contains(t, vcard.Links(suite.indexDoc()), "/contact.vcf", "The home page should link to the card")
 }
 
 // TestIndexHTMLExists verifies index.html was generated
@@ -73,114 +365,483 @@ func (suite *HugoTestSuite) TestIndexHTMLExists() {
 	assert.FileExists(t, indexPath, "index.html should exist")
 }
 
+// indexDoc returns the parsed home page from the cached crawl
+func (suite *HugoTestSuite) indexDoc() *match.Document {
+	t := suite.T()
+	page := suite.crawlSite().Page("/")
+	require.NotNil(t, page, "Crawl should include the home page")
+	require.NotNil(t, page.Doc, "Home page should parse as HTML")
+	return match.FromNode(page.Doc)
+}
+
 // TestResumeContent verifies resume content is present
 func (suite *HugoTestSuite) TestResumeContent() {
 	t := suite.T()
-	indexPath := filepath.Join(suite.publicDir, "index.html")
+	doc := suite.indexDoc()
 
-	content, err := os.ReadFile(indexPath)
-	require.NoError(t, err, "Should be able to read index.html")
+	assert.NoError(t, doc.Select("h1").TextEquals(settings.Author), "Resume should contain author name")
+}
 
-	contentStr := string(content)
-	assert.Contains(t, contentStr, "Princeton A. Strong", "Resume should contain author name")
+// TestRenderedMatchesData reads the resume back out of the rendered home
+// page and compares it entry by entry with the content file, catching a
+// template that silently drops a job or certification, or renders one
+// that is not in the data
+func (suite *HugoTestSuite) TestRenderedMatchesData() {
+	t := suite.T()
+
+	data, err := resume.Load(contentFile)
+	require.NoError(t, err, "The resume content file should parse")
+	rendered, err := resume.FromHTML(suite.indexDoc())
+	require.NoError(t, err, "The home page should hold the resume in <main>")
+
+	for _, p := range resume.Compare(data, rendered) {
+		t.Error(p)
+	}
+}
+
+// TestJSONResumeGenerated verifies the build exports the resume as JSON
+// Resume and the export names the same person, jobs, schools,
+// certifications, skills and projects as the home page
+func (suite *HugoTestSuite) TestJSONResumeGenerated() {
+	t := suite.T()
+
+	data, err := os.ReadFile(filepath.Join(suite.publicDir, "resume.json"))
+	require.NoError(t, err, "Build should generate resume.json")
+	exported, err := parity.FromJSONResume(data)
+	require.NoError(t, err, "resume.json should be valid JSON")
+	page, err := parity.FromHTML(suite.indexDoc())
+	require.NoError(t, err, "The home page should hold the resume in <main>")
+
+	for _, p := range parity.Compare(page, exported) {
+		t.Error(p)
+	}
+}
+
+// pageContacts returns the contact details in a page's header, footer and
+// JSON-LD
+func pageContacts(doc *match.Document) ([]contact.Sighting, error) {
+	var found []contact.Sighting
+	for _, part := range []string{"header", "footer"} {
+		for _, n := range doc.Select(part).Nodes() {
+			found = append(found, contact.FromHTML(part, n)...)
+		}
+	}
+	ld, err := contact.FromJSONLD(doc)
+	return append(found, ld...), err
+}
+
+// TestContactConsistency checks the email, phone and social handles are
+// the same everywhere the build publishes them: the home page header and
+// footer, its JSON-LD and any vCard. The printed PDF is compared by
+// BrowserTestSuite.TestContactInPrint.
+func (suite *HugoTestSuite) TestContactConsistency() {
+	t := suite.T()
+
+	found, err := pageContacts(suite.indexDoc())
+	require.NoError(t, err, "The home page's JSON-LD should parse")
+	cards, err := filepath.Glob(filepath.Join(suite.publicDir, "*.vcf"))
+	require.NoError(t, err)
+	for _, path := range cards {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err, "Should be able to read %s", path)
+		card, err := vcard.Parse(data)
+		require.NoError(t, err, "%s should parse", path)
+		found = append(found, contact.FromVCard(card)...)
+	}
+	require.NotEmpty(t, found, "The home page should publish contact details")
+
+	for _, p := range contact.Compare(found) {
+		t.Error(p)
+	}
+	t.Logf("Compared %d contact details from the page and %d vCards", len(found), len(cards))
+}
+
+// TestATSKeywords reads the home page as an applicant tracking system
+// would. At least OSYRAA_ATS_MIN_COVERAGE (default 0.8) of the keywords
+// in OSYRAA_ATS_KEYWORDS (default testdata/ats-keywords.txt) must appear
+// in its visible text, headings must be ones an ATS recognises, and no
+// content may exist only as an image. The report lists each keyword.
+func (suite *HugoTestSuite) TestATSKeywords() {
+	t := suite.T()
+
+	path := os.Getenv("OSYRAA_ATS_KEYWORDS")
+	if path == "" {
+		path = filepath.Join("testdata", "ats-keywords.txt")
+	}
+	minCoverage := 0.8
+	if v := os.Getenv("OSYRAA_ATS_MIN_COVERAGE"); v != "" {
+		var err error
+		minCoverage, err = strconv.ParseFloat(v, 64)
+		require.NoError(t, err, "OSYRAA_ATS_MIN_COVERAGE should be a fraction like 0.8")
+	}
+	f, err := os.Open(path)
+	require.NoError(t, err, "Failed to open the keyword list")
+	defer f.Close()
+	keywords, err := ats.ReadKeywords(f)
+	require.NoError(t, err, "Failed to read %s", path)
+
+	doc := suite.indexDoc()
+	coverage := ats.Keywords(ats.Text(doc), keywords)
+	assert.GreaterOrEqual(t, coverage.Ratio(), minCoverage,
+		"The resume should mention at least %.0f%% of the target keywords; missing %v", minCoverage*100, coverage.Missing)
+	for _, p := range ats.Headings(doc) {
+		t.Errorf("Heading structure: %s", p)
+	}
+	for _, p := range ats.Images(doc) {
+		t.Errorf("Image-only content: %s", p)
+	}
+
+	status := report.Pass
+	if t.Failed() {
+		status = report.Fail
+	} else if len(coverage.Missing) > 0 {
+		status = report.Warn
+	}
+	table := &report.Table{Header: []string{"Keyword", "Found"}}
+	for _, k := range coverage.Found {
+		table.Rows = append(table.Rows, []string{k, "yes"})
+	}
+	for _, k := range coverage.Missing {
+		table.Rows = append(table.Rows, []string{k, "no"})
+	}
+	harnessReport.Add(report.Section{Title: "ATS keywords", Status: status, Table: table,
+		Summary: fmt.Sprintf("%d of %d keywords (%.0f%%)", len(coverage.Found), len(keywords), coverage.Ratio()*100)})
 }
 
 // TestCertificationsSection verifies certifications are present
 func (suite *HugoTestSuite) TestCertificationsSection() {
 	t := suite.T()
-	indexPath := filepath.Join(suite.publicDir, "index.html")
-
-	content, err := os.ReadFile(indexPath)
-	require.NoError(t, err, "Should be able to read index.html")
+	doc := suite.indexDoc()
 
-	contentStr := string(content)
-	assert.Contains(t, contentStr, "Certified Kubernetes Administrator",
-		"Resume should contain certifications")
+	assert.NoError(t, doc.Select("h2").TextEquals("Certifications"), "Resume should have a certifications heading")
+	assert.NoError(t, doc.XPath("//h2[normalize-space()='Certifications']/following-sibling::ul[1]/li").
+		TextContains("Certified Kubernetes Administrator"), "Resume should contain certifications")
 }
 
 // TestHTMLStructure validates proper HTML structure
 func (suite *HugoTestSuite) TestHTMLStructure() {
 	t := suite.T()
-	indexPath := filepath.Join(suite.publicDir, "index.html")
+	doc := suite.indexDoc()
 
-	content, err := os.ReadFile(indexPath)
-	require.NoError(t, err, "Should be able to read index.html")
+	// Minification drops optional tags, so check the parsed tree rather than raw markup
+	assert.True(t, doc.HasDoctype(), "Should have DOCTYPE declaration")
+	assert.NoError(t, doc.Select("html[lang]").Exists(), "Should declare a document language")
+	assert.NoError(t, doc.Select("head > title").Exists(), "Should have head section with a title")
+	assert.NoError(t, doc.Select("body main").Exists(), "Should have body section with main content")
+}
 
-	contentStr := string(content)
-	assert.Contains(t, contentStr, "<!DOCTYPE html>", "Should have DOCTYPE declaration")
-	assert.Contains(t, contentStr, "</html>", "Should have closing html tag")
-	assert.Contains(t, contentStr, "<head>", "Should have head section")
-	assert.Contains(t, contentStr, "<body>", "Should have body section")
+// TestHTMLValidity checks the markup of every page for what browsers
+// silently repair: unclosed and misnested tags, stray end tags, duplicate
+// IDs, images without alt text and empty links. Each problem is reported
+// with its page and line.
+func (suite *HugoTestSuite) TestHTMLValidity() {
+	t := suite.T()
+	issues, err := htmlvalid.Dir(suite.publicDir)
+	require.NoError(t, err, "Failed to read the build")
+	for _, issue := range issues {
+		t.Errorf("Invalid HTML: %s", issue)
+	}
 }
 
-// TestMinifiedOutput verifies output is minified
+// TestMinifiedOutput verifies hugo --minify ran over the home page and
+// its stylesheets: the markup keeps no comments or runs of whitespace
+// outside <pre> and <textarea>, and the CSS no line breaks
 func (suite *HugoTestSuite) TestMinifiedOutput() {
 	t := suite.T()
-	indexPath := filepath.Join(suite.publicDir, "index.html")
-
-	content, err := os.ReadFile(indexPath)
-	require.NoError(t, err, "Should be able to read index.html")
+	doc := suite.indexDoc()
+
+	assert.NoError(t, doc.XPath("//comment()").Absent(), "Minified HTML should have no comments")
+	text := doc.XPath("//text()[not(ancestor::pre or ancestor::textarea)]")
+	require.NoError(t, text.Err())
+	for _, n := range text.Nodes() {
+		if whitespaceRun.MatchString(n.Data) {
+			t.Errorf("Minified HTML should collapse whitespace, found %q in <%s>", n.Data, n.Parent.Data)
+		}
+	}
 
-	// Minified HTML should have minimal whitespace
-	// This is a basic check - real minification is more complex
-	assert.NotEmpty(t, content, "index.html should not be empty")
+	sheets := doc.Select(`link[rel="stylesheet"][href^="/"]`)
+	require.NoError(t, sheets.Exists(), "The home page should link its stylesheet")
+	for _, n := range sheets.Nodes() {
+		href, _ := match.Attr(n, "href")
+		css, err := os.ReadFile(filepath.Join(suite.publicDir, filepath.FromSlash(strings.TrimPrefix(href, "/"))))
+		require.NoError(t, err, "Stylesheet %s should be in the build", href)
+		assert.NotContains(t, strings.TrimSpace(string(css)), "\n", "Stylesheet %s should be minified", href)
+	}
 }
 
-// TestNoInlineScripts checks for inline scripts (security concern)
+// whitespaceRun is whitespace a minifier would have collapsed
+var whitespaceRun = regexp.MustCompile(`\s\s`)
+
+// TestNoInlineScripts requires every script to load from a file, which
+// the Content-Security-Policy can restrict: no inline <script> of any type
+// that runs, and no event handler attributes. JSON-LD and other data
+// blocks never run, so they are allowed.
 func (suite *HugoTestSuite) TestNoInlineScripts() {
 	t := suite.T()
-	indexPath := filepath.Join(suite.publicDir, "index.html")
+	doc := suite.indexDoc()
+
+	for _, n := range doc.Select("script:not([src])").Nodes() {
+		typ, _ := match.Attr(n, "type")
+		switch strings.ToLower(strings.TrimSpace(typ)) {
+		case "", "text/javascript", "application/javascript", "module":
+			t.Errorf("Inline script found: %q", match.Text(n))
+		}
+	}
+	for _, n := range doc.Select("*").Nodes() {
+		for _, a := range n.Attr {
+			if strings.HasPrefix(strings.ToLower(a.Key), "on") {
+				t.Errorf("Event handler attribute found: <%s %s=%q>", n.Data, a.Key, a.Val)
+			}
+		}
+	}
+}
+
+// htmlSnapshots are the normalized renderings of the key pages committed
+// in testdata/snapshots. OSYRAA_SNAPSHOTS moves them and
+// OSYRAA_UPDATE_SNAPSHOTS=1 replaces them with the current build, to
+// accept an intended change or add a page; a missing one fails.
+func htmlSnapshots() snapshot.Store {
+	dir := os.Getenv("OSYRAA_SNAPSHOTS")
+	if dir == "" {
+		dir = filepath.Join("testdata", "snapshots")
+	}
+	return snapshot.Store{Dir: dir, Update: os.Getenv("OSYRAA_UPDATE_SNAPSHOTS") == "1"}
+}
+
+// TestHTMLSnapshots compares each key page's markup with its snapshot, so
+// a template change that alters the output shows up as a diff in review
+// rather than going unnoticed
+func (suite *HugoTestSuite) TestHTMLSnapshots() {
+	t := suite.T()
+	snapshots := htmlSnapshots()
+
+	for _, page := range keyPages {
+		content, err := os.ReadFile(filepath.Join(suite.publicDir, filepath.FromSlash(page.Path), "index.html"))
+		require.NoError(t, err, "Build should generate %s", page.Path)
+
+		res, err := snapshots.Check(page.Name, content)
+		if errors.Is(err, golden.ErrMissing) {
+			t.Errorf("%s has no snapshot (rerun with OSYRAA_UPDATE_SNAPSHOTS=1 and commit it): %v", page.Path, err)
+			continue
+		}
+		require.NoError(t, err, "Comparing %s with its snapshot should succeed", page.Name)
+		if res.Updated {
+			t.Logf("Stored snapshot %s.html in %s", page.Name, snapshots.Dir)
+			continue
+		}
+		if res.Diff != "" {
+			t.Errorf("%s changed from its snapshot (rerun with OSYRAA_UPDATE_SNAPSHOTS=1 if intended):\n%s", page.Path, res.Diff)
+		}
+	}
+}
+
+// timeTravelProbes are pages added to a copy of the site whose presence
+// depends on the build date, relative to now, so the scheduling checks
+// have something to find even while the real content has no dates to come
+func timeTravelProbes(now time.Time) map[string]string {
+	day := 24 * time.Hour
+	stamp := func(d time.Duration) string { return now.Add(d).UTC().Format(time.RFC3339) }
+	return map[string]string{
+		"upcoming.md": "---\ntitle: Upcoming\ndate: " + stamp(-day) + "\npublishDate: " + stamp(30*day) + "\n---\n",
+		"expiring.md": "---\ntitle: Expiring\ndate: " + stamp(-60*day) + "\nexpiryDate: " + stamp(60*day) + "\n---\n",
+		"expired.md":  "---\ntitle: Expired\ndate: " + stamp(-90*day) + "\nexpiryDate: " + stamp(-30*day) + "\n---\n",
+		"draft.md":    "---\ntitle: Draft\ndraft: true\n---\n",
+	}
+}
+
+// copySite copies the parts of the Hugo source tree at src that a build
+// reads into dst, leaving out the tests and any earlier output
+func copySite(src, dst string) error {
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	for _, name := range []string{"config.toml", "content", "layouts", "themes", "static", "assets", "data", "i18n", "archetypes"} {
+		info, err := os.Stat(filepath.Join(src, name))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			return err
+		case info.IsDir():
+			err = os.CopyFS(filepath.Join(dst, name), os.DirFS(filepath.Join(src, name)))
+		default:
+			var data []byte
+			if data, err = os.ReadFile(filepath.Join(src, name)); err == nil {
+				err = os.WriteFile(filepath.Join(dst, name), data, 0o644)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestScheduledContent builds the site with Hugo's clock set just before
+// and after each page's publish and expiry dates (OSYRAA_TIME_TRAVEL=1),
+// and checks scheduled pages appear on time, future, expired and draft
+// pages do not leak into public/ or the sitemap, no lastmod is ahead of the build
+// and security.txt's Expires follows the build date. OSYRAA_CLOCK adds
+// dates of its own, comma-separated, to preview the site as of then.
+func (suite *HugoTestSuite) TestScheduledContent() {
+	t := suite.T()
+	if os.Getenv("OSYRAA_TIME_TRAVEL") != "1" {
+		t.Skip("set OSYRAA_TIME_TRAVEL=1 to build the site at scheduled dates")
+	}
+
+	src, err := filepath.Abs("..")
+	require.NoError(t, err)
+	site := filepath.Join(suite.workDir, "time-travel", "src")
+	require.NoError(t, copySite(src, site), "Failed to copy the site source")
+	now := time.Now().UTC()
+	probes := filepath.Join(site, "content", "osyraa-time-travel")
+	require.NoError(t, os.MkdirAll(probes, 0o755))
+	for name, body := range timeTravelProbes(now) {
+		require.NoError(t, os.WriteFile(filepath.Join(probes, name), []byte(body), 0o644))
+	}
+	// A page template, so published probes render and leaks show in public/
+	probeLayout := filepath.Join(site, "layouts", "osyraa-time-travel", "single.html")
+	require.NoError(t, os.MkdirAll(filepath.Dir(probeLayout), 0o755))
+	require.NoError(t, os.WriteFile(probeLayout, []byte("<h1>{{ .Title }}</h1>\n"), 0o644))
+
+	pages, err := schedule.Load(filepath.Join(site, "content"))
+	require.NoError(t, err, "Content front matter should parse")
+	draft := slices.IndexFunc(pages, func(p schedule.Page) bool { return p.File == "osyraa-time-travel/draft.md" })
+	require.NotEqual(t, -1, draft, "The draft probe should be loaded")
+	require.True(t, pages[draft].Draft && pages[draft].Scheduled(), "The draft probe should be checked at every build date")
+	instants := schedule.Instants(pages, now)
+	for _, v := range strings.Split(os.Getenv("OSYRAA_CLOCK"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			at, err = time.Parse("2006-01-02", v)
+		}
+		require.NoError(t, err, "OSYRAA_CLOCK takes RFC 3339 timestamps or dates")
+		instants = append(instants, at.UTC())
+	}
+
+	table := &report.Table{Header: []string{"Build date", "Pages", "Problems"}}
+	failed := false
+	for i, at := range instants {
+		out := filepath.Join(suite.workDir, "time-travel", fmt.Sprint(i))
+		require.NoError(t, os.MkdirAll(out, 0o755))
+		output, err := suite.hugo(fmt.Sprintf("-clock-%d", i), site, out, "--clock", at.Format(time.RFC3339))
+		require.NoError(t, err, "Hugo build at %s failed: %s", at.Format(time.RFC3339), string(output))
+		public := filepath.Join(out, "public")
+
+		data, err := os.ReadFile(filepath.Join(public, "sitemap.xml"))
+		require.NoError(t, err, "Build at %s should write a sitemap", at)
+		sitemap, err := schedule.ParseSitemap(data)
+		require.NoError(t, err)
+		problems := schedule.Verify(pages, at, sitemap)
+		for _, p := range pages {
+			if p.Scheduled() && !p.Live(at) {
+				if _, err := os.Stat(filepath.Join(public, filepath.FromSlash(p.URL), "index.html")); err == nil {
+					problems = append(problems, fmt.Sprintf("%s (%s) is rendered although a build at that date must not publish it", p.URL, p.File))
+				}
+			}
+		}
 
-	content, err := os.ReadFile(indexPath)
-	require.NoError(t, err, "Should be able to read index.html")
+		data, err = os.ReadFile(filepath.Join(public, ".well-known", "security.txt"))
+		require.NoError(t, err)
+		f, err := securitytxt.Parse(data)
+		require.NoError(t, err)
+		if _, err := f.Validate(at); err != nil {
+			problems = append(problems, "security.txt: "+err.Error())
+		} else if f.Expires.Sub(at.Truncate(time.Second))%(24*time.Hour) != 0 {
+			problems = append(problems, fmt.Sprintf("security.txt Expires %s is not counted from the build date", f.Expires.Format(time.RFC3339)))
+		}
 
-	contentStr := string(content)
-	// For a basic resume site, we may not want inline scripts
-	// This is a basic XSS prevention check
-	if strings.Contains(contentStr, "<script>") {
-		t.Log("Warning: inline scripts detected - review for XSS risks")
+		for _, p := range problems {
+			t.Errorf("Built at %s: %s", at.Format(time.RFC3339), p)
+		}
+		failed = failed || len(problems) > 0
+		table.Rows = append(table.Rows, []string{at.Format(time.RFC3339), fmt.Sprint(len(sitemap)), strings.Join(problems, "; ")})
+		// Each build is a full copy of the site; drop it once checked
+		os.RemoveAll(out)
 	}
+	section := report.Section{Title: "Scheduled content", Status: report.Pass, Table: table,
+		Summary: fmt.Sprintf("Built at %d dates around each page's publish and expiry dates", len(instants))}
+	if failed {
+		section.Status = report.Fail
+	}
+	harnessReport.Add(section)
 }
 
-// SetupSuite runs once before all Docker tests
+// SetupSuite runs once before all Docker tests. testify runs the tests
+// in name order, so the image and the container they share are built and
+// started here rather than by one of them.
 func (suite *DockerTestSuite) SetupSuite() {
-	suite.ctx = context.Background()
-	suite.imageTag = "resume:test"
+	suite.ctx = suiteContext(suite.T())
+	suite.imageTag = imageTag()
+	suite.cleanups = teardown.Scope()
 
-	var err error
-	suite.client, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	_, err := containerEngine()
+	require.NoError(suite.T(), err, "A container engine is needed for the Docker tests")
+	suite.client, err = newDockerClient()
 	require.NoError(suite.T(), err, "Failed to create Docker client")
+	// Registered first so it runs last, after the removals that use it
+	suite.cleanups.Add("Docker client", func(context.Context) error { return suite.client.Close() })
+
+	// Registered before building so an interrupted build is covered too
+	tag := suite.imageTag
+	suite.cleanups.Add("image "+tag, func(ctx context.Context) error { return removeImage(ctx, suite.client, tag) })
+	_, err = buildSiteImage(suite.ctx, suite.imageTag)
+	require.NoError(suite.T(), err, "Docker build failed")
+
+	require.NoError(suite.T(), suite.startSite("site.log"), "The container should start and serve the site")
+	suite.T().Logf("Site published at %s", suite.siteURL)
 }
 
-// TearDownSuite cleans up after all Docker tests
-func (suite *DockerTestSuite) TearDownSuite() {
-	if suite.containerID != "" {
-		// Stop and remove container
-		timeout := 10
-		suite.client.ContainerStop(suite.ctx, suite.containerID, container.StopOptions{Timeout: &timeout})
-		suite.client.ContainerRemove(suite.ctx, suite.containerID, container.RemoveOptions{Force: true})
+// startSite runs the suite's container of the built image and points the
+// suite at it. It is confined as the site is run, which
+// TestRuntimePrivileges checks, and nginx's output is kept with the
+// report as logName.
+func (suite *DockerTestSuite) startSite(logName string) error {
+	c, baseURL, stop, err := suite.runSite(suite.imageTag, "", logName, nil)
+	if err != nil {
+		return err
 	}
+	suite.containerID = c.GetContainerID()
+	suite.siteStop = stop
+	suite.startedAt = time.Now()
+	suite.siteURL = baseURL
+	suite.siteAddr = strings.TrimPrefix(baseURL, "http://")
+	return nil
+}
 
-	// Remove test image
-	if suite.imageTag != "" {
-		suite.client.ImageRemove(suite.ctx, suite.imageTag, types.ImageRemoveOptions{Force: true})
+// TearDownSuite removes the container, the image and the client, newest
+// first
+func (suite *DockerTestSuite) TearDownSuite() {
+	if err := suite.cleanups.Close(context.Background()); err != nil {
+		suite.T().Log("Teardown:", err)
 	}
+}
+
+// HandleStats records a span per test once the suite is done
+func (suite *DockerTestSuite) HandleStats(_ string, stats *suiteStats) {
+	traceTests(suite.ctx, stats)
+}
+
+// siteClient fetches from the container through the shared pooled
+// transport
+var siteClient = &http.Client{Timeout: 10 * time.Second}
 
-	if suite.client != nil {
-		suite.client.Close()
+// get fetches path from the container under the suite's context
+func (suite *DockerTestSuite) get(path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(suite.ctx, http.MethodGet, suite.siteURL+path, nil)
+	if err != nil {
+		return nil, err
 	}
+	return siteClient.Do(req)
 }
 
-// TestDockerBuild tests Docker image building
+// TestDockerBuild checks the image SetupSuite built is listed
 func (suite *DockerTestSuite) TestDockerBuild() {
 	t := suite.T()
 
-	// Build Docker image using docker build command
-	cmd := exec.Command("docker", "build", "-t", suite.imageTag, "..")
-	output, err := cmd.CombinedOutput()
-	require.NoError(t, err, "Docker build failed: %s", string(output))
-
-	// Verify image exists
 	images, err := suite.client.ImageList(suite.ctx, types.ImageListOptions{})
 	require.NoError(t, err, "Failed to list images")
 
@@ -216,70 +877,38 @@ func (suite *DockerTestSuite) TestDockerImageSize() {
 	}
 }
 
-// TestContainerStart tests starting a container
+// TestContainerStart checks the container SetupSuite started is running
 func (suite *DockerTestSuite) TestContainerStart() {
 	t := suite.T()
 
-	// Create container
-	resp, err := suite.client.ContainerCreate(
-		suite.ctx,
-		&container.Config{
-			Image: suite.imageTag,
-			ExposedPorts: nat.PortSet{
-				"80/tcp": struct{}{},
-			},
-		},
-		&container.HostConfig{
-			PortBindings: nat.PortMap{
-				"80/tcp": []nat.PortBinding{
-					{
-						HostIP:   "127.0.0.1",
-						HostPort: "8080",
-					},
-				},
-			},
-		},
-		nil,
-		nil,
-		"",
-	)
-	require.NoError(t, err, "Failed to create container")
-	suite.containerID = resp.ID
-
-	// Start container
-	err = suite.client.ContainerStart(suite.ctx, suite.containerID, container.StartOptions{})
-	require.NoError(t, err, "Failed to start container")
-
-	// Wait for container to be ready
-	time.Sleep(5 * time.Second)
-
-	// Verify container is running
-	containerJSON, err := suite.client.ContainerInspect(suite.ctx, suite.containerID)
+	inspect, err := suite.client.ContainerInspect(suite.ctx, suite.containerID)
 	require.NoError(t, err, "Failed to inspect container")
-	assert.True(t, containerJSON.State.Running, "Container should be running")
+	assert.True(t, inspect.State.Running, "Container should be running")
 }
 
-// TestContainerHealth checks container health status
+// TestContainerHealth waits for the image's HEALTHCHECK to pass. The
+// engine runs the first check one interval after the start, so the wait
+// allows the start period plus an interval per retry.
 func (suite *DockerTestSuite) TestContainerHealth() {
 	t := suite.T()
 
-	// Wait for health check to run
-	time.Sleep(6 * time.Second)
-
 	containerJSON, err := suite.client.ContainerInspect(suite.ctx, suite.containerID)
 	require.NoError(t, err, "Failed to inspect container")
-
-	if containerJSON.State.Health != nil {
-		t.Logf("Health status: %s", containerJSON.State.Health.Status)
-		// Health check may take time to become healthy
-	}
+	check := containerJSON.Config.Healthcheck
+	require.NotNil(t, check, "The image should declare a HEALTHCHECK")
+
+	o := readyOptions()
+	o.Timeout = check.StartPeriod + time.Duration(check.Retries+1)*(check.Interval+check.Timeout)
+	o.MaxInterval = time.Second
+	err = ready.WaitFor(suite.ctx, ready.ContainerHealthy(suite.client, suite.containerID), o)
+	assert.NoError(t, err, "Container should become healthy")
 }
 
 // TestHTTPEndpoint tests the HTTP endpoint
 func (suite *DockerTestSuite) TestHTTPEndpoint() {
 	t := suite.T()
 
-	resp, err := http.Get("http://localhost:8080/")
+	resp, err := suite.get("/")
 	require.NoError(t, err, "HTTP request should succeed")
 	defer resp.Body.Close()
 
@@ -290,36 +919,586 @@ func (suite *DockerTestSuite) TestHTTPEndpoint() {
 func (suite *DockerTestSuite) TestHTTPContent() {
 	t := suite.T()
 
-	resp, err := http.Get("http://localhost:8080/")
+	resp, err := suite.get("/")
 	require.NoError(t, err, "HTTP request should succeed")
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	require.NoError(t, err, "Should be able to read response body")
 
-	contentStr := string(body)
-	assert.Contains(t, contentStr, "Princeton A. Strong", "Resume content should be served")
+	doc, err := match.ParseBytes(body)
+	require.NoError(t, err, "Response body should parse as HTML")
+	assert.NoError(t, doc.Select("h1").TextEquals(settings.Author), "Resume content should be served")
+}
+
+// headerPolicy is the policy TestSecurityHeaders holds responses to, read
+// from testdata/headers.yaml or the file OSYRAA_HEADER_POLICY names
+func headerPolicy(t *testing.T) *headerpolicy.Policy {
+	t.Helper()
+	path := os.Getenv("OSYRAA_HEADER_POLICY")
+	if path == "" {
+		path = filepath.Join("testdata", "headers.yaml")
+	}
+	policy, err := headerpolicy.Load(path)
+	require.NoError(t, err, "The header policy should load")
+	return policy
 }
 
-// TestSecurityHeaders verifies security headers are present
+// TestSecurityHeaders fetches every page the header policy lists and
+// reports every header that breaks it, on every page, in one failure
 func (suite *DockerTestSuite) TestSecurityHeaders() {
 	t := suite.T()
+	policy := headerPolicy(t)
+
+	var violations []headerpolicy.Violation
+	for _, page := range policy.Pages {
+		resp, err := suite.get(page)
+		require.NoError(t, err, "GET %s should succeed", page)
+		resp.Body.Close()
+		violations = append(violations, policy.Evaluate(page, resp.TLS != nil, resp.Header)...)
+	}
+	table := &report.Table{Header: []string{"Page", "Header", "Problem"}}
+	for _, v := range violations {
+		table.Rows = append(table.Rows, []string{v.Path, v.Header, v.Problem})
+	}
+	section := report.Section{Title: "Security headers", Status: report.Pass,
+		Summary: fmt.Sprintf("%d rules checked on %d pages", len(policy.Rules), len(policy.Pages))}
+	if len(violations) > 0 {
+		section.Status = report.Fail
+		section.Summary += fmt.Sprintf(", %d violations", len(violations))
+		section.Table = table
+	}
+	harnessReport.Add(section)
+	assert.Empty(t, violations, "Every header should match the policy")
 
-	resp, err := http.Get("http://localhost:8080/")
-	require.NoError(t, err, "HTTP request should succeed")
-	defer resp.Body.Close()
+	assert.NoError(t, battery.CheckFraming(suite.ctx, newTarget(suite.siteURL)),
+		"X-Frame-Options and CSP frame-ancestors should agree")
+	assert.NoError(t, battery.CheckCookies(suite.ctx, newTarget(suite.siteURL)),
+		"A static site should set no cookies")
+}
+
+// TestClickjackingInBrowser frames the home page from another origin in
+// headless Chrome and requires the browser to refuse rendering it
+func (suite *DockerTestSuite) TestClickjackingInBrowser() {
+	t := suite.T()
+	b := newBrowser(t)
+
+	tab, cancel := b.NewTab()
+	defer cancel()
+	tab, cancelTimeout := context.WithTimeout(tab, time.Minute)
+	defer cancelTimeout()
+	blocked, err := browser.FrameBlocked(tab, suite.siteURL+"/")
+	require.NoError(t, err, "Framing page should load")
+	assert.True(t, blocked, "The site should refuse to render inside a cross-origin frame")
+}
+
+// TestSecurityTxt verifies the container serves a valid security.txt as
+// UTF-8 text/plain rather than falling back to index.html
+func (suite *DockerTestSuite) TestSecurityTxt() {
+	t := suite.T()
+
+	f, err := securitytxt.Fetch(suite.ctx, newTarget(suite.siteURL))
+	require.NoError(t, err, "security.txt should be served")
+	_, err = f.Validate(time.Now())
+	assert.NoError(t, err, "security.txt should satisfy RFC 9116")
+}
+
+// TestVCardServed follows each vCard link on the served home page and
+// verifies the card is served as text/vcard rather than as a download of
+// unknown type or the index.html fallback
+func (suite *DockerTestSuite) TestVCardServed() {
+	t := suite.T()
+	target := newTarget(suite.siteURL)
+
+	_, body, err := target.Get(suite.ctx, "/")
+	require.NoError(t, err, "Home page should be served")
+	doc, err := match.ParseBytes(body)
+	require.NoError(t, err, "Home page should parse as HTML")
+	links := vcard.Links(doc)
+	require.NotEmpty(t, links, "The home page should link to a vCard")
+	for _, link := range links {
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+		card, err := vcard.Fetch(suite.ctx, target, u.Path)
+		if !assert.NoError(t, err, "%s should be served", link) {
+			continue
+		}
+		assert.NoError(t, card.Validate(), "%s should satisfy RFC 6350", link)
+	}
+}
+
+// zapTarget is how the ZAP container reaches the site container. On Linux
+// host networking puts it on the host's loopback; Docker Desktop on macOS
+// and Windows runs containers in a VM where that would be the VM's own, so
+// it goes through host.docker.internal instead.
+func (suite *DockerTestSuite) zapTarget() (target, network string) {
+	if runtime.GOOS == "linux" {
+		return suite.siteURL, "host"
+	}
+	_, port, _ := net.SplitHostPort(suite.siteAddr)
+	return "http://host.docker.internal:" + port, ""
+}
+
+// TestZAPBaseline runs the OWASP ZAP baseline scan against the container
+// and fails on Medium or higher alerts (OSYRAA_ZAP_MIN_RISK=low|medium|high).
+// OSYRAA_ZAP_IGNORE lists plugin IDs accepted as known issues.
+func (suite *DockerTestSuite) TestZAPBaseline() {
+	t := suite.T()
+	if os.Getenv("OSYRAA_ZAP") != "1" {
+		t.Skip("set OSYRAA_ZAP=1 to run the ZAP baseline scan")
+	}
+
+	minRisk := zap.Medium
+	switch level := strings.ToLower(os.Getenv("OSYRAA_ZAP_MIN_RISK")); level {
+	case "", "medium":
+	case "low":
+		minRisk = zap.Low
+	case "high":
+		minRisk = zap.High
+	default:
+		t.Fatalf("unsupported OSYRAA_ZAP_MIN_RISK %q", level)
+	}
+	var ignore []string
+	for _, id := range strings.Split(os.Getenv("OSYRAA_ZAP_IGNORE"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ignore = append(ignore, id)
+		}
+	}
+
+	workDir, relDir, err := harnessReport.ArtifactPath("zap")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(workDir, 0o755))
+
+	ctx, cancel := context.WithTimeout(suite.ctx, 15*time.Minute)
+	defer cancel()
+	target, network := suite.zapTarget()
+	name := "osyraa-zap-" + runID
+	scan := suite.cleanups.Add("ZAP container "+name, func(ctx context.Context) error { return removeContainer(ctx, suite.client, name) })
+	defer scan.Run(context.Background())
+	result, err := zap.Baseline(ctx, suite.client, target, zap.Options{Network: network, WorkDir: workDir, RunOptions: runOptions(name)})
+	require.NoError(t, err, "ZAP baseline scan should complete")
+
+	findings := result.Findings(minRisk, ignore)
+	table := &report.Table{Header: []string{"Risk", "Plugin", "Alert", "URLs"}}
+	for _, a := range findings {
+		table.Rows = append(table.Rows, []string{zap.RiskName(a.Risk()), a.PluginID, a.Name, fmt.Sprint(len(a.Instances))})
+		assert.Fail(t, "ZAP alert", "%s [%s] %s on %d URLs", a.RiskDesc, a.PluginID, a.Name, len(a.Instances))
+	}
+	section := report.Section{Title: "ZAP baseline scan", Status: report.Pass, Table: table,
+		Summary: fmt.Sprintf("%d alerts at %s or above; full report in %s/zap.html", len(findings), zap.RiskName(minRisk), relDir)}
+	if len(findings) > 0 {
+		section.Status = report.Fail
+	}
+	harnessReport.Add(section)
+}
+
+// TestMixedContentInBrowser loads every page in headless Chrome and fails on
+// http:// requests to other hosts, catching URLs assembled at runtime
+func (suite *DockerTestSuite) TestMixedContentInBrowser() {
+	t := suite.T()
+	b := newBrowser(t)
+
+	ctx, cancel := context.WithTimeout(suite.ctx, 5*time.Minute)
+	defer cancel()
+	start, _ := url.Parse(suite.siteURL + "/")
+	site, err := newCrawler(t, crawl.NewHTTPFetcher(nil)).Run(ctx, start)
+	require.NoError(t, err, "Crawling the container should succeed")
+
+	for _, page := range site.HTML() {
+		tab, closeTab := b.NewTab()
+		found, err := mixedcontent.Browse(tab, page.URL.String())
+		closeTab()
+		require.NoError(t, err, "Loading %s in the browser should succeed", page.URL.Path)
+		for _, f := range found {
+			t.Errorf("Mixed content: %s", f)
+		}
+	}
+}
+
+// performanceBudgets are the limits TestPerformanceBudgets holds pages to,
+// read from testdata/budgets.yaml or the file OSYRAA_BUDGETS names
+func performanceBudgets(t *testing.T) *perfbudget.File {
+	t.Helper()
+	path := os.Getenv("OSYRAA_BUDGETS")
+	if path == "" {
+		path = filepath.Join("testdata", "budgets.yaml")
+	}
+	budgets, err := perfbudget.Load(path)
+	require.NoError(t, err, "The performance budgets should load")
+	return budgets
+}
+
+// TestPerformanceBudgets loads every page of the container in a fresh
+// headless Chrome tab under a performance trace and holds it to its
+// budgets: LCP, total blocking time, CLS, page weight and request count.
+// Each failure names the budget and how far over it the page went.
+func (suite *DockerTestSuite) TestPerformanceBudgets() {
+	t := suite.T()
+	budgets := performanceBudgets(t)
+	b := newBrowser(t)
+
+	ctx, cancel := context.WithTimeout(suite.ctx, 5*time.Minute)
+	defer cancel()
+	start, _ := url.Parse(suite.siteURL + "/")
+	site, err := newCrawler(t, crawl.NewHTTPFetcher(nil)).Run(ctx, start)
+	require.NoError(t, err, "Crawling the container should succeed")
+
+	section := report.Section{Title: "Performance budgets", Status: report.Pass,
+		Table: &report.Table{Header: []string{"Page", "LCP", "TBT", "CLS", "Weight", "Requests", "Over budget"}}}
+	for _, page := range site.HTML() {
+		budget, ok := budgets.For(page.URL.Path)
+		if !ok {
+			continue
+		}
+		tab, closeTab := b.NewTab()
+		tabCtx, stop := context.WithTimeout(tab, time.Minute)
+		trace, err := browser.CaptureTrace(tabCtx, page.URL.String())
+		stop()
+		closeTab()
+		require.NoError(t, err, "Tracing %s should succeed", page.URL.Path)
+
+		m := perfbudget.Measure(trace)
+		var over []string
+		for _, v := range budget.Check(m) {
+			t.Errorf("%s: %s (budget for %s)", page.URL.Path, v, budget.Path)
+			over = append(over, v.String())
+		}
+		if len(over) > 0 {
+			section.Status = report.Fail
+		}
+		lcp := m.LCP.Round(time.Millisecond).String()
+		if m.NoLCP {
+			lcp = "no candidate"
+		}
+		section.Table.Rows = append(section.Table.Rows, []string{page.URL.Path,
+			lcp, m.TBT.Round(time.Millisecond).String(), fmt.Sprintf("%.3f", m.CLS),
+			m.PageWeight.String(), strconv.Itoa(m.Requests), strings.Join(over, "; ")})
+	}
+	section.Summary = fmt.Sprintf("%d pages measured", len(section.Table.Rows))
+	harnessReport.Add(section)
+}
+
+// TestCSPViolationsInBrowser loads every page in headless Chrome with the
+// site's policies reporting to a local collector, and fails on any
+// violation. OSYRAA_CSP_TRIAL adds a report-only policy to try out a
+// stricter policy against the real pages before shipping it.
+func (suite *DockerTestSuite) TestCSPViolationsInBrowser() {
+	t := suite.T()
+	b := newBrowser(t)
+
+	collector, err := cspreport.New()
+	require.NoError(t, err, "Failed to start the CSP report collector")
+	defer collector.Close()
+	trial := os.Getenv("OSYRAA_CSP_TRIAL")
+
+	ctx, cancel := context.WithTimeout(suite.ctx, 5*time.Minute)
+	defer cancel()
+	start, _ := url.Parse(suite.siteURL + "/")
+	site, err := newCrawler(t, crawl.NewHTTPFetcher(nil)).Run(ctx, start)
+	require.NoError(t, err, "Crawling the container should succeed")
+
+	for _, page := range site.HTML() {
+		tab, closeTab := b.NewTab()
+		err := browser.RewriteDocumentHeaders(tab, func(_ string, h http.Header) {
+			cspreport.Instrument(h, collector.URL(), trial)
+		})
+		if err == nil {
+			err = chromedp.Run(tab,
+				chromedp.Navigate(page.URL.String()),
+				chromedp.WaitReady("body"),
+				chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil),
+			)
+		}
+		closeTab()
+		require.NoError(t, err, "Loading %s in the browser should succeed", page.URL.Path)
+	}
+
+	for _, r := range collector.Wait(ctx, 2*time.Second) {
+		if !r.Extension() {
+			t.Errorf("CSP violation: %s", r)
+		}
+	}
+}
+
+// TestContentSecurityPolicy parses the policy every page is served with
+// and cross-references it with the page: scripts and plugins must be
+// restricted, by their own directives or default-src, no directive may
+// allow 'unsafe-inline' or 'unsafe-eval', every script, stylesheet, image and
+// inline block the HTML uses must be allowed, and a directive that no page
+// under the policy uses is reported as unused
+func (suite *DockerTestSuite) TestContentSecurityPolicy() {
+	t := suite.T()
+	ctx, cancel := context.WithTimeout(suite.ctx, settings.Timeouts.Crawl)
+	defer cancel()
+	start, _ := url.Parse(suite.siteURL + "/")
+	site, err := newCrawler(t, crawl.NewHTTPFetcher(nil)).Run(ctx, start)
+	require.NoError(t, err, "Crawling the container should succeed")
+
+	// Pages sharing a policy share its unused directives
+	type served struct {
+		policies  []csp.Policy
+		resources []csp.Resource
+	}
+	byHeader := map[string]*served{}
+	var headers []string
+	var problems []csp.Problem
+	for _, page := range site.HTML() {
+		values := page.Header.Values("Content-Security-Policy")
+		policies := csp.ParseHeader(values)
+		if len(policies) == 0 {
+			t.Errorf("%s is served without a Content-Security-Policy", page.URL.Path)
+			continue
+		}
+		resources := csp.Resources(page.Doc, page.URL)
+		problems = append(problems, csp.Blocked(policies, page.URL, resources)...)
+		key := strings.Join(values, "\n")
+		if byHeader[key] == nil {
+			byHeader[key] = &served{policies: policies}
+			headers = append(headers, key)
+		}
+		byHeader[key].resources = append(byHeader[key].resources, resources...)
+	}
+	var unused []csp.Problem
+	for _, key := range headers {
+		problems = append(problems, csp.Unrestricted(byHeader[key].policies)...)
+		for _, p := range byHeader[key].policies {
+			problems = append(problems, csp.Unsafe(p)...)
+			unused = append(unused, csp.Unused(p, byHeader[key].resources)...)
+		}
+	}
+
+	table := &report.Table{Header: []string{"Page", "Directive", "Problem"}}
+	for _, p := range problems {
+		table.Rows = append(table.Rows, []string{p.Page, p.Directive, p.Message})
+		t.Errorf("CSP: %s", p)
+	}
+	for _, p := range unused {
+		table.Rows = append(table.Rows, []string{"", p.Directive, p.Message})
+		t.Logf("CSP: unused directive %s", p)
+	}
+	section := report.Section{Title: "Content-Security-Policy", Status: report.Pass,
+		Summary: fmt.Sprintf("%d pages under %d policies", len(site.HTML()), len(headers))}
+	switch {
+	case len(problems) > 0:
+		section.Status = report.Fail
+	case len(unused) > 0:
+		section.Status = report.Warn
+	}
+	if len(table.Rows) > 0 {
+		section.Table = table
+	}
+	harnessReport.Add(section)
+}
+
+// compressionRatios are the least compression ratios TestCompression
+// holds each type to; OSYRAA_MIN_COMPRESSION_RATIO sets one for them all
+func compressionRatios(t *testing.T) map[string]float64 {
+	t.Helper()
+	v := os.Getenv("OSYRAA_MIN_COMPRESSION_RATIO")
+	if v == "" {
+		return compression.DefaultMinRatios
+	}
+	least, err := strconv.ParseFloat(v, 64)
+	require.NoError(t, err, "OSYRAA_MIN_COMPRESSION_RATIO should be a number")
+	ratios := map[string]float64{}
+	for typ := range compression.DefaultMinRatios {
+		ratios[typ] = least
+	}
+	return ratios
+}
+
+// compressible are the extensions of the files TestCompression fetches
+var compressible = []string{".html", ".css", ".js", ".json", ".svg"}
+
+// TestCompression fetches every HTML, CSS, JavaScript, JSON and SVG file
+// the container serves with Accept-Encoding: gzip, br and with gzip alone,
+// and requires a compressed response that says so in Content-Encoding and
+// Vary, decodes to the file, and is compressed by at least the type's
+// minimum ratio. nginx:alpine can't serve Brotli, so br is only required
+// with OSYRAA_BROTLI=1.
+func (suite *DockerTestSuite) TestCompression() {
+	t := suite.T()
+	ratios := compressionRatios(t)
+	// Smaller files are below gzip_min_length
+	const minSize = 1024
+	preferred := []string{compression.Gzip, compression.Brotli}
+	if os.Getenv("OSYRAA_BROTLI") == "1" {
+		preferred = []string{compression.Brotli}
+	}
+	checks := []compression.Options{
+		{Accept: "gzip, br", Want: preferred, MinRatios: ratios, MinSize: minSize},
+		{Accept: "gzip", MinRatios: ratios, MinSize: minSize},
+	}
+
+	var paths []string
+	for rel := range suite.servedTree() {
+		if slices.Contains(compressible, strings.ToLower(filepath.Ext(rel))) {
+			paths = append(paths, "/"+rel)
+		}
+	}
+	slices.Sort(paths)
+	require.NotEmpty(t, paths, "The container should serve HTML, CSS or JavaScript")
+
+	ctx, cancel := context.WithTimeout(suite.ctx, settings.Timeouts.Crawl)
+	defer cancel()
+	section := report.Section{Title: "Compression", Status: report.Pass,
+		Table: &report.Table{Header: []string{"Path", "Accept-Encoding", "Type", "Encoding", "Size", "Compressed", "Ratio", "Problems"}}}
+	for _, p := range paths {
+		for _, opts := range checks {
+			r, err := compression.Check(ctx, siteClient, suite.siteURL, p, opts)
+			require.NoError(t, err, "Fetching %s should succeed", p)
+			for _, problem := range r.Problems {
+				t.Errorf("%s with Accept-Encoding %s: %s", p, opts.Accept, problem)
+			}
+			if len(r.Problems) > 0 {
+				section.Status = report.Fail
+			}
+			section.Table.Rows = append(section.Table.Rows, []string{p, opts.Accept, r.ContentType, r.Encoding,
+				strconv.Itoa(r.Size), strconv.Itoa(r.Compressed), fmt.Sprintf("%.2f", r.Ratio()), strings.Join(r.Problems, "; ")})
+		}
+	}
+	section.Summary = fmt.Sprintf("%d files checked", len(paths))
+	harnessReport.Add(section)
+}
+
+// servedTree copies the web root out of the container and hashes it, so
+// checks compare against exactly what nginx serves
+func (suite *DockerTestSuite) servedTree() map[string]deploy.LocalFile {
+	t := suite.T()
+	rc, _, err := suite.client.CopyFromContainer(suite.ctx, suite.containerID, "/usr/share/nginx/html")
+	require.NoError(t, err, "Failed to copy the web root out of the container")
+	defer rc.Close()
+
+	dir := t.TempDir()
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err, "Failed to read the web root archive")
+		// Entries are prefixed with the copied directory's name
+		_, rel, _ := strings.Cut(hdr.Name, "/")
+		if hdr.Typeflag != tar.TypeReg || rel == "" {
+			continue
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(dest), 0o755))
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(dest, data, 0o644))
+	}
+	tree, err := deploy.LocalTree(dir)
+	require.NoError(t, err, "Failed to hash the web root")
+	return tree
+}
+
+// TestDirectoryIndexes requests every directory with and without a trailing
+// slash: autoindex must be off, directories serve their index.html, and
+// slashless paths redirect without losing the published port
+func (suite *DockerTestSuite) TestDirectoryIndexes() {
+	t := suite.T()
+
+	tree := suite.servedTree()
+	problems, err := deploy.CheckDirectories(suite.ctx, newTarget(suite.siteURL), tree)
+	require.NoError(t, err, "Directory requests should complete")
+	for _, p := range problems {
+		t.Error(p)
+	}
+	t.Logf("Checked %d directories", len(deploy.Directories(tree)))
+}
 
-	// Check for security headers
-	xFrameOptions := resp.Header.Get("X-Frame-Options")
-	assert.NotEmpty(t, xFrameOptions, "X-Frame-Options header should be set")
-	assert.Equal(t, "SAMEORIGIN", xFrameOptions, "X-Frame-Options should be SAMEORIGIN")
+// TestRedirects checks the served slash policy rather than nginx.conf:
+// every file answers at its canonical path without a redirect, the other
+// slash form of a page redirects there in one hop, and no redirect chains
+// or loops
+func (suite *DockerTestSuite) TestRedirects() {
+	t := suite.T()
+
+	problems, err := deploy.CheckRedirects(suite.ctx, newTarget(suite.siteURL), suite.servedTree())
+	require.NoError(t, err, "Redirect checks should complete")
+	for _, p := range problems {
+		t.Error(p)
+	}
+}
+
+// TestSensitiveFiles probes for repository internals and backups over HTTP
+// and scans the web root for them, catching a build context that copies
+// more than the Hugo output into the image
+func (suite *DockerTestSuite) TestSensitiveFiles() {
+	t := suite.T()
+
+	assert.NoError(t, battery.CheckSensitiveFiles(suite.ctx, newTarget(suite.siteURL)),
+		"Sensitive paths should return 404 or 403")
+	for p := range suite.servedTree() {
+		if battery.IsSensitive(p) {
+			t.Errorf("Web root contains %s", p)
+		}
+	}
+}
+
+// TestMalformedRequests sends malformed request lines, headers and bodies
+// over raw TCP. Each must get a 4xx without leaking the server version, and
+// the container must still be up and serving afterwards.
+func (suite *DockerTestSuite) TestMalformedRequests() {
+	t := suite.T()
 
-	xContentType := resp.Header.Get("X-Content-Type-Options")
-	assert.NotEmpty(t, xContentType, "X-Content-Type-Options header should be set")
-	assert.Equal(t, "nosniff", xContentType, "X-Content-Type-Options should be nosniff")
+	for _, r := range httpfuzz.Run(suite.ctx, suite.siteAddr, httpfuzz.Cases()) {
+		if p := r.Problem(); p != "" {
+			t.Error(p)
+		}
+	}
+
+	inspect, err := suite.client.ContainerInspect(suite.ctx, suite.containerID)
+	require.NoError(t, err, "Failed to inspect container")
+	assert.True(t, inspect.State.Running, "Container should survive malformed requests")
+	assert.Zero(t, inspect.RestartCount, "Container should not have restarted")
+
+	resp, err := suite.get("/")
+	require.NoError(t, err, "Site should still respond after fuzzing")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// TestPathTraversal probes for escapes from the web root with encoded,
+// null-byte and Windows-style paths. Anything that is not refused must be
+// the SPA fallback, i.e. the home page.
+func (suite *DockerTestSuite) TestPathTraversal() {
+	t := suite.T()
 
-	xXSSProtection := resp.Header.Get("X-XSS-Protection")
-	assert.NotEmpty(t, xXSSProtection, "X-XSS-Protection header should be set")
+	resp, err := suite.get("/")
+	require.NoError(t, err, "Failed to fetch home page")
+	home, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+
+	for _, p := range httpfuzz.CheckTraversal(suite.ctx, suite.siteAddr, string(home)) {
+		t.Error(p)
+	}
+}
+
+// TestWebFontHeaders checks the container serves each self-hosted WOFF2
+// as font/woff2 and lets browsers cache it for at least a month
+func (suite *DockerTestSuite) TestWebFontHeaders() {
+	t := suite.T()
+
+	ctx, cancel := context.WithTimeout(suite.ctx, settings.Timeouts.Crawl)
+	defer cancel()
+	start, _ := url.Parse(suite.siteURL + "/")
+	fetcher := crawl.NewHTTPFetcher(nil)
+	site, err := newCrawler(t, fetcher).Run(ctx, start)
+	require.NoError(t, err, "Crawling the container should succeed")
+
+	faces := fonts.Faces(site.Pages)
+	if len(faces) == 0 {
+		t.Log("No @font-face rules; the site uses system fonts")
+		return
+	}
+	problems, err := fonts.Check(ctx, fetcher, faces, fonts.Options{MinCacheAge: 30 * 24 * time.Hour})
+	require.NoError(t, err, "Fetching font files should succeed")
+	for _, p := range problems {
+		t.Error(p)
+	}
 }
 
 // TestNginxStatus tests the nginx status endpoint
@@ -347,12 +1526,214 @@ func (suite *DockerTestSuite) TestNginxStatus() {
 	assert.Contains(t, outputStr, "Active connections", "Nginx status should show active connections")
 }
 
+// startScratch starts a container of the suite's image apart from the
+// suite's own, for tests that break or replace it. host is completed with
+// the suite's confinement and an ephemeral port on the loopback; the
+// container is removed at teardown. It returns once the site answers.
+func (suite *DockerTestSuite) startScratch(host *container.HostConfig) (id, baseURL string) {
+	return suite.startImage(suite.imageTag, nil, host)
+}
+
+// startImage starts a container of image like startScratch, for platform
+// when it isn't nil
+func (suite *DockerTestSuite) startImage(image string, platform *ocispec.Platform, host *container.HostConfig) (id, baseURL string) {
+	t := suite.T()
+	var p string
+	if platform != nil {
+		p = platform.OS + "/" + platform.Architecture
+		if platform.Variant != "" {
+			p += "/" + platform.Variant
+		}
+	}
+	c, baseURL, _, err := suite.runSite(image, p, "", func(hc *container.HostConfig) {
+		*hc = *host
+	})
+	require.NoError(t, err, "A scratch container should start serving")
+	return c.GetContainerID(), baseURL
+}
+
+// execInContainer runs a command in the test container and returns its exit code
+func (suite *DockerTestSuite) execInContainer(ctx context.Context, cmd []string) (int, error) {
+	execResp, err := suite.client.ContainerExecCreate(ctx, suite.containerID, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	attachResp, err := suite.client.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{})
+	if err != nil {
+		return 0, err
+	}
+	defer attachResp.Close()
+	io.Copy(io.Discard, attachResp.Reader)
+
+	inspect, err := suite.client.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return 0, err
+	}
+	return inspect.ExitCode, nil
+}
+
+// outputInContainer runs a command in the test container and returns its stdout
+func (suite *DockerTestSuite) outputInContainer(ctx context.Context, cmd []string) (string, error) {
+	return suite.outputIn(ctx, suite.containerID, cmd)
+}
+
+// outputIn runs a command in container id and returns its stdout. Output
+// on stderr is an error.
+func (suite *DockerTestSuite) outputIn(ctx context.Context, id string, cmd []string) (string, error) {
+	execResp, err := suite.client.ContainerExecCreate(ctx, id, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	attachResp, err := suite.client.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{})
+	if err != nil {
+		return "", err
+	}
+	defer attachResp.Close()
+	var stdout, stderr strings.Builder
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attachResp.Reader); err != nil {
+		return "", err
+	}
+	if stderr.Len() > 0 {
+		return stdout.String(), fmt.Errorf("%v: %s", cmd, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// TestRuntimePrivileges enforces the container's confinement instead of
+// assuming it: the runtime configuration must not be privileged, share
+// host namespaces, map devices or mount sensitive host paths, and must set
+// no-new-privileges. The kernel's view of the server (PID 1) and of a
+// process exec'd alongside it must agree.
+func (suite *DockerTestSuite) TestRuntimePrivileges() {
+	t := suite.T()
+
+	inspect, err := suite.client.ContainerInspect(suite.ctx, suite.containerID)
+	require.NoError(t, err, "Failed to inspect container")
+	assert.NoError(t, hardening.CheckHostConfig(inspect.HostConfig), "Container runtime configuration should be confined")
+
+	for _, proc := range []string{"/proc/1/status", "/proc/self/status"} {
+		status, err := suite.outputInContainer(suite.ctx, []string{"cat", proc})
+		require.NoError(t, err, "Failed to read %s", proc)
+		assert.NoError(t, hardening.CheckProcStatus(status), "%s should show a confined process", proc)
+	}
+}
+
+// TestProbeConformance runs the probes declared in the deployment manifest
+// against the container, with the manifest's timeouts and thresholds
+func (suite *DockerTestSuite) TestProbeConformance() {
+	t := suite.T()
+
+	objs, err := kube.ReadManifests(manifestPath)
+	require.NoError(t, err, "Failed to read manifests")
+	deployment := kube.Find(objs, "Deployment", "resume")
+	require.NotNil(t, deployment, "Manifests should contain the resume Deployment")
+
+	probes, err := kube.Probes(deployment, "resume")
+	require.NoError(t, err, "Failed to parse probes")
+	require.NotEmpty(t, probes, "The resume container should declare probes")
+
+	env := kube.ProbeEnv{
+		Addr: func(port int) (string, error) {
+			if port != 80 {
+				return "", fmt.Errorf("container port %d is not published", port)
+			}
+			return suite.siteAddr, nil
+		},
+		Exec:    suite.execInContainer,
+		Started: suite.startedAt,
+	}
+
+	for _, probe := range probes {
+		ctx, cancel := context.WithTimeout(suite.ctx, probe.Window()+probe.Timeout)
+		outcome, err := probe.Run(ctx, env)
+		cancel()
+
+		assert.NoError(t, err, "%s probe should pass within its failure window", probe.Kind)
+		assert.Less(t, outcome.Slowest, probe.Timeout, "%s probe should answer within timeoutSeconds", probe.Kind)
+		t.Logf("%s probe: %d/%d attempts succeeded, slowest %v", probe.Kind, outcome.Successes, outcome.Attempts, outcome.Slowest)
+	}
+}
+
+// TestGitOpsDrift fails when the GitOps manifests would deploy an image
+// other than the one this suite built and validated. OSYRAA_IMAGE names the
+// reference the tested image is published under; every manifest reference
+// must be in its repository, and is pulled so the image the registry
+// serves for it can be compared with the tested one by ID.
+func (suite *DockerTestSuite) TestGitOpsDrift() {
+	t := suite.T()
+
+	published := expectedImage()
+	if published == "" {
+		t.Skip("set OSYRAA_IMAGE to the published image reference to check for drift")
+	}
+	requireNetwork(t)
+
+	inspect, _, err := suite.client.ImageInspectWithRaw(suite.ctx, suite.imageTag)
+	require.NoError(t, err, "Failed to inspect tested image")
+
+	objs, err := kube.ReadManifestDir(gitopsDir)
+	require.NoError(t, err, "Failed to read GitOps manifests")
+	refs := kube.ImageRefs(objs, "resume")
+	require.NotEmpty(t, refs, "GitOps manifests should reference the resume image")
+
+	section := report.Section{Title: "GitOps drift", Status: report.Pass,
+		Table: &report.Table{Header: []string{"Manifest image", "Result"}}}
+	for _, ref := range refs {
+		err := kube.CheckReference(ref, published)
+		if err == nil {
+			var deployedID string
+			deployedID, err = suite.pulledImageID(ref)
+			if err == nil {
+				err = kube.CheckDrift(ref, deployedID, inspect.ID)
+			}
+		}
+		result := "matches the tested image"
+		if err != nil {
+			t.Errorf("Manifest image %s should match the tested image: %v", ref, err)
+			section.Status = report.Fail
+			result = err.Error()
+		}
+		section.Table.Rows = append(section.Table.Rows, []string{ref, result})
+	}
+	section.Summary = fmt.Sprintf("%d manifest image references compared with the tested image %s", len(refs), inspect.ID)
+	harnessReport.Add(section)
+}
+
+// pulledImageID pulls ref and returns its image ID. A reference the host
+// didn't already have is removed again at teardown.
+func (suite *DockerTestSuite) pulledImageID(ref string) (string, error) {
+	if _, _, err := suite.client.ImageInspectWithRaw(suite.ctx, ref); client.IsErrNotFound(err) {
+		suite.cleanups.Add("image "+ref, func(ctx context.Context) error { return removeImage(ctx, suite.client, ref) })
+	}
+	rc, err := suite.client.ImagePull(suite.ctx, ref, types.ImagePullOptions{})
+	if err != nil {
+		return "", fmt.Errorf("pull %s: %w", ref, err)
+	}
+	defer rc.Close()
+	if err := jsonmessage.DisplayJSONMessagesStream(rc, io.Discard, 0, false, nil); err != nil {
+		return "", fmt.Errorf("pull %s: %w", ref, err)
+	}
+	pulled, _, err := suite.client.ImageInspectWithRaw(suite.ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	return pulled.ID, nil
+}
+
 // TestResponseTime checks response time is acceptable
 func (suite *DockerTestSuite) TestResponseTime() {
 	t := suite.T()
 
 	start := time.Now()
-	resp, err := http.Get("http://localhost:8080/")
+	resp, err := suite.get("/")
 	duration := time.Since(start)
 
 	require.NoError(t, err, "HTTP request should succeed")
@@ -403,11 +1784,16 @@ func (suite *DockerTestSuite) TestMultiStageBuild() {
 	t.Logf("Multi-stage build evidence: %v", foundHugo)
 }
 
-// Run test suites
+// Run test suites. Each suite keeps its state on its own instance and
+// names its output after the run, so the suites run in parallel. The
+// tests of a suite run in name order and share what its SetupSuite
+// built.
 func TestHugoSuite(t *testing.T) {
+	t.Parallel()
 	suite.Run(t, new(HugoTestSuite))
 }
 
 func TestDockerSuite(t *testing.T) {
+	t.Parallel()
 	suite.Run(t, new(DockerTestSuite))
 }
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGitHubPagesParity compares the GitHub Pages deployment at
+// OSYRAA_PAGES_URL with the locally built public/ page by page
+func TestGitHubPagesParity(t *testing.T) {
+	requireNetwork(t)
+	url := os.Getenv("OSYRAA_PAGES_URL")
+	if url == "" {
+		t.Skip("set OSYRAA_PAGES_URL to compare the GitHub Pages deployment")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	local, err := deploy.LocalTree(publicDir(t))
+	require.NoError(t, err, "Failed to hash local public/")
+	require.NotEmpty(t, local, "public/ should be built before checking parity")
+
+	report, err := deploy.Parity(ctx, newTarget(url), local)
+	require.NoError(t, err, "Failed to fetch the Pages deployment")
+
+	assert.Empty(t, report.Missing, "Pages should serve every built file")
+	assert.Empty(t, report.Stale, "Pages should serve the current build")
+	for path, diffs := range report.Headers {
+		t.Logf("%s: %v", path, diffs)
+	}
+}
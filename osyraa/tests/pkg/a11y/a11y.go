@@ -0,0 +1,364 @@
+// Package a11y checks pages against baseline WCAG 2.1 AA rules. The
+// structural rules (document language and title, image alternatives,
+// landmarks, heading order, and names for form fields, links and buttons)
+// read the markup, so they run on public/ without a browser or on the DOM
+// a browser rendered. Colour contrast needs computed styles, so Contrast
+// measures it in a headless Chrome tab.
+package a11y
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"golang.org/x/net/html"
+)
+
+// Rules
+const (
+	DocumentLanguage = "html-lang"
+	PageTitle        = "page-title"
+	ImageAlt         = "image-alt"
+	Landmarks        = "landmarks"
+	HeadingOrder     = "heading-order"
+	FormLabel        = "form-label"
+	LinkName         = "link-name"
+	ButtonName       = "button-name"
+	ColorContrast    = "color-contrast"
+)
+
+// Criteria maps each rule to its WCAG 2.1 success criteria
+var Criteria = map[string]string{
+	DocumentLanguage: "3.1.1",
+	PageTitle:        "2.4.2",
+	ImageAlt:         "1.1.1",
+	Landmarks:        "1.3.1, 2.4.1",
+	HeadingOrder:     "1.3.1, 2.4.6",
+	FormLabel:        "1.3.1, 4.1.2",
+	LinkName:         "2.4.4, 4.1.2",
+	ButtonName:       "4.1.2",
+	ColorContrast:    "1.4.3",
+}
+
+// Issue is one failure of a rule on a page
+type Issue struct {
+	Page string
+	Rule string
+	// Element describes the offending element, e.g. `img src="me.png"`
+	Element string
+	Message string
+}
+
+// String formats the issue for test output
+func (i Issue) String() string {
+	s := fmt.Sprintf("%s: %s (WCAG %s): %s", i.Page, i.Rule, Criteria[i.Rule], i.Message)
+	if i.Element != "" {
+		s += ": <" + i.Element + ">"
+	}
+	return s
+}
+
+// Check runs the structural rules on a parsed page
+func Check(page string, doc *match.Document) []Issue {
+	c := &checker{page: page, doc: doc, ids: map[string]*html.Node{}}
+	walk(doc.Root(), func(n *html.Node) {
+		if id, ok := match.Attr(n, "id"); ok {
+			c.ids[id] = n
+		}
+	})
+	c.document()
+	c.images()
+	c.landmarks()
+	c.headings()
+	c.formFields()
+	c.names()
+	return c.issues
+}
+
+// Dir runs the structural rules on every HTML file under root, naming
+// each issue's page by its path relative to root
+func Dir(root string) ([]Issue, error) {
+	var issues []Issue
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".html") {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		doc, err := match.Parse(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		rel, _ := filepath.Rel(root, file)
+		issues = append(issues, Check(filepath.ToSlash(rel), doc)...)
+		return nil
+	})
+	return issues, err
+}
+
+type checker struct {
+	page   string
+	doc    *match.Document
+	ids    map[string]*html.Node
+	issues []Issue
+}
+
+func (c *checker) add(rule string, n *html.Node, format string, args ...any) {
+	issue := Issue{Page: c.page, Rule: rule, Message: fmt.Sprintf(format, args...)}
+	if n != nil {
+		issue.Element = describe(n)
+	}
+	c.issues = append(c.issues, issue)
+}
+
+func (c *checker) document() {
+	if lang, _ := c.doc.Select("html").Attr("lang"); strings.TrimSpace(lang) == "" {
+		c.add(DocumentLanguage, nil, "<html> has no lang attribute")
+	}
+	if strings.TrimSpace(c.doc.Select("head title").Text()) == "" {
+		c.add(PageTitle, nil, "the page has no title")
+	}
+}
+
+func (c *checker) images() {
+	for _, n := range c.doc.Select(`img, input[type=image i], area[href]`).Nodes() {
+		if _, ok := match.Attr(n, "alt"); !ok && c.ariaName(n) == "" && !hidden(n) {
+			c.add(ImageAlt, n, "image has no text alternative; use alt=\"\" if it is decorative")
+		}
+	}
+	for _, n := range c.doc.Select(`svg[role=img], [role=img]:not(svg):not(img)`).Nodes() {
+		if c.ariaName(n) != "" || hidden(n) {
+			continue
+		}
+		if title := firstChild(n, "title"); title == nil || strings.TrimSpace(match.Text(title)) == "" {
+			c.add(ImageAlt, n, "role=img has no accessible name")
+		}
+	}
+}
+
+func (c *checker) landmarks() {
+	mains := c.doc.Select(`main, [role=main]`).Nodes()
+	switch {
+	case len(mains) == 0:
+		c.add(Landmarks, nil, "the page has no <main> landmark")
+	case len(mains) > 1:
+		c.add(Landmarks, mains[1], "the page has %d main landmarks; it should have one", len(mains))
+	}
+	// Top-level banner and contentinfo landmarks are unique like main;
+	// headers and footers inside sections are not landmarks at all
+	for _, l := range []struct{ selector, role string }{
+		{`header, [role=banner]`, "banner"},
+		{`footer, [role=contentinfo]`, "contentinfo"},
+	} {
+		var top []*html.Node
+		for _, n := range c.doc.Select(l.selector).Nodes() {
+			if role, ok := match.Attr(n, "role"); ok && role == l.role || !sectioned(n) {
+				top = append(top, n)
+			}
+		}
+		if len(top) > 1 {
+			c.add(Landmarks, top[1], "the page has %d %s landmarks; it should have at most one", len(top), l.role)
+		}
+	}
+}
+
+func (c *checker) headings() {
+	headings := c.doc.Select(`h1, h2, h3, h4, h5, h6`).Nodes()
+	h1s := 0
+	last := 0
+	for _, n := range headings {
+		if hidden(n) {
+			continue
+		}
+		level := int(n.Data[1] - '0')
+		if level == 1 {
+			h1s++
+		}
+		if strings.TrimSpace(match.Text(n)) == "" && c.ariaName(n) == "" {
+			c.add(HeadingOrder, n, "heading is empty")
+		}
+		if last > 0 && level > last+1 {
+			c.add(HeadingOrder, n, "h%d follows h%d, skipping a level", level, last)
+		} else if last == 0 && level != 1 {
+			c.add(HeadingOrder, n, "the first heading is h%d, not h1", level)
+		}
+		last = level
+	}
+	if h1s != 1 {
+		c.add(HeadingOrder, nil, "the page has %d h1 headings; it should have one", h1s)
+	}
+}
+
+func (c *checker) formFields() {
+	fields := c.doc.Select(`input:not([type=hidden i]):not([type=submit i]):not([type=reset i]):not([type=button i]):not([type=image i]), select, textarea`).Nodes()
+	for _, n := range fields {
+		if c.ariaName(n) != "" || hidden(n) {
+			continue
+		}
+		if id, ok := match.Attr(n, "id"); ok && id != "" && c.doc.Select(fmt.Sprintf(`label[for=%q]`, id)).Len() > 0 {
+			continue
+		}
+		if ancestor(n, "label") != nil {
+			continue
+		}
+		if title, _ := match.Attr(n, "title"); strings.TrimSpace(title) != "" {
+			continue
+		}
+		c.add(FormLabel, n, "form field has no label")
+	}
+}
+
+func (c *checker) names() {
+	for _, n := range c.doc.Select(`a[href]`).Nodes() {
+		if !hidden(n) && c.accessibleText(n) == "" {
+			c.add(LinkName, n, "link has no text, label or image alternative")
+		}
+	}
+	for _, n := range c.doc.Select(`button, [role=button], input[type=submit i], input[type=reset i], input[type=button i]`).Nodes() {
+		if n.Data == "input" {
+			// Submit and reset buttons have a default name
+			if t := strings.ToLower(attr(n, "type")); t == "submit" || t == "reset" || strings.TrimSpace(attr(n, "value")) != "" {
+				continue
+			}
+		}
+		if !hidden(n) && c.accessibleText(n) == "" {
+			c.add(ButtonName, n, "button has no text or label")
+		}
+	}
+}
+
+// ariaName is the name aria-labelledby or aria-label give n
+func (c *checker) ariaName(n *html.Node) string {
+	if ids, ok := match.Attr(n, "aria-labelledby"); ok {
+		var parts []string
+		for _, id := range strings.Fields(ids) {
+			if ref := c.ids[id]; ref != nil {
+				parts = append(parts, match.Text(ref))
+			}
+		}
+		if name := strings.TrimSpace(strings.Join(parts, " ")); name != "" {
+			return name
+		}
+	}
+	label, _ := match.Attr(n, "aria-label")
+	return strings.TrimSpace(label)
+}
+
+// accessibleText approximates the name a link or button gets from its
+// content: text, and the alt text or label of images inside it
+func (c *checker) accessibleText(n *html.Node) string {
+	if name := c.ariaName(n); name != "" {
+		return name
+	}
+	if title, _ := match.Attr(n, "title"); strings.TrimSpace(title) != "" {
+		return title
+	}
+	var parts []string
+	var text func(d *html.Node)
+	text = func(d *html.Node) {
+		switch {
+		case d.Type == html.TextNode:
+			parts = append(parts, d.Data)
+			return
+		case d.Type != html.ElementNode:
+		case d != n && hidden(d):
+			return
+		case d.Data == "img" || d.Data == "area":
+			parts = append(parts, attr(d, "alt"))
+		case d != n:
+			if name := c.ariaName(d); name != "" {
+				parts = append(parts, name)
+				return
+			}
+		}
+		for child := d.FirstChild; child != nil; child = child.NextSibling {
+			text(child)
+		}
+	}
+	text(n)
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// hidden reports whether n, or an ancestor, is removed from the
+// accessibility tree by markup alone
+func hidden(n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if v, _ := match.Attr(n, "aria-hidden"); v == "true" {
+			return true
+		}
+		if _, ok := match.Attr(n, "hidden"); ok {
+			return true
+		}
+		if n.Data == "template" {
+			return true
+		}
+	}
+	return false
+}
+
+// sectioned reports whether n is inside sectioning content, where header
+// and footer are not landmarks
+func sectioned(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		switch p.Data {
+		case "article", "aside", "main", "nav", "section":
+			return true
+		}
+	}
+	return false
+}
+
+func ancestor(n *html.Node, tag string) *html.Node {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == tag {
+			return p
+		}
+	}
+	return nil
+}
+
+func firstChild(n *html.Node, tag string) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return c
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, name string) string {
+	v, _ := match.Attr(n, name)
+	return v
+}
+
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+// describe renders an element's start tag with the attributes that
+// identify it
+func describe(n *html.Node) string {
+	var b strings.Builder
+	b.WriteString(n.Data)
+	for _, key := range []string{"id", "class", "href", "src", "name", "type", "role"} {
+		if v, ok := match.Attr(n, key); ok {
+			if len(v) > 60 {
+				v = v[:57] + "..."
+			}
+			fmt.Fprintf(&b, " %s=%q", key, v)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,156 @@
+package a11y
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func check(t *testing.T, body string) []Issue {
+	t.Helper()
+	doc, err := match.Parse(strings.NewReader(`<!DOCTYPE html><html lang="en"><head><title>Resume</title></head><body>` + body + `</body></html>`))
+	require.NoError(t, err)
+	return Check("index.html", doc)
+}
+
+func rules(issues []Issue) []string {
+	var out []string
+	for _, i := range issues {
+		out = append(out, i.Rule)
+	}
+	return out
+}
+
+const accessible = `<header><nav><a href="/">Home</a></nav></header>
+<main>
+<h1>Princeton A. Strong</h1>
+<section><header><h2>Experience</h2></header><h3>Engineer</h3><img src="me.png" alt="Portrait"></section>
+<form><label for="q">Search</label><input id="q" type="search"><label>Email <input type="email"></label>
+<input type="hidden" name="token"><input type="submit"><button><svg aria-hidden="true"></svg>Send</button></form>
+<a href="/cv.pdf"><img src="pdf.svg" alt="Download PDF"></a>
+<a href="https://github.com/" aria-label="GitHub"><svg></svg></a>
+</main>
+<footer>&copy; 2026</footer>`
+
+func TestCheckAccessible(t *testing.T) {
+	assert.Empty(t, check(t, accessible))
+}
+
+func TestCheckDocument(t *testing.T) {
+	doc, err := match.Parse(strings.NewReader(`<html><head></head><body><main><h1>Hi</h1></main></body></html>`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{DocumentLanguage, PageTitle}, rules(Check("index.html", doc)))
+}
+
+func TestCheckRules(t *testing.T) {
+	for _, tc := range []struct {
+		name, body string
+		want       []string
+	}{
+		{"image without alt", `<main><h1>A</h1><img src="me.png"></main>`, []string{ImageAlt}},
+		{"hidden image", `<main><h1>A</h1><div aria-hidden="true"><img src="x.png"></div></main>`, nil},
+		{"unnamed role=img", `<main><h1>A</h1><svg role="img"></svg></main>`, []string{ImageAlt}},
+		{"svg with title", `<main><h1>A</h1><svg role="img"><title>Logo</title></svg></main>`, nil},
+		{"no main", `<h1>A</h1>`, []string{Landmarks}},
+		{"two mains", `<main><h1>A</h1></main><main></main>`, []string{Landmarks}},
+		{"two banners", `<header></header><header></header><main><h1>A</h1></main>`, []string{Landmarks}},
+		{"skipped level", `<main><h1>A</h1><h3>B</h3></main>`, []string{HeadingOrder}},
+		{"starts at h2", `<main><h2>A</h2><h1>B</h1></main>`, []string{HeadingOrder}},
+		{"two h1", `<main><h1>A</h1><h1>B</h1></main>`, []string{HeadingOrder}},
+		{"empty heading", `<main><h1>A</h1><h2></h2></main>`, []string{HeadingOrder}},
+		{"unlabelled field", `<main><h1>A</h1><input type="text"><textarea></textarea></main>`, []string{FormLabel, FormLabel}},
+		{"aria-labelledby", `<main><h1 id="t">A</h1><input aria-labelledby="t"></main>`, nil},
+		{"empty link", `<main><h1>A</h1><a href="/x"><img src="x.png" alt=""></a></main>`, []string{LinkName}},
+		{"hidden link text", `<main><h1>A</h1><a href="/x"><span aria-hidden="true">→</span></a></main>`, []string{LinkName}},
+		{"empty button", `<main><h1>A</h1><button><svg></svg></button><input type="button"></main>`, []string{ButtonName, ButtonName}},
+		{"titled button", `<main><h1>A</h1><button title="Menu"></button></main>`, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, rules(check(t, tc.body)))
+		})
+	}
+}
+
+func TestIssueString(t *testing.T) {
+	issues := check(t, `<main><h1>A</h1><img src="me.png" class="avatar"></main>`)
+	require.Len(t, issues, 1)
+	assert.Equal(t, `index.html: image-alt (WCAG 1.1.1): image has no text alternative; use alt="" if it is decorative: <img class="avatar" src="me.png">`, issues[0].String())
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "about"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "about", "index.html"), []byte(`<html><body><h1>About</h1></body></html>`), 0o644))
+	issues, err := Dir(dir)
+	require.NoError(t, err)
+	require.NotEmpty(t, issues)
+	assert.Equal(t, "about/index.html", issues[0].Page)
+}
+
+func TestParseColor(t *testing.T) {
+	for in, want := range map[string]Color{
+		"rgb(255, 0, 0)":           {1, 0, 0, 1},
+		"rgba(0, 0, 0, 0.5)":       {0, 0, 0, 0.5},
+		"rgba(0, 0, 0, 0)":         {},
+		"transparent":              {},
+		"color(srgb 0 0.5 1)":      {0, 0.5, 1, 1},
+		"color(srgb 1 1 1 / 0.25)": {1, 1, 1, 0.25},
+		"rgb(100% 0% 0% / 50%)":    {1, 0, 0, 0.5},
+	} {
+		got, ok := ParseColor(in)
+		assert.True(t, ok, in)
+		assert.InDelta(t, want.R, got.R, 0.001, in)
+		assert.InDelta(t, want.G, got.G, 0.001, in)
+		assert.InDelta(t, want.B, got.B, 0.001, in)
+		assert.InDelta(t, want.A, got.A, 0.001, in)
+	}
+	for _, in := range []string{"oklch(0.5 0.1 200)", "color(display-p3 1 0 0)", "red", "rgb(1, 2)"} {
+		_, ok := ParseColor(in)
+		assert.False(t, ok, in)
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	black, _ := ParseColor("rgb(0, 0, 0)")
+	grey, _ := ParseColor("rgb(119, 119, 119)")
+	assert.InDelta(t, 21, ContrastRatio(black, white), 0.01)
+	assert.InDelta(t, 21, ContrastRatio(white, black), 0.01)
+	assert.InDelta(t, 4.48, ContrastRatio(grey, white), 0.01, "#777 on white is just below AA")
+
+	half := Color{0, 0, 0, 0.5}
+	assert.Equal(t, "#808080", half.Over(white).String())
+
+	assert.True(t, LargeText(24, 400))
+	assert.True(t, LargeText(18.67, 700))
+	assert.False(t, LargeText(18.67, 400))
+}
+
+func TestTextStyleCheck(t *testing.T) {
+	grey := textStyle{Element: "p.muted", Text: "Updated", Color: "rgb(119, 119, 119)",
+		Backgrounds: []string{"rgba(0, 0, 0, 0)", "rgb(255, 255, 255)"}, FontSize: 16, FontWeight: "400"}
+	issue, ok := grey.check()
+	assert.False(t, ok)
+	assert.Equal(t, ColorContrast, issue.Rule)
+	assert.Contains(t, issue.Message, "4.48:1, below 4.5:1 (#777777 on #ffffff at 16px)")
+
+	large := grey
+	large.FontSize = 32
+	_, ok = large.check()
+	assert.True(t, ok, "large text needs only 3:1")
+
+	overImage := grey
+	overImage.Image = true
+	_, ok = overImage.check()
+	assert.True(t, ok, "text over a background image is not measured")
+
+	dark := grey
+	dark.Color = "rgb(230, 230, 230)"
+	dark.Backgrounds = []string{"rgba(0, 0, 0, 0.9)"}
+	_, ok = dark.check()
+	assert.True(t, ok, "a translucent background is composited over the white canvas")
+}
@@ -0,0 +1,199 @@
+package a11y
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Minimum contrast ratios WCAG 2.1 AA requires of text
+const (
+	MinContrast      = 4.5
+	MinContrastLarge = 3.0
+)
+
+// Color is an sRGB colour with channels and alpha from 0 to 1
+type Color struct {
+	R, G, B, A float64
+}
+
+// white is the canvas behind a page that sets no background
+var white = Color{1, 1, 1, 1}
+
+// ParseColor reads a computed CSS colour: rgb(), rgba() or color(srgb ...),
+// the forms getComputedStyle returns for sRGB colours
+func ParseColor(s string) (Color, bool) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "transparent" {
+		return Color{}, true
+	}
+	open, end := strings.IndexByte(s, '('), strings.LastIndexByte(s, ')')
+	if open < 0 || end < open {
+		return Color{}, false
+	}
+	fn, args := s[:open], strings.NewReplacer(",", " ", "/", " ").Replace(s[open+1:end])
+	fields := strings.Fields(args)
+	scale := 255.0
+	switch fn {
+	case "rgb", "rgba":
+	case "color":
+		if len(fields) == 0 || fields[0] != "srgb" {
+			return Color{}, false
+		}
+		fields, scale = fields[1:], 1
+	default:
+		return Color{}, false
+	}
+	if len(fields) != 3 && len(fields) != 4 {
+		return Color{}, false
+	}
+	v := [4]float64{0, 0, 0, 1}
+	for i, f := range fields {
+		full := scale
+		if i == 3 {
+			full = 1
+		}
+		if strings.HasSuffix(f, "%") {
+			f, full = strings.TrimSuffix(f, "%"), 100
+		}
+		n, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return Color{}, false
+		}
+		v[i] = math.Min(math.Max(n/full, 0), 1)
+	}
+	return Color{v[0], v[1], v[2], v[3]}, true
+}
+
+// Over composites c over an opaque background
+func (c Color) Over(bg Color) Color {
+	mix := func(fg, bg float64) float64 { return fg*c.A + bg*(1-c.A) }
+	return Color{mix(c.R, bg.R), mix(c.G, bg.G), mix(c.B, bg.B), 1}
+}
+
+// String formats the colour as #rrggbb, ignoring alpha
+func (c Color) String() string {
+	hex := func(v float64) int { return int(math.Round(v * 255)) }
+	return fmt.Sprintf("#%02x%02x%02x", hex(c.R), hex(c.G), hex(c.B))
+}
+
+// Luminance is the colour's relative luminance as WCAG defines it
+func (c Color) Luminance() float64 {
+	linear := func(v float64) float64 {
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+// ContrastRatio is the WCAG contrast ratio of two opaque colours, from 1
+// to 21
+func ContrastRatio(a, b Color) float64 {
+	la, lb := a.Luminance(), b.Luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// LargeText reports whether text of this computed size in CSS pixels and
+// weight counts as large, which needs less contrast: 18pt, or 14pt bold
+func LargeText(sizePx float64, weight int) bool {
+	return sizePx >= 24 || sizePx >= 18.66 && weight >= 700
+}
+
+// textStyle is what the page reports for an element with its own text
+type textStyle struct {
+	Element string `json:"element"`
+	Text    string `json:"text"`
+	Color   string `json:"color"`
+	// Backgrounds are the background colours from the element outwards,
+	// up to the first opaque one
+	Backgrounds []string `json:"backgrounds"`
+	// Image is set when a background image lies behind the text, so the
+	// contrast can't be computed from colours
+	Image      bool    `json:"image"`
+	FontSize   float64 `json:"fontSize"`
+	FontWeight string  `json:"fontWeight"`
+}
+
+// textStylesJS collects every visible element that has its own text
+const textStylesJS = `(() => {
+	const describe = el => el.tagName.toLowerCase() +
+		(el.id ? "#" + el.id : "") +
+		(typeof el.className === "string" && el.className.trim() ? "." + el.className.trim().split(/\s+/).join(".") : "");
+	const out = [];
+	for (const el of document.body.querySelectorAll("*")) {
+		const text = [...el.childNodes].filter(n => n.nodeType === Node.TEXT_NODE).map(n => n.data).join("").trim();
+		if (!text || el.closest("script, style, noscript, template, [aria-hidden=true]")) continue;
+		const style = getComputedStyle(el);
+		if (style.visibility !== "visible" || el.getClientRects().length === 0) continue;
+		const backgrounds = [];
+		let image = false;
+		for (let a = el; a; a = a.parentElement) {
+			const s = getComputedStyle(a);
+			if (s.backgroundImage !== "none") { image = true; break; }
+			backgrounds.push(s.backgroundColor);
+			if (/^rgb\(/.test(s.backgroundColor)) break;
+		}
+		out.push({element: describe(el), text: text.slice(0, 40), color: style.color, backgrounds, image,
+			fontSize: parseFloat(style.fontSize), fontWeight: style.fontWeight});
+		if (out.length >= 5000) break;
+	}
+	return out;
+})()`
+
+// Contrast measures the contrast of every visible text element on the
+// page loaded in the tab, e.g. by a preceding browser.Render, against the
+// background colours behind it. Text over background images is skipped.
+func Contrast(ctx context.Context, page string) ([]Issue, error) {
+	var styles []textStyle
+	if err := chromedp.Run(ctx, chromedp.Evaluate(textStylesJS, &styles)); err != nil {
+		return nil, fmt.Errorf("contrast on %s: %w", page, err)
+	}
+	var issues []Issue
+	for _, s := range styles {
+		if issue, ok := s.check(); !ok {
+			issue.Page = page
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// check compares the text's contrast to the AA minimum for its size
+func (s textStyle) check() (Issue, bool) {
+	if s.Image {
+		return Issue{}, true
+	}
+	bg := white
+	for i := len(s.Backgrounds) - 1; i >= 0; i-- {
+		layer, ok := ParseColor(s.Backgrounds[i])
+		if !ok {
+			return Issue{}, true
+		}
+		bg = layer.Over(bg)
+	}
+	fg, ok := ParseColor(s.Color)
+	if !ok {
+		return Issue{}, true
+	}
+	weight, _ := strconv.Atoi(s.FontWeight)
+	required := MinContrast
+	if LargeText(s.FontSize, weight) {
+		required = MinContrastLarge
+	}
+	fg = fg.Over(bg)
+	ratio := ContrastRatio(fg, bg)
+	if ratio >= required {
+		return Issue{}, true
+	}
+	return Issue{Rule: ColorContrast, Element: s.Element,
+		Message: fmt.Sprintf("%q has contrast %.2f:1, below %.1f:1 (%s on %s at %gpx)", s.Text, ratio, required, fg, bg, s.FontSize)}, false
+}
@@ -0,0 +1,213 @@
+// Package ats looks at the resume the way an applicant tracking system
+// does: as the plain text of the page, split into sections by headings.
+// It reports which target keywords the text covers, headings an ATS would
+// not recognise, and content only present as images, which an ATS cannot
+// read.
+package ats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"golang.org/x/net/html"
+)
+
+// StandardSections are the section headings ATS parsers look for; a
+// heading containing one of them, case-insensitively, is recognised
+var StandardSections = []string{
+	"summary", "profile", "experience", "employment", "work history",
+	"education", "skills", "certifications", "licenses", "projects",
+	"awards", "publications", "volunteer", "languages",
+}
+
+// RequiredSections must each be matched by some h2
+var RequiredSections = []string{"experience", "education", "skills"}
+
+// ReadKeywords reads a keyword list, one per line; blank lines and lines
+// starting with # are skipped
+func ReadKeywords(r io.Reader) ([]string, error) {
+	var keywords []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			keywords = append(keywords, line)
+		}
+	}
+	return keywords, scanner.Err()
+}
+
+// Coverage is how many of the target keywords the text contains
+type Coverage struct {
+	Found, Missing []string
+}
+
+// Ratio is the share of keywords found, 1 when there are none
+func (c Coverage) Ratio() float64 {
+	total := len(c.Found) + len(c.Missing)
+	if total == 0 {
+		return 1
+	}
+	return float64(len(c.Found)) / float64(total)
+}
+
+// Keywords matches each keyword against text case-insensitively, as a
+// whole word, so "Go" does not match "Google" while "CI/CD" and ".NET"
+// match as written
+func Keywords(text string, keywords []string) Coverage {
+	var c Coverage
+	lower := strings.ToLower(text)
+	for _, k := range keywords {
+		if containsWord(lower, strings.ToLower(k)) {
+			c.Found = append(c.Found, k)
+		} else {
+			c.Missing = append(c.Missing, k)
+		}
+	}
+	return c
+}
+
+func containsWord(text, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		if !wordByteAt(text, start-1) && !wordByteAt(text, end) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func wordByteAt(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	r := rune(s[i])
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r >= 0x80
+}
+
+// Headings reports a heading structure an ATS may misread: not exactly
+// one h1, empty headings, skipped levels, h2 sections with names no
+// parser recognises, and required sections missing
+func Headings(doc *match.Document) []string {
+	var problems []string
+	if n := doc.Select("h1").Len(); n != 1 {
+		problems = append(problems, fmt.Sprintf("page has %d h1 headings, want 1 with the candidate's name", n))
+	}
+	level, found := 0, map[string]bool{}
+	for _, h := range doc.XPath("//*[self::h1 or self::h2 or self::h3 or self::h4 or self::h5 or self::h6]").Nodes() {
+		text := match.Text(h)
+		l := int(h.Data[1] - '0')
+		if text == "" {
+			problems = append(problems, fmt.Sprintf("empty <%s>", h.Data))
+		}
+		if level > 0 && l > level+1 {
+			problems = append(problems, fmt.Sprintf("<%s> %q skips a level after <h%d>", h.Data, text, level))
+		}
+		level = l
+		if l != 2 || text == "" {
+			continue
+		}
+		if s := standardSection(text); s != "" {
+			found[s] = true
+		} else {
+			problems = append(problems, fmt.Sprintf("section %q is not a standard heading an ATS recognises", text))
+		}
+	}
+	for _, s := range RequiredSections {
+		if !found[s] {
+			problems = append(problems, fmt.Sprintf("no %q section heading", s))
+		}
+	}
+	return problems
+}
+
+func standardSection(heading string) string {
+	lower := strings.ToLower(heading)
+	for _, s := range StandardSections {
+		if containsWord(lower, s) {
+			return s
+		}
+	}
+	return ""
+}
+
+// Images reports content an ATS cannot read: images with meaningful alt
+// text (the words are only in the picture), images without alt, and
+// <svg> text and <canvas>
+func Images(doc *match.Document) []string {
+	var problems []string
+	for _, n := range doc.Select("img").Nodes() {
+		src, _ := match.Attr(n, "src")
+		alt, ok := match.Attr(n, "alt")
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("<img src=%q> has no alt text", src))
+		case strings.TrimSpace(alt) != "":
+			problems = append(problems, fmt.Sprintf("<img src=%q> shows %q, which an ATS cannot read", src, alt))
+		}
+	}
+	for _, n := range doc.Select("svg text").Nodes() {
+		problems = append(problems, fmt.Sprintf("<svg> text %q is drawn, not written", match.Text(n)))
+	}
+	for range doc.Select("canvas").Nodes() {
+		problems = append(problems, "<canvas> content cannot be read by an ATS")
+	}
+	return problems
+}
+
+// Text returns the text an ATS extracts from the page body: what a reader
+// sees, without scripts, styles or hidden elements
+func Text(doc *match.Document) string {
+	body := doc.Select("body").Nodes()
+	if len(body) == 0 {
+		return ""
+	}
+	var visible func(*html.Node)
+	visible = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			if c.Type == html.ElementNode && hidden(c) {
+				n.RemoveChild(c)
+			} else {
+				visible(c)
+			}
+			c = next
+		}
+	}
+	// Work on a copy so the caller's document is untouched
+	copied := clone(body[0])
+	visible(copied)
+	return match.Text(copied)
+}
+
+func hidden(n *html.Node) bool {
+	switch n.Data {
+	case "template", "noscript":
+		return true
+	}
+	if _, ok := match.Attr(n, "hidden"); ok {
+		return true
+	}
+	if v, _ := match.Attr(n, "aria-hidden"); v == "true" {
+		return true
+	}
+	style, _ := match.Attr(n, "style")
+	style = strings.ReplaceAll(strings.ToLower(style), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+func clone(n *html.Node) *html.Node {
+	c := &html.Node{Type: n.Type, DataAtom: n.DataAtom, Data: n.Data, Namespace: n.Namespace, Attr: n.Attr}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.AppendChild(clone(child))
+	}
+	return c
+}
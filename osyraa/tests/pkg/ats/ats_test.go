@@ -0,0 +1,64 @@
+package ats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadKeywords(t *testing.T) {
+	keywords, err := ReadKeywords(strings.NewReader("# Cloud\nAWS\n\n  Azure  \n# Languages\nGo\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AWS", "Azure", "Go"}, keywords)
+}
+
+func TestKeywords(t *testing.T) {
+	c := Keywords("Built CI/CD on Google Cloud with Terraform; shipped .NET and C# services",
+		[]string{"ci/cd", "Terraform", "Go", ".NET", "C#", "Kubernetes", "terra"})
+	assert.Equal(t, []string{"ci/cd", "Terraform", ".NET", "C#"}, c.Found)
+	assert.Equal(t, []string{"Go", "Kubernetes", "terra"}, c.Missing)
+	assert.InDelta(t, 4.0/7, c.Ratio(), 1e-9)
+	assert.Equal(t, 1.0, Coverage{}.Ratio())
+}
+
+func TestHeadings(t *testing.T) {
+	doc, err := match.ParseBytes([]byte(`<h1>Jane Doe</h1><h2>Professional Summary</h2><h2>Work Experience</h2>
+<h3>Engineer</h3><h2>Education</h2><h2>Skills</h2><h3>Cloud</h3>`))
+	require.NoError(t, err)
+	assert.Empty(t, Headings(doc))
+
+	doc, err = match.ParseBytes([]byte(`<h1>Jane</h1><h1>Doe</h1><h2>Where I've been</h2><h4>Acme</h4><h2></h2><h2>Skills</h2>`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"page has 2 h1 headings, want 1 with the candidate's name",
+		`section "Where I've been" is not a standard heading an ATS recognises`,
+		`<h4> "Acme" skips a level after <h2>`,
+		"empty <h2>",
+		`no "experience" section heading`,
+		`no "education" section heading`,
+	}, Headings(doc))
+}
+
+func TestImages(t *testing.T) {
+	doc, err := match.ParseBytes([]byte(`<img src="divider.png" alt=""><img src="skills.png" alt="AWS, Azure, GCP">
+<img src="logo.png"><svg><text>Kubernetes</text></svg><canvas></canvas>`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`<img src="skills.png"> shows "AWS, Azure, GCP", which an ATS cannot read`,
+		`<img src="logo.png"> has no alt text`,
+		`<svg> text "Kubernetes" is drawn, not written`,
+		"<canvas> content cannot be read by an ATS",
+	}, Images(doc))
+}
+
+func TestText(t *testing.T) {
+	doc, err := match.ParseBytes([]byte(`<html><head><title>Resume</title></head><body>
+<h1>Jane</h1><p>Terraform</p><p hidden>Kubernetes</p><template><p>Helm</p></template>
+<span style="display: none">Docker</span><span aria-hidden="true">★</span><script>var AWS</script></body></html>`))
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Terraform", Text(doc))
+	assert.Equal(t, 1, doc.Select("p[hidden]").Len(), "Text should not change the document")
+}
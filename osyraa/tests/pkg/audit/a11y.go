@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/a11y"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/browser"
+)
+
+// Accessibility checks every page in the build in dir against the WCAG
+// 2.1 AA baseline. Without a browser it reads the markup on disk; with
+// one it serves the build on loopback, checks each page's rendered DOM and
+// adds a color-contrast check, which needs computed styles.
+func Accessibility(ctx context.Context, dir string, b *browser.Browser) (*Report, error) {
+	report := &Report{Target: dir, Started: time.Now().UTC(), Passed: true}
+	start := time.Now()
+	if b == nil {
+		issues, err := a11y.Dir(dir)
+		if err != nil {
+			return nil, err
+		}
+		report.add(Outcome{Check: "accessibility", Problems: issueStrings(issues), Duration: time.Since(start)})
+		return report, nil
+	}
+
+	var pages []string
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".html") {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, file)
+		pages = append(pages, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: http.FileServer(http.Dir(dir)), ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	structure := Outcome{Check: "accessibility"}
+	contrast := Outcome{Check: "color-contrast"}
+	for _, page := range pages {
+		issues, contrastIssues, err := renderedIssues(ctx, b, "http://"+ln.Addr().String()+"/"+page, page)
+		if err != nil {
+			structure.Error = err.Error()
+			break
+		}
+		structure.Problems = append(structure.Problems, issueStrings(issues)...)
+		contrast.Problems = append(contrast.Problems, issueStrings(contrastIssues)...)
+	}
+	structure.Duration = time.Since(start)
+	contrast.Duration = structure.Duration
+	report.add(structure)
+	if structure.Error == "" {
+		report.add(contrast)
+	}
+	return report, nil
+}
+
+// renderedIssues renders a page in a new tab and runs the structural rules
+// and contrast check on it
+func renderedIssues(ctx context.Context, b *browser.Browser, pageURL, page string) (structure, contrast []a11y.Issue, err error) {
+	tab, cancel := b.NewTab()
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	tab, stop := context.WithDeadline(tab, deadline)
+	defer stop()
+	doc, err := browser.Render(tab, pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	contrast, err = a11y.Contrast(tab, page)
+	return a11y.Check(page, doc), contrast, err
+}
+
+func issueStrings(issues []a11y.Issue) []string {
+	var out []string
+	for _, i := range issues {
+		out = append(out, i.String())
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+// Package audit runs the suites' checks outside `go test`, against a
+// built site directory or a served URL, and reports the outcome in a form
+// both people and CI can read. It is the library behind the osyraa
+// command's test and audit subcommands.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// Outcome is the result of one check
+type Outcome struct {
+	Check  string `json:"check"`
+	Passed bool   `json:"passed"`
+	// Problems are what the check found wrong
+	Problems []string `json:"problems,omitempty"`
+	// Warnings are worth a look but don't fail the check
+	Warnings []string `json:"warnings,omitempty"`
+	// Error is set when the check could not run at all
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Report is the outcome of every check run against a target
+type Report struct {
+	// Target is the directory or URL checked
+	Target  string    `json:"target"`
+	Started time.Time `json:"started"`
+	Passed  bool      `json:"passed"`
+	Checks  []Outcome `json:"checks"`
+}
+
+// Failed counts the checks that did not pass
+func (r *Report) Failed() int {
+	n := 0
+	for _, o := range r.Checks {
+		if !o.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Report) add(o Outcome) {
+	o.Passed = o.Error == "" && len(o.Problems) == 0
+	r.Checks = append(r.Checks, o)
+	r.Passed = r.Failed() == 0
+}
+
+// WriteJSON writes the report as one indented JSON document
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes a line per check, followed by its problems
+func (r *Report) WriteText(w io.Writer) {
+	for _, o := range r.Checks {
+		status := "PASS"
+		if !o.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s  %-20s %v\n", status, o.Check, o.Duration.Round(time.Millisecond))
+		if o.Error != "" {
+			fmt.Fprintf(w, "      error: %s\n", o.Error)
+		}
+		for _, p := range o.Problems {
+			fmt.Fprintf(w, "      %s\n", p)
+		}
+		for _, p := range o.Warnings {
+			fmt.Fprintf(w, "      warning: %s\n", p)
+		}
+	}
+	fmt.Fprintf(w, "%s: %d of %d checks failed\n", r.Target, r.Failed(), len(r.Checks))
+}
+
+// Site runs HTTP checks against a served copy of the site. A check's
+// error is split into one problem per line, so the several failures a
+// check joins are listed apart.
+func Site(ctx context.Context, target *battery.Target, checks []battery.Check) *Report {
+	report := &Report{Target: target.BaseURL, Started: time.Now().UTC(), Passed: true}
+	for _, result := range battery.Run(ctx, target, checks) {
+		o := Outcome{Check: result.Check, Duration: result.Duration}
+		if result.Err != nil {
+			o.Problems = problems(result.Err)
+		}
+		report.add(o)
+	}
+	return report
+}
+
+func problems(err error) []string {
+	var out []string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// ErrFailed is returned by commands whose report has failed checks, so
+// they exit non-zero
+var ErrFailed = errors.New("checks failed")
@@ -0,0 +1,209 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/fixture"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/linkcheck"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/perfbudget"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func site(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestSelectBuildChecks(t *testing.T) {
+	checks, err := SelectBuildChecks(nil)
+	require.NoError(t, err)
+	assert.Len(t, checks, len(BuildChecks))
+
+	checks, err = SelectBuildChecks([]string{"secrets", " links"})
+	require.NoError(t, err)
+	require.Len(t, checks, 2)
+	assert.Equal(t, "secrets", checks[0].Name)
+	assert.Equal(t, "links", checks[1].Name)
+
+	_, err = SelectBuildChecks([]string{"nope"})
+	assert.ErrorContains(t, err, `unknown check "nope"`)
+}
+
+func TestRunBuild(t *testing.T) {
+	dir := site(t, map[string]string{
+		"index.html":       `<html><body><a href="/about/">About</a><a href="/gone/">Gone</a></body></html>`,
+		"about/index.html": `<html><body><a href="/">Home</a></body></html>`,
+	})
+	checks, err := SelectBuildChecks([]string{"links", "security-txt"})
+	require.NoError(t, err)
+
+	report, err := RunBuild(context.Background(), &Build{Dir: dir}, checks)
+	require.NoError(t, err)
+	assert.False(t, report.Passed)
+	assert.Equal(t, 2, report.Failed())
+	require.Len(t, report.Checks, 2)
+	require.Len(t, report.Checks[0].Problems, 1)
+	assert.Contains(t, report.Checks[0].Problems[0], "missing /gone/")
+	assert.Equal(t, []string{"no .well-known/security.txt in the build"}, report.Checks[1].Problems)
+
+	_, err = RunBuild(context.Background(), &Build{Dir: filepath.Join(dir, "nope")}, checks)
+	assert.ErrorContains(t, err, "no build to check")
+}
+
+// TestFixtureDefects runs the offline checks over a fixture site with each
+// defect seeded, and requires the check meant to find it to report it and
+// no other check to complain
+func TestFixtureDefects(t *testing.T) {
+	checks, err := SelectBuildChecks([]string{"links", "html", "secrets"})
+	require.NoError(t, err)
+
+	for defect, want := range map[fixture.Defect]map[string][]string{
+		fixture.Valid: {},
+		fixture.BrokenLinks: {"links": {
+			"missing /css/missing.css (referenced from http://localhost/)",
+			"missing /blog/ (referenced from http://localhost/)",
+			"missing /img/missing.png (referenced from http://localhost/)",
+		}},
+		fixture.MissingMeta: {"accessibility": {
+			"index.html: html-lang (WCAG 3.1.1): <html> has no lang attribute",
+			"index.html: page-title (WCAG 2.4.2): the page has no title",
+			"about/index.html: html-lang (WCAG 3.1.1): <html> has no lang attribute",
+			"about/index.html: page-title (WCAG 2.4.2): the page has no title",
+		}},
+	} {
+		t.Run(defect.String(), func(t *testing.T) {
+			s := fixture.New(t, defect)
+			report, err := RunBuild(context.Background(), &Build{Dir: s.Public}, checks)
+			require.NoError(t, err)
+			a11y, err := Accessibility(context.Background(), s.Public, nil)
+			require.NoError(t, err)
+			for _, o := range append(report.Checks, a11y.Checks...) {
+				require.Empty(t, o.Error, o.Check)
+				if expected, failing := want[o.Check]; failing {
+					assert.ElementsMatch(t, expected, o.Problems, o.Check)
+				} else {
+					assert.Empty(t, o.Problems, "%s should find nothing wrong with a %s site", o.Check, defect)
+				}
+			}
+		})
+	}
+}
+
+func TestPageWeight(t *testing.T) {
+	budgets, err := perfbudget.Parse([]byte("budgets:\n  - path: /\n    page-weight: 1MiB\n    requests: 10\n"))
+	require.NoError(t, err)
+	checks, err := SelectBuildChecks([]string{"page-weight"})
+	require.NoError(t, err)
+
+	report, err := RunBuild(context.Background(), &Build{Dir: fixture.New(t, fixture.Valid).Public, Options: BuildOptions{Budgets: budgets}}, checks)
+	require.NoError(t, err)
+	assert.True(t, report.Passed, "%v", report.Checks[0].Problems)
+
+	report, err = RunBuild(context.Background(), &Build{Dir: fixture.New(t, fixture.HugeImages).Public, Options: BuildOptions{Budgets: budgets}}, checks)
+	require.NoError(t, err)
+	problems := report.Checks[0].Problems
+	require.Len(t, problems, 2, "both pages embed the huge image")
+	for _, p := range problems {
+		assert.Contains(t, p, "page-weight")
+		assert.Contains(t, p, "exceeds the 1.0MiB budget")
+		assert.Contains(t, p, "(budget for /)")
+	}
+
+	report, err = RunBuild(context.Background(), &Build{Dir: fixture.New(t, fixture.HugeImages).Public}, checks)
+	require.NoError(t, err)
+	assert.True(t, report.Passed, "without budgets nothing is checked")
+}
+
+func TestBuildCheckErrorFailsReport(t *testing.T) {
+	broken := BuildCheck{Name: "broken", Run: func(context.Context, *Build) ([]string, error) {
+		return nil, errors.New("cannot run")
+	}}
+	report, err := RunBuild(context.Background(), &Build{Dir: t.TempDir()}, []BuildCheck{broken})
+	require.NoError(t, err)
+	assert.False(t, report.Passed)
+	assert.Equal(t, "cannot run", report.Checks[0].Error)
+}
+
+func TestSite(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("<h1>Princeton A. Strong</h1>"))
+	}))
+	defer srv.Close()
+
+	checks := []battery.Check{
+		{Name: "endpoint", Run: battery.CheckEndpoint},
+		{Name: "two-problems", Run: func(context.Context, *battery.Target) error {
+			return errors.Join(errors.New("first"), errors.New("second"))
+		}},
+	}
+	report := Site(context.Background(), battery.NewTarget(srv.URL), checks)
+	assert.Equal(t, srv.URL, report.Target)
+	assert.False(t, report.Passed)
+	assert.True(t, report.Checks[0].Passed)
+	assert.Equal(t, []string{"first", "second"}, report.Checks[1].Problems)
+}
+
+func TestReportOutput(t *testing.T) {
+	report := &Report{Target: "public", Passed: true}
+	report.add(Outcome{Check: "links", Duration: time.Millisecond})
+	report.add(Outcome{Check: "secrets", Problems: []string{"possible secret in a.js"}})
+
+	var text bytes.Buffer
+	report.WriteText(&text)
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], "PASS  links"))
+	assert.True(t, strings.HasPrefix(lines[1], "FAIL  secrets"))
+	assert.Equal(t, "      possible secret in a.js", lines[2])
+	assert.Equal(t, "public: 1 of 2 checks failed", lines[3])
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.False(t, decoded.Passed)
+	assert.Equal(t, []string{"possible secret in a.js"}, decoded.Checks[1].Problems)
+}
+
+func TestLinks(t *testing.T) {
+	dir := site(t, map[string]string{
+		"index.html":       `<a href="/about/">About</a><img src="/missing.png">`,
+		"about/index.html": `<a href="/">Home</a>`,
+	})
+	report, err := Links(context.Background(), dir, linkcheck.SiteOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Checks, 1, "external links are only checked with a client")
+	assert.Equal(t, "internal-links", report.Checks[0].Check)
+	assert.False(t, report.Passed)
+	require.Len(t, report.Checks[0].Problems, 1)
+	assert.Contains(t, report.Checks[0].Problems[0], "/missing.png")
+}
+
+func TestAccessibility(t *testing.T) {
+	dir := site(t, map[string]string{
+		"index.html": `<html lang="en"><head><title>Resume</title></head><body><main><h1>Resume</h1><img src="me.png"></main></body></html>`,
+	})
+	report, err := Accessibility(context.Background(), dir, nil)
+	require.NoError(t, err)
+	require.Len(t, report.Checks, 1, "contrast is only checked in a browser")
+	assert.False(t, report.Passed)
+	require.Len(t, report.Checks[0].Problems, 1)
+	assert.Contains(t, report.Checks[0].Problems[0], "index.html: image-alt")
+}
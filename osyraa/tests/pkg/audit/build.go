@@ -0,0 +1,250 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/htmlvalid"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/jsaudit"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/mixedcontent"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/perfbudget"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/secrets"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/securitytxt"
+)
+
+// Build is a built site directory under check. Its crawl is shared by
+// the checks that need one.
+type Build struct {
+	Dir     string
+	Options BuildOptions
+
+	site *crawl.Result
+}
+
+// BuildOptions tune the build checks
+type BuildOptions struct {
+	// Scope bounds the crawl
+	Scope crawl.Scope
+	// SecretsAllow lists matches the secrets check accepts as public
+	SecretsAllow []*regexp.Regexp
+	// JS is the advisory database; nil means the embedded one
+	JS jsaudit.Repository
+	// JSMinSeverity is the lowest advisory severity that fails; empty
+	// means medium
+	JSMinSeverity string
+	// Budgets hold pages to a page weight and request count; nil leaves
+	// them unbudgeted
+	Budgets *perfbudget.File
+}
+
+// Site crawls the build, once
+func (b *Build) Site(ctx context.Context) (*crawl.Result, error) {
+	if b.site == nil {
+		site, err := crawl.DirScope(ctx, b.Dir, b.Options.Scope)
+		if err != nil {
+			return nil, err
+		}
+		b.site = site
+	}
+	return b.site, nil
+}
+
+// BuildCheck is a named check of a built site. It returns what it found
+// wrong, or an error if it could not run.
+type BuildCheck struct {
+	Name string
+	Run  func(ctx context.Context, b *Build) ([]string, error)
+}
+
+// BuildChecks are the checks of a built site, by name, in the order
+// they run
+var BuildChecks = []BuildCheck{
+	{Name: "links", Run: checkLinks},
+	{Name: "canonical-links", Run: checkCanonicalLinks},
+	{Name: "html", Run: checkHTML},
+	{Name: "page-weight", Run: checkPageWeight},
+	{Name: "mixed-content", Run: checkMixedContent},
+	{Name: "secrets", Run: checkSecrets},
+	{Name: "js-vulnerabilities", Run: checkJS},
+	{Name: "security-txt", Run: checkSecurityTxt},
+}
+
+// SelectBuildChecks looks up build checks by name; no names means all
+func SelectBuildChecks(names []string) ([]BuildCheck, error) {
+	if len(names) == 0 {
+		return BuildChecks, nil
+	}
+	byName := make(map[string]BuildCheck, len(BuildChecks))
+	known := make([]string, len(BuildChecks))
+	for i, c := range BuildChecks {
+		byName[c.Name] = c
+		known[i] = c.Name
+	}
+	var checks []BuildCheck
+	for _, name := range names {
+		c, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown check %q (known: %s)", name, strings.Join(known, ", "))
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+// RunBuild runs checks against the build in b.Dir
+func RunBuild(ctx context.Context, b *Build, checks []BuildCheck) (*Report, error) {
+	if _, err := os.Stat(b.Dir); err != nil {
+		return nil, fmt.Errorf("no build to check: %w", err)
+	}
+	report := &Report{Target: b.Dir, Started: time.Now().UTC(), Passed: true}
+	for _, c := range checks {
+		start := time.Now()
+		found, err := c.Run(ctx, b)
+		o := Outcome{Check: c.Name, Problems: found, Duration: time.Since(start)}
+		if err != nil {
+			o.Error = err.Error()
+		}
+		report.add(o)
+	}
+	return report, nil
+}
+
+func checkLinks(ctx context.Context, b *Build) ([]string, error) {
+	site, err := b.Site(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(site.HTML()) == 0 {
+		return []string{"no HTML pages in the build"}, nil
+	}
+	var found []string
+	for _, page := range site.Missing {
+		found = append(found, fmt.Sprintf("missing %s (referenced from %s)", page.URL.Path, page.Referrer))
+	}
+	return found, nil
+}
+
+func checkCanonicalLinks(ctx context.Context, b *Build) ([]string, error) {
+	site, err := b.Site(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := deploy.LocalTree(b.Dir)
+	if err != nil {
+		return nil, err
+	}
+	return deploy.NonCanonicalLinks(site.Pages, tree), nil
+}
+
+func checkHTML(_ context.Context, b *Build) ([]string, error) {
+	issues, err := htmlvalid.Dir(b.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, issue := range issues {
+		found = append(found, issue.String())
+	}
+	return found, nil
+}
+
+// checkPageWeight holds each page, with the subresources it references in
+// the build, to its page-weight and request budgets. The other budgets
+// need a browser and are left to the performance suite.
+func checkPageWeight(ctx context.Context, b *Build) ([]string, error) {
+	if b.Options.Budgets == nil {
+		return nil, nil
+	}
+	site, err := b.Site(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, page := range site.HTML() {
+		budget, ok := b.Options.Budgets.For(page.URL.Path)
+		if !ok {
+			continue
+		}
+		budget.LCP, budget.TBT, budget.CLS = 0, 0, 0
+		for _, v := range budget.Check(perfbudget.Weigh(site, page)) {
+			found = append(found, fmt.Sprintf("%s: %s (budget for %s)", page.URL.Path, v, budget.Path))
+		}
+	}
+	return found, nil
+}
+
+func checkMixedContent(ctx context.Context, b *Build) ([]string, error) {
+	site, err := b.Site(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, f := range mixedcontent.Static(site.Pages) {
+		found = append(found, f.String())
+	}
+	return found, nil
+}
+
+func checkSecrets(_ context.Context, b *Build) ([]string, error) {
+	findings, err := secrets.Scan(b.Dir, secrets.DefaultRules, b.Options.SecretsAllow)
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, f := range findings {
+		found = append(found, "possible secret in "+f.String())
+	}
+	return found, nil
+}
+
+func checkJS(_ context.Context, b *Build) ([]string, error) {
+	repo := b.Options.JS
+	if repo == nil {
+		repo = jsaudit.DefaultRepository()
+	}
+	minSeverity := strings.ToLower(b.Options.JSMinSeverity)
+	if minSeverity == "" {
+		minSeverity = "medium"
+	}
+	threshold, ok := jsaudit.Severities[minSeverity]
+	if !ok {
+		return nil, fmt.Errorf("severity %q should be low, medium, high or critical", minSeverity)
+	}
+	_, findings, err := repo.Scan(b.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, f := range findings {
+		if jsaudit.Severities[f.Vulnerability.Severity] >= threshold {
+			found = append(found, fmt.Sprintf("%s %s (%s): %s %s", f.Library, f.Version, f.Location,
+				f.Vulnerability.ID(), f.Vulnerability.Identifiers.Summary))
+		}
+	}
+	return found, nil
+}
+
+func checkSecurityTxt(_ context.Context, b *Build) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(b.Dir, ".well-known", "security.txt"))
+	if os.IsNotExist(err) {
+		return []string{"no .well-known/security.txt in the build"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := securitytxt.Parse(data)
+	if err != nil {
+		return []string{"security.txt: " + err.Error()}, nil
+	}
+	if _, err := f.Validate(time.Now()); err != nil {
+		return []string{"security.txt: " + err.Error()}, nil
+	}
+	return nil, nil
+}
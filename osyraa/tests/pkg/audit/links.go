@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/linkcheck"
+)
+
+// Links checks every link in the build in dir: those within the site as
+// one check, and external ones as another when o.Client is set. Links an
+// external host refused to an automated client are warnings.
+func Links(ctx context.Context, dir string, o linkcheck.SiteOptions) (*Report, error) {
+	report := &Report{Target: dir, Started: time.Now().UTC(), Passed: true}
+	start := time.Now()
+	result, err := linkcheck.Site(ctx, dir, o)
+	if err != nil {
+		return nil, err
+	}
+	internal := Outcome{Check: "internal-links", Duration: time.Since(start)}
+	external := Outcome{Check: "external-links", Duration: internal.Duration}
+	for _, b := range result.Broken {
+		if b.External {
+			external.Problems = append(external.Problems, b.String())
+		} else {
+			internal.Problems = append(internal.Problems, b.String())
+		}
+	}
+	for _, w := range result.Warnings {
+		external.Warnings = append(external.Warnings, w.String())
+	}
+	report.add(internal)
+	if o.Client != nil {
+		report.add(external)
+	}
+	return report, nil
+}
@@ -0,0 +1,219 @@
+// Package battery holds the HTTP checks run against a served copy of the
+// site. The same battery runs against the local container, an in-cluster
+// service, or a remote deployment; only the Target changes.
+package battery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Expectations describe what a correctly deployed site looks like
+type Expectations struct {
+	// Name is the text expected in the page's h1
+	Name string
+	// Headers maps header names to required values; an empty value only
+	// requires the header to be present
+	Headers map[string]string
+	// MaxResponseTime bounds the time to fetch the home page
+	MaxResponseTime time.Duration
+	// ContentHashes maps URL paths to the hex SHA-256 of the expected body,
+	// proving the deployment serves exactly the tested build
+	ContentHashes map[string]string
+	// Cookies lists the cookies the deployment may set; anything else
+	// fails the cookies check
+	Cookies []CookieRule
+}
+
+// DefaultExpectations matches the resume site as built by the Containerfile
+func DefaultExpectations() Expectations {
+	return Expectations{
+		Name: "Princeton A. Strong",
+		Headers: map[string]string{
+			"X-Frame-Options":        "SAMEORIGIN",
+			"X-Content-Type-Options": "nosniff",
+			"X-XSS-Protection":       "",
+		},
+		MaxResponseTime: time.Second,
+	}
+}
+
+// Target is a served copy of the site
+type Target struct {
+	BaseURL string
+	Client  *http.Client
+	Expect  Expectations
+}
+
+// NewTarget creates a target with default expectations and client
+func NewTarget(baseURL string) *Target {
+	return &Target{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		Expect:  DefaultExpectations(),
+	}
+}
+
+// Get fetches a path relative to the target and returns the response with
+// its body fully read
+func (t *Target) Get(ctx context.Context, path string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.BaseURL+path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp, body, err
+}
+
+// Check is a single named assertion against a target
+type Check struct {
+	Name string
+	Run  func(ctx context.Context, t *Target) error
+}
+
+// Result records the outcome of one check
+type Result struct {
+	Check    string
+	Err      error
+	Duration time.Duration
+}
+
+// Passed reports whether the check succeeded
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Default returns the standard HTTP battery
+func Default() []Check {
+	return []Check{
+		{Name: "endpoint", Run: CheckEndpoint},
+		{Name: "content", Run: CheckContent},
+		{Name: "security-headers", Run: CheckSecurityHeaders},
+		{Name: "framing", Run: CheckFraming},
+		{Name: "cookies", Run: CheckCookies},
+		{Name: "response-time", Run: CheckResponseTime},
+		{Name: "content-hash", Run: CheckContentHashes},
+	}
+}
+
+// Run executes every check against target and returns all results; a
+// failing check never prevents the remaining checks from running
+func Run(ctx context.Context, target *Target, checks []Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, c := range checks {
+		checkCtx, span := tracing.Start(ctx, "check "+c.Name, trace.WithAttributes(attribute.String("osyraa.target", target.BaseURL)))
+		start := time.Now()
+		err := c.Run(checkCtx, target)
+		tracing.End(span, err)
+		results = append(results, Result{Check: c.Name, Err: err, Duration: time.Since(start)})
+	}
+	return results
+}
+
+// Failed filters results down to failures
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if !r.Passed() {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// CheckEndpoint requires the home page to return 200 OK
+func CheckEndpoint(ctx context.Context, t *Target) error {
+	resp, _, err := t.Get(ctx, "/")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET / returned %d, want 200", resp.StatusCode)
+	}
+	return nil
+}
+
+// CheckContent requires the home page to render the expected name
+func CheckContent(ctx context.Context, t *Target) error {
+	_, body, err := t.Get(ctx, "/")
+	if err != nil {
+		return err
+	}
+	doc, err := match.ParseBytes(body)
+	if err != nil {
+		return err
+	}
+	return doc.Select("h1").TextEquals(t.Expect.Name)
+}
+
+// CheckSecurityHeaders requires every expected header, reporting all
+// missing or mismatched headers together
+func CheckSecurityHeaders(ctx context.Context, t *Target) error {
+	resp, _, err := t.Get(ctx, "/")
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for name, want := range t.Expect.Headers {
+		got := resp.Header.Get(name)
+		switch {
+		case got == "":
+			errs = append(errs, fmt.Errorf("%s header missing", name))
+		case want != "" && got != want:
+			errs = append(errs, fmt.Errorf("%s is %q, want %q", name, got, want))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckResponseTime bounds the time taken to fetch the home page
+func CheckResponseTime(ctx context.Context, t *Target) error {
+	start := time.Now()
+	if _, _, err := t.Get(ctx, "/"); err != nil {
+		return err
+	}
+	if elapsed := time.Since(start); t.Expect.MaxResponseTime > 0 && elapsed > t.Expect.MaxResponseTime {
+		return fmt.Errorf("response took %v, budget %v", elapsed, t.Expect.MaxResponseTime)
+	}
+	return nil
+}
+
+// ContentHash returns the hex SHA-256 of a response body
+func ContentHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckContentHashes compares served bodies with the expected hashes; it
+// passes trivially when no hashes are configured
+func CheckContentHashes(ctx context.Context, t *Target) error {
+	var errs []error
+	for path, want := range t.Expect.ContentHashes {
+		_, body, err := t.Get(ctx, path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if got := ContentHash(body); got != want {
+			errs = append(errs, fmt.Errorf("%s hash %s, want %s", path, got[:12], want[:min(12, len(want))]))
+		}
+	}
+	return errors.Join(errs...)
+}
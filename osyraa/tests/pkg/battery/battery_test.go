@@ -0,0 +1,66 @@
+package battery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const page = `<!doctype html><html><body><h1>Princeton A. Strong</h1></body></html>`
+
+func server(headers map[string]string, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(page))
+	}))
+}
+
+func TestDefaultBatteryPasses(t *testing.T) {
+	srv := server(map[string]string{
+		"X-Frame-Options":         "SAMEORIGIN",
+		"Content-Security-Policy": "frame-ancestors 'self'",
+		"X-Content-Type-Options":  "nosniff",
+		"X-XSS-Protection":        "1; mode=block",
+	}, http.StatusOK)
+	defer srv.Close()
+
+	results := Run(context.Background(), NewTarget(srv.URL), Default())
+	require.Len(t, results, len(Default()))
+	assert.Empty(t, Failed(results))
+}
+
+func TestFailuresAreReportedPerCheck(t *testing.T) {
+	srv := server(map[string]string{"X-Frame-Options": "DENY"}, http.StatusServiceUnavailable)
+	defer srv.Close()
+
+	results := Run(context.Background(), NewTarget(srv.URL), Default())
+	failed := map[string]error{}
+	for _, r := range Failed(results) {
+		failed[r.Check] = r.Err
+	}
+
+	assert.Contains(t, failed, "endpoint")
+	assert.NotContains(t, failed, "content")
+	require.Contains(t, failed, "security-headers")
+	assert.ErrorContains(t, failed["security-headers"], `X-Frame-Options is "DENY"`)
+	assert.ErrorContains(t, failed["security-headers"], "X-Content-Type-Options header missing")
+}
+
+func TestContentHashes(t *testing.T) {
+	srv := server(nil, http.StatusOK)
+	defer srv.Close()
+
+	target := NewTarget(srv.URL)
+	target.Expect.ContentHashes = map[string]string{"/": ContentHash([]byte(page))}
+	assert.NoError(t, CheckContentHashes(context.Background(), target))
+
+	target.Expect.ContentHashes["/"] = ContentHash([]byte("stale build"))
+	assert.ErrorContains(t, CheckContentHashes(context.Background(), target), "/ hash")
+}
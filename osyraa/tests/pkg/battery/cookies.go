@@ -0,0 +1,75 @@
+package battery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CookieRule allows a cookie the deployment is expected to set, such as a
+// CDN bot-management cookie
+type CookieRule struct {
+	// Name is the exact cookie name, or a prefix when it ends in "*"
+	Name string
+	// ScriptReadable exempts the cookie from HttpOnly, for analytics
+	// cookies that page scripts must read
+	ScriptReadable bool
+}
+
+func (r CookieRule) matches(name string) bool {
+	if prefix, ok := strings.CutSuffix(r.Name, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return r.Name == name
+}
+
+// CheckCookies fails on any cookie the home page sets that is not allowed
+// by Expect.Cookies; a static site has no reason to set one. Allowed
+// cookies still need Secure, SameSite and, unless ScriptReadable, HttpOnly.
+func CheckCookies(ctx context.Context, t *Target) error {
+	resp, _, err := t.Get(ctx, "/")
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, line := range resp.Header.Values("Set-Cookie") {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("malformed Set-Cookie %q: %w", line, err))
+			continue
+		}
+		var rule *CookieRule
+		for i := range t.Expect.Cookies {
+			if t.Expect.Cookies[i].matches(c.Name) {
+				rule = &t.Expect.Cookies[i]
+				break
+			}
+		}
+		if rule == nil {
+			errs = append(errs, fmt.Errorf("unexpected cookie %s; allow it in Expectations.Cookies if intended", c.Name))
+			continue
+		}
+		errs = append(errs, cookieAttributes(c, *rule)...)
+	}
+	return errors.Join(errs...)
+}
+
+func cookieAttributes(c *http.Cookie, rule CookieRule) []error {
+	var errs []error
+	if !c.Secure {
+		errs = append(errs, fmt.Errorf("cookie %s lacks Secure", c.Name))
+	}
+	if !c.HttpOnly && !rule.ScriptReadable {
+		errs = append(errs, fmt.Errorf("cookie %s lacks HttpOnly", c.Name))
+	}
+	// Zero means the attribute is absent; DefaultMode means it was unrecognised
+	if c.SameSite == 0 || c.SameSite == http.SameSiteDefaultMode {
+		errs = append(errs, fmt.Errorf("cookie %s has no valid SameSite attribute", c.Name))
+	}
+	if strings.HasPrefix(c.Name, "__Host-") && (c.Domain != "" || c.Path != "/") {
+		errs = append(errs, fmt.Errorf("cookie %s breaks the __Host- prefix rules (no Domain, Path=/)", c.Name))
+	}
+	return errs
+}
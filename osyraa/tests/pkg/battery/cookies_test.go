@@ -0,0 +1,44 @@
+package battery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCookies(t *testing.T) {
+	var cookies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, c := range cookies {
+			w.Header().Add("Set-Cookie", c)
+		}
+		w.Write([]byte(page))
+	}))
+	defer srv.Close()
+	target := NewTarget(srv.URL)
+
+	assert.NoError(t, CheckCookies(context.Background(), target), "no cookies is the static-site default")
+
+	cookies = []string{"session=abc; Path=/"}
+	assert.ErrorContains(t, CheckCookies(context.Background(), target), "unexpected cookie session")
+
+	target.Expect.Cookies = []CookieRule{{Name: "__cf_bm"}, {Name: "_ga*", ScriptReadable: true}}
+	cookies = []string{
+		"__cf_bm=x; Path=/; Secure; HttpOnly; SameSite=None",
+		"_ga_ABC=1; Path=/; Secure; SameSite=Lax",
+	}
+	assert.NoError(t, CheckCookies(context.Background(), target))
+
+	cookies = []string{"__cf_bm=x; Path=/", "_ga=1; SameSite=Strict"}
+	err := CheckCookies(context.Background(), target)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "cookie __cf_bm lacks Secure")
+	assert.ErrorContains(t, err, "cookie __cf_bm lacks HttpOnly")
+	assert.ErrorContains(t, err, "cookie __cf_bm has no valid SameSite attribute")
+	assert.ErrorContains(t, err, "cookie _ga lacks Secure")
+	assert.NotContains(t, err.Error(), "_ga lacks HttpOnly")
+}
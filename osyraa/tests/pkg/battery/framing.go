@@ -0,0 +1,73 @@
+package battery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+)
+
+// FrameAncestors returns the sources of the frame-ancestors directive in a
+// Content-Security-Policy value and whether the directive is present
+func FrameAncestors(csp string) ([]string, bool) {
+	for _, directive := range strings.Split(csp, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) > 0 && strings.EqualFold(fields[0], "frame-ancestors") {
+			return fields[1:], true
+		}
+	}
+	return nil, false
+}
+
+// CheckFraming requires clickjacking protection through X-Frame-Options
+// and CSP frame-ancestors, with both headers agreeing: DENY pairs with
+// 'none' and SAMEORIGIN with 'self'. Browsers that honour frame-ancestors
+// ignore X-Frame-Options, so a mismatch means old and new browsers behave
+// differently.
+func CheckFraming(ctx context.Context, t *Target) error {
+	resp, body, err := t.Get(ctx, "/")
+	if err != nil {
+		return err
+	}
+	var errs []error
+
+	xfo := strings.ToUpper(strings.TrimSpace(resp.Header.Get("X-Frame-Options")))
+	switch {
+	case xfo == "":
+		errs = append(errs, errors.New("X-Frame-Options header missing"))
+	case xfo != "DENY" && xfo != "SAMEORIGIN":
+		// ALLOW-FROM was never widely supported and is ignored by current browsers
+		errs = append(errs, fmt.Errorf("X-Frame-Options %q is not DENY or SAMEORIGIN", xfo))
+	}
+
+	var sources []string
+	var found bool
+	for _, csp := range resp.Header.Values("Content-Security-Policy") {
+		if s, ok := FrameAncestors(csp); ok {
+			sources, found = s, true
+		}
+	}
+	policy := strings.ToLower(strings.Join(sources, " "))
+	switch {
+	case !found:
+		errs = append(errs, errors.New("Content-Security-Policy has no frame-ancestors directive"))
+	case xfo == "DENY" && policy != "'none'":
+		errs = append(errs, fmt.Errorf("X-Frame-Options DENY but frame-ancestors %s", policy))
+	case xfo == "SAMEORIGIN" && policy != "'self'":
+		errs = append(errs, fmt.Errorf("X-Frame-Options SAMEORIGIN but frame-ancestors %s", policy))
+	}
+
+	// frame-ancestors is ignored in <meta>, so a policy there protects nothing
+	if doc, err := match.ParseBytes(body); err == nil {
+		for _, n := range doc.Select(`meta[http-equiv]`).Nodes() {
+			equiv, _ := match.Attr(n, "http-equiv")
+			content, _ := match.Attr(n, "content")
+			if _, ok := FrameAncestors(content); ok && strings.EqualFold(equiv, "Content-Security-Policy") {
+				errs = append(errs, errors.New("frame-ancestors in a <meta> CSP is ignored by browsers; send it as a header"))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,45 @@
+package battery
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameAncestors(t *testing.T) {
+	sources, ok := FrameAncestors("default-src 'self'; frame-ancestors 'self' https://example.com")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"'self'", "https://example.com"}, sources)
+
+	_, ok = FrameAncestors("default-src 'self'")
+	assert.False(t, ok)
+}
+
+func TestCheckFraming(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		err     string
+	}{
+		{"consistent sameorigin", map[string]string{"X-Frame-Options": "SAMEORIGIN", "Content-Security-Policy": "default-src 'self'; frame-ancestors 'self'"}, ""},
+		{"consistent deny", map[string]string{"X-Frame-Options": "deny", "Content-Security-Policy": "frame-ancestors 'none'"}, ""},
+		{"no csp", map[string]string{"X-Frame-Options": "SAMEORIGIN"}, "no frame-ancestors directive"},
+		{"no xfo", map[string]string{"Content-Security-Policy": "frame-ancestors 'none'"}, "X-Frame-Options header missing"},
+		{"allow-from", map[string]string{"X-Frame-Options": "ALLOW-FROM https://example.com", "Content-Security-Policy": "frame-ancestors https://example.com"}, "not DENY or SAMEORIGIN"},
+		{"mismatch", map[string]string{"X-Frame-Options": "DENY", "Content-Security-Policy": "frame-ancestors 'self'"}, "X-Frame-Options DENY but frame-ancestors 'self'"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := server(tc.headers, http.StatusOK)
+			defer srv.Close()
+
+			err := CheckFraming(context.Background(), NewTarget(srv.URL))
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
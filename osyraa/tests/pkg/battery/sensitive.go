@@ -0,0 +1,69 @@
+package battery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// SensitivePaths are repository internals and editor leftovers that must
+// never be served, whatever ends up in the build context
+var SensitivePaths = []string{
+	"/.git/config", "/.git/HEAD", "/.gitignore",
+	"/.env", "/.env.local",
+	"/.hugo_build.lock", "/config.toml", "/config.toml.template",
+	"/Containerfile", "/Dockerfile", "/.dockerignore", "/.containerignore",
+	"/.github/workflows/build-and-push.yml",
+	"/.DS_Store", "/.htaccess",
+	"/index.html~", "/index.html.bak", "/index.html.orig", "/.index.html.swp",
+	"/content/_index.md",
+}
+
+// sensitiveNames are build inputs that have no business in the web root
+var sensitiveNames = []string{"config.toml", "config.toml.template", "Containerfile", "Dockerfile", "go.mod", "Makefile"}
+
+// sensitiveSuffixes mark backups, swap files and environment files
+var sensitiveSuffixes = []string{"~", ".bak", ".orig", ".old", ".swp", ".env", ".lock", ".toml"}
+
+// IsSensitive reports whether a path in the web root looks like a
+// repository internal, backup or dotfile. /.well-known/ is exempt.
+func IsSensitive(p string) bool {
+	p = strings.TrimPrefix(p, "/")
+	for _, segment := range strings.Split(p, "/") {
+		if strings.HasPrefix(segment, ".") && segment != ".well-known" {
+			return true
+		}
+	}
+	base := path.Base(p)
+	for _, name := range sensitiveNames {
+		if base == name {
+			return true
+		}
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSensitiveFiles requests every path in SensitivePaths and requires a
+// 404 or 403. A 200 here usually means the fallback served the home page,
+// which still hides whether the file exists, so it is reported too.
+func CheckSensitiveFiles(ctx context.Context, t *Target) error {
+	var errs []error
+	for _, p := range SensitivePaths {
+		resp, _, err := t.Get(ctx, p)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusNotFound && resp.StatusCode != http.StatusForbidden {
+			errs = append(errs, fmt.Errorf("%s returned %d, want 404 or 403", p, resp.StatusCode))
+		}
+	}
+	return errors.Join(errs...)
+}
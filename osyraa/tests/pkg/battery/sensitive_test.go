@@ -0,0 +1,35 @@
+package battery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSensitive(t *testing.T) {
+	for _, p := range []string{".git/config", "/.env", "css/.DS_Store", "index.html~", "about/index.html.bak", "config.toml", ".hugo_build.lock"} {
+		assert.True(t, IsSensitive(p), p)
+	}
+	for _, p := range []string{"index.html", ".well-known/security.txt", "css/site.css", "sitemap.xml"} {
+		assert.False(t, IsSensitive(p), p)
+	}
+}
+
+func TestCheckSensitiveFiles(t *testing.T) {
+	leak := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == leak {
+			w.Write([]byte("[core]"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	assert.NoError(t, CheckSensitiveFiles(context.Background(), NewTarget(srv.URL)))
+	leak = "/.git/config"
+	assert.EqualError(t, CheckSensitiveFiles(context.Background(), NewTarget(srv.URL)), "/.git/config returned 200, want 404 or 403")
+}
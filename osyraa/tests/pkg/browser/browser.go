@@ -0,0 +1,133 @@
+// Package browser drives headless Chrome through chromedp for checks that
+// need a rendered page: executed scripts, computed styles and the requests
+// the page actually makes. One browser process serves many tabs.
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ErrNoChrome means no Chrome or Chromium binary was found; callers
+// usually skip browser checks rather than fail
+var ErrNoChrome = errors.New("no Chrome or Chromium found")
+
+// candidates are the binaries tried, in order, when Options.ExecPath is empty
+var candidates = []string{
+	"headless-shell", "chromium", "chromium-browser",
+	"google-chrome", "google-chrome-stable", "chrome",
+}
+
+// Options configure the browser process
+type Options struct {
+	// ExecPath overrides the Chrome binary lookup
+	ExecPath string
+	// RemoteURL connects to an already running browser (a devtools
+	// websocket URL, e.g. from a chromedp/headless-shell container)
+	// instead of starting one
+	RemoteURL string
+	// Width and Height set the window size, default 1280x800
+	Width, Height int
+}
+
+// Browser is a running headless Chrome
+type Browser struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// installPaths are where Chrome, Chromium and Edge install outside PATH,
+// as on macOS and Windows
+func installPaths(goos string, getenv func(string) string) []string {
+	switch goos {
+	case "darwin":
+		return []string{
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+			"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+		}
+	case "windows":
+		var paths []string
+		for _, root := range []string{getenv("ProgramFiles"), getenv("ProgramFiles(x86)"), getenv("LocalAppData")} {
+			if root == "" {
+				continue
+			}
+			paths = append(paths,
+				filepath.Join(root, "Google", "Chrome", "Application", "chrome.exe"),
+				filepath.Join(root, "Chromium", "Application", "chrome.exe"),
+				filepath.Join(root, "Microsoft", "Edge", "Application", "msedge.exe"))
+		}
+		return paths
+	}
+	return nil
+}
+
+// FindChrome returns the path of the first Chrome binary in PATH, or in
+// the platform's usual install locations
+func FindChrome() (string, error) {
+	for _, name := range candidates {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	for _, path := range installPaths(runtime.GOOS, os.Getenv) {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrNoChrome
+}
+
+// New starts headless Chrome, or connects to opts.RemoteURL
+func New(ctx context.Context, opts Options) (*Browser, error) {
+	if opts.Width == 0 {
+		opts.Width, opts.Height = 1280, 800
+	}
+	var allocCtx context.Context
+	var allocCancel context.CancelFunc
+	if opts.RemoteURL != "" {
+		allocCtx, allocCancel = chromedp.NewRemoteAllocator(ctx, opts.RemoteURL)
+	} else {
+		path := opts.ExecPath
+		if path == "" {
+			var err error
+			if path, err = FindChrome(); err != nil {
+				return nil, err
+			}
+		}
+		flags := append(chromedp.DefaultExecAllocatorOptions[:],
+			chromedp.ExecPath(path), chromedp.WindowSize(opts.Width, opts.Height))
+		// Chrome refuses to sandbox as root, which is the norm in CI containers
+		if os.Geteuid() == 0 {
+			flags = append(flags, chromedp.NoSandbox)
+		}
+		allocCtx, allocCancel = chromedp.NewExecAllocator(ctx, flags...)
+	}
+
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	// Running no actions starts the browser, so failures surface here
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		return nil, fmt.Errorf("start browser: %w", err)
+	}
+	return &Browser{ctx: browserCtx, cancel: func() { browserCancel(); allocCancel() }}, nil
+}
+
+// NewTab opens a tab; cancel closes it. Actions run with chromedp.Run on
+// the returned context.
+func (b *Browser) NewTab() (context.Context, context.CancelFunc) {
+	return chromedp.NewContext(b.ctx)
+}
+
+// Close shuts the browser down
+func (b *Browser) Close() {
+	b.cancel()
+}
@@ -0,0 +1,99 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithoutChrome(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := New(context.Background(), Options{})
+	assert.ErrorIs(t, err, ErrNoChrome)
+}
+
+func TestInstallPaths(t *testing.T) {
+	env := map[string]string{"ProgramFiles": `C:\Program Files`}
+	paths := installPaths("windows", func(k string) string { return env[k] })
+	assert.Len(t, paths, 3, "roots that are unset are skipped")
+	assert.Contains(t, installPaths("darwin", nil), "/Applications/Google Chrome.app/Contents/MacOS/Google Chrome")
+	assert.Empty(t, installPaths("linux", nil), "Linux browsers are found in PATH")
+}
+
+func TestFrameBlocked(t *testing.T) {
+	b := NewForTest(t)
+
+	for _, tc := range []struct {
+		xfo     string
+		blocked bool
+	}{{"SAMEORIGIN", true}, {"", false}} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tc.xfo != "" {
+				w.Header().Set("X-Frame-Options", tc.xfo)
+			}
+			w.Write([]byte("<h1>victim</h1>"))
+		}))
+		ctx, cancel := b.NewTab()
+		blocked, err := FrameBlocked(ctx, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1))
+		cancel()
+		srv.Close()
+		require.NoError(t, err)
+		assert.Equal(t, tc.blocked, blocked, "X-Frame-Options %q", tc.xfo)
+	}
+
+	// A frame that ends up elsewhere says nothing about the target
+	elsewhere := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<h1>elsewhere</h1>"))
+	}))
+	defer elsewhere.Close()
+	srv := httptest.NewServer(http.RedirectHandler(elsewhere.URL, http.StatusFound))
+	defer srv.Close()
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	_, err := FrameBlocked(ctx, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1))
+	assert.ErrorContains(t, err, "rather than")
+}
+
+func TestRender(t *testing.T) {
+	b := NewForTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html><html><body><main></main><script>
+setTimeout(() => { document.querySelector("main").innerHTML = "<h2>Experience</h2>" }, 200)
+</script></body></html>`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	doc, err := Render(ctx, srv.URL)
+	require.NoError(t, err)
+	assert.True(t, doc.HasDoctype())
+	assert.NoError(t, doc.Select("main h2").TextEquals("Experience"), "Content added by script should be rendered")
+}
+
+func TestScreenshot(t *testing.T) {
+	b := NewForTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html><body style="margin:0"><div style="height:2000px;background:#00f"></div></body>`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	shot, err := Screenshot(ctx, srv.URL, Viewport{Name: "small", Width: 400, Height: 300})
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(shot))
+	require.NoError(t, err)
+	assert.Equal(t, 400, img.Bounds().Dx())
+	assert.Equal(t, 2000, img.Bounds().Dy(), "the whole page should be captured, not just the viewport")
+}
@@ -0,0 +1,144 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// Message is a console message, uncaught exception, browser log entry or
+// failed request seen in a tab
+type Message struct {
+	// Level is "error", "warning" or "info"
+	Level string
+	// Source is console, exception, network or the browser log source
+	// (security, violation, ...)
+	Source string
+	Text   string
+	URL    string
+	Line   int64
+}
+
+func (m Message) String() string {
+	s := fmt.Sprintf("[%s %s] %s", m.Source, m.Level, m.Text)
+	switch {
+	case m.URL != "" && m.Line > 0:
+		s += fmt.Sprintf(" (%s:%d)", m.URL, m.Line)
+	case m.URL != "":
+		s += " (" + m.URL + ")"
+	}
+	return s
+}
+
+// Console collects what a tab reports while pages load
+type Console struct {
+	mu       sync.Mutex
+	messages []Message
+	urls     map[network.RequestID]string
+}
+
+// WatchConsole starts collecting messages in the tab. Failed requests
+// include HTTP errors (404 fonts, missing scripts) and loads the browser
+// blocked (CSP, mixed content, CORS).
+func WatchConsole(ctx context.Context) (*Console, error) {
+	c := &Console{urls: map[network.RequestID]string{}}
+	chromedp.ListenTarget(ctx, c.handle)
+	return c, chromedp.Run(ctx, runtime.Enable(), log.Enable(), network.Enable())
+}
+
+func (c *Console) handle(ev any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch ev := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		level := "info"
+		switch ev.Type {
+		case runtime.APITypeError, runtime.APITypeAssert:
+			level = "error"
+		case runtime.APITypeWarning:
+			level = "warning"
+		}
+		var args []string
+		for _, arg := range ev.Args {
+			args = append(args, remoteText(arg))
+		}
+		m := Message{Level: level, Source: "console", Text: strings.Join(args, " ")}
+		if ev.StackTrace != nil && len(ev.StackTrace.CallFrames) > 0 {
+			m.URL, m.Line = ev.StackTrace.CallFrames[0].URL, ev.StackTrace.CallFrames[0].LineNumber+1
+		}
+		c.messages = append(c.messages, m)
+	case *runtime.EventExceptionThrown:
+		d := ev.ExceptionDetails
+		text := d.Text
+		if d.Exception != nil && d.Exception.Description != "" {
+			text = d.Exception.Description
+		}
+		c.messages = append(c.messages, Message{Level: "error", Source: "exception", Text: text, URL: d.URL, Line: d.LineNumber + 1})
+	case *log.EventEntryAdded:
+		// Network entries duplicate the request events below
+		if ev.Entry.Source == log.SourceNetwork {
+			return
+		}
+		level := string(ev.Entry.Level)
+		if level == "verbose" {
+			level = "info"
+		}
+		c.messages = append(c.messages, Message{Level: level, Source: string(ev.Entry.Source), Text: ev.Entry.Text,
+			URL: ev.Entry.URL, Line: ev.Entry.LineNumber})
+	case *network.EventRequestWillBeSent:
+		c.urls[ev.RequestID] = ev.Request.URL
+	case *network.EventResponseReceived:
+		if ev.Response.Status >= 400 {
+			c.messages = append(c.messages, Message{Level: "error", Source: "network",
+				Text: fmt.Sprintf("%s %d %s", ev.Type, ev.Response.Status, ev.Response.StatusText), URL: ev.Response.URL})
+		}
+	case *network.EventLoadingFailed:
+		if ev.Canceled {
+			return
+		}
+		text := fmt.Sprintf("%s failed: %s", ev.Type, ev.ErrorText)
+		if ev.BlockedReason != "" {
+			text += " (blocked: " + string(ev.BlockedReason) + ")"
+		}
+		c.messages = append(c.messages, Message{Level: "error", Source: "network", Text: text, URL: c.urls[ev.RequestID]})
+	}
+}
+
+func remoteText(o *runtime.RemoteObject) string {
+	if len(o.Value) > 0 {
+		var s string
+		if json.Unmarshal(o.Value, &s) == nil {
+			return s
+		}
+		return string(o.Value)
+	}
+	if o.UnserializableValue != "" {
+		return string(o.UnserializableValue)
+	}
+	return o.Description
+}
+
+// Messages returns everything collected so far
+func (c *Console) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.messages...)
+}
+
+// Errors returns the error-level messages
+func (c *Console) Errors() []Message {
+	var errs []Message
+	for _, m := range c.Messages() {
+		if m.Level == "error" {
+			errs = append(errs, m)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,74 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleHandle(t *testing.T) {
+	c := &Console{urls: map[network.RequestID]string{}}
+	c.handle(&runtime.EventConsoleAPICalled{Type: runtime.APITypeError, Args: []*runtime.RemoteObject{
+		{Value: []byte(`"boom"`)}, {Value: []byte(`42`)}, {Description: "Error: x"},
+	}})
+	c.handle(&runtime.EventConsoleAPICalled{Type: runtime.APITypeLog, Args: []*runtime.RemoteObject{{Value: []byte(`"hi"`)}}})
+	c.handle(&runtime.EventExceptionThrown{ExceptionDetails: &runtime.ExceptionDetails{
+		Text: "Uncaught", URL: "http://x/app.js", LineNumber: 9,
+		Exception: &runtime.RemoteObject{Description: "TypeError: a is undefined"},
+	}})
+	c.handle(&log.EventEntryAdded{Entry: &log.Entry{Source: log.SourceNetwork, Level: log.LevelError, Text: "Failed to load resource"}})
+	c.handle(&log.EventEntryAdded{Entry: &log.Entry{Source: log.SourceSecurity, Level: log.LevelWarning, Text: "insecure form"}})
+	c.handle(&network.EventRequestWillBeSent{RequestID: "1", Request: &network.Request{URL: "http://x/font.woff2"}})
+	c.handle(&network.EventResponseReceived{Type: network.ResourceTypeFont, Response: &network.Response{
+		URL: "http://x/font.woff2", Status: 404, StatusText: "Not Found"}})
+	c.handle(&network.EventRequestWillBeSent{RequestID: "2", Request: &network.Request{URL: "http://cdn/lib.js"}})
+	c.handle(&network.EventLoadingFailed{RequestID: "2", Type: network.ResourceTypeScript,
+		ErrorText: "net::ERR_BLOCKED_BY_CLIENT", BlockedReason: network.BlockedReasonCsp})
+	c.handle(&network.EventLoadingFailed{RequestID: "3", Canceled: true})
+
+	assert.Len(t, c.Messages(), 6)
+	var errs []string
+	for _, m := range c.Errors() {
+		errs = append(errs, m.String())
+	}
+	assert.Equal(t, []string{
+		`[console error] boom 42 Error: x`,
+		`[exception error] TypeError: a is undefined (http://x/app.js:10)`,
+		`[network error] Font 404 Not Found (http://x/font.woff2)`,
+		`[network error] Script failed: net::ERR_BLOCKED_BY_CLIENT (blocked: csp) (http://cdn/lib.js)`,
+	}, errs)
+}
+
+func TestWatchConsole(t *testing.T) {
+	b := NewForTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<!DOCTYPE html><body><img src="/missing.png"><script>console.error("boom"); undefinedFn()</script></body>`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	console, err := WatchConsole(ctx)
+	require.NoError(t, err)
+	require.NoError(t, chromedp.Run(ctx, chromedp.Navigate(srv.URL), WaitRendered()))
+
+	var sources []string
+	for _, m := range console.Errors() {
+		sources = append(sources, m.Source)
+	}
+	assert.Contains(t, sources, "console")
+	assert.Contains(t, sources, "exception")
+	assert.Contains(t, sources, "network")
+}
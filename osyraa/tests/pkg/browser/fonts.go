@@ -0,0 +1,110 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/chromedp"
+)
+
+// textSelectors are the elements whose rendered fonts are inspected; the
+// first match of each stands for the rest
+var textSelectors = []string{"body", "h1", "h2", "h3", "p", "li", "a"}
+
+// FontFace is a face from document.fonts after the page rendered
+type FontFace struct {
+	Family string `json:"family"`
+	// Status is "loaded", "error", "loading" or "unloaded"; unloaded faces
+	// were never needed by the page
+	Status string `json:"status"`
+}
+
+// FontUse is the font an element asks for and what Chrome drew it with
+type FontUse struct {
+	Selector string
+	// Family is the first family of the element's computed font-family
+	Family string
+	// Rendered lists the platform fonts used for the element's text
+	Rendered []string
+	// Custom reports whether any of them was a downloaded web font
+	Custom bool
+}
+
+// Fonts is the web font state of a rendered page
+type Fonts struct {
+	Faces []FontFace
+	Uses  []FontUse
+}
+
+// Problems lists faces that failed to load and text that asked for a web
+// font but was drawn with a system fallback
+func (f Fonts) Problems() []string {
+	var problems []string
+	declared := map[string]bool{}
+	for _, face := range f.Faces {
+		declared[strings.ToLower(face.Family)] = true
+		switch face.Status {
+		case "error":
+			problems = append(problems, fmt.Sprintf("web font %q failed to load", face.Family))
+		case "loading":
+			problems = append(problems, fmt.Sprintf("web font %q was still loading after render", face.Family))
+		}
+	}
+	for _, u := range f.Uses {
+		if declared[strings.ToLower(u.Family)] && !u.Custom && len(u.Rendered) > 0 {
+			problems = append(problems, fmt.Sprintf("%s asks for %q but renders with %s", u.Selector, u.Family, strings.Join(u.Rendered, ", ")))
+		}
+	}
+	return problems
+}
+
+// LoadFonts renders pageURL and reports which web fonts loaded and which
+// fonts Chrome actually drew the text with
+func LoadFonts(ctx context.Context, pageURL string) (*Fonts, error) {
+	var (
+		fonts    Fonts
+		families map[string]string
+	)
+	selectors, _ := json.Marshal(textSelectors)
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(pageURL),
+		WaitRendered(),
+		chromedp.Evaluate(`[...document.fonts].map(f => ({family: f.family.replace(/^["']|["']$/g, ""), status: f.status}))`, &fonts.Faces),
+		chromedp.Evaluate(fmt.Sprintf(`Object.fromEntries(%s
+			.map(s => [s, document.querySelector(s)])
+			.filter(([, el]) => el)
+			.map(([s, el]) => [s, getComputedStyle(el).fontFamily.split(",")[0].trim().replace(/^["']|["']$/g, "")]))`,
+			selectors), &families),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("fonts %s: %w", pageURL, err)
+	}
+	for _, sel := range textSelectors {
+		family, ok := families[sel]
+		if !ok {
+			continue
+		}
+		use := FontUse{Selector: sel, Family: family}
+		var ids []cdp.NodeID
+		err := chromedp.Run(ctx, chromedp.NodeIDs(sel, &ids, chromedp.ByQuery), chromedp.ActionFunc(func(ctx context.Context) error {
+			used, err := css.GetPlatformFontsForNode(ids[0]).Do(ctx)
+			if err != nil {
+				return err
+			}
+			for _, p := range used {
+				use.Rendered = append(use.Rendered, p.FamilyName)
+				use.Custom = use.Custom || p.IsCustomFont
+			}
+			return nil
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("fonts %s: %s: %w", pageURL, sel, err)
+		}
+		fonts.Uses = append(fonts.Uses, use)
+	}
+	return &fonts, nil
+}
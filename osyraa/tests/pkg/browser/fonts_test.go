@@ -0,0 +1,49 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFontsProblems(t *testing.T) {
+	f := Fonts{
+		Faces: []FontFace{{Family: "Inter", Status: "loaded"}, {Family: "Mono", Status: "error"}, {Family: "Serif", Status: "unloaded"}},
+		Uses: []FontUse{
+			{Selector: "body", Family: "Inter", Rendered: []string{"Inter"}, Custom: true},
+			{Selector: "h1", Family: "inter", Rendered: []string{"DejaVu Sans"}},
+			{Selector: "p", Family: "system-ui", Rendered: []string{"DejaVu Sans"}},
+		},
+	}
+	assert.Equal(t, []string{
+		`web font "Mono" failed to load`,
+		`h1 asks for "inter" but renders with DejaVu Sans`,
+	}, f.Problems())
+}
+
+func TestLoadFonts(t *testing.T) {
+	b := NewForTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<!DOCTYPE html><style>
+@font-face { font-family: "Missing"; font-display: swap; src: url(/missing.woff2) format("woff2"); }
+h1 { font-family: "Missing", sans-serif; }
+</style><h1>Title</h1><p>Text</p>`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	f, err := LoadFonts(ctx, srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []FontFace{{Family: "Missing", Status: "error"}}, f.Faces)
+	assert.Contains(t, f.Problems(), `web font "Missing" failed to load`)
+	assert.Contains(t, f.Problems()[1], `h1 asks for "Missing" but renders with`)
+}
@@ -0,0 +1,67 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// FrameBlocked embeds target in an iframe on a page served from a
+// throwaway origin on 127.0.0.1, the way a clickjacking page would, and
+// reports whether the browser refused to render it. An iframe that was
+// never created, or navigated somewhere else, is an error rather than a
+// block. ctx is a tab from NewTab.
+func FrameBlocked(ctx context.Context, target string) (bool, error) {
+	want, err := url.Parse(target)
+	if err != nil {
+		return false, err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return false, err
+	}
+	attacker := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		// The name identifies the frame in the frame tree
+		fmt.Fprintf(w, `<!DOCTYPE html><title>framer</title><iframe id="victim" name="victim" src="%s" width="800" height="600"></iframe>`,
+			html.EscapeString(target))
+	})}
+	go attacker.Serve(ln)
+	defer attacker.Close()
+
+	var tree *page.FrameTree
+	err = chromedp.Run(ctx,
+		// Navigate returns after the load event, which waits for the iframe
+		chromedp.Navigate("http://"+ln.Addr().String()+"/"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			tree, err = page.GetFrameTree().Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return false, err
+	}
+	if n := len(tree.ChildFrames); n != 1 || tree.ChildFrames[0].Frame.Name != "victim" {
+		return false, fmt.Errorf("the framing page should hold one iframe named victim, found %d frames", n)
+	}
+	f := tree.ChildFrames[0].Frame
+	// Blocked frames commit Chrome's error page instead of the document
+	// and keep the URL they were refused in UnreachableURL
+	loaded := f.URL
+	if f.UnreachableURL != "" {
+		loaded = f.UnreachableURL
+	}
+	got, err := url.Parse(loaded)
+	if err != nil || got.Host != want.Host {
+		return false, fmt.Errorf("the iframe holds %q rather than %s", loaded, target)
+	}
+	return f.UnreachableURL != "" || !strings.HasPrefix(f.URL, "http"), nil
+}
@@ -0,0 +1,45 @@
+package browser
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// RewriteDocumentHeaders intercepts every document response in the tab,
+// frames included, and lets edit change its headers before the page sees
+// them. ctx is a tab from NewTab; interception lasts as long as the tab.
+func RewriteDocumentHeaders(ctx context.Context, edit func(url string, h http.Header)) error {
+	chromedp.ListenTarget(ctx, func(ev any) {
+		paused, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+		// CDP calls cannot be made from the listener itself
+		go func() {
+			h := http.Header{}
+			for _, e := range paused.ResponseHeaders {
+				h.Add(e.Name, e.Value)
+			}
+			edit(paused.Request.URL, h)
+			var entries []*fetch.HeaderEntry
+			for name, values := range h {
+				for _, v := range values {
+					entries = append(entries, &fetch.HeaderEntry{Name: name, Value: v})
+				}
+			}
+			c := chromedp.FromContext(ctx)
+			fetch.ContinueResponse(paused.RequestID).WithResponseHeaders(entries).
+				Do(cdp.WithExecutor(ctx, c.Target))
+		}()
+	})
+	return chromedp.Run(ctx, fetch.Enable().WithPatterns([]*fetch.RequestPattern{{
+		URLPattern:   "*",
+		ResourceType: network.ResourceTypeDocument,
+		RequestStage: fetch.RequestStageResponse,
+	}}))
+}
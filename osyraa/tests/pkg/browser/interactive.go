@@ -0,0 +1,211 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+// transitionWait lets CSS transitions and scripts finish after a toggle
+const transitionWait = 400 * time.Millisecond
+
+// Control kinds found by Interact
+const (
+	// Disclosure is a button with aria-expanded, such as a menu toggle
+	Disclosure = "disclosure"
+	// Details is a native <details> element
+	Details = "details"
+	// Switch is a button with aria-pressed or a theme switcher
+	Switch = "switch"
+)
+
+// Control is an interactive element of the page
+type Control struct {
+	Index    int    `json:"index"`
+	Kind     string `json:"kind"`
+	Selector string `json:"selector"`
+	Text     string `json:"text"`
+	// Controls is the aria-controls id of a disclosure
+	Controls string `json:"controls"`
+	Visible  bool   `json:"visible"`
+}
+
+func (c Control) String() string {
+	if c.Text == "" {
+		return c.Selector
+	}
+	return fmt.Sprintf("%s %q", c.Selector, c.Text)
+}
+
+// ControlState is what a control and the content it governs look like
+type ControlState struct {
+	// Expanded and Pressed are the raw ARIA attributes, "" when absent
+	Expanded string `json:"expanded"`
+	Pressed  string `json:"pressed"`
+	Open     bool   `json:"open"`
+	// Target reports whether the controlled element exists; TargetVisible
+	// whether it is laid out and not hidden
+	Target        bool `json:"target"`
+	TargetVisible bool `json:"targetVisible"`
+	// Theme fingerprints the root element's class, data-theme,
+	// color-scheme and background
+	Theme string `json:"theme"`
+}
+
+// Interaction is the outcome of operating one control
+type Interaction struct {
+	Control  Control
+	Problems []string
+}
+
+const controlsJS = `(() => {
+	const visible = el => {
+		const r = el.getBoundingClientRect(), cs = getComputedStyle(el);
+		return r.width > 0 && r.height > 0 && cs.visibility !== "hidden" && cs.display !== "none";
+	};
+	const describe = el => {
+		let s = el.tagName.toLowerCase();
+		if (el.id) return s + "#" + el.id;
+		if (el.classList.length) s += "." + [...el.classList].join(".");
+		return s;
+	};
+	const theme = /theme|dark|light|mode/i;
+	const found = [];
+	const add = (el, kind) => {
+		if (el.hasAttribute("data-osyraa-control")) return;
+		el.setAttribute("data-osyraa-control", found.length);
+		found.push({index: found.length, kind, selector: describe(el),
+			text: (el.innerText || el.getAttribute("aria-label") || el.title || "").trim().slice(0, 60),
+			controls: el.getAttribute("aria-controls") || "", visible: visible(el)});
+	};
+	document.querySelectorAll("[aria-expanded]").forEach(el => add(el, "disclosure"));
+	document.querySelectorAll("details > summary:first-of-type").forEach(el => add(el, "details"));
+	document.querySelectorAll("[aria-pressed]").forEach(el => add(el, "switch"));
+	document.querySelectorAll("button, [role=button], [role=switch]").forEach(el => {
+		const label = [el.id, el.className, el.getAttribute("aria-label"), el.title, el.innerText].join(" ");
+		if (theme.test(label)) add(el, "switch");
+	});
+	return found;
+})()`
+
+func controlStateJS(index int) string {
+	return fmt.Sprintf(`(() => {
+	const el = document.querySelector('[data-osyraa-control="%d"]');
+	const visible = el => {
+		const r = el.getBoundingClientRect(), cs = getComputedStyle(el);
+		return r.width > 0 && r.height > 0 && cs.visibility !== "hidden" && cs.display !== "none";
+	};
+	let target = null, open = false;
+	if (el.tagName === "SUMMARY") {
+		open = el.parentElement.open;
+		target = [...el.parentElement.children].find(c => c !== el) || null;
+	} else if (el.getAttribute("aria-controls")) {
+		target = document.getElementById(el.getAttribute("aria-controls"));
+	}
+	const root = document.documentElement;
+	return {
+		expanded: el.getAttribute("aria-expanded") || "", pressed: el.getAttribute("aria-pressed") || "",
+		open, target: !!target, targetVisible: !!target && visible(target),
+		theme: [root.className, root.dataset.theme, getComputedStyle(root).colorScheme,
+			getComputedStyle(document.body).backgroundColor].join("|"),
+	};
+})()`, index)
+}
+
+// Interact renders pageURL at vp and operates every visible disclosure,
+// <details> and switch by mouse and then by keyboard. Each activation must
+// flip the control's state and the content it governs, and a second one
+// must put both back.
+func Interact(ctx context.Context, pageURL string, vp Viewport) ([]Interaction, error) {
+	var controls []Control
+	err := chromedp.Run(ctx,
+		vp.Emulate(),
+		chromedp.Navigate(pageURL),
+		WaitRendered(),
+		chromedp.Evaluate(controlsJS, &controls),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("interact %s at %s: %w", pageURL, vp.Name, err)
+	}
+
+	var results []Interaction
+	for _, c := range controls {
+		if !c.Visible {
+			continue
+		}
+		sel := fmt.Sprintf(`[data-osyraa-control="%d"]`, c.Index)
+		click := chromedp.Click(sel, chromedp.ByQuery)
+		key := chromedp.ActionFunc(func(ctx context.Context) error {
+			return chromedp.Run(ctx, chromedp.Focus(sel, chromedp.ByQuery), chromedp.KeyEvent(kb.Enter))
+		})
+		result := Interaction{Control: c}
+		for _, step := range []struct {
+			how    string
+			action chromedp.Action
+		}{{"click", click}, {"Enter", key}} {
+			var before, toggled, restored ControlState
+			err := chromedp.Run(ctx,
+				chromedp.Evaluate(controlStateJS(c.Index), &before),
+				step.action, chromedp.Sleep(transitionWait),
+				chromedp.Evaluate(controlStateJS(c.Index), &toggled),
+				step.action, chromedp.Sleep(transitionWait),
+				chromedp.Evaluate(controlStateJS(c.Index), &restored),
+			)
+			if err != nil {
+				return results, fmt.Errorf("interact %s at %s: %s: %w", pageURL, vp.Name, c, err)
+			}
+			if p := CheckToggle(c, before, toggled); p != "" {
+				result.Problems = append(result.Problems, fmt.Sprintf("%s: %s", step.how, p))
+			} else if p := CheckToggle(c, toggled, restored); p != "" {
+				result.Problems = append(result.Problems, fmt.Sprintf("second %s: %s", step.how, p))
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// CheckToggle explains why activating c did not properly move it from
+// before to after, or returns ""
+func CheckToggle(c Control, before, after ControlState) string {
+	switch c.Kind {
+	case Disclosure:
+		if after.Expanded != "true" && after.Expanded != "false" {
+			return fmt.Sprintf("aria-expanded is %q, want true or false", after.Expanded)
+		}
+		if after.Expanded == before.Expanded {
+			return fmt.Sprintf("aria-expanded stayed %s", after.Expanded)
+		}
+		if c.Controls != "" && !after.Target {
+			return fmt.Sprintf("aria-controls names #%s, which does not exist", c.Controls)
+		}
+		if after.Target && after.TargetVisible != (after.Expanded == "true") {
+			return fmt.Sprintf("aria-expanded is %s but #%s is %s", after.Expanded, c.Controls, shown(after.TargetVisible))
+		}
+	case Details:
+		if after.Open == before.Open {
+			return fmt.Sprintf("details stayed %s", map[bool]string{true: "open", false: "closed"}[after.Open])
+		}
+		if after.Target && after.TargetVisible != after.Open {
+			return fmt.Sprintf("details is %s but its content is %s", map[bool]string{true: "open", false: "closed"}[after.Open], shown(after.TargetVisible))
+		}
+	case Switch:
+		if before.Pressed != "" && after.Pressed == before.Pressed {
+			return fmt.Sprintf("aria-pressed stayed %s", after.Pressed)
+		}
+		if before.Pressed == "" && after.Theme == before.Theme {
+			return "nothing changed"
+		}
+	}
+	return ""
+}
+
+func shown(visible bool) string {
+	if visible {
+		return "shown"
+	}
+	return "hidden"
+}
@@ -0,0 +1,67 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckToggle(t *testing.T) {
+	menu := Control{Kind: Disclosure, Selector: "button.menu", Controls: "nav"}
+	closed := ControlState{Expanded: "false", Target: true}
+	open := ControlState{Expanded: "true", Target: true, TargetVisible: true}
+
+	assert.Empty(t, CheckToggle(menu, closed, open))
+	assert.Empty(t, CheckToggle(menu, open, closed))
+	assert.Equal(t, "aria-expanded stayed false", CheckToggle(menu, closed, closed))
+	assert.Equal(t, `aria-expanded is "", want true or false`, CheckToggle(menu, closed, ControlState{Target: true}))
+	assert.Equal(t, "aria-expanded is true but #nav is hidden",
+		CheckToggle(menu, closed, ControlState{Expanded: "true", Target: true}))
+	assert.Equal(t, "aria-controls names #nav, which does not exist",
+		CheckToggle(menu, ControlState{Expanded: "false"}, ControlState{Expanded: "true"}))
+
+	details := Control{Kind: Details, Selector: "summary"}
+	assert.Empty(t, CheckToggle(details, ControlState{Target: true}, ControlState{Open: true, Target: true, TargetVisible: true}))
+	assert.Equal(t, "details stayed closed", CheckToggle(details, ControlState{}, ControlState{}))
+
+	theme := Control{Kind: Switch, Selector: "button#theme"}
+	assert.Empty(t, CheckToggle(theme, ControlState{Theme: "light"}, ControlState{Theme: "dark"}))
+	assert.Equal(t, "nothing changed", CheckToggle(theme, ControlState{Theme: "light"}, ControlState{Theme: "light"}))
+	assert.Equal(t, "aria-pressed stayed true", CheckToggle(theme, ControlState{Pressed: "true"}, ControlState{Pressed: "true"}))
+}
+
+func TestInteract(t *testing.T) {
+	b := NewForTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html>
+<button id="menu" aria-expanded="false" aria-controls="nav">Menu</button>
+<nav id="nav" hidden><a href="/">Home</a></nav>
+<div id="broken" role="button" tabindex="0" aria-expanded="false">Broken</div>
+<details><summary>More</summary><p>Hidden text</p></details>
+<script>
+document.getElementById("menu").addEventListener("click", e => {
+	const open = e.target.getAttribute("aria-expanded") === "true";
+	e.target.setAttribute("aria-expanded", String(!open));
+	document.getElementById("nav").hidden = open;
+});
+document.getElementById("broken").addEventListener("click", e => {
+	e.target.setAttribute("aria-expanded", e.target.getAttribute("aria-expanded") === "true" ? "false" : "true");
+});
+</script>`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	results, err := Interact(ctx, srv.URL, Desktop)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Empty(t, results[0].Problems, "the menu toggles by mouse and keyboard")
+	assert.Equal(t, []string{"Enter: aria-expanded stayed false"}, results[1].Problems, "a div button without a key handler")
+	assert.Equal(t, Details, results[2].Control.Kind)
+	assert.Empty(t, results[2].Problems)
+}
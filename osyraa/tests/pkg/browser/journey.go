@@ -0,0 +1,154 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// Link is a same-origin link on a page, identified by its position in
+// document.links so it can be clicked again after a reload
+type Link struct {
+	Index int    `json:"index"`
+	Href  string `json:"href"`
+	Text  string `json:"text"`
+	// Fragment is set for in-page anchors (#section)
+	Fragment bool `json:"fragment"`
+}
+
+const linksJS = `[...document.links].map((a, index) => ({
+	index, href: a.href, text: a.textContent.trim(),
+	fragment: a.origin === location.origin && a.pathname === location.pathname && a.hash !== "",
+})).filter(l => new URL(l.href).origin === location.origin)`
+
+// anchorInViewJS reports whether the element the hash names is on screen
+const anchorInViewJS = `(() => {
+	const id = decodeURIComponent(location.hash.slice(1));
+	const el = document.getElementById(id) || document.getElementsByName(id)[0];
+	if (!el) return false;
+	const r = el.getBoundingClientRect();
+	return r.bottom > 0 && r.top < innerHeight;
+})()`
+
+// Step is the outcome of clicking one link
+type Step struct {
+	Link Link
+	// URL is where the click landed and Status the HTTP status of the
+	// document there (0 for in-page anchors)
+	URL    string
+	Status int64
+	// Problem is empty when the click landed on the right content
+	Problem string
+}
+
+// documentStatus tracks the status of the last document the tab loaded
+type documentStatus struct {
+	mu     sync.Mutex
+	status int64
+}
+
+func (d *documentStatus) handle(ev any) {
+	if r, ok := ev.(*network.EventResponseReceived); ok && r.Type == network.ResourceTypeDocument {
+		d.mu.Lock()
+		d.status = r.Response.Status
+		d.mu.Unlock()
+	}
+}
+
+func (d *documentStatus) get() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+// Journey loads startURL and clicks each of its same-origin links in turn,
+// reloading the start page before each click. Section anchors must scroll
+// their target into view; page links must load with a 200 and must not be
+// the start page served as a fallback for a missing path.
+func Journey(ctx context.Context, startURL string) ([]Step, error) {
+	start, err := url.Parse(startURL)
+	if err != nil {
+		return nil, err
+	}
+	doc := &documentStatus{}
+	chromedp.ListenTarget(ctx, doc.handle)
+
+	var links []Link
+	var home string
+	err = chromedp.Run(ctx,
+		network.Enable(),
+		chromedp.Navigate(startURL),
+		WaitRendered(),
+		chromedp.Evaluate(linksJS, &links),
+		chromedp.Evaluate(`document.documentElement.outerHTML`, &home),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", startURL, err)
+	}
+
+	seen := map[string]bool{}
+	var steps []Step
+	for _, l := range links {
+		if seen[l.Href] {
+			continue
+		}
+		seen[l.Href] = true
+		step := Step{Link: l}
+		click := fmt.Sprintf(`document.links[%d].click()`, l.Index)
+		if err := chromedp.Run(ctx, chromedp.Navigate(startURL), WaitRendered()); err != nil {
+			return steps, fmt.Errorf("reload %s: %w", startURL, err)
+		}
+
+		if l.Fragment {
+			var visible bool
+			err := chromedp.Run(ctx,
+				chromedp.Evaluate(click, nil),
+				chromedp.Sleep(200*time.Millisecond),
+				chromedp.Location(&step.URL),
+				chromedp.Evaluate(anchorInViewJS, &visible),
+			)
+			if err != nil {
+				return steps, fmt.Errorf("click %s: %w", l.Href, err)
+			}
+			if !visible {
+				step.Problem = fmt.Sprintf("anchor %s has no target in view after clicking", l.Href)
+			}
+			steps = append(steps, step)
+			continue
+		}
+
+		// The marker disappears with the old document, which also covers
+		// links back to the start page itself
+		var landed string
+		var loaded bool
+		err := chromedp.Run(ctx,
+			chromedp.Evaluate(`window.__journeyStart = true; `+click, nil),
+			chromedp.Poll(`!window.__journeyStart && document.readyState === "complete"`, &loaded,
+				chromedp.WithPollingInterval(50*time.Millisecond)),
+			WaitRendered(),
+			chromedp.Location(&step.URL),
+			chromedp.Evaluate(`document.documentElement.outerHTML`, &landed),
+		)
+		if err != nil {
+			return steps, fmt.Errorf("click %s: %w", l.Href, err)
+		}
+		step.Status = doc.get()
+		want, _ := url.Parse(l.Href)
+		got, _ := url.Parse(step.URL)
+		switch {
+		case step.Status != 200:
+			step.Problem = fmt.Sprintf("%s returned %d", l.Href, step.Status)
+		case got == nil || got.Path != want.Path:
+			step.Problem = fmt.Sprintf("%s landed on %s", l.Href, step.URL)
+		case want.Path != start.Path && landed == home:
+			step.Problem = fmt.Sprintf("%s served the start page, probably as a not-found fallback", l.Href)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
@@ -0,0 +1,45 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJourney(t *testing.T) {
+	b := NewForTest(t)
+
+	home := `<!DOCTYPE html><nav><a href="/about/">About</a> <a href="/gone/">Gone</a> <a href="#skills">Skills</a>
+<a href="#nowhere">Broken</a> <a href="https://example.com/">External</a></nav>
+<div style="height:3000px"></div><h2 id="skills">Skills</h2><div style="height:3000px"></div>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(home))
+		case "/about/":
+			w.Write([]byte(`<!DOCTYPE html><h1>About</h1>`))
+		default:
+			// An SPA-style fallback that hides the missing page
+			w.Write([]byte(home))
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	steps, err := Journey(ctx, srv.URL+"/")
+	require.NoError(t, err)
+	require.Len(t, steps, 4, "the external link is not followed")
+
+	problems := map[string]string{}
+	for _, s := range steps {
+		problems[s.Link.Text] = s.Problem
+	}
+	assert.Empty(t, problems["About"])
+	assert.Contains(t, problems["Gone"], "not-found fallback")
+	assert.Empty(t, problems["Skills"])
+	assert.Contains(t, problems["Broken"], "no target in view")
+}
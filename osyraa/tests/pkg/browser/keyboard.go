@@ -0,0 +1,248 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+// MaxTabStops bounds the walk through a page's tab order
+const MaxTabStops = 200
+
+// SkipLinkThreshold is how many tab stops may come before the main content
+// without a skip link to bypass them (WCAG 2.4.1)
+const SkipLinkThreshold = 4
+
+// FocusStop is an element reached by pressing Tab
+type FocusStop struct {
+	Selector string  `json:"selector"`
+	Text     string  `json:"text"`
+	Href     string  `json:"href"`
+	TabIndex int     `json:"tabIndex"`
+	X        float64 `json:"x"`
+	// Y is relative to the document, not the viewport
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	// InView reports whether the focused element was scrolled on screen
+	InView bool `json:"inView"`
+	// Indicator reports whether focus changed the element's outline,
+	// shadow, border, background or text
+	Indicator bool `json:"indicator"`
+	InMain    bool `json:"inMain"`
+	// Revisit is set when Tab returned to an element already seen
+	Revisit bool `json:"revisit"`
+}
+
+func (s FocusStop) String() string {
+	if s.Text == "" {
+		return s.Selector
+	}
+	return fmt.Sprintf("%s %q", s.Selector, s.Text)
+}
+
+// SkipLink is a first tab stop that jumps to the main content
+type SkipLink struct {
+	Target string
+	// Exists reports whether the fragment names an element
+	Exists bool
+	// Works reports whether activating it scrolled to the target and moved
+	// the next Tab into it
+	Works bool
+}
+
+// Keyboard is a keyboard-only walk through a page at one viewport
+type Keyboard struct {
+	Viewport Viewport
+	Stops    []FocusStop
+	// Unreachable lists visible, focusable elements Tab never reached
+	Unreachable []string
+	SkipLink    *SkipLink
+	// ZoomBlocked is the meta viewport content when it prevents zooming
+	ZoomBlocked string
+}
+
+// keyboardSetupJS records how every focusable element looks unfocused, so
+// stops can tell whether focus is visible
+const keyboardSetupJS = `(() => {
+	const sig = el => {
+		const cs = getComputedStyle(el);
+		return [cs.outlineStyle, cs.outlineWidth, cs.outlineColor, cs.boxShadow, cs.borderColor,
+			cs.backgroundColor, cs.color, cs.textDecorationLine].join("|");
+	};
+	window.__kbSig = sig;
+	window.__kbBefore = new Map();
+	window.__kbSeen = new Set();
+	for (const el of document.querySelectorAll("a[href], button, input, select, textarea, summary, [tabindex]")) {
+		window.__kbBefore.set(el, sig(el));
+	}
+	document.activeElement && document.activeElement.blur();
+	const vp = document.querySelector('meta[name="viewport"]');
+	const content = vp ? vp.content : "";
+	const max = /maximum-scale\s*=\s*([\d.]+)/i.exec(content);
+	return /user-scalable\s*=\s*(no|0)/i.test(content) || (max && parseFloat(max[1]) < 2) ? content : "";
+})()`
+
+// keyboardStopJS describes the focused element, or returns null when
+// focus has left the page
+const keyboardStopJS = `(() => {
+	const el = document.activeElement;
+	if (!el || el === document.body || el === document.documentElement) return null;
+	let selector = el.tagName.toLowerCase();
+	if (el.id) selector += "#" + el.id;
+	else if (el.classList.length) selector += "." + [...el.classList].join(".");
+	const r = el.getBoundingClientRect();
+	const revisit = window.__kbSeen.has(el);
+	window.__kbSeen.add(el);
+	const before = window.__kbBefore.get(el);
+	return {
+		selector, text: (el.innerText || el.value || el.getAttribute("aria-label") || "").trim().slice(0, 60),
+		href: el.getAttribute("href") || "", tabIndex: el.tabIndex,
+		x: r.x + scrollX, y: r.y + scrollY, width: r.width, height: r.height,
+		inView: r.width > 0 && r.height > 0 && r.bottom > 0 && r.right > 0 && r.top < innerHeight && r.left < innerWidth,
+		indicator: before === undefined || before !== window.__kbSig(el),
+		inMain: !!el.closest("main, [role=main]"), revisit,
+	};
+})()`
+
+// keyboardUnreachableJS lists visible focusable elements never focused
+const keyboardUnreachableJS = `[...document.querySelectorAll("a[href], button:not([disabled]), input:not([disabled]):not([type=hidden]), select:not([disabled]), textarea:not([disabled]), summary, [tabindex]")]
+	.filter(el => el.tabIndex >= 0 && !window.__kbSeen.has(el))
+	.filter(el => { const r = el.getBoundingClientRect(), cs = getComputedStyle(el);
+		return r.width > 0 && r.height > 0 && cs.visibility !== "hidden"; })
+	.map(el => el.tagName.toLowerCase() + (el.id ? "#" + el.id : "") + " " + JSON.stringify((el.innerText || "").trim().slice(0, 60)))`
+
+// AuditKeyboard renders pageURL at vp and presses Tab until focus leaves
+// the page or comes back around, recording each stop. A skip link found
+// at the first stop is then activated from a fresh load.
+func AuditKeyboard(ctx context.Context, pageURL string, vp Viewport) (Keyboard, error) {
+	audit := Keyboard{Viewport: vp}
+	err := chromedp.Run(ctx,
+		vp.Emulate(),
+		chromedp.Navigate(pageURL),
+		WaitRendered(),
+		chromedp.Evaluate(keyboardSetupJS, &audit.ZoomBlocked),
+	)
+	if err != nil {
+		return audit, fmt.Errorf("keyboard %s at %s: %w", pageURL, vp.Name, err)
+	}
+	for len(audit.Stops) < MaxTabStops {
+		var stop *FocusStop
+		if err := chromedp.Run(ctx, chromedp.KeyEvent(kb.Tab), chromedp.Evaluate(keyboardStopJS, &stop)); err != nil {
+			return audit, fmt.Errorf("keyboard %s at %s: %w", pageURL, vp.Name, err)
+		}
+		if stop == nil {
+			break
+		}
+		audit.Stops = append(audit.Stops, *stop)
+		if stop.Revisit {
+			break
+		}
+	}
+	if err := chromedp.Run(ctx, chromedp.Evaluate(keyboardUnreachableJS, &audit.Unreachable)); err != nil {
+		return audit, fmt.Errorf("keyboard %s at %s: %w", pageURL, vp.Name, err)
+	}
+
+	if len(audit.Stops) > 0 && isSkipLink(audit.Stops[0]) {
+		audit.SkipLink, err = followSkipLink(ctx, pageURL, audit.Stops[0].Href)
+		if err != nil {
+			return audit, fmt.Errorf("keyboard %s at %s: skip link: %w", pageURL, vp.Name, err)
+		}
+	}
+	return audit, nil
+}
+
+func isSkipLink(s FocusStop) bool {
+	if !strings.HasPrefix(s.Href, "#") || len(s.Href) < 2 {
+		return false
+	}
+	text := strings.ToLower(s.Text)
+	return strings.Contains(text, "skip") || strings.Contains(text, "jump to") || strings.Contains(text, "main content")
+}
+
+// followSkipLink reloads the page, activates the skip link with Enter and
+// checks the target is on screen and the next Tab lands inside or after it
+func followSkipLink(ctx context.Context, pageURL, href string) (*SkipLink, error) {
+	link := &SkipLink{Target: href}
+	target := fmt.Sprintf(`document.getElementById(%q)`, strings.TrimPrefix(href, "#"))
+	var inView, next bool
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(pageURL),
+		WaitRendered(),
+		chromedp.Evaluate(`document.activeElement && document.activeElement.blur()`, nil),
+		chromedp.KeyEvent(kb.Tab),
+		chromedp.KeyEvent(kb.Enter),
+		chromedp.Evaluate(target+` !== null`, &link.Exists),
+		chromedp.Evaluate(fmt.Sprintf(`(() => { const t = %s; if (!t) return false;
+			const r = t.getBoundingClientRect(); return r.top < innerHeight && r.bottom > 0; })()`, target), &inView),
+		chromedp.KeyEvent(kb.Tab),
+		chromedp.Evaluate(fmt.Sprintf(`(() => { const t = %s, el = document.activeElement;
+			return !!t && !!el && el !== document.body &&
+				(t.contains(el) || !!(t.compareDocumentPosition(el) & Node.DOCUMENT_POSITION_FOLLOWING)); })()`, target), &next),
+	)
+	if err != nil {
+		return nil, err
+	}
+	link.Works = link.Exists && inView && next
+	return link, nil
+}
+
+// Problems lists focus that cannot be seen, tab order that fights the
+// layout, traps, unreachable controls, a missing or broken skip link, and
+// a viewport that blocks zooming
+func (k Keyboard) Problems() []string {
+	name := k.Viewport.Name
+	var problems []string
+	if k.ZoomBlocked != "" {
+		problems = append(problems, fmt.Sprintf("%s: meta viewport %q stops users zooming", name, k.ZoomBlocked))
+	}
+	// Stops before the first one in <main>; -1 when none is
+	beforeMain := -1
+	for i, s := range k.Stops {
+		if s.InMain && !s.Revisit {
+			beforeMain = i
+			break
+		}
+	}
+	for i, s := range k.Stops {
+		if s.Revisit {
+			if i > 0 && !sameStop(s, k.Stops[0]) {
+				problems = append(problems, fmt.Sprintf("%s: focus is trapped, Tab cycles back to %s", name, s))
+			}
+			break
+		}
+		if s.TabIndex > 0 {
+			problems = append(problems, fmt.Sprintf("%s: %s has tabindex=%d, which overrides the document order", name, s, s.TabIndex))
+		}
+		if !s.InView {
+			problems = append(problems, fmt.Sprintf("%s: %s takes focus but is not visible", name, s))
+		} else if !s.Indicator {
+			problems = append(problems, fmt.Sprintf("%s: %s shows no focus indicator", name, s))
+		}
+		if i > 0 {
+			prev := k.Stops[i-1]
+			if s.InView && prev.InView && s.Y+s.Height <= prev.Y {
+				problems = append(problems, fmt.Sprintf("%s: focus jumps back up the page from %s to %s", name, prev, s))
+			}
+		}
+	}
+	for _, el := range k.Unreachable {
+		problems = append(problems, fmt.Sprintf("%s: %s cannot be reached with Tab", name, el))
+	}
+	switch {
+	case k.SkipLink != nil && !k.SkipLink.Exists:
+		problems = append(problems, fmt.Sprintf("%s: skip link points at %s, which does not exist", name, k.SkipLink.Target))
+	case k.SkipLink != nil && !k.SkipLink.Works:
+		problems = append(problems, fmt.Sprintf("%s: skip link to %s does not move focus to the content", name, k.SkipLink.Target))
+	case k.SkipLink == nil && beforeMain >= SkipLinkThreshold:
+		problems = append(problems, fmt.Sprintf("%s: %d tab stops before the main content and no skip link", name, beforeMain))
+	}
+	return problems
+}
+
+func sameStop(a, b FocusStop) bool {
+	return a.Selector == b.Selector && a.Text == b.Text && a.Href == b.Href
+}
@@ -0,0 +1,72 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyboardProblems(t *testing.T) {
+	stop := func(sel, text string, y float64, inMain bool) FocusStop {
+		return FocusStop{Selector: sel, Text: text, Y: y, Height: 20, InView: true, Indicator: true, InMain: inMain}
+	}
+	header := []FocusStop{stop("a", "Home", 0, false), stop("a", "Blog", 0, false), stop("a", "CV", 0, false), stop("a", "Contact", 0, false)}
+
+	k := Keyboard{Viewport: Breakpoints[0], ZoomBlocked: "width=device-width, user-scalable=no"}
+	k.Stops = append(k.Stops, header...)
+	k.Stops = append(k.Stops,
+		stop("a", "Intro", 400, true),
+		FocusStop{Selector: "a.ghost", TabIndex: 2, Y: 500, Height: 20, Indicator: true, InMain: true},
+		FocusStop{Selector: "button", Text: "Copy", Y: 600, Height: 20, InView: true, InMain: true},
+		stop("a", "Top", 100, true),
+		FocusStop{Selector: "a", Text: "Intro", Revisit: true},
+	)
+	k.Unreachable = []string{`div#card "Open"`}
+	assert.Equal(t, []string{
+		`mobile: meta viewport "width=device-width, user-scalable=no" stops users zooming`,
+		`mobile: a.ghost has tabindex=2, which overrides the document order`,
+		`mobile: a.ghost takes focus but is not visible`,
+		`mobile: button "Copy" shows no focus indicator`,
+		`mobile: focus jumps back up the page from button "Copy" to a "Top"`,
+		`mobile: focus is trapped, Tab cycles back to a "Intro"`,
+		`mobile: div#card "Open" cannot be reached with Tab`,
+		`mobile: 4 tab stops before the main content and no skip link`,
+	}, k.Problems())
+
+	// Wrapping back to the first stop is the normal end of the walk, and a
+	// working skip link satisfies the bypass requirement
+	k = Keyboard{Viewport: Desktop, SkipLink: &SkipLink{Target: "#main", Exists: true, Works: true}}
+	k.Stops = append([]FocusStop{stop("a", "Skip to content", 0, false)}, header...)
+	k.Stops = append(k.Stops, stop("a", "Intro", 400, true), FocusStop{Selector: "a", Text: "Skip to content", Revisit: true})
+	assert.Empty(t, k.Problems())
+
+	k.SkipLink.Works = false
+	assert.Equal(t, []string{"desktop: skip link to #main does not move focus to the content"}, k.Problems())
+}
+
+func TestAuditKeyboard(t *testing.T) {
+	b := NewForTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html><meta name="viewport" content="width=device-width">
+<style>.skip{position:absolute;left:-999px}.skip:focus{left:0}.plain:focus{outline:none}</style>
+<a class="skip" href="#main">Skip to content</a>
+<header><a href="/a">A</a><a href="/b">B</a><a href="/c">C</a><a href="/d">D</a></header>
+<main id="main" tabindex="-1"><p>Text</p><a class="plain" href="/e">E</a></main>`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	k, err := AuditKeyboard(ctx, srv.URL, Desktop)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(k.Stops), 6)
+	assert.Equal(t, "a.skip", k.Stops[0].Selector)
+	assert.True(t, k.Stops[0].InView, "the skip link appears when focused")
+	require.NotNil(t, k.SkipLink)
+	assert.True(t, k.SkipLink.Works)
+	assert.Equal(t, []string{`desktop: a.plain "E" shows no focus indicator`}, k.Problems())
+}
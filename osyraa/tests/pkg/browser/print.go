@@ -0,0 +1,135 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/ledongthuc/pdf"
+)
+
+// Letter paper with 0.4in margins, the size most resumes are printed on
+const (
+	paperWidth, paperHeight = 8.5, 11.0
+	paperMargin             = 0.4
+)
+
+// printWidth and printHeight are the printable area in CSS pixels, at 96
+// per inch
+const printWidth, printHeight = 739, 979
+
+// Printed is a page printed to PDF
+type Printed struct {
+	PDF   []byte
+	Pages int
+	// Headings are the PDF's outline entries, which Chrome builds from
+	// the page's h1-h6
+	Headings []string
+	// Clipped lists elements that lose content in the print layout:
+	// wider than the paper, or cut off by overflow
+	Clipped []string
+	// Hidden lists headings the print stylesheet hides
+	Hidden []string
+	// Text is the PDF's extracted text, for checking what a reader of the
+	// printout sees
+	Text string
+}
+
+const printLayoutJS = `(() => {
+	const describe = el => {
+		let s = el.tagName.toLowerCase();
+		if (el.id) return s + "#" + el.id;
+		if (el.classList.length) s += "." + [...el.classList].join(".");
+		const text = el.textContent.trim().slice(0, 40);
+		return text ? s + " \"" + text + "\"" : s;
+	};
+	const width = document.documentElement.clientWidth;
+	const clipped = [];
+	for (const el of document.body.querySelectorAll("*")) {
+		const cs = getComputedStyle(el), r = el.getBoundingClientRect();
+		if (cs.display === "none" || r.width === 0) continue;
+		const cuts = cs.overflowX !== "visible" || cs.overflowY !== "visible";
+		if ((cuts && (el.scrollWidth > el.clientWidth + 1 || el.scrollHeight > el.clientHeight + 1)) ||
+			r.right > width + 1) {
+			if (!clipped.some(c => c.contains(el))) clipped.push(el);
+		}
+	}
+	const hidden = [...document.querySelectorAll("h1, h2, h3")]
+		.filter(h => getComputedStyle(h).display === "none" || getComputedStyle(h).visibility === "hidden");
+	return {clipped: clipped.map(describe), hidden: hidden.map(h => h.textContent.trim())};
+})()`
+
+// PrintPDF loads pageURL, lays it out for print at the paper's width to
+// find clipped content, and prints it to a tagged PDF with an outline
+func PrintPDF(ctx context.Context, pageURL string) (Printed, error) {
+	var p Printed
+	var layout struct {
+		Clipped []string `json:"clipped"`
+		Hidden  []string `json:"hidden"`
+	}
+	err := chromedp.Run(ctx,
+		chromedp.EmulateViewport(printWidth, printHeight),
+		chromedp.Navigate(pageURL),
+		WaitRendered(),
+		emulation.SetEmulatedMedia().WithMedia("print"),
+		chromedp.Evaluate(printLayoutJS, &layout),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			p.PDF, _, err = page.PrintToPDF().
+				WithPaperWidth(paperWidth).WithPaperHeight(paperHeight).
+				WithMarginTop(paperMargin).WithMarginBottom(paperMargin).
+				WithMarginLeft(paperMargin).WithMarginRight(paperMargin).
+				WithGenerateTaggedPDF(true).WithGenerateDocumentOutline(true).
+				Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return p, fmt.Errorf("print %s: %w", pageURL, err)
+	}
+	p.Clipped, p.Hidden = layout.Clipped, layout.Hidden
+	p.Pages, p.Headings, err = ReadPDF(p.PDF)
+	if err != nil {
+		return p, err
+	}
+	p.Text, err = PDFText(p.PDF)
+	return p, err
+}
+
+// ReadPDF returns a PDF's page count and the titles in its outline, depth
+// first
+func ReadPDF(data []byte) (pages int, headings []string, err error) {
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return 0, nil, fmt.Errorf("read PDF: %w", err)
+	}
+	var walk func(o pdf.Outline)
+	walk = func(o pdf.Outline) {
+		if o.Title != "" {
+			headings = append(headings, o.Title)
+		}
+		for _, c := range o.Child {
+			walk(c)
+		}
+	}
+	walk(r.Outline())
+	return r.NumPage(), headings, nil
+}
+
+// PDFText returns the text of every page of a PDF, in content order
+func PDFText(data []byte) (string, error) {
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("read PDF: %w", err)
+	}
+	text, err := r.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("read PDF text: %w", err)
+	}
+	b, err := io.ReadAll(text)
+	return string(b), err
+}
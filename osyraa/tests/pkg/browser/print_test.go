@@ -0,0 +1,113 @@
+package browser
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// minimalPDF builds a PDF with the given number of blank pages and an
+// outline with the given titles
+func minimalPDF(pages int, titles []string) []byte {
+	var objs []string
+	kids := ""
+	for i := 0; i < pages; i++ {
+		kids += fmt.Sprintf("%d 0 R ", 4+i)
+	}
+	objs = append(objs,
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 3 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids, pages))
+	first, last := 4+pages, 4+pages+len(titles)-1
+	objs = append(objs, fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>", first, last, len(titles)))
+	for i := 0; i < pages; i++ {
+		objs = append(objs, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>")
+	}
+	for i, title := range titles {
+		entry := fmt.Sprintf("<< /Title (%s) /Parent 3 0 R", title)
+		if i > 0 {
+			entry += fmt.Sprintf(" /Prev %d 0 R", first+i-1)
+		}
+		if i < len(titles)-1 {
+			entry += fmt.Sprintf(" /Next %d 0 R", first+i+1)
+		}
+		objs = append(objs, entry+" >>")
+	}
+
+	return writePDF(objs)
+}
+
+// textPDF builds a one-page PDF showing text in Helvetica
+func textPDF(text string) []byte {
+	stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	return writePDF(objs)
+}
+
+// writePDF numbers objs from 1, with the catalog first, and adds the
+// cross-reference table
+func writePDF(objs []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+func TestReadPDF(t *testing.T) {
+	pages, headings, err := ReadPDF(minimalPDF(2, []string{"Experience", "Skills"}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []string{"Experience", "Skills"}, headings)
+
+	_, _, err = ReadPDF([]byte("not a pdf"))
+	assert.Error(t, err)
+
+	text, err := PDFText(textPDF("jane@example.com"))
+	require.NoError(t, err)
+	assert.Contains(t, text, "jane@example.com")
+	_, err = PDFText([]byte("not a pdf"))
+	assert.Error(t, err)
+}
+
+func TestPrintPDF(t *testing.T) {
+	b := NewForTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html><style>@media print { .screen-only { display: none } }</style>
+<h1>Resume</h1><h2>Experience</h2><h2 class="screen-only">Contact form</h2>
+<pre style="width:1200px">a very wide block</pre>
+<div style="page-break-before:always"><h2>Skills</h2></div>`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	p, err := PrintPDF(ctx, srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.Pages)
+	assert.Subset(t, p.Headings, []string{"Resume", "Experience", "Skills"})
+	assert.Equal(t, []string{"Contact form"}, p.Hidden)
+	require.Len(t, p.Clipped, 1)
+	assert.Contains(t, p.Clipped[0], "pre")
+	assert.Contains(t, p.Text, "a very wide block")
+}
@@ -0,0 +1,65 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+)
+
+// idleQuiet is how long the page must go without starting a new request
+// before it counts as rendered
+const idleQuiet = 500 * time.Millisecond
+
+// WaitRendered waits for the load event, web fonts and a quiet network, so
+// content inserted by scripts or lazy loading is in place. The network is
+// judged quiet when the Resource Timing buffer stops growing.
+func WaitRendered() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		var ready bool
+		err := chromedp.Run(ctx,
+			chromedp.Poll(`document.readyState === "complete"`, &ready, chromedp.WithPollingInterval(50*time.Millisecond)),
+			chromedp.Evaluate(`document.fonts.ready.then(() => true)`, &ready,
+				func(p *runtime.EvaluateParams) *runtime.EvaluateParams { return p.WithAwaitPromise(true) }),
+		)
+		if err != nil {
+			return err
+		}
+		last, stableSince := -1, time.Now()
+		for {
+			var n int
+			if err := chromedp.Evaluate(`performance.getEntriesByType("resource").length`, &n).Do(ctx); err != nil {
+				return err
+			}
+			if n != last {
+				last, stableSince = n, time.Now()
+			} else if time.Since(stableSince) >= idleQuiet {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	})
+}
+
+// Render loads pageURL in the tab, waits for it to render and returns the
+// DOM as scripts left it, doctype included
+func Render(ctx context.Context, pageURL string) (*match.Document, error) {
+	var markup string
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(pageURL),
+		WaitRendered(),
+		chromedp.Evaluate(`new XMLSerializer().serializeToString(document)`, &markup),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", pageURL, err)
+	}
+	return match.Parse(strings.NewReader(markup))
+}
@@ -0,0 +1,143 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Viewport is a device size to render at
+type Viewport struct {
+	Name          string
+	Width, Height int64
+	// Mobile emulates a touch device with a mobile user agent viewport
+	Mobile bool
+}
+
+// Emulate applies the viewport to the tab
+func (v Viewport) Emulate() chromedp.Action {
+	if v.Mobile {
+		return chromedp.EmulateViewport(v.Width, v.Height, chromedp.EmulateMobile, chromedp.EmulateTouch)
+	}
+	return chromedp.EmulateViewport(v.Width, v.Height)
+}
+
+// Desktop is the default viewport for single-size checks
+var Desktop = Viewport{Name: "desktop", Width: 1280, Height: 800}
+
+// Breakpoints are the phone, tablet and desktop sizes checked for
+// responsive layout
+var Breakpoints = []Viewport{
+	{Name: "mobile", Width: 375, Height: 667, Mobile: true},
+	{Name: "tablet", Width: 768, Height: 1024, Mobile: true},
+	Desktop,
+}
+
+// MinTargetSize is the smallest tap target, in CSS pixels, that WCAG 2.2
+// (2.5.8) accepts
+const MinTargetSize = 24
+
+// Target is a navigation link or button as laid out
+type Target struct {
+	Selector string  `json:"selector"`
+	Text     string  `json:"text"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Width    float64 `json:"width"`
+	Height   float64 `json:"height"`
+	Visible  bool    `json:"visible"`
+}
+
+// Layout is what a viewport-sized render of a page looks like
+type Layout struct {
+	Viewport      Viewport `json:"-"`
+	ViewportWidth float64  `json:"viewportWidth"`
+	ScrollWidth   float64  `json:"scrollWidth"`
+	// Overflowing lists the outermost elements that stick out of the
+	// viewport horizontally, outside any scrolling container
+	Overflowing []string `json:"overflowing"`
+	// Targets are the links and buttons in nav and header
+	Targets []Target `json:"targets"`
+	// MenuToggle reports a visible button that opens a collapsed menu
+	MenuToggle bool `json:"menuToggle"`
+}
+
+const measureJS = `(() => {
+	const vw = document.documentElement.clientWidth;
+	const describe = el => {
+		let s = el.tagName.toLowerCase();
+		if (el.id) return s + "#" + el.id;
+		if (el.classList.length) s += "." + [...el.classList].join(".");
+		return s;
+	};
+	const visible = el => {
+		const r = el.getBoundingClientRect(), cs = getComputedStyle(el);
+		return r.width > 0 && r.height > 0 && cs.visibility !== "hidden" && cs.display !== "none";
+	};
+	const scrolls = el => {
+		for (let p = el.parentElement; p && p !== document.body; p = p.parentElement) {
+			if (getComputedStyle(p).overflowX !== "visible") return true;
+		}
+		return false;
+	};
+	const overflowing = [];
+	for (const el of document.body.querySelectorAll("*")) {
+		const r = el.getBoundingClientRect();
+		if (r.width === 0 || (r.right <= vw + 1 && r.left >= -1) || scrolls(el)) continue;
+		if (!overflowing.some(o => o.contains(el))) overflowing.push(el);
+	}
+	const targets = [...document.querySelectorAll("nav a, nav button, header a, header button, [role=navigation] a")]
+		.map(el => {
+			const r = el.getBoundingClientRect();
+			return {selector: describe(el), text: el.textContent.trim(), x: r.x, y: r.y,
+				width: r.width, height: r.height, visible: visible(el)};
+		});
+	const toggle = [...document.querySelectorAll("button[aria-expanded], button[aria-controls]")].some(visible);
+	return {viewportWidth: vw, scrollWidth: document.documentElement.scrollWidth,
+		overflowing: overflowing.map(describe), targets, menuToggle: toggle};
+})()`
+
+// MeasureLayout renders pageURL at vp and measures overflow and the
+// navigation's tap targets
+func MeasureLayout(ctx context.Context, pageURL string, vp Viewport) (Layout, error) {
+	layout := Layout{Viewport: vp}
+	err := chromedp.Run(ctx,
+		vp.Emulate(),
+		chromedp.Navigate(pageURL),
+		WaitRendered(),
+		chromedp.Evaluate(measureJS, &layout),
+	)
+	if err != nil {
+		return layout, fmt.Errorf("measure %s at %s: %w", pageURL, vp.Name, err)
+	}
+	return layout, nil
+}
+
+// Problems lists horizontal overflow and navigation that is hidden
+// without a menu toggle, off-screen or too small to tap
+func (l Layout) Problems() []string {
+	var problems []string
+	if l.ScrollWidth > l.ViewportWidth+1 {
+		problems = append(problems, fmt.Sprintf("%s: page scrolls horizontally (%.0fpx wide in a %.0fpx viewport)",
+			l.Viewport.Name, l.ScrollWidth, l.ViewportWidth))
+	}
+	for _, el := range l.Overflowing {
+		problems = append(problems, fmt.Sprintf("%s: %s overflows the viewport", l.Viewport.Name, el))
+	}
+	for _, t := range l.Targets {
+		name := fmt.Sprintf("%s %q", t.Selector, t.Text)
+		switch {
+		case !t.Visible:
+			if !l.MenuToggle {
+				problems = append(problems, fmt.Sprintf("%s: %s is hidden and no menu toggle is shown", l.Viewport.Name, name))
+			}
+		case t.X < 0 || t.X+t.Width > l.ViewportWidth+1:
+			problems = append(problems, fmt.Sprintf("%s: %s is partly off-screen", l.Viewport.Name, name))
+		case l.Viewport.Mobile && (t.Width < MinTargetSize || t.Height < MinTargetSize):
+			problems = append(problems, fmt.Sprintf("%s: %s is %.0fx%.0fpx, smaller than the %dpx tap target minimum",
+				l.Viewport.Name, name, t.Width, t.Height, MinTargetSize))
+		}
+	}
+	return problems
+}
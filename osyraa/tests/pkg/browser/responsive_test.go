@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutProblems(t *testing.T) {
+	mobile := Breakpoints[0]
+	l := Layout{
+		Viewport: mobile, ViewportWidth: 375, ScrollWidth: 600,
+		Overflowing: []string{"pre.code"},
+		Targets: []Target{
+			{Selector: "a", Text: "GitHub", X: 10, Width: 60, Height: 26, Visible: true},
+			{Selector: "a", Text: "CV", X: 10, Width: 18, Height: 18, Visible: true},
+			{Selector: "a", Text: "Blog", X: 360, Width: 40, Height: 26, Visible: true},
+			{Selector: "a.menu", Text: "About"},
+		},
+	}
+	assert.Equal(t, []string{
+		"mobile: page scrolls horizontally (600px wide in a 375px viewport)",
+		"mobile: pre.code overflows the viewport",
+		`mobile: a "CV" is 18x18px, smaller than the 24px tap target minimum`,
+		`mobile: a "Blog" is partly off-screen`,
+		`mobile: a.menu "About" is hidden and no menu toggle is shown`,
+	}, l.Problems())
+
+	// A collapsed menu is fine when a toggle is shown, and small targets
+	// only matter on touch devices
+	l = Layout{Viewport: Desktop, ViewportWidth: 1280, ScrollWidth: 1280, MenuToggle: true,
+		Targets: []Target{{Text: "CV", Width: 18, Height: 18, Visible: true}, {Text: "About"}}}
+	assert.Empty(t, l.Problems())
+}
+
+func TestMeasureLayout(t *testing.T) {
+	b := NewForTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html><meta name="viewport" content="width=device-width">
+<body style="margin:0"><header><a href="/" style="display:inline-block;padding:8px">Home</a></header>
+<div id="wide" style="width:900px">wide</div></body>`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	l, err := MeasureLayout(ctx, srv.URL, Breakpoints[0])
+	require.NoError(t, err)
+	assert.Equal(t, float64(375), l.ViewportWidth)
+	assert.Equal(t, []string{"div#wide"}, l.Overflowing)
+	require.Len(t, l.Targets, 1)
+	assert.True(t, l.Targets[0].Visible)
+	assert.Contains(t, l.Problems(), "mobile: page scrolls horizontally (900px wide in a 375px viewport)")
+}
@@ -0,0 +1,35 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/chromedp"
+)
+
+// freezeCSS stops animations, transitions and the caret so repeated
+// screenshots of an unchanged page are identical
+const freezeCSS = `*, *::before, *::after {
+	animation: none !important; transition: none !important; caret-color: transparent !important;
+}`
+
+// Screenshot renders pageURL at vp and returns a PNG of the full page
+func Screenshot(ctx context.Context, pageURL string, vp Viewport) ([]byte, error) {
+	var png []byte
+	err := chromedp.Run(ctx,
+		vp.Emulate(),
+		chromedp.Navigate(pageURL),
+		chromedp.Evaluate(fmt.Sprintf(`{
+			const s = document.createElement("style");
+			s.textContent = %q;
+			document.head.appendChild(s);
+		}`, freezeCSS), nil),
+		WaitRendered(),
+		// Quality 100 selects PNG, which the diff needs to be lossless
+		chromedp.FullScreenshot(&png, 100),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("screenshot %s: %w", pageURL, err)
+	}
+	return png, nil
+}
@@ -0,0 +1,28 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+// NewForTest starts headless Chrome for a test and closes it when the
+// test ends. OSYRAA_CHROME overrides the binary and OSYRAA_CHROME_URL
+// connects to a running browser instead. The test is skipped when Chrome
+// is not installed and fails when it will not start.
+func NewForTest(t testing.TB) *Browser {
+	t.Helper()
+	b, err := New(context.Background(), Options{
+		ExecPath:  os.Getenv("OSYRAA_CHROME"),
+		RemoteURL: os.Getenv("OSYRAA_CHROME_URL"),
+	})
+	if errors.Is(err, ErrNoChrome) {
+		t.Skip("install Chrome or Chromium, or set OSYRAA_CHROME_URL, to run browser checks")
+	}
+	if err != nil {
+		t.Fatalf("Failed to start headless Chrome: %v", err)
+	}
+	t.Cleanup(b.Close)
+	return b
+}
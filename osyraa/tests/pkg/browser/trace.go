@@ -0,0 +1,306 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/tracing"
+	"github.com/chromedp/chromedp"
+)
+
+// TraceCategories are the categories DevTools' Performance panel records,
+// enough for tasks, layout shifts and network timing
+var TraceCategories = []string{
+	"__metadata", "toplevel", "devtools.timeline", "disabled-by-default-devtools.timeline",
+	"loading", "blink.user_timing", "v8.execute",
+}
+
+// LongTaskThreshold is the duration above which a main-thread task blocks
+// input, as defined for Total Blocking Time
+const LongTaskThreshold = 50 * time.Millisecond
+
+// TraceEvent is one entry of a Chrome trace
+type TraceEvent struct {
+	Name string          `json:"name"`
+	Cat  string          `json:"cat"`
+	Ph   string          `json:"ph"`
+	TS   float64         `json:"ts"`
+	Dur  float64         `json:"dur"`
+	PID  int64           `json:"pid"`
+	TID  int64           `json:"tid"`
+	Args json.RawMessage `json:"args"`
+}
+
+// Trace is a recorded page load
+type Trace struct {
+	Events []TraceEvent
+	// JSON is the trace in the format DevTools and Perfetto open
+	JSON []byte
+	// origin is the navigation start, in trace microseconds
+	origin float64
+}
+
+// Task is a main-thread task, timed from navigation start
+type Task struct {
+	Start    time.Duration
+	Duration time.Duration
+}
+
+// Shift is a layout shift, timed from navigation start
+type Shift struct {
+	At    time.Duration
+	Score float64
+	// RecentInput shifts follow user input and do not count toward CLS
+	RecentInput bool
+}
+
+// Resource is one request of the load, timed from navigation start
+type Resource struct {
+	URL      string
+	Status   int
+	MimeType string
+	Size     int64
+	Start    time.Duration
+	Response time.Duration
+	End      time.Duration
+}
+
+// CaptureTrace records a Chrome performance trace while pageURL loads and
+// renders. The tab must be fresh, so the trace covers the whole load.
+func CaptureTrace(ctx context.Context, pageURL string) (*Trace, error) {
+	var (
+		mu     sync.Mutex
+		chunks [][]byte
+		done   = make(chan struct{})
+	)
+	chromedp.ListenTarget(ctx, func(ev any) {
+		switch ev := ev.(type) {
+		case *tracing.EventDataCollected:
+			mu.Lock()
+			for _, v := range ev.Value {
+				chunks = append(chunks, bytes.Clone(v))
+			}
+			mu.Unlock()
+		case *tracing.EventTracingComplete:
+			close(done)
+		}
+	})
+	err := chromedp.Run(ctx,
+		tracing.Start().
+			WithTransferMode(tracing.TransferModeReportEvents).
+			WithTraceConfig(&tracing.TraceConfig{IncludedCategories: TraceCategories}),
+		chromedp.Navigate(pageURL),
+		WaitRendered(),
+		tracing.End(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("trace %s: %w", pageURL, err)
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("trace %s: %w", pageURL, ctx.Err())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	data := append([]byte(`{"traceEvents":[`), bytes.Join(chunks, []byte(","))...)
+	return ParseTrace(append(data, "]}"...))
+}
+
+// ParseTrace reads a trace in the JSON object or bare array format
+func ParseTrace(data []byte) (*Trace, error) {
+	t := &Trace{JSON: data}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &t.Events); err != nil {
+			return nil, fmt.Errorf("parse trace: %w", err)
+		}
+	} else {
+		var wrapper struct {
+			TraceEvents []TraceEvent `json:"traceEvents"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("parse trace: %w", err)
+		}
+		t.Events = wrapper.TraceEvents
+	}
+	sort.SliceStable(t.Events, func(i, j int) bool { return t.Events[i].TS < t.Events[j].TS })
+
+	for _, ev := range t.Events {
+		if ev.Name == "navigationStart" {
+			t.origin = ev.TS
+			break
+		}
+	}
+	if t.origin == 0 {
+		for _, ev := range t.Events {
+			if ev.Ph != "M" && ev.TS > 0 {
+				t.origin = ev.TS
+				break
+			}
+		}
+	}
+	return t, nil
+}
+
+// since converts a trace timestamp to time since navigation start
+func (t *Trace) since(ts float64) time.Duration {
+	return micros(ts - t.origin)
+}
+
+func micros(us float64) time.Duration {
+	return time.Duration(us * float64(time.Microsecond))
+}
+
+// mainThreads are the renderer main threads, named in metadata events
+func (t *Trace) mainThreads() map[[2]int64]bool {
+	threads := map[[2]int64]bool{}
+	for _, ev := range t.Events {
+		if ev.Ph != "M" || ev.Name != "thread_name" {
+			continue
+		}
+		var args struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(ev.Args, &args) == nil && args.Name == "CrRendererMain" {
+			threads[[2]int64{ev.PID, ev.TID}] = true
+		}
+	}
+	return threads
+}
+
+// LongTasks returns the renderer main-thread tasks over LongTaskThreshold
+func (t *Trace) LongTasks() []Task {
+	main := t.mainThreads()
+	var tasks []Task
+	for _, ev := range t.Events {
+		if ev.Name != "RunTask" || ev.Ph != "X" || !main[[2]int64{ev.PID, ev.TID}] {
+			continue
+		}
+		if d := micros(ev.Dur); d > LongTaskThreshold {
+			tasks = append(tasks, Task{Start: t.since(ev.TS), Duration: d})
+		}
+	}
+	return tasks
+}
+
+// TotalBlockingTime sums the time each long task ran past the threshold
+func TotalBlockingTime(tasks []Task) time.Duration {
+	var total time.Duration
+	for _, task := range tasks {
+		total += task.Duration - LongTaskThreshold
+	}
+	return total
+}
+
+// LCP is the Largest Contentful Paint: when the last candidate Chrome
+// reported was painted. ok is false when the page painted no candidate, or
+// Chrome invalidated the last one.
+func (t *Trace) LCP() (lcp time.Duration, ok bool) {
+	for _, ev := range t.Events {
+		switch ev.Name {
+		case "largestContentfulPaint::Candidate":
+			lcp, ok = t.since(ev.TS), true
+		case "largestContentfulPaint::Invalidate":
+			lcp, ok = 0, false
+		}
+	}
+	return lcp, ok
+}
+
+// LayoutShifts returns every layout shift in the trace
+func (t *Trace) LayoutShifts() []Shift {
+	var shifts []Shift
+	for _, ev := range t.Events {
+		if ev.Name != "LayoutShift" {
+			continue
+		}
+		var args struct {
+			Data struct {
+				Score          float64  `json:"score"`
+				Weighted       *float64 `json:"weighted_score_delta"`
+				HadRecentInput bool     `json:"had_recent_input"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(ev.Args, &args) != nil {
+			continue
+		}
+		score := args.Data.Score
+		if args.Data.Weighted != nil {
+			score = *args.Data.Weighted
+		}
+		shifts = append(shifts, Shift{At: t.since(ev.TS), Score: score, RecentInput: args.Data.HadRecentInput})
+	}
+	return shifts
+}
+
+// CLS is the Cumulative Layout Shift: the largest session window of
+// shifts less than a second apart and spanning at most five seconds
+func CLS(shifts []Shift) float64 {
+	var best, window float64
+	var first, last time.Duration
+	started := false
+	for _, s := range shifts {
+		if s.RecentInput {
+			continue
+		}
+		if !started || s.At-last >= time.Second || s.At-first >= 5*time.Second {
+			window, first, started = 0, s.At, true
+		}
+		window += s.Score
+		last = s.At
+		best = max(best, window)
+	}
+	return best
+}
+
+// Waterfall returns the load's requests in the order they started
+func (t *Trace) Waterfall() []Resource {
+	type data struct {
+		RequestID         string `json:"requestId"`
+		URL               string `json:"url"`
+		StatusCode        int    `json:"statusCode"`
+		MimeType          string `json:"mimeType"`
+		EncodedDataLength int64  `json:"encodedDataLength"`
+	}
+	var order []string
+	byID := map[string]*Resource{}
+	for _, ev := range t.Events {
+		switch ev.Name {
+		case "ResourceSendRequest", "ResourceReceiveResponse", "ResourceFinish":
+		default:
+			continue
+		}
+		var args struct {
+			Data data `json:"data"`
+		}
+		if json.Unmarshal(ev.Args, &args) != nil || args.Data.RequestID == "" {
+			continue
+		}
+		r := byID[args.Data.RequestID]
+		if r == nil {
+			if ev.Name != "ResourceSendRequest" {
+				continue
+			}
+			r = &Resource{URL: args.Data.URL, Start: t.since(ev.TS)}
+			byID[args.Data.RequestID] = r
+			order = append(order, args.Data.RequestID)
+		}
+		switch ev.Name {
+		case "ResourceReceiveResponse":
+			r.Status, r.MimeType, r.Response = args.Data.StatusCode, args.Data.MimeType, t.since(ev.TS)
+		case "ResourceFinish":
+			r.Size, r.End = args.Data.EncodedDataLength, t.since(ev.TS)
+		}
+	}
+	resources := make([]Resource, 0, len(order))
+	for _, id := range order {
+		resources = append(resources, *byID[id])
+	}
+	return resources
+}
@@ -0,0 +1,96 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleTrace is a trimmed page load: navigation at 1s, two tasks on the
+// renderer main thread and one elsewhere, two LCP candidates, three shifts
+// and two requests
+const sampleTrace = `{"traceEvents":[
+{"name":"thread_name","ph":"M","pid":7,"tid":1,"ts":0,"args":{"name":"CrRendererMain"}},
+{"name":"thread_name","ph":"M","pid":7,"tid":2,"ts":0,"args":{"name":"Compositor"}},
+{"name":"navigationStart","ph":"R","pid":7,"tid":1,"ts":1000000,"args":{}},
+{"name":"ResourceSendRequest","ph":"I","pid":7,"tid":1,"ts":1001000,"args":{"data":{"requestId":"1","url":"https://example.com/"}}},
+{"name":"ResourceReceiveResponse","ph":"I","pid":7,"tid":1,"ts":1040000,"args":{"data":{"requestId":"1","statusCode":200,"mimeType":"text/html"}}},
+{"name":"ResourceFinish","ph":"I","pid":7,"tid":1,"ts":1050000,"args":{"data":{"requestId":"1","encodedDataLength":5120}}},
+{"name":"RunTask","ph":"X","pid":7,"tid":1,"ts":1060000,"dur":120000,"args":{}},
+{"name":"RunTask","ph":"X","pid":7,"tid":1,"ts":1200000,"dur":10000,"args":{}},
+{"name":"RunTask","ph":"X","pid":7,"tid":2,"ts":1200000,"dur":900000,"args":{}},
+{"name":"ResourceSendRequest","ph":"I","pid":7,"tid":1,"ts":1070000,"args":{"data":{"requestId":"2","url":"https://example.com/font.woff2"}}},
+{"name":"ResourceReceiveResponse","ph":"I","pid":7,"tid":1,"ts":1090000,"args":{"data":{"requestId":"2","statusCode":404,"mimeType":"text/html"}}},
+{"name":"largestContentfulPaint::Candidate","ph":"R","pid":7,"tid":1,"ts":1250000,"args":{"data":{"size":5000}}},
+{"name":"largestContentfulPaint::Candidate","ph":"R","pid":7,"tid":1,"ts":1400000,"args":{"data":{"size":90000}}},
+{"name":"LayoutShift","ph":"I","pid":7,"tid":1,"ts":1300000,"args":{"data":{"score":0.2,"weighted_score_delta":0.05,"had_recent_input":false}}},
+{"name":"LayoutShift","ph":"I","pid":7,"tid":1,"ts":1500000,"args":{"data":{"score":0.03,"had_recent_input":false}}},
+{"name":"LayoutShift","ph":"I","pid":7,"tid":1,"ts":1600000,"args":{"data":{"score":0.5,"had_recent_input":true}}}
+]}`
+
+func TestParseTrace(t *testing.T) {
+	tr, err := ParseTrace([]byte(sampleTrace))
+	require.NoError(t, err)
+
+	tasks := tr.LongTasks()
+	assert.Equal(t, []Task{{Start: 60 * time.Millisecond, Duration: 120 * time.Millisecond}}, tasks,
+		"only main-thread tasks over 50ms count")
+	assert.Equal(t, 70*time.Millisecond, TotalBlockingTime(tasks))
+
+	shifts := tr.LayoutShifts()
+	require.Len(t, shifts, 3)
+	assert.Equal(t, 0.05, shifts[0].Score, "the weighted score is preferred")
+	assert.InDelta(t, 0.08, CLS(shifts), 1e-9, "shifts after input are excluded")
+
+	lcp, ok := tr.LCP()
+	assert.True(t, ok)
+	assert.Equal(t, 400*time.Millisecond, lcp, "the last candidate is the largest paint")
+
+	assert.Equal(t, []Resource{
+		{URL: "https://example.com/", Status: 200, MimeType: "text/html", Size: 5120,
+			Start: time.Millisecond, Response: 40 * time.Millisecond, End: 50 * time.Millisecond},
+		{URL: "https://example.com/font.woff2", Status: 404, MimeType: "text/html",
+			Start: 70 * time.Millisecond, Response: 90 * time.Millisecond},
+	}, tr.Waterfall())
+
+	bare, err := ParseTrace([]byte(`[{"name":"RunTask","ph":"X","ts":5,"dur":1}]`))
+	assert.NoError(t, err, "the bare array format is accepted")
+	_, ok = bare.LCP()
+	assert.False(t, ok, "a load with no candidate has no LCP")
+	_, err = ParseTrace([]byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestCLSWindows(t *testing.T) {
+	s := func(at time.Duration, score float64) Shift { return Shift{At: at, Score: score} }
+	assert.Zero(t, CLS(nil))
+	// Two bursts more than a second apart: the larger burst wins
+	assert.InDelta(t, 0.3, CLS([]Shift{s(0, 0.1), s(500*time.Millisecond, 0.1), s(3*time.Second, 0.3)}), 1e-9)
+	// A window closes after five seconds even without a gap
+	var steady []Shift
+	for i := range 12 {
+		steady = append(steady, s(time.Duration(i)*900*time.Millisecond, 0.01))
+	}
+	assert.InDelta(t, 0.06, CLS(steady), 1e-9)
+}
+
+func TestCaptureTrace(t *testing.T) {
+	b := NewForTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html><h1>Hello</h1><script>const end = Date.now() + 120; while (Date.now() < end) {}</script>`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	tr, err := CaptureTrace(ctx, srv.URL)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tr.LongTasks(), "the busy loop is a long task")
+	require.NotEmpty(t, tr.Waterfall())
+	assert.Equal(t, srv.URL+"/", tr.Waterfall()[0].URL)
+}
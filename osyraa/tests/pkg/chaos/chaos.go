@@ -0,0 +1,264 @@
+// Package chaos injects faults into the running site while it is under
+// load and checks it keeps serving and recovers. A Scenario's fault is
+// injected between a steady window before and a recovery window after,
+// and each window's requests are summarised separately, so a failure
+// points at the fault rather than at a site that was already unhealthy.
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Exec runs a command inside the system under test, such as a docker exec
+// into the container, and returns its standard output
+type Exec func(ctx context.Context, cmd []string) (string, error)
+
+// Load sends GET requests to a URL from several workers at once until
+// stopped
+type Load struct {
+	URL    string
+	Client *http.Client
+	// Workers is the number of concurrent requesters; zero means 4
+	Workers int
+	// Pause is each worker's wait between requests
+	Pause time.Duration
+}
+
+// Sample is one request's outcome
+type Sample struct {
+	At       time.Time
+	Duration time.Duration
+	Status   int
+	Err      error
+}
+
+// OK reports whether the request got a 2xx response
+func (s Sample) OK() bool {
+	return s.Err == nil && s.Status >= 200 && s.Status < 300
+}
+
+// Running is a load in progress
+type Running struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	samples []Sample
+}
+
+// Start begins sending requests; Stop ends them
+func (l Load) Start(ctx context.Context) *Running {
+	client := l.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	workers := l.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r := &Running{cancel: cancel}
+	for i := 0; i < workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for ctx.Err() == nil {
+				s := get(ctx, client, l.URL)
+				if ctx.Err() != nil {
+					// Requests cut short by Stop say nothing about the site
+					return
+				}
+				r.mu.Lock()
+				r.samples = append(r.samples, s)
+				r.mu.Unlock()
+				if l.Pause > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(l.Pause):
+					}
+				}
+			}
+		}()
+	}
+	return r
+}
+
+func get(ctx context.Context, client *http.Client, url string) Sample {
+	s := Sample{At: time.Now()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		s.Err = err
+		return s
+	}
+	resp, err := client.Do(req)
+	if err == nil {
+		s.Status = resp.StatusCode
+		// A body cut off by a dying worker is a failure too
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	s.Err = err
+	s.Duration = time.Since(s.At)
+	return s
+}
+
+// Samples returns the outcomes so far, in order of completion
+func (r *Running) Samples() []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Sample(nil), r.samples...)
+}
+
+// Stop ends the load and returns every outcome
+func (r *Running) Stop() []Sample {
+	r.cancel()
+	r.wg.Wait()
+	return r.Samples()
+}
+
+// Stats summarise the requests sent in a window
+type Stats struct {
+	Requests int
+	Failures int
+	// Errors holds the first few failures' reasons
+	Errors []string
+	// Slowest is the longest request
+	Slowest time.Duration
+}
+
+// Availability is the fraction of requests that succeeded, 1 when none
+// were sent
+func (s Stats) Availability() float64 {
+	if s.Requests == 0 {
+		return 1
+	}
+	return float64(s.Requests-s.Failures) / float64(s.Requests)
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d requests, %d failed (%.2f%% available), slowest %v",
+		s.Requests, s.Failures, s.Availability()*100, s.Slowest.Round(time.Millisecond))
+}
+
+// Summarize covers the samples that completed from from up to, but
+// excluding, to. A request in flight when a fault starts counts towards
+// the fault, which is when its outcome was decided.
+func Summarize(samples []Sample, from, to time.Time) Stats {
+	var st Stats
+	for _, s := range samples {
+		if done := s.At.Add(s.Duration); done.Before(from) || !done.Before(to) {
+			continue
+		}
+		st.Requests++
+		st.Slowest = max(st.Slowest, s.Duration)
+		if s.OK() {
+			continue
+		}
+		st.Failures++
+		if len(st.Errors) < 5 {
+			reason := fmt.Sprintf("status %d", s.Status)
+			if s.Err != nil {
+				reason = s.Err.Error()
+			}
+			st.Errors = append(st.Errors, reason)
+		}
+	}
+	return st
+}
+
+// Scenario is a fault and how much of it the site must absorb
+type Scenario struct {
+	Name string
+	// Inject applies the fault. The returned undo, if not nil, reverts
+	// it at the end of the fault window; faults the site heals from on
+	// its own, like a killed worker, return nil.
+	Inject func(ctx context.Context) (undo func(context.Context) error, err error)
+	// MinAvailability is the fraction of requests during the fault that
+	// must succeed. Before and after it, every request must.
+	MinAvailability float64
+}
+
+// Timing sets the windows of a scenario run
+type Timing struct {
+	Before, During, After time.Duration
+}
+
+// DefaultTiming gives the site a few seconds in each window
+func DefaultTiming() Timing {
+	return Timing{Before: 2 * time.Second, During: 5 * time.Second, After: 3 * time.Second}
+}
+
+// Report is the outcome of a scenario run
+type Report struct {
+	Scenario string
+	Before   Stats
+	During   Stats
+	After    Stats
+}
+
+// Run puts load on the site, injects the scenario's fault after the
+// steady window and recovers from it after the fault window. Recovery is
+// measured from the moment undo returns.
+func Run(ctx context.Context, load Load, s Scenario, timing Timing) (Report, error) {
+	report := Report{Scenario: s.Name}
+	running := load.Start(ctx)
+	start := time.Now()
+	if err := sleep(ctx, timing.Before); err != nil {
+		running.Stop()
+		return report, err
+	}
+
+	injected := time.Now()
+	undo, err := s.Inject(ctx)
+	if err != nil {
+		running.Stop()
+		return report, fmt.Errorf("%s: inject: %w", s.Name, err)
+	}
+	err = sleep(ctx, timing.During)
+	if undo != nil {
+		if rerr := undo(ctx); rerr != nil && err == nil {
+			err = fmt.Errorf("%s: recover: %w", s.Name, rerr)
+		}
+	}
+	recovered := time.Now()
+	if err == nil {
+		err = sleep(ctx, timing.After)
+	}
+	samples := running.Stop()
+	end := time.Now()
+
+	report.Before = Summarize(samples, start, injected)
+	report.During = Summarize(samples, injected, recovered)
+	report.After = Summarize(samples, recovered, end)
+	return report, err
+}
+
+// Problems lists how the run fell short of the scenario
+func (r Report) Problems(s Scenario) []string {
+	var problems []string
+	if r.Before.Failures > 0 {
+		problems = append(problems, fmt.Sprintf("%s: failing before the fault: %v %v", r.Scenario, r.Before, r.Before.Errors))
+	}
+	if r.During.Availability() < s.MinAvailability {
+		problems = append(problems, fmt.Sprintf("%s: %.2f%% available during the fault, want %.2f%%: %v %v",
+			r.Scenario, r.During.Availability()*100, s.MinAvailability*100, r.During, r.During.Errors))
+	}
+	if r.After.Failures > 0 || r.After.Requests == 0 {
+		problems = append(problems, fmt.Sprintf("%s: not recovered: %v %v", r.Scenario, r.After, r.After.Errors))
+	}
+	return problems
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
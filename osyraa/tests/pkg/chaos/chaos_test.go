@@ -0,0 +1,154 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	at := time.Unix(1000, 0)
+	samples := []Sample{
+		{At: at, Status: 200, Duration: time.Millisecond},
+		{At: at.Add(time.Second), Status: 502, Duration: 3 * time.Millisecond},
+		{At: at.Add(time.Second), Err: errors.New("connection reset"), Duration: 2 * time.Millisecond},
+		{At: at.Add(2 * time.Second), Status: 200},
+	}
+	st := Summarize(samples, at.Add(time.Second), at.Add(2*time.Second))
+	assert.Equal(t, 2, st.Requests)
+	assert.Equal(t, 2, st.Failures)
+	assert.Equal(t, []string{"status 502", "connection reset"}, st.Errors)
+	assert.Equal(t, 3*time.Millisecond, st.Slowest)
+	assert.Equal(t, 0.0, st.Availability())
+	assert.Equal(t, 1.0, Summarize(nil, at, at).Availability())
+	assert.Equal(t, 1, Summarize(samples, at, at.Add(time.Second)).Requests)
+}
+
+func TestRun(t *testing.T) {
+	var broken atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if broken.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	load := Load{URL: srv.URL, Workers: 2, Pause: 5 * time.Millisecond}
+	timing := Timing{Before: 100 * time.Millisecond, During: 100 * time.Millisecond, After: 100 * time.Millisecond}
+	outage := Scenario{
+		Name:            "outage",
+		MinAvailability: 0.5,
+		Inject: func(context.Context) (func(context.Context) error, error) {
+			broken.Store(true)
+			return func(context.Context) error {
+				broken.Store(false)
+				return nil
+			}, nil
+		},
+	}
+	report, err := Run(context.Background(), load, outage, timing)
+	require.NoError(t, err)
+	assert.Equal(t, "outage", report.Scenario)
+	assert.Positive(t, report.Before.Requests)
+	assert.Zero(t, report.Before.Failures)
+	assert.Equal(t, report.During.Requests, report.During.Failures)
+	assert.Positive(t, report.After.Requests)
+	assert.Zero(t, report.After.Failures)
+	problems := report.Problems(outage)
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0], "outage: 0.00% available during the fault, want 50.00%")
+
+	outage.MinAvailability = 0
+	assert.Empty(t, report.Problems(outage))
+
+	// A fault that is never undone leaves the site unrecovered
+	stuck := Scenario{
+		Name: "stuck",
+		Inject: func(context.Context) (func(context.Context) error, error) {
+			broken.Store(true)
+			return nil, nil
+		},
+	}
+	report, err = Run(context.Background(), load, stuck, timing)
+	require.NoError(t, err)
+	problems = report.Problems(stuck)
+	require.Len(t, problems, 1)
+	assert.True(t, strings.HasPrefix(problems[0], "stuck: not recovered:"), problems[0])
+}
+
+func TestRunInjectError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	failing := Scenario{
+		Name: "failing",
+		Inject: func(context.Context) (func(context.Context) error, error) {
+			return nil, errors.New("no such container")
+		},
+	}
+	_, err := Run(context.Background(), Load{URL: srv.URL}, failing, Timing{})
+	assert.EqualError(t, err, "failing: inject: no such container")
+}
+
+// fakeExec records commands and answers them from a table keyed by the
+// command's first word
+type fakeExec struct {
+	out  map[string]string
+	cmds [][]string
+}
+
+func (f *fakeExec) exec(_ context.Context, cmd []string) (string, error) {
+	f.cmds = append(f.cmds, cmd)
+	return f.out[cmd[0]], nil
+}
+
+const busyboxPS = `PID   USER     TIME  COMMAND
+    1 root      0:00 nginx: master process nginx -g daemon off;
+   29 nginx     0:00 nginx: worker process
+   30 nginx     0:00 nginx: worker process
+   41 root      0:00 ps -o pid,args
+`
+
+func TestKillWorkers(t *testing.T) {
+	assert.Equal(t, []int{29, 30}, parseWorkers(busyboxPS))
+
+	f := &fakeExec{out: map[string]string{"ps": busyboxPS}}
+	undo, err := KillWorkers(f.exec, 0.9).Inject(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, undo)
+	assert.Equal(t, []string{"kill", "-9", "29", "30"}, f.cmds[len(f.cmds)-1])
+
+	f = &fakeExec{out: map[string]string{"ps": "PID COMMAND\n1 sleep 60\n"}}
+	_, err = KillWorkers(f.exec, 0.9).Inject(context.Background())
+	assert.EqualError(t, err, "no nginx worker processes found")
+}
+
+func TestFillDisk(t *testing.T) {
+	f := &fakeExec{out: map[string]string{"sh": "tmpfs 16384 16384 0 100% /tmp\n"}}
+	undo, err := FillDisk(f.exec, "/tmp", 1).Inject(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/.osyraa-chaos-fill", f.cmds[0][len(f.cmds[0])-1])
+	require.NotNil(t, undo)
+	require.NoError(t, undo(context.Background()))
+	assert.Equal(t, []string{"rm", "-f", "/tmp/.osyraa-chaos-fill"}, f.cmds[1])
+
+	// dd stopping early for any reason but a full disk is an error, and
+	// the partial file is still removed
+	f = &fakeExec{out: map[string]string{"sh": "overlay 1048576 1024 1047552 1% /\n"}}
+	_, err = FillDisk(f.exec, "/", 1).Inject(context.Background())
+	assert.EqualError(t, err, "/ still has 1047552 KiB free after filling")
+	assert.Equal(t, []string{"rm", "-f", "/.osyraa-chaos-fill"}, f.cmds[1])
+
+	f = &fakeExec{out: map[string]string{"sh": "df: /nope: No such file\n"}}
+	_, err = FillDisk(f.exec, "/nope", 1).Inject(context.Background())
+	assert.ErrorContains(t, err, "unexpected df output")
+}
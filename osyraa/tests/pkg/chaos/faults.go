@@ -0,0 +1,107 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// NginxWorkers lists the PIDs of nginx's worker processes. It reads ps
+// rather than pgrep, which busybox images may leave out.
+func NginxWorkers(ctx context.Context, exec Exec) ([]int, error) {
+	out, err := exec(ctx, []string{"ps", "-o", "pid,args"})
+	if err != nil {
+		return nil, err
+	}
+	return parseWorkers(out), nil
+}
+
+func parseWorkers(ps string) []int {
+	var pids []int
+	for _, line := range strings.Split(ps, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.Contains(line, "nginx: worker process") {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[0]); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// KillWorkers is a scenario that SIGKILLs every nginx worker at once. The
+// master must start new ones; only requests in flight on the killed
+// workers may fail.
+func KillWorkers(exec Exec, minAvailability float64) Scenario {
+	return Scenario{
+		Name:            "kill nginx workers",
+		MinAvailability: minAvailability,
+		Inject: func(ctx context.Context) (func(context.Context) error, error) {
+			pids, err := NginxWorkers(ctx, exec)
+			if err != nil {
+				return nil, err
+			}
+			if len(pids) == 0 {
+				return nil, fmt.Errorf("no nginx worker processes found")
+			}
+			cmd := []string{"kill", "-9"}
+			for _, pid := range pids {
+				cmd = append(cmd, strconv.Itoa(pid))
+			}
+			if _, err := exec(ctx, cmd); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		},
+	}
+}
+
+// fillName is the file FillDisk writes, named so it is recognisable if
+// a run dies before removing it
+const fillName = ".osyraa-chaos-fill"
+
+// FillDisk is a scenario that writes to dir until its file system is
+// full, and removes the file to recover. Point it at a size-limited
+// tmpfs, never at a disk shared with the host.
+func FillDisk(exec Exec, dir string, minAvailability float64) Scenario {
+	file := path.Join(dir, fillName)
+	return Scenario{
+		Name:            "fill " + dir,
+		MinAvailability: minAvailability,
+		Inject: func(ctx context.Context) (func(context.Context) error, error) {
+			// dd fails once the file system is full; df then shows what is left
+			out, err := exec(ctx, []string{"sh", "-c",
+				`dd if=/dev/zero of="$1" bs=64k 2>/dev/null; df -Pk "$(dirname "$1")" | tail -n 1`, "sh", file})
+			if err != nil {
+				return nil, err
+			}
+			available, err := dfAvailable(out)
+			if err != nil {
+				return nil, err
+			}
+			release := func(ctx context.Context) error {
+				_, err := exec(ctx, []string{"rm", "-f", file})
+				return err
+			}
+			// Less than one dd block left means dd stopped because the
+			// file system is full, not for another reason
+			if available >= 64 {
+				release(ctx)
+				return nil, fmt.Errorf("%s still has %d KiB free after filling", dir, available)
+			}
+			return release, nil
+		},
+	}
+}
+
+// dfAvailable reads the available KiB from a line of df -P output
+func dfAvailable(line string) (int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return 0, fmt.Errorf("unexpected df output %q", strings.TrimSpace(line))
+	}
+	return strconv.Atoi(fields[3])
+}
@@ -0,0 +1,294 @@
+// Package cleanup finds and removes what test runs leave behind. Every
+// container, image and network the harness creates carries labels naming
+// the run, the process and the host, and every temporary directory holds a
+// marker file with the same; a resource whose process is gone, or which is
+// older than a cutoff, is an orphan.
+package cleanup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+)
+
+// Labels put on harness resources
+const (
+	LabelRun  = "io.osyraa.run"
+	LabelPID  = "io.osyraa.pid"
+	LabelHost = "io.osyraa.host"
+	// LabelKindCluster names the kind cluster a marked directory belongs
+	// to. kind can't label its node containers, so the cluster is found
+	// through the directory holding its kubeconfig.
+	LabelKindCluster = "io.osyraa.kind-cluster"
+)
+
+// kindClusterLabel is the label kind puts on the node containers of a
+// cluster
+const kindClusterLabel = "io.x-k8s.kind.cluster"
+
+// MarkerFile is written into harness temporary directories
+const MarkerFile = ".osyraa-run.json"
+
+// TempPattern matches harness temporary directory names under os.TempDir
+const TempPattern = "osyraa-*"
+
+// Labels returns the labels for resources created by run in this process
+func Labels(run string) map[string]string {
+	host, _ := os.Hostname()
+	return map[string]string{LabelRun: run, LabelPID: strconv.Itoa(os.Getpid()), LabelHost: host}
+}
+
+// LabelArgs renders labels as docker CLI --label flags, sorted
+func LabelArgs(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var args []string
+	for _, k := range keys {
+		args = append(args, "--label", k+"="+labels[k])
+	}
+	return args
+}
+
+// MarkDir records labels in dir so Find recognizes it
+func MarkDir(dir string, labels map[string]string) error {
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, MarkerFile), data, 0o644)
+}
+
+// Resource kinds
+const (
+	Container = "container"
+	Image     = "image"
+	Network   = "network"
+	Cluster   = "cluster"
+	Dir       = "dir"
+)
+
+// Resource is something a run created
+type Resource struct {
+	Kind string
+	// ID is the engine's ID, or the path of a directory
+	ID      string
+	Name    string
+	Labels  map[string]string
+	Created time.Time
+	// Orphaned is set when the resource may be removed, with the reason
+	Orphaned bool
+	Reason   string
+}
+
+func (r Resource) String() string {
+	name := r.Name
+	if name == "" {
+		name = r.ID
+	}
+	s := fmt.Sprintf("%-9s %s (run %s", r.Kind, name, r.Labels[LabelRun])
+	if !r.Created.IsZero() {
+		s += ", created " + r.Created.Format(time.RFC3339)
+	}
+	return s + ")"
+}
+
+// Docker is the part of the engine client Find and Remove use
+type Docker interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ImageList(ctx context.Context, options types.ImageListOptions) ([]image.Summary, error)
+	NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	ImageRemove(ctx context.Context, imageID string, options types.ImageRemoveOptions) ([]image.DeleteResponse, error)
+	NetworkRemove(ctx context.Context, networkID string) error
+}
+
+// Options decide what counts as orphaned
+type Options struct {
+	// OlderThan marks anything created longer ago as orphaned, whatever
+	// its process; zero disables the age cutoff
+	OlderThan time.Duration
+	// All marks everything as orphaned, including live runs
+	All bool
+	// Run limits the search to one run ID
+	Run string
+	// Host is this machine's hostname; processes are only checked for
+	// resources created here. Empty means os.Hostname.
+	Host string
+	// Alive reports whether a process is running; nil checks for real
+	Alive func(pid int) bool
+	Now   func() time.Time
+}
+
+func (o Options) classify(r *Resource) {
+	now := time.Now
+	if o.Now != nil {
+		now = o.Now
+	}
+	alive := processAlive
+	if o.Alive != nil {
+		alive = o.Alive
+	}
+	host := o.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	pid, pidErr := strconv.Atoi(r.Labels[LabelPID])
+	switch {
+	case o.All:
+		r.Orphaned, r.Reason = true, "all runs selected"
+	case r.Labels[LabelHost] == host && pidErr == nil && !alive(pid):
+		r.Orphaned, r.Reason = true, fmt.Sprintf("process %d has exited", pid)
+	case o.OlderThan > 0 && !r.Created.IsZero() && now().Sub(r.Created) > o.OlderThan:
+		r.Orphaned, r.Reason = true, fmt.Sprintf("older than %s", o.OlderThan)
+	case r.Labels[LabelHost] != host:
+		r.Reason = "created on " + r.Labels[LabelHost]
+	default:
+		r.Reason = fmt.Sprintf("process %d is running", pid)
+	}
+}
+
+// processAlive reports whether pid is a running process. Signal 0 checks
+// without delivering anything; on Windows FindProcess itself fails for a
+// process that has gone.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// Find lists the labelled containers, images and networks on the engine
+// (when docker is not nil) and the marked directories under tempDir,
+// classified by opts
+func Find(ctx context.Context, docker Docker, tempDir string, opts Options) ([]Resource, error) {
+	var found []Resource
+	if docker != nil {
+		label := LabelRun
+		if opts.Run != "" {
+			label += "=" + opts.Run
+		}
+		args := filters.NewArgs(filters.Arg("label", label))
+
+		containers, err := docker.ContainerList(ctx, container.ListOptions{All: true, Filters: args})
+		if err != nil {
+			return nil, fmt.Errorf("list containers: %w", err)
+		}
+		for _, c := range containers {
+			name := c.ID[:min(12, len(c.ID))]
+			if len(c.Names) > 0 {
+				name = c.Names[0]
+			}
+			found = append(found, Resource{Kind: Container, ID: c.ID, Name: name, Labels: c.Labels, Created: time.Unix(c.Created, 0)})
+		}
+		images, err := docker.ImageList(ctx, types.ImageListOptions{Filters: args})
+		if err != nil {
+			return nil, fmt.Errorf("list images: %w", err)
+		}
+		for _, img := range images {
+			name := img.ID
+			if len(img.RepoTags) > 0 {
+				name = img.RepoTags[0]
+			}
+			found = append(found, Resource{Kind: Image, ID: img.ID, Name: name, Labels: img.Labels, Created: time.Unix(img.Created, 0)})
+		}
+		networks, err := docker.NetworkList(ctx, types.NetworkListOptions{Filters: args})
+		if err != nil {
+			return nil, fmt.Errorf("list networks: %w", err)
+		}
+		for _, n := range networks {
+			found = append(found, Resource{Kind: Network, ID: n.ID, Name: n.Name, Labels: n.Labels, Created: n.Created})
+		}
+	}
+
+	if tempDir != "" {
+		dirs, err := filepath.Glob(filepath.Join(tempDir, TempPattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			data, err := os.ReadFile(filepath.Join(dir, MarkerFile))
+			if err != nil {
+				continue
+			}
+			var labels map[string]string
+			if json.Unmarshal(data, &labels) != nil || opts.Run != "" && labels[LabelRun] != opts.Run {
+				continue
+			}
+			r := Resource{Kind: Dir, ID: dir, Labels: labels}
+			if info, err := os.Stat(dir); err == nil {
+				r.Created = info.ModTime()
+			}
+			if name := labels[LabelKindCluster]; name != "" {
+				if docker == nil {
+					// Kept, so the cluster can still be found with an engine
+					continue
+				}
+				found = append(found, Resource{Kind: Cluster, ID: name, Labels: labels, Created: r.Created})
+			}
+			found = append(found, r)
+		}
+	}
+
+	for i := range found {
+		opts.classify(&found[i])
+	}
+	return found, nil
+}
+
+// Remove deletes a resource: containers first stopped by force, kind
+// clusters by removing their node containers, images untagged and
+// deleted, directories removed recursively
+func Remove(ctx context.Context, docker Docker, r Resource) error {
+	switch r.Kind {
+	case Container:
+		return docker.ContainerRemove(ctx, r.ID, container.RemoveOptions{Force: true, RemoveVolumes: true})
+	case Cluster:
+		nodes, err := docker.ContainerList(ctx, container.ListOptions{All: true,
+			Filters: filters.NewArgs(filters.Arg("label", kindClusterLabel+"="+r.ID))})
+		if err != nil {
+			return fmt.Errorf("list nodes: %w", err)
+		}
+		var errs []error
+		for _, n := range nodes {
+			errs = append(errs, docker.ContainerRemove(ctx, n.ID, container.RemoveOptions{Force: true, RemoveVolumes: true}))
+		}
+		return errors.Join(errs...)
+	case Image:
+		_, err := docker.ImageRemove(ctx, r.ID, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+		return err
+	case Network:
+		return docker.NetworkRemove(ctx, r.ID)
+	case Dir:
+		return os.RemoveAll(r.ID)
+	}
+	return fmt.Errorf("unknown resource kind %q", r.Kind)
+}
+
+// RemoveOrder sorts resources so nothing is removed while another still
+// uses it: containers and clusters, then networks and images, then
+// directories
+func RemoveOrder(rs []Resource) {
+	rank := map[string]int{Container: 0, Cluster: 0, Network: 1, Image: 2, Dir: 3}
+	sort.SliceStable(rs, func(i, j int) bool { return rank[rs[i].Kind] < rank[rs[j].Kind] })
+}
@@ -0,0 +1,152 @@
+package cleanup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker returns fixed resources and records removals; filtering by
+// label is the engine's job, so it only checks a filter was sent
+type fakeDocker struct {
+	t          *testing.T
+	containers []types.Container
+	images     []image.Summary
+	networks   []types.NetworkResource
+	removed    []string
+	// listed are the label filters of the containers listed
+	listed []string
+}
+
+func (f *fakeDocker) ContainerList(_ context.Context, o container.ListOptions) ([]types.Container, error) {
+	assert.True(f.t, o.All, "stopped containers are orphans too")
+	assert.NotEmpty(f.t, o.Filters.Get("label"))
+	f.listed = append(f.listed, o.Filters.Get("label")...)
+	return f.containers, nil
+}
+
+func (f *fakeDocker) ImageList(_ context.Context, o types.ImageListOptions) ([]image.Summary, error) {
+	assert.NotEmpty(f.t, o.Filters.Get("label"))
+	return f.images, nil
+}
+
+func (f *fakeDocker) NetworkList(_ context.Context, o types.NetworkListOptions) ([]types.NetworkResource, error) {
+	assert.NotEmpty(f.t, o.Filters.Get("label"))
+	return f.networks, nil
+}
+
+func (f *fakeDocker) ContainerRemove(_ context.Context, id string, _ container.RemoveOptions) error {
+	f.removed = append(f.removed, "container "+id)
+	return nil
+}
+
+func (f *fakeDocker) ImageRemove(_ context.Context, id string, _ types.ImageRemoveOptions) ([]image.DeleteResponse, error) {
+	f.removed = append(f.removed, "image "+id)
+	return nil, nil
+}
+
+func (f *fakeDocker) NetworkRemove(_ context.Context, id string) error {
+	f.removed = append(f.removed, "network "+id)
+	return nil
+}
+
+var now = time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+func labels(run, pid, host string) map[string]string {
+	return map[string]string{LabelRun: run, LabelPID: pid, LabelHost: host}
+}
+
+func TestFind(t *testing.T) {
+	docker := &fakeDocker{t: t,
+		containers: []types.Container{
+			{ID: "c-crashed", Names: []string{"/crashed"}, Labels: labels("r1", "100", "here"), Created: now.Add(-time.Minute).Unix()},
+			{ID: "c-live", Labels: labels("r2", "200", "here"), Created: now.Add(-time.Minute).Unix()},
+		},
+		images: []image.Summary{
+			{ID: "sha256:old", RepoTags: []string{"resume:test-r3"}, Labels: labels("r3", "300", "ci-runner"), Created: now.Add(-48 * time.Hour).Unix()},
+			{ID: "sha256:remote", Labels: labels("r4", "400", "ci-runner"), Created: now.Add(-time.Hour).Unix()},
+		},
+		networks: []types.NetworkResource{{ID: "n1", Name: "osyraa-r1", Labels: labels("r1", "100", "here"), Created: now}},
+	}
+	tmp := t.TempDir()
+	for name, l := range map[string]map[string]string{"osyraa-r1-abc": labels("r1", "100", "here"), "osyraa-r2-def": labels("r2", "200", "here")} {
+		require.NoError(t, os.Mkdir(filepath.Join(tmp, name), 0o755))
+		require.NoError(t, MarkDir(filepath.Join(tmp, name), l))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(tmp, "osyraa-unmarked"), 0o755))
+	kind := labels("r1", "100", "here")
+	kind[LabelKindCluster] = "osyraa-r1"
+	require.NoError(t, os.Mkdir(filepath.Join(tmp, "osyraa-kind-ghi"), 0o755))
+	require.NoError(t, MarkDir(filepath.Join(tmp, "osyraa-kind-ghi"), kind))
+
+	opts := Options{OlderThan: 24 * time.Hour, Host: "here", Now: func() time.Time { return now },
+		Alive: func(pid int) bool { return pid == 200 }}
+	found, err := Find(context.Background(), docker, tmp, opts)
+	require.NoError(t, err)
+
+	got := map[string]string{}
+	for _, r := range found {
+		got[r.Kind+" "+r.ID] = r.Reason
+		assert.Equal(t, r.Reason != "process 200 is running" && r.Reason != "created on ci-runner", r.Orphaned, r.ID)
+	}
+	assert.Equal(t, map[string]string{
+		"container c-crashed": "process 100 has exited",
+		"container c-live":    "process 200 is running",
+		"image sha256:old":    "older than 24h0m0s",
+		"image sha256:remote": "created on ci-runner",
+		"network n1":          "process 100 has exited",
+		"dir " + filepath.Join(tmp, "osyraa-r1-abc"):   "process 100 has exited",
+		"dir " + filepath.Join(tmp, "osyraa-r2-def"):   "process 200 is running",
+		"cluster osyraa-r1":                            "process 100 has exited",
+		"dir " + filepath.Join(tmp, "osyraa-kind-ghi"): "process 100 has exited",
+	}, got, "unmarked directories are not the harness's")
+
+	opts.Run = "r1"
+	found, err = Find(context.Background(), nil, tmp, opts)
+	require.NoError(t, err)
+	require.Len(t, found, 1, "a cluster's directory is kept without an engine to remove the cluster")
+	assert.Equal(t, filepath.Join(tmp, "osyraa-r1-abc"), found[0].ID)
+
+	found, err = Find(context.Background(), nil, tmp, Options{All: true})
+	require.NoError(t, err)
+	for _, r := range found {
+		assert.True(t, r.Orphaned)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	docker := &fakeDocker{t: t}
+	dir := filepath.Join(t.TempDir(), "osyraa-x")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	rs := []Resource{{Kind: Dir, ID: dir}, {Kind: Image, ID: "i"}, {Kind: Network, ID: "n"}, {Kind: Container, ID: "c"}}
+	RemoveOrder(rs)
+	for _, r := range rs {
+		require.NoError(t, Remove(context.Background(), docker, r))
+	}
+	assert.Equal(t, []string{"container c", "network n", "image i"}, docker.removed)
+	assert.NoDirExists(t, dir)
+	assert.Error(t, Remove(context.Background(), docker, Resource{Kind: "volume"}))
+}
+
+func TestRemoveCluster(t *testing.T) {
+	docker := &fakeDocker{t: t, containers: []types.Container{{ID: "control-plane"}, {ID: "worker"}}}
+	require.NoError(t, Remove(context.Background(), docker, Resource{Kind: Cluster, ID: "osyraa-r1"}))
+	assert.Equal(t, []string{"container control-plane", "container worker"}, docker.removed)
+	assert.Equal(t, []string{kindClusterLabel + "=osyraa-r1"}, docker.listed)
+}
+
+func TestLabels(t *testing.T) {
+	l := Labels("r1")
+	assert.Equal(t, "r1", l[LabelRun])
+	assert.NotEmpty(t, l[LabelPID])
+	assert.Equal(t, []string{"--label", "a=1", "--label", "b=2"}, LabelArgs(map[string]string{"b": "2", "a": "1"}))
+	assert.True(t, processAlive(os.Getpid()))
+}
@@ -0,0 +1,181 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Manager holds the teardown step of every resource a run has created and
+// not yet removed. Steps normally run when their owner finishes, but if
+// the process is interrupted the Manager runs whatever is still pending,
+// so a cancelled run doesn't leave a container holding a port.
+type Manager struct {
+	mu    sync.Mutex
+	steps []*Step
+	// Log receives progress while tearing down after a signal; nil is
+	// os.Stderr
+	Log io.Writer
+}
+
+// NewManager returns an empty manager
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Step is one registered teardown
+type Step struct {
+	Name string
+	fn   func(context.Context) error
+	m    *Manager
+	once sync.Once
+	err  error
+}
+
+// Add registers fn to remove the resource called name
+func (m *Manager) Add(name string, fn func(ctx context.Context) error) *Step {
+	s := &Step{Name: name, fn: fn, m: m}
+	m.mu.Lock()
+	m.steps = append(m.steps, s)
+	m.mu.Unlock()
+	return s
+}
+
+// Run tears the resource down the first time it is called and returns
+// that outcome every time
+func (s *Step) Run(ctx context.Context) error {
+	s.once.Do(func() {
+		s.m.mu.Lock()
+		s.m.steps = slices.DeleteFunc(s.m.steps, func(o *Step) bool { return o == s })
+		s.m.mu.Unlock()
+		if err := s.fn(ctx); err != nil {
+			s.err = fmt.Errorf("%s: %w", s.Name, err)
+		}
+	})
+	return s.err
+}
+
+// Pending names the steps not yet run, oldest first
+func (m *Manager) Pending() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, len(m.steps))
+	for i, s := range m.steps {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// runNewestFirst runs steps in reverse order of registration, so a
+// resource goes before what it depends on (a container before its image)
+func runNewestFirst(ctx context.Context, steps []*Step) error {
+	var errs []error
+	for i := len(steps) - 1; i >= 0; i-- {
+		errs = append(errs, steps[i].Run(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// RunAll runs every pending step, newest first
+func (m *Manager) RunAll(ctx context.Context) error {
+	m.mu.Lock()
+	steps := slices.Clone(m.steps)
+	m.mu.Unlock()
+	return runNewestFirst(ctx, steps)
+}
+
+// Scope groups the steps of one owner, such as a test suite, so they can
+// be run together when it finishes
+type Scope struct {
+	m     *Manager
+	mu    sync.Mutex
+	steps []*Step
+}
+
+// Scope returns a new, empty scope
+func (m *Manager) Scope() *Scope {
+	return &Scope{m: m}
+}
+
+// Add registers a step with the manager and the scope
+func (s *Scope) Add(name string, fn func(ctx context.Context) error) *Step {
+	step := s.m.Add(name, fn)
+	s.mu.Lock()
+	s.steps = append(s.steps, step)
+	s.mu.Unlock()
+	return step
+}
+
+// Close runs the scope's steps, newest first
+func (s *Scope) Close(ctx context.Context) error {
+	s.mu.Lock()
+	steps := s.steps
+	s.steps = nil
+	s.mu.Unlock()
+	return runNewestFirst(ctx, steps)
+}
+
+// HandleSignals tears everything down when the process receives one of
+// sigs (default SIGINT and SIGTERM), allowing timeout for it, then exits
+// with 128 plus the signal number as a shell would report. A second
+// signal exits at once. exit is os.Exit when nil. The returned func stops
+// handling.
+func (m *Manager) HandleSignals(timeout time.Duration, exit func(int), sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	if exit == nil {
+		exit = os.Exit
+	}
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-ch:
+		case <-done:
+			return
+		}
+		code := 1
+		if n, ok := sig.(syscall.Signal); ok {
+			code = 128 + int(n)
+		}
+		log := m.Log
+		if log == nil {
+			log = os.Stderr
+		}
+		go func() {
+			select {
+			case <-ch:
+				fmt.Fprintln(log, "Second signal; exiting without finishing teardown")
+				exit(code)
+			case <-done:
+			}
+		}()
+
+		pending := m.Pending()
+		fmt.Fprintf(log, "\nReceived %v; tearing down %d resources\n", sig, len(pending))
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := m.RunAll(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(log, "Teardown incomplete (osyraa clean removes the rest):", err)
+		}
+		exit(code)
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
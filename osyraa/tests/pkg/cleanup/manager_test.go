@@ -0,0 +1,101 @@
+package cleanup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	var ran []string
+	step := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+
+	suite := m.Scope()
+	suite.Add("image", step("image", nil))
+	container := suite.Add("container", step("container", errors.New("in use")))
+	m.Add("dir", step("dir", nil))
+	assert.Equal(t, []string{"image", "container", "dir"}, m.Pending())
+
+	err := container.Run(context.Background())
+	assert.EqualError(t, err, "container: in use")
+	assert.Equal(t, err, container.Run(context.Background()), "a step runs once and keeps its outcome")
+	assert.Equal(t, []string{"image", "dir"}, m.Pending())
+
+	assert.EqualError(t, suite.Close(context.Background()), "container: in use", "a failed step is still reported")
+	assert.Equal(t, []string{"container", "image"}, ran)
+	assert.Equal(t, []string{"dir"}, m.Pending(), "closing a scope leaves other steps")
+
+	m.Add("network", step("network", nil))
+	require.NoError(t, m.RunAll(context.Background()))
+	assert.Equal(t, []string{"container", "image", "network", "dir"}, ran, "newest first")
+	assert.Empty(t, m.Pending())
+}
+
+func TestManagerConcurrent(t *testing.T) {
+	m := NewManager()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	count := 0
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := m.Add("x", func(context.Context) error {
+				mu.Lock()
+				count++
+				mu.Unlock()
+				return nil
+			})
+			go s.Run(context.Background())
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, m.RunAll(context.Background()))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 50, count)
+}
+
+func TestHandleSignals(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("a process cannot send itself an interrupt on Windows")
+	}
+	m := NewManager()
+	var log bytes.Buffer
+	m.Log = &log
+	removed := make(chan struct{})
+	m.Add("container", func(context.Context) error {
+		close(removed)
+		return nil
+	})
+	exited := make(chan int, 1)
+	stop := m.HandleSignals(time.Second, func(code int) { exited <- code }, os.Interrupt)
+	defer stop()
+
+	p, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, p.Signal(os.Interrupt))
+
+	select {
+	case code := <-exited:
+		assert.Equal(t, 130, code, "128 + SIGINT, as a shell reports it")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no teardown after the signal")
+	}
+	<-removed
+	assert.Contains(t, log.String(), "tearing down 1 resources")
+}
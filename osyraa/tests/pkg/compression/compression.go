@@ -0,0 +1,196 @@
+// Package compression checks that a server compresses what it should:
+// that a response asked for with Accept-Encoding comes back gzip or
+// Brotli encoded, says so in Content-Encoding and Vary, decodes to the
+// same bytes as the uncompressed response, and is compressed by at least
+// a minimum ratio, so a server that forgot gzip_types or compresses at
+// level 1 is caught.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Encodings
+const (
+	Gzip     = "gzip"
+	Brotli   = "br"
+	Identity = "identity"
+)
+
+// DefaultMinRatios are the least uncompressed-to-compressed size ratios
+// for the text types a static site serves. Minified HTML, CSS and
+// JavaScript compress three to five times with gzip at nginx's level 6.
+var DefaultMinRatios = map[string]float64{
+	"text/html":              2.5,
+	"text/css":               2.5,
+	"text/javascript":        2,
+	"application/javascript": 2,
+	"application/json":       2,
+	"image/svg+xml":          2,
+}
+
+// Options tune a check
+type Options struct {
+	// Accept is the request's Accept-Encoding, e.g. "gzip, br"
+	Accept string
+	// Want lists the encodings the response may use; empty accepts any
+	// encoding named in Accept
+	Want []string
+	// MinRatios map media types to the least ratio of uncompressed to
+	// compressed size; a type not listed isn't held to a ratio
+	MinRatios map[string]float64
+	// MinSize is the smallest body expected to be compressed, since
+	// servers skip small ones (nginx's gzip_min_length)
+	MinSize int
+}
+
+// Result is one response's compression
+type Result struct {
+	Path        string
+	ContentType string
+	// Encoding is the response's Content-Encoding, or identity
+	Encoding string
+	// Size and Compressed are the body's bytes without and with encoding
+	Size, Compressed int
+	Problems         []string
+}
+
+// Ratio is the uncompressed size over the compressed size
+func (r Result) Ratio() float64 {
+	if r.Compressed == 0 {
+		return 0
+	}
+	return float64(r.Size) / float64(r.Compressed)
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s (%s, %d bytes): %s", r.Path, r.ContentType, r.Size, strings.Join(r.Problems, "; "))
+}
+
+// Check fetches path from baseURL twice, once uncompressed and once with
+// opts.Accept, and returns what is wrong with the compressed response.
+// Go's transport leaves the body encoded, since the request sets
+// Accept-Encoding itself.
+func Check(ctx context.Context, client *http.Client, baseURL, path string, opts Options) (Result, error) {
+	result := Result{Path: path}
+	plain, _, err := fetch(ctx, client, baseURL+path, Identity)
+	if err != nil {
+		return result, err
+	}
+	body, resp, err := fetch(ctx, client, baseURL+path, opts.Accept)
+	if err != nil {
+		return result, err
+	}
+	result.Size, result.Compressed = len(plain), len(body)
+	result.ContentType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	result.Encoding = strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if result.Encoding == "" {
+		result.Encoding = Identity
+	}
+	minRatio, compressible := opts.MinRatios[result.ContentType]
+	problem := func(format string, args ...any) {
+		result.Problems = append(result.Problems, fmt.Sprintf(format, args...))
+	}
+
+	// nginx only adds Vary when it compresses, which it skips below
+	// gzip_min_length
+	if !varies(resp.Header) && ((compressible && len(plain) >= opts.MinSize) || result.Encoding != Identity) {
+		problem("Vary doesn't include Accept-Encoding, so caches may serve one encoding to every client")
+	}
+	if result.Encoding == Identity {
+		if compressible && len(plain) >= opts.MinSize {
+			problem("sent uncompressed for Accept-Encoding: %s", opts.Accept)
+		}
+		return result, nil
+	}
+
+	want := opts.Want
+	if len(want) == 0 {
+		want = offered(opts.Accept)
+	}
+	if !slices.Contains(want, result.Encoding) {
+		problem("Content-Encoding is %s for Accept-Encoding: %s, want %s", result.Encoding, opts.Accept, strings.Join(want, " or "))
+	}
+	decoded, err := Decode(result.Encoding, body)
+	switch {
+	case err != nil:
+		problem("doesn't decode as %s: %v", result.Encoding, err)
+	case !bytes.Equal(decoded, plain):
+		problem("decodes to %d bytes that differ from the %d bytes sent uncompressed", len(decoded), len(plain))
+	}
+	if compressible && result.Ratio() < minRatio {
+		problem("compressed %d to %d bytes, a ratio of %.2f, below the minimum of %.2f", result.Size, result.Compressed, result.Ratio(), minRatio)
+	}
+	return result, nil
+}
+
+func fetch(ctx context.Context, client *http.Client, url, accept string) ([]byte, *http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept-Encoding", accept)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("GET %s with Accept-Encoding %s returned %s", url, accept, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	return body, resp, err
+}
+
+// varies reports whether Vary names Accept-Encoding
+func varies(h http.Header) bool {
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if f := strings.TrimSpace(field); f == "*" || strings.EqualFold(f, "Accept-Encoding") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// offered lists the encodings an Accept-Encoding value names, without
+// their weights
+func offered(accept string) []string {
+	var encodings []string
+	for _, part := range strings.Split(accept, ",") {
+		name, _, _ := strings.Cut(part, ";")
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			encodings = append(encodings, name)
+		}
+	}
+	return encodings
+}
+
+// Decode undoes a Content-Encoding
+func Decode(encoding string, body []byte) ([]byte, error) {
+	switch encoding {
+	case Identity, "":
+		return body, nil
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case Brotli:
+		return io.ReadAll(brotli.NewReader(bytes.NewReader(body)))
+	}
+	return nil, fmt.Errorf("unsupported encoding %s", encoding)
+}
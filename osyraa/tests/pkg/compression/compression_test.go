@@ -0,0 +1,136 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var page = []byte("<!DOCTYPE html><html><body>" + strings.Repeat("<p>Platform engineer, Kubernetes, Terraform</p>", 200) + "</body></html>")
+
+// server serves page the way the handler's options say
+type server struct {
+	brotli    bool
+	noVary    bool
+	level     int
+	corrupt   bool
+	plainOnly bool
+}
+
+func (s server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body := page
+	if r.URL.Path == "/random.css" {
+		// Random bytes don't compress
+		body = make([]byte, 4096)
+		rand.New(rand.NewSource(1)).Read(body)
+		w.Header().Set("Content-Type", "text/css")
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if !s.noVary {
+		w.Header().Set("Vary", "Accept-Encoding")
+	}
+	accept := r.Header.Get("Accept-Encoding")
+	var buf bytes.Buffer
+	switch {
+	case s.plainOnly || accept == Identity:
+		w.Write(body)
+		return
+	case s.brotli && strings.Contains(accept, Brotli):
+		bw := brotli.NewWriter(&buf)
+		bw.Write(body)
+		bw.Close()
+		w.Header().Set("Content-Encoding", Brotli)
+	default:
+		level := s.level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		gw, _ := gzip.NewWriterLevel(&buf, level)
+		gw.Write(body)
+		gw.Close()
+		w.Header().Set("Content-Encoding", Gzip)
+	}
+	out := buf.Bytes()
+	if s.corrupt {
+		out = out[:len(out)/2]
+	}
+	w.Write(out)
+}
+
+func check(t *testing.T, s server, path string, opts Options) Result {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	if opts.MinRatios == nil {
+		opts.MinRatios = DefaultMinRatios
+	}
+	r, err := Check(context.Background(), srv.Client(), srv.URL, path, opts)
+	require.NoError(t, err)
+	return r
+}
+
+func TestCheckPasses(t *testing.T) {
+	r := check(t, server{}, "/", Options{Accept: "gzip, br"})
+	assert.Empty(t, r.Problems)
+	assert.Equal(t, Gzip, r.Encoding)
+	assert.Equal(t, "text/html", r.ContentType)
+	assert.Equal(t, len(page), r.Size)
+	assert.Greater(t, r.Ratio(), 10.0)
+
+	r = check(t, server{brotli: true}, "/", Options{Accept: "gzip, br", Want: []string{Brotli}})
+	assert.Empty(t, r.Problems)
+	assert.Equal(t, Brotli, r.Encoding)
+}
+
+func TestCheckProblems(t *testing.T) {
+	for name, tc := range map[string]struct {
+		server server
+		path   string
+		opts   Options
+		want   string
+	}{
+		"uncompressed":   {server{plainOnly: true}, "/", Options{Accept: "gzip"}, "sent uncompressed for Accept-Encoding: gzip"},
+		"no vary":        {server{noVary: true}, "/", Options{Accept: "gzip"}, "Vary doesn't include Accept-Encoding"},
+		"brotli missing": {server{}, "/", Options{Accept: "gzip, br", Want: []string{Brotli}}, "Content-Encoding is gzip for Accept-Encoding: gzip, br, want br"},
+		"not offered":    {server{}, "/", Options{Accept: "br"}, "Content-Encoding is gzip for Accept-Encoding: br, want br"},
+		"corrupt":        {server{corrupt: true}, "/", Options{Accept: "gzip"}, "doesn't decode as gzip"},
+		"poor ratio":     {server{}, "/random.css", Options{Accept: "gzip"}, "below the minimum of 2.50"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := check(t, tc.server, tc.path, tc.opts)
+			require.NotEmpty(t, r.Problems)
+			assert.Contains(t, strings.Join(r.Problems, "; "), tc.want)
+		})
+	}
+}
+
+func TestCheckSmallBodies(t *testing.T) {
+	r := check(t, server{plainOnly: true}, "/", Options{Accept: "gzip", MinSize: len(page) + 1})
+	assert.Empty(t, r.Problems, "bodies below MinSize may go uncompressed")
+	r = check(t, server{plainOnly: true}, "/", Options{Accept: "gzip", MinRatios: map[string]float64{"text/css": 2}})
+	assert.Empty(t, r.Problems, "types without a ratio may go uncompressed")
+	r = check(t, server{plainOnly: true, noVary: true}, "/", Options{Accept: "gzip", MinSize: len(page) + 1})
+	assert.Empty(t, r.Problems, "nginx sends no Vary for bodies it doesn't compress")
+	r = check(t, server{noVary: true}, "/", Options{Accept: "gzip", MinSize: len(page) + 1})
+	assert.Equal(t, []string{"Vary doesn't include Accept-Encoding, so caches may serve one encoding to every client"}, r.Problems,
+		"a compressed response needs Vary whatever its size")
+}
+
+func TestDecode(t *testing.T) {
+	_, err := Decode("compress", nil)
+	assert.ErrorContains(t, err, "unsupported encoding compress")
+	body, err := Decode(Identity, []byte("plain"))
+	require.NoError(t, err)
+	assert.Equal(t, "plain", string(body))
+	assert.Equal(t, []string{"gzip", "br", "deflate"}, offered("gzip;q=1.0, BR , deflate;q=0.5"))
+}
@@ -0,0 +1,184 @@
+// Package config reads osyraa.yaml: the settings file long-running modes
+// pick up without a restart, and the site-specific values the test suites
+// check against. Values in the file override the defaults of the matching
+// command-line flags; flags given explicitly override the file. For the
+// suites, environment variables override the file.
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is the file read when OSYRAA_CONFIG does not name another
+const DefaultPath = "osyraa.yaml"
+
+// Path is OSYRAA_CONFIG, or DefaultPath
+func Path() string {
+	if p := os.Getenv("OSYRAA_CONFIG"); p != "" {
+		return p
+	}
+	return DefaultPath
+}
+
+// File is the contents of osyraa.yaml
+type File struct {
+	Monitor Monitor `yaml:"monitor"`
+	Suite   Suite   `yaml:"suite"`
+}
+
+// Monitor configures monitor mode. Zero values leave the flag's value in
+// place.
+type Monitor struct {
+	// Interval is the time between rounds of checks
+	Interval time.Duration `yaml:"interval"`
+	// Checks names the checks to run
+	Checks []string `yaml:"checks"`
+	// Budgets bound what a passing round may cost
+	Budgets Budgets `yaml:"budgets"`
+	// EmailEvery is how often alert digests are mailed
+	EmailEvery time.Duration `yaml:"email-every"`
+}
+
+// Budgets are the limits checks hold the target to
+type Budgets struct {
+	// ResponseTime bounds the time to fetch the home page
+	ResponseTime time.Duration `yaml:"response-time"`
+	// Availability is the SLO objective, e.g. 0.999
+	Availability float64 `yaml:"availability"`
+}
+
+// Parse decodes data, rejecting unknown keys so a misspelt setting is an
+// error rather than silently ignored
+func Parse(data []byte) (*File, error) {
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Validate checks the values a decoder cannot
+func (f *File) Validate() error {
+	m := f.Monitor
+	switch {
+	case m.Interval < 0:
+		return fmt.Errorf("monitor.interval %v must be positive", m.Interval)
+	case m.EmailEvery < 0:
+		return fmt.Errorf("monitor.email-every %v must be positive", m.EmailEvery)
+	case m.Budgets.ResponseTime < 0:
+		return fmt.Errorf("monitor.budgets.response-time %v must be positive", m.Budgets.ResponseTime)
+	case m.Budgets.Availability < 0 || m.Budgets.Availability >= 1:
+		return fmt.Errorf("monitor.budgets.availability %v must be between 0 and 1", m.Budgets.Availability)
+	}
+	return f.Suite.validate()
+}
+
+// Load reads and parses the file at path. A missing file is an empty
+// configuration.
+func Load(path string) (*File, error) {
+	f, _, err := load(path)
+	return f, err
+}
+
+func load(path string) (*File, [sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &File{}, [sha256.Size]byte{}, nil
+	}
+	if err != nil {
+		return nil, [sha256.Size]byte{}, err
+	}
+	sum := sha256.Sum256(data)
+	f, err := Parse(data)
+	if err != nil {
+		return nil, sum, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, sum, nil
+}
+
+// Watcher polls a configuration file and hands each valid new version to
+// Apply. An invalid version is reported once through Logf and the running
+// configuration is kept until the file is fixed.
+type Watcher struct {
+	Path string
+	// Every is the polling interval, 2s by default. Polling rather than
+	// file system events survives editors that replace the file and
+	// mounted ConfigMaps that swap a symlink.
+	Every time.Duration
+	// Apply receives each new configuration; an error rejects it like a
+	// parse error
+	Apply func(*File) error
+	Logf  func(format string, args ...any)
+
+	seen [sha256.Size]byte
+}
+
+// Load reads the current configuration and remembers it, so Run only
+// reports later changes
+func (w *Watcher) Load() (*File, error) {
+	f, sum, err := load(w.Path)
+	w.seen = sum
+	return f, err
+}
+
+// Poll applies the file if its contents changed since the last Load or
+// Poll, and reports whether it did
+func (w *Watcher) Poll() (bool, error) {
+	f, sum, err := load(w.Path)
+	if sum == w.seen {
+		return false, nil
+	}
+	w.seen = sum
+	if err == nil {
+		err = w.Apply(f)
+	}
+	if err != nil {
+		return false, fmt.Errorf("keeping the running configuration: %w", err)
+	}
+	return true, nil
+}
+
+// Run polls until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) {
+	every := w.Every
+	if every <= 0 {
+		every = 2 * time.Second
+	}
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			applied, err := w.Poll()
+			switch {
+			case err != nil:
+				w.logf("%v", err)
+			case applied:
+				w.logf("reloaded %s", w.Path)
+			}
+		}
+	}
+}
+
+func (w *Watcher) logf(format string, args ...any) {
+	if w.Logf != nil {
+		w.Logf(format, args...)
+	}
+}
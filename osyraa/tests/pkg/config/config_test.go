@@ -0,0 +1,161 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(`
+monitor:
+  interval: 5m
+  checks: [availability, cert-expiry]
+  email-every: 6h
+  budgets:
+    response-time: 800ms
+    availability: 0.995
+`))
+	require.NoError(t, err)
+	assert.Equal(t, Monitor{
+		Interval:   5 * time.Minute,
+		Checks:     []string{"availability", "cert-expiry"},
+		EmailEvery: 6 * time.Hour,
+		Budgets:    Budgets{ResponseTime: 800 * time.Millisecond, Availability: 0.995},
+	}, f.Monitor)
+
+	f, err = Parse(nil)
+	require.NoError(t, err, "an empty file is an empty configuration")
+	assert.Equal(t, &File{}, f)
+
+	_, err = Parse([]byte("monitor:\n  intervall: 5m\n"))
+	assert.ErrorContains(t, err, "field intervall not found")
+	_, err = Parse([]byte("monitor:\n  interval: soon\n"))
+	assert.Error(t, err)
+	_, err = Parse([]byte("monitor:\n  budgets:\n    availability: 99.9\n"))
+	assert.ErrorContains(t, err, "between 0 and 1")
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv("OSYRAA_CONFIG", "")
+	assert.Equal(t, DefaultPath, Path())
+	t.Setenv("OSYRAA_CONFIG", "/etc/osyraa.yaml")
+	assert.Equal(t, "/etc/osyraa.yaml", Path())
+}
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "osyraa.yaml")
+	var applied []*File
+	w := &Watcher{Path: path, Apply: func(f *File) error {
+		if f.Monitor.Interval == time.Second {
+			return errors.New("too often")
+		}
+		applied = append(applied, f)
+		return nil
+	}}
+
+	f, err := w.Load()
+	require.NoError(t, err, "a missing file is an empty configuration")
+	assert.Equal(t, &File{}, f)
+	changed, err := w.Poll()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	write := func(s string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(path, []byte(s), 0o644))
+	}
+	write("monitor:\n  interval: 5m\n")
+	changed, err = w.Poll()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, _ = w.Poll()
+	assert.False(t, changed, "an unchanged file is applied once")
+
+	write("monitor: [\n")
+	_, err = w.Poll()
+	assert.ErrorContains(t, err, "keeping the running configuration")
+	_, err = w.Poll()
+	assert.NoError(t, err, "an invalid file is reported once")
+
+	write("monitor:\n  interval: 1s\n")
+	_, err = w.Poll()
+	assert.ErrorContains(t, err, "too often", "Apply can reject a configuration")
+
+	require.NoError(t, os.Remove(path))
+	changed, err = w.Poll()
+	require.NoError(t, err)
+	assert.True(t, changed, "removing the file reverts to the flags")
+
+	require.Len(t, applied, 2)
+	assert.Equal(t, 5*time.Minute, applied[0].Monitor.Interval)
+	assert.Equal(t, &File{}, applied[1])
+}
+
+func TestLoadSuite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "osyraa.yaml")
+	env := map[string]string{}
+	getenv := func(k string) string { return env[k] }
+
+	s, err := LoadSuite(path, getenv)
+	require.NoError(t, err, "a missing file leaves the defaults")
+	assert.Equal(t, DefaultSuite(), s)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+suite:
+  image: portfolio
+  port: 8080/tcp
+  author: Ada Lovelace
+  sections: [Experience, Skills]
+  timeouts:
+    ready: 1m
+`), 0o644))
+	env["OSYRAA_AUTHOR"] = "Grace Hopper"
+	env["OSYRAA_HTTP_TIMEOUT"] = "30s"
+	s, err = LoadSuite(path, getenv)
+	require.NoError(t, err)
+	assert.Equal(t, "portfolio", s.Image)
+	assert.Equal(t, "8080/tcp", s.Port)
+	assert.Equal(t, DefaultSuite().HugoImage, s.HugoImage, "unset keys keep the default")
+	assert.Equal(t, "Grace Hopper", s.Author, "the environment overrides the file")
+	assert.Equal(t, []string{"Experience", "Skills"}, s.Sections)
+	assert.Equal(t, time.Minute, s.Timeouts.Ready)
+	assert.Equal(t, 30*time.Second, s.Timeouts.HTTP)
+	assert.Equal(t, DefaultSuite().Timeouts.Build, s.Timeouts.Build)
+
+	env["OSYRAA_SECTIONS"] = " Summary, Projects ,"
+	s, err = LoadSuite(path, getenv)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Summary", "Projects"}, s.Sections)
+
+	env["OSYRAA_READY_TIMEOUT"] = "soon"
+	_, err = LoadSuite(path, getenv)
+	assert.ErrorContains(t, err, "OSYRAA_READY_TIMEOUT")
+	delete(env, "OSYRAA_READY_TIMEOUT")
+
+	env["OSYRAA_SITE_PORT"] = "8080"
+	_, err = LoadSuite(path, getenv)
+	assert.ErrorContains(t, err, "should name a protocol")
+
+	delete(env, "OSYRAA_SITE_PORT")
+
+	env["OSYRAA_HUGO_BACKEND"] = "release"
+	env["OSYRAA_HUGO_VERSION"] = "0.125.7"
+	s, err = LoadSuite(path, getenv)
+	require.NoError(t, err)
+	assert.Equal(t, Hugo{Backend: "release", Version: "0.125.7"}, s.Hugo)
+	env["OSYRAA_HUGO_CHECKSUM"] = "abc"
+	_, err = LoadSuite(path, getenv)
+	assert.ErrorContains(t, err, "SHA-256")
+	env["OSYRAA_HUGO_BACKEND"] = "brew"
+	_, err = LoadSuite(path, getenv)
+	assert.ErrorContains(t, err, "should be one of image, hugomods, local, release")
+
+	_, err = Parse([]byte("suite:\n  portt: 80/tcp\n"))
+	assert.ErrorContains(t, err, "field portt not found")
+}
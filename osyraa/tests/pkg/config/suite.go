@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var sha256Hex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// Suite holds the site-specific values of the go test suites, so they can
+// be pointed at another site, author, base image or port. Zero values keep
+// the defaults.
+type Suite struct {
+	// Image is the repository the run's image is tagged in, as
+	// <image>:test-<run ID>
+	Image string `yaml:"image"`
+	// Port is the container port the site is served on, e.g. 80/tcp
+	Port string `yaml:"port"`
+	// HugoImage is the container image that builds the site with the
+	// image backend
+	HugoImage string `yaml:"hugo-image"`
+	Hugo      Hugo   `yaml:"hugo"`
+	// Author is the name the home page's h1, the vCard and the PDF show
+	Author string `yaml:"author"`
+	// Sections are the resume's top-level headings, in order
+	Sections []string `yaml:"sections"`
+	Timeouts Timeouts `yaml:"timeouts"`
+}
+
+// Hugo chooses how the suites run Hugo
+type Hugo struct {
+	// Backend is image (HugoImage), hugomods (the hugomods image for
+	// Version), local (a hugo binary on this host) or release (Version
+	// downloaded from GitHub)
+	Backend string `yaml:"backend"`
+	// Version pins Hugo for the hugomods and release backends, and is the
+	// version a local binary must report
+	Version string `yaml:"version"`
+	// Binary is the local backend's hugo; empty looks it up on PATH
+	Binary string `yaml:"binary"`
+	// Checksum is the SHA-256 of the release archive for this host's
+	// platform; empty checks the release's published checksums
+	Checksum string `yaml:"checksum"`
+}
+
+// hugoBackends are the values Hugo.Backend takes
+var hugoBackends = []string{"image", "hugomods", "local", "release"}
+
+// Timeouts bound the suites' waits
+type Timeouts struct {
+	// Build limits building the site image
+	Build time.Duration `yaml:"build"`
+	// Ready limits the wait for a container to run and serve
+	Ready time.Duration `yaml:"ready"`
+	// HTTP limits each request to the site
+	HTTP time.Duration `yaml:"http"`
+	// Crawl limits crawling a served site
+	Crawl time.Duration `yaml:"crawl"`
+}
+
+// DefaultSuite is the resume site as the Containerfile builds it
+func DefaultSuite() Suite {
+	return Suite{
+		Image:     "resume",
+		Port:      "80/tcp",
+		HugoImage: "hugomods/hugo:exts-0.111.3",
+		Author:    "Princeton A. Strong",
+		Hugo:      Hugo{Backend: "hugomods", Version: "0.111.3"},
+		Sections:  []string{"Professional Summary", "Experience", "Education", "Certifications", "Skills", "Projects"},
+		Timeouts: Timeouts{
+			Build: 15 * time.Minute,
+			Ready: 30 * time.Second,
+			HTTP:  10 * time.Second,
+			Crawl: 2 * time.Minute,
+		},
+	}
+}
+
+// Merge returns s with the non-zero settings of over in place
+func (s Suite) Merge(over Suite) Suite {
+	str := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	dur := func(dst *time.Duration, v time.Duration) {
+		if v > 0 {
+			*dst = v
+		}
+	}
+	str(&s.Image, over.Image)
+	str(&s.Port, over.Port)
+	str(&s.HugoImage, over.HugoImage)
+	str(&s.Author, over.Author)
+	str(&s.Hugo.Backend, over.Hugo.Backend)
+	str(&s.Hugo.Version, over.Hugo.Version)
+	str(&s.Hugo.Binary, over.Hugo.Binary)
+	str(&s.Hugo.Checksum, over.Hugo.Checksum)
+	if len(over.Sections) > 0 {
+		s.Sections = over.Sections
+	}
+	dur(&s.Timeouts.Build, over.Timeouts.Build)
+	dur(&s.Timeouts.Ready, over.Timeouts.Ready)
+	dur(&s.Timeouts.HTTP, over.Timeouts.HTTP)
+	dur(&s.Timeouts.Crawl, over.Timeouts.Crawl)
+	return s
+}
+
+// SuiteFromEnv reads the suite settings set in the environment:
+// OSYRAA_IMAGE_REPO, OSYRAA_SITE_PORT, OSYRAA_HUGO_IMAGE, OSYRAA_AUTHOR,
+// OSYRAA_SECTIONS (comma-separated), OSYRAA_HUGO_BACKEND,
+// OSYRAA_HUGO_VERSION, OSYRAA_HUGO_BINARY, OSYRAA_HUGO_CHECKSUM and
+// OSYRAA_BUILD_TIMEOUT,
+// OSYRAA_READY_TIMEOUT, OSYRAA_HTTP_TIMEOUT and OSYRAA_CRAWL_TIMEOUT (Go
+// durations)
+func SuiteFromEnv(getenv func(string) string) (Suite, error) {
+	var s Suite
+	s.Image = getenv("OSYRAA_IMAGE_REPO")
+	s.Port = getenv("OSYRAA_SITE_PORT")
+	s.HugoImage = getenv("OSYRAA_HUGO_IMAGE")
+	s.Author = getenv("OSYRAA_AUTHOR")
+	s.Hugo = Hugo{
+		Backend:  getenv("OSYRAA_HUGO_BACKEND"),
+		Version:  getenv("OSYRAA_HUGO_VERSION"),
+		Binary:   getenv("OSYRAA_HUGO_BINARY"),
+		Checksum: getenv("OSYRAA_HUGO_CHECKSUM"),
+	}
+	for _, section := range strings.Split(getenv("OSYRAA_SECTIONS"), ",") {
+		if section = strings.TrimSpace(section); section != "" {
+			s.Sections = append(s.Sections, section)
+		}
+	}
+	for name, dst := range map[string]*time.Duration{
+		"OSYRAA_BUILD_TIMEOUT": &s.Timeouts.Build,
+		"OSYRAA_READY_TIMEOUT": &s.Timeouts.Ready,
+		"OSYRAA_HTTP_TIMEOUT":  &s.Timeouts.HTTP,
+		"OSYRAA_CRAWL_TIMEOUT": &s.Timeouts.Crawl,
+	} {
+		v := getenv(name)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return Suite{}, fmt.Errorf("%s=%q should be a positive duration such as 90s", name, v)
+		}
+		*dst = d
+	}
+	return s, nil
+}
+
+// LoadSuite resolves the suite settings: the defaults, then the suite
+// section of the file at path, then the environment
+func LoadSuite(path string, getenv func(string) string) (Suite, error) {
+	f, err := Load(path)
+	if err != nil {
+		return Suite{}, err
+	}
+	env, err := SuiteFromEnv(getenv)
+	if err != nil {
+		return Suite{}, err
+	}
+	s := DefaultSuite().Merge(f.Suite).Merge(env)
+	return s, s.validate()
+}
+
+func (s Suite) validate() error {
+	t := s.Timeouts
+	switch {
+	case t.Build < 0 || t.Ready < 0 || t.HTTP < 0 || t.Crawl < 0:
+		return errors.New("suite.timeouts must be positive")
+	case s.Port != "" && !strings.Contains(s.Port, "/"):
+		return fmt.Errorf("suite.port %q should name a protocol, e.g. 80/tcp", s.Port)
+	case s.Hugo.Backend != "" && !slices.Contains(hugoBackends, s.Hugo.Backend):
+		return fmt.Errorf("suite.hugo.backend %q should be one of %s", s.Hugo.Backend, strings.Join(hugoBackends, ", "))
+	case (s.Hugo.Backend == "hugomods" || s.Hugo.Backend == "release") && s.Hugo.Version == "":
+		return fmt.Errorf("suite.hugo.backend %s needs suite.hugo.version", s.Hugo.Backend)
+	case s.Hugo.Checksum != "" && !sha256Hex.MatchString(s.Hugo.Checksum):
+		return fmt.Errorf("suite.hugo.checksum should be a hex SHA-256")
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+// Package contact finds the author's email, phone and social profiles
+// wherever the site publishes them (page header and footer, JSON-LD,
+// vCard, the printed PDF) and checks every copy agrees. Values are
+// normalised first, so "(206) 666-5568" and "tel:+12066665568" are the
+// same number.
+package contact
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/vcard"
+	"golang.org/x/net/html"
+)
+
+// Fields compared besides the social profiles, which are named after
+// their site (see Profiles)
+const (
+	Email = "email"
+	Phone = "phone"
+)
+
+// Profiles maps social hosts to the field their handle is compared under
+var Profiles = map[string]string{
+	"github.com":   "github",
+	"gitlab.com":   "gitlab",
+	"linkedin.com": "linkedin",
+	"twitter.com":  "x",
+	"x.com":        "x",
+	"bsky.app":     "bluesky",
+}
+
+// Sighting is one place a contact detail appears
+type Sighting struct {
+	// Source names where it was found, e.g. "header" or "JSON-LD"
+	Source string
+	Field  string
+	// Value is the normalised form that is compared; Raw is as published
+	Value string
+	Raw   string
+}
+
+var (
+	emailRe   = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)
+	phoneRe   = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?\(?\b\d{3}\)?[\s.-]?\d{3}[\s.-]?\d{4}\b`)
+	profileRe = regexp.MustCompile(`(?i)\b(?:https?://)?(?:www\.)?(?:github\.com|gitlab\.com|linkedin\.com|twitter\.com|x\.com|bsky\.app)/[^\s"'<>)]+`)
+)
+
+// NormalizeEmail lower-cases an address and drops a mailto: scheme and
+// query
+func NormalizeEmail(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 7 && strings.EqualFold(s[:7], "mailto:") {
+		s = s[7:]
+	}
+	s, _, _ = strings.Cut(s, "?")
+	return strings.ToLower(s)
+}
+
+// NormalizePhone reduces a number to "+" and digits. Ten digits are taken
+// as a North American number without its country code.
+func NormalizePhone(s string) string {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "tel:")
+	var digits strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	if len(d) == 10 && !strings.HasPrefix(s, "+") {
+		d = "1" + d
+	}
+	return "+" + d
+}
+
+// NormalizeProfile returns the field and lower-cased handle of a social
+// profile URL, with or without a scheme; ok is false for other URLs
+func NormalizeProfile(s string) (field, handle string, ok bool) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", "", false
+	}
+	field, ok = Profiles[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")]
+	if !ok {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	// LinkedIn and Bluesky put the handle after /in/ or /profile/
+	if len(parts) > 1 && (parts[0] == "in" || parts[0] == "profile") {
+		parts = parts[1:]
+	}
+	handle = strings.TrimPrefix(strings.ToLower(parts[0]), "@")
+	return field, handle, handle != ""
+}
+
+// seen collects a source's sightings, keeping one of each value
+type seen struct {
+	source string
+	out    []Sighting
+}
+
+func (s *seen) add(field, value, raw string) {
+	for _, o := range s.out {
+		if o.Field == field && o.Value == value {
+			return
+		}
+	}
+	s.out = append(s.out, Sighting{Source: s.source, Field: field, Value: value, Raw: raw})
+}
+
+func (s *seen) url(raw string) {
+	switch {
+	case strings.HasPrefix(strings.ToLower(raw), "mailto:"):
+		s.add(Email, NormalizeEmail(raw), raw)
+	case strings.HasPrefix(raw, "tel:"):
+		s.add(Phone, NormalizePhone(raw), raw)
+	default:
+		if field, handle, ok := NormalizeProfile(raw); ok {
+			s.add(field, handle, raw)
+		}
+	}
+}
+
+func (s *seen) text(text string) {
+	for _, m := range emailRe.FindAllString(text, -1) {
+		s.add(Email, NormalizeEmail(m), m)
+	}
+	for _, m := range phoneRe.FindAllString(text, -1) {
+		s.add(Phone, NormalizePhone(m), m)
+	}
+	for _, m := range profileRe.FindAllString(text, -1) {
+		s.url(m)
+	}
+}
+
+// FromText finds contact details in plain text, such as the text of the
+// printed PDF
+func FromText(source, text string) []Sighting {
+	s := seen{source: source}
+	s.text(text)
+	return s.out
+}
+
+// FromHTML finds contact details under n: mailto:, tel: and profile links,
+// and addresses or numbers written out in the text
+func FromHTML(source string, n *html.Node) []Sighting {
+	s := seen{source: source}
+	for _, a := range match.FromNode(n).Select("a[href]").Nodes() {
+		href, _ := match.Attr(a, "href")
+		s.url(href)
+	}
+	s.text(match.Text(n))
+	return s.out
+}
+
+// FromJSONLD finds contact details in the page's JSON-LD blocks: email,
+// telephone, url and sameAs of any object, such as a schema.org Person
+func FromJSONLD(doc *match.Document) ([]Sighting, error) {
+	s := seen{source: "JSON-LD"}
+	for i, n := range doc.Select(`script[type="application/ld+json"]`).Nodes() {
+		if n.FirstChild == nil {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal([]byte(n.FirstChild.Data), &v); err != nil {
+			return nil, fmt.Errorf("JSON-LD block %d: %w", i+1, err)
+		}
+		walkJSON(v, &s)
+	}
+	return s.out, nil
+}
+
+func walkJSON(v any, s *seen) {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			walkJSON(e, s)
+		}
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			e := v[key]
+			switch key {
+			case "email":
+				for _, str := range stringValues(e) {
+					s.add(Email, NormalizeEmail(str), str)
+				}
+			case "telephone":
+				for _, str := range stringValues(e) {
+					s.add(Phone, NormalizePhone(str), str)
+				}
+			case "url", "sameAs":
+				for _, str := range stringValues(e) {
+					s.url(str)
+				}
+			default:
+				walkJSON(e, s)
+			}
+		}
+	}
+}
+
+// stringValues returns a JSON-LD value that may be a string or a list of them
+func stringValues(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var out []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// FromVCard finds contact details in a vCard's EMAIL, TEL, URL and
+// X-SOCIALPROFILE properties
+func FromVCard(card *vcard.Card) []Sighting {
+	s := seen{source: "vCard"}
+	for _, p := range card.Properties {
+		switch p.Name {
+		case "EMAIL":
+			s.add(Email, NormalizeEmail(p.Value), p.Value)
+		case "TEL":
+			s.add(Phone, NormalizePhone(p.Value), p.Value)
+		case "URL", "X-SOCIALPROFILE":
+			s.url(p.Value)
+		}
+	}
+	return s.out
+}
+
+// Compare reports each field whose normalised value differs between
+// sources, naming the sources on each side. A field missing from a
+// source is not a disagreement: the footer need not repeat the phone.
+func Compare(sightings []Sighting) []string {
+	type variant struct {
+		value, raw string
+		sources    []string
+	}
+	var fields []string
+	byField := map[string][]*variant{}
+	for _, s := range sightings {
+		vs, known := byField[s.Field]
+		if !known {
+			fields = append(fields, s.Field)
+		}
+		var v *variant
+		for _, e := range vs {
+			if e.value == s.Value {
+				v = e
+			}
+		}
+		if v == nil {
+			v = &variant{value: s.Value, raw: s.Raw}
+			byField[s.Field] = append(vs, v)
+		}
+		v.sources = append(v.sources, s.Source)
+	}
+
+	var problems []string
+	for _, f := range fields {
+		vs := byField[f]
+		if len(vs) < 2 {
+			continue
+		}
+		parts := make([]string, len(vs))
+		for i, v := range vs {
+			parts[i] = fmt.Sprintf("%q in %s", v.raw, strings.Join(v.sources, ", "))
+		}
+		problems = append(problems, fmt.Sprintf("%s differs: %s", f, strings.Join(parts, "; ")))
+	}
+	return problems
+}
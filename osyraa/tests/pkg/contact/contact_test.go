@@ -0,0 +1,108 @@
+package contact
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/vcard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "info@example.com", NormalizeEmail("mailto:Info@Example.com?subject=Hi"))
+	for _, s := range []string{"206-666-5568", "(206) 666-5568", "tel:+12066665568", "+1 206.666.5568"} {
+		assert.Equal(t, "+12066665568", NormalizePhone(s), s)
+	}
+	assert.Equal(t, "+442071234567", NormalizePhone("+44 20 7123 4567"))
+
+	for raw, want := range map[string][2]string{
+		"github.com/BornInTheDark":                   {"github", "borninthedark"},
+		"https://www.linkedin.com/in/jane-doe/":      {"linkedin", "jane-doe"},
+		"https://x.com/@jane":                        {"x", "jane"},
+		"https://bsky.app/profile/jane.bsky.social":  {"bluesky", "jane.bsky.social"},
+		"https://github.com/borninthedark?tab=repos": {"github", "borninthedark"},
+	} {
+		field, handle, ok := NormalizeProfile(raw)
+		assert.True(t, ok, raw)
+		assert.Equal(t, want, [2]string{field, handle}, raw)
+	}
+	_, _, ok := NormalizeProfile("https://example.com/jane")
+	assert.False(t, ok)
+	_, _, ok = NormalizeProfile("https://github.com/")
+	assert.False(t, ok, "a profile URL needs a handle")
+}
+
+const page = `<!DOCTYPE html><html><head>
+<script type="application/ld+json">{"@context":"https://schema.org","@type":"Person","name":"Jane",
+ "email":"mailto:jane@example.com","telephone":"+1-206-666-5569",
+ "sameAs":["https://github.com/jane","https://www.linkedin.com/in/jane"]}</script>
+</head><body>
+<header><a href="mailto:jane@example.com">jane@example.com</a> <span>206-666-5568</span>
+<a href="https://github.com/Jane">GitHub</a></header>
+<main><p>Managed 2020 - 2022 budgets of 1,000,000</p></main>
+<footer>Contact: JANE@example.com</footer>
+</body></html>`
+
+func TestSources(t *testing.T) {
+	doc, err := match.ParseBytes([]byte(page))
+	require.NoError(t, err)
+
+	header := FromHTML("header", doc.Select("header").Nodes()[0])
+	assert.Equal(t, []Sighting{
+		{"header", Email, "jane@example.com", "mailto:jane@example.com"},
+		{"header", "github", "jane", "https://github.com/Jane"},
+		{"header", Phone, "+12066665568", "206-666-5568"},
+	}, header)
+	assert.Empty(t, FromHTML("main", doc.Select("main").Nodes()[0]), "dates and figures are not phone numbers")
+
+	ld, err := FromJSONLD(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []Sighting{
+		{"JSON-LD", Email, "jane@example.com", "mailto:jane@example.com"},
+		{"JSON-LD", "github", "jane", "https://github.com/jane"},
+		{"JSON-LD", "linkedin", "jane", "https://www.linkedin.com/in/jane"},
+		{"JSON-LD", Phone, "+12066665569", "+1-206-666-5569"},
+	}, ld)
+
+	bad, err := match.ParseBytes([]byte(`<script type="application/ld+json">{"email":</script>`))
+	require.NoError(t, err)
+	_, err = FromJSONLD(bad)
+	assert.ErrorContains(t, err, "JSON-LD block 1")
+
+	card := strings.Join([]string{
+		"BEGIN:VCARD", "VERSION:4.0", "FN:Jane",
+		"EMAIL;TYPE=work:jane@exa", " mple.com",
+		"TEL;VALUE=uri:tel:+1-206-666-5568",
+		"item1.URL:https://github.com/jane", "END:VCARD", "",
+	}, "\r\n")
+	parsed, err := vcard.Parse([]byte(card))
+	require.NoError(t, err)
+	assert.Equal(t, []Sighting{
+		{"vCard", Email, "jane@example.com", "jane@example.com"},
+		{"vCard", Phone, "+12066665568", "tel:+1-206-666-5568"},
+		{"vCard", "github", "jane", "https://github.com/jane"},
+	}, FromVCard(parsed))
+
+	assert.Equal(t, []Sighting{
+		{"PDF", Email, "jane@example.com", "jane@example.com"},
+		{"PDF", Phone, "+12066665568", "(206) 666-5568"},
+		{"PDF", "github", "jane", "github.com/jane"},
+	}, FromText("PDF", "Jane Doe\njane@example.com (206) 666-5568 github.com/jane"))
+}
+
+func TestCompare(t *testing.T) {
+	doc, err := match.ParseBytes([]byte(page))
+	require.NoError(t, err)
+	all := FromHTML("header", doc.Select("header").Nodes()[0])
+	all = append(all, FromHTML("footer", doc.Select("footer").Nodes()[0])...)
+	ld, err := FromJSONLD(doc)
+	require.NoError(t, err)
+	all = append(all, ld...)
+
+	assert.Equal(t, []string{
+		`phone differs: "206-666-5568" in header; "+1-206-666-5569" in JSON-LD`,
+	}, Compare(all))
+	assert.Empty(t, Compare(all[:3]))
+}
@@ -0,0 +1,348 @@
+// Package contentlint checks Hugo content files without building the site:
+// front matter that Hugo would reject or misread, Markdown that renders
+// differently from how it reads, and internal links to pages the site
+// doesn't have. It is fast enough to run on every commit.
+package contentlint
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/schedule"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is one finding, located in a content file
+type Problem struct {
+	File string `json:"file"`
+	// Line is 1-based; 0 is the file as a whole
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", p.File, p.Rule, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, p.Rule, p.Message)
+}
+
+// frontMatter splits data into its YAML front matter and body, with the
+// number of lines before the body; ok is false without front matter
+func frontMatter(data []byte) (fm []byte, body []byte, offset int, ok bool) {
+	rest, found := bytes.CutPrefix(data, []byte("---\n"))
+	if !found {
+		return nil, data, 0, false
+	}
+	end := bytes.Index(rest, []byte("\n---"))
+	if end < 0 {
+		return rest, nil, 0, true
+	}
+	body = rest[end+len("\n---"):]
+	body, _ = bytes.CutPrefix(body, []byte("\n"))
+	return rest[:end], body, bytes.Count(data[:len(data)-len(body)], []byte("\n")), true
+}
+
+// dateKeys are the front matter dates Hugo parses
+var dateKeys = []string{"date", "publishDate", "expiryDate", "lastmod"}
+
+// FrontMatter checks a content file's front matter: that it is closed,
+// valid YAML, has a title, and that its dates, draft flag and expiry make
+// sense. file names the file in problems.
+func FrontMatter(file string, data []byte) []Problem {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	problem := func(rule, format string, args ...any) []Problem {
+		return []Problem{{File: file, Line: 1, Rule: rule, Message: fmt.Sprintf(format, args...)}}
+	}
+	if bytes.HasPrefix(data, []byte("+++")) || bytes.HasPrefix(data, []byte("{")) {
+		return problem("front-matter", "use YAML front matter; the harness reads only YAML")
+	}
+	raw, body, _, ok := frontMatter(data)
+	if !ok {
+		return problem("front-matter", "missing front matter")
+	}
+	if body == nil {
+		return problem("front-matter", "front matter is not closed with ---")
+	}
+	var fm map[string]any
+	if err := yaml.Unmarshal(raw, &fm); err != nil {
+		return problem("front-matter", "invalid YAML: %v", err)
+	}
+
+	var problems []Problem
+	add := func(rule, format string, args ...any) {
+		problems = append(problems, problem(rule, format, args...)...)
+	}
+	if title, _ := fm["title"].(string); strings.TrimSpace(title) == "" {
+		add("front-matter-title", "title is missing or empty")
+	}
+	if v, ok := fm["draft"]; ok {
+		if _, isBool := v.(bool); !isBool {
+			add("front-matter-draft", "draft is %v; use true or false, unquoted", v)
+		}
+	}
+	for _, key := range dateKeys {
+		if v, ok := fm[key]; ok && v == nil {
+			add("front-matter-date", "%s is empty", key)
+		}
+	}
+	page, err := schedule.Parse(file, data)
+	if err != nil {
+		add("front-matter-date", "%v", err)
+		return problems
+	}
+	if !page.ExpiryDate.IsZero() && !page.Published().IsZero() && !page.ExpiryDate.After(page.Published()) {
+		add("front-matter-date", "expiryDate %s is not after the publish date %s, so the page is never published",
+			page.ExpiryDate.Format(time.DateOnly), page.Published().Format(time.DateOnly))
+	}
+	return problems
+}
+
+var (
+	atxRe       = regexp.MustCompile(`^(#{1,6})(\s*)(.*)$`)
+	fenceRe     = regexp.MustCompile("^\\s*(```|~~~)")
+	emptyLinkRe = regexp.MustCompile(`\[[^\]]*\]\(\s*\)`)
+	reversedRe  = regexp.MustCompile(`\([^()\s]+\)\[[^\]]+\]`)
+)
+
+// Markdown lints a content file's body with rules that catch rendering
+// surprises: skipped heading levels, "##Heading" without a space,
+// trailing and tab whitespace, runs of blank lines, empty or reversed
+// links, an unclosed code fence and a missing final newline. Two trailing
+// spaces are a hard line break and are allowed.
+func Markdown(file string, data []byte) []Problem {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	_, body, offset, _ := frontMatter(data)
+	if body == nil {
+		// FrontMatter reports the unclosed block
+		return nil
+	}
+	var problems []Problem
+	add := func(line int, rule, format string, args ...any) {
+		problems = append(problems, Problem{File: file, Line: line, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	lines := strings.Split(string(body), "\n")
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		add(offset+len(lines), "md-final-newline", "file should end with a newline")
+	}
+	level, blanks, fenceLine := 0, 0, 0
+	for i, line := range lines {
+		n := offset + i + 1
+		if fenceRe.MatchString(line) {
+			if fenceLine == 0 {
+				fenceLine = n
+			} else {
+				fenceLine = 0
+			}
+			continue
+		}
+		if fenceLine != 0 {
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			blanks++
+			if blanks == 2 && i < len(lines)-1 {
+				add(n, "md-blank-lines", "more than one blank line in a row")
+			}
+		} else {
+			blanks = 0
+		}
+		if trimmed := strings.TrimRight(line, " \t"); trimmed != line && strings.TrimSpace(line) != "" {
+			if line[len(trimmed):] != "  " {
+				add(n, "md-trailing-space", "trailing whitespace; end with exactly two spaces for a line break")
+			}
+		}
+		if strings.HasPrefix(line, "\t") {
+			add(n, "md-hard-tab", "indent with spaces; tabs render differently between tools")
+		}
+
+		if m := atxRe.FindStringSubmatch(line); m != nil {
+			if m[2] == "" && m[3] != "" && !strings.HasPrefix(m[3], "#") {
+				add(n, "md-heading-space", "%q is not a heading without a space after the #", line)
+				continue
+			}
+			h := len(m[1])
+			if level > 0 && h > level+1 {
+				add(n, "md-heading-increment", "h%d follows h%d; don't skip heading levels", h, level)
+			}
+			level = h
+		}
+		if emptyLinkRe.MatchString(line) {
+			add(n, "md-empty-link", "link has no target")
+		}
+		if reversedRe.MatchString(line) {
+			add(n, "md-reversed-link", "(target)[text] is reversed; write [text](target)")
+		}
+	}
+	if fenceLine != 0 {
+		add(fenceLine, "md-unclosed-fence", "code fence is never closed")
+	}
+	return problems
+}
+
+// Index is the set of paths a build of the site serves
+type Index struct {
+	paths map[string]bool
+}
+
+// NewIndex indexes the pages of a content tree, as schedule.Load reads
+// them, and the files under static, which is nil without one. Other outputs,
+// such as the home page's alternative formats, are added with Add.
+func NewIndex(pages []schedule.Page, static fs.FS) (*Index, error) {
+	idx := &Index{paths: map[string]bool{}}
+	for _, p := range pages {
+		idx.Add(p.URL)
+	}
+	if static == nil {
+		return idx, nil
+	}
+	err := fs.WalkDir(static, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		idx.Add("/" + p)
+		return nil
+	})
+	return idx, err
+}
+
+// Add records a served path
+func (idx *Index) Add(p string) {
+	idx.paths[p] = true
+}
+
+// Has reports whether p is served, as a file or as a page's directory
+func (idx *Index) Has(p string) bool {
+	if idx.paths[p] {
+		return true
+	}
+	if !strings.HasSuffix(p, "/") && idx.paths[p+"/"] {
+		return true
+	}
+	return strings.HasSuffix(p, "/index.html") && idx.paths[strings.TrimSuffix(p, "index.html")]
+}
+
+var (
+	mdLinkRe   = regexp.MustCompile(`!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	htmlLinkRe = regexp.MustCompile(`(?i)\b(?:href|src)\s*=\s*"([^"]+)"`)
+	refRe      = regexp.MustCompile(`\{\{<\s*(?:rel)?ref\s+"([^"]+)"\s*>\}\}`)
+	anchorRe   = regexp.MustCompile(`[^\p{L}\p{N}_\- ]+`)
+)
+
+// Anchor is the id Hugo gives a heading by default: lowercased, spaces as
+// hyphens, punctuation dropped
+func Anchor(heading string) string {
+	return strings.ReplaceAll(anchorRe.ReplaceAllString(strings.ToLower(strings.TrimSpace(heading)), ""), " ", "-")
+}
+
+// Links checks the internal links in a content file: Markdown and HTML
+// links to absolute or relative paths must be served by idx, "#fragment"
+// links must name a heading of the page, and {{< ref >}} shortcodes must
+// name a content file. file is relative to the content directory.
+func Links(file string, data []byte, idx *Index) []Problem {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	_, body, offset, _ := frontMatter(data)
+	if body == nil {
+		return nil
+	}
+	page, _ := schedule.Parse(file, data)
+	self := page.URL
+
+	anchors := map[string]bool{}
+	inFence := false
+	for _, line := range strings.Split(string(body), "\n") {
+		if fenceRe.MatchString(line) {
+			inFence = !inFence
+		}
+		if m := atxRe.FindStringSubmatch(line); !inFence && m != nil && m[2] != "" {
+			anchors[Anchor(strings.TrimRight(m[3], " #"))] = true
+		}
+	}
+
+	var problems []Problem
+	inFence = false
+	for i, line := range strings.Split(string(body), "\n") {
+		n := offset + i + 1
+		if fenceRe.MatchString(line) {
+			inFence = !inFence
+		}
+		if inFence {
+			continue
+		}
+		var targets []string
+		for _, m := range mdLinkRe.FindAllStringSubmatch(line, -1) {
+			targets = append(targets, m[1])
+		}
+		for _, m := range htmlLinkRe.FindAllStringSubmatch(line, -1) {
+			targets = append(targets, m[1])
+		}
+		for _, target := range targets {
+			if msg := checkLink(target, self, anchors, idx); msg != "" {
+				problems = append(problems, Problem{File: file, Line: n, Rule: "link", Message: msg})
+			}
+		}
+		for _, m := range refRe.FindAllStringSubmatch(line, -1) {
+			ref, _, _ := strings.Cut(m[1], "#")
+			target := pageOf(path.Join(path.Dir(file), ref))
+			if strings.HasPrefix(ref, "/") {
+				target = pageOf(strings.TrimPrefix(ref, "/"))
+			}
+			if !idx.Has(target) {
+				problems = append(problems, Problem{File: file, Line: n, Rule: "link", Message: fmt.Sprintf("ref %q names no content file", m[1])})
+			}
+		}
+	}
+	return problems
+}
+
+// pageOf is the URL of the content file at rel, the way schedule reads it
+func pageOf(rel string) string {
+	if !strings.HasSuffix(rel, ".md") {
+		rel += ".md"
+	}
+	p, _ := schedule.Parse(rel, nil)
+	return p.URL
+}
+
+// checkLink explains what is wrong with one link target, or returns ""
+func checkLink(target, self string, anchors map[string]bool, idx *Index) string {
+	if strings.HasPrefix(target, "{{") || strings.HasPrefix(target, "//") {
+		return ""
+	}
+	if i := strings.Index(target, ":"); i > 0 && !strings.ContainsAny(target[:i], "/#?") {
+		// A scheme: mailto:, https:, tel: and so on are not internal
+		return ""
+	}
+	p, fragment, _ := strings.Cut(target, "#")
+	p, _, _ = strings.Cut(p, "?")
+	if p == "" {
+		if fragment != "" && !anchors[fragment] {
+			return fmt.Sprintf("#%s names no heading on this page", fragment)
+		}
+		return ""
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join(self, p)
+		if strings.HasSuffix(target, "/") {
+			p += "/"
+		}
+	}
+	if !idx.Has(p) {
+		return fmt.Sprintf("%s is not a page or file of the site", target)
+	}
+	return ""
+}
+
+// ContentFile reports whether rel, relative to the site root with forward
+// slashes, is a content file the checks apply to
+func ContentFile(rel string) bool {
+	return strings.HasPrefix(rel, "content/") && path.Ext(rel) == ".md"
+}
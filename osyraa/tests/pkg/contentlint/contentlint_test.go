@@ -0,0 +1,110 @@
+package contentlint
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rules lists the rule and line of each problem, for compact assertions
+func rules(problems []Problem) []string {
+	var out []string
+	for _, p := range problems {
+		out = append(out, p.String()[len(p.File)+1:])
+	}
+	return out
+}
+
+func TestFrontMatter(t *testing.T) {
+	for name, tc := range map[string]struct {
+		data string
+		want []string
+	}{
+		"valid":      {"---\ntitle: Resume\ndate: 2024-01-01\ndraft: false\n---\nbody\n", nil},
+		"missing":    {"# Resume\n", []string{"1: front-matter: missing front matter"}},
+		"toml":       {"+++\ntitle = 'x'\n+++\n", []string{"1: front-matter: use YAML front matter; the harness reads only YAML"}},
+		"unclosed":   {"---\ntitle: x\n", []string{"1: front-matter: front matter is not closed with ---"}},
+		"bad yaml":   {"---\ntitle: [x\n---\n", []string{"1: front-matter: invalid YAML: yaml: line 1: did not find expected ',' or ']'"}},
+		"no title":   {"---\ntitle: \" \"\n---\n", []string{"1: front-matter-title: title is missing or empty"}},
+		"draft text": {"---\ntitle: x\ndraft: \"false\"\n---\n", []string{"1: front-matter-draft: draft is false; use true or false, unquoted"}},
+		"empty date": {"---\ntitle: x\ndate:\n---\n", []string{"1: front-matter-date: date is empty"}},
+		"bad date":   {"---\ntitle: x\ndate: next week\n---\n", []string{`1: front-matter-date: date: unrecognized date "next week"`}},
+		"never published": {"---\ntitle: x\npublishDate: 2025-06-01\nexpiryDate: 2025-05-01\n---\n",
+			[]string{"1: front-matter-date: expiryDate 2025-05-01 is not after the publish date 2025-06-01, so the page is never published"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, rules(FrontMatter("post.md", []byte(tc.data))))
+		})
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	data := "---\ntitle: x\n---\n" + // lines 1-3
+		"\n" + // 4
+		"## Experience\n" + // 5
+		"#### Skipped\n" + // 6
+		"##No space\n" + // 7
+		"Hard break  \n" + // 8
+		"Trailing \n" + // 9
+		"\tTabbed\n" + // 10
+		"\n\n" + // 11-12
+		"[empty]() and (https://example.com)[reversed]\n" + // 13
+		"```\n" + // 14
+		"#not a heading in code \n" + // 15
+		"```\n" + // 16
+		"~~~\n" + // 17
+		"never closed" // 18
+	assert.Equal(t, []string{
+		"18: md-final-newline: file should end with a newline",
+		"6: md-heading-increment: h4 follows h2; don't skip heading levels",
+		`7: md-heading-space: "##No space" is not a heading without a space after the #`,
+		"9: md-trailing-space: trailing whitespace; end with exactly two spaces for a line break",
+		"10: md-hard-tab: indent with spaces; tabs render differently between tools",
+		"12: md-blank-lines: more than one blank line in a row",
+		"13: md-empty-link: link has no target",
+		"13: md-reversed-link: (target)[text] is reversed; write [text](target)",
+		"17: md-unclosed-fence: code fence is never closed",
+	}, rules(Markdown("_index.md", []byte(data))))
+
+	assert.Empty(t, Markdown("_index.md", []byte("---\ntitle: x\n---\n\n## A\n\n### B\n\n## C\n")))
+}
+
+func TestLinks(t *testing.T) {
+	var pages []schedule.Page
+	for _, f := range []string{"_index.md", "posts/_index.md", "posts/launch.md"} {
+		p, err := schedule.Parse(f, nil)
+		require.NoError(t, err)
+		pages = append(pages, p)
+	}
+	idx, err := NewIndex(pages, fstest.MapFS{"img/me.png": {}})
+	require.NoError(t, err)
+	idx.Add("/contact.vcf")
+
+	data := "---\ntitle: Launch\n---\n" +
+		"## Getting Started!\n" + // 4
+		"[home](/) [posts](/posts/) [card](/contact.vcf) [me](/img/me.png) ![me](../../img/me.png)\n" + // 5
+		"[mail](mailto:a@example.com) [ext](https://example.com/x) [top](#getting-started) [q](/posts/?page=2)\n" + // 6
+		"[gone](/old/) [typo](#getting-startd) <a href=\"/missing.pdf\">cv</a>\n" + // 7
+		"{{< ref \"_index.md\" >}} {{< ref \"/posts/nope.md\" >}}\n" + // 8
+		"```\n[in code](/nowhere/)\n```\n"
+	assert.Equal(t, []string{
+		"7: link: /old/ is not a page or file of the site",
+		"7: link: #getting-startd names no heading on this page",
+		"7: link: /missing.pdf is not a page or file of the site",
+		`8: link: ref "/posts/nope.md" names no content file`,
+	}, rules(Links("posts/launch.md", []byte(data), idx)))
+}
+
+func TestAnchor(t *testing.T) {
+	assert.Equal(t, "getting-started", Anchor("Getting Started!"))
+	assert.Equal(t, "cicd--devops", Anchor("CI/CD & DevOps"))
+}
+
+func TestContentFile(t *testing.T) {
+	assert.True(t, ContentFile("content/_index.md"))
+	assert.False(t, ContentFile("layouts/index.html"))
+	assert.False(t, ContentFile("content/img/me.png"))
+}
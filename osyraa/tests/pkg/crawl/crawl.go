@@ -0,0 +1,254 @@
+// Package crawl walks a generated Hugo site once and hands every page to a set
+// of visitors, so link checking, SEO and accessibility audits share a single
+// traversal instead of each re-reading public/.
+package crawl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Page is a single fetched resource handed to visitors
+type Page struct {
+	URL      *url.URL
+	Status   int
+	Header   http.Header
+	Body     []byte
+	Doc      *html.Node
+	Links    []Link
+	Duration time.Duration
+	Depth    int
+	Referrer string
+}
+
+// IsHTML reports whether the page was served as an HTML document
+func (p *Page) IsHTML() bool {
+	return strings.HasPrefix(p.Header.Get("Content-Type"), "text/html")
+}
+
+// Link is a reference found in an HTML document
+type Link struct {
+	Tag  string
+	Attr string
+	Raw  string
+	URL  *url.URL
+}
+
+// Visitor is called once for every page reached by the crawler
+type Visitor interface {
+	Visit(ctx context.Context, page *Page) error
+}
+
+// VisitorFunc adapts a plain function to the Visitor interface
+type VisitorFunc func(ctx context.Context, page *Page) error
+
+// Visit calls f(ctx, page)
+func (f VisitorFunc) Visit(ctx context.Context, page *Page) error {
+	return f(ctx, page)
+}
+
+// Fetcher retrieves a single URL from the site under test
+type Fetcher interface {
+	Fetch(ctx context.Context, u *url.URL) (*Page, error)
+}
+
+// Result summarises a completed crawl
+type Result struct {
+	Pages   []*Page
+	Missing []*Page
+	// Skipped lists the same-host URLs the crawl's Scope left out
+	Skipped []string
+	Elapsed time.Duration
+}
+
+// Crawler performs a breadth-first traversal of a single site
+type Crawler struct {
+	// Scope bounds the traversal; the zero Scope visits everything
+	Scope Scope
+
+	fetcher  Fetcher
+	visitors []Visitor
+}
+
+// New creates a crawler reading pages through f and notifying visitors
+func New(f Fetcher, visitors ...Visitor) *Crawler {
+	return &Crawler{fetcher: f, visitors: visitors}
+}
+
+// Run crawls every same-host resource reachable from start within the
+// crawler's Scope. Visitor errors are collected and returned together so one
+// failing check doesn't hide the rest.
+func (c *Crawler) Run(ctx context.Context, start *url.URL) (*Result, error) {
+	began := time.Now()
+	result := &Result{}
+
+	type item struct {
+		u        *url.URL
+		depth    int
+		referrer string
+		page     bool
+	}
+
+	seen := map[string]bool{normalize(start): true}
+	queue := []item{{u: start, page: true}}
+	var errs []error
+	pages := 0
+
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		next := queue[0]
+		queue = queue[1:]
+		if next.page {
+			if c.Scope.MaxPages > 0 && pages >= c.Scope.MaxPages {
+				result.Skipped = append(result.Skipped, normalize(next.u))
+				continue
+			}
+			pages++
+		}
+
+		page, err := c.fetcher.Fetch(ctx, next.u)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fetch %s: %w", next.u, err))
+			continue
+		}
+		page.Depth = next.depth
+		page.Referrer = next.referrer
+
+		if page.IsHTML() {
+			doc, err := html.Parse(bytes.NewReader(page.Body))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("parse %s: %w", page.URL, err))
+			} else {
+				page.Doc = doc
+				page.Links = Links(doc, page.URL)
+			}
+		}
+
+		result.Pages = append(result.Pages, page)
+		if page.Status == http.StatusNotFound {
+			result.Missing = append(result.Missing, page)
+		}
+
+		for _, v := range c.visitors {
+			if err := v.Visit(ctx, page); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", page.URL.Path, err))
+			}
+		}
+
+		for _, link := range page.Links {
+			if link.URL == nil || !sameHost(start, link.URL) {
+				continue
+			}
+			key := normalize(link.URL)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			pageLink := isPage(link)
+			if pageLink && c.Scope.MaxDepth > 0 && next.depth >= c.Scope.MaxDepth ||
+				!pageLink && c.Scope.SkipAssets || !c.Scope.allows(link.URL, pageLink) {
+				result.Skipped = append(result.Skipped, key)
+				continue
+			}
+			queue = append(queue, item{u: link.URL, depth: next.depth + 1, referrer: page.URL.String(), page: pageLink})
+		}
+	}
+
+	result.Elapsed = time.Since(began)
+	return result, errors.Join(errs...)
+}
+
+// linkAttrs lists the element attributes that reference other resources
+var linkAttrs = map[string][]string{
+	"a":      {"href"},
+	"link":   {"href"},
+	"img":    {"src", "srcset"},
+	"script": {"src"},
+	"source": {"src", "srcset"},
+	"iframe": {"src"},
+	"video":  {"src", "poster"},
+	"audio":  {"src"},
+}
+
+// Links extracts every resource reference from doc, resolved against base.
+// Unparseable references are returned with a nil URL so checkers can flag them.
+func Links(doc *html.Node, base *url.URL) []Link {
+	var links []Link
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if attrs, ok := linkAttrs[n.Data]; ok {
+				for _, a := range n.Attr {
+					if !contains(attrs, a.Key) {
+						continue
+					}
+					for _, raw := range splitRefs(a.Key, a.Val) {
+						link := Link{Tag: n.Data, Attr: a.Key, Raw: raw}
+						if ref, err := url.Parse(raw); err == nil && base != nil {
+							link.URL = base.ResolveReference(ref)
+						}
+						links = append(links, link)
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return links
+}
+
+// splitRefs returns the individual URLs in an attribute value, expanding srcset
+func splitRefs(attr, val string) []string {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return nil
+	}
+	if attr != "srcset" {
+		return []string{val}
+	}
+	var refs []string
+	for _, candidate := range strings.Split(val, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 {
+			refs = append(refs, fields[0])
+		}
+	}
+	return refs
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sameHost(a, b *url.URL) bool {
+	return a.Scheme == b.Scheme && a.Host == b.Host
+}
+
+// normalize drops fragments so in-page anchors don't cause refetches
+func normalize(u *url.URL) string {
+	c := *u
+	c.Fragment = ""
+	c.RawFragment = ""
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	return c.String()
+}
@@ -0,0 +1,200 @@
+package crawl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSite(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, body := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(body), 0o644))
+	}
+	return root
+}
+
+var site = map[string]string{
+	"index.html": `<!DOCTYPE html><html><head><link rel="stylesheet" href="/css/site.css"></head>
+<body><a href="/about/">About</a><a href="#top">Top</a><a href="https://example.com/">Ext</a>
+<img src="/img/missing.png" srcset="/img/a.png 1x, /img/b.png 2x"></body></html>`,
+	"about/index.html": `<!DOCTYPE html><html><body><a href="/">Home</a></body></html>`,
+	"css/site.css":     `body{}`,
+	"img/a.png":        "png",
+	"img/b.png":        "png",
+}
+
+func TestDirCrawlVisitsEveryPageOnce(t *testing.T) {
+	root := writeSite(t, site)
+
+	var visits int32
+	res, err := Dir(context.Background(), root, VisitorFunc(func(_ context.Context, _ *Page) error {
+		atomic.AddInt32(&visits, 1)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	assert.Len(t, res.Pages, 6)
+	assert.EqualValues(t, 6, visits)
+	assert.Len(t, res.HTML(), 2)
+	require.Len(t, res.Missing, 1)
+	assert.Equal(t, "/img/missing.png", res.Missing[0].URL.Path)
+
+	about := res.Page("/about/")
+	require.NotNil(t, about)
+	assert.Equal(t, 1, about.Depth)
+	assert.Equal(t, "http://localhost/", about.Referrer)
+}
+
+func TestVisitorErrorsAreCollected(t *testing.T) {
+	root := writeSite(t, site)
+
+	res, err := Dir(context.Background(), root, VisitorFunc(func(_ context.Context, p *Page) error {
+		if p.IsHTML() {
+			return assert.AnError
+		}
+		return nil
+	}))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Len(t, res.Pages, 6, "errors should not stop the traversal")
+}
+
+func TestLinksResolveAgainstBase(t *testing.T) {
+	root := writeSite(t, site)
+	page, err := NewDirFetcher(root).Fetch(context.Background(), &url.URL{Scheme: "https", Host: "example.org", Path: "/"})
+	require.NoError(t, err)
+
+	res, err := New(staticFetcher{page}).Run(context.Background(), page.URL)
+	require.NoError(t, err)
+
+	var raws []string
+	for _, l := range res.Pages[0].Links {
+		raws = append(raws, l.URL.String())
+	}
+	assert.Contains(t, raws, "https://example.org/css/site.css")
+	assert.Contains(t, raws, "https://example.com/")
+	assert.Contains(t, raws, "https://example.org/img/b.png")
+}
+
+func TestHTTPFetcherRecordsHeaders(t *testing.T) {
+	root := writeSite(t, site)
+	srv := httptest.NewServer(http.FileServer(http.Dir(root)))
+	defer srv.Close()
+
+	start, err := url.Parse(srv.URL + "/")
+	require.NoError(t, err)
+
+	res, err := New(NewHTTPFetcher(srv.Client())).Run(context.Background(), start)
+	require.NoError(t, err)
+
+	home := res.Page("/")
+	require.NotNil(t, home)
+	assert.Equal(t, http.StatusOK, home.Status)
+	assert.True(t, home.IsHTML())
+	assert.NotNil(t, home.Doc)
+	assert.NotNil(t, res.Page("/css/site.css"))
+}
+
+func TestResultPage(t *testing.T) {
+	page := func(p string) *Page { return &Page{URL: &url.URL{Path: p}} }
+	about, foo := page("/about/index.html"), page("/fooindex.html")
+	res := &Result{Pages: []*Page{about, foo}}
+	assert.Same(t, about, res.Page("/about/"), "A directory index is found by its directory")
+	assert.Same(t, about, res.Page("/about/index.html"))
+	assert.Same(t, foo, res.Page("/fooindex.html"))
+	assert.Nil(t, res.Page("/foo"), "Only a whole index.html is a directory index")
+}
+
+func TestVisitorPath(t *testing.T) {
+	assert.Equal(t, "", VisitorPath("index.html"))
+	assert.Equal(t, "blog/", VisitorPath("blog/index.html"))
+	assert.Equal(t, "/blog/", VisitorPath("/blog/index.html"))
+	assert.Equal(t, "blog/myindex.html", VisitorPath("blog/myindex.html"), "only a whole index.html is a directory index")
+	assert.Equal(t, "css/site.css", VisitorPath("css/site.css"))
+}
+
+// staticFetcher returns a single page and 404s everything else
+type staticFetcher struct{ page *Page }
+
+func (s staticFetcher) Fetch(_ context.Context, u *url.URL) (*Page, error) {
+	if u.String() == s.page.URL.String() {
+		return s.page, nil
+	}
+	return &Page{URL: u, Status: http.StatusNotFound, Header: http.Header{}}, nil
+}
+
+func TestScope(t *testing.T) {
+	blog := map[string]string{
+		"index.html": `<html><head><link rel="stylesheet" href="/css/site.css"></head>
+<body><a href="/posts/">Posts</a><a href="/tags/">Tags</a></body></html>`,
+		"posts/index.html":          `<html><body><a href="/posts/one/">1</a><a href="/posts/two/">2</a><a href="/posts/three/">3</a></body></html>`,
+		"posts/one/index.html":      `<html><body><img src="/img/one.png"><a href="/posts/one/deep/">Deeper</a></body></html>`,
+		"posts/one/deep/index.html": `<html><body></body></html>`,
+		"posts/two/index.html":      `<html><body></body></html>`,
+		"posts/three/index.html":    `<html><body></body></html>`,
+		"tags/index.html":           `<html><body><a href="/tags/go/">go</a></body></html>`,
+		"tags/go/index.html":        `<html><body></body></html>`,
+		"css/site.css":              `body{}`,
+		"img/one.png":               "png",
+	}
+	root := writeSite(t, blog)
+	paths := func(res *Result) []string {
+		var got []string
+		for _, p := range res.Pages {
+			got = append(got, p.URL.Path)
+		}
+		return got
+	}
+
+	res, err := DirScope(context.Background(), root, Scope{MaxDepth: 2})
+	require.NoError(t, err)
+	assert.NotContains(t, paths(res), "/posts/one/deep/")
+	assert.Contains(t, paths(res), "/img/one.png", "assets of a page within the depth are fetched")
+	assert.Equal(t, []string{"http://localhost/posts/one/deep/"}, res.Skipped)
+
+	exclude, err := ParsePatterns("/tags/, /posts/t*/")
+	require.NoError(t, err)
+	res, err = DirScope(context.Background(), root, Scope{Exclude: exclude, SkipAssets: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/posts/", "/posts/one/", "/posts/one/deep/"}, paths(res))
+
+	include, err := ParsePatterns("/posts/**")
+	require.NoError(t, err)
+	res, err = DirScope(context.Background(), root, Scope{Include: include, MaxPages: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/css/site.css", "/posts/", "/posts/one/", "/img/one.png"}, paths(res),
+		"the start and assets are always crawled, and assets don't count towards MaxPages")
+	assert.Contains(t, res.Skipped, "http://localhost/tags/")
+	assert.Contains(t, res.Skipped, "http://localhost/posts/three/")
+}
+
+func TestPattern(t *testing.T) {
+	for glob, cases := range map[string]map[string]bool{
+		"/tags/":       {"/tags/": true, "/tags/go/": true, "/tags": false, "/tagsx/": false},
+		"/posts/*/":    {"/posts/a/": true, "/posts/a/b/": true, "/posts/": false},
+		"/posts/*":     {"/posts/a": true, "/posts/a/b": false},
+		"/**/*.pdf":    {"/cv.pdf": false, "/files/cv.pdf": true, "/a/b/cv.pdf": true},
+		"/page/?/":     {"/page/2/": true, "/page/10/": false},
+		"/resume.json": {"/resume.json": true, "/resumexjson": false},
+	} {
+		p, err := CompilePattern(glob)
+		require.NoError(t, err)
+		for path, want := range cases {
+			assert.Equal(t, want, p.Match(path), "%s matching %s", glob, path)
+		}
+	}
+	_, err := CompilePattern("tags/")
+	assert.ErrorContains(t, err, "must start with /")
+}
@@ -0,0 +1,153 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DirFetcher serves pages straight from a build output directory such as
+// public/. The host of the requested URL is ignored, so a crawl can start at
+// the site's production baseURL and still read local files.
+type DirFetcher struct {
+	Root string
+}
+
+// NewDirFetcher creates a fetcher rooted at dir
+func NewDirFetcher(dir string) *DirFetcher {
+	return &DirFetcher{Root: dir}
+}
+
+// Fetch resolves u to a file below Root, mirroring nginx index handling
+func (f *DirFetcher) Fetch(ctx context.Context, u *url.URL) (*Page, error) {
+	start := time.Now()
+	page := &Page{URL: u, Header: http.Header{}}
+
+	clean := path.Clean("/" + u.Path)
+	file := filepath.Join(f.Root, filepath.FromSlash(clean))
+	if info, err := os.Stat(file); err == nil && info.IsDir() {
+		file = filepath.Join(file, "index.html")
+	}
+
+	body, err := os.ReadFile(file)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		page.Status = http.StatusNotFound
+	case err != nil:
+		return nil, err
+	default:
+		page.Status = http.StatusOK
+		page.Body = body
+		page.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		if ct := mime.TypeByExtension(filepath.Ext(file)); ct != "" {
+			page.Header.Set("Content-Type", ct)
+		} else {
+			page.Header.Set("Content-Type", http.DetectContentType(body))
+		}
+	}
+
+	page.Duration = time.Since(start)
+	return page, nil
+}
+
+// HTTPFetcher retrieves pages from a running server
+type HTTPFetcher struct {
+	Client *http.Client
+	// MaxBody caps how much of each response is kept; zero means 10 MiB
+	MaxBody int64
+}
+
+// NewHTTPFetcher creates a fetcher using client, or a default client when nil
+func NewHTTPFetcher(client *http.Client) *HTTPFetcher {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &HTTPFetcher{Client: client}
+}
+
+// Fetch issues a GET for u and records status, headers, body and timing
+func (f *HTTPFetcher) Fetch(ctx context.Context, u *url.URL) (*Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	limit := f.MaxBody
+	if limit <= 0 {
+		limit = 10 << 20
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Page{
+		URL:      resp.Request.URL,
+		Status:   resp.StatusCode,
+		Header:   resp.Header,
+		Body:     body,
+		Duration: time.Since(start),
+	}, nil
+}
+
+// Dir crawls a build output directory starting at its root index page
+func Dir(ctx context.Context, root string, visitors ...Visitor) (*Result, error) {
+	return DirScope(ctx, root, Scope{}, visitors...)
+}
+
+// DirScope crawls a build output directory within scope
+func DirScope(ctx context.Context, root string, scope Scope, visitors ...Visitor) (*Result, error) {
+	start := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	c := New(NewDirFetcher(root), visitors...)
+	c.Scope = scope
+	return c.Run(ctx, start)
+}
+
+// HTML returns only the pages that parsed as HTML documents
+func (r *Result) HTML() []*Page {
+	var pages []*Page
+	for _, p := range r.Pages {
+		if p.Doc != nil {
+			pages = append(pages, p)
+		}
+	}
+	return pages
+}
+
+// Page looks up a crawled page by URL path; a directory index is also
+// found by its directory
+func (r *Result) Page(urlPath string) *Page {
+	for _, p := range r.Pages {
+		if p.URL.Path == urlPath || VisitorPath(p.URL.Path) == urlPath {
+			return p
+		}
+	}
+	return nil
+}
+
+// VisitorPath is the URL path visitors use for a built file or page:
+// directory indexes are requested by directory, and caches key them that
+// way. Only a whole index.html segment is a directory index.
+func VisitorPath(p string) string {
+	if path.Base(p) != "index.html" {
+		return p
+	}
+	return strings.TrimSuffix(p, "index.html")
+}
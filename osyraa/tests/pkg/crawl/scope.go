@@ -0,0 +1,121 @@
+package crawl
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Scope bounds a crawl, so checks stay fast on a site with hundreds of
+// pages. The zero Scope crawls every same-host resource.
+type Scope struct {
+	// MaxDepth stops following page links more than this many links from
+	// the start; zero means no limit. The assets of a page within the
+	// depth are still fetched.
+	MaxDepth int
+	// MaxPages stops fetching pages, reached through <a> or <iframe>,
+	// after this many; zero means no limit. Assets don't count.
+	MaxPages int
+	// Include, when not empty, limits the pages crawled to paths matching
+	// one of its patterns. The start and the assets of included pages are
+	// always crawled.
+	Include []Pattern
+	// Exclude skips pages and assets whose paths match any of its patterns
+	Exclude []Pattern
+	// SkipAssets fetches only pages, leaving out stylesheets, scripts,
+	// images and media
+	SkipAssets bool
+}
+
+// allows reports whether u is within the include and exclude patterns
+func (s Scope) allows(u *url.URL, page bool) bool {
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+	for _, pat := range s.Exclude {
+		if pat.Match(p) {
+			return false
+		}
+	}
+	if len(s.Include) == 0 || !page {
+		return true
+	}
+	for _, pat := range s.Include {
+		if pat.Match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPage reports whether a link leads to a document rather than a
+// resource of the page it is on
+func isPage(l Link) bool {
+	return l.Tag == "a" || l.Tag == "iframe"
+}
+
+// Pattern matches URL paths with a glob: * matches within a path
+// segment, ** across segments and ? one character. A pattern ending in
+// a slash also matches everything below it, so /tags/ covers /tags/go/.
+type Pattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+// CompilePattern parses a glob
+func CompilePattern(glob string) (Pattern, error) {
+	if !strings.HasPrefix(glob, "/") {
+		return Pattern{}, fmt.Errorf("pattern %q must start with /", glob)
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if strings.HasSuffix(glob, "/") {
+		b.WriteString(".*")
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return Pattern{}, fmt.Errorf("pattern %q: %w", glob, err)
+	}
+	return Pattern{glob: glob, re: re}, nil
+}
+
+// ParsePatterns compiles a comma-separated list of globs
+func ParsePatterns(list string) ([]Pattern, error) {
+	var patterns []Pattern
+	for _, glob := range strings.Split(list, ",") {
+		if glob = strings.TrimSpace(glob); glob == "" {
+			continue
+		}
+		p, err := CompilePattern(glob)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// Match reports whether path matches the pattern
+func (p Pattern) Match(path string) bool {
+	return p.re != nil && p.re.MatchString(path)
+}
+
+func (p Pattern) String() string {
+	return p.glob
+}
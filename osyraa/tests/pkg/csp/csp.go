@@ -0,0 +1,82 @@
+// Package csp parses Content-Security-Policy values into their directives
+// and sources, so checks can reason about what a policy allows instead of
+// matching substrings of the header. It cross-references a policy with
+// the pages served under it: what each page loads and runs, whether the
+// policy would block any of it, and which directives nothing uses.
+package csp
+
+import (
+	"slices"
+	"strings"
+)
+
+// Directive is one directive of a policy, e.g. script-src 'self'
+type Directive struct {
+	// Name is lower-cased; directive names are case-insensitive
+	Name    string
+	Sources []string
+}
+
+// Has reports whether the directive lists source. Keywords such as
+// 'self' and schemes compare case-insensitively, as browsers do.
+func (d Directive) Has(source string) bool {
+	return slices.ContainsFunc(d.Sources, func(s string) bool { return strings.EqualFold(s, source) })
+}
+
+func (d Directive) String() string {
+	return strings.Join(append([]string{d.Name}, d.Sources...), " ")
+}
+
+// Policy is a parsed policy, its directives in the order they appear
+type Policy []Directive
+
+// Parse reads a policy. Empty directives are skipped and a repeated
+// directive is dropped, since browsers only honour its first occurrence.
+func Parse(value string) Policy {
+	var p Policy
+	for _, part := range strings.Split(value, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, ok := p.Directive(name); ok {
+			continue
+		}
+		p = append(p, Directive{Name: name, Sources: fields[1:]})
+	}
+	return p
+}
+
+// ParseHeader reads every policy in a header's values. Each value, and
+// each comma-separated policy within one, is a policy of its own, and a
+// browser enforces all of them.
+func ParseHeader(values []string) []Policy {
+	var policies []Policy
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if p := Parse(part); len(p) > 0 {
+				policies = append(policies, p)
+			}
+		}
+	}
+	return policies
+}
+
+// Directive returns the named directive
+func (p Policy) Directive(name string) (Directive, bool) {
+	for _, d := range p {
+		if d.Name == strings.ToLower(name) {
+			return d, true
+		}
+	}
+	return Directive{}, false
+}
+
+func (p Policy) String() string {
+	parts := make([]string, len(p))
+	for i, d := range p {
+		parts[i] = d.String()
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,34 @@
+package csp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	p := Parse("default-src 'self';  Script-Src 'self' https://cdn.example.com ;; upgrade-insecure-requests; script-src *")
+	assert.Equal(t, Policy{
+		{Name: "default-src", Sources: []string{"'self'"}},
+		{Name: "script-src", Sources: []string{"'self'", "https://cdn.example.com"}},
+		{Name: "upgrade-insecure-requests", Sources: []string{}},
+	}, p, "names are lower-cased and a repeated directive is dropped")
+	assert.Equal(t, "default-src 'self'; script-src 'self' https://cdn.example.com; upgrade-insecure-requests", p.String())
+
+	d, ok := p.Directive("SCRIPT-SRC")
+	assert.True(t, ok)
+	assert.True(t, d.Has("'SELF'"))
+	assert.False(t, d.Has("*"))
+	_, ok = p.Directive("img-src")
+	assert.False(t, ok)
+	assert.Empty(t, Parse(" ; "))
+}
+
+func TestParseHeader(t *testing.T) {
+	policies := ParseHeader([]string{"frame-ancestors 'self'", "script-src 'self', img-src data:", ""})
+	assert.Equal(t, []Policy{
+		{{Name: "frame-ancestors", Sources: []string{"'self'"}}},
+		{{Name: "script-src", Sources: []string{"'self'"}}},
+		{{Name: "img-src", Sources: []string{"data:"}}},
+	}, policies)
+}
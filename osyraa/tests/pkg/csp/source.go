@@ -0,0 +1,186 @@
+package csp
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// fallbacks lists, for each directive a browser checks, the directives it
+// falls back to when the policy doesn't have it, nearest first
+var fallbacks = map[string][]string{
+	"script-src-elem": {"script-src", "default-src"},
+	"script-src-attr": {"script-src", "default-src"},
+	"style-src-elem":  {"style-src", "default-src"},
+	"style-src-attr":  {"style-src", "default-src"},
+	"script-src":      {"default-src"},
+	"style-src":       {"default-src"},
+	"img-src":         {"default-src"},
+	"media-src":       {"default-src"},
+	"font-src":        {"default-src"},
+	"object-src":      {"default-src"},
+	"manifest-src":    {"default-src"},
+	"connect-src":     {"default-src"},
+	"frame-src":       {"child-src", "default-src"},
+	"worker-src":      {"child-src", "script-src", "default-src"},
+	"child-src":       {"default-src"},
+}
+
+// Effective returns the directive that governs name: name itself, or the
+// one it falls back to, e.g. default-src for img-src. ok is false when
+// the policy doesn't restrict name at all.
+func (p Policy) Effective(name string) (Directive, bool) {
+	if d, ok := p.Directive(name); ok {
+		return d, true
+	}
+	for _, fallback := range fallbacks[strings.ToLower(name)] {
+		if d, ok := p.Directive(fallback); ok {
+			return d, true
+		}
+	}
+	return Directive{}, false
+}
+
+// AllowsURL reports whether the directive's sources allow loading u into
+// a document at self: 'self', *, scheme sources such as https: and host
+// sources with optional wildcards, ports and paths. An http source also
+// allows https, as browsers upgrade it.
+func (d Directive) AllowsURL(u, self *url.URL) bool {
+	for _, source := range d.Sources {
+		if matchSource(strings.ToLower(source), u, self) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSource(source string, u, self *url.URL) bool {
+	scheme := strings.ToLower(u.Scheme)
+	switch {
+	case source == "'self'":
+		return schemeAllows(strings.ToLower(self.Scheme), scheme) && strings.EqualFold(u.Hostname(), self.Hostname()) &&
+			port(u) == port(self)
+	case source == "*":
+		// * leaves out data:, blob: and filesystem:, which must be named
+		return scheme == "http" || scheme == "https" || scheme == "ws" || scheme == "wss" || scheme == strings.ToLower(self.Scheme)
+	case strings.HasPrefix(source, "'"):
+		// 'none', nonces, hashes and the unsafe-* keywords name no URLs
+		return false
+	case strings.HasSuffix(source, ":"):
+		return schemeAllows(strings.TrimSuffix(source, ":"), scheme)
+	}
+
+	want := strings.ToLower(self.Scheme)
+	if s, rest, ok := strings.Cut(source, "://"); ok {
+		want, source = s, rest
+	}
+	if !schemeAllows(want, scheme) {
+		return false
+	}
+	hostPort, path, _ := strings.Cut(source, "/")
+	host, wantPort, hasPort := strings.Cut(hostPort, ":")
+	hostname := strings.ToLower(u.Hostname())
+	switch {
+	case host == "*":
+	case strings.HasPrefix(host, "*."):
+		if !strings.HasSuffix(hostname, host[1:]) {
+			return false
+		}
+	case host != hostname:
+		return false
+	}
+	switch {
+	case hasPort && wantPort == "*":
+	case hasPort:
+		if wantPort != port(u) {
+			return false
+		}
+	case port(u) != defaultPort(scheme):
+		// Without a port, only the scheme's default is allowed
+		return false
+	}
+	if path == "" {
+		return true
+	}
+	path = "/" + path
+	if strings.HasSuffix(path, "/") {
+		return strings.HasPrefix(u.Path, path)
+	}
+	return u.Path == path
+}
+
+// schemeAllows reports whether a source for scheme want allows a URL of
+// scheme got, upgrades included
+func schemeAllows(want, got string) bool {
+	return want == got || (want == "http" && got == "https") || (want == "ws" && got == "wss")
+}
+
+func port(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	return defaultPort(strings.ToLower(u.Scheme))
+}
+
+func defaultPort(scheme string) string {
+	switch scheme {
+	case "http", "ws":
+		return "80"
+	case "https", "wss":
+		return "443"
+	}
+	return ""
+}
+
+// AllowsInline reports whether the directive allows an inline script or
+// style with content. A matching nonce or hash allows it, and so does
+// 'unsafe-inline' unless a nonce or hash is listed, which makes browsers
+// ignore it. attr is set for event handler and style attributes, which
+// nonces never cover and hashes only cover with 'unsafe-hashes'.
+func (d Directive) AllowsInline(content, nonce string, attr bool) bool {
+	unsafeInline, strict := false, false
+	for _, source := range d.Sources {
+		lower := strings.ToLower(source)
+		switch {
+		case lower == "'unsafe-inline'":
+			unsafeInline = true
+		case strings.HasPrefix(lower, "'nonce-"):
+			strict = true
+			if !attr && nonce != "" && source == "'nonce-"+nonce+"'" {
+				return true
+			}
+		case isHash(lower):
+			strict = true
+			if (!attr || d.Has("'unsafe-hashes'")) && matchesHash(source, content) {
+				return true
+			}
+		case lower == "'strict-dynamic'":
+			strict = true
+		}
+	}
+	return unsafeInline && !strict
+}
+
+func isHash(source string) bool {
+	return strings.HasPrefix(source, "'sha256-") || strings.HasPrefix(source, "'sha384-") || strings.HasPrefix(source, "'sha512-")
+}
+
+func matchesHash(source, content string) bool {
+	algorithm, digest, _ := strings.Cut(strings.Trim(source, "'"), "-")
+	var sum []byte
+	switch strings.ToLower(algorithm) {
+	case "sha256":
+		s := sha256.Sum256([]byte(content))
+		sum = s[:]
+	case "sha384":
+		s := sha512.Sum384([]byte(content))
+		sum = s[:]
+	case "sha512":
+		s := sha512.Sum512([]byte(content))
+		sum = s[:]
+	}
+	// Browsers accept base64url digests as well as base64
+	return digest == base64.StdEncoding.EncodeToString(sum) || digest == base64.URLEncoding.EncodeToString(sum)
+}
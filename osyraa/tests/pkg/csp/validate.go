@@ -0,0 +1,290 @@
+package csp
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"golang.org/x/net/html"
+)
+
+// Resource is something a page loads or runs that a policy governs
+type Resource struct {
+	// Directive is the directive a browser checks it against, e.g.
+	// script-src-elem for <script src> and style-src-attr for a style
+	// attribute
+	Directive string
+	// Source says where it was found, e.g. "<img src>" or "inline <script>"
+	Source string
+	// URL is the resolved URL of a loaded resource; nil for inline code
+	URL *url.URL
+	// Inline is the code of an inline script, style or attribute
+	Inline string
+	// Nonce is the element's nonce attribute
+	Nonce string
+}
+
+func (r Resource) String() string {
+	if r.URL != nil {
+		return fmt.Sprintf("%s %s", r.Source, r.URL)
+	}
+	return r.Source
+}
+
+// loaded maps selectors to the attribute holding what they load and the
+// directive that governs it. Links are only loads for the relations in
+// linkDirectives.
+var loaded = []struct{ selector, attr, directive string }{
+	{"script[src]", "src", "script-src-elem"},
+	{"img[src]", "src", "img-src"}, {"img[srcset]", "srcset", "img-src"},
+	{"picture source[srcset]", "srcset", "img-src"},
+	{"video[src]", "src", "media-src"}, {"video[poster]", "poster", "img-src"},
+	{"audio[src]", "src", "media-src"}, {"video source[src]", "src", "media-src"},
+	{"audio source[src]", "src", "media-src"}, {"track[src]", "src", "media-src"},
+	{"iframe[src]", "src", "frame-src"}, {"frame[src]", "src", "frame-src"},
+	{"object[data]", "data", "object-src"}, {"embed[src]", "src", "object-src"},
+}
+
+// linkDirectives maps the link relations that load href to the directive
+// that governs the load
+var linkDirectives = map[string]string{
+	"stylesheet":       "style-src-elem",
+	"icon":             "img-src",
+	"apple-touch-icon": "img-src",
+	"mask-icon":        "img-src",
+	"manifest":         "manifest-src",
+}
+
+// scriptTypes are the type attributes of classic and module scripts; any
+// other type is a data block, such as JSON-LD, which never runs
+var scriptTypes = []string{"", "text/javascript", "application/javascript", "module"}
+
+// Resources returns what doc, served at page, loads and runs: external
+// scripts, stylesheets, images, media, frames and plugins, the favicon,
+// inline <script> and <style> elements, style attributes and event
+// handler attributes
+func Resources(doc *html.Node, page *url.URL) []Resource {
+	d := match.FromNode(doc)
+	var found []Resource
+	add := func(n *html.Node, attr, directive, ref string) {
+		parsed, err := url.Parse(strings.TrimSpace(ref))
+		if err != nil || strings.TrimSpace(ref) == "" {
+			return
+		}
+		found = append(found, Resource{Directive: directive, Source: fmt.Sprintf("<%s %s>", n.Data, attr),
+			URL: page.ResolveReference(parsed)})
+	}
+	for _, l := range loaded {
+		for _, n := range d.Select(l.selector).Nodes() {
+			val, _ := match.Attr(n, l.attr)
+			for _, ref := range candidates(l.attr, val) {
+				add(n, l.attr, l.directive, ref)
+			}
+		}
+	}
+	icon := false
+	for _, n := range d.Select("link[href][rel]").Nodes() {
+		rel, _ := match.Attr(n, "rel")
+		href, _ := match.Attr(n, "href")
+		for _, r := range strings.Fields(strings.ToLower(rel)) {
+			if directive, ok := linkDirectives[r]; ok {
+				add(n, "href", directive, href)
+				icon = icon || r == "icon"
+				break
+			}
+		}
+	}
+	if !icon {
+		// Browsers request /favicon.ico for a page that links no icon,
+		// under img-src like any image
+		found = append(found, Resource{Directive: "img-src", Source: "implicit favicon",
+			URL: page.ResolveReference(&url.URL{Path: "/favicon.ico"})})
+	}
+
+	for _, n := range d.Select("script:not([src])").Nodes() {
+		typ, _ := match.Attr(n, "type")
+		if !slices.Contains(scriptTypes, strings.ToLower(strings.TrimSpace(typ))) {
+			continue
+		}
+		nonce, _ := match.Attr(n, "nonce")
+		found = append(found, Resource{Directive: "script-src-elem", Source: "inline <script>", Inline: text(n), Nonce: nonce})
+	}
+	for _, n := range d.Select("style").Nodes() {
+		nonce, _ := match.Attr(n, "nonce")
+		found = append(found, Resource{Directive: "style-src-elem", Source: "inline <style>", Inline: text(n), Nonce: nonce})
+	}
+	for _, n := range d.Select("*").Nodes() {
+		for _, a := range n.Attr {
+			key := strings.ToLower(a.Key)
+			switch {
+			case key == "style":
+				found = append(found, Resource{Directive: "style-src-attr", Source: fmt.Sprintf("<%s style>", n.Data), Inline: a.Val})
+			case strings.HasPrefix(key, "on"):
+				found = append(found, Resource{Directive: "script-src-attr", Source: fmt.Sprintf("<%s %s>", n.Data, key), Inline: a.Val})
+			}
+		}
+	}
+	return found
+}
+
+// text is the raw content of a script or style element
+func text(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return b.String()
+}
+
+// candidates splits srcset into its URLs; other attributes hold one. A
+// candidate's URL runs to the next whitespace, so data: URLs keep their
+// commas.
+func candidates(attr, val string) []string {
+	if attr != "srcset" {
+		return []string{val}
+	}
+	var out []string
+	for rest := val; ; {
+		rest = strings.TrimLeft(rest, " \t\n\r\f,")
+		if rest == "" {
+			return out
+		}
+		end := strings.IndexAny(rest, " \t\n\r\f")
+		if end < 0 {
+			end = len(rest)
+		}
+		ref := rest[:end]
+		rest = rest[end:]
+		if trimmed := strings.TrimRight(ref, ","); trimmed != ref {
+			// No descriptors follow
+			out = append(out, trimmed)
+			continue
+		}
+		out = append(out, ref)
+		// Skip the descriptors, up to the comma ending the candidate
+		if i := strings.IndexByte(rest, ','); i >= 0 {
+			rest = rest[i+1:]
+		} else {
+			rest = ""
+		}
+	}
+}
+
+// Problem is something wrong with a policy, or with a page under it
+type Problem struct {
+	Page      string
+	Directive string
+	Message   string
+}
+
+func (p Problem) String() string {
+	if p.Page == "" {
+		return fmt.Sprintf("%s: %s", p.Directive, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Page, p.Directive, p.Message)
+}
+
+// unsafeSources are the keywords that defeat a policy's protection
+// against injected code
+var unsafeSources = []string{"'unsafe-inline'", "'unsafe-eval'"}
+
+// Unsafe returns every directive listing 'unsafe-inline' or
+// 'unsafe-eval'
+func Unsafe(p Policy) []Problem {
+	var problems []Problem
+	for _, d := range p {
+		for _, source := range unsafeSources {
+			if d.Has(source) {
+				problems = append(problems, Problem{Directive: d.Name, Message: "allows " + source})
+			}
+		}
+	}
+	return problems
+}
+
+// restricted are the directives every page must be restricted by, since
+// without them nothing stops injected scripts and plugins. Each is
+// satisfied by default-src too.
+var restricted = []string{"script-src", "object-src"}
+
+// Unrestricted returns a problem for each of script-src and object-src
+// that none of the policies a page is served with restricts, neither
+// directly nor through default-src. A policy of only frame-ancestors lets
+// any inline or third-party script run, which is worse than
+// 'unsafe-inline'.
+func Unrestricted(policies []Policy) []Problem {
+	var problems []Problem
+	for _, name := range restricted {
+		governed := slices.ContainsFunc(policies, func(p Policy) bool {
+			_, ok := p.Effective(name)
+			return ok
+		})
+		if !governed {
+			problems = append(problems, Problem{Directive: name,
+				Message: fmt.Sprintf("is not restricted: the policy has neither %s nor default-src", name)})
+		}
+	}
+	return problems
+}
+
+// Blocked returns every resource of a page at page that one of the
+// policies would block. Every policy a page is served with is enforced,
+// so a resource must pass all of them.
+func Blocked(policies []Policy, page *url.URL, resources []Resource) []Problem {
+	var problems []Problem
+	for _, r := range resources {
+		for _, p := range policies {
+			d, ok := p.Effective(r.Directive)
+			if !ok {
+				continue
+			}
+			allowed := false
+			if r.URL != nil {
+				allowed = d.AllowsURL(r.URL, page)
+			} else {
+				allowed = d.AllowsInline(r.Inline, r.Nonce, strings.HasSuffix(r.Directive, "-attr"))
+			}
+			if !allowed {
+				problems = append(problems, Problem{Page: page.Path, Directive: d.Name,
+					Message: fmt.Sprintf("blocks %s (%s)", r, d)})
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// observable are the directives whose use shows in a page's markup.
+// Directives for fonts, connections and workers are left out, since only
+// stylesheets and scripts use those, and default-src stands in for
+// everything else.
+var observable = []string{
+	"script-src", "script-src-elem", "script-src-attr", "style-src", "style-src-elem", "style-src-attr",
+	"img-src", "media-src", "frame-src", "object-src", "manifest-src",
+}
+
+// Unused returns the directives of p that allow something no resource
+// of the pages served with it uses, e.g. an img-src on a site without
+// images. A directive of 'none', or with no sources, only forbids, so
+// it is never unused.
+func Unused(p Policy, resources []Resource) []Problem {
+	used := map[string]bool{}
+	for _, r := range resources {
+		if d, ok := p.Effective(r.Directive); ok {
+			used[d.Name] = true
+		}
+	}
+	var problems []Problem
+	for _, d := range p {
+		if !slices.Contains(observable, d.Name) || used[d.Name] || len(d.Sources) == 0 || (len(d.Sources) == 1 && d.Has("'none'")) {
+			continue
+		}
+		problems = append(problems, Problem{Directive: d.Name,
+			Message: fmt.Sprintf("allows %s, but no page loads anything it governs", strings.Join(d.Sources, " "))})
+	}
+	return problems
+}
@@ -0,0 +1,155 @@
+package csp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const page = `<!DOCTYPE html>
+<html><head>
+<link rel="stylesheet" href="/css/main.css">
+<link rel="icon" href="/favicon.ico">
+<link rel="preconnect" href="https://fonts.example.com">
+<script src="https://cdn.example.com/lib.js"></script>
+<script nonce="r4nd0m">console.log("nonced")</script>
+<script type="application/ld+json">{"@type": "Person"}</script>
+<style>h1 { color: navy }</style>
+</head><body onload="init()">
+<h1 style="margin: 0">Princeton A. Strong</h1>
+<img src="/avatar.png" srcset="/avatar-2x.png 2x, data:image/png;base64,AAAA 3x">
+</body></html>`
+
+func resources(t *testing.T) (*url.URL, []Resource) {
+	t.Helper()
+	doc, err := match.ParseBytes([]byte(page))
+	require.NoError(t, err)
+	self, _ := url.Parse("http://127.0.0.1:8080/about/")
+	return self, Resources(doc.Root(), self)
+}
+
+func TestResources(t *testing.T) {
+	_, found := resources(t)
+	var got []string
+	for _, r := range found {
+		got = append(got, r.Directive+" "+r.String())
+	}
+	assert.Equal(t, []string{
+		"script-src-elem <script src> https://cdn.example.com/lib.js",
+		"img-src <img src> http://127.0.0.1:8080/avatar.png",
+		"img-src <img srcset> http://127.0.0.1:8080/avatar-2x.png",
+		"img-src <img srcset> data:image/png;base64,AAAA",
+		"style-src-elem <link href> http://127.0.0.1:8080/css/main.css",
+		"img-src <link href> http://127.0.0.1:8080/favicon.ico",
+		"script-src-elem inline <script>",
+		"style-src-elem inline <style>",
+		"script-src-attr <body onload>",
+		"style-src-attr <h1 style>",
+	}, got, "JSON-LD never runs and preconnect loads nothing")
+	assert.Equal(t, "r4nd0m", found[6].Nonce)
+	assert.Equal(t, `console.log("nonced")`, found[6].Inline)
+}
+
+func TestUnsafe(t *testing.T) {
+	p := Parse("default-src 'self'; script-src 'self' 'unsafe-eval'; style-src 'self' 'UNSAFE-INLINE'")
+	assert.Equal(t, []Problem{
+		{Directive: "script-src", Message: "allows 'unsafe-eval'"},
+		{Directive: "style-src", Message: "allows 'unsafe-inline'"},
+	}, Unsafe(p))
+	assert.Empty(t, Unsafe(Parse("default-src 'self'")))
+}
+
+func TestUnrestricted(t *testing.T) {
+	assert.Equal(t, []Problem{
+		{Directive: "script-src", Message: "is not restricted: the policy has neither script-src nor default-src"},
+		{Directive: "object-src", Message: "is not restricted: the policy has neither object-src nor default-src"},
+	}, Unrestricted([]Policy{Parse("frame-ancestors 'self'")}))
+	assert.Equal(t, []Problem{
+		{Directive: "object-src", Message: "is not restricted: the policy has neither object-src nor default-src"},
+	}, Unrestricted([]Policy{Parse("script-src 'self'")}))
+	assert.Empty(t, Unrestricted([]Policy{Parse("frame-ancestors 'self'"), Parse("default-src 'none'")}),
+		"every policy served is enforced, so one restricting both is enough")
+	assert.Empty(t, Unrestricted([]Policy{Parse("script-src 'self'; object-src 'none'")}))
+}
+
+func TestResourcesImplicitFavicon(t *testing.T) {
+	page, _ := url.Parse("http://127.0.0.1:8080/about/")
+	doc, err := match.ParseBytes([]byte(`<link rel="stylesheet" href="/css/style.css"><p>Hi</p>`))
+	require.NoError(t, err)
+	var got []string
+	for _, r := range Resources(doc.Root(), page) {
+		got = append(got, r.Directive+" "+r.String())
+	}
+	assert.Equal(t, []string{
+		"style-src-elem <link href> http://127.0.0.1:8080/css/style.css",
+		"img-src implicit favicon http://127.0.0.1:8080/favicon.ico",
+	}, got)
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
+
+func TestBlocked(t *testing.T) {
+	self, found := resources(t)
+	strict := Parse("default-src 'none'; script-src 'self' https://*.example.com 'nonce-r4nd0m' " + hash("init()") + " 'unsafe-hashes'; " +
+		"style-src 'self' " + hash("h1 { color: navy }") + " " + hash("margin: 0") + " 'unsafe-hashes'; img-src 'self' data:")
+	assert.Empty(t, Blocked([]Policy{strict}, self, found))
+
+	loose := Parse("default-src 'self'; style-src 'self' 'unsafe-inline' 'nonce-other'")
+	var problems []string
+	for _, p := range Blocked([]Policy{Parse("frame-ancestors 'self'"), loose}, self, found) {
+		problems = append(problems, p.String())
+	}
+	assert.Equal(t, []string{
+		"/about/: default-src: blocks <script src> https://cdn.example.com/lib.js (default-src 'self')",
+		"/about/: default-src: blocks <img srcset> data:image/png;base64,AAAA (default-src 'self')",
+		"/about/: default-src: blocks inline <script> (default-src 'self')",
+		"/about/: style-src: blocks inline <style> (style-src 'self' 'unsafe-inline' 'nonce-other')",
+		"/about/: default-src: blocks <body onload> (default-src 'self')",
+		"/about/: style-src: blocks <h1 style> (style-src 'self' 'unsafe-inline' 'nonce-other')",
+	}, problems, "a nonce makes browsers ignore 'unsafe-inline'")
+}
+
+func TestAllowsURL(t *testing.T) {
+	self, _ := url.Parse("https://resume.example.com/")
+	for source, cases := range map[string]map[string]bool{
+		"'self'":                   {"https://resume.example.com/a.js": true, "http://resume.example.com/a.js": false, "https://example.com/": false},
+		"*":                        {"https://anything.test/x": true, "data:image/png,AA": false, "blob:https://resume.example.com/1": false},
+		"https:":                   {"https://cdn.test/x": true, "http://cdn.test/x": false},
+		"http:":                    {"https://cdn.test/x": true},
+		"cdn.test":                 {"https://cdn.test/x": true, "https://cdn.test:8443/x": false, "https://www.cdn.test/x": false},
+		"*.cdn.test":               {"https://a.cdn.test/x": true, "https://cdn.test/x": false},
+		"http://cdn.test:*":        {"https://cdn.test:8443/x": true, "ws://cdn.test/x": false},
+		"https://cdn.test/js/":     {"https://cdn.test/js/a.js": true, "https://cdn.test/css/a.css": false},
+		"https://cdn.test/js/a.js": {"https://cdn.test/js/a.js": true, "https://cdn.test/js/b.js": false},
+		"'none'":                   {"https://resume.example.com/": false},
+	} {
+		d := Directive{Name: "img-src", Sources: []string{source}}
+		for raw, want := range cases {
+			u, err := url.Parse(raw)
+			require.NoError(t, err)
+			assert.Equal(t, want, d.AllowsURL(u, self), "%s allows %s", source, raw)
+		}
+	}
+}
+
+func TestUnused(t *testing.T) {
+	_, found := resources(t)
+	p := Parse("default-src 'self'; script-src 'self' https:; img-src 'self' data:; media-src https://video.test; " +
+		"frame-src 'none'; object-src; font-src https://fonts.test; connect-src 'self'")
+	assert.Equal(t, []Problem{
+		{Directive: "media-src", Message: "allows https://video.test, but no page loads anything it governs"},
+	}, Unused(p, found), "fonts and connections don't show in markup, and 'none' only forbids")
+
+	withStyles := Parse("style-src 'self'; style-src-elem 'self'")
+	assert.Equal(t, []Problem{
+		{Directive: "style-src", Message: "allows 'self', but no page loads anything it governs"},
+	}, Unused(withStyles, found[4:5]), "style-src-elem governs the stylesheet, leaving style-src unused")
+}
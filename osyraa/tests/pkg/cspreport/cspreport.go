@@ -0,0 +1,237 @@
+// Package cspreport collects Content-Security-Policy violation reports
+// while browser checks exercise the site, so a policy is validated against
+// what pages actually load rather than what it was assumed they load.
+package cspreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Report is a violation in either the report-uri or the Reporting API
+// format, normalised
+type Report struct {
+	DocumentURL string
+	// Directive is the effective directive, e.g. script-src-elem
+	Directive   string
+	BlockedURL  string
+	SourceFile  string
+	Line        int
+	Column      int
+	Sample      string
+	Disposition string
+}
+
+func (r Report) String() string {
+	s := fmt.Sprintf("%s: %s blocked %s", r.DocumentURL, r.Directive, r.BlockedURL)
+	if r.SourceFile != "" {
+		s += fmt.Sprintf(" (%s:%d:%d)", r.SourceFile, r.Line, r.Column)
+	}
+	if r.Disposition == "report" {
+		s += " [report-only]"
+	}
+	return s
+}
+
+// Extension reports whether the violation came from a browser extension
+// rather than the site
+func (r Report) Extension() bool {
+	for _, u := range []string{r.BlockedURL, r.SourceFile} {
+		for _, scheme := range []string{"chrome-extension:", "moz-extension:", "safari-extension:", "safari-web-extension:"} {
+			if strings.HasPrefix(u, scheme) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// legacyReport is the body of a report-uri POST (application/csp-report)
+type legacyReport struct {
+	CSPReport struct {
+		DocumentURI        string `json:"document-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		BlockedURI         string `json:"blocked-uri"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+		ScriptSample       string `json:"script-sample"`
+		Disposition        string `json:"disposition"`
+	} `json:"csp-report"`
+}
+
+// apiReport is one entry of a Reporting API POST (application/reports+json)
+type apiReport struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		BlockedURL         string `json:"blockedURL"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+		Sample             string `json:"sample"`
+		Disposition        string `json:"disposition"`
+	} `json:"body"`
+}
+
+// Parse decodes a report POST body in either format
+func Parse(body []byte) ([]Report, error) {
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		var entries []apiReport
+		if err := json.Unmarshal(body, &entries); err != nil {
+			return nil, err
+		}
+		var reports []Report
+		for _, e := range entries {
+			if e.Type != "csp-violation" {
+				continue
+			}
+			b := e.Body
+			reports = append(reports, Report{
+				DocumentURL: b.DocumentURL, Directive: b.EffectiveDirective, BlockedURL: b.BlockedURL,
+				SourceFile: b.SourceFile, Line: b.LineNumber, Column: b.ColumnNumber,
+				Sample: b.Sample, Disposition: b.Disposition,
+			})
+		}
+		return reports, nil
+	}
+
+	var legacy legacyReport
+	if err := json.Unmarshal(body, &legacy); err != nil {
+		return nil, err
+	}
+	r := legacy.CSPReport
+	if r.DocumentURI == "" {
+		return nil, fmt.Errorf("not a CSP report: %.80s", trimmed)
+	}
+	directive := r.EffectiveDirective
+	if directive == "" {
+		directive, _, _ = strings.Cut(r.ViolatedDirective, " ")
+	}
+	return []Report{{
+		DocumentURL: r.DocumentURI, Directive: directive, BlockedURL: r.BlockedURI,
+		SourceFile: r.SourceFile, Line: r.LineNumber, Column: r.ColumnNumber,
+		Sample: r.ScriptSample, Disposition: r.Disposition,
+	}}, nil
+}
+
+// Collector is a report endpoint on 127.0.0.1
+type Collector struct {
+	srv *http.Server
+	url string
+
+	mu      sync.Mutex
+	reports []Report
+	last    time.Time
+}
+
+// New starts a collector on a free port
+func New() (*Collector, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	c := &Collector{url: "http://" + ln.Addr().String() + "/csp-report", last: time.Now()}
+	c.srv = &http.Server{Handler: c}
+	go c.srv.Serve(ln)
+	return c, nil
+}
+
+// URL is the endpoint to name in report-uri
+func (c *Collector) URL() string { return c.url }
+
+// ServeHTTP accepts report POSTs in either format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reports, err := Parse(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.reports = append(c.reports, reports...)
+	c.last = time.Now()
+	c.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Reports returns the reports received so far
+func (c *Collector) Reports() []Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Report(nil), c.reports...)
+}
+
+// Wait returns the reports once none has arrived for quiet, since browsers
+// send them asynchronously after the violation, or when ctx is done
+func (c *Collector) Wait(ctx context.Context, quiet time.Duration) []Report {
+	tick := time.NewTicker(quiet / 4)
+	defer tick.Stop()
+	for {
+		c.mu.Lock()
+		idle := time.Since(c.last)
+		c.mu.Unlock()
+		if idle >= quiet {
+			return c.Reports()
+		}
+		select {
+		case <-ctx.Done():
+			return c.Reports()
+		case <-tick.C:
+		}
+	}
+}
+
+// Close stops the collector
+func (c *Collector) Close() error { return c.srv.Close() }
+
+var reportDirectiveRe = regexp.MustCompile(`(?i)^\s*(report-uri|report-to)\b`)
+
+// WithReportURI points policy's reports at uri, replacing any report-uri
+// or report-to directive so reports are not also sent elsewhere
+func WithReportURI(policy, uri string) string {
+	var kept []string
+	for _, d := range strings.Split(policy, ";") {
+		if strings.TrimSpace(d) == "" || reportDirectiveRe.MatchString(d) {
+			continue
+		}
+		kept = append(kept, strings.TrimSpace(d))
+	}
+	return strings.Join(append(kept, "report-uri "+uri), "; ")
+}
+
+// Instrument rewrites a document's response headers so every policy, both
+// enforced and report-only, reports to uri. A non-empty trial policy is
+// added as Content-Security-Policy-Report-Only, to see what a stricter
+// policy would break before shipping it.
+func Instrument(h http.Header, uri, trial string) {
+	for _, name := range []string{"Content-Security-Policy", "Content-Security-Policy-Report-Only"} {
+		values := h.Values(name)
+		h.Del(name)
+		for _, v := range values {
+			h.Add(name, WithReportURI(v, uri))
+		}
+	}
+	if trial != "" {
+		h.Add("Content-Security-Policy-Report-Only", WithReportURI(trial, uri))
+	}
+}
@@ -0,0 +1,114 @@
+package cspreport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/browser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacy = `{"csp-report":{"document-uri":"http://localhost:8080/","violated-directive":"img-src 'self'",
+"blocked-uri":"https://tracker.example/p.gif","source-file":"http://localhost:8080/js/app.js",
+"line-number":12,"column-number":3,"disposition":"enforce"}}`
+
+const reportingAPI = `[{"type":"csp-violation","url":"http://localhost:8080/","body":{
+"documentURL":"http://localhost:8080/about/","effectiveDirective":"script-src-elem",
+"blockedURL":"inline","lineNumber":4,"columnNumber":1,"sample":"alert(1)","disposition":"report"}},
+{"type":"deprecation","body":{}}]`
+
+func TestParse(t *testing.T) {
+	reports, err := Parse([]byte(legacy))
+	require.NoError(t, err)
+	assert.Equal(t, []Report{{
+		DocumentURL: "http://localhost:8080/", Directive: "img-src", BlockedURL: "https://tracker.example/p.gif",
+		SourceFile: "http://localhost:8080/js/app.js", Line: 12, Column: 3, Disposition: "enforce",
+	}}, reports)
+	assert.Equal(t, "http://localhost:8080/: img-src blocked https://tracker.example/p.gif (http://localhost:8080/js/app.js:12:3)",
+		reports[0].String())
+
+	reports, err = Parse([]byte(reportingAPI))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "script-src-elem", reports[0].Directive)
+	assert.Equal(t, "alert(1)", reports[0].Sample)
+	assert.Equal(t, "http://localhost:8080/about/: script-src-elem blocked inline [report-only]", reports[0].String())
+
+	_, err = Parse([]byte(`{"hello":"world"}`))
+	assert.Error(t, err)
+}
+
+func TestExtension(t *testing.T) {
+	assert.True(t, Report{BlockedURL: "chrome-extension://abc/inject.js"}.Extension())
+	assert.True(t, Report{SourceFile: "moz-extension://abc/content.js"}.Extension())
+	assert.False(t, Report{BlockedURL: "inline"}.Extension())
+}
+
+func TestCollector(t *testing.T) {
+	c, err := New()
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, body := range []string{legacy, reportingAPI} {
+		resp, err := http.Post(c.URL(), "application/csp-report", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+	resp, err := http.Post(c.URL(), "application/json", strings.NewReader("nope"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Len(t, c.Wait(ctx, 100*time.Millisecond), 2)
+}
+
+func TestWithReportURI(t *testing.T) {
+	assert.Equal(t, "frame-ancestors 'self'; report-uri http://c/r",
+		WithReportURI("frame-ancestors 'self'", "http://c/r"))
+	assert.Equal(t, "default-src 'self'; img-src *; report-uri http://c/r",
+		WithReportURI("default-src 'self'; report-uri /old; report-to csp; img-src *;", "http://c/r"))
+}
+
+func TestInstrument(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Security-Policy", "frame-ancestors 'self'")
+	Instrument(h, "http://c/r", "default-src 'self'")
+	assert.Equal(t, []string{"frame-ancestors 'self'; report-uri http://c/r"}, h.Values("Content-Security-Policy"))
+	assert.Equal(t, []string{"default-src 'self'; report-uri http://c/r"}, h.Values("Content-Security-Policy-Report-Only"))
+}
+
+func TestCollectInBrowser(t *testing.T) {
+	b := browser.NewForTest(t)
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "frame-ancestors 'self'")
+		w.Write([]byte(`<!DOCTYPE html><body><script>document.body.dataset.ran = "1"</script></body>`))
+	}))
+	defer site.Close()
+	c, err := New()
+	require.NoError(t, err)
+	defer c.Close()
+
+	tab, cancel := b.NewTab()
+	defer cancel()
+	require.NoError(t, browser.RewriteDocumentHeaders(tab, func(_ string, h http.Header) {
+		Instrument(h, c.URL(), "script-src 'self'")
+	}))
+	require.NoError(t, chromedp.Run(tab, chromedp.Navigate(site.URL), chromedp.WaitReady("body")))
+
+	ctx, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+	reports := c.Wait(ctx, time.Second)
+	require.NotEmpty(t, reports, "The inline script should violate the trial policy")
+	assert.Equal(t, "report", reports[0].Disposition)
+	assert.True(t, strings.HasPrefix(reports[0].Directive, "script-src"))
+}
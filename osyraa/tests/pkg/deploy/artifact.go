@@ -0,0 +1,249 @@
+package deploy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Artifact is a packaged copy of the built site, shipped to a CDN or
+// object storage apart from the image
+type Artifact struct {
+	// Path is the tarball; its checksum is beside it in Path.sha256
+	Path   string
+	SHA256 string
+	Files  int
+	Size   int64
+}
+
+// SourceDateEpoch is the time SOURCE_DATE_EPOCH sets for reproducible
+// builds, or the Unix epoch when it is unset
+func SourceDateEpoch() (time.Time, error) {
+	v := os.Getenv("SOURCE_DATE_EPOCH")
+	if v == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("SOURCE_DATE_EPOCH: %w", err)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// WriteArchive writes dir as a gzipped tarball that depends only on the
+// files' paths, contents and executable bits: entries are in lexical
+// order, every one has mtime, no owner and mode 0644 or 0755, and the
+// gzip header carries no name or time. Packing the same build twice gives
+// the same bytes.
+func WriteArchive(w io.Writer, dir string, mtime time.Time) (files int, err error) {
+	zw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return 0, err
+	}
+	tw := tar.NewWriter(zw)
+	mtime = mtime.Truncate(time.Second)
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		hdr := &tar.Header{Name: filepath.ToSlash(rel), ModTime: mtime, Mode: 0o644}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			hdr.Typeflag, hdr.Name, hdr.Mode = tar.TypeDir, hdr.Name+"/", 0o755
+			return tw.WriteHeader(hdr)
+		case !info.Mode().IsRegular():
+			return fmt.Errorf("%s: only regular files and directories can be packaged", hdr.Name)
+		case info.Mode()&0o111 != 0:
+			hdr.Mode = 0o755
+		}
+		hdr.Typeflag, hdr.Size = tar.TypeReg, info.Size()
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := io.Copy(tw, f); err != nil {
+			return err
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		return files, err
+	}
+	if err := tw.Close(); err != nil {
+		return files, err
+	}
+	return files, zw.Close()
+}
+
+// Package writes dir to the tarball out and its SHA-256 to out.sha256, in
+// the format sha256sum -c reads
+func Package(dir, out string, mtime time.Time) (*Artifact, error) {
+	f, err := os.Create(out)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(f, h)}
+	files, err := WriteArchive(counter, dir, mtime)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(out)
+		return nil, err
+	}
+	a := &Artifact{Path: out, SHA256: hex.EncodeToString(h.Sum(nil)), Files: files, Size: counter.n}
+	line := a.SHA256 + "  " + filepath.Base(out) + "\n"
+	if err := os.WriteFile(out+".sha256", []byte(line), 0o644); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ReadChecksum returns the SHA-256 that archive.sha256 records for archive
+func ReadChecksum(archive string) (string, error) {
+	data, err := os.ReadFile(archive + ".sha256")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// sha256sum marks files hashed in binary mode with a * before the name
+		if f := strings.Fields(line); len(f) == 2 && strings.TrimPrefix(f[1], "*") == filepath.Base(archive) {
+			return f[0], nil
+		}
+	}
+	return "", fmt.Errorf("%s.sha256 has no checksum for %s", archive, filepath.Base(archive))
+}
+
+// Unpack extracts a tarball written by WriteArchive into dest. Entries
+// that would land outside dest are refused.
+func Unpack(archive, dest string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", archive, err)
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", archive, err)
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("%s: entry %q is outside the archive root", archive, hdr.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fs.FileMode(hdr.Mode)&0o755)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			if cerr := out.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: entry %q is not a file or directory", archive, hdr.Name)
+		}
+	}
+}
+
+// VerifyArtifact checks archive against its .sha256 file, unpacks it and
+// compares the files with dir. Missing lists files of dir the archive
+// lacks, Extra files only the archive has.
+func VerifyArtifact(archive, dir string) (TreeDiff, error) {
+	want, err := ReadChecksum(archive)
+	if err != nil {
+		return TreeDiff{}, err
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return TreeDiff{}, err
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return TreeDiff{}, err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return TreeDiff{}, fmt.Errorf("%s hashes to %s, its checksum file says %s", archive, got, want)
+	}
+
+	unpacked, err := os.MkdirTemp("", "osyraa-artifact-")
+	if err != nil {
+		return TreeDiff{}, err
+	}
+	defer os.RemoveAll(unpacked)
+	if err := Unpack(archive, unpacked); err != nil {
+		return TreeDiff{}, err
+	}
+	packed, err := LocalTree(unpacked)
+	if err != nil {
+		return TreeDiff{}, err
+	}
+	local, err := LocalTree(dir)
+	if err != nil {
+		return TreeDiff{}, err
+	}
+	paths := make([]string, 0, len(packed))
+	for p := range packed {
+		paths = append(paths, p)
+	}
+	return CompareTrees(local, paths, func(lf LocalFile, p string) bool {
+		return packed[p].SHA256 == lf.SHA256
+	}), nil
+}
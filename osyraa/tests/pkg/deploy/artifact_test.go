@@ -0,0 +1,121 @@
+package deploy
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteArchiveIsDeterministic(t *testing.T) {
+	dir, _ := canonicalSite(t)
+	var first bytes.Buffer
+	files, err := WriteArchive(&first, dir, time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 3, files)
+
+	// Touching the files changes nothing in the archive
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "index.html"), later, later))
+	var second bytes.Buffer
+	_, err = WriteArchive(&second, dir, time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, first.Bytes(), second.Bytes())
+
+	zr, err := gzip.NewReader(&first)
+	require.NoError(t, err)
+	var names []string
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+		assert.Equal(t, int64(1700000000), hdr.ModTime.Unix(), hdr.Name)
+		assert.Zero(t, hdr.Uid, hdr.Name)
+	}
+	assert.Equal(t, []string{"about/", "about/index.html", "index.html", "resume.json"}, names)
+}
+
+func TestPackageAndVerify(t *testing.T) {
+	dir, _ := canonicalSite(t)
+	out := filepath.Join(t.TempDir(), "public.tar.gz")
+	a, err := Package(dir, out, time.Unix(0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 3, a.Files)
+	info, err := os.Stat(out)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), a.Size)
+	sum, err := os.ReadFile(out + ".sha256")
+	require.NoError(t, err)
+	assert.Equal(t, a.SHA256+"  public.tar.gz\n", string(sum))
+
+	diff, err := VerifyArtifact(out, dir)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty(), "%+v", diff)
+
+	// The build moving on after packaging shows up as a difference
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "resume.json"), []byte(`{"name":"changed"}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.html"), nil, 0o644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "about", "index.html")))
+	diff, err = VerifyArtifact(out, dir)
+	require.NoError(t, err)
+	assert.Equal(t, TreeDiff{Missing: []string{"new.html"}, Extra: []string{"about/index.html"}, Changed: []string{"resume.json"}}, diff)
+
+	// A tarball altered after packaging fails its checksum
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	data[len(data)/2] ^= 0xff
+	require.NoError(t, os.WriteFile(out, data, 0o644))
+	_, err = VerifyArtifact(out, dir)
+	assert.ErrorContains(t, err, "its checksum file says "+a.SHA256)
+}
+
+func TestReadChecksum(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "public.tar.gz")
+	require.NoError(t, os.WriteFile(out+".sha256", []byte("abc  other.tar.gz\n"), 0o644))
+	_, err := ReadChecksum(out)
+	assert.ErrorContains(t, err, "has no checksum for public.tar.gz")
+
+	require.NoError(t, os.WriteFile(out+".sha256", []byte("abc  other.tar.gz\ndef *public.tar.gz\n"), 0o644))
+	sum, err := ReadChecksum(out)
+	require.NoError(t, err)
+	assert.Equal(t, "def", sum)
+}
+
+func TestUnpackRefusesEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0o644}))
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+	archive := filepath.Join(t.TempDir(), "evil.tar.gz")
+	require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0o644))
+
+	dest := t.TempDir()
+	assert.ErrorContains(t, Unpack(archive, dest), `entry "../evil" is outside the archive root`)
+	_, err := os.Stat(filepath.Join(filepath.Dir(dest), "evil"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	epoch, err := SourceDateEpoch()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), epoch.Unix())
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	epoch, err = SourceDateEpoch()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), epoch.Unix())
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = SourceDateEpoch()
+	assert.Error(t, err)
+}
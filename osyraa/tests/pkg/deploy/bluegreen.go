@@ -0,0 +1,132 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// BlueGreen is a pair of environments behind a public endpoint. Candidate
+// is the environment about to receive traffic, Live the one serving now.
+type BlueGreen struct {
+	Candidate *battery.Target
+	Live      *battery.Target
+	Public    *battery.Target
+}
+
+// CutoverOptions control a blue/green cutover verification
+type CutoverOptions struct {
+	Checks []battery.Check
+	// Switch moves traffic to the candidate; nil when the switch happens
+	// outside the harness and is only observed
+	Switch Hook
+	// Timeout bounds the wait for the public endpoint to serve the candidate
+	Timeout time.Duration
+	// Interval between polls of the public endpoint
+	Interval time.Duration
+	// Version tells the environments apart by their home page; nil uses
+	// its content hash, which can't for a release that changes no content
+	Version VersionSource
+}
+
+// CutoverReport is the outcome of a cutover verification
+type CutoverReport struct {
+	// CandidateVersion and LiveVersion are what each environment's home
+	// page reported, the content hash unless CutoverOptions.Version is set
+	CandidateVersion string
+	LiveVersion      string
+	Results          []battery.Result
+	// SwitchedAfter is how long the public endpoint took to serve the candidate
+	SwitchedAfter time.Duration
+}
+
+// VerifyCutover checks both environments, runs the battery against the
+// candidate, switches traffic and waits until the public endpoint serves
+// the candidate's version. Traffic is never switched to a failing
+// candidate, nor when the two environments report the same version, since
+// the public endpoint would seem to switch before any traffic moved.
+func VerifyCutover(ctx context.Context, bg BlueGreen, opts CutoverOptions) (*CutoverReport, error) {
+	if opts.Checks == nil {
+		opts.Checks = battery.Default()
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.Interval == 0 {
+		opts.Interval = 5 * time.Second
+	}
+
+	report := &CutoverReport{}
+	var err error
+	if report.CandidateVersion, err = homeVersion(ctx, bg.Candidate, opts.Version); err != nil {
+		return nil, fmt.Errorf("candidate: %w", err)
+	}
+	if report.LiveVersion, err = homeVersion(ctx, bg.Live, opts.Version); err != nil {
+		return nil, fmt.Errorf("live: %w", err)
+	}
+	if report.CandidateVersion == report.LiveVersion {
+		what := "home page"
+		if opts.Version != nil {
+			what = "version " + report.LiveVersion
+		}
+		return report, fmt.Errorf("candidate and live environments serve the same %s, so the cutover can't be observed; tell them apart by build ID or a version header", what)
+	}
+
+	report.Results = battery.Run(ctx, bg.Candidate, opts.Checks)
+	if failed := battery.Failed(report.Results); len(failed) > 0 {
+		return report, errors.Join(errors.New("candidate failed the battery, not switching traffic"), failed[0].Err)
+	}
+
+	start := time.Now()
+	if opts.Switch != nil {
+		if err := opts.Switch(ctx); err != nil {
+			return report, fmt.Errorf("switch traffic: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	for {
+		version, err := homeVersion(ctx, bg.Public, opts.Version)
+		if err == nil && version == report.CandidateVersion {
+			report.SwitchedAfter = time.Since(start)
+			return report, nil
+		}
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = fmt.Errorf("public endpoint still serves %s", describeVersion(version, report.LiveVersion))
+			}
+			return report, fmt.Errorf("cutover not observed after %v: %w", opts.Timeout, err)
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+// homeVersion fetches the target's home page and returns the version
+// source reads from it, or its content hash when source is nil
+func homeVersion(ctx context.Context, t *battery.Target, source VersionSource) (string, error) {
+	resp, body, err := t.Get(ctx, "/")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %d", t.BaseURL, resp.StatusCode)
+	}
+	if source == nil {
+		return battery.ContentHash(body), nil
+	}
+	return source(resp, body)
+}
+
+// describeVersion names a version for error messages
+func describeVersion(version, live string) string {
+	if version == live {
+		return "the live environment's version"
+	}
+	return "unknown version " + version[:min(len(version), 12)]
+}
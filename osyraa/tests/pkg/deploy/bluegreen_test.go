@@ -0,0 +1,111 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func page(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(body))
+	}))
+}
+
+func TestVerifyCutover(t *testing.T) {
+	const candidate = `<html><body><h1>Princeton A. Strong</h1><p>v2</p></body></html>`
+	green, blue := page(candidate), page(good)
+	defer green.Close()
+	defer blue.Close()
+
+	var switched atomic.Bool
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if switched.Load() {
+			w.Write([]byte(candidate))
+			return
+		}
+		w.Write([]byte(good))
+	}))
+	defer public.Close()
+
+	bg := BlueGreen{Candidate: battery.NewTarget(green.URL), Live: battery.NewTarget(blue.URL), Public: battery.NewTarget(public.URL)}
+	checks := []battery.Check{{Name: "content", Run: battery.CheckContent}}
+
+	_, err := VerifyCutover(context.Background(), bg, CutoverOptions{Checks: checks, Timeout: 50 * time.Millisecond, Interval: 10 * time.Millisecond})
+	assert.ErrorContains(t, err, "live environment")
+
+	report, err := VerifyCutover(context.Background(), bg, CutoverOptions{
+		Checks:   checks,
+		Switch:   func(context.Context) error { switched.Store(true); return nil },
+		Interval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, battery.ContentHash([]byte(candidate)), report.CandidateVersion)
+	assert.NotEqual(t, report.CandidateVersion, report.LiveVersion)
+}
+
+func TestVerifyCutoverNeedsDistinctVersions(t *testing.T) {
+	// A config-only release serves the same home page from both sides
+	green, blue := page(good), page(good)
+	defer green.Close()
+	defer blue.Close()
+
+	called := false
+	bg := BlueGreen{Candidate: battery.NewTarget(green.URL), Live: battery.NewTarget(blue.URL), Public: battery.NewTarget(blue.URL)}
+	opts := CutoverOptions{
+		Checks: []battery.Check{{Name: "content", Run: battery.CheckContent}},
+		Switch: func(context.Context) error { called = true; return nil },
+	}
+	_, err := VerifyCutover(context.Background(), bg, opts)
+	assert.ErrorContains(t, err, "same home page")
+	assert.False(t, called, "Traffic must not be switched when the cutover can't be observed")
+
+	opts.Version = VersionHeader("X-Version")
+	_, err = VerifyCutover(context.Background(), bg, opts)
+	assert.ErrorContains(t, err, "X-Version header missing")
+
+	versioned := func(v string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("X-Version", v)
+			w.Write([]byte(good))
+		}))
+	}
+	green2, blue2 := versioned("v2"), versioned("v1")
+	defer green2.Close()
+	defer blue2.Close()
+	bg = BlueGreen{Candidate: battery.NewTarget(green2.URL), Live: battery.NewTarget(blue2.URL), Public: battery.NewTarget(green2.URL)}
+	report, err := VerifyCutover(context.Background(), bg, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", report.CandidateVersion)
+	assert.True(t, called)
+}
+
+func TestVerifyCutoverRejectsFailingCandidate(t *testing.T) {
+	broken, blue := page("<h1>Oops</h1>"), page(good)
+	defer broken.Close()
+	defer blue.Close()
+
+	called := false
+	bg := BlueGreen{Candidate: battery.NewTarget(broken.URL), Live: battery.NewTarget(blue.URL), Public: battery.NewTarget(blue.URL)}
+	_, err := VerifyCutover(context.Background(), bg, CutoverOptions{
+		Checks: []battery.Check{{Name: "content", Run: battery.CheckContent}},
+		Switch: func(context.Context) error { called = true; return nil },
+	})
+	assert.ErrorContains(t, err, "not switching traffic")
+	assert.False(t, called)
+}
+
+func TestShellHook(t *testing.T) {
+	assert.Nil(t, ShellHook(""))
+	assert.NoError(t, ShellHook("true")(context.Background()))
+	assert.ErrorContains(t, ShellHook("echo nope >&2; exit 3")(context.Background()), "nope")
+	shell, flag := shellFor("linux")
+	assert.Equal(t, []string{"sh", "-c"}, []string{shell, flag})
+}
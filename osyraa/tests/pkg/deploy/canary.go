@@ -0,0 +1,99 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+)
+
+// VersionSource extracts the version that served a response
+type VersionSource func(resp *http.Response, body []byte) (string, error)
+
+// VersionHeader reads the version from a response header
+func VersionHeader(name string) VersionSource {
+	return func(resp *http.Response, _ []byte) (string, error) {
+		if v := resp.Header.Get(name); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("%s header missing", name)
+	}
+}
+
+// BuildIDMeta reads the build ID the layout embeds as <meta name="build-id">
+func BuildIDMeta(_ *http.Response, body []byte) (string, error) {
+	doc, err := match.ParseBytes(body)
+	if err != nil {
+		return "", err
+	}
+	if id, ok := doc.Select(`meta[name="build-id"]`).Attr("content"); ok && id != "" {
+		return id, nil
+	}
+	return "", errors.New(`no <meta name="build-id"> in page`)
+}
+
+// SampleVersions fetches the home page n times and counts the versions seen.
+// Reused connections can pin a client to one backend, so target's client
+// should disable keep-alives.
+func SampleVersions(ctx context.Context, target *battery.Target, n int, source VersionSource) (map[string]int, error) {
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		resp, body, err := target.Get(ctx, "/")
+		if err != nil {
+			return counts, err
+		}
+		version, err := source(resp, body)
+		if err != nil {
+			return counts, fmt.Errorf("sample %d: %w", i+1, err)
+		}
+		counts[version]++
+	}
+	return counts, nil
+}
+
+// CheckSplit compares observed version counts with the configured split
+// (version to expected fraction). A zero tolerance allows three standard
+// deviations of sampling noise, which shrinks as the sample grows.
+func CheckSplit(counts map[string]int, split map[string]float64, tolerance float64) error {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return errors.New("no samples")
+	}
+
+	var errs []error
+	versions := make([]string, 0, len(split))
+	for v := range split {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	for _, v := range versions {
+		want := split[v]
+		got := float64(counts[v]) / float64(total)
+		allowed := tolerance
+		if allowed == 0 {
+			allowed = 3 * math.Sqrt(want*(1-want)/float64(total))
+		}
+		if math.Abs(got-want) > allowed {
+			errs = append(errs, fmt.Errorf("%s served %.1f%% of %d requests, want %.1f%% ± %.1f%%", v, got*100, total, want*100, allowed*100))
+		}
+	}
+	var unexpected []string
+	for v := range counts {
+		if _, ok := split[v]; !ok {
+			unexpected = append(unexpected, v)
+		}
+	}
+	sort.Strings(unexpected)
+	for _, v := range unexpected {
+		errs = append(errs, fmt.Errorf("unexpected version %s served %d of %d requests", v, counts[v], total))
+	}
+	return errors.Join(errs...)
+}
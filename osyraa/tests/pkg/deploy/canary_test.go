@@ -0,0 +1,51 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleVersions(t *testing.T) {
+	var n atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		id := "stable"
+		if n.Add(1)%4 == 0 {
+			id = "canary"
+		}
+		w.Header().Set("X-Version", id)
+		fmt.Fprintf(w, `<html><head><meta name="build-id" content="%s"></head></html>`, id)
+	}))
+	defer srv.Close()
+
+	for _, source := range []VersionSource{VersionHeader("X-Version"), BuildIDMeta} {
+		n.Store(0)
+		counts, err := SampleVersions(context.Background(), battery.NewTarget(srv.URL), 100, source)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"stable": 75, "canary": 25}, counts)
+	}
+
+	_, err := SampleVersions(context.Background(), battery.NewTarget(srv.URL), 1, VersionHeader("X-Missing"))
+	assert.ErrorContains(t, err, "X-Missing header missing")
+}
+
+func TestCheckSplit(t *testing.T) {
+	split := map[string]float64{"stable": 0.9, "canary": 0.1}
+
+	assert.NoError(t, CheckSplit(map[string]int{"stable": 88, "canary": 12}, split, 0))
+	assert.ErrorContains(t, CheckSplit(map[string]int{"stable": 50, "canary": 50}, split, 0), "canary served 50.0%")
+	assert.NoError(t, CheckSplit(map[string]int{"stable": 80, "canary": 20}, split, 0.15))
+	assert.ErrorContains(t, CheckSplit(map[string]int{"stable": 90, "canary": 9, "old": 1}, split, 0.05), "unexpected version old")
+	assert.EqualError(t, CheckSplit(map[string]int{"stable": 90, "canary": 10, "zeta": 1, "alpha": 1, "mid": 1}, split, 1),
+		"unexpected version alpha served 1 of 103 requests\n"+
+			"unexpected version mid served 1 of 103 requests\n"+
+			"unexpected version zeta served 1 of 103 requests")
+	assert.Error(t, CheckSplit(nil, split, 0))
+}
@@ -0,0 +1,214 @@
+package deploy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+)
+
+// maxHops bounds how far CheckRedirects follows a chain before giving up
+const maxHops = 10
+
+// CanonicalPath is the one URL path a file of the built tree is served
+// at. Hugo's pretty URLs set the slash policy: a directory's index.html is
+// the directory with a trailing slash, and every other file has none.
+func CanonicalPath(file string) string {
+	if file == "index.html" {
+		return "/"
+	}
+	if dir, ok := strings.CutSuffix(file, "/index.html"); ok {
+		return "/" + dir + "/"
+	}
+	return "/" + file
+}
+
+// variant is the other slash form of a canonical path, or "" for the root
+func variant(canonical string) string {
+	switch {
+	case canonical == "/":
+		return ""
+	case strings.HasSuffix(canonical, "/"):
+		return strings.TrimSuffix(canonical, "/")
+	default:
+		return canonical + "/"
+	}
+}
+
+// CheckRedirects requests every file of the built tree at its canonical
+// path and at the other slash form. The canonical path must be served
+// without a redirect. A directory's slashless form must redirect to it in
+// exactly one hop. A file's slashed form may redirect to it in one hop or
+// serve something else, such as a 404 or the SPA fallback, but never the
+// file itself. Any redirect chain longer than one hop, or looping, is a
+// problem.
+func CheckRedirects(ctx context.Context, t *battery.Target, local map[string]LocalFile) ([]string, error) {
+	// Redirects are followed by hand, one hop at a time
+	client := *t.Client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	files := make([]string, 0, len(local))
+	for p := range local {
+		files = append(files, p)
+	}
+	sort.Strings(files)
+
+	var problems []string
+	for _, file := range files {
+		canonical := CanonicalPath(file)
+		r, err := follow(ctx, &client, t.BaseURL, t.BaseURL+canonical)
+		if err != nil {
+			return problems, err
+		}
+		if len(r.hops) > 0 {
+			problems = append(problems, fmt.Sprintf("%s redirects to %s, want it served directly", canonical, short(t.BaseURL, r.hops[0])))
+		} else if r.status != http.StatusOK {
+			problems = append(problems, fmt.Sprintf("%s returned %d", canonical, r.status))
+		}
+
+		other := variant(canonical)
+		if other == "" {
+			continue
+		}
+		if r, err = follow(ctx, &client, t.BaseURL, t.BaseURL+other); err != nil {
+			return problems, err
+		}
+		if p := checkVariant(t.BaseURL, other, canonical, r, local[file]); p != "" {
+			problems = append(problems, p)
+		}
+	}
+	return problems, nil
+}
+
+func checkVariant(base, other, canonical string, r redirects, file LocalFile) string {
+	switch {
+	case r.loop:
+		return fmt.Sprintf("%s is a redirect loop: %s", other, chain(base, other, r.hops))
+	case len(r.hops) > maxHops:
+		return fmt.Sprintf("%s redirects more than %d times: %s ...", other, maxHops, chain(base, other, r.hops[:3]))
+	case len(r.hops) > 1:
+		return fmt.Sprintf("%s takes %d redirects (%s), want one to %s", other, len(r.hops), chain(base, other, r.hops), canonical)
+	case len(r.hops) == 1 && r.hops[0] != base+canonical:
+		return fmt.Sprintf("%s redirects to %s, want %s", other, short(base, r.hops[0]), canonical)
+	case len(r.hops) == 1:
+		return ""
+	case strings.HasSuffix(canonical, "/"):
+		return fmt.Sprintf("%s returned %d, want a redirect to %s", other, r.status, canonical)
+	case r.status == http.StatusOK && sha(r.body) == file.SHA256:
+		return fmt.Sprintf("%s serves the same content as %s; it should redirect or not be found", other, canonical)
+	}
+	return ""
+}
+
+// redirects is where following a URL led
+type redirects struct {
+	// hops are the resolved locations in order
+	hops []string
+	// status and body are the last response's
+	status int
+	body   []byte
+	// loop is set when the last hop was already visited
+	loop bool
+}
+
+// follow requests u and each redirect it leads to, stopping at a location
+// seen before, one off base, or after maxHops
+func follow(ctx context.Context, client *http.Client, base, u string) (redirects, error) {
+	var r redirects
+	seen := map[string]bool{u: true}
+	for {
+		status, location, body, err := get(ctx, client, u)
+		if err != nil {
+			return r, err
+		}
+		r.status, r.body = status, body
+		if status < 300 || status >= 400 || location == "" {
+			return r, nil
+		}
+		from, err := url.Parse(u)
+		if err != nil {
+			return r, err
+		}
+		next, err := from.Parse(location)
+		if err != nil {
+			return r, fmt.Errorf("%s: bad Location %q: %w", u, location, err)
+		}
+		u = next.String()
+		r.hops = append(r.hops, u)
+		if seen[u] {
+			r.loop = true
+			return r, nil
+		}
+		if len(r.hops) > maxHops || !strings.HasPrefix(u, base+"/") {
+			return r, nil
+		}
+		seen[u] = true
+	}
+}
+
+// chain renders a redirect chain, by path where it stays on base
+func chain(base, from string, hops []string) string {
+	parts := []string{from}
+	for _, h := range hops {
+		parts = append(parts, short(base, h))
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// short is u's path when u is on base, so problems read like the site's
+// own links; a redirect to another host or port keeps the full URL
+func short(base, u string) string {
+	if rest, ok := strings.CutPrefix(u, base); ok && strings.HasPrefix(rest, "/") {
+		return rest
+	}
+	return u
+}
+
+func sha(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// NonCanonicalLinks lists same-site links on pages that point at the
+// other slash form of a file in the built tree, each of which costs
+// visitors a redirect
+func NonCanonicalLinks(pages []*crawl.Page, local map[string]LocalFile) []string {
+	variants := map[string]string{}
+	for file := range local {
+		canonical := CanonicalPath(file)
+		if other := variant(canonical); other != "" {
+			variants[other] = canonical
+		}
+	}
+	var problems []string
+	seen := map[string]bool{}
+	for _, page := range pages {
+		for _, link := range page.Links {
+			if link.URL == nil || link.URL.Host != page.URL.Host {
+				continue
+			}
+			p := path.Clean("/" + link.URL.Path)
+			if strings.HasSuffix(link.URL.Path, "/") && p != "/" {
+				p += "/"
+			}
+			canonical, ok := variants[p]
+			if !ok {
+				continue
+			}
+			problem := fmt.Sprintf("%s links to %s, want %s", page.URL.Path, link.URL.Path, canonical)
+			if !seen[problem] {
+				seen[problem] = true
+				problems = append(problems, problem)
+			}
+		}
+	}
+	return problems
+}
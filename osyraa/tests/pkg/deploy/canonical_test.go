@@ -0,0 +1,110 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalPath(t *testing.T) {
+	assert.Equal(t, "/", CanonicalPath("index.html"))
+	assert.Equal(t, "/about/", CanonicalPath("about/index.html"))
+	assert.Equal(t, "/resume.json", CanonicalPath("resume.json"))
+	assert.Equal(t, "/css/site.css", CanonicalPath("css/site.css"))
+	assert.Equal(t, "/about", variant("/about/"))
+	assert.Equal(t, "/resume.json/", variant("/resume.json"))
+	assert.Equal(t, "", variant("/"))
+}
+
+// canonicalSite writes a small built tree and returns it with its hashes
+func canonicalSite(t *testing.T) (string, map[string]LocalFile) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "about"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte(`<a href="/about">About</a> <a href="/resume.json">JSON</a>`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "about", "index.html"), []byte(`<a href="/">Home</a> <a href="/resume.json/">JSON</a>`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "resume.json"), []byte(`{}`), 0o644))
+	local, err := LocalTree(dir)
+	require.NoError(t, err)
+	return dir, local
+}
+
+func TestCheckRedirects(t *testing.T) {
+	dir, local := canonicalSite(t)
+	// http.FileServer follows the same slash policy as nginx: one relative
+	// redirect from /about to /about/ and from /resume.json/ to /resume.json
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+	problems, err := CheckRedirects(context.Background(), battery.NewTarget(srv.URL), local)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	files := http.FileServer(http.Dir(dir))
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/about":
+			http.Redirect(w, r, "/about-us", http.StatusMovedPermanently)
+		case "/resume.json":
+			// Off-site redirects keep the full URL in the problem
+			http.Redirect(w, r, "https://cdn.example.com/resume.json", http.StatusFound)
+		case "/about-us":
+			http.Redirect(w, r, "/about/", http.StatusMovedPermanently)
+		case "/resume.json/":
+			http.ServeFile(w, r, filepath.Join(dir, "resume.json"))
+		case "/":
+			http.Redirect(w, r, "/en/", http.StatusFound)
+		case "/en/":
+			http.Redirect(w, r, "/", http.StatusFound)
+		default:
+			files.ServeHTTP(w, r)
+		}
+	}))
+	defer bad.Close()
+	problems, err = CheckRedirects(context.Background(), battery.NewTarget(bad.URL), local)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/about takes 2 redirects (/about -> /about-us -> /about/), want one to /about/",
+		"/ redirects to /en/, want it served directly",
+		"/resume.json redirects to https://cdn.example.com/resume.json, want it served directly",
+		"/resume.json/ serves the same content as /resume.json; it should redirect or not be found",
+	}, problems)
+}
+
+func TestCheckRedirectsLoop(t *testing.T) {
+	_, local := canonicalSite(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/about":
+			http.Redirect(w, r, "/about/", http.StatusMovedPermanently)
+		case "/about/":
+			http.Redirect(w, r, "/about", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	problems, err := CheckRedirects(context.Background(), battery.NewTarget(srv.URL), local)
+	require.NoError(t, err)
+	assert.Contains(t, problems, "/about is a redirect loop: /about -> /about/ -> /about")
+	assert.Contains(t, problems, "/about/ redirects to /about, want it served directly")
+}
+
+func TestNonCanonicalLinks(t *testing.T) {
+	dir, local := canonicalSite(t)
+	start, err := url.Parse("https://resume.example.com/")
+	require.NoError(t, err)
+	site, err := crawl.New(crawl.NewDirFetcher(dir)).Run(context.Background(), start)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"/ links to /about, want /about/",
+		"/about links to /resume.json/, want /resume.json",
+	}, NonCanonicalLinks(site.Pages, local))
+}
@@ -0,0 +1,91 @@
+// Package deploy verifies a site after it has been deployed to a hosting
+// platform and rolls the platform back when the new revision is bad.
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// Platform is a hosting platform that tracks revisions of the service
+type Platform interface {
+	Name() string
+	// Revisions returns known revisions, newest first; the first entry is
+	// the one currently receiving traffic
+	Revisions(ctx context.Context) ([]string, error)
+	// Rollback routes all traffic to revision
+	Rollback(ctx context.Context, revision string) error
+}
+
+// SmokeOptions control a post-deploy smoke test
+type SmokeOptions struct {
+	Checks            []battery.Check
+	RollbackOnFailure bool
+}
+
+// SmokeReport is the outcome of a smoke test
+type SmokeReport struct {
+	Revision   string
+	Results    []battery.Result
+	RolledBack string
+	// PostRollback holds the endpoint checks run after a rollback
+	PostRollback []battery.Result
+}
+
+// Failed reports whether the deployed revision failed any check
+func (r *SmokeReport) Failed() bool {
+	return len(battery.Failed(r.Results)) > 0
+}
+
+// Smoke runs the battery against the deployed service. When checks fail and
+// RollbackOnFailure is set, traffic is moved to the previous revision and the
+// service is checked again so the rollback itself is verified.
+func Smoke(ctx context.Context, target *battery.Target, p Platform, opts SmokeOptions) (*SmokeReport, error) {
+	if opts.Checks == nil {
+		opts.Checks = battery.Default()
+	}
+
+	revisions, err := p.Revisions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: list revisions: %w", p.Name(), err)
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%s: no revisions found", p.Name())
+	}
+
+	report := &SmokeReport{Revision: revisions[0]}
+	report.Results = battery.Run(ctx, target, opts.Checks)
+	if !report.Failed() || !opts.RollbackOnFailure {
+		return report, nil
+	}
+
+	if len(revisions) < 2 {
+		return report, fmt.Errorf("%s: revision %s failed and there is no previous revision to roll back to", p.Name(), revisions[0])
+	}
+	previous := revisions[1]
+	if err := p.Rollback(ctx, previous); err != nil {
+		return report, fmt.Errorf("%s: rollback to %s: %w", p.Name(), previous, err)
+	}
+	report.RolledBack = previous
+
+	current, err := p.Revisions(ctx)
+	if err != nil {
+		return report, fmt.Errorf("%s: list revisions after rollback: %w", p.Name(), err)
+	}
+	if len(current) == 0 || current[0] != previous {
+		return report, fmt.Errorf("%s: rollback did not take effect, serving %v", p.Name(), current)
+	}
+
+	// The old revision predates the build under test, so only check that it serves
+	report.PostRollback = battery.Run(ctx, target, []battery.Check{
+		{Name: "endpoint", Run: battery.CheckEndpoint},
+		{Name: "content", Run: battery.CheckContent},
+	})
+	if failed := battery.Failed(report.PostRollback); len(failed) > 0 {
+		return report, errors.Join(errors.New("previous revision is not healthy after rollback"), failed[0].Err)
+	}
+	return report, nil
+}
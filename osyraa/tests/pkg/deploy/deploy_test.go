@@ -0,0 +1,72 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePlatform serves a page per revision and supports rollback
+type fakePlatform struct {
+	revisions []string
+	pages     map[string]string
+}
+
+func (f *fakePlatform) Name() string { return "fake" }
+
+func (f *fakePlatform) Revisions(context.Context) ([]string, error) { return f.revisions, nil }
+
+func (f *fakePlatform) Rollback(_ context.Context, rev string) error {
+	f.revisions = moveToFront(f.revisions, rev)
+	return nil
+}
+
+func (f *fakePlatform) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Write([]byte(f.pages[f.revisions[0]]))
+}
+
+const good = `<html><body><h1>Princeton A. Strong</h1></body></html>`
+
+func TestSmokeRollsBackBadRevision(t *testing.T) {
+	p := &fakePlatform{
+		revisions: []string{"rev-2", "rev-1"},
+		pages:     map[string]string{"rev-2": "<h1>Oops</h1>", "rev-1": good},
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	checks := []battery.Check{{Name: "content", Run: battery.CheckContent}}
+	report, err := Smoke(context.Background(), battery.NewTarget(srv.URL), p, SmokeOptions{Checks: checks, RollbackOnFailure: true})
+	require.NoError(t, err)
+
+	assert.True(t, report.Failed())
+	assert.Equal(t, "rev-2", report.Revision)
+	assert.Equal(t, "rev-1", report.RolledBack)
+	assert.Empty(t, battery.Failed(report.PostRollback))
+}
+
+func TestSmokeWithoutRollback(t *testing.T) {
+	p := &fakePlatform{revisions: []string{"rev-1"}, pages: map[string]string{"rev-1": good}}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	checks := []battery.Check{{Name: "content", Run: battery.CheckContent}}
+	report, err := Smoke(context.Background(), battery.NewTarget(srv.URL), p, SmokeOptions{Checks: checks, RollbackOnFailure: true})
+	require.NoError(t, err)
+	assert.False(t, report.Failed())
+	assert.Empty(t, report.RolledBack)
+
+	p.pages["rev-1"] = "broken"
+	_, err = Smoke(context.Background(), battery.NewTarget(srv.URL), p, SmokeOptions{Checks: checks, RollbackOnFailure: true})
+	assert.ErrorContains(t, err, "no previous revision")
+}
+
+func TestMoveToFront(t *testing.T) {
+	assert.Equal(t, []string{"b", "a", "c"}, moveToFront([]string{"a", "b", "c"}, "b"))
+	assert.Equal(t, []string{"a", "b"}, moveToFront([]string{"a", "b"}, ""))
+}
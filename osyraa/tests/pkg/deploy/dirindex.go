@@ -0,0 +1,114 @@
+package deploy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// listingRe matches the directory listings of nginx autoindex, Apache and
+// common static servers
+var listingRe = regexp.MustCompile(`(?i)<title>\s*(Index of|Directory listing for) `)
+
+// Directories returns every directory of a built tree, "" being the root
+func Directories(local map[string]LocalFile) []string {
+	seen := map[string]bool{"": true}
+	for p := range local {
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			seen[dir] = true
+		}
+	}
+	dirs := make([]string, 0, len(seen))
+	for d := range seen {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// CheckDirectories requests every directory of the built tree with and
+// without a trailing slash. Directories with an index.html must serve it,
+// and a slashless request may only redirect to the slashed path on the same
+// host and port. No directory may return a listing.
+func CheckDirectories(ctx context.Context, t *battery.Target, local map[string]LocalFile) ([]string, error) {
+	// Redirects are inspected, not followed
+	client := *t.Client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	var problems []string
+	for _, dir := range Directories(local) {
+		index, hasIndex := local[path.Join(dir, "index.html")]
+		slashed := "/"
+		if dir != "" {
+			slashed = "/" + dir + "/"
+		}
+
+		status, location, body, err := get(ctx, &client, t.BaseURL+slashed)
+		if err != nil {
+			return problems, err
+		}
+		problems = append(problems, checkIndex(slashed, status, body, index, hasIndex)...)
+
+		if dir == "" {
+			continue
+		}
+		bare := strings.TrimSuffix(slashed, "/")
+		status, location, body, err = get(ctx, &client, t.BaseURL+bare)
+		if err != nil {
+			return problems, err
+		}
+		switch {
+		case status >= 300 && status < 400:
+			want, _ := url.Parse(t.BaseURL + slashed)
+			base, _ := url.Parse(t.BaseURL + bare)
+			got, err := base.Parse(location)
+			if err != nil || got.String() != want.String() {
+				problems = append(problems, fmt.Sprintf("%s redirects to %q, want %s", bare, location, want))
+			}
+		default:
+			problems = append(problems, checkIndex(bare, status, body, index, hasIndex)...)
+		}
+	}
+	return problems, nil
+}
+
+func checkIndex(p string, status int, body []byte, index LocalFile, hasIndex bool) []string {
+	if listingRe.Match(body) {
+		return []string{fmt.Sprintf("%s returns a directory listing", p)}
+	}
+	if !hasIndex {
+		return nil
+	}
+	if status != http.StatusOK {
+		return []string{fmt.Sprintf("%s returned %d, want its index.html", p, status)}
+	}
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != index.SHA256 {
+		return []string{fmt.Sprintf("%s does not serve %s", p, index.Path)}
+	}
+	return nil
+}
+
+func get(ctx context.Context, client *http.Client, u string) (status int, location string, body []byte, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header.Get("Location"), body, err
+}
@@ -0,0 +1,55 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDirectories(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "about"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte(good), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "about", "index.html"), []byte("<p>about</p>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "css", "site.css"), []byte("body{}"), 0o644))
+	local, err := LocalTree(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "about", "css"}, Directories(local))
+
+	var absoluteRedirect, autoindex bool
+	files := http.FileServer(http.Dir(dir))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/about" && absoluteRedirect:
+			// nginx's default absolute_redirect drops the published port
+			http.Redirect(w, r, "http://"+r.Host[:len("127.0.0.1")]+"/about/", http.StatusMovedPermanently)
+		case r.URL.Path == "/css/" && autoindex:
+			w.Write([]byte("<html><head><title>Index of /css/</title></head><body><a href=\"site.css\">site.css</a></body></html>"))
+		case r.URL.Path == "/css/" || r.URL.Path == "/css":
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			files.ServeHTTP(w, r)
+		}
+	}))
+	defer srv.Close()
+	target := battery.NewTarget(srv.URL)
+
+	problems, err := CheckDirectories(context.Background(), target, local)
+	require.NoError(t, err)
+	assert.Empty(t, problems, "relative redirects and 403 on index-less directories are fine")
+
+	absoluteRedirect, autoindex = true, true
+	problems, err = CheckDirectories(context.Background(), target, local)
+	require.NoError(t, err)
+	assert.Len(t, problems, 2)
+	assert.Contains(t, problems[0], `/about redirects to "http://127.0.0.1/about/"`)
+	assert.Equal(t, "/css/ returns a directory listing", problems[1])
+}
@@ -0,0 +1,100 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// RollbackDrill proves the failure path: a deliberately broken version is
+// deployed, the battery must notice, and the rollback hook must restore the
+// version that was serving before
+type RollbackDrill struct {
+	Target *battery.Target
+	Checks []battery.Check
+	// Break deploys the broken version
+	Break Hook
+	// Rollback is the configured rollback under test
+	Rollback Hook
+	// Timeout bounds each wait, for detection and for recovery
+	Timeout time.Duration
+	// Interval between polls of the target
+	Interval time.Duration
+}
+
+// DrillReport is the outcome of a rollback drill
+type DrillReport struct {
+	BaselineHash string
+	// Detected holds the failed checks that revealed the broken version
+	Detected      []battery.Result
+	DetectedAfter time.Duration
+	RestoredAfter time.Duration
+}
+
+// Run performs the drill. The target must pass the battery beforehand,
+// otherwise a failure can't be attributed to the broken deploy.
+func (d *RollbackDrill) Run(ctx context.Context) (*DrillReport, error) {
+	if d.Checks == nil {
+		d.Checks = battery.Default()
+	}
+	if d.Timeout == 0 {
+		d.Timeout = 5 * time.Minute
+	}
+	if d.Interval == 0 {
+		d.Interval = 2 * time.Second
+	}
+
+	report := &DrillReport{}
+	if failed := battery.Failed(battery.Run(ctx, d.Target, d.Checks)); len(failed) > 0 {
+		return nil, errors.Join(errors.New("target is unhealthy before the drill"), failed[0].Err)
+	}
+	var err error
+	if report.BaselineHash, err = homeVersion(ctx, d.Target, nil); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if err := d.Break(ctx); err != nil {
+		return report, fmt.Errorf("deploy broken version: %w", err)
+	}
+	err = d.poll(ctx, func() bool {
+		report.Detected = battery.Failed(battery.Run(ctx, d.Target, d.Checks))
+		return len(report.Detected) > 0
+	})
+	if err != nil {
+		return report, fmt.Errorf("battery did not detect the broken version: %w", err)
+	}
+	report.DetectedAfter = time.Since(start)
+
+	start = time.Now()
+	if err := d.Rollback(ctx); err != nil {
+		return report, fmt.Errorf("rollback hook: %w", err)
+	}
+	err = d.poll(ctx, func() bool {
+		hash, err := homeVersion(ctx, d.Target, nil)
+		return err == nil && hash == report.BaselineHash &&
+			len(battery.Failed(battery.Run(ctx, d.Target, d.Checks))) == 0
+	})
+	if err != nil {
+		return report, fmt.Errorf("previous version is not serving after rollback: %w", err)
+	}
+	report.RestoredAfter = time.Since(start)
+	return report, nil
+}
+
+// poll calls done every Interval until it returns true or Timeout passes
+func (d *RollbackDrill) poll(ctx context.Context, done func() bool) error {
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+	for !done() {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after %v: %w", d.Timeout, ctx.Err())
+		case <-time.After(d.Interval):
+		}
+	}
+	return nil
+}
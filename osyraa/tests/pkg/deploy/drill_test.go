@@ -0,0 +1,63 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackDrill(t *testing.T) {
+	var broken atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if broken.Load() {
+			w.Write([]byte("<h1>Oops</h1>"))
+			return
+		}
+		w.Write([]byte(good))
+	}))
+	defer srv.Close()
+
+	checks := []battery.Check{{Name: "content", Run: battery.CheckContent}}
+	drill := &RollbackDrill{
+		Target:   battery.NewTarget(srv.URL),
+		Checks:   checks,
+		Break:    func(context.Context) error { broken.Store(true); return nil },
+		Rollback: func(context.Context) error { broken.Store(false); return nil },
+		Interval: 10 * time.Millisecond,
+	}
+	report, err := drill.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, report.Detected, 1)
+	assert.Equal(t, "content", report.Detected[0].Check)
+	assert.Equal(t, battery.ContentHash([]byte(good)), report.BaselineHash)
+}
+
+func TestRollbackDrillFailsWhenRollbackDoesNothing(t *testing.T) {
+	var broken atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if broken.Load() {
+			w.Write([]byte("<h1>Oops</h1>"))
+			return
+		}
+		w.Write([]byte(good))
+	}))
+	defer srv.Close()
+
+	drill := &RollbackDrill{
+		Target:   battery.NewTarget(srv.URL),
+		Checks:   []battery.Check{{Name: "content", Run: battery.CheckContent}},
+		Break:    func(context.Context) error { broken.Store(true); return nil },
+		Rollback: func(context.Context) error { return nil },
+		Timeout:  50 * time.Millisecond,
+		Interval: 10 * time.Millisecond,
+	}
+	_, err := drill.Run(context.Background())
+	assert.ErrorContains(t, err, "not serving after rollback")
+}
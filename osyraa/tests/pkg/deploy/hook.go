@@ -0,0 +1,33 @@
+package deploy
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+)
+
+// Hook is an operator-supplied action such as a traffic switch or rollback
+type Hook func(ctx context.Context) error
+
+// ShellHook runs script with sh -c, so hooks can be configured from env vars
+// or CI settings without code changes. On Windows without sh in PATH (Git
+// for Windows provides one) it runs with cmd /C. An empty script yields a
+// nil hook.
+func ShellHook(script string) Hook {
+	if script == "" {
+		return nil
+	}
+	return func(ctx context.Context) error {
+		shell, flag := shellFor(runtime.GOOS)
+		_, err := command(ctx, shell, flag, script)
+		return err
+	}
+}
+
+// shellFor picks the shell hooks run with on goos
+func shellFor(goos string) (shell, flag string) {
+	if _, err := exec.LookPath("sh"); err != nil && goos == "windows" {
+		return "cmd", "/C"
+	}
+	return "sh", "-c"
+}
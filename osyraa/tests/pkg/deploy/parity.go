@@ -0,0 +1,87 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// ParityReport compares a remote deployment with the local build page by page
+type ParityReport struct {
+	// Missing lists local files the deployment does not serve
+	Missing []string
+	// Stale lists files served with a body different from the local build
+	Stale []string
+	// Headers maps paths to the header differences the host imposes, such as
+	// security headers it cannot set or a different Content-Type
+	Headers map[string][]string
+}
+
+// Clean reports whether every local file is served unchanged; header
+// differences are informational since static hosts rarely allow custom headers
+func (r *ParityReport) Clean() bool {
+	return len(r.Missing) == 0 && len(r.Stale) == 0
+}
+
+// Parity fetches every file of the local build from the target and compares
+// status, body hash and headers against the target's expectations
+func Parity(ctx context.Context, target *battery.Target, local map[string]LocalFile) (*ParityReport, error) {
+	paths := make([]string, 0, len(local))
+	for p := range local {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	report := &ParityReport{Headers: map[string][]string{}}
+	for _, p := range paths {
+		resp, body, err := target.Get(ctx, "/"+p)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", p, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			report.Missing = append(report.Missing, p)
+			continue
+		}
+		if battery.ContentHash(body) != local[p].SHA256 {
+			report.Stale = append(report.Stale, p)
+		}
+		if diffs := headerDiffs(p, resp.Header, target.Expect.Headers); len(diffs) > 0 {
+			report.Headers[p] = diffs
+		}
+	}
+	return report, nil
+}
+
+// headerDiffs lists how served headers differ from the expected ones
+func headerDiffs(p string, got http.Header, want map[string]string) []string {
+	var diffs []string
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch v := got.Get(name); {
+		case v == "":
+			diffs = append(diffs, fmt.Sprintf("%s missing", name))
+		case want[name] != "" && v != want[name]:
+			diffs = append(diffs, fmt.Sprintf("%s is %q, want %q", name, v, want[name]))
+		}
+	}
+	if ext := path.Ext(p); ext != "" {
+		wantType, _, _ := mime.ParseMediaType(mime.TypeByExtension(ext))
+		gotType, _, _ := mime.ParseMediaType(got.Get("Content-Type"))
+		if wantType != "" && gotType != wantType {
+			diffs = append(diffs, fmt.Sprintf("Content-Type is %q, want %q", gotType, wantType))
+		}
+	}
+	if cc := got.Get("Cache-Control"); cc != "" {
+		diffs = append(diffs, fmt.Sprintf("Cache-Control imposed as %q", cc))
+	}
+	return diffs
+}
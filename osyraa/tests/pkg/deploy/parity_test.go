@@ -0,0 +1,56 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParity(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":     good,
+		"css/site.css":   "body{}",
+		"about/new.html": "<p>new</p>",
+	}
+	for name, body := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+	}
+	local, err := LocalTree(dir)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=600")
+		switch r.URL.Path {
+		case "/index.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-XSS-Protection", "1")
+			w.Write([]byte(good))
+		case "/css/site.css":
+			w.Header().Set("Content-Type", "text/css")
+			w.Write([]byte("body{color:red}"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	report, err := Parity(context.Background(), battery.NewTarget(srv.URL), local)
+	require.NoError(t, err)
+
+	assert.False(t, report.Clean())
+	assert.Equal(t, []string{"about/new.html"}, report.Missing)
+	assert.Equal(t, []string{"css/site.css"}, report.Stale)
+	assert.Equal(t, []string{`Cache-Control imposed as "max-age=600"`}, report.Headers["index.html"])
+	assert.Contains(t, report.Headers["css/site.css"], "X-Frame-Options missing")
+}
@@ -0,0 +1,119 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CloudRun manages a Cloud Run service through the gcloud CLI, which picks up
+// credentials from the usual gcloud auth or GOOGLE_APPLICATION_CREDENTIALS
+type CloudRun struct {
+	Service string
+	Region  string
+	Project string
+}
+
+// Name identifies the platform in reports
+func (c *CloudRun) Name() string { return "cloudrun" }
+
+func (c *CloudRun) args(args ...string) []string {
+	args = append(args, "--region", c.Region)
+	if c.Project != "" {
+		args = append(args, "--project", c.Project)
+	}
+	return args
+}
+
+// Revisions lists revisions by creation time, moving the one serving all
+// traffic to the front
+func (c *CloudRun) Revisions(ctx context.Context) ([]string, error) {
+	out, err := command(ctx, "gcloud", c.args("run", "revisions", "list",
+		"--service", c.Service, "--sort-by", "~metadata.creationTimestamp",
+		"--format", "value(metadata.name)")...)
+	if err != nil {
+		return nil, err
+	}
+	revisions := strings.Fields(string(out))
+
+	serving, err := command(ctx, "gcloud", c.args("run", "services", "describe", c.Service,
+		"--format", "value(status.traffic[0].revisionName)")...)
+	if err != nil {
+		return nil, err
+	}
+	return moveToFront(revisions, strings.TrimSpace(string(serving))), nil
+}
+
+// Rollback sends 100% of traffic to revision
+func (c *CloudRun) Rollback(ctx context.Context, revision string) error {
+	_, err := command(ctx, "gcloud", c.args("run", "services", "update-traffic", c.Service,
+		"--to-revisions", revision+"=100")...)
+	return err
+}
+
+// Fly manages a Fly.io app through flyctl, authenticated via FLY_API_TOKEN
+type Fly struct {
+	App string
+}
+
+// Name identifies the platform in reports
+func (f *Fly) Name() string { return "fly" }
+
+type flyRelease struct {
+	Version     int    `json:"Version"`
+	Status      string `json:"Status"`
+	ImageRef    string `json:"ImageRef"`
+	Description string `json:"Description"`
+}
+
+// Revisions returns the image references of successful releases, newest first
+func (f *Fly) Revisions(ctx context.Context) ([]string, error) {
+	out, err := command(ctx, "flyctl", "releases", "--app", f.App, "--image", "--json")
+	if err != nil {
+		return nil, err
+	}
+	var releases []flyRelease
+	if err := json.Unmarshal(out, &releases); err != nil {
+		return nil, fmt.Errorf("parse flyctl releases: %w", err)
+	}
+	var revisions []string
+	for _, r := range releases {
+		if r.Status == "complete" || r.Status == "succeeded" {
+			revisions = append(revisions, r.ImageRef)
+		}
+	}
+	return revisions, nil
+}
+
+// Rollback redeploys the app with a previous release's image
+func (f *Fly) Rollback(ctx context.Context, image string) error {
+	_, err := command(ctx, "flyctl", "deploy", "--app", f.App, "--image", image, "--strategy", "immediate")
+	return err
+}
+
+func moveToFront(list []string, item string) []string {
+	out := []string{item}
+	for _, v := range list {
+		if v != item {
+			out = append(out, v)
+		}
+	}
+	if item == "" {
+		return out[1:]
+	}
+	return out
+}
+
+func command(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// PreviewProvider guesses the host of a preview deployment from its URL:
+// "netlify", "vercel" or "" when unknown
+func PreviewProvider(previewURL string) string {
+	u, err := url.Parse(previewURL)
+	if err != nil {
+		return ""
+	}
+	host := u.Hostname()
+	switch {
+	case strings.HasSuffix(host, ".netlify.app"):
+		return "netlify"
+	case strings.HasSuffix(host, ".vercel.app"):
+		return "vercel"
+	}
+	return ""
+}
+
+// PreviewTarget creates a battery target for a preview deployment. bypass is
+// sent as Vercel's deployment protection bypass token when set.
+func PreviewTarget(previewURL, bypass string) *battery.Target {
+	target := battery.NewTarget(previewURL)
+	if bypass != "" && PreviewProvider(previewURL) == "vercel" {
+		target.Client.Transport = &headerTransport{
+			base:   http.DefaultTransport,
+			header: http.Header{"X-Vercel-Protection-Bypass": {bypass}},
+		}
+	}
+	return target
+}
+
+// PreviewReport combines the battery results with the parity diff
+type PreviewReport struct {
+	Provider string
+	Results  []battery.Result
+	Parity   *ParityReport
+}
+
+// Failed reports whether any check failed or the preview differs from the build
+func (r *PreviewReport) Failed() bool {
+	return len(battery.Failed(r.Results)) > 0 || !r.Parity.Clean()
+}
+
+// CheckPreview runs the checks against a preview deployment and diffs it
+// with the local build
+func CheckPreview(ctx context.Context, target *battery.Target, checks []battery.Check, local map[string]LocalFile) (*PreviewReport, error) {
+	report := &PreviewReport{
+		Provider: PreviewProvider(target.BaseURL),
+		Results:  battery.Run(ctx, target, checks),
+	}
+	parity, err := Parity(ctx, target, local)
+	if err != nil {
+		return nil, err
+	}
+	report.Parity = parity
+	return report, nil
+}
+
+// headerTransport adds fixed headers to every request
+type headerTransport struct {
+	base   http.RoundTripper
+	header http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+	return t.base.RoundTrip(req)
+}
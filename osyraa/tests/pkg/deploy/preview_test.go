@@ -0,0 +1,52 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewProvider(t *testing.T) {
+	assert.Equal(t, "netlify", PreviewProvider("https://deploy-preview-12--resume.netlify.app"))
+	assert.Equal(t, "vercel", PreviewProvider("https://resume-git-feature-me.vercel.app/"))
+	assert.Equal(t, "", PreviewProvider("https://example.com"))
+}
+
+func TestPreviewTargetSendsBypass(t *testing.T) {
+	var got string
+	target := PreviewTarget("https://resume-abc.vercel.app", "secret")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Vercel-Protection-Bypass")
+	}))
+	defer srv.Close()
+	target.BaseURL = srv.URL
+
+	_, _, err := target.Get(context.Background(), "/")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got)
+}
+
+func TestCheckPreview(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte(good), 0o644))
+	local, err := LocalTree(dir)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(good))
+	}))
+	defer srv.Close()
+
+	checks := []battery.Check{{Name: "content", Run: battery.CheckContent}}
+	report, err := CheckPreview(context.Background(), battery.NewTarget(srv.URL), checks, local)
+	require.NoError(t, err)
+	assert.False(t, report.Failed())
+	assert.Empty(t, report.Provider)
+}
@@ -0,0 +1,223 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+)
+
+// Purger evicts paths from a CDN's edge caches
+type Purger interface {
+	Purge(ctx context.Context, paths []string) error
+}
+
+// CloudFrontPurger invalidates paths on a CloudFront distribution and waits
+// for the invalidation to complete
+type CloudFrontPurger struct {
+	Site *S3Site
+}
+
+// Purge creates an invalidation for paths
+func (p *CloudFrontPurger) Purge(ctx context.Context, paths []string) error {
+	args := []string{"cloudfront", "create-invalidation", "--distribution-id", p.Site.DistributionID,
+		"--output", "json", "--paths"}
+	for _, path := range paths {
+		args = append(args, "/"+strings.TrimPrefix(path, "/"))
+	}
+	out, err := command(ctx, "aws", args...)
+	if err != nil {
+		return err
+	}
+	var created struct {
+		Invalidation struct {
+			ID string `json:"Id"`
+		} `json:"Invalidation"`
+	}
+	if err := json.Unmarshal(out, &created); err != nil {
+		return fmt.Errorf("parse invalidation: %w", err)
+	}
+	return p.Site.WaitInvalidation(ctx, created.Invalidation.ID, 10*time.Second)
+}
+
+// CloudflarePurger purges URLs through the Cloudflare API
+type CloudflarePurger struct {
+	ZoneID string
+	Token  string
+	// BaseURL is the public site URL the paths are relative to
+	BaseURL string
+	// APIBase defaults to the public Cloudflare API
+	APIBase string
+	Client  *http.Client
+}
+
+// Purge evicts the URLs of paths, 30 at a time as the API allows
+func (p *CloudflarePurger) Purge(ctx context.Context, paths []string) error {
+	api := p.APIBase
+	if api == "" {
+		api = "https://api.cloudflare.com/client/v4"
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	for start := 0; start < len(paths); start += 30 {
+		var files []string
+		for _, path := range paths[start:min(start+30, len(paths))] {
+			files = append(files, strings.TrimSuffix(p.BaseURL, "/")+"/"+strings.TrimPrefix(path, "/"))
+		}
+		body, err := json.Marshal(map[string][]string{"files": files})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, api+"/zones/"+p.ZoneID+"/purge_cache", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		var result struct {
+			Success bool `json:"success"`
+			Errors  []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("cloudflare purge: status %d: %w", resp.StatusCode, err)
+		}
+		if !result.Success {
+			msg := "status " + strconv.Itoa(resp.StatusCode)
+			if len(result.Errors) > 0 {
+				msg = result.Errors[0].Message
+			}
+			return fmt.Errorf("cloudflare purge failed: %s", msg)
+		}
+	}
+	return nil
+}
+
+// WriteManifest saves a build's file hashes so the next deploy can tell
+// which files changed
+func WriteManifest(path string, files map[string]LocalFile) error {
+	data, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ReadManifest loads a manifest written by WriteManifest
+func ReadManifest(path string) (map[string]LocalFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var files map[string]LocalFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return files, nil
+}
+
+// ChangedPaths lists files that are new or differ from the previous build;
+// with no previous build every file counts as changed
+func ChangedPaths(previous, current map[string]LocalFile) []string {
+	var changed []string
+	for p, lf := range current {
+		if old, ok := previous[p]; !ok || old.SHA256 != lf.SHA256 {
+			changed = append(changed, p)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// PropagationOptions control a propagation check
+type PropagationOptions struct {
+	// Purger is called once when stale content is found; nil only waits
+	// for caches to expire
+	Purger   Purger
+	Timeout  time.Duration
+	Interval time.Duration
+}
+
+// PropagationReport is the outcome of a propagation check
+type PropagationReport struct {
+	// Stale lists paths the edge served stale on the first request
+	Stale  []string
+	Purged bool
+	// FreshAfter is how long the edge took to serve every path fresh
+	FreshAfter time.Duration
+}
+
+// VerifyPropagation requests every changed path from the CDN as a normal
+// visitor would, with no cache-busting, purges stale paths when a purger is
+// configured, and polls until the edge serves the new build everywhere
+func VerifyPropagation(ctx context.Context, client *http.Client, cdnURL string, local map[string]LocalFile, paths []string, opts PropagationOptions) (*PropagationReport, error) {
+	if opts.Timeout == 0 {
+		opts.Timeout = 15 * time.Minute
+	}
+	if opts.Interval == 0 {
+		opts.Interval = 15 * time.Second
+	}
+	start := time.Now()
+	report := &PropagationReport{}
+	stale, err := VerifyCDN(ctx, client, cdnURL, local, paths)
+	if err != nil {
+		return nil, err
+	}
+	report.Stale = stale
+	if len(stale) == 0 {
+		return report, nil
+	}
+
+	if opts.Purger != nil {
+		var purge []string
+		for _, p := range stale {
+			purge = append(purge, p)
+			if v := crawl.VisitorPath(p); v != p {
+				purge = append(purge, v)
+			}
+		}
+		if err := opts.Purger.Purge(ctx, purge); err != nil {
+			return report, fmt.Errorf("purge: %w", err)
+		}
+		report.Purged = true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	for len(stale) > 0 {
+		select {
+		case <-ctx.Done():
+			return report, errors.Join(fmt.Errorf("still stale after %v: %v", opts.Timeout, stale), ctx.Err())
+		case <-time.After(opts.Interval):
+		}
+		next, err := VerifyCDN(ctx, client, cdnURL, local, stale)
+		if err != nil {
+			if ctx.Err() != nil {
+				// The deadline hit mid-request; report what is still stale
+				return report, errors.Join(fmt.Errorf("still stale after %v: %v", opts.Timeout, stale), ctx.Err())
+			}
+			return report, err
+		}
+		stale = next
+	}
+	report.FreshAfter = time.Since(start)
+	return report, nil
+}
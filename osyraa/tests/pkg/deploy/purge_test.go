@@ -0,0 +1,90 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type purgerFunc func(paths []string) error
+
+func (f purgerFunc) Purge(_ context.Context, paths []string) error { return f(paths) }
+
+func TestVerifyPropagation(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "about"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte(good), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "about", "index.html"), []byte("<p>new</p>"), 0o644))
+	local, err := LocalTree(dir)
+	require.NoError(t, err)
+
+	var purged atomic.Bool
+	var requested []string
+	edge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch {
+		case r.URL.Path == "/about/" && !purged.Load():
+			w.Write([]byte("<p>old</p>"))
+		case r.URL.Path == "/about/":
+			w.Write([]byte("<p>new</p>"))
+		default:
+			w.Write([]byte(good))
+		}
+	}))
+	defer edge.Close()
+
+	var purgedPaths []string
+	report, err := VerifyPropagation(context.Background(), edge.Client(), edge.URL, local, ChangedPaths(nil, local), PropagationOptions{
+		Purger:   purgerFunc(func(paths []string) error { purgedPaths = paths; purged.Store(true); return nil }),
+		Interval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"about/index.html"}, report.Stale)
+	assert.True(t, report.Purged)
+	assert.Equal(t, []string{"about/index.html", "about/"}, purgedPaths)
+	assert.Contains(t, requested, "/", "home is requested as visitors do")
+
+	purged.Store(false)
+	_, err = VerifyPropagation(context.Background(), edge.Client(), edge.URL, local, []string{"about/index.html"}, PropagationOptions{
+		Timeout: 30 * time.Millisecond, Interval: 10 * time.Millisecond,
+	})
+	assert.ErrorContains(t, err, "still stale")
+}
+
+func TestChangedPathsAndManifest(t *testing.T) {
+	previous := map[string]LocalFile{"index.html": {SHA256: "a"}, "css/site.css": {SHA256: "b"}}
+	current := map[string]LocalFile{"index.html": {SHA256: "a2"}, "css/site.css": {SHA256: "b"}, "new.html": {SHA256: "c"}}
+	assert.Equal(t, []string{"index.html", "new.html"}, ChangedPaths(previous, current))
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, WriteManifest(path, current))
+	loaded, err := ReadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, current, loaded)
+}
+
+func TestCloudflarePurger(t *testing.T) {
+	var got struct {
+		Files []string `json:"files"`
+	}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/zones/zone1/purge_cache", r.URL.Path)
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer api.Close()
+
+	p := &CloudflarePurger{ZoneID: "zone1", Token: "tok", BaseURL: "https://resume.example.com/", APIBase: api.URL}
+	require.NoError(t, p.Purge(context.Background(), []string{"about/", "index.html"}))
+	assert.Equal(t, []string{"https://resume.example.com/about/", "https://resume.example.com/index.html"}, got.Files)
+}
@@ -0,0 +1,194 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+)
+
+// S3Site is a static site synced to an S3 bucket and fronted by CloudFront.
+// Calls go through the aws CLI so the usual credential chain applies.
+type S3Site struct {
+	Bucket         string
+	Prefix         string
+	DistributionID string
+	// CDNURL is the CloudFront (or custom) domain serving the bucket
+	CDNURL string
+}
+
+// S3Object is the metadata that matters for serving an object
+type S3Object struct {
+	Key          string
+	ETag         string
+	Size         int64
+	ContentType  string
+	CacheControl string
+}
+
+// List returns every object below Prefix keyed by path relative to it
+func (s *S3Site) List(ctx context.Context) (map[string]S3Object, error) {
+	args := []string{"s3api", "list-objects-v2", "--bucket", s.Bucket, "--output", "json"}
+	if s.Prefix != "" {
+		args = append(args, "--prefix", s.Prefix)
+	}
+	out, err := command(ctx, "aws", args...)
+	if err != nil {
+		return nil, err
+	}
+	var listing struct {
+		Contents []struct {
+			Key  string `json:"Key"`
+			ETag string `json:"ETag"`
+			Size int64  `json:"Size"`
+		} `json:"Contents"`
+	}
+	if err := json.Unmarshal(out, &listing); err != nil {
+		return nil, fmt.Errorf("parse object listing: %w", err)
+	}
+	objects := map[string]S3Object{}
+	for _, c := range listing.Contents {
+		rel := strings.TrimPrefix(strings.TrimPrefix(c.Key, s.Prefix), "/")
+		objects[rel] = S3Object{Key: c.Key, ETag: strings.Trim(c.ETag, `"`), Size: c.Size}
+	}
+	return objects, nil
+}
+
+// Head fetches the serving metadata of a single object
+func (s *S3Site) Head(ctx context.Context, key string) (S3Object, error) {
+	out, err := command(ctx, "aws", "s3api", "head-object", "--bucket", s.Bucket, "--key", key, "--output", "json")
+	if err != nil {
+		return S3Object{}, err
+	}
+	var head struct {
+		ETag          string `json:"ETag"`
+		ContentLength int64  `json:"ContentLength"`
+		ContentType   string `json:"ContentType"`
+		CacheControl  string `json:"CacheControl"`
+	}
+	if err := json.Unmarshal(out, &head); err != nil {
+		return S3Object{}, fmt.Errorf("parse head-object: %w", err)
+	}
+	return S3Object{Key: key, ETag: strings.Trim(head.ETag, `"`), Size: head.ContentLength,
+		ContentType: head.ContentType, CacheControl: head.CacheControl}, nil
+}
+
+// SameAsLocal compares an object with a local file. Single-part uploads use
+// the MD5 as ETag; multipart ETags ("<hash>-<parts>") fall back to size.
+func SameAsLocal(obj S3Object, lf LocalFile) bool {
+	if strings.Contains(obj.ETag, "-") {
+		return obj.Size == lf.Size
+	}
+	return obj.ETag == lf.MD5
+}
+
+// CachePolicy holds the Cache-Control rules served objects must satisfy
+type CachePolicy struct {
+	// HTMLMaxAge is the longest HTML may be cached so deploys show up quickly
+	HTMLMaxAge time.Duration
+	// AssetMinAge is the shortest acceptable lifetime for static assets
+	AssetMinAge time.Duration
+}
+
+// DefaultCachePolicy keeps HTML fresh and lets assets be cached for a day
+func DefaultCachePolicy() CachePolicy {
+	return CachePolicy{HTMLMaxAge: 5 * time.Minute, AssetMinAge: 24 * time.Hour}
+}
+
+var maxAgeRe = regexp.MustCompile(`(?:^|,)\s*(?:s-)?max-age=(\d+)`)
+
+// CheckObjectHeaders validates Content-Type against the file extension and
+// Cache-Control against the policy
+func CheckObjectHeaders(obj S3Object, policy CachePolicy) error {
+	var errs []error
+	ext := path.Ext(obj.Key)
+
+	if want := mime.TypeByExtension(ext); want != "" {
+		gotType, _, _ := mime.ParseMediaType(obj.ContentType)
+		wantType, _, _ := mime.ParseMediaType(want)
+		if gotType != wantType {
+			errs = append(errs, fmt.Errorf("%s: Content-Type %q, want %q", obj.Key, obj.ContentType, wantType))
+		}
+	}
+
+	cc := strings.ToLower(obj.CacheControl)
+	maxAge := -1
+	if m := maxAgeRe.FindStringSubmatch(cc); m != nil {
+		maxAge, _ = strconv.Atoi(m[1])
+	}
+	if ext == ".html" || ext == "" {
+		if !strings.Contains(cc, "no-cache") && (maxAge < 0 || time.Duration(maxAge)*time.Second > policy.HTMLMaxAge) {
+			errs = append(errs, fmt.Errorf("%s: Cache-Control %q lets HTML go stale for longer than %v", obj.Key, obj.CacheControl, policy.HTMLMaxAge))
+		}
+	} else if maxAge < 0 || time.Duration(maxAge)*time.Second < policy.AssetMinAge {
+		errs = append(errs, fmt.Errorf("%s: Cache-Control %q caches assets for less than %v", obj.Key, obj.CacheControl, policy.AssetMinAge))
+	}
+	return errors.Join(errs...)
+}
+
+// WaitInvalidation polls a CloudFront invalidation until it completes
+func (s *S3Site) WaitInvalidation(ctx context.Context, id string, interval time.Duration) error {
+	for {
+		out, err := command(ctx, "aws", "cloudfront", "get-invalidation",
+			"--distribution-id", s.DistributionID, "--id", id, "--output", "json")
+		if err != nil {
+			return err
+		}
+		var inv struct {
+			Invalidation struct {
+				Status string `json:"Status"`
+			} `json:"Invalidation"`
+		}
+		if err := json.Unmarshal(out, &inv); err != nil {
+			return fmt.Errorf("parse invalidation: %w", err)
+		}
+		if inv.Invalidation.Status == "Completed" {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("invalidation %s still %s: %w", id, inv.Invalidation.Status, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// VerifyCDN fetches each path through the CDN and compares it with the local
+// build, returning the paths still served stale
+func VerifyCDN(ctx context.Context, client *http.Client, cdnURL string, local map[string]LocalFile, paths []string) ([]string, error) {
+	var stale []string
+	for _, p := range paths {
+		lf, ok := local[p]
+		if !ok {
+			return nil, fmt.Errorf("%s is not part of the local build", p)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(cdnURL, "/")+"/"+crawl.VisitorPath(p), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK || battery.ContentHash(body) != lf.SHA256 {
+			stale = append(stale, p)
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,60 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareTrees(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>hi</h1>"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "css", "site.css"), []byte("body{}"), 0o644))
+
+	local, err := LocalTree(dir)
+	require.NoError(t, err)
+	require.Contains(t, local, "css/site.css")
+
+	remote := map[string]S3Object{
+		"index.html": {Key: "index.html", ETag: local["index.html"].MD5},
+		"old.html":   {Key: "old.html", ETag: "abc"},
+	}
+	keys := []string{"index.html", "old.html"}
+	diff := CompareTrees(local, keys, func(lf LocalFile, p string) bool { return SameAsLocal(remote[p], lf) })
+	assert.Equal(t, []string{"css/site.css"}, diff.Missing)
+	assert.Equal(t, []string{"old.html"}, diff.Extra)
+	assert.Empty(t, diff.Changed)
+	assert.False(t, diff.Empty())
+
+	assert.True(t, SameAsLocal(S3Object{ETag: "deadbeef-3", Size: 11}, local["index.html"]), "multipart ETags compare by size")
+}
+
+func TestCheckObjectHeaders(t *testing.T) {
+	policy := DefaultCachePolicy()
+
+	assert.NoError(t, CheckObjectHeaders(S3Object{Key: "index.html", ContentType: "text/html; charset=utf-8", CacheControl: "public, max-age=60"}, policy))
+	assert.NoError(t, CheckObjectHeaders(S3Object{Key: "css/site.css", ContentType: "text/css", CacheControl: "public, max-age=31536000, immutable"}, policy))
+
+	err := CheckObjectHeaders(S3Object{Key: "index.html", ContentType: "binary/octet-stream", CacheControl: "max-age=86400"}, policy)
+	assert.ErrorContains(t, err, `Content-Type "binary/octet-stream"`)
+	assert.ErrorContains(t, err, "lets HTML go stale")
+
+	assert.ErrorContains(t, CheckObjectHeaders(S3Object{Key: "app.js", ContentType: "text/javascript"}, policy), "caches assets for less than")
+}
+
+func TestVerifyCDN(t *testing.T) {
+	local := map[string]LocalFile{"index.html": {Path: "index.html", SHA256: "0f2cf2a6c2c7d1b0c8a0f3f45e4e8a0c0f2cf2a6c2c7d1b0c8a0f3f45e4e8a0c"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.Write([]byte("old")) }))
+	defer srv.Close()
+
+	stale, err := VerifyCDN(context.Background(), srv.Client(), srv.URL, local, []string{"index.html"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"index.html"}, stale)
+}
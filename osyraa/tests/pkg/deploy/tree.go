@@ -0,0 +1,82 @@
+package deploy
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// LocalFile describes a file in the built site
+type LocalFile struct {
+	Path   string
+	Size   int64
+	MD5    string
+	SHA256 string
+}
+
+// LocalTree hashes every file under dir, keyed by slash-separated relative path
+func LocalTree(dir string) (map[string]LocalFile, error) {
+	files := map[string]LocalFile{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		m := md5.Sum(body)
+		s := sha256.Sum256(body)
+		key := filepath.ToSlash(rel)
+		files[key] = LocalFile{Path: key, Size: int64(len(body)), MD5: hex.EncodeToString(m[:]), SHA256: hex.EncodeToString(s[:])}
+		return nil
+	})
+	return files, err
+}
+
+// TreeDiff lists the differences between a local and a remote file tree
+type TreeDiff struct {
+	Missing []string
+	Extra   []string
+	Changed []string
+}
+
+// Empty reports whether both trees matched
+func (d TreeDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changed) == 0
+}
+
+// CompareTrees diffs local files against remote paths. same decides whether
+// the remote copy of a file matches; it is only called for paths on both sides.
+func CompareTrees(local map[string]LocalFile, remote []string, same func(LocalFile, string) bool) TreeDiff {
+	var diff TreeDiff
+	seen := map[string]bool{}
+	for _, path := range remote {
+		seen[path] = true
+		lf, ok := local[path]
+		if !ok {
+			diff.Extra = append(diff.Extra, path)
+			continue
+		}
+		if !same(lf, path) {
+			diff.Changed = append(diff.Changed, path)
+		}
+	}
+	for path := range local {
+		if !seen[path] {
+			diff.Missing = append(diff.Missing, path)
+		}
+	}
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Extra)
+	sort.Strings(diff.Changed)
+	return diff
+}
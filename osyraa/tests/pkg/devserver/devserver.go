@@ -0,0 +1,171 @@
+// Package devserver runs `hugo server` for the quick checks made while
+// authoring. The server renders to memory from the source tree, so there is
+// no build step and no container: the checks see exactly what the author
+// sees at localhost.
+package devserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dockerrun"
+)
+
+// ErrNoHugo is returned when no hugo binary is available
+var ErrNoHugo = errors.New("hugo is not installed")
+
+// Options configure Start
+type Options struct {
+	// Hugo is the hugo binary; empty looks it up on PATH
+	Hugo string
+	// Source is the site's root directory
+	Source string
+	// Port to listen on; zero picks a free one
+	Port int
+	// Args are extra flags, such as --buildDrafts
+	Args []string
+	// Ready bounds the wait for the first render; zero means a minute
+	Ready time.Duration
+}
+
+// Server is a running hugo server
+type Server struct {
+	// URL is the server's root, with a trailing slash
+	URL  string
+	cmd  *exec.Cmd
+	done chan struct{}
+
+	mu     sync.Mutex
+	output bytes.Buffer
+}
+
+// Write collects the server's output for error messages
+func (s *Server) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.output.Write(p)
+}
+
+// Output returns what the server has printed so far
+func (s *Server) Output() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.output.String()
+}
+
+// args are the hugo flags for serving source on port. Rendering to memory
+// leaves the source tree's public/ alone, fast render is off so every
+// request gets a fully rendered page, and no build lock is taken so a
+// build of the same checkout can run alongside.
+func args(opts Options, port int) []string {
+	a := []string{"server",
+		"--source", opts.Source,
+		"--bind", "127.0.0.1",
+		"--port", strconv.Itoa(port),
+		"--baseURL", "http://127.0.0.1/",
+		"--renderToMemory",
+		"--disableFastRender",
+		"--noBuildLock",
+	}
+	return append(a, opts.Args...)
+}
+
+// freePort asks the kernel for an unused port
+func freePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// Start launches hugo server and returns once it serves the home page
+func Start(ctx context.Context, opts Options) (*Server, error) {
+	hugo := opts.Hugo
+	if hugo == "" {
+		path, err := exec.LookPath("hugo")
+		if err != nil {
+			return nil, ErrNoHugo
+		}
+		hugo = path
+	}
+	port := opts.Port
+	if port == 0 {
+		var err error
+		if port, err = freePort(); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Server{URL: fmt.Sprintf("http://127.0.0.1:%d/", port), done: make(chan struct{})}
+	s.cmd = exec.Command(hugo, args(opts, port)...)
+	s.cmd.Stdout, s.cmd.Stderr = s, s
+	if err := s.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		s.cmd.Wait()
+		close(s.done)
+	}()
+
+	ready := opts.Ready
+	if ready == 0 {
+		ready = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, ready)
+	defer cancel()
+	if err := waitReady(ctx, s.URL, s.done); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("hugo server: %w: %s", err, dockerrun.LastLines(s.Output(), 20))
+	}
+	return s, nil
+}
+
+// waitReady polls url until it answers 200, the server exits or ctx ends
+func waitReady(ctx context.Context, url string, exited <-chan struct{}) error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	tick := time.NewTicker(200 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-exited:
+			return errors.New("exited before serving the site")
+		case <-ctx.Done():
+			return fmt.Errorf("not serving the site: %w", ctx.Err())
+		case <-tick.C:
+		}
+	}
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+	if err := s.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	<-s.done
+	return nil
+}
@@ -0,0 +1,75 @@
+package devserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain lets the test binary stand in for hugo: started with
+// FAKE_HUGO=1 it serves a page on the --port it is given, or fails like a
+// broken config when --fail is passed
+func TestMain(m *testing.M) {
+	if os.Getenv("FAKE_HUGO") == "1" {
+		fakeHugo(os.Args[1:])
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func fakeHugo(args []string) {
+	if slices.Contains(args, "--fail") {
+		fmt.Println("Error: failed to load config")
+		os.Exit(1)
+	}
+	port := args[slices.Index(args, "--port")+1]
+	fmt.Println("Web Server is available at http://127.0.0.1:" + port + "/")
+	http.ListenAndServe("127.0.0.1:"+port, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<h1>served by fake hugo</h1>")
+	}))
+}
+
+func TestArgs(t *testing.T) {
+	a := args(Options{Source: "/site", Args: []string{"--buildDrafts"}}, 1313)
+	assert.Equal(t, []string{"server", "--source", "/site", "--bind", "127.0.0.1", "--port", "1313",
+		"--baseURL", "http://127.0.0.1/", "--renderToMemory", "--disableFastRender", "--noBuildLock", "--buildDrafts"}, a)
+}
+
+func TestStart(t *testing.T) {
+	t.Setenv("FAKE_HUGO", "1")
+	srv, err := Start(context.Background(), Options{Hugo: os.Args[0], Source: t.TempDir()})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "<h1>served by fake hugo</h1>", string(body))
+	assert.Contains(t, srv.Output(), "Web Server is available at "+srv.URL)
+
+	require.NoError(t, srv.Close())
+	require.NoError(t, srv.Close(), "closing twice is harmless")
+}
+
+func TestStartFails(t *testing.T) {
+	t.Setenv("FAKE_HUGO", "1")
+	_, err := Start(context.Background(), Options{Hugo: os.Args[0], Args: []string{"--fail"}, Ready: 10 * time.Second})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exited before serving the site")
+	assert.Contains(t, err.Error(), "failed to load config", "the server's output explains why")
+
+	_, err = Start(context.Background(), Options{Hugo: "/nonexistent/hugo"})
+	assert.Error(t, err)
+	t.Setenv("PATH", t.TempDir())
+	_, err = Start(context.Background(), Options{})
+	assert.ErrorIs(t, err, ErrNoHugo)
+}
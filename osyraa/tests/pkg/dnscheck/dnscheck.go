@@ -0,0 +1,202 @@
+// Package dnscheck verifies the DNS records of the site's domains: address
+// and alias targets, CAA authorization for the CA issuing certificates, and
+// CNAMEs left pointing at deprovisioned hosts.
+package dnscheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// DefaultServer is queried when no resolver is configured
+const DefaultServer = "1.1.1.1:53"
+
+// Resolver sends queries straight to a DNS server so record types the
+// standard library can't look up (CAA) are available
+type Resolver struct {
+	Server string
+	Client *dns.Client
+}
+
+// NewResolver creates a resolver for server ("host:port"); an empty server
+// uses the first nameserver in /etc/resolv.conf, then DefaultServer
+func NewResolver(server string) *Resolver {
+	if server == "" {
+		server = DefaultServer
+		if conf, err := dns.ClientConfigFromFile("/etc/resolv.conf"); err == nil && len(conf.Servers) > 0 {
+			server = net.JoinHostPort(conf.Servers[0], conf.Port)
+		}
+	}
+	return &Resolver{Server: server, Client: &dns.Client{}}
+}
+
+// ErrNXDomain is returned when a name does not exist
+var ErrNXDomain = errors.New("no such domain")
+
+// Lookup returns the answer records of type qtype for name
+func (r *Resolver) Lookup(ctx context.Context, name string, qtype uint16) ([]dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.RecursionDesired = true
+	resp, _, err := r.Client.ExchangeContext(ctx, msg, r.Server)
+	if err != nil {
+		return nil, fmt.Errorf("query %s %s: %w", dns.TypeToString[qtype], name, err)
+	}
+	switch resp.Rcode {
+	case dns.RcodeSuccess:
+	case dns.RcodeNameError:
+		return nil, fmt.Errorf("%s: %w", name, ErrNXDomain)
+	default:
+		return nil, fmt.Errorf("query %s %s: %s", dns.TypeToString[qtype], name, dns.RcodeToString[resp.Rcode])
+	}
+	var answers []dns.RR
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == qtype {
+			answers = append(answers, rr)
+		}
+	}
+	return answers, nil
+}
+
+// Expectation describes where a domain should point. Empty fields aren't
+// checked; A and AAAA compare as sets.
+type Expectation struct {
+	Name  string
+	A     []string
+	AAAA  []string
+	CNAME string
+}
+
+// CheckRecords compares a domain's records with the expectation
+func (r *Resolver) CheckRecords(ctx context.Context, exp Expectation) error {
+	var errs []error
+	if exp.CNAME != "" {
+		rrs, err := r.Lookup(ctx, exp.Name, dns.TypeCNAME)
+		if err != nil {
+			return err
+		}
+		var got []string
+		for _, rr := range rrs {
+			got = append(got, rr.(*dns.CNAME).Target)
+		}
+		if !slices.Contains(got, dns.Fqdn(exp.CNAME)) {
+			errs = append(errs, fmt.Errorf("%s CNAME is %v, want %s", exp.Name, got, dns.Fqdn(exp.CNAME)))
+		}
+	}
+	for _, want := range []struct {
+		qtype uint16
+		addrs []string
+	}{{dns.TypeA, exp.A}, {dns.TypeAAAA, exp.AAAA}} {
+		if len(want.addrs) == 0 {
+			continue
+		}
+		got, err := r.addresses(ctx, exp.Name, want.qtype)
+		if err != nil {
+			return err
+		}
+		if !sameSet(got, want.addrs) {
+			errs = append(errs, fmt.Errorf("%s %s records are %v, want %v", exp.Name, dns.TypeToString[want.qtype], got, want.addrs))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addresses returns the A or AAAA records of name as strings
+func (r *Resolver) addresses(ctx context.Context, name string, qtype uint16) ([]string, error) {
+	rrs, err := r.Lookup(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, rr := range rrs {
+		switch rr := rr.(type) {
+		case *dns.A:
+			addrs = append(addrs, rr.A.String())
+		case *dns.AAAA:
+			addrs = append(addrs, rr.AAAA.String())
+		}
+	}
+	return addrs, nil
+}
+
+// CheckCAA verifies the CAA records governing name permit ca (for example
+// "letsencrypt.org"). Per RFC 8659 the closest ancestor with CAA records
+// applies, and a domain without any allows every CA.
+func (r *Resolver) CheckCAA(ctx context.Context, name, ca string) error {
+	wildcard := strings.HasPrefix(name, "*.")
+	labels := dns.SplitDomainName(strings.TrimPrefix(name, "*."))
+	for i := range labels {
+		domain := strings.Join(labels[i:], ".")
+		rrs, err := r.Lookup(ctx, domain, dns.TypeCAA)
+		if err != nil && !errors.Is(err, ErrNXDomain) {
+			return err
+		}
+		if len(rrs) == 0 {
+			continue
+		}
+		var issue, issueWild []string
+		for _, rr := range rrs {
+			caa := rr.(*dns.CAA)
+			issuer := strings.TrimSpace(strings.SplitN(caa.Value, ";", 2)[0])
+			switch strings.ToLower(caa.Tag) {
+			case "issue":
+				issue = append(issue, issuer)
+			case "issuewild":
+				issueWild = append(issueWild, issuer)
+			}
+		}
+		allowed := issue
+		if wildcard && len(issueWild) > 0 {
+			allowed = issueWild
+		}
+		if len(allowed) == 0 || slices.Contains(allowed, ca) {
+			return nil
+		}
+		return fmt.Errorf("CAA records on %s allow %v, not %s", domain, allowed, ca)
+	}
+	return nil
+}
+
+// DanglingCNAMEs returns the names whose CNAME target no longer resolves, a
+// sign the aliased host was deprovisioned and the name could be taken over
+func (r *Resolver) DanglingCNAMEs(ctx context.Context, names []string) ([]string, error) {
+	var dangling []string
+	for _, name := range names {
+		rrs, err := r.Lookup(ctx, name, dns.TypeCNAME)
+		if err != nil {
+			if errors.Is(err, ErrNXDomain) {
+				continue
+			}
+			return nil, err
+		}
+		for _, rr := range rrs {
+			target := rr.(*dns.CNAME).Target
+			a, errA := r.Lookup(ctx, target, dns.TypeA)
+			aaaa, errAAAA := r.Lookup(ctx, target, dns.TypeAAAA)
+			if errors.Is(errA, ErrNXDomain) || (errA == nil && errAAAA == nil && len(a) == 0 && len(aaaa) == 0) {
+				dangling = append(dangling, fmt.Sprintf("%s -> %s", name, target))
+			} else if errA != nil {
+				return nil, errA
+			}
+		}
+	}
+	return dangling, nil
+}
+
+func sameSet(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for _, w := range want {
+		if !slices.Contains(got, w) {
+			return false
+		}
+	}
+	return true
+}
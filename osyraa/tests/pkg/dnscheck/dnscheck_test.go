@@ -0,0 +1,88 @@
+package dnscheck
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zone answers queries from a fixed set of records; unknown names are NXDOMAIN
+var zone = []string{
+	"resume.example.com. 300 IN A 203.0.113.10",
+	"www.example.com. 300 IN CNAME resume.example.com.",
+	"old.example.com. 300 IN CNAME gone.azurewebsites.net.",
+	"example.com. 300 IN CAA 0 issue \"letsencrypt.org\"",
+	"example.com. 300 IN CAA 0 issuewild \";\"",
+	"example.com. 300 IN SOA ns1.example.com. admin.example.com. 1 3600 600 86400 300",
+}
+
+func serve(t *testing.T) *Resolver {
+	t.Helper()
+	var records []dns.RR
+	for _, s := range zone {
+		rr, err := dns.NewRR(s)
+		require.NoError(t, err)
+		records = append(records, rr)
+	}
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	mux := dns.NewServeMux()
+	mux.HandleFunc(".", func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		q := req.Question[0]
+		known := false
+		for _, rr := range records {
+			if rr.Header().Name != q.Name {
+				continue
+			}
+			known = true
+			if rr.Header().Rrtype == q.Qtype {
+				resp.Answer = append(resp.Answer, rr)
+			}
+		}
+		if !known {
+			resp.Rcode = dns.RcodeNameError
+		}
+		w.WriteMsg(resp)
+	})
+	srv := &dns.Server{PacketConn: pc, Handler: mux}
+	go srv.ActivateAndServe()
+	t.Cleanup(func() { srv.Shutdown() })
+
+	return NewResolver(pc.LocalAddr().String())
+}
+
+func TestCheckRecords(t *testing.T) {
+	r := serve(t)
+	ctx := context.Background()
+
+	assert.NoError(t, r.CheckRecords(ctx, Expectation{Name: "resume.example.com", A: []string{"203.0.113.10"}}))
+	assert.NoError(t, r.CheckRecords(ctx, Expectation{Name: "www.example.com", CNAME: "resume.example.com"}))
+	assert.ErrorContains(t, r.CheckRecords(ctx, Expectation{Name: "resume.example.com", A: []string{"198.51.100.1"}}), "A records are")
+
+	err := r.CheckRecords(ctx, Expectation{Name: "missing.example.com", A: []string{"203.0.113.10"}})
+	assert.ErrorIs(t, err, ErrNXDomain)
+}
+
+func TestCheckCAA(t *testing.T) {
+	r := serve(t)
+	ctx := context.Background()
+
+	assert.NoError(t, r.CheckCAA(ctx, "resume.example.com", "letsencrypt.org"), "inherits the apex CAA")
+	assert.ErrorContains(t, r.CheckCAA(ctx, "resume.example.com", "digicert.com"), "not digicert.com")
+	assert.Error(t, r.CheckCAA(ctx, "*.example.com", "letsencrypt.org"), "issuewild forbids wildcards")
+	assert.NoError(t, r.CheckCAA(ctx, "other.test", "digicert.com"), "no CAA allows any CA")
+}
+
+func TestDanglingCNAMEs(t *testing.T) {
+	r := serve(t)
+	dangling, err := r.DanglingCNAMEs(context.Background(), []string{"www.example.com", "old.example.com", "resume.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old.example.com -> gone.azurewebsites.net."}, dangling)
+}
@@ -0,0 +1,184 @@
+// Package dockerrun runs the tools the harness keeps in throwaway
+// containers (Hugo, syft, Trivy, ZAP and the binfmt probe) through the
+// engine's API, so only a reachable engine is needed, never the docker
+// CLI. Each container is named, labelled and pulled the same way, can be
+// found and removed by osyraa clean if the run is interrupted, and is
+// removed once its tool exits.
+package dockerrun
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Client is the part of the engine API a tool container needs;
+// *client.Client implements it
+type Client interface {
+	ImageInspectWithRaw(ctx context.Context, image string) (types.ImageInspect, []byte, error)
+	ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error)
+	ContainerCreate(ctx context.Context, config *container.Config, host *container.HostConfig, networking *network.NetworkingConfig, platform *ocispec.Platform, name string) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, id string, options container.StartOptions) error
+	ContainerWait(ctx context.Context, id string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error)
+	ContainerLogs(ctx context.Context, id string, options container.LogsOptions) (io.ReadCloser, error)
+	ContainerRemove(ctx context.Context, id string, options container.RemoveOptions) error
+}
+
+// Pull policies, as docker run's --pull names them
+const (
+	PullMissing = "missing"
+	PullAlways  = "always"
+	PullNever   = "never"
+)
+
+// Container is a tool's container
+type Container struct {
+	Image string
+	// Cmd is the tool's arguments after the image's entrypoint
+	Cmd []string
+	Env []string
+	// User is user[:group]; empty keeps the image's
+	User string
+	// Mounts are bind mounts of host directories
+	Mounts []Mount
+	// Network is the network mode, e.g. "host"; empty is the default bridge
+	Network string
+	// Privileged gives the container every capability
+	Privileged bool
+	RunOptions
+}
+
+// RunOptions name, label and pull a tool's container. The tool packages
+// embed them in their own options and pass them on to Container.
+type RunOptions struct {
+	// Name names the container so it can be removed if the run is
+	// interrupted. Empty lets Docker choose.
+	Name string
+	// Labels are put on the container
+	Labels map[string]string
+	// Pull is the pull policy; empty is PullMissing, as for docker run
+	Pull string
+}
+
+// Mount binds Source on the host to Target in the container
+type Mount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// Result is how a tool exited
+type Result struct {
+	ExitCode int
+	// Output is the tool's stdout and stderr, interleaved
+	Output string
+}
+
+// Run pulls c's image as its policy says, runs the container to
+// completion and removes it. A tool that exits non-zero is not an error:
+// callers decide what its exit code means.
+func Run(ctx context.Context, cli Client, c Container) (Result, error) {
+	if err := pull(ctx, cli, c.Image, c.Pull); err != nil {
+		return Result{}, err
+	}
+	config := &container.Config{Image: c.Image, Cmd: c.Cmd, Env: c.Env, User: c.User, Labels: c.Labels}
+	host := &container.HostConfig{NetworkMode: container.NetworkMode(c.Network), Privileged: c.Privileged}
+	for _, m := range c.Mounts {
+		host.Mounts = append(host.Mounts, mount.Mount{Type: mount.TypeBind, Source: m.Source, Target: m.Target, ReadOnly: m.ReadOnly})
+	}
+	created, err := cli.ContainerCreate(ctx, config, host, nil, nil, c.Name)
+	if err != nil {
+		return Result{}, fmt.Errorf("create %s container: %w", c.Image, err)
+	}
+	defer func() {
+		// Removed even when ctx is done, so a cancelled run leaves nothing behind
+		_ = cli.ContainerRemove(context.WithoutCancel(ctx), created.ID, container.RemoveOptions{Force: true})
+	}()
+
+	// Waiting before starting doesn't miss a tool that exits at once
+	waitC, errC := cli.ContainerWait(ctx, created.ID, container.WaitConditionNextExit)
+	if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		return Result{}, fmt.Errorf("start %s container: %w", c.Image, err)
+	}
+	var res Result
+	select {
+	case wait := <-waitC:
+		if wait.Error != nil {
+			return Result{}, fmt.Errorf("wait for %s container: %s", c.Image, wait.Error.Message)
+		}
+		res.ExitCode = int(wait.StatusCode)
+	case err := <-errC:
+		return Result{}, fmt.Errorf("wait for %s container: %w", c.Image, err)
+	}
+
+	logs, err := cli.ContainerLogs(ctx, created.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return res, fmt.Errorf("read %s container's output: %w", c.Image, err)
+	}
+	defer logs.Close()
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, logs); err != nil {
+		return res, fmt.Errorf("read %s container's output: %w", c.Image, err)
+	}
+	res.Output = output.String()
+	return res, nil
+}
+
+// pull fetches image unless policy says the local copy will do
+func pull(ctx context.Context, cli Client, image, policy string) error {
+	switch policy {
+	case "", PullMissing, PullNever:
+		_, _, err := cli.ImageInspectWithRaw(ctx, image)
+		if err == nil {
+			return nil
+		}
+		if !client.IsErrNotFound(err) {
+			return fmt.Errorf("inspect %s: %w", image, err)
+		}
+		if policy == PullNever {
+			return fmt.Errorf("image %s isn't present and the pull policy is never", image)
+		}
+	case PullAlways:
+	default:
+		return fmt.Errorf("unknown pull policy %q (want missing, always or never)", policy)
+	}
+	rc, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("pull %s: %w", image, err)
+	}
+	defer rc.Close()
+	// The pull only finishes when its progress stream has been read, and
+	// reports failures in it
+	if err := jsonmessage.DisplayJSONMessagesStream(rc, io.Discard, 0, false, nil); err != nil {
+		return fmt.Errorf("pull %s: %w", image, err)
+	}
+	return nil
+}
+
+// ErrExit reports a tool that exited non-zero, with the end of its output
+func (r Result) ErrExit(tool string) error {
+	if r.ExitCode == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s exited with status %d: %s", tool, r.ExitCode, LastLines(r.Output, 20))
+}
+
+// LastLines keeps the last n lines of a tool's output for an error message
+func LastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,119 @@
+package dockerrun
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEngine runs every container to exitCode, printing output
+type fakeEngine struct {
+	images   map[string]bool
+	exitCode int64
+	output   string
+
+	pulled  []string
+	config  *container.Config
+	host    *container.HostConfig
+	name    string
+	removed []string
+}
+
+func (f *fakeEngine) ImageInspectWithRaw(_ context.Context, image string) (types.ImageInspect, []byte, error) {
+	if !f.images[image] {
+		return types.ImageInspect{}, nil, errdefs.NotFound(io.EOF)
+	}
+	return types.ImageInspect{ID: "sha256:" + image}, nil, nil
+}
+
+func (f *fakeEngine) ImagePull(_ context.Context, ref string, _ types.ImagePullOptions) (io.ReadCloser, error) {
+	f.pulled = append(f.pulled, ref)
+	return io.NopCloser(strings.NewReader(`{"status":"Pull complete"}` + "\n")), nil
+}
+
+func (f *fakeEngine) ContainerCreate(_ context.Context, config *container.Config, host *container.HostConfig, _ *network.NetworkingConfig, _ *ocispec.Platform, name string) (container.CreateResponse, error) {
+	f.config, f.host, f.name = config, host, name
+	return container.CreateResponse{ID: "c1"}, nil
+}
+
+func (f *fakeEngine) ContainerStart(context.Context, string, container.StartOptions) error {
+	return nil
+}
+
+func (f *fakeEngine) ContainerWait(context.Context, string, container.WaitCondition) (<-chan container.WaitResponse, <-chan error) {
+	wait := make(chan container.WaitResponse, 1)
+	wait <- container.WaitResponse{StatusCode: f.exitCode}
+	return wait, make(chan error)
+}
+
+func (f *fakeEngine) ContainerLogs(context.Context, string, container.LogsOptions) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+	_, _ = stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(f.output))
+	_, _ = stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte("warning\n"))
+	return io.NopCloser(&buf), nil
+}
+
+func (f *fakeEngine) ContainerRemove(_ context.Context, id string, _ container.RemoveOptions) error {
+	f.removed = append(f.removed, id)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	f := &fakeEngine{output: "scanned\n", exitCode: 2}
+	res, err := Run(t.Context(), f, Container{
+		Image:   "aquasec/trivy:0.50.1",
+		Cmd:     []string{"image", "--quiet"},
+		User:    "1000:1000",
+		Mounts:  []Mount{{Source: "/tmp/work", Target: "/work"}},
+		Network: "host",
+		RunOptions: RunOptions{
+			Name:   "osyraa-trivy-r1",
+			Labels: map[string]string{"io.osyraa.run": "r1"},
+		},
+	})
+	require.NoError(t, err, "A tool's exit code is the caller's to judge")
+	assert.Equal(t, Result{ExitCode: 2, Output: "scanned\nwarning\n"}, res)
+	assert.Equal(t, []string{"aquasec/trivy:0.50.1"}, f.pulled, "A missing image is pulled")
+	assert.Equal(t, []string{"image", "--quiet"}, []string(f.config.Cmd))
+	assert.Equal(t, "1000:1000", f.config.User)
+	assert.Equal(t, map[string]string{"io.osyraa.run": "r1"}, f.config.Labels)
+	assert.Equal(t, "osyraa-trivy-r1", f.name)
+	assert.Equal(t, container.NetworkMode("host"), f.host.NetworkMode)
+	require.Len(t, f.host.Mounts, 1)
+	assert.Equal(t, "/tmp/work", f.host.Mounts[0].Source)
+	assert.Equal(t, []string{"c1"}, f.removed, "The container is removed once it exits")
+	assert.ErrorContains(t, res.ErrExit("Trivy"), "Trivy exited with status 2: scanned\nwarning")
+}
+
+func TestPull(t *testing.T) {
+	f := &fakeEngine{images: map[string]bool{"local": true}}
+	require.NoError(t, pull(t.Context(), f, "local", ""))
+	require.NoError(t, pull(t.Context(), f, "local", PullNever))
+	assert.Empty(t, f.pulled, "A present image isn't pulled unless the policy says always")
+	require.NoError(t, pull(t.Context(), f, "local", PullAlways))
+	assert.Equal(t, []string{"local"}, f.pulled)
+
+	assert.ErrorContains(t, pull(t.Context(), f, "remote", PullNever), "isn't present")
+	assert.ErrorContains(t, pull(t.Context(), f, "remote", "sometimes"), "unknown pull policy")
+	_, err := Run(t.Context(), f, Container{Image: "remote", RunOptions: RunOptions{Pull: PullNever}})
+	assert.Error(t, err)
+	assert.Empty(t, f.removed, "No container is created when the image can't be had")
+}
+
+func TestLastLines(t *testing.T) {
+	output := strings.Repeat("step\n", 30) + "error: boom\n\n"
+	got := LastLines(output, 3)
+	assert.Equal(t, "step\nstep\nerror: boom", got)
+	assert.Equal(t, "one", LastLines("one\n", 20))
+}
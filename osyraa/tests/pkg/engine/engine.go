@@ -0,0 +1,263 @@
+// Package engine finds the container engine to test against. client.FromEnv
+// only knows DOCKER_HOST and the default socket, so on Docker Desktop for
+// macOS, colima, rootless Docker or Podman it fails with a bare connection
+// error. Detect probes the places those runtimes listen, negotiates the
+// API version, and says which one it chose, or lists every endpoint it
+// tried and why each failed.
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/client"
+)
+
+// Candidate is an endpoint an engine may listen on
+type Candidate struct {
+	// Host is a Docker host URL: unix://, npipe:// or tcp://
+	Host string
+	// Source says where the endpoint came from, e.g. "DOCKER_HOST" or
+	// "docker context colima"
+	Source string
+}
+
+// Env is what candidate discovery reads from the host
+type Env struct {
+	Getenv func(string) string
+	Home   string
+	GOOS   string
+	UID    int
+}
+
+// HostEnv is the running process's environment
+func HostEnv() Env {
+	home, _ := os.UserHomeDir()
+	return Env{Getenv: os.Getenv, Home: home, GOOS: runtime.GOOS, UID: os.Getuid()}
+}
+
+func unix(path string) string {
+	return "unix://" + filepath.ToSlash(path)
+}
+
+// Candidates lists the endpoints to probe, most specific first. An explicit
+// DOCKER_HOST is the only candidate. Otherwise the active docker context
+// comes first, then the usual sockets of Docker, Docker Desktop, rootless
+// Docker, colima, Rancher Desktop and Podman for the platform.
+func Candidates(env Env) []Candidate {
+	if host := env.Getenv("DOCKER_HOST"); host != "" {
+		return []Candidate{{Host: host, Source: "DOCKER_HOST"}}
+	}
+	var cs []Candidate
+	add := func(host, source string) {
+		if !slices.ContainsFunc(cs, func(c Candidate) bool { return c.Host == host }) {
+			cs = append(cs, Candidate{Host: host, Source: source})
+		}
+	}
+	if name, host := contextHost(env); host != "" {
+		add(host, "docker context "+name)
+	}
+
+	home := env.Home
+	xdg := env.Getenv("XDG_RUNTIME_DIR")
+	if xdg == "" && env.GOOS == "linux" {
+		xdg = fmt.Sprintf("/run/user/%d", env.UID)
+	}
+	switch env.GOOS {
+	case "windows":
+		add("npipe:////./pipe/docker_engine", "Docker Desktop")
+		add("npipe:////./pipe/podman-machine-default", "Podman machine")
+	case "darwin":
+		add(unix(filepath.Join(home, ".docker", "run", "docker.sock")), "Docker Desktop")
+		add("unix:///var/run/docker.sock", "default socket")
+		add(unix(filepath.Join(home, ".colima", "default", "docker.sock")), "colima")
+		add(unix(filepath.Join(home, ".colima", "docker.sock")), "colima")
+		add(unix(filepath.Join(home, ".rd", "docker.sock")), "Rancher Desktop")
+		add(unix(filepath.Join(home, ".local", "share", "containers", "podman", "machine", "podman.sock")), "Podman machine")
+		add(unix(filepath.Join(home, ".local", "share", "containers", "podman", "machine", "qemu", "podman.sock")), "Podman machine")
+	default:
+		add("unix:///var/run/docker.sock", "default socket")
+		add(unix(filepath.Join(xdg, "docker.sock")), "rootless Docker")
+		add(unix(filepath.Join(home, ".docker", "desktop", "docker.sock")), "Docker Desktop")
+		add(unix(filepath.Join(home, ".colima", "default", "docker.sock")), "colima")
+		add(unix(filepath.Join(home, ".colima", "docker.sock")), "colima")
+		add(unix(filepath.Join(xdg, "podman", "podman.sock")), "rootless Podman")
+		add("unix:///run/podman/podman.sock", "Podman")
+	}
+	return cs
+}
+
+// contextHost reads the docker CLI's current context, from DOCKER_CONTEXT
+// or config.json, and returns its name and endpoint. The default context
+// has no stored endpoint and yields "".
+func contextHost(env Env) (name, host string) {
+	configDir := env.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		configDir = filepath.Join(env.Home, ".docker")
+	}
+	name = env.Getenv("DOCKER_CONTEXT")
+	if name == "" {
+		var config struct {
+			CurrentContext string `json:"currentContext"`
+		}
+		data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+		if err != nil || json.Unmarshal(data, &config) != nil {
+			return "", ""
+		}
+		name = config.CurrentContext
+	}
+	if name == "" || name == "default" {
+		return "", ""
+	}
+	// Context metadata lives under the SHA-256 of the context's name
+	sum := sha256.Sum256([]byte(name))
+	data, err := os.ReadFile(filepath.Join(configDir, "contexts", "meta", hex.EncodeToString(sum[:]), "meta.json"))
+	if err != nil {
+		return name, ""
+	}
+	var meta struct {
+		Endpoints map[string]struct {
+			Host string `json:"Host"`
+		} `json:"Endpoints"`
+	}
+	if json.Unmarshal(data, &meta) != nil {
+		return name, ""
+	}
+	return name, meta.Endpoints["docker"].Host
+}
+
+// Runtime is the engine Detect chose
+type Runtime struct {
+	Candidate
+	// Name is the product, e.g. "Docker Engine", "Docker Desktop",
+	// "colima" or "Podman"
+	Name string
+	// Version is the engine's version and APIVersion the API version
+	// negotiated with it
+	Version    string
+	APIVersion string
+	Rootless   bool
+}
+
+func (r Runtime) String() string {
+	name := r.Name
+	if r.Rootless {
+		name = "rootless " + name
+	}
+	return fmt.Sprintf("%s %s (API %s) at %s via %s", name, r.Version, r.APIVersion, r.Host, r.Source)
+}
+
+// ProbeError lists every endpoint tried and why it failed
+type ProbeError struct {
+	Tried []Candidate
+	Errs  []error
+}
+
+func (e *ProbeError) Error() string {
+	var b strings.Builder
+	b.WriteString("no container engine found; tried:")
+	for i, c := range e.Tried {
+		fmt.Fprintf(&b, "\n  %s (%s): %v", c.Host, c.Source, e.Errs[i])
+	}
+	b.WriteString("\nset DOCKER_HOST to the engine's socket if it listens elsewhere")
+	return b.String()
+}
+
+// errNoSocket marks a unix socket that does not exist, so it is skipped
+// without a connection attempt
+var errNoSocket = errors.New("no socket")
+
+// Detect probes the candidates from env in order and returns a client for
+// the first engine that answers, with the API version negotiated
+func Detect(ctx context.Context, env Env) (*Runtime, *client.Client, error) {
+	return Probe(ctx, Candidates(env))
+}
+
+// Probe tries candidates in order and returns the first engine that
+// answers
+func Probe(ctx context.Context, candidates []Candidate) (*Runtime, *client.Client, error) {
+	probeErr := &ProbeError{}
+	for _, c := range candidates {
+		rt, cli, err := probe(ctx, c)
+		if err == nil {
+			return rt, cli, nil
+		}
+		probeErr.Tried = append(probeErr.Tried, c)
+		probeErr.Errs = append(probeErr.Errs, err)
+	}
+	return nil, nil, probeErr
+}
+
+func probe(ctx context.Context, c Candidate) (*Runtime, *client.Client, error) {
+	if path, ok := strings.CutPrefix(c.Host, "unix://"); ok {
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil, errNoSocket
+		}
+	}
+	// DOCKER_HOST may come with DOCKER_TLS_VERIFY and DOCKER_CERT_PATH,
+	// which FromEnv applies too
+	hostOpt := client.WithHost(c.Host)
+	if c.Source == "DOCKER_HOST" {
+		hostOpt = client.FromEnv
+	}
+	cli, err := client.NewClientWithOpts(hostOpt, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := cli.Ping(ctx); err != nil {
+		cli.Close()
+		return nil, nil, err
+	}
+	cli.NegotiateAPIVersion(ctx)
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		cli.Close()
+		return nil, nil, err
+	}
+	info, err := cli.Info(ctx)
+	if err != nil {
+		cli.Close()
+		return nil, nil, err
+	}
+	rt := &Runtime{Candidate: c, Version: version.Version, APIVersion: cli.ClientVersion()}
+	rt.Name, rt.Rootless = Classify(c, version, info)
+	return rt, cli, nil
+}
+
+// Classify names the product behind an engine from its version and info
+// responses and the endpoint it was reached on
+func Classify(c Candidate, version types.Version, info system.Info) (name string, rootless bool) {
+	rootless = slices.ContainsFunc(info.SecurityOptions, func(o string) bool { return strings.Contains(o, "name=rootless") })
+	isPodman := slices.ContainsFunc(version.Components, func(comp types.ComponentVersion) bool {
+		return strings.Contains(strings.ToLower(comp.Name), "podman")
+	})
+	platform := strings.ToLower(version.Platform.Name)
+	host := strings.ToLower(c.Host + " " + c.Source)
+	switch {
+	case isPodman:
+		name = "Podman"
+	case strings.Contains(platform, "docker desktop") || strings.Contains(strings.ToLower(info.OperatingSystem), "docker desktop"):
+		name = "Docker Desktop"
+	case strings.Contains(host, "colima"):
+		name = "colima"
+	case strings.Contains(host, "rancher") || strings.Contains(host, "/.rd/"):
+		name = "Rancher Desktop"
+	default:
+		name = "Docker Engine"
+	}
+	return name, rootless
+}
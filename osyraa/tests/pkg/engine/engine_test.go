@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(goos, home string, vars map[string]string) Env {
+	return Env{Getenv: func(k string) string { return vars[k] }, Home: home, GOOS: goos, UID: 1000}
+}
+
+func hosts(cs []Candidate) []string {
+	var hs []string
+	for _, c := range cs {
+		hs = append(hs, c.Host)
+	}
+	return hs
+}
+
+func TestCandidates(t *testing.T) {
+	assert.Equal(t, []Candidate{{Host: "tcp://10.0.0.2:2375", Source: "DOCKER_HOST"}},
+		Candidates(env("linux", "/home/me", map[string]string{"DOCKER_HOST": "tcp://10.0.0.2:2375"})),
+		"an explicit DOCKER_HOST is the only candidate")
+
+	assert.Equal(t, []string{
+		"unix:///var/run/docker.sock",
+		"unix:///run/user/1000/docker.sock",
+		"unix:///home/me/.docker/desktop/docker.sock",
+		"unix:///home/me/.colima/default/docker.sock",
+		"unix:///home/me/.colima/docker.sock",
+		"unix:///run/user/1000/podman/podman.sock",
+		"unix:///run/podman/podman.sock",
+	}, hosts(Candidates(env("linux", "/home/me", nil))))
+
+	mac := hosts(Candidates(env("darwin", "/Users/me", nil)))
+	assert.Equal(t, "unix:///Users/me/.docker/run/docker.sock", mac[0])
+	assert.Contains(t, mac, "unix:///Users/me/.colima/default/docker.sock")
+	assert.Contains(t, hosts(Candidates(env("windows", `C:\Users\me`, nil))), "npipe:////./pipe/docker_engine")
+}
+
+func TestCandidatesFromContext(t *testing.T) {
+	config := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(config, "config.json"), []byte(`{"currentContext":"colima"}`), 0o644))
+	sum := sha256.Sum256([]byte("colima"))
+	meta := filepath.Join(config, "contexts", "meta", hex.EncodeToString(sum[:]))
+	require.NoError(t, os.MkdirAll(meta, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(meta, "meta.json"),
+		[]byte(`{"Name":"colima","Endpoints":{"docker":{"Host":"unix:///Users/me/.colima/default/docker.sock"}}}`), 0o644))
+
+	cs := Candidates(env("darwin", "/Users/me", map[string]string{"DOCKER_CONFIG": config}))
+	assert.Equal(t, Candidate{Host: "unix:///Users/me/.colima/default/docker.sock", Source: "docker context colima"}, cs[0])
+	assert.Len(t, cs, 7, "the context's socket is not probed twice")
+
+	cs = Candidates(env("darwin", "/Users/me", map[string]string{"DOCKER_CONFIG": config, "DOCKER_CONTEXT": "default"}))
+	assert.Equal(t, "Docker Desktop", cs[0].Source, "DOCKER_CONTEXT overrides config.json")
+}
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		candidate Candidate
+		version   types.Version
+		info      system.Info
+		want      string
+		rootless  bool
+	}{
+		{"engine", Candidate{Host: "unix:///var/run/docker.sock"}, types.Version{}, system.Info{}, "Docker Engine", false},
+		{"desktop", Candidate{Host: "unix:///Users/me/.docker/run/docker.sock"},
+			types.Version{Platform: struct{ Name string }{"Docker Desktop 4.28.0 (139021)"}}, system.Info{}, "Docker Desktop", false},
+		{"colima", Candidate{Host: "unix:///Users/me/.colima/default/docker.sock"}, types.Version{}, system.Info{}, "colima", false},
+		{"rootless", Candidate{Host: "unix:///run/user/1000/docker.sock"}, types.Version{},
+			system.Info{SecurityOptions: []string{"name=seccomp,profile=builtin", "name=rootless"}}, "Docker Engine", true},
+		{"podman", Candidate{Host: "unix:///run/podman/podman.sock"},
+			types.Version{Components: []types.ComponentVersion{{Name: "Podman Engine"}}}, system.Info{}, "Podman", false},
+	} {
+		name, rootless := Classify(tc.candidate, tc.version, tc.info)
+		assert.Equal(t, tc.want, name, tc.name)
+		assert.Equal(t, tc.rootless, rootless, tc.name)
+	}
+}
+
+// fakeEngine serves the endpoints Detect calls on a unix socket
+func fakeEngine(t *testing.T, socket string) {
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	versioned := regexp.MustCompile(`^/v[0-9.]+`)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		switch versioned.ReplaceAllString(r.URL.Path, "") {
+		case "/_ping":
+			w.Write([]byte("OK"))
+		case "/version":
+			json.NewEncoder(w).Encode(types.Version{Version: "4.9.3", APIVersion: "1.41",
+				Components: []types.ComponentVersion{{Name: "Podman Engine", Version: "4.9.3"}}})
+		case "/info":
+			json.NewEncoder(w).Encode(system.Info{SecurityOptions: []string{"name=rootless"}})
+		default:
+			http.NotFound(w, r)
+		}
+	})}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+}
+
+func TestDetect(t *testing.T) {
+	// Unix socket paths are limited to about 100 bytes, so keep it short
+	dir, err := os.MkdirTemp("", "eng")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "podman", "podman.sock")
+	require.NoError(t, os.MkdirAll(filepath.Dir(socket), 0o755))
+	fakeEngine(t, socket)
+
+	missing := Candidate{Host: "unix://" + filepath.Join(dir, "docker.sock"), Source: "rootless Docker"}
+	podman := Candidate{Host: "unix://" + socket, Source: "rootless Podman"}
+	rt, cli, err := Probe(context.Background(), []Candidate{missing, podman})
+	require.NoError(t, err)
+	defer cli.Close()
+	assert.Equal(t, "Podman", rt.Name)
+	assert.True(t, rt.Rootless)
+	assert.Equal(t, "1.41", rt.APIVersion, "the API version is negotiated down to the engine's")
+	assert.Equal(t, "rootless Podman 4.9.3 (API 1.41) at unix://"+socket+" via rootless Podman", rt.String())
+
+	_, _, err = Detect(context.Background(), env("linux", dir, map[string]string{"DOCKER_HOST": "unix://" + filepath.Join(dir, "missing.sock")}))
+	var probeErr *ProbeError
+	require.ErrorAs(t, err, &probeErr)
+	assert.Contains(t, err.Error(), "missing.sock (DOCKER_HOST): no socket")
+}
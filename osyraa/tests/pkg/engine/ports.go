@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// Loopback publishes port on the IPv4 loopback under a host port the
+// engine picks, so concurrent runs on one host never ask for the same
+// one. It is the IPv4 loopback rather than localhost, which resolves to
+// ::1 first on macOS and Windows.
+func Loopback(port nat.Port) nat.PortMap {
+	return nat.PortMap{port: []nat.PortBinding{{HostIP: "127.0.0.1"}}}
+}
+
+// PublishedAddr is the host address a container's port was published on,
+// from ContainerInspect's NetworkSettings.Ports. An IPv4 binding wins over
+// an IPv6 one of the same port, and a binding on every interface is
+// reached through the loopback.
+func PublishedAddr(ports nat.PortMap, port nat.Port) (string, error) {
+	var ipv6 string
+	for _, b := range ports[port] {
+		if b.HostPort == "" || b.HostPort == "0" {
+			continue
+		}
+		ip := net.ParseIP(b.HostIP)
+		switch {
+		case ip == nil || ip.Equal(net.IPv4zero):
+			return net.JoinHostPort("127.0.0.1", b.HostPort), nil
+		case ip.To4() != nil:
+			return net.JoinHostPort(b.HostIP, b.HostPort), nil
+		case ipv6 != "":
+		case ip.IsUnspecified():
+			ipv6 = net.JoinHostPort("::1", b.HostPort)
+		default:
+			ipv6 = net.JoinHostPort(b.HostIP, b.HostPort)
+		}
+	}
+	if ipv6 == "" {
+		return "", fmt.Errorf("port %s is not published", port)
+	}
+	return ipv6, nil
+}
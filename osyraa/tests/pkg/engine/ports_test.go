@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishedAddr(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		bindings []nat.PortBinding
+		want     string
+	}{
+		{"loopback", []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: "49153"}}, "127.0.0.1:49153"},
+		{"every interface", []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "49153"}}, "127.0.0.1:49153"},
+		{"no host IP", []nat.PortBinding{{HostPort: "49153"}}, "127.0.0.1:49153"},
+		{"IPv4 after IPv6", []nat.PortBinding{{HostIP: "::", HostPort: "49154"}, {HostIP: "0.0.0.0", HostPort: "49153"}}, "127.0.0.1:49153"},
+		{"IPv6 only", []nat.PortBinding{{HostIP: "::", HostPort: "49154"}}, "[::1]:49154"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, err := PublishedAddr(nat.PortMap{"80/tcp": tc.bindings}, "80/tcp")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, addr)
+		})
+	}
+
+	_, err := PublishedAddr(nat.PortMap{"80/tcp": {{HostIP: "127.0.0.1"}}}, "80/tcp")
+	assert.ErrorContains(t, err, "80/tcp is not published")
+	_, err = PublishedAddr(nil, "80/tcp")
+	assert.Error(t, err)
+}
+
+func TestLoopback(t *testing.T) {
+	ports := Loopback("80/tcp")
+	assert.Equal(t, []nat.PortBinding{{HostIP: "127.0.0.1"}}, ports["80/tcp"])
+}
@@ -0,0 +1,271 @@
+// Package fixture writes small throwaway sites for testing the harness's
+// own checks. Each site is a Hugo source tree (config.toml, a resume in
+// content/_index.md and a layout) next to the public/ tree Hugo builds
+// from it, so a check can be run against known-good and known-bad input
+// without Hugo, Docker or the real resume.
+package fixture
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Defect is a fault written into a fixture site; defects combine with |
+type Defect uint
+
+const (
+	// BrokenLinks links pages, a stylesheet and an image that do not exist
+	BrokenLinks Defect = 1 << iota
+	// MissingMeta drops the doctype, lang, title, description and
+	// viewport from every page
+	MissingMeta
+	// HugeImages adds a photo of HugeImagePixels square that PNG cannot
+	// compress, several megabytes on disk
+	HugeImages
+)
+
+// Valid is a site with no defects
+const Valid Defect = 0
+
+// Defects lists every defect, for table-driven tests
+var Defects = []Defect{BrokenLinks, MissingMeta, HugeImages}
+
+// HugeImagePixels is the width and height of the HugeImages photo
+const HugeImagePixels = 1200
+
+// String names the defects in d, or "valid"
+func (d Defect) String() string {
+	var names []string
+	for _, defect := range Defects {
+		if d&defect == 0 {
+			continue
+		}
+		switch defect {
+		case BrokenLinks:
+			names = append(names, "broken-links")
+		case MissingMeta:
+			names = append(names, "missing-meta")
+		case HugeImages:
+			names = append(names, "huge-images")
+		}
+	}
+	if len(names) == 0 {
+		return "valid"
+	}
+	return strings.Join(names, "+")
+}
+
+// Site is a fixture written to disk
+type Site struct {
+	// Dir holds the Hugo source: config.toml, content/ and layouts/
+	Dir string
+	// Public is the built site, as Hugo would write it to Dir/public
+	Public string
+	// Content is the resume content file
+	Content string
+	Defects Defect
+}
+
+// Name and other details of the fixture resume
+const (
+	Name    = "Jane Doe"
+	Tagline = "Platform Engineer"
+	Email   = "jane@example.com"
+	BaseURL = "https://jane.example.com/"
+)
+
+const config = `baseURL = %q
+languageCode = "en-us"
+title = "Jane Doe - Resume"
+
+[params]
+  name = %q
+  tagline = %q
+  email = %q
+`
+
+const content = `---
+title: "Jane Doe - Resume"
+date: 2024-01-01
+---
+
+## Professional Summary
+
+Platform engineer who builds paved roads.
+
+## Experience
+
+### Staff Engineer
+**Acme Corp** | March 2021 - Present | Remote
+
+- Built the deployment platform
+- Ran the on-call rotation
+
+### Engineer
+**Initech** | January 2018 - February 2021 | Austin, TX
+
+- Automated the release process
+
+## Education
+
+**B.Sc. Computer Science** | State University | 2010 - 2014
+
+## Certifications
+
+- **Certified Kubernetes Administrator** (CKA)
+
+## Skills
+
+### Cloud
+- **AWS**: EC2, S3, RDS
+
+## Projects
+
+### Resume Site
+A static resume site.
+`
+
+const layout = `<!DOCTYPE html>
+<html lang="{{ .Site.LanguageCode }}">
+<head><meta charset="utf-8"><title>{{ .Title }}</title></head>
+<body><header><h1>{{ .Site.Params.name }}</h1></header><main>{{ .Content }}</main></body>
+</html>
+`
+
+// rendered is content/_index.md as Hugo renders it
+const rendered = `<h2 id="professional-summary">Professional Summary</h2>
+<p>Platform engineer who builds paved roads.</p>
+<h2 id="experience">Experience</h2>
+<h3 id="staff-engineer">Staff Engineer</h3>
+<p><strong>Acme Corp</strong> | March 2021 - Present | Remote</p>
+<ul><li>Built the deployment platform</li><li>Ran the on-call rotation</li></ul>
+<h3 id="engineer">Engineer</h3>
+<p><strong>Initech</strong> | January 2018 - February 2021 | Austin, TX</p>
+<ul><li>Automated the release process</li></ul>
+<h2 id="education">Education</h2>
+<p><strong>B.Sc. Computer Science</strong> | State University | 2010 - 2014</p>
+<h2 id="certifications">Certifications</h2>
+<ul><li><strong>Certified Kubernetes Administrator</strong> (CKA)</li></ul>
+<h2 id="skills">Skills</h2>
+<h3 id="cloud">Cloud</h3>
+<ul><li><strong>AWS</strong>: EC2, S3, RDS</li></ul>
+<h2 id="projects">Projects</h2>
+<h3 id="resume-site">Resume Site</h3>
+<p>A static resume site.</p>
+`
+
+// New writes a fixture site with the given defects to a temporary
+// directory removed when the test ends
+func New(t testing.TB, defects Defect) *Site {
+	t.Helper()
+	s, err := Write(t.TempDir(), defects)
+	if err != nil {
+		t.Fatalf("write %s fixture site: %v", defects, err)
+	}
+	return s
+}
+
+// Write creates a fixture site with the given defects in dir
+func Write(dir string, defects Defect) (*Site, error) {
+	s := &Site{
+		Dir:     dir,
+		Public:  filepath.Join(dir, "public"),
+		Content: filepath.Join(dir, "content", "_index.md"),
+		Defects: defects,
+	}
+	files := map[string]string{
+		"config.toml":             fmt.Sprintf(config, BaseURL, Name, Tagline, Email),
+		"content/_index.md":       content,
+		"layouts/index.html":      layout,
+		"public/index.html":       s.page("Jane Doe - Resume", rendered),
+		"public/about/index.html": s.page("About - Jane Doe", `<p>About me. <a href="/">Back to the resume</a></p>`),
+		"public/css/site.css":     "body{font-family:system-ui,sans-serif}\n",
+	}
+	for name, body := range files {
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(body)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writePNG(filepath.Join(s.Public, "img", "photo.png"), 64); err != nil {
+		return nil, err
+	}
+	if defects&HugeImages != 0 {
+		if err := writePNG(filepath.Join(s.Public, "img", "hero.png"), HugeImagePixels); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// page renders a public/ page around main with the site's defects
+func (s *Site) page(title, main string) string {
+	var b strings.Builder
+	if s.Defects&MissingMeta == 0 {
+		b.WriteString("<!DOCTYPE html>\n<html lang=\"en-us\">\n<head>\n<meta charset=\"utf-8\">\n")
+		b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+		fmt.Fprintf(&b, "<title>%s</title>\n<meta name=\"description\" content=\"Resume of %s, %s\">\n", title, Name, Tagline)
+	} else {
+		b.WriteString("<html>\n<head>\n")
+	}
+	b.WriteString("<link rel=\"stylesheet\" href=\"/css/site.css\">\n")
+	if s.Defects&BrokenLinks != 0 {
+		b.WriteString("<link rel=\"stylesheet\" href=\"/css/missing.css\">\n")
+	}
+	b.WriteString("</head>\n<body>\n<header>\n")
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<p class=\"tagline\">%s</p>\n", Name, Tagline)
+	fmt.Fprintf(&b, "<p class=\"contact-info\"><a href=\"mailto:%s\">%[1]s</a></p>\n", Email)
+	b.WriteString("<nav><a href=\"/\">Resume</a> <a href=\"/about/\">About</a>")
+	if s.Defects&BrokenLinks != 0 {
+		b.WriteString(" <a href=\"/blog/\">Blog</a>")
+	}
+	b.WriteString("</nav>\n<img src=\"/img/photo.png\" alt=\"\" width=\"64\" height=\"64\">\n")
+	if s.Defects&BrokenLinks != 0 {
+		b.WriteString("<img src=\"/img/missing.png\" alt=\"\">\n")
+	}
+	if s.Defects&HugeImages != 0 {
+		fmt.Fprintf(&b, "<img src=\"/img/hero.png\" alt=\"\" width=\"%d\" height=\"%[1]d\">\n", HugeImagePixels)
+	}
+	b.WriteString("</header>\n<main>\n")
+	b.WriteString(main)
+	b.WriteString("</main>\n</body>\n</html>\n")
+	return b.String()
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// writePNG writes a square of seeded noise, which PNG cannot compress, so
+// the file size follows the pixel count
+func writePNG(path string, size int) error {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	rng := rand.New(rand.NewPCG(uint64(size), 1))
+	for y := range size {
+		for x := range size {
+			v := rng.Uint32()
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(v), G: uint8(v >> 8), B: uint8(v >> 16), A: 0xff})
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
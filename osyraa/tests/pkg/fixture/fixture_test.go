@@ -0,0 +1,88 @@
+package fixture
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/ats"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/resume"
+)
+
+func crawlSite(t *testing.T, s *Site) *crawl.Result {
+	t.Helper()
+	site, err := crawl.Dir(context.Background(), s.Public)
+	require.NoError(t, err)
+	return site
+}
+
+func home(t *testing.T, site *crawl.Result) *match.Document {
+	t.Helper()
+	page := site.Page("/")
+	require.NotNil(t, page)
+	return match.FromNode(page.Doc)
+}
+
+func missing(site *crawl.Result) []string {
+	var paths []string
+	for _, p := range site.Missing {
+		paths = append(paths, p.URL.Path)
+	}
+	return paths
+}
+
+func TestValidSitePassesChecks(t *testing.T) {
+	s := New(t, Valid)
+	site := crawlSite(t, s)
+	doc := home(t, site)
+
+	assert.Empty(t, missing(site), "every link resolves")
+	assert.True(t, doc.HasDoctype())
+	assert.NoError(t, doc.Select("html[lang]").Exists())
+	assert.NoError(t, doc.Select("head > title").Exists())
+	assert.NoError(t, doc.Select(`meta[name="description"]`).Exists())
+	assert.Empty(t, ats.Headings(doc))
+	assert.Empty(t, ats.Images(doc))
+
+	data, err := resume.Load(s.Content)
+	require.NoError(t, err)
+	rendered, err := resume.FromHTML(doc)
+	require.NoError(t, err)
+	assert.Empty(t, resume.Compare(data, rendered), "public/ renders the content file")
+	assert.Len(t, data.Experience, 2)
+}
+
+func TestBrokenLinksAreFound(t *testing.T) {
+	site := crawlSite(t, New(t, BrokenLinks))
+	assert.ElementsMatch(t, []string{"/css/missing.css", "/blog/", "/img/missing.png"}, missing(site))
+}
+
+func TestMissingMetaIsFound(t *testing.T) {
+	doc := home(t, crawlSite(t, New(t, MissingMeta)))
+	assert.False(t, doc.HasDoctype())
+	assert.Error(t, doc.Select("html[lang]").Exists())
+	assert.Error(t, doc.Select("head > title").Exists())
+	assert.Error(t, doc.Select(`meta[name="description"]`).Exists())
+	assert.Error(t, doc.Select(`meta[name="viewport"]`).Exists())
+}
+
+func TestHugeImagesAreFound(t *testing.T) {
+	site := crawlSite(t, New(t, HugeImages))
+	hero := site.Page("/img/hero.png")
+	require.NotNil(t, hero, "the page links the huge image")
+	assert.Greater(t, len(hero.Body), 2<<20)
+	assert.Less(t, len(site.Page("/img/photo.png").Body), 64<<10)
+}
+
+func TestDefectsCombine(t *testing.T) {
+	assert.Equal(t, "valid", Valid.String())
+	assert.Equal(t, "broken-links+huge-images", (BrokenLinks | HugeImages).String())
+
+	site := crawlSite(t, New(t, BrokenLinks|MissingMeta))
+	assert.Len(t, site.Missing, 3)
+	assert.False(t, home(t, site).HasDoctype())
+}
@@ -0,0 +1,328 @@
+// Package flaky keeps a history of every check's outcome across runs and
+// flags checks that both pass and fail at the same code revision, which
+// only nondeterminism explains. Checks on the quarantine list are still
+// run and reported, but their failures do not gate the build until they
+// pass reliably again.
+package flaky
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record is one check's outcome in one run
+type Record struct {
+	At time.Time `json:"at"`
+	// Revision is the code the run tested; empty when unknown, e.g. for
+	// a working tree with uncommitted changes
+	Revision string `json:"revision,omitempty"`
+	Check    string `json:"check"`
+	Passed   bool   `json:"passed"`
+}
+
+// Options tune detection
+type Options struct {
+	// Window is how many of each check's latest records are considered
+	Window int
+	// Stable is how many passes in a row release a check from quarantine
+	Stable int
+}
+
+// DefaultOptions looks at the last 20 outcomes and releases a check after
+// 10 straight passes
+func DefaultOptions() Options {
+	return Options{Window: 20, Stable: 10}
+}
+
+// LoadHistory reads records saved as JSON lines; a missing file is an
+// empty history
+func LoadHistory(file string) ([]Record, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var history []Record
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, n, err)
+		}
+		history = append(history, r)
+	}
+	return history, scanner.Err()
+}
+
+// AppendHistory adds records to the file, creating it if needed
+func AppendHistory(file string, records []Record) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// LoadQuarantine reads the quarantined check names, one per line; blank
+// lines and lines starting with # are skipped. A missing file is an empty
+// list.
+func LoadQuarantine(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var checks []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			checks = append(checks, line)
+		}
+	}
+	return checks, nil
+}
+
+// WriteQuarantine replaces the quarantine file with checks, sorted, under
+// a comment explaining the file
+func WriteQuarantine(file string, checks []string) error {
+	checks = slices.Clone(checks)
+	sort.Strings(checks)
+	var b strings.Builder
+	b.WriteString("# Checks whose failures are reported but do not fail the run, one per\n")
+	b.WriteString("# line. Maintained by `osyraa flaky -update`; a check leaves once it\n")
+	b.WriteString("# passes reliably again.\n")
+	for _, c := range checks {
+		b.WriteString(c + "\n")
+	}
+	return os.WriteFile(file, []byte(b.String()), 0o644)
+}
+
+// testEvent is a line of `go test -json` output
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+}
+
+// ReadGoTest reads `go test -json` output and returns the outcome of every
+// test and subtest that passed or failed, stamped with at and revision.
+// Checks are named "<package>.<Test>" by the package's last path element,
+// e.g. "tests.TestHugoSuite/TestSiteCrawl". A package that failed without
+// any test failing, such as one that did not compile, is recorded under
+// its own name so it still gates.
+func ReadGoTest(r io.Reader, at time.Time, revision string) ([]Record, error) {
+	var records []Record
+	failedTests := map[string]bool{}
+	dec := json.NewDecoder(r)
+	for {
+		var e testEvent
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read go test -json output: %w", err)
+		}
+		if e.Action != "pass" && e.Action != "fail" {
+			continue
+		}
+		pkg := path.Base(e.Package)
+		if e.Test == "" {
+			if e.Action == "fail" && !failedTests[pkg] {
+				records = append(records, Record{At: at, Revision: revision, Check: pkg, Passed: false})
+			}
+			continue
+		}
+		if e.Action == "fail" {
+			failedTests[pkg] = true
+		}
+		records = append(records, Record{At: at, Revision: revision, Check: pkg + "." + e.Test, Passed: e.Action == "pass"})
+	}
+	return records, nil
+}
+
+// Finding is a check whose recent history is mixed
+type Finding struct {
+	Check string
+	// Revision is the code the check both passed and failed at
+	Revision      string
+	Passes, Fails int
+	Flips         int
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s passed %d and failed %d times at %s (%d flips)", f.Check, f.Passes, f.Fails, short(f.Revision), f.Flips)
+}
+
+func short(revision string) string {
+	if len(revision) > 12 {
+		return revision[:12]
+	}
+	return revision
+}
+
+// byCheck groups records per check, oldest first, keeping the latest
+// window of each
+func byCheck(history []Record, window int) map[string][]Record {
+	sorted := slices.Clone(history)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+	checks := map[string][]Record{}
+	for _, r := range sorted {
+		checks[r.Check] = append(checks[r.Check], r)
+	}
+	for c, rs := range checks {
+		if window > 0 && len(rs) > window {
+			checks[c] = rs[len(rs)-window:]
+		}
+	}
+	return checks
+}
+
+// Detect returns the checks that passed and failed at the same known
+// revision within their latest records, sorted by name
+func Detect(history []Record, opts Options) []Finding {
+	var findings []Finding
+	for check, records := range byCheck(history, opts.Window) {
+		revisions := map[string][]Record{}
+		for _, r := range records {
+			if r.Revision != "" {
+				revisions[r.Revision] = append(revisions[r.Revision], r)
+			}
+		}
+		var worst *Finding
+		for rev, rs := range revisions {
+			f := Finding{Check: check, Revision: rev}
+			for i, r := range rs {
+				if r.Passed {
+					f.Passes++
+				} else {
+					f.Fails++
+				}
+				if i > 0 && r.Passed != rs[i-1].Passed {
+					f.Flips++
+				}
+			}
+			if f.Passes == 0 || f.Fails == 0 {
+				continue
+			}
+			if worst == nil || f.Flips > worst.Flips || (f.Flips == worst.Flips && f.Revision < worst.Revision) {
+				worst = &f
+			}
+		}
+		if worst != nil {
+			findings = append(findings, *worst)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Check < findings[j].Check })
+	return findings
+}
+
+// Stable returns the quarantined checks whose latest opts.Stable records
+// are all passes
+func Stable(history []Record, quarantine []string, opts Options) []string {
+	checks := byCheck(history, 0)
+	var stable []string
+	for _, q := range quarantine {
+		rs := checks[q]
+		if opts.Stable <= 0 || len(rs) < opts.Stable {
+			continue
+		}
+		if !slices.ContainsFunc(rs[len(rs)-opts.Stable:], func(r Record) bool { return !r.Passed }) {
+			stable = append(stable, q)
+		}
+	}
+	return stable
+}
+
+// Gate splits a run's failures into those that fail the run and those
+// excused by the quarantine. A parent test fails whenever a subtest does,
+// so a parent is excused when every failing subtest is.
+func Gate(run []Record, quarantine []string) (gating, excused []string) {
+	var failed []string
+	for _, r := range run {
+		if !r.Passed {
+			failed = append(failed, r.Check)
+		}
+	}
+	isExcused := map[string]bool{}
+	// Deepest first, so subtests are settled before their parents
+	order := slices.Clone(failed)
+	sort.SliceStable(order, func(i, j int) bool { return strings.Count(order[i], "/") > strings.Count(order[j], "/") })
+	for _, check := range order {
+		if slices.Contains(quarantine, check) {
+			isExcused[check] = true
+			continue
+		}
+		children := false
+		all := true
+		for _, other := range failed {
+			if strings.HasPrefix(other, check+"/") && !strings.Contains(other[len(check)+1:], "/") {
+				children = true
+				all = all && isExcused[other]
+			}
+		}
+		isExcused[check] = children && all
+	}
+	for _, check := range failed {
+		if isExcused[check] {
+			excused = append(excused, check)
+		} else {
+			gating = append(gating, check)
+		}
+	}
+	return gating, excused
+}
+
+// Trend compares a run with each check's previous outcome in history,
+// which may already include the run: broke lists checks that failed after
+// passing, fixed those that passed after failing. Checks without earlier
+// outcomes are in neither.
+func Trend(history, run []Record) (broke, fixed []string) {
+	if len(run) == 0 {
+		return nil, nil
+	}
+	previous := map[string]Record{}
+	for _, r := range history {
+		if !r.At.Before(run[0].At) {
+			continue
+		}
+		if p, ok := previous[r.Check]; !ok || !r.At.Before(p.At) {
+			previous[r.Check] = r
+		}
+	}
+	for _, r := range run {
+		p, ok := previous[r.Check]
+		switch {
+		case !ok || p.Passed == r.Passed:
+		case r.Passed:
+			fixed = append(fixed, r.Check)
+		default:
+			broke = append(broke, r.Check)
+		}
+	}
+	sort.Strings(broke)
+	sort.Strings(fixed)
+	return broke, fixed
+}
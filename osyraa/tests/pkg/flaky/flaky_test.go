@@ -0,0 +1,117 @@
+package flaky
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// runs builds a history from outcome strings, one character per run:
+// p for pass, f for fail
+func runs(check, revision, outcomes string) []Record {
+	var rs []Record
+	for i, o := range outcomes {
+		rs = append(rs, Record{At: start.Add(time.Duration(i) * time.Hour), Revision: revision, Check: check, Passed: o == 'p'})
+	}
+	return rs
+}
+
+func TestReadGoTest(t *testing.T) {
+	out := `{"Action":"run","Package":"example.com/m/tests","Test":"TestSuite"}
+{"Action":"pass","Package":"example.com/m/tests","Test":"TestSuite/TestA","Elapsed":0.1}
+{"Action":"fail","Package":"example.com/m/tests","Test":"TestSuite/TestB","Elapsed":0.1}
+{"Action":"skip","Package":"example.com/m/tests","Test":"TestSuite/TestC","Elapsed":0}
+{"Action":"fail","Package":"example.com/m/tests","Test":"TestSuite","Elapsed":0.2}
+{"Action":"fail","Package":"example.com/m/tests","Elapsed":0.3}
+{"Action":"fail","Package":"example.com/m/pkg/broken","Elapsed":0}
+`
+	records, err := ReadGoTest(strings.NewReader(out), start, "abc")
+	require.NoError(t, err)
+	var got []string
+	for _, r := range records {
+		assert.Equal(t, "abc", r.Revision)
+		got = append(got, r.Check+map[bool]string{true: " pass", false: " fail"}[r.Passed])
+	}
+	assert.Equal(t, []string{"tests.TestSuite/TestA pass", "tests.TestSuite/TestB fail", "tests.TestSuite fail", "broken fail"}, got,
+		"a package fails on its own only when no test explains it")
+}
+
+func TestDetect(t *testing.T) {
+	var history []Record
+	history = append(history, runs("steady", "r1", "ppppp")...)
+	history = append(history, runs("broken", "r1", "fffff")...)
+	history = append(history, runs("flaky", "r1", "ppfpfp")...)
+	// Failing before a fix and passing after is a code change, not a flake
+	history = append(history, runs("fixed", "r1", "fff")...)
+	history = append(history, runs("fixed", "r2", "ppp")...)
+	// Runs of uncommitted code are not compared
+	history = append(history, runs("local", "", "pfpf")...)
+
+	findings := Detect(history, DefaultOptions())
+	require.Len(t, findings, 1)
+	assert.Equal(t, Finding{Check: "flaky", Revision: "r1", Passes: 4, Fails: 2, Flips: 4}, findings[0])
+
+	// An old flake drops out of the window
+	assert.Empty(t, Detect(runs("old", "r1", "fp"+strings.Repeat("p", 20)), DefaultOptions()))
+}
+
+func TestStable(t *testing.T) {
+	history := append(runs("recovered", "r1", "fpppppppppppp"), runs("still", "r1", "pppppppppfp")...)
+	history = append(history, runs("new", "r1", "ppp")...)
+	assert.Equal(t, []string{"recovered"}, Stable(history, []string{"recovered", "still", "new", "unknown"}, DefaultOptions()))
+}
+
+func TestGate(t *testing.T) {
+	run := []Record{
+		{Check: "tests.TestSuite/TestFlaky", Passed: false},
+		{Check: "tests.TestSuite/TestOK", Passed: true},
+		{Check: "tests.TestSuite", Passed: false},
+		{Check: "tests.TestOther/TestBroken", Passed: false},
+		{Check: "tests.TestOther/TestFlaky", Passed: false},
+		{Check: "tests.TestOther", Passed: false},
+	}
+	gating, excused := Gate(run, []string{"tests.TestSuite/TestFlaky", "tests.TestOther/TestFlaky"})
+	assert.Equal(t, []string{"tests.TestOther/TestBroken", "tests.TestOther"}, gating)
+	assert.Equal(t, []string{"tests.TestSuite/TestFlaky", "tests.TestSuite", "tests.TestOther/TestFlaky"}, excused)
+}
+
+func TestTrend(t *testing.T) {
+	history := append(runs("broke", "r1", "pp"), runs("fixed", "r1", "ff")...)
+	history = append(history, runs("same", "r1", "pf")...)
+	at := start.Add(2 * time.Hour)
+	run := []Record{
+		{At: at, Check: "broke", Passed: false},
+		{At: at, Check: "fixed", Passed: true},
+		{At: at, Check: "same", Passed: false},
+		{At: at, Check: "new", Passed: false},
+	}
+	broke, fixed := Trend(append(history, run...), run)
+	assert.Equal(t, []string{"broke"}, broke)
+	assert.Equal(t, []string{"fixed"}, fixed)
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	history := filepath.Join(dir, "history.jsonl")
+	loaded, err := LoadHistory(history)
+	require.NoError(t, err)
+	assert.Empty(t, loaded)
+
+	require.NoError(t, AppendHistory(history, runs("a", "r1", "pf")))
+	require.NoError(t, AppendHistory(history, runs("b", "r1", "p")))
+	loaded, err = LoadHistory(history)
+	require.NoError(t, err)
+	assert.Len(t, loaded, 3)
+
+	quarantine := filepath.Join(dir, "quarantine.txt")
+	require.NoError(t, WriteQuarantine(quarantine, []string{"z", "a"}))
+	checks, err := LoadQuarantine(quarantine)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "z"}, checks)
+}
@@ -0,0 +1,184 @@
+// Package fonts checks the site's self-hosted web fonts: every @font-face
+// should offer WOFF2, avoid invisible text while loading, exist, be
+// subset to a reasonable size and be served as font/woff2 with a long
+// cache lifetime.
+package fonts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+)
+
+// DefaultMaxSize is the largest WOFF2 file accepted; a Latin subset of a
+// text face is usually well under this
+const DefaultMaxSize = 100 << 10
+
+// Source is one url() in a face's src descriptor
+type Source struct {
+	URL    *url.URL
+	Format string
+}
+
+// Face is an @font-face rule
+type Face struct {
+	Family  string
+	Display string
+	Sources []Source
+	// DeclaredIn is the path of the stylesheet or page with the rule
+	DeclaredIn string
+	// host is where the rule was declared; fonts on other hosts are not
+	// the site's to fix
+	host string
+}
+
+var (
+	fontFaceRe = regexp.MustCompile(`(?is)@font-face\s*\{([^}]*)\}`)
+	srcURLRe   = regexp.MustCompile(`(?i)url\(\s*['"]?([^'")]+?)['"]?\s*\)(?:\s*format\(\s*['"]?([\w-]+)['"]?\s*\))?`)
+)
+
+// Parse returns the @font-face rules of a stylesheet, resolving source
+// URLs against base
+func Parse(css string, base *url.URL) []Face {
+	var faces []Face
+	for _, m := range fontFaceRe.FindAllStringSubmatch(css, -1) {
+		var face Face
+		for _, decl := range strings.Split(m[1], ";") {
+			name, value, ok := strings.Cut(decl, ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "font-family":
+				face.Family = strings.Trim(value, `"' `)
+			case "font-display":
+				face.Display = strings.ToLower(value)
+			case "src":
+				for _, s := range srcURLRe.FindAllStringSubmatch(value, -1) {
+					u, err := base.Parse(s[1])
+					if err != nil {
+						continue
+					}
+					face.Sources = append(face.Sources, Source{URL: u, Format: strings.ToLower(s[2])})
+				}
+			}
+		}
+		face.DeclaredIn, face.host = base.Path, base.Host
+		faces = append(faces, face)
+	}
+	return faces
+}
+
+// Faces collects the @font-face rules from crawled stylesheets and the
+// <style> blocks of crawled pages
+func Faces(pages []*crawl.Page) []Face {
+	var faces []Face
+	for _, p := range pages {
+		switch {
+		case p.Doc != nil:
+			for _, n := range match.FromNode(p.Doc).Select("style").Nodes() {
+				if n.FirstChild != nil {
+					faces = append(faces, Parse(n.FirstChild.Data, p.URL)...)
+				}
+			}
+		case strings.HasPrefix(p.Header.Get("Content-Type"), "text/css"):
+			faces = append(faces, Parse(string(p.Body), p.URL)...)
+		}
+	}
+	return faces
+}
+
+// WOFF2 returns the face's WOFF2 source, if it has one
+func (f Face) WOFF2() (Source, bool) {
+	for _, s := range f.Sources {
+		if s.Format == "woff2" || strings.HasSuffix(strings.ToLower(s.URL.Path), ".woff2") {
+			return s, true
+		}
+	}
+	return Source{}, false
+}
+
+// Options tune Check
+type Options struct {
+	// MaxSize caps the WOFF2 size in bytes; zero means DefaultMaxSize
+	MaxSize int64
+	// MinCacheAge is the shortest Cache-Control max-age accepted; zero
+	// skips header checks, for fetchers that read files from disk
+	MinCacheAge time.Duration
+}
+
+// Check fetches each face's WOFF2 source with f and reports every problem.
+// Faces hosted elsewhere are checked for their descriptors only.
+func Check(ctx context.Context, f crawl.Fetcher, faces []Face, opts Options) ([]string, error) {
+	if opts.MaxSize == 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	var problems []string
+	for _, face := range faces {
+		id := fmt.Sprintf("%s: @font-face %q", face.DeclaredIn, face.Family)
+		switch face.Display {
+		case "swap", "fallback", "optional":
+		case "":
+			problems = append(problems, id+" has no font-display, so text stays invisible while it loads")
+		default:
+			problems = append(problems, fmt.Sprintf("%s uses font-display: %s, which hides text while it loads", id, face.Display))
+		}
+		src, ok := face.WOFF2()
+		if !ok {
+			problems = append(problems, id+" has no WOFF2 source")
+			continue
+		}
+		if src.URL.Host != face.host {
+			continue
+		}
+		page, err := f.Fetch(ctx, src.URL)
+		if err != nil {
+			return problems, err
+		}
+		problems = append(problems, checkFile(id, src.URL.Path, page, opts)...)
+	}
+	return problems, nil
+}
+
+func checkFile(id, path string, page *crawl.Page, opts Options) []string {
+	if page.Status != http.StatusOK {
+		return []string{fmt.Sprintf("%s: %s returned %d", id, path, page.Status)}
+	}
+	var problems []string
+	if !strings.HasPrefix(string(page.Body), "wOF2") {
+		problems = append(problems, fmt.Sprintf("%s: %s is not a WOFF2 file", id, path))
+	}
+	if size := int64(len(page.Body)); size > opts.MaxSize {
+		problems = append(problems, fmt.Sprintf("%s: %s is %d KiB, over %d KiB; subset it", id, path, size>>10, opts.MaxSize>>10))
+	}
+	if opts.MinCacheAge > 0 {
+		if ct := page.Header.Get("Content-Type"); ct != "font/woff2" {
+			problems = append(problems, fmt.Sprintf("%s: %s served as %q, want font/woff2", id, path, ct))
+		}
+		if age := maxAge(page.Header.Get("Cache-Control")); age < opts.MinCacheAge {
+			problems = append(problems, fmt.Sprintf("%s: %s is cached for %v, want at least %v", id, path, age, opts.MinCacheAge))
+		}
+	}
+	return problems
+}
+
+// maxAge reads max-age from a Cache-Control header, zero when absent
+func maxAge(cc string) time.Duration {
+	for _, d := range strings.Split(cc, ",") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(strings.ToLower(d)), "max-age="); ok {
+			if secs, err := strconv.Atoi(v); err == nil {
+				return time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return 0
+}
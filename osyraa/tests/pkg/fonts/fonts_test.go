@@ -0,0 +1,144 @@
+package fonts
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+)
+
+const css = `
+body { font-family: Inter, sans-serif; }
+@font-face {
+	font-family: "Inter";
+	font-display: swap;
+	src: url("/fonts/inter.woff2") format("woff2"), url(/fonts/inter.woff) format("woff");
+}
+@font-face {
+	font-family: 'Mono';
+	font-display: block;
+	src: url(mono.ttf);
+}
+@font-face {
+	font-family: Remote;
+	src: url(https://fonts.example/remote.woff2);
+}
+`
+
+func site() *url.URL {
+	return &url.URL{Scheme: "http", Host: "localhost", Path: "/css/site.css"}
+}
+
+func TestParse(t *testing.T) {
+	faces := Parse(css, site())
+	require.Len(t, faces, 3)
+
+	assert.Equal(t, "Inter", faces[0].Family)
+	assert.Equal(t, "swap", faces[0].Display)
+	assert.Equal(t, "/css/site.css", faces[0].DeclaredIn)
+	require.Len(t, faces[0].Sources, 2)
+	src, ok := faces[0].WOFF2()
+	require.True(t, ok)
+	assert.Equal(t, "http://localhost/fonts/inter.woff2", src.URL.String())
+
+	assert.Equal(t, "Mono", faces[1].Family)
+	assert.Equal(t, "/css/mono.ttf", faces[1].Sources[0].URL.Path)
+	_, ok = faces[1].WOFF2()
+	assert.False(t, ok)
+
+	src, ok = faces[2].WOFF2()
+	require.True(t, ok, "the extension identifies WOFF2 without format()")
+	assert.Equal(t, "fonts.example", src.URL.Host)
+}
+
+func TestFaces(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "index.html"), []byte(
+		`<html><head><link rel="stylesheet" href="/site.css"><style>@font-face{font-family:Inline;font-display:swap;src:url(/i.woff2)}</style></head><body></body></html>`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "site.css"), []byte(css), 0o644))
+
+	result, err := crawl.Dir(context.Background(), root)
+	require.NoError(t, err)
+
+	var families []string
+	for _, f := range Faces(result.Pages) {
+		families = append(families, f.Family)
+	}
+	assert.ElementsMatch(t, []string{"Inline", "Inter", "Mono", "Remote"}, families)
+}
+
+// fetcher serves canned pages by path
+type fetcher map[string]*crawl.Page
+
+func (f fetcher) Fetch(_ context.Context, u *url.URL) (*crawl.Page, error) {
+	if p, ok := f[u.Path]; ok {
+		return p, nil
+	}
+	return &crawl.Page{URL: u, Status: http.StatusNotFound, Header: http.Header{}}, nil
+}
+
+func font(size int, header http.Header) *crawl.Page {
+	body := []byte("wOF2" + strings.Repeat("x", size-4))
+	return &crawl.Page{Status: http.StatusOK, Header: header, Body: body}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	faces := Parse(css, site())[:1]
+	served := http.Header{"Content-Type": {"font/woff2"}, "Cache-Control": {"public, max-age=31536000, immutable"}}
+	opts := Options{MinCacheAge: 30 * 24 * time.Hour}
+
+	t.Run("good", func(t *testing.T) {
+		problems, err := Check(ctx, fetcher{"/fonts/inter.woff2": font(40<<10, served)}, faces, opts)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		problems, err := Check(ctx, fetcher{}, faces, opts)
+		require.NoError(t, err)
+		assert.Equal(t, []string{`/css/site.css: @font-face "Inter": /fonts/inter.woff2 returned 404`}, problems)
+	})
+
+	t.Run("served badly", func(t *testing.T) {
+		header := http.Header{"Content-Type": {"application/octet-stream"}, "Cache-Control": {"max-age=3600"}}
+		page := font(300<<10, header)
+		problems, err := Check(ctx, fetcher{"/fonts/inter.woff2": page}, faces, opts)
+		require.NoError(t, err)
+		require.Len(t, problems, 3)
+		assert.Contains(t, problems[0], "is 300 KiB, over 100 KiB")
+		assert.Contains(t, problems[1], `served as "application/octet-stream"`)
+		assert.Contains(t, problems[2], "cached for 1h0m0s")
+	})
+
+	t.Run("headers skipped for files", func(t *testing.T) {
+		problems, err := Check(ctx, fetcher{"/fonts/inter.woff2": font(1<<10, http.Header{})}, faces, Options{})
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("descriptors", func(t *testing.T) {
+		problems, err := Check(ctx, fetcher{}, Parse(css, site())[1:], Options{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			`/css/site.css: @font-face "Mono" uses font-display: block, which hides text while it loads`,
+			`/css/site.css: @font-face "Mono" has no WOFF2 source`,
+			`/css/site.css: @font-face "Remote" has no font-display, so text stays invisible while it loads`,
+		}, problems, "the remote WOFF2 is not fetched")
+	})
+}
+
+func TestMaxAge(t *testing.T) {
+	assert.Equal(t, time.Hour, maxAge("public, max-age=3600"))
+	assert.Equal(t, time.Duration(0), maxAge("no-store"))
+	assert.Equal(t, time.Duration(0), maxAge(""))
+}
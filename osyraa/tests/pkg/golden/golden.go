@@ -0,0 +1,41 @@
+// Package golden keeps the expected output of a check in a directory of
+// files committed with the tests. A missing file is an error rather than
+// a new baseline, so a clean checkout or a CI run can never pass by
+// comparing the output with itself; baselines are only written when an
+// update is asked for.
+package golden
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrMissing is returned by Load when the file has not been stored yet
+var ErrMissing = errors.New("no golden file")
+
+// Dir is a directory of golden files
+type Dir struct {
+	Path string
+	// Update overwrites the files with the new output instead of
+	// comparing, to accept an intended change or store a new one
+	Update bool
+}
+
+// Load returns the golden file called name to compare got with. With
+// Update set it stores got instead and reports updated.
+func (d Dir) Load(name string, got []byte) (want []byte, updated bool, err error) {
+	path := filepath.Join(d.Path, name)
+	if d.Update {
+		if err := os.MkdirAll(d.Path, 0o755); err != nil {
+			return nil, false, err
+		}
+		return nil, true, os.WriteFile(path, got, 0o644)
+	}
+	want, err = os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, fmt.Errorf("%w %s", ErrMissing, path)
+	}
+	return want, false, err
+}
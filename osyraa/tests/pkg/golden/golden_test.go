@@ -0,0 +1,35 @@
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	d := Dir{Path: filepath.Join(t.TempDir(), "golden")}
+
+	_, updated, err := d.Load("home.html", []byte("new"))
+	assert.ErrorIs(t, err, ErrMissing, "a missing file fails rather than passing")
+	assert.ErrorContains(t, err, "home.html")
+	assert.False(t, updated)
+	assert.NoDirExists(t, d.Path, "nothing is stored without Update")
+
+	d.Update = true
+	want, updated, err := d.Load("home.html", []byte("new"))
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Nil(t, want)
+	stored, err := os.ReadFile(filepath.Join(d.Path, "home.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(stored))
+
+	d.Update = false
+	want, updated, err = d.Load("home.html", []byte("newer"))
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.Equal(t, "new", string(want), "the stored file is returned for comparison")
+}
@@ -0,0 +1,145 @@
+// Package hardening checks that a running container is confined the way
+// it is assumed to be: no privileged mode, no host namespaces, no host
+// devices or sensitive host paths, and no way to gain privileges. The
+// runtime configuration and the kernel's view from /proc are both checked,
+// since either can be changed without the other noticing.
+package hardening
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// DangerousCaps are capabilities that amount to host access from inside a
+// container, keyed by their bit number in /proc CapEff
+var DangerousCaps = map[int]string{
+	2:  "DAC_READ_SEARCH",
+	12: "NET_ADMIN",
+	16: "SYS_MODULE",
+	17: "SYS_RAWIO",
+	19: "SYS_PTRACE",
+	21: "SYS_ADMIN",
+	22: "SYS_BOOT",
+	25: "SYS_TIME",
+}
+
+// sensitiveHostPaths must never be bind-mounted into the container
+var sensitiveHostPaths = []string{
+	"/", "/boot", "/dev", "/etc", "/proc", "/root", "/sys",
+	"/var/lib/docker", "/var/run/docker.sock", "/run/docker.sock", "/run/containerd",
+}
+
+// CheckHostConfig reports every way hc weakens the container's isolation
+func CheckHostConfig(hc *container.HostConfig) error {
+	var errs []error
+	if hc.Privileged {
+		errs = append(errs, errors.New("container is privileged"))
+	}
+	for name, host := range map[string]bool{
+		"PID":     hc.PidMode.IsHost(),
+		"network": hc.NetworkMode.IsHost(),
+		"IPC":     hc.IpcMode.IsHost(),
+		"UTS":     hc.UTSMode.IsHost(),
+		"cgroup":  hc.CgroupnsMode.IsHost(),
+	} {
+		if host {
+			errs = append(errs, fmt.Errorf("container shares the host %s namespace", name))
+		}
+	}
+	for _, d := range hc.Devices {
+		errs = append(errs, fmt.Errorf("host device %s is mapped into the container", d.PathOnHost))
+	}
+	for _, rule := range hc.DeviceCgroupRules {
+		errs = append(errs, fmt.Errorf("device cgroup rule %q grants device access", rule))
+	}
+	for _, bind := range hc.Binds {
+		src, _, _ := strings.Cut(bind, ":")
+		if sensitiveHostPath(src) {
+			errs = append(errs, fmt.Errorf("host path %s is bind-mounted", src))
+		}
+	}
+	for _, m := range hc.Mounts {
+		if m.Type == "bind" && sensitiveHostPath(m.Source) {
+			errs = append(errs, fmt.Errorf("host path %s is bind-mounted", m.Source))
+		}
+	}
+	for _, c := range hc.CapAdd {
+		name := strings.TrimPrefix(strings.ToUpper(c), "CAP_")
+		if name == "ALL" || dangerousCapName(name) {
+			errs = append(errs, fmt.Errorf("capability %s is added", name))
+		}
+	}
+
+	noNewPrivs := false
+	for _, opt := range hc.SecurityOpt {
+		switch strings.ReplaceAll(opt, "=", ":") {
+		case "no-new-privileges", "no-new-privileges:true":
+			noNewPrivs = true
+		case "seccomp:unconfined", "apparmor:unconfined", "label:disable":
+			errs = append(errs, fmt.Errorf("security option %s disables confinement", opt))
+		}
+	}
+	if !noNewPrivs {
+		errs = append(errs, errors.New("no-new-privileges is not set"))
+	}
+	return errors.Join(errs...)
+}
+
+func sensitiveHostPath(p string) bool {
+	p = path.Clean(p)
+	for _, s := range sensitiveHostPaths {
+		if p == s {
+			return true
+		}
+	}
+	return false
+}
+
+func dangerousCapName(name string) bool {
+	for _, c := range DangerousCaps {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseStatus reads the "Key:\tvalue" lines of /proc/<pid>/status
+func ParseStatus(status string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(status, "\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			fields[k] = strings.TrimSpace(v)
+		}
+	}
+	return fields
+}
+
+// CheckProcStatus checks a process from the kernel's side: no_new_privs
+// must be set, seccomp must be filtering and the effective capability set
+// must hold none of DangerousCaps
+func CheckProcStatus(status string) error {
+	fields := ParseStatus(status)
+	var errs []error
+	if fields["NoNewPrivs"] != "1" {
+		errs = append(errs, fmt.Errorf("NoNewPrivs is %q, want 1", fields["NoNewPrivs"]))
+	}
+	if s, ok := fields["Seccomp"]; !ok || s == "0" {
+		errs = append(errs, fmt.Errorf("seccomp is not enabled (Seccomp %q)", s))
+	}
+	capEff, err := strconv.ParseUint(fields["CapEff"], 16, 64)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("unreadable CapEff %q", fields["CapEff"]))
+	}
+	for bit := 0; bit < 64; bit++ {
+		if name, ok := DangerousCaps[bit]; ok && capEff&(1<<bit) != 0 {
+			errs = append(errs, fmt.Errorf("effective capabilities include %s", name))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,65 @@
+package hardening
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHostConfig(t *testing.T) {
+	assert.NoError(t, CheckHostConfig(&container.HostConfig{
+		SecurityOpt: []string{"no-new-privileges:true"},
+		Binds:       []string{"/srv/site:/usr/share/nginx/html:ro"},
+		CapAdd:      []string{"NET_BIND_SERVICE"},
+	}))
+	assert.NoError(t, CheckHostConfig(&container.HostConfig{SecurityOpt: []string{"no-new-privileges"}}))
+
+	err := CheckHostConfig(&container.HostConfig{
+		Privileged:  true,
+		PidMode:     "host",
+		NetworkMode: "host",
+		Resources: container.Resources{
+			Devices: []container.DeviceMapping{{PathOnHost: "/dev/mem", PathInContainer: "/dev/mem"}},
+		},
+		Binds:       []string{"/var/run/docker.sock:/var/run/docker.sock"},
+		Mounts:      []mount.Mount{{Type: mount.TypeBind, Source: "/etc/", Target: "/host-etc"}},
+		CapAdd:      []string{"CAP_SYS_ADMIN"},
+		SecurityOpt: []string{"seccomp=unconfined"},
+	})
+	for _, want := range []string{
+		"container is privileged",
+		"host PID namespace",
+		"host network namespace",
+		"host device /dev/mem",
+		"host path /var/run/docker.sock",
+		"host path /etc",
+		"capability SYS_ADMIN",
+		"seccomp=unconfined disables confinement",
+		"no-new-privileges is not set",
+	} {
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+const status = `Name:	nginx
+Uid:	0	0	0	0
+CapEff:	00000000a80425fb
+NoNewPrivs:	1
+Seccomp:	2
+Seccomp_filters:	1
+`
+
+func TestCheckProcStatus(t *testing.T) {
+	// 00000000a80425fb is Docker's default capability set
+	assert.NoError(t, CheckProcStatus(status))
+
+	privileged := "CapEff:\t000001ffffffffff\nNoNewPrivs:\t0\nSeccomp:\t0\n"
+	err := CheckProcStatus(privileged)
+	for _, want := range []string{`NoNewPrivs is "0"`, "seccomp is not enabled", "SYS_ADMIN", "SYS_PTRACE", "NET_ADMIN"} {
+		assert.ErrorContains(t, err, want)
+	}
+
+	assert.ErrorContains(t, CheckProcStatus("NoNewPrivs:\t1\nSeccomp:\t2\n"), "unreadable CapEff")
+}
@@ -0,0 +1,310 @@
+// Package headerpolicy checks a site's response headers against a policy
+// read from YAML: which headers each path must send, matched exactly, by
+// regular expression, by the directives a list header carries, or
+// directive by directive for Content-Security-Policy. Every violation on
+// every page is reported, rather than stopping at the first.
+package headerpolicy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/csp"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is a header policy file
+type Policy struct {
+	// Pages are the paths fetched to check the rules; the default is /
+	Pages []string `yaml:"pages"`
+	Rules []Rule   `yaml:"rules"`
+}
+
+// Rule is what one header must look like on the paths it covers. Every
+// matcher set applies.
+type Rule struct {
+	Header string `yaml:"header"`
+	// Paths are globs as in crawl scopes; empty covers every page
+	Paths []string `yaml:"paths"`
+	// HTTPSOnly skips the rule on plain HTTP, where browsers ignore
+	// headers such as Strict-Transport-Security
+	HTTPSOnly bool `yaml:"https-only"`
+
+	// Present only requires the header
+	Present bool `yaml:"present"`
+	// Absent forbids the header, e.g. X-Powered-By
+	Absent bool `yaml:"absent"`
+	// Exact is the value the header must have, sent once
+	Exact string `yaml:"exact"`
+	// Regex must match the value; anchor it with ^ and $ to match all of it
+	Regex string `yaml:"regex"`
+	// Directives must each appear in a list header such as Cache-Control
+	// or Permissions-Policy, as a name (no-store, includeSubDomains) or
+	// name=value (camera=()). Names compare case-insensitively.
+	Directives []string `yaml:"directives"`
+	// MinMaxAge is the least max-age directive allowed, for
+	// Strict-Transport-Security or Cache-Control
+	MinMaxAge time.Duration `yaml:"min-max-age"`
+	// CSP checks a Content-Security-Policy header directive by directive
+	CSP *CSPRule `yaml:"csp"`
+
+	paths []crawl.Pattern
+	regex *regexp.Regexp
+}
+
+// CSPRule checks the policies a Content-Security-Policy header sends
+type CSPRule struct {
+	// Directives must each be in a policy with exactly these sources, in
+	// any order; an empty list only requires the directive
+	Directives map[string][]string `yaml:"directives"`
+	// Forbid are sources no directive may list, e.g. 'unsafe-eval'
+	Forbid []string `yaml:"forbid"`
+}
+
+// Violation is a header that breaks a rule on a page
+type Violation struct {
+	Path    string
+	Header  string
+	Problem string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s %s", v.Path, v.Header, v.Problem)
+}
+
+// Parse decodes a policy, rejecting unknown keys so a misspelt matcher is
+// an error rather than an unchecked rule
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(p.Pages) == 0 {
+		p.Pages = []string{"/"}
+	}
+	for _, page := range p.Pages {
+		if !strings.HasPrefix(page, "/") {
+			return nil, fmt.Errorf("page %q must start with /", page)
+		}
+	}
+	for i := range p.Rules {
+		if err := p.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("rules[%d] (%s): %w", i, p.Rules[i].Header, err)
+		}
+	}
+	return &p, nil
+}
+
+// Load reads and parses the policy file at path
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Header == "" {
+		return errors.New("header is required")
+	}
+	for _, glob := range r.Paths {
+		pattern, err := crawl.CompilePattern(glob)
+		if err != nil {
+			return err
+		}
+		r.paths = append(r.paths, pattern)
+	}
+	matchers := 0
+	for _, set := range []bool{r.Present, r.Exact != "", r.Regex != "", len(r.Directives) > 0, r.MinMaxAge > 0, r.CSP != nil} {
+		if set {
+			matchers++
+		}
+	}
+	switch {
+	case r.Absent && matchers > 0:
+		return errors.New("absent can't be combined with other matchers")
+	case !r.Absent && matchers == 0:
+		return errors.New("no matcher: set present, absent, exact, regex, directives, min-max-age or csp")
+	case r.MinMaxAge < 0:
+		return errors.New("min-max-age must not be negative")
+	case r.CSP != nil && !isCSP(r.Header):
+		return errors.New("csp only applies to Content-Security-Policy headers")
+	}
+	if r.Regex != "" {
+		var err error
+		if r.regex, err = regexp.Compile(r.Regex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isCSP(header string) bool {
+	return strings.EqualFold(header, "Content-Security-Policy") || strings.EqualFold(header, "Content-Security-Policy-Report-Only")
+}
+
+// Applies reports whether the rule covers path
+func (r Rule) Applies(path string) bool {
+	if len(r.paths) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(r.paths, func(p crawl.Pattern) bool { return p.Match(path) })
+}
+
+// Evaluate checks the headers of the response for path against every
+// rule that covers it. https is whether the page was fetched over TLS.
+func (p *Policy) Evaluate(path string, https bool, h http.Header) []Violation {
+	var violations []Violation
+	for _, r := range p.Rules {
+		if !r.Applies(path) || (r.HTTPSOnly && !https) {
+			continue
+		}
+		for _, problem := range r.check(h.Values(r.Header)) {
+			violations = append(violations, Violation{Path: path, Header: r.Header, Problem: problem})
+		}
+	}
+	return violations
+}
+
+// check returns every way values break the rule
+func (r Rule) check(values []string) []string {
+	if r.Absent {
+		if len(values) > 0 {
+			return []string{fmt.Sprintf("is sent (%q) but must not be", strings.Join(values, ", "))}
+		}
+		return nil
+	}
+	if len(values) == 0 {
+		return []string{"is missing"}
+	}
+	// A list header may be split across several fields
+	value := strings.Join(values, ", ")
+	var problems []string
+	if r.Exact != "" {
+		switch {
+		case len(values) > 1:
+			problems = append(problems, fmt.Sprintf("is sent %d times (%q), want once", len(values), value))
+		case strings.TrimSpace(values[0]) != r.Exact:
+			problems = append(problems, fmt.Sprintf("is %q, want %q", values[0], r.Exact))
+		}
+	}
+	if r.regex != nil && !r.regex.MatchString(value) {
+		problems = append(problems, fmt.Sprintf("is %q, which doesn't match %s", value, r.Regex))
+	}
+	if len(r.Directives) > 0 || r.MinMaxAge > 0 {
+		directives := parseDirectives(value)
+		for _, want := range r.Directives {
+			name, wantValue, hasValue := strings.Cut(want, "=")
+			got, ok := directives[strings.ToLower(strings.TrimSpace(name))]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("has no %s directive (%q)", name, value))
+			case hasValue && got != strings.TrimSpace(wantValue):
+				problems = append(problems, fmt.Sprintf("has %s=%s, want %s", name, got, want))
+			}
+		}
+		if r.MinMaxAge > 0 {
+			problems = append(problems, checkMaxAge(directives, r.MinMaxAge)...)
+		}
+	}
+	if r.CSP != nil {
+		problems = append(problems, r.CSP.check(csp.ParseHeader(values))...)
+	}
+	return problems
+}
+
+// parseDirectives splits a list header on commas and semicolons into
+// lower-cased directive names and their values, unquoted
+func parseDirectives(value string) map[string]string {
+	directives := map[string]string{}
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		name, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			directives[name] = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return directives
+}
+
+func checkMaxAge(directives map[string]string, least time.Duration) []string {
+	v, ok := directives["max-age"]
+	if !ok {
+		return []string{"has no max-age directive"}
+	}
+	seconds, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || seconds < 0 {
+		return []string{fmt.Sprintf("has max-age=%s, which is not a number of seconds", v)}
+	}
+	if got := time.Duration(seconds) * time.Second; got < least {
+		return []string{fmt.Sprintf("has max-age=%d (%v), want at least %d (%v)", seconds, got, int64(least.Seconds()), least)}
+	}
+	return nil
+}
+
+// check returns every way the policies break the rule: a required
+// directive missing from all of them or listing other sources, and every
+// forbidden source any directive lists
+func (c *CSPRule) check(policies []csp.Policy) []string {
+	var problems []string
+	names := slices.Sorted(maps.Keys(c.Directives))
+	for _, name := range names {
+		want := c.Directives[name]
+		var found []csp.Directive
+		for _, p := range policies {
+			if d, ok := p.Directive(name); ok {
+				found = append(found, d)
+			}
+		}
+		if len(found) == 0 {
+			problems = append(problems, fmt.Sprintf("has no %s directive", name))
+			continue
+		}
+		if len(want) == 0 {
+			continue
+		}
+		if !slices.ContainsFunc(found, func(d csp.Directive) bool { return sameSources(d.Sources, want) }) {
+			problems = append(problems, fmt.Sprintf("has %s, want %s %s", found[0], strings.ToLower(name), strings.Join(want, " ")))
+		}
+	}
+	for _, p := range policies {
+		for _, d := range p {
+			for _, source := range c.Forbid {
+				if d.Has(source) {
+					problems = append(problems, fmt.Sprintf("allows %s in %s", source, d.Name))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// sameSources compares source lists as sets, case-insensitively
+func sameSources(got, want []string) bool {
+	norm := func(sources []string) []string {
+		out := make([]string, len(sources))
+		for i, s := range sources {
+			out[i] = strings.ToLower(s)
+		}
+		slices.Sort(out)
+		return slices.Compact(out)
+	}
+	return slices.Equal(norm(got), norm(want))
+}
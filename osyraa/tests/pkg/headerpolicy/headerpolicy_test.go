@@ -0,0 +1,126 @@
+package headerpolicy
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const policy = `
+pages: [/, /resume.json, /fonts/inter.woff2]
+rules:
+  - header: X-Frame-Options
+    exact: SAMEORIGIN
+  - header: X-XSS-Protection
+    regex: '^(0|1; mode=block)$'
+  - header: Content-Security-Policy
+    csp:
+      directives:
+        frame-ancestors: ["'self'"]
+        upgrade-insecure-requests: []
+      forbid: ["'unsafe-eval'", "'unsafe-inline'"]
+  - header: Strict-Transport-Security
+    https-only: true
+    min-max-age: 8760h
+    directives: [includeSubDomains]
+  - header: Permissions-Policy
+    directives: [camera=(), geolocation=()]
+  - header: Cache-Control
+    paths: ["/fonts/"]
+    min-max-age: 720h
+    directives: [immutable]
+  - header: x-powered-by
+    absent: true
+`
+
+func load(t *testing.T) *Policy {
+	t.Helper()
+	p, err := Parse([]byte(policy))
+	require.NoError(t, err)
+	return p
+}
+
+func TestEvaluatePasses(t *testing.T) {
+	p := load(t)
+	h := http.Header{}
+	h.Set("X-Frame-Options", "SAMEORIGIN")
+	h.Set("X-XSS-Protection", "1; mode=block")
+	h.Add("Content-Security-Policy", "upgrade-insecure-requests")
+	h.Add("Content-Security-Policy", "Frame-Ancestors 'SELF'; img-src 'self' data:")
+	h.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+	h.Set("Permissions-Policy", "camera=(), geolocation=(), microphone=()")
+	assert.Empty(t, p.Evaluate("/", true, h))
+
+	h.Set("Cache-Control", "public, max-age=31536000, immutable")
+	assert.Empty(t, p.Evaluate("/fonts/inter.woff2", true, h))
+}
+
+func TestEvaluateReportsEveryViolation(t *testing.T) {
+	p := load(t)
+	h := http.Header{}
+	h.Add("X-Frame-Options", "SAMEORIGIN")
+	h.Add("X-Frame-Options", "DENY")
+	h.Set("X-XSS-Protection", "1")
+	h.Set("Content-Security-Policy", "frame-ancestors *; script-src 'self' 'unsafe-inline' 'unsafe-eval'")
+	h.Set("Strict-Transport-Security", "max-age=300")
+	h.Set("Permissions-Policy", "camera=(self)")
+	h.Set("Cache-Control", "max-age=3600")
+	h.Set("X-Powered-By", "Hugo")
+
+	var problems []string
+	for _, v := range p.Evaluate("/fonts/inter.woff2", true, h) {
+		assert.Equal(t, "/fonts/inter.woff2", v.Path)
+		problems = append(problems, v.Header+" "+v.Problem)
+	}
+	assert.Equal(t, []string{
+		`X-Frame-Options is sent 2 times ("SAMEORIGIN, DENY"), want once`,
+		`X-XSS-Protection is "1", which doesn't match ^(0|1; mode=block)$`,
+		`Content-Security-Policy has frame-ancestors *, want frame-ancestors 'self'`,
+		`Content-Security-Policy has no upgrade-insecure-requests directive`,
+		`Content-Security-Policy allows 'unsafe-eval' in script-src`,
+		`Content-Security-Policy allows 'unsafe-inline' in script-src`,
+		`Strict-Transport-Security has no includeSubDomains directive ("max-age=300")`,
+		`Strict-Transport-Security has max-age=300 (5m0s), want at least 31536000 (8760h0m0s)`,
+		`Permissions-Policy has camera=(self), want camera=()`,
+		`Permissions-Policy has no geolocation directive ("camera=(self)")`,
+		`Cache-Control has no immutable directive ("max-age=3600")`,
+		`Cache-Control has max-age=3600 (1h0m0s), want at least 2592000 (720h0m0s)`,
+		`x-powered-by is sent ("Hugo") but must not be`,
+	}, problems)
+}
+
+func TestEvaluateScopesRules(t *testing.T) {
+	p := load(t)
+	h := http.Header{}
+	h.Set("X-Frame-Options", "SAMEORIGIN")
+	h.Set("X-XSS-Protection", "0")
+	h.Set("Content-Security-Policy", "frame-ancestors 'self'; upgrade-insecure-requests")
+	h.Set("Permissions-Policy", "camera=(), geolocation=()")
+	assert.Empty(t, p.Evaluate("/", false, h), "HSTS is only checked over HTTPS and Cache-Control only under /fonts/")
+
+	violations := p.Evaluate("/", true, h)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "/: Strict-Transport-Security is missing", violations[0].String())
+}
+
+func TestParseErrors(t *testing.T) {
+	for yaml, want := range map[string]string{
+		"rules: [{exact: DENY}]":                                   "header is required",
+		"rules: [{header: X-Frame-Options}]":                       "no matcher",
+		"rules: [{header: Server, absent: true, regex: nginx}]":    "absent can't be combined",
+		"rules: [{header: Server, regex: '('}]":                    "missing closing )",
+		"rules: [{header: Server, present: true, paths: [fonts]}]": `pattern "fonts" must start with /`,
+		"rules: [{header: X-Frame-Options, csp: {}}]":              "csp only applies to Content-Security-Policy",
+		"rules: [{header: Server, exact: nginx, exactly: nginx}]":  "field exactly not found",
+		"pages: [index.html]":                                      "must start with /",
+	} {
+		_, err := Parse([]byte(yaml))
+		assert.ErrorContains(t, err, want, yaml)
+	}
+
+	p, err := Parse([]byte("rules: []"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/"}, p.Pages)
+}
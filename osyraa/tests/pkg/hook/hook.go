@@ -0,0 +1,208 @@
+// Package hook installs the harness as git hooks and works out which files
+// a commit or a push changes, so the hooks check only those. Hooks are
+// small shell scripts that run `osyraa hook run` from the tests module;
+// they carry a marker line so they are never confused with, or overwrite,
+// hooks installed by other tools.
+package hook
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Marker identifies a hook script written by Install
+const Marker = "# Installed by osyraa hook install"
+
+// Stages are the hooks Install supports
+var Stages = []string{"pre-commit", "pre-push"}
+
+var (
+	// ErrForeignHook is returned by Install when the hook exists and was
+	// not written by it
+	ErrForeignHook = errors.New("a hook not installed by osyraa is in the way")
+	// ErrUnknownBase is returned by Pushed when a new branch shares no
+	// history with the fallback base, so every file counts as changed
+	ErrUnknownBase = errors.New("no base to compare the push with")
+)
+
+// Repo runs git in a directory of a work tree
+type Repo struct {
+	Dir string
+}
+
+func (r Repo) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Root is the top of the work tree
+func (r Repo) Root(ctx context.Context) (string, error) {
+	out, err := r.git(ctx, "rev-parse", "--show-toplevel")
+	return strings.TrimSpace(string(out)), err
+}
+
+// HooksDir is where git looks for hooks, honouring core.hooksPath and
+// linked worktrees
+func (r Repo) HooksDir(ctx context.Context) (string, error) {
+	out, err := r.git(ctx, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.Dir, dir)
+	}
+	return filepath.Abs(dir)
+}
+
+// Script is the hook for stage; module is the tests module's directory
+// relative to the top of the work tree, with forward slashes
+func Script(stage, module string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s; "osyraa hook uninstall" removes it
+cd "$(git rev-parse --show-toplevel)/%s" || exit 1
+exec go run ./cmd/osyraa hook run -stage %s
+`, Marker, module, stage)
+}
+
+// Install writes the hook for stage and returns its path. An existing hook
+// is replaced only if Install wrote it, or if force is set.
+func (r Repo) Install(ctx context.Context, stage, module string, force bool) (string, error) {
+	dir, err := r.HooksDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, stage)
+	if data, err := os.ReadFile(path); err == nil && !bytes.Contains(data, []byte(Marker)) && !force {
+		return path, fmt.Errorf("%s: %w", path, ErrForeignHook)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return path, err
+	}
+	return path, os.WriteFile(path, []byte(Script(stage, module)), 0o755)
+}
+
+// Uninstall removes the hook for stage if Install wrote it, and reports
+// whether it did
+func (r Repo) Uninstall(ctx context.Context, stage string) (bool, error) {
+	dir, err := r.HooksDir(ctx)
+	if err != nil {
+		return false, err
+	}
+	path := filepath.Join(dir, stage)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && !bytes.Contains(data, []byte(Marker))) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, os.Remove(path)
+}
+
+// Change is a file a commit or push touches, relative to the top of the
+// work tree with forward slashes. A rename is a deletion and an addition.
+type Change struct {
+	Path    string
+	Deleted bool
+}
+
+// Changed lists the files `git diff args...` reports
+func (r Repo) Changed(ctx context.Context, args ...string) ([]Change, error) {
+	out, err := r.git(ctx, append([]string{"diff", "--name-status", "--no-renames", "-z"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	var changes []Change
+	for i := 0; i+1 < len(fields); i += 2 {
+		changes = append(changes, Change{Path: fields[i+1], Deleted: fields[i] == "D"})
+	}
+	return changes, nil
+}
+
+// Staged lists the files the next commit changes
+func (r Repo) Staged(ctx context.Context) ([]Change, error) {
+	return r.Changed(ctx, "--cached")
+}
+
+// Show reads a file at a revision; rev "" is the index
+func (r Repo) Show(ctx context.Context, rev, path string) ([]byte, error) {
+	return r.git(ctx, "show", rev+":"+path)
+}
+
+// PushRef is one line git passes a pre-push hook on stdin
+type PushRef struct {
+	LocalRef, LocalSHA, RemoteRef, RemoteSHA string
+}
+
+// zero is git's name for a missing object
+func zero(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// ParsePush reads the refs a pre-push hook is given
+func ParsePush(in io.Reader) ([]PushRef, error) {
+	var refs []PushRef
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) != 4 {
+			return nil, fmt.Errorf("unexpected pre-push line %q", scanner.Text())
+		}
+		refs = append(refs, PushRef{f[0], f[1], f[2], f[3]})
+	}
+	return refs, scanner.Err()
+}
+
+// Pushed lists the files a push changes on the remote. A new branch is
+// compared with its merge base with base, such as "origin/HEAD"; deleted
+// branches change nothing.
+func (r Repo) Pushed(ctx context.Context, refs []PushRef, base string) ([]Change, error) {
+	byPath := map[string]Change{}
+	for _, ref := range refs {
+		if zero(ref.LocalSHA) {
+			continue
+		}
+		from := ref.RemoteSHA
+		if zero(from) {
+			out, err := r.git(ctx, "merge-base", ref.LocalSHA, base)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ref.LocalRef, ErrUnknownBase)
+			}
+			from = strings.TrimSpace(string(out))
+		}
+		changes, err := r.Changed(ctx, from, ref.LocalSHA)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			byPath[c.Path] = c
+		}
+	}
+	changes := make([]Change, 0, len(byPath))
+	for _, c := range byPath {
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
@@ -0,0 +1,129 @@
+package hook
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRepo makes a repository with one commit of a content file
+func newRepo(t *testing.T) (Repo, func(args ...string) string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com", "GIT_CONFIG_GLOBAL=/dev/null")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-q", "-b", "main")
+	write(t, dir, "site/content/_index.md", "---\ntitle: x\n---\n")
+	write(t, dir, "site/content/old.md", "---\ntitle: old\n---\n")
+	run("add", "-A")
+	run("commit", "-qm", "first")
+	return Repo{Dir: dir}, run
+}
+
+func write(t *testing.T, dir, name, body string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+}
+
+func TestInstall(t *testing.T) {
+	repo, _ := newRepo(t)
+	ctx := context.Background()
+
+	path, err := repo.Install(ctx, "pre-commit", "site/tests", false)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `cd "$(git rev-parse --show-toplevel)/site/tests"`)
+	assert.Contains(t, string(data), "hook run -stage pre-commit")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0o100, "hooks must be executable")
+	_, err = repo.Install(ctx, "pre-commit", "site/tests", false)
+	assert.NoError(t, err, "reinstalling replaces our own hook")
+
+	foreign := filepath.Join(filepath.Dir(path), "pre-push")
+	require.NoError(t, os.WriteFile(foreign, []byte("#!/bin/sh\npre-commit run\n"), 0o755))
+	_, err = repo.Install(ctx, "pre-push", "site/tests", false)
+	assert.ErrorIs(t, err, ErrForeignHook)
+	removed, err := repo.Uninstall(ctx, "pre-push")
+	require.NoError(t, err)
+	assert.False(t, removed, "other tools' hooks are left alone")
+	assert.FileExists(t, foreign)
+
+	removed, err = repo.Uninstall(ctx, "pre-commit")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.NoFileExists(t, path)
+	removed, err = repo.Uninstall(ctx, "pre-commit")
+	assert.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestStaged(t *testing.T) {
+	repo, run := newRepo(t)
+	ctx := context.Background()
+	write(t, repo.Dir, "site/content/_index.md", "---\ntitle: staged\n---\n")
+	write(t, repo.Dir, "site/content/new.md", "---\ntitle: new\n---\n")
+	run("add", "-A")
+	run("mv", "site/content/old.md", "site/content/renamed.md")
+	write(t, repo.Dir, "site/content/_index.md", "---\ntitle: unstaged\n---\n")
+
+	changes, err := repo.Staged(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Change{
+		{Path: "site/content/_index.md"},
+		{Path: "site/content/new.md"},
+		{Path: "site/content/old.md", Deleted: true},
+		{Path: "site/content/renamed.md"},
+	}, changes)
+
+	data, err := repo.Show(ctx, "", "site/content/_index.md")
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: staged\n---\n", string(data), "the index, not the work tree, is what gets committed")
+}
+
+func TestPushed(t *testing.T) {
+	repo, run := newRepo(t)
+	ctx := context.Background()
+	first := run("rev-parse", "HEAD")
+	write(t, repo.Dir, "site/content/new.md", "---\ntitle: new\n---\n")
+	run("add", "-A")
+	run("commit", "-qm", "second")
+	second := run("rev-parse", "HEAD")
+	zeros := strings.Repeat("0", 40)
+
+	refs, err := ParsePush(strings.NewReader(
+		"refs/heads/main " + second + " refs/heads/main " + first + "\n" +
+			"(delete) " + zeros + " refs/heads/gone " + first + "\n"))
+	require.NoError(t, err)
+	require.Len(t, refs, 2)
+	changes, err := repo.Pushed(ctx, refs, "main")
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Path: "site/content/new.md"}}, changes)
+
+	run("branch", "base", first)
+	changes, err = repo.Pushed(ctx, []PushRef{{"refs/heads/feature", second, "refs/heads/feature", zeros}}, "base")
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Path: "site/content/new.md"}}, changes, "a new branch is compared with its merge base")
+
+	_, err = repo.Pushed(ctx, []PushRef{{"refs/heads/feature", second, "refs/heads/feature", zeros}}, "origin/HEAD")
+	assert.ErrorIs(t, err, ErrUnknownBase)
+
+	_, err = ParsePush(strings.NewReader("garbage\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,230 @@
+// Package htmlvalid finds markup mistakes that browsers silently repair
+// and so a parsed tree never shows: unclosed and misnested elements,
+// stray end tags, duplicate IDs, images without alt text and empty links.
+// It reads the token stream rather than the tree, so every problem comes
+// with the line it is on. End tags HTML lets a document leave out, as
+// minifiers do, are not required.
+package htmlvalid
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Rules
+const (
+	Unclosed          = "unclosed-tag"
+	Misnested         = "misnested-tag"
+	StrayEnd          = "stray-end-tag"
+	DuplicateID       = "duplicate-id"
+	MissingAlt        = "missing-alt"
+	EmptyHref         = "empty-href"
+	NestedInteractive = "nested-interactive"
+)
+
+// Issue is one problem in a document
+type Issue struct {
+	// Page is the document's path, when validated as part of a site
+	Page    string
+	Line    int
+	Rule    string
+	Message string
+}
+
+// String formats the issue as path:line: rule: message
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", i.Page, i.Line, i.Rule, i.Message)
+}
+
+// voidElements never have content or an end tag
+var voidElements = set("area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta",
+	"param", "source", "track", "wbr")
+
+// optionalEnd are elements whose end tag HTML lets a document leave out
+var optionalEnd = set("html", "head", "body", "p", "li", "dt", "dd", "rt", "rp", "optgroup", "option",
+	"colgroup", "caption", "thead", "tbody", "tfoot", "tr", "td", "th")
+
+// closesP are the start tags that end an open <p>
+var closesP = set("address", "article", "aside", "blockquote", "details", "dialog", "div", "dl",
+	"fieldset", "figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header",
+	"hgroup", "hr", "main", "menu", "nav", "ol", "p", "pre", "section", "table", "ul")
+
+// scopeBoundaries stop the search for an open <p> or interactive ancestor
+var scopeBoundaries = set("html", "table", "td", "th", "caption", "template", "object", "button",
+	"svg", "math")
+
+// interactive elements may not contain one another
+var interactive = []string{"a", "button"}
+
+func set(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+type open struct {
+	name string
+	line int
+}
+
+type validator struct {
+	issues []Issue
+	stack  []open
+	ids    map[string]int
+}
+
+func (v *validator) add(line int, rule, format string, args ...any) {
+	v.issues = append(v.issues, Issue{Line: line, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// popTo closes the element at stack index i and everything opened within
+// it, reporting those whose end tag is required as misnested
+func (v *validator) popTo(i, line int, why string) {
+	for j := len(v.stack) - 1; j > i; j-- {
+		inner := v.stack[j]
+		if !optionalEnd[inner.name] {
+			v.add(line, Misnested, "<%s> opened on line %d is still open when %s", inner.name, inner.line, why)
+		}
+	}
+	v.stack = v.stack[:i]
+}
+
+// inScope finds the innermost open element named name, not looking past
+// a scope boundary
+func (v *validator) inScope(name string) int {
+	for i := len(v.stack) - 1; i >= 0; i-- {
+		switch {
+		case v.stack[i].name == name:
+			return i
+		case scopeBoundaries[v.stack[i].name]:
+			return -1
+		}
+	}
+	return -1
+}
+
+func (v *validator) start(t html.Token, line int) {
+	name := t.Data
+	if closesP[name] {
+		if i := v.inScope("p"); i >= 0 {
+			v.popTo(i, line, fmt.Sprintf("<%s> on line %d implicitly closes <p> from line %d", name, line, v.stack[i].line))
+		}
+	}
+	if slices.Contains(interactive, name) {
+		for _, other := range interactive {
+			if i := v.inScope(other); i >= 0 {
+				v.add(line, NestedInteractive, "<%s> inside <%s> opened on line %d", name, other, v.stack[i].line)
+				break
+			}
+		}
+	}
+
+	attrs := map[string]string{}
+	for _, a := range t.Attr {
+		attrs[a.Key] = a.Val
+	}
+	if id, ok := attrs["id"]; ok {
+		if first, seen := v.ids[id]; seen {
+			v.add(line, DuplicateID, "id %q is already used on line %d", id, first)
+		} else {
+			v.ids[id] = line
+		}
+	}
+	if _, ok := attrs["alt"]; !ok && (name == "img" || name == "area" || name == "input" && strings.EqualFold(attrs["type"], "image")) {
+		v.add(line, MissingAlt, "<%s> has no alt attribute; use alt=\"\" for decoration", name)
+	}
+	if href, ok := attrs["href"]; ok && strings.TrimSpace(href) == "" && (name == "a" || name == "area" || name == "link") {
+		v.add(line, EmptyHref, "<%s> has an empty href", name)
+	}
+
+	if t.Type == html.StartTagToken && !voidElements[name] {
+		v.stack = append(v.stack, open{name: name, line: line})
+	}
+}
+
+func (v *validator) end(name string, line int) {
+	if voidElements[name] {
+		if name != "br" {
+			v.add(line, StrayEnd, "</%s> closes a void element", name)
+		}
+		return
+	}
+	for i := len(v.stack) - 1; i >= 0; i-- {
+		if v.stack[i].name == name {
+			v.popTo(i, line, fmt.Sprintf("</%s> on line %d closes <%s> from line %d", name, line, name, v.stack[i].line))
+			return
+		}
+	}
+	v.add(line, StrayEnd, "</%s> has no open <%s>", name, name)
+}
+
+// Validate checks one document
+func Validate(r io.Reader) ([]Issue, error) {
+	v := &validator{ids: map[string]int{}}
+	z := html.NewTokenizer(r)
+	line := 1
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return v.issues, err
+			}
+			break
+		}
+		raw := z.Raw()
+		startLine := line
+		line += bytes.Count(raw, []byte("\n"))
+		t := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			v.start(t, startLine)
+		case html.EndTagToken:
+			v.end(t.Data, startLine)
+		}
+	}
+	for i := len(v.stack) - 1; i >= 0; i-- {
+		if o := v.stack[i]; !optionalEnd[o.name] {
+			v.add(o.line, Unclosed, "<%s> is never closed", o.name)
+		}
+	}
+	sort.SliceStable(v.issues, func(i, j int) bool { return v.issues[i].Line < v.issues[j].Line })
+	return v.issues, nil
+}
+
+// Dir checks every HTML file under root, naming each issue's page by its
+// path relative to root
+func Dir(root string) ([]Issue, error) {
+	var issues []Issue
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".html") {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		found, err := Validate(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		rel, _ := filepath.Rel(root, file)
+		for _, issue := range found {
+			issue.Page = filepath.ToSlash(rel)
+			issues = append(issues, issue)
+		}
+		return nil
+	})
+	return issues, err
+}
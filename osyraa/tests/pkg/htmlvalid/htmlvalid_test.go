@@ -0,0 +1,64 @@
+package htmlvalid
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validate(t *testing.T, doc string) []string {
+	t.Helper()
+	issues, err := Validate(strings.NewReader(doc))
+	require.NoError(t, err)
+	var out []string
+	for _, i := range issues {
+		out = append(out, i.String())
+	}
+	return out
+}
+
+func TestValidDocument(t *testing.T) {
+	// Minified: no </p>, </li>, </body> or </html>, and unquoted attributes
+	doc := `<!doctype html><html lang=en><meta charset=utf-8><title>Jane</title>
+<body><main id=main><h1>Jane</h1><p>One<p>Two<ul><li>a<li>b</ul>
+<img src=me.png alt=""><a href=/about/>About</a><br></br>
+<table><tr><td>1<td>2</table><svg><path d="M0"/></svg>
+<script>if (a < b) { document.write("</div>") }</script></main>`
+	assert.Empty(t, validate(t, doc))
+}
+
+func TestProblems(t *testing.T) {
+	doc := `<main>
+<div id=a><span>text</div>
+<p id=a>para<div>block</div></p>
+<img src=x.png>
+<a href="">empty</a><a href=/x><button>b</button></a>
+</section>
+<article>`
+	assert.Equal(t, []string{
+		`:1: unclosed-tag: <main> is never closed`,
+		`:2: misnested-tag: <span> opened on line 2 is still open when </div> on line 2 closes <div> from line 2`,
+		`:3: duplicate-id: id "a" is already used on line 2`,
+		`:3: stray-end-tag: </p> has no open <p>`,
+		`:4: missing-alt: <img> has no alt attribute; use alt="" for decoration`,
+		`:5: empty-href: <a> has an empty href`,
+		`:5: nested-interactive: <button> inside <a> opened on line 5`,
+		`:6: stray-end-tag: </section> has no open <section>`,
+		`:7: unclosed-tag: <article> is never closed`,
+	}, validate(t, doc))
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "about"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte(`<p>fine`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "about", "index.html"), []byte("\n<div>"), 0o644))
+	issues, err := Dir(dir)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "about/index.html:2: unclosed-tag: <div> is never closed", issues[0].String())
+}
@@ -0,0 +1,155 @@
+// Package httpclient builds the one tuned transport the harness's HTTP
+// clients share. The standard library's default keeps only two idle
+// connections per host, so a crawl or a burst of probes against one site
+// closes and reopens connections until the machine runs out of ephemeral
+// ports. The shared transport keeps a pool per host sized to a cap on
+// concurrent connections, and requests over the cap wait for a free one.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Options tune the shared transport
+type Options struct {
+	// MaxConnsPerHost caps the connections to one host, idle or in use;
+	// zero means 8
+	MaxConnsPerHost int
+	// MaxIdleConns caps idle connections across hosts; zero means 100
+	MaxIdleConns int
+	// IdleConnTimeout closes connections idle this long; zero means 90s
+	IdleConnTimeout time.Duration
+	// MinTLSVersion is the oldest TLS version accepted; zero means TLS 1.2
+	MinTLSVersion uint16
+	// RootCAs verifies servers; nil means the system roots
+	RootCAs *x509.CertPool
+}
+
+// DefaultOptions are the options used when nothing is configured
+func DefaultOptions() Options {
+	return Options{
+		MaxConnsPerHost: 8,
+		MaxIdleConns:    100,
+		IdleConnTimeout: 90 * time.Second,
+		MinTLSVersion:   tls.VersionTLS12,
+	}
+}
+
+// FromEnv reads DefaultOptions overridden by the environment:
+// OSYRAA_HTTP_MAX_CONNS_PER_HOST, OSYRAA_TLS_MIN_VERSION (1.2 or 1.3) and
+// OSYRAA_TLS_CA_FILE, PEM certificates trusted in addition to the system
+// roots, e.g. for a staging site behind a private CA. Options that fail to
+// parse keep their defaults and are reported in the error.
+func FromEnv() (Options, error) {
+	o := DefaultOptions()
+	var errs []error
+	if v := os.Getenv("OSYRAA_HTTP_MAX_CONNS_PER_HOST"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err == nil && n > 0 {
+			o.MaxConnsPerHost = n
+		} else {
+			errs = append(errs, fmt.Errorf("OSYRAA_HTTP_MAX_CONNS_PER_HOST=%q is not a positive number", v))
+		}
+	}
+	switch v := os.Getenv("OSYRAA_TLS_MIN_VERSION"); v {
+	case "":
+	case "1.2":
+		o.MinTLSVersion = tls.VersionTLS12
+	case "1.3":
+		o.MinTLSVersion = tls.VersionTLS13
+	default:
+		errs = append(errs, fmt.Errorf("OSYRAA_TLS_MIN_VERSION=%q is not 1.2 or 1.3", v))
+	}
+	if file := os.Getenv("OSYRAA_TLS_CA_FILE"); file != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(file)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("OSYRAA_TLS_CA_FILE: %w", err))
+		case !pool.AppendCertsFromPEM(pem):
+			errs = append(errs, fmt.Errorf("OSYRAA_TLS_CA_FILE: no certificates in %s", file))
+		default:
+			o.RootCAs = pool
+		}
+	}
+	return o, errors.Join(errs...)
+}
+
+// NewTransport builds a transport from o. It matches the standard
+// library's default transport apart from the pool and TLS settings.
+func NewTransport(o Options) *http.Transport {
+	d := DefaultOptions()
+	if o.MaxConnsPerHost <= 0 {
+		o.MaxConnsPerHost = d.MaxConnsPerHost
+	}
+	if o.MaxIdleConns <= 0 {
+		o.MaxIdleConns = d.MaxIdleConns
+	}
+	if o.IdleConnTimeout <= 0 {
+		o.IdleConnTimeout = d.IdleConnTimeout
+	}
+	if o.MinTLSVersion == 0 {
+		o.MinTLSVersion = d.MinTLSVersion
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DialContext:       dialer.DialContext,
+		ForceAttemptHTTP2: true,
+		MaxConnsPerHost:   o.MaxConnsPerHost,
+		// Idle connections up to the cap are kept, so a connection freed
+		// by one request is reused by the next rather than closed
+		MaxIdleConnsPerHost:   o.MaxConnsPerHost,
+		MaxIdleConns:          o.MaxIdleConns,
+		IdleConnTimeout:       o.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       &tls.Config{MinVersion: o.MinTLSVersion, RootCAs: o.RootCAs},
+	}
+}
+
+var (
+	mu      sync.Mutex
+	current = DefaultOptions()
+)
+
+// Current is the options of the installed transport, or DefaultOptions.
+// Clients that need a transport of their own, such as one without
+// keep-alives, start from NewTransport(Current()) to keep the TLS
+// settings.
+func Current() Options {
+	mu.Lock()
+	defer mu.Unlock()
+	return current
+}
+
+// Install makes a transport built from o http.DefaultTransport, so every
+// client without a Transport of its own, including http.Get and the
+// wrappers that default to it, shares its pool. Install before anything
+// wraps the default transport. It returns a func restoring the previous
+// default.
+func Install(o Options) (restore func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	prev, prevOptions := http.DefaultTransport, current
+	transport := NewTransport(o)
+	http.DefaultTransport, current = transport, o
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		transport.CloseIdleConnections()
+		http.DefaultTransport, current = prev, prevOptions
+	}
+}
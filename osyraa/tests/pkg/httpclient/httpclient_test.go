@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransport(t *testing.T) {
+	tr := NewTransport(Options{})
+	assert.Equal(t, 8, tr.MaxConnsPerHost)
+	assert.Equal(t, 8, tr.MaxIdleConnsPerHost, "idle connections are kept up to the cap")
+	assert.Equal(t, 100, tr.MaxIdleConns)
+	assert.Equal(t, uint16(tls.VersionTLS12), tr.TLSClientConfig.MinVersion)
+	assert.NotNil(t, tr.Proxy)
+
+	tr = NewTransport(Options{MaxConnsPerHost: 2, MinTLSVersion: tls.VersionTLS13})
+	assert.Equal(t, 2, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, uint16(tls.VersionTLS13), tr.TLSClientConfig.MinVersion)
+}
+
+func TestPerHostCap(t *testing.T) {
+	var conns, active, peak atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		active.Add(-1)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(Options{MaxConnsPerHost: 3})}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 10; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := client.Get(srv.URL)
+				if assert.NoError(t, err) {
+					resp.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	assert.LessOrEqual(t, peak.Load(), int32(3), "requests over the cap wait")
+	assert.LessOrEqual(t, conns.Load(), int32(3), "connections are reused across bursts")
+}
+
+func TestFromEnv(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o644))
+
+	t.Setenv("OSYRAA_HTTP_MAX_CONNS_PER_HOST", "4")
+	t.Setenv("OSYRAA_TLS_MIN_VERSION", "1.3")
+	t.Setenv("OSYRAA_TLS_CA_FILE", caFile)
+	o, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 4, o.MaxConnsPerHost)
+	assert.Equal(t, uint16(tls.VersionTLS13), o.MinTLSVersion)
+	resp, err := (&http.Client{Transport: NewTransport(o)}).Get(srv.URL)
+	require.NoError(t, err, "the CA file is trusted")
+	resp.Body.Close()
+
+	t.Setenv("OSYRAA_HTTP_MAX_CONNS_PER_HOST", "lots")
+	t.Setenv("OSYRAA_TLS_MIN_VERSION", "1.0")
+	t.Setenv("OSYRAA_TLS_CA_FILE", filepath.Join(t.TempDir(), "missing.pem"))
+	o, err = FromEnv()
+	assert.ErrorContains(t, err, "OSYRAA_HTTP_MAX_CONNS_PER_HOST")
+	assert.ErrorContains(t, err, "OSYRAA_TLS_MIN_VERSION")
+	assert.ErrorContains(t, err, "OSYRAA_TLS_CA_FILE")
+	assert.Equal(t, DefaultOptions(), o, "bad values keep their defaults")
+}
+
+func TestInstall(t *testing.T) {
+	prev := http.DefaultTransport
+	restore := Install(Options{MaxConnsPerHost: 5})
+	tr, ok := http.DefaultTransport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 5, tr.MaxConnsPerHost)
+	assert.Equal(t, 5, Current().MaxConnsPerHost)
+	restore()
+	assert.Same(t, prev, http.DefaultTransport)
+	assert.Equal(t, DefaultOptions(), Current())
+}
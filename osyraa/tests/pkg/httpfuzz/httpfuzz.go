@@ -0,0 +1,132 @@
+// Package httpfuzz sends malformed HTTP requests over raw TCP, bypassing
+// net/http's client-side validation, and checks the server answers each
+// with a sane status instead of crashing or leaking internals.
+package httpfuzz
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Case is one raw request and the statuses it may produce
+type Case struct {
+	Name string
+	Raw  string
+	// Accept lists acceptable status codes; empty means any 4xx
+	Accept []int
+}
+
+// Cases is the default corpus. Each request is complete, so a server
+// that waits for more input has mishandled it.
+func Cases() []Case {
+	hostHeader := "Host: localhost\r\n"
+	var manyHeaders strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&manyHeaders, "X-Fuzz-%d: %s\r\n", i, strings.Repeat("a", 30))
+	}
+	return []Case{
+		{Name: "garbage request line", Raw: "GARBAGE\r\n\r\n"},
+		{Name: "extra request-line token", Raw: "GET / / HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "unknown HTTP version", Raw: "GET / HTTP/9.9\r\n" + hostHeader + "\r\n", Accept: []int{400, 505}},
+		{Name: "lowercase method", Raw: "get / HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "unknown method", Raw: "FUZZ / HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "TRACE", Raw: "TRACE / HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "CONNECT", Raw: "CONNECT localhost:80 HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "DELETE", Raw: "DELETE /index.html HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "PUT", Raw: "PUT /index.html HTTP/1.1\r\n" + hostHeader + "Content-Length: 2\r\n\r\nhi"},
+		{Name: "null byte in path", Raw: "GET /\x00 HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "truncated percent escape", Raw: "GET /% HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "invalid percent escape", Raw: "GET /%zz HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "encoded null byte", Raw: "GET /%00 HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "oversized URI", Raw: "GET /" + strings.Repeat("a", 70000) + " HTTP/1.1\r\n" + hostHeader + "\r\n"},
+		{Name: "oversized header", Raw: "GET / HTTP/1.1\r\n" + hostHeader + "X-Big: " + strings.Repeat("b", 70000) + "\r\n\r\n"},
+		{Name: "too many headers", Raw: "GET / HTTP/1.1\r\n" + hostHeader + manyHeaders.String() + "\r\n"},
+		{Name: "missing Host", Raw: "GET / HTTP/1.1\r\n\r\n"},
+		{Name: "duplicate Host", Raw: "GET / HTTP/1.1\r\n" + hostHeader + "Host: evil.example\r\n\r\n"},
+		{Name: "invalid header name", Raw: "GET / HTTP/1.1\r\n" + hostHeader + "Bad Header: x\r\n\r\n", Accept: []int{400, 200}},
+		{Name: "header without colon", Raw: "GET / HTTP/1.1\r\n" + hostHeader + "NoColon\r\n\r\n"},
+		{Name: "obsolete line folding", Raw: "GET / HTTP/1.1\r\n" + hostHeader + "X-Fold: a\r\n b\r\n\r\n", Accept: []int{400, 200}},
+		{Name: "invalid Content-Length", Raw: "POST / HTTP/1.1\r\n" + hostHeader + "Content-Length: abc\r\n\r\n"},
+		{Name: "Content-Length with Transfer-Encoding", Raw: "POST / HTTP/1.1\r\n" + hostHeader +
+			"Content-Length: 4\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n"},
+		{Name: "unknown Transfer-Encoding", Raw: "POST / HTTP/1.1\r\n" + hostHeader + "Transfer-Encoding: fuzz\r\n\r\n", Accept: []int{400, 501}},
+	}
+}
+
+// Result is the server's answer to a case
+type Result struct {
+	Case   Case
+	Status int
+	Body   string
+	Err    error
+}
+
+// maxBody caps how much of each response is kept
+const maxBody = 64 << 10
+
+// leakRe matches stack traces, debug pages and server versions
+var leakRe = regexp.MustCompile(`(?i)goroutine \d+|traceback \(most recent call last\)|\bat [\w.$]+\([\w.]+:\d+\)|stack trace|nginx/\d|apache/\d|php/\d`)
+
+// Problem explains why the result is unacceptable, or returns ""
+func (r Result) Problem() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("%s: no response: %v", r.Case.Name, r.Err)
+	case r.Status >= 500 && !slices.Contains(r.Case.Accept, r.Status):
+		return fmt.Sprintf("%s: server error %d", r.Case.Name, r.Status)
+	case len(r.Case.Accept) == 0 && (r.Status < 400 || r.Status >= 500):
+		return fmt.Sprintf("%s: got %d, want 4xx", r.Case.Name, r.Status)
+	case len(r.Case.Accept) > 0 && !slices.Contains(r.Case.Accept, r.Status):
+		return fmt.Sprintf("%s: got %d, want one of %v", r.Case.Name, r.Status, r.Case.Accept)
+	}
+	if m := leakRe.FindString(r.Body); m != "" {
+		return fmt.Sprintf("%s: response leaks %q", r.Case.Name, m)
+	}
+	if m := escapeRe.FindString(r.Body); m != "" {
+		return fmt.Sprintf("%s: escaped the web root, response contains %q", r.Case.Name, m)
+	}
+	return ""
+}
+
+// Run sends each case on its own connection to addr ("host:port")
+func Run(ctx context.Context, addr string, cases []Case) []Result {
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		status, body, err := send(ctx, addr, c.Raw)
+		results = append(results, Result{Case: c, Status: status, Body: body, Err: err})
+	}
+	return results
+}
+
+func send(ctx context.Context, addr, raw string) (int, string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, "", err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetDeadline(deadline)
+
+	// The server may answer and close before reading an oversized request,
+	// so a failed write is not an error until the response is read
+	go io.WriteString(conn, raw)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	return resp.StatusCode, string(body), nil
+}
@@ -0,0 +1,60 @@
+package httpfuzz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblem(t *testing.T) {
+	c := Case{Name: "c"}
+	assert.Empty(t, Result{Case: c, Status: 400}.Problem())
+	assert.Equal(t, "c: got 200, want 4xx", Result{Case: c, Status: 200}.Problem())
+	assert.Equal(t, "c: server error 500", Result{Case: c, Status: 500}.Problem())
+	assert.Equal(t, "c: no response: EOF", Result{Case: c, Err: errors.New("EOF")}.Problem())
+	assert.Equal(t, `c: response leaks "nginx/1"`, Result{Case: c, Status: 400, Body: "<center>nginx/1.25.5</center>"}.Problem())
+
+	c.Accept = []int{400, 505}
+	assert.Empty(t, Result{Case: c, Status: 505}.Problem())
+	assert.Equal(t, "c: got 200, want one of [400 505]", Result{Case: c, Status: 200}.Problem())
+}
+
+func TestRun(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/panic":
+			panic("boom")
+		case "/trace":
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, "goroutine 1 [running]:\nmain.main()")
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.Start()
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	results := Run(context.Background(), addr, []Case{
+		{Name: "garbage", Raw: "GARBAGE\r\n\r\n"},
+		{Name: "unknown method", Raw: "FUZZ / HTTP/1.1\r\nHost: x\r\n\r\n"},
+		{Name: "oversized header", Raw: "GET / HTTP/1.1\r\nHost: x\r\nX-Big: " + strings.Repeat("b", 2<<20) + "\r\n\r\n"},
+		{Name: "panic", Raw: "GET /panic HTTP/1.1\r\nHost: x\r\n\r\n"},
+		{Name: "trace", Raw: "GET /trace HTTP/1.1\r\nHost: x\r\n\r\n", Accept: []int{500}},
+	})
+	require.Len(t, results, 5)
+	assert.Empty(t, results[0].Problem())
+	assert.Empty(t, results[1].Problem())
+	assert.Equal(t, 431, results[2].Status)
+	assert.Contains(t, results[3].Problem(), "panic: no response")
+	assert.Equal(t, `trace: response leaks "goroutine 1"`, results[4].Problem())
+}
@@ -0,0 +1,75 @@
+package httpfuzz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// TraversalPaths try to climb out of the web root with plain, encoded,
+// double-encoded, overlong UTF-8, null-byte and Windows-style sequences
+var TraversalPaths = []string{
+	"/../etc/passwd",
+	"/../../../../../../etc/passwd",
+	"/./././../../etc/passwd",
+	"/....//....//....//etc/passwd",
+	"//etc/passwd",
+	"/..%2f..%2f..%2f..%2fetc%2fpasswd",
+	"/%2e%2e/%2e%2e/%2e%2e/etc/passwd",
+	"/%2e%2e%2f%2e%2e%2f%2e%2e%2fetc%2fpasswd",
+	"/%252e%252e%252f%252e%252e%252f%252e%252e%252fetc%252fpasswd",
+	"/%c0%ae%c0%ae/%c0%ae%c0%ae/%c0%ae%c0%ae/etc/passwd",
+	"/%c0%ae%c0%ae%c0%af%c0%ae%c0%ae%c0%afetc%c0%afpasswd",
+	"/../../../../etc/passwd%00.html",
+	"/index.html%00.txt",
+	"/..\\..\\..\\..\\etc\\passwd",
+	"/..%5c..%5c..%5c..%5cetc%5cpasswd",
+	"/..%255c..%255c..%255cetc%255cpasswd",
+	"/..\\..\\..\\windows\\win.ini",
+	"/css/../../../../etc/passwd",
+	"/css/..%2f..%2f..%2f..%2fetc%2fnginx%2fnginx.conf",
+	"/../../../../etc/nginx/conf.d/default.conf",
+	"/../../../../proc/self/environ",
+}
+
+// escapeRe matches the contents of files outside the web root that a
+// traversal typically reaches
+var escapeRe = regexp.MustCompile(`root:[^:\n]*:0:0:|\[fonts\]|\[extensions\]|worker_processes|\blisten\s+\d+;|(?m)^PATH=|HOSTNAME=|-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+
+// TraversalCases wraps TraversalPaths as raw requests, which keeps the
+// paths byte-for-byte instead of letting a client clean or re-encode them.
+// A 200 is accepted so the SPA fallback can answer; CheckTraversal decides
+// whether its body is acceptable.
+func TraversalCases() []Case {
+	cases := make([]Case, 0, len(TraversalPaths))
+	for _, p := range TraversalPaths {
+		cases = append(cases, Case{
+			Name:   "traversal " + p,
+			Raw:    "GET " + p + " HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n",
+			Accept: []int{http.StatusOK, http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+		})
+	}
+	return cases
+}
+
+// CheckTraversal sends TraversalCases to addr. Every response must have an
+// accepted status and no file contents from outside the web root, and a
+// 200 must be the home page, which is what the SPA fallback serves.
+func CheckTraversal(ctx context.Context, addr, home string) []string {
+	if len(home) > maxBody {
+		home = home[:maxBody]
+	}
+	var problems []string
+	for _, r := range Run(ctx, addr, TraversalCases()) {
+		if p := r.Problem(); p != "" {
+			problems = append(problems, p)
+			continue
+		}
+		if r.Status == http.StatusOK && strings.TrimSpace(r.Body) != strings.TrimSpace(home) {
+			problems = append(problems, fmt.Sprintf("%s: returned 200 with content other than the home page", r.Case.Name))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,60 @@
+package httpfuzz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const home = "<html><body>home</body></html>"
+
+func traversalServer(t *testing.T, h http.HandlerFunc) string {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestCheckTraversal(t *testing.T) {
+	root := t.TempDir()
+	public := filepath.Join(root, "a", "b", "c", "d", "e", "public")
+	require.NoError(t, os.MkdirAll(public, 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "etc"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "etc", "passwd"), []byte("root:x:0:0:root:/root:/bin/sh\n"), 0o644))
+
+	t.Run("fallback", func(t *testing.T) {
+		addr := traversalServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(home))
+		})
+		assert.Empty(t, CheckTraversal(context.Background(), addr, home))
+	})
+
+	t.Run("vulnerable", func(t *testing.T) {
+		// Joins the raw path onto the web root, the classic mistake
+		addr := traversalServer(t, func(w http.ResponseWriter, r *http.Request) {
+			body, err := os.ReadFile(filepath.Join(public, r.URL.Path))
+			if err != nil {
+				w.Write([]byte(home))
+				return
+			}
+			w.Write(body)
+		})
+		problems := CheckTraversal(context.Background(), addr, home)
+		assert.Contains(t, problems, `traversal /../../../../../../etc/passwd: escaped the web root, response contains "root:x:0:0:"`)
+	})
+
+	t.Run("unexpected content", func(t *testing.T) {
+		addr := traversalServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.URL.Path))
+		})
+		problems := CheckTraversal(context.Background(), addr, home)
+		assert.Len(t, problems, len(TraversalPaths))
+		assert.Contains(t, problems[0], "content other than the home page")
+	})
+}
@@ -0,0 +1,154 @@
+// Package hugobuild builds the site with Hugo from one of several
+// backends: a hugo binary on this host, a container image such as the
+// hugomods images, or a pinned Hugo release downloaded and checksummed at
+// test time. Each backend runs a Build in a span of the harness's trace:
+// the binaries as commands, the image through the engine's API.
+package hugobuild
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dockerrun"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/tracing"
+)
+
+// Backends
+const (
+	// Image runs the image the suite settings name
+	Image = "image"
+	// Hugomods runs the hugomods extended image for the pinned version
+	Hugomods = "hugomods"
+	// Local runs a hugo binary on this host
+	Local = "local"
+	// Release downloads the pinned release from GitHub
+	Release = "release"
+)
+
+// Backends lists the backend names
+var Backends = []string{Image, Hugomods, Local, Release}
+
+// HugomodsImage is the hugomods image with the extended edition of version
+func HugomodsImage(version string) string {
+	return "hugomods/hugo:exts-" + strings.TrimPrefix(version, "v")
+}
+
+// Build is one build of a site
+type Build struct {
+	// Source is the site root, which the build only reads
+	Source string
+	// Dest receives public/, with Hugo's generated resources and cache
+	// beside it in resources/ and cache/
+	Dest string
+	// Flags are passed to hugo after the suite's own
+	Flags []string
+}
+
+// args are the hugo flags for building src into dest. No build lock is
+// taken, so parallel builds of one checkout are safe.
+func (b Build) args(src, dest string) []string {
+	args := []string{"--source", src, "--minify", "--noBuildLock",
+		"--destination", dest + "/public", "--cacheDir", dest + "/cache"}
+	return append(args, b.Flags...)
+}
+
+// Builder runs a build and returns Hugo's output
+type Builder interface {
+	Run(ctx context.Context, b Build) ([]byte, error)
+}
+
+// Binary runs a hugo binary on this host
+type Binary struct {
+	// Path is the hugo binary; empty looks it up on PATH
+	Path string
+	// Version, if set, is the version the binary must report
+	Version string
+}
+
+// Command checks the binary's version and returns the build command
+func (h Binary) Command(ctx context.Context, b Build) (*exec.Cmd, error) {
+	path := h.Path
+	if path == "" {
+		var err error
+		if path, err = exec.LookPath("hugo"); err != nil {
+			return nil, fmt.Errorf("hugo is not installed: %w", err)
+		}
+	}
+	if h.Version != "" {
+		out, err := exec.CommandContext(ctx, path, "version").CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("%s version: %w: %s", path, err, strings.TrimSpace(string(out)))
+		}
+		got, ok := ParseVersion(string(out))
+		if !ok {
+			return nil, fmt.Errorf("%s version printed no version: %s", path, strings.TrimSpace(string(out)))
+		}
+		if want := strings.TrimPrefix(h.Version, "v"); got != want {
+			return nil, fmt.Errorf("%s is Hugo %s, but %s is pinned", path, got, want)
+		}
+	}
+	cmd := exec.CommandContext(ctx, path, b.args(b.Source, filepath.ToSlash(b.Dest))...)
+	cmd.Env = append(os.Environ(), "HUGO_RESOURCEDIR="+filepath.Join(b.Dest, "resources"))
+	return cmd, nil
+}
+
+// Run runs the build command
+func (h Binary) Run(ctx context.Context, b Build) ([]byte, error) {
+	cmd, err := h.Command(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.Run(ctx, "hugo build", cmd)
+}
+
+var versionPattern = regexp.MustCompile(`\bv?(\d+\.\d+\.\d+)\b`)
+
+// ParseVersion reads the version from hugo version's output, e.g. 0.111.3
+// from "hugo v0.111.3-5d4eb515+extended linux/amd64 BuildDate=..."
+func ParseVersion(output string) (string, bool) {
+	m := versionPattern.FindStringSubmatch(output)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Container runs hugo in a container image through the engine, the
+// source mounted read-only at /src and the destination at /work
+type Container struct {
+	Docker dockerrun.Client
+	Image  string
+	// User is the container's user, e.g. uid:gid so files are written as
+	// the caller; empty keeps the image's user
+	User string
+	// RunOptions name, label and pull the Hugo container
+	dockerrun.RunOptions
+}
+
+// Run runs hugo in the container
+func (c Container) Run(ctx context.Context, b Build) ([]byte, error) {
+	if c.Image == "" {
+		return nil, fmt.Errorf("no Hugo image set")
+	}
+	return tracing.RunContainer(ctx, "hugo build", c.Docker, c.container(b))
+}
+
+// container is the tool container of the build
+func (c Container) container(b Build) dockerrun.Container {
+	return dockerrun.Container{
+		Image: c.Image,
+		Cmd:   append([]string{"hugo"}, b.args("/src", "/work")...),
+		Env:   []string{"HUGO_RESOURCEDIR=/work/resources"},
+		User:  c.User,
+		Mounts: []dockerrun.Mount{
+			{Source: b.Source, Target: "/src", ReadOnly: true},
+			{Source: b.Dest, Target: "/work"},
+		},
+		RunOptions: c.RunOptions,
+	}
+}
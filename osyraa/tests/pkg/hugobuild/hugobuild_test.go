@@ -0,0 +1,200 @@
+package hugobuild
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dockerrun"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain lets the test binary stand in for hugo: started with
+// FAKE_HUGO=1 it prints a version line like hugo version does
+func TestMain(m *testing.M) {
+	if os.Getenv("FAKE_HUGO") == "1" {
+		fmt.Println("hugo v0.111.3-5d4eb5154e1fed125ca8e9b5a0315c4180dab192+extended linux/amd64 BuildDate=2023-03-12T11:40:50Z")
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func TestParseVersion(t *testing.T) {
+	v, ok := ParseVersion("hugo v0.111.3-5d4eb515+extended linux/amd64 BuildDate=2023-03-12T11:40:50Z VendorInfo=gohugoio")
+	assert.True(t, ok)
+	assert.Equal(t, "0.111.3", v)
+	_, ok = ParseVersion("command not found")
+	assert.False(t, ok)
+}
+
+func TestBinary(t *testing.T) {
+	t.Setenv("FAKE_HUGO", "1")
+	b := Build{Source: "/site", Dest: "/out", Flags: []string{"--clock", "2026-01-01T00:00:00Z"}}
+
+	cmd, err := Binary{Path: os.Args[0], Version: "v0.111.3"}.Command(context.Background(), b)
+	require.NoError(t, err)
+	assert.Equal(t, []string{os.Args[0], "--source", "/site", "--minify", "--noBuildLock", "--destination", "/out/public",
+		"--cacheDir", "/out/cache", "--clock", "2026-01-01T00:00:00Z"}, cmd.Args)
+	assert.Contains(t, cmd.Env, "HUGO_RESOURCEDIR="+filepath.Join("/out", "resources"))
+
+	_, err = Binary{Path: os.Args[0], Version: "0.120.0"}.Command(context.Background(), b)
+	assert.ErrorContains(t, err, "is Hugo 0.111.3, but 0.120.0 is pinned")
+}
+
+func TestContainer(t *testing.T) {
+	run := dockerrun.RunOptions{Name: "osyraa-hugo-r1", Labels: map[string]string{"osyraa.run": "r1"}, Pull: "never"}
+	c := Container{Image: HugomodsImage("v0.111.3"), User: "1000:1000", RunOptions: run}
+	assert.Equal(t, dockerrun.Container{
+		Image: "hugomods/hugo:exts-0.111.3",
+		Cmd:   []string{"hugo", "--source", "/src", "--minify", "--noBuildLock", "--destination", "/work/public", "--cacheDir", "/work/cache"},
+		Env:   []string{"HUGO_RESOURCEDIR=/work/resources"},
+		User:  "1000:1000",
+		Mounts: []dockerrun.Mount{
+			{Source: "/site", Target: "/src", ReadOnly: true},
+			{Source: "/tmp/out", Target: "/work"},
+		},
+		RunOptions: run,
+	}, c.container(Build{Source: "/site", Dest: "/tmp/out"}))
+
+	_, err := Container{}.Run(context.Background(), Build{})
+	assert.Error(t, err)
+}
+
+func TestAsset(t *testing.T) {
+	for want, d := range map[string]Download{
+		"hugo_extended_0.111.3_linux-amd64.tar.gz":      {Version: "v0.111.3", GOOS: "linux", GOARCH: "amd64"},
+		"hugo_extended_0.111.3_linux-arm64.tar.gz":      {Version: "0.111.3", GOOS: "linux", GOARCH: "arm64"},
+		"hugo_extended_0.111.3_darwin-universal.tar.gz": {Version: "0.111.3", GOOS: "darwin", GOARCH: "arm64"},
+		"hugo_extended_0.111.3_windows-amd64.zip":       {Version: "0.111.3", GOOS: "windows", GOARCH: "amd64"},
+	} {
+		assert.Equal(t, want, d.Asset())
+	}
+}
+
+// releaseServer serves a fake release: the archive and a checksums file
+func releaseServer(t *testing.T, asset string, archive []byte, listed string) (*httptest.Server, *int) {
+	t.Helper()
+	downloads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v0.111.3/" + asset:
+			downloads++
+			w.Write(archive)
+		case "/v0.111.3/hugo_0.111.3_checksums.txt":
+			fmt.Fprintf(w, "0000  hugo_0.111.3_Linux-64bit.deb\n%s  %s\n", listed, asset)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &downloads
+}
+
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestDownloadInstall(t *testing.T) {
+	archive := tarball(t, map[string]string{"LICENSE": "Apache-2.0", "README.md": "# Hugo", "hugo": "#!/bin/sh\necho fake\n"})
+	sum := sha256.Sum256(archive)
+	checksum := hex.EncodeToString(sum[:])
+	d := Download{Version: "0.111.3", CacheDir: t.TempDir(), GOOS: "linux", GOARCH: "amd64"}
+	srv, downloads := releaseServer(t, d.Asset(), archive, checksum)
+	d.BaseURL = srv.URL
+
+	binary, err := d.Install(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(d.CacheDir, "hugo_extended_0.111.3_linux-amd64", "hugo"), binary)
+	data, err := os.ReadFile(binary)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho fake\n", string(data))
+
+	again, err := d.Install(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, binary, again)
+	assert.Equal(t, 1, *downloads, "the cached binary is reused")
+
+	require.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\necho tampered\n"), 0o755))
+	_, err = d.Install(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, *downloads, "a changed binary is downloaded again")
+	data, err = os.ReadFile(binary)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho fake\n", string(data))
+
+	pinned := d
+	pinned.Checksum = strings.ToUpper(checksum)
+	_, err = pinned.Install(context.Background())
+	assert.NoError(t, err, "a pinned checksum is compared case-insensitively")
+	assert.Equal(t, 2, *downloads, "the cache already holds the pinned archive")
+
+	pinned.Checksum = strings.Repeat("ab", 32)
+	_, err = pinned.Install(context.Background())
+	assert.ErrorContains(t, err, "want "+strings.Repeat("ab", 32), "a newly pinned checksum is checked against the cache")
+}
+
+func TestDownloadOffline(t *testing.T) {
+	archive := tarball(t, map[string]string{"hugo": "#!/bin/sh\necho fake\n"})
+	sum := sha256.Sum256(archive)
+	d := Download{Version: "0.111.3", CacheDir: t.TempDir(), GOOS: "linux", GOARCH: "amd64", Offline: true}
+	srv, downloads := releaseServer(t, d.Asset(), archive, hex.EncodeToString(sum[:]))
+	d.BaseURL = srv.URL
+
+	_, err := d.Install(context.Background())
+	assert.ErrorContains(t, err, "offline, so Hugo 0.111.3 cannot be downloaded: no verified install")
+	assert.Zero(t, *downloads)
+
+	online := d
+	online.Offline = false
+	binary, err := online.Install(context.Background())
+	require.NoError(t, err)
+	cached, err := d.Install(context.Background())
+	require.NoError(t, err, "an installed release is used offline")
+	assert.Equal(t, binary, cached)
+
+	d.Checksum = strings.Repeat("ab", 32)
+	_, err = d.Install(context.Background())
+	assert.ErrorContains(t, err, "offline, so Hugo 0.111.3 cannot be downloaded: the cached")
+	assert.Equal(t, 1, *downloads)
+}
+
+func TestDownloadRejectsChecksumMismatch(t *testing.T) {
+	archive := tarball(t, map[string]string{"hugo": "tampered"})
+	d := Download{Version: "0.111.3", CacheDir: t.TempDir(), GOOS: "linux", GOARCH: "amd64"}
+	srv, _ := releaseServer(t, d.Asset(), archive, strings.Repeat("ab", 32))
+	d.BaseURL = srv.URL
+
+	_, err := d.Install(context.Background())
+	assert.ErrorContains(t, err, "want "+strings.Repeat("ab", 32))
+	assert.NoDirExists(t, filepath.Join(d.CacheDir, "hugo_extended_0.111.3_linux-amd64"), "nothing is installed")
+
+	d.Checksum = strings.Repeat("cd", 32)
+	_, err = d.Install(context.Background())
+	assert.ErrorContains(t, err, "want "+strings.Repeat("cd", 32), "a pinned checksum wins over the published one")
+
+	d.Version = "0.120.0"
+	_, err = d.Install(context.Background())
+	assert.ErrorContains(t, err, "404")
+}
@@ -0,0 +1,262 @@
+package hugobuild
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DefaultReleaseURL is where Hugo's releases are downloaded from
+const DefaultReleaseURL = "https://github.com/gohugoio/hugo/releases/download"
+
+// sumsFile sits beside a cached binary and lists the SHA-256 of the
+// archive it came from and of the binary itself, in the format of the
+// release's checksums file
+const sumsFile = "SHA256SUMS"
+
+// Download fetches a pinned Hugo release, verifies its checksum and
+// unpacks the binary into a cache, so later runs reuse it. The cached
+// binary is checked again on every use.
+type Download struct {
+	Version string
+	// Checksum is the SHA-256 of the archive for this OS and architecture.
+	// Empty verifies the archive against the release's checksums file.
+	Checksum string
+	// CacheDir keeps the unpacked binaries, one directory per version and
+	// platform
+	CacheDir string
+	// BaseURL defaults to DefaultReleaseURL
+	BaseURL string
+	Client  *http.Client
+	// GOOS and GOARCH default to this host's
+	GOOS, GOARCH string
+	// Offline uses the cache only: a release not yet installed, or one
+	// that no longer verifies, is an error rather than a download
+	Offline bool
+}
+
+func (d Download) platform() (goos, goarch string) {
+	goos, goarch = d.GOOS, d.GOARCH
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	return goos, goarch
+}
+
+// Asset is the release file for the platform: the extended edition, which
+// the site's SCSS needs. macOS releases are universal binaries.
+func (d Download) Asset() string {
+	goos, goarch := d.platform()
+	version := strings.TrimPrefix(d.Version, "v")
+	switch goos {
+	case "darwin":
+		return fmt.Sprintf("hugo_extended_%s_darwin-universal.tar.gz", version)
+	case "windows":
+		return fmt.Sprintf("hugo_extended_%s_windows-%s.zip", version, goarch)
+	}
+	return fmt.Sprintf("hugo_extended_%s_%s-%s.tar.gz", version, goos, goarch)
+}
+
+func (d Download) url(file string) string {
+	base := d.BaseURL
+	if base == "" {
+		base = DefaultReleaseURL
+	}
+	return strings.TrimSuffix(base, "/") + "/v" + strings.TrimPrefix(d.Version, "v") + "/" + file
+}
+
+// Install returns the path of the release's hugo binary, downloading and
+// verifying it first unless the cache has it and it still verifies
+func (d Download) Install(ctx context.Context) (string, error) {
+	if d.Version == "" {
+		return "", errors.New("no Hugo version pinned")
+	}
+	goos, _ := d.platform()
+	name := "hugo"
+	if goos == "windows" {
+		name = "hugo.exe"
+	}
+	asset := d.Asset()
+	dir := filepath.Join(d.CacheDir, strings.TrimSuffix(strings.TrimSuffix(asset, ".tar.gz"), ".zip"))
+	binary := filepath.Join(dir, name)
+	err := d.verifyCached(dir, name, asset)
+	if err == nil {
+		return binary, nil
+	}
+	if d.Offline {
+		return "", fmt.Errorf("offline, so Hugo %s cannot be downloaded: %w", d.Version, err)
+	}
+
+	archive, err := d.fetch(ctx, asset)
+	if err != nil {
+		return "", err
+	}
+	want := strings.ToLower(d.Checksum)
+	if want == "" {
+		if want, err = d.publishedChecksum(ctx, asset); err != nil {
+			return "", err
+		}
+	}
+	if got := sha256Hex(archive); got != want {
+		return "", fmt.Errorf("%s has SHA-256 %s, want %s", asset, got, want)
+	}
+	exe, err := unpack(asset, archive, name)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", asset, err)
+	}
+
+	// Written beside its final name and renamed, so an interrupted
+	// install never leaves a partial binary in the cache
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, name+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(exe); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), binary); err != nil {
+		return "", err
+	}
+	// Written last, so a binary without its sums is downloaded again
+	sums := fmt.Sprintf("%s  %s\n%s  %s\n", want, asset, sha256Hex(exe), name)
+	return binary, os.WriteFile(filepath.Join(dir, sumsFile), []byte(sums), 0o644)
+}
+
+// verifyCached checks the binary in dir against the sums recorded when it
+// was installed, and the archive it came from against the pinned
+// checksum, which may have changed since
+func (d Download) verifyCached(dir, name, asset string) error {
+	data, err := os.ReadFile(filepath.Join(dir, sumsFile))
+	if err != nil {
+		return fmt.Errorf("no verified install in %s", dir)
+	}
+	sums := checksums(data)
+	exe, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	if got := sha256Hex(exe); got != sums[name] {
+		return fmt.Errorf("%s has SHA-256 %s, but %s was installed", filepath.Join(dir, name), got, sums[name])
+	}
+	if want := strings.ToLower(d.Checksum); want != "" && sums[asset] != want {
+		return fmt.Errorf("the cached %s has SHA-256 %s, want %s", asset, sums[asset], want)
+	}
+	return nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// checksums reads a checksums file, one "<SHA-256>  <file>" per line,
+// into a map from file to lower-case SHA-256
+func checksums(data []byte) map[string]string {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 2 {
+			sums[fields[1]] = strings.ToLower(fields[0])
+		}
+	}
+	return sums
+}
+
+func (d Download) fetch(ctx context.Context, file string) ([]byte, error) {
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url(file), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: %s", req.URL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// publishedChecksum reads asset's SHA-256 from the release's checksums file
+func (d Download) publishedChecksum(ctx context.Context, asset string) (string, error) {
+	file := fmt.Sprintf("hugo_%s_checksums.txt", strings.TrimPrefix(d.Version, "v"))
+	data, err := d.fetch(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	if sum, ok := checksums(data)[asset]; ok {
+		return sum, nil
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", file, asset)
+}
+
+// unpack returns the named file from a .tar.gz or .zip archive
+func unpack(asset string, archive []byte, name string) ([]byte, error) {
+	if strings.HasSuffix(asset, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if path.Base(f.Name) == name {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+		return nil, fmt.Errorf("no %s in the archive", name)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no %s in the archive", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag == tar.TypeReg && path.Base(h.Name) == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package imagebuild builds images through the engine's API rather than
+// the docker CLI, which remote DOCKER_HOSTs and rootless setups may not
+// have. The build context is streamed as a tar, the JSON progress the
+// engine answers with is turned back into a build log, and a failed
+// build comes back as a *BuildError naming the step that failed.
+package imagebuild
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// tailLines is how much of a failed step's output a BuildError keeps
+const tailLines = 20
+
+// Client is the part of the Docker client a build needs
+type Client interface {
+	ImageBuild(ctx context.Context, context io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error)
+}
+
+// Options describe a build
+type Options struct {
+	Tags   []string
+	Labels map[string]string
+	// Dockerfile is the Dockerfile's path in the context; empty means
+	// Dockerfile
+	Dockerfile string
+	BuildArgs  map[string]*string
+	// Pull always pulls base images instead of using local copies
+	Pull bool
+	// Platform builds for another platform, e.g. linux/arm64; empty builds
+	// for the engine's own. RUN steps for a foreign platform need QEMU.
+	Platform string
+	// Classic uses the legacy builder rather than BuildKit. The site's
+	// Containerfile needs BuildKit for its heredoc.
+	Classic bool
+	// Log receives the build output as plain text
+	Log io.Writer
+}
+
+// Result is a finished build
+type Result struct {
+	// ID is the built image's ID
+	ID string
+}
+
+// BuildError is a build the engine reported as failed
+type BuildError struct {
+	// Step is the instruction that failed as the builder names it, e.g.
+	// "[builder 4/4] RUN hugo --minify" or "Step 4/4 : RUN hugo --minify",
+	// when it is known
+	Step string
+	// Message is the engine's error
+	Message string
+	// Code is the engine's error code, if it gave one
+	Code int
+	// Output is the end of the failed step's output
+	Output []string
+}
+
+func (e *BuildError) Error() string {
+	var b strings.Builder
+	b.WriteString("build failed")
+	if e.Step != "" {
+		fmt.Fprintf(&b, " at %s", e.Step)
+	}
+	fmt.Fprintf(&b, ": %s", e.Message)
+	if len(e.Output) > 0 {
+		b.WriteString("\n" + strings.Join(e.Output, "\n"))
+	}
+	return b.String()
+}
+
+// Build sends buildContext to the engine and waits for the image
+func Build(ctx context.Context, c Client, buildContext io.Reader, o Options) (Result, error) {
+	version := types.BuilderBuildKit
+	if o.Classic {
+		version = types.BuilderV1
+	}
+	resp, err := c.ImageBuild(ctx, buildContext, types.ImageBuildOptions{
+		Tags:        o.Tags,
+		Labels:      o.Labels,
+		Dockerfile:  o.Dockerfile,
+		BuildArgs:   o.BuildArgs,
+		PullParent:  o.Pull,
+		Platform:    o.Platform,
+		Remove:      true,
+		ForceRemove: true,
+		Version:     version,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	id, err := ReadOutput(resp.Body, o.Log)
+	return Result{ID: id}, err
+}
+
+// message is one line of the engine's build progress
+type message struct {
+	Stream      string          `json:"stream"`
+	Status      string          `json:"status"`
+	ID          string          `json:"id"`
+	Aux         json.RawMessage `json:"aux"`
+	Error       string          `json:"error"`
+	ErrorDetail *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// classicStep is how the legacy builder announces an instruction
+var classicStep = regexp.MustCompile(`^Step \d+/\d+ : `)
+
+// ReadOutput follows the engine's JSON build progress to its end. Output
+// is written to log, if not nil, as plain text. It returns the built
+// image's ID, or a *BuildError if the engine reported one.
+func ReadOutput(r io.Reader, log io.Writer) (string, error) {
+	if log == nil {
+		log = io.Discard
+	}
+	var id string
+	p := newProgress()
+	dec := json.NewDecoder(r)
+	for {
+		var m message
+		if err := dec.Decode(&m); err == io.EOF {
+			return id, nil
+		} else if err != nil {
+			return id, fmt.Errorf("reading build output: %w", err)
+		}
+		switch {
+		case m.ErrorDetail != nil || m.Error != "":
+			e := &BuildError{Message: m.Error}
+			if m.ErrorDetail != nil {
+				e.Code = m.ErrorDetail.Code
+				if m.ErrorDetail.Message != "" {
+					e.Message = m.ErrorDetail.Message
+				}
+			}
+			e.Step, e.Output = p.failed()
+			return id, e
+		case m.Stream != "":
+			io.WriteString(log, m.Stream)
+			for _, line := range strings.Split(strings.TrimRight(m.Stream, "\n"), "\n") {
+				if classicStep.MatchString(line) {
+					p.start(line, line)
+				} else {
+					p.output(p.current, line)
+				}
+			}
+		case m.ID == "moby.buildkit.trace":
+			var raw []byte
+			if err := json.Unmarshal(m.Aux, &raw); err != nil {
+				return id, fmt.Errorf("reading BuildKit progress: %w", err)
+			}
+			if err := p.trace(raw, log); err != nil {
+				return id, fmt.Errorf("reading BuildKit progress: %w", err)
+			}
+		case len(m.Aux) > 0:
+			// The legacy builder's and BuildKit's (moby.image.id) image ID
+			var aux struct{ ID string }
+			if json.Unmarshal(m.Aux, &aux) == nil && aux.ID != "" {
+				id = aux.ID
+			}
+		case m.Status != "":
+			fmt.Fprintln(log, m.Status)
+		}
+	}
+}
+
+// progress tracks build steps and their output
+type progress struct {
+	names map[string]string
+	logs  map[string][]string
+	// current is the step running last; errored the first one BuildKit
+	// marked as failed
+	current, errored string
+}
+
+func newProgress() *progress {
+	return &progress{names: map[string]string{}, logs: map[string][]string{}}
+}
+
+func (p *progress) start(key, name string) {
+	if _, ok := p.names[key]; !ok {
+		p.names[key] = name
+	}
+	p.current = key
+}
+
+func (p *progress) output(key, line string) {
+	lines := append(p.logs[key], line)
+	if len(lines) > tailLines {
+		lines = lines[len(lines)-tailLines:]
+	}
+	p.logs[key] = lines
+}
+
+// failed names the step a build error belongs to and its last output
+func (p *progress) failed() (string, []string) {
+	key := p.errored
+	if key == "" {
+		key = p.current
+	}
+	return p.names[key], p.logs[key]
+}
@@ -0,0 +1,211 @@
+package imagebuild
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Dir streams dir as a build context, leaving out what its ignore file
+// excludes as the docker CLI and podman do. The Dockerfile and the ignore
+// file are always sent. Reading the returned context walks the directory,
+// so a large tree is never held in memory.
+func Dir(dir, dockerfile string) (io.ReadCloser, error) {
+	name, ignore, err := readIgnore(dir, dockerfile)
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeDir(pw, dir, ignore, name, dockerfile))
+	}()
+	return pr, nil
+}
+
+func writeDir(w io.Writer, dir string, ignore *ignoreRules, ignoreFile, dockerfile string) error {
+	tw := tar.NewWriter(w)
+	keep := map[string]bool{ignoreFile: true, path.Clean(filepath.ToSlash(dockerfile)): true}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if ignore.excludes(rel) && !keep[rel] {
+			// A later ! rule may bring back something below a directory
+			if d.IsDir() && !ignore.negates {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		hdr.Name = rel
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		// Ownership on the host means nothing to the build
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Dockerfile is a build context holding only a Dockerfile, for images
+// that copy nothing in, as `docker build -` builds them
+func Dockerfile(content string) io.Reader {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "Dockerfile", Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))})
+	tw.Write([]byte(content))
+	tw.Close()
+	return &buf
+}
+
+// ignoreRules are the patterns of an ignore file in order; the last one
+// matching a path decides whether it is left out
+type ignoreRules struct {
+	rules []ignoreRule
+	// negates is set when some rule brings paths back with !
+	negates bool
+}
+
+type ignoreRule struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// ignoreFiles are the ignore files a context may have, relative to it,
+// in the order they are looked for; the first that exists is used.
+// <Dockerfile>.dockerignore lets images sharing a context filter it
+// differently, and podman reads .containerignore before .dockerignore.
+func ignoreFiles(dockerfile string) []string {
+	return []string{path.Clean(filepath.ToSlash(dockerfile)) + ".dockerignore", ".containerignore", ".dockerignore"}
+}
+
+// readIgnore reads the first ignore file dir has, returning its name
+// relative to dir; a context without one excludes nothing
+func readIgnore(dir, dockerfile string) (string, *ignoreRules, error) {
+	for _, name := range ignoreFiles(dockerfile) {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		defer f.Close()
+		ig, err := parseIgnore(f)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return name, ig, nil
+	}
+	return "", &ignoreRules{}, nil
+}
+
+// parseIgnore reads ignore file patterns: # comments, ! to re-include,
+// * and ? within a path segment and ** across segments. A pattern
+// matching a directory also covers everything below it.
+func parseIgnore(r io.Reader) (*ignoreRules, error) {
+	ig := &ignoreRules{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			rule.negate, ig.negates = true, true
+			line = strings.TrimSpace(rest)
+		}
+		line = strings.TrimPrefix(path.Clean(filepath.ToSlash(line)), "/")
+		re, err := compileIgnore(line)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", line, err)
+		}
+		rule.re = re
+		ig.rules = append(ig.rules, rule)
+	}
+	return ig, sc.Err()
+}
+
+func compileIgnore(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// excludes reports whether rel, a slash-separated path in the context, is
+// left out. A rule matches a path or any directory above it.
+func (ig *ignoreRules) excludes(rel string) bool {
+	excluded := false
+	for _, r := range ig.rules {
+		for p := rel; ; p = path.Dir(p) {
+			if r.re.MatchString(p) {
+				excluded = !r.negate
+				break
+			}
+			if !strings.Contains(p, "/") {
+				break
+			}
+		}
+	}
+	return excluded
+}
@@ -0,0 +1,263 @@
+package imagebuild
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func names(t *testing.T, r io.Reader) []string {
+	var got []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, hdr.Name)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"Containerfile":           "FROM scratch\n",
+		"config.toml":             "",
+		"content/_index.md":       "",
+		"tests/go.mod":            "",
+		"tests/testdata/keep.txt": "",
+		"public/index.html":       "",
+		".git/HEAD":               "",
+		"notes.swp":               "",
+		"content/draft.swp":       "",
+		".dockerignore":           "# build outputs\n/public\n.git\n**/*.swp\ntests\n!tests/testdata\nContainerfile\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+	}
+	rc, err := Dir(dir, "Containerfile")
+	require.NoError(t, err)
+	defer rc.Close()
+	assert.Equal(t, []string{
+		".dockerignore",
+		"Containerfile",
+		"config.toml",
+		"content/",
+		"content/_index.md",
+		"tests/testdata/",
+		"tests/testdata/keep.txt",
+	}, names(t, rc))
+}
+
+func TestDirIgnoreFilePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"Containerfile":              "FROM scratch\n",
+		"a.txt":                      "",
+		"b.txt":                      "",
+		"c.txt":                      "",
+		".dockerignore":              "a.txt\n",
+		".containerignore":           "b.txt\n",
+		"Containerfile.dockerignore": "c.txt\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+	}
+	context := func() []string {
+		rc, err := Dir(dir, "Containerfile")
+		require.NoError(t, err)
+		defer rc.Close()
+		return names(t, rc)
+	}
+	got := context()
+	assert.NotContains(t, got, "c.txt", "<Containerfile>.dockerignore comes first")
+	assert.Contains(t, got, "b.txt")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "Containerfile.dockerignore")))
+	got = context()
+	assert.NotContains(t, got, "b.txt", ".containerignore comes before .dockerignore")
+	assert.Contains(t, got, "a.txt")
+	assert.Contains(t, got, ".containerignore")
+}
+
+// TestDirRepository builds the context the suite sends for the real
+// image and checks .containerignore keeps the tests, build outputs and
+// secrets out of it
+func TestDirRepository(t *testing.T) {
+	root := filepath.Join("..", "..", "..")
+	if _, err := os.Stat(filepath.Join(root, ".containerignore")); err != nil {
+		t.Fatalf("osyraa/.containerignore should exist: %v", err)
+	}
+	rc, err := Dir(root, "Containerfile")
+	require.NoError(t, err)
+	defer rc.Close()
+	got := names(t, rc)
+	assert.Contains(t, got, "Containerfile")
+	assert.Contains(t, got, "config.toml")
+	for _, name := range got {
+		assert.False(t, strings.HasPrefix(name, "tests/"), "%s should not be in the build context", name)
+		assert.False(t, strings.HasSuffix(name, ".env"), "%s should not be in the build context", name)
+	}
+
+	_, ig, err := readIgnore(root, "Containerfile")
+	require.NoError(t, err)
+	for _, rel := range []string{"tests/go.mod", "public/index.html", "prod.env", "config/site.env", "secrets/token", "deploy.key"} {
+		assert.True(t, ig.excludes(rel), rel)
+	}
+}
+
+func TestDirMissing(t *testing.T) {
+	rc, err := Dir(filepath.Join(t.TempDir(), "nope"), "Dockerfile")
+	require.NoError(t, err)
+	_, err = io.ReadAll(rc)
+	assert.Error(t, err)
+}
+
+func TestIgnorePatterns(t *testing.T) {
+	ig, err := parseIgnore(strings.NewReader("**/node_modules\n*.log\ndocs/**/*.png\n"))
+	require.NoError(t, err)
+	for rel, want := range map[string]bool{
+		"node_modules/x.js":           true,
+		"themes/a/node_modules/x.js":  true,
+		"build.log":                   true,
+		"logs/build.log":              false,
+		"docs/a.png":                  true,
+		"docs/img/b/a.png":            true,
+		"static/a.png":                false,
+		"node_modules_backup/file.js": false,
+	} {
+		assert.Equal(t, want, ig.excludes(rel), rel)
+	}
+}
+
+func TestDockerfile(t *testing.T) {
+	assert.Equal(t, []string{"Dockerfile"}, names(t, Dockerfile("FROM scratch\n")))
+}
+
+func TestReadOutputClassic(t *testing.T) {
+	out := `{"stream":"Step 1/2 : FROM nginx:1.25-alpine\n"}
+{"stream":" ---> 8f1ad\n"}
+{"stream":"Step 2/2 : RUN hugo --minify\n"}
+{"stream":" ---> Running in 4b2\n"}
+{"stream":"Error: unable to locate config file\n"}
+{"errorDetail":{"code":1,"message":"The command '/bin/sh -c hugo --minify' returned a non-zero code: 1"},"error":"The command '/bin/sh -c hugo --minify' returned a non-zero code: 1"}
+`
+	var log bytes.Buffer
+	_, err := ReadOutput(strings.NewReader(out), &log)
+	var be *BuildError
+	require.True(t, errors.As(err, &be), "%v", err)
+	assert.Equal(t, "Step 2/2 : RUN hugo --minify", be.Step)
+	assert.Equal(t, 1, be.Code)
+	assert.Equal(t, []string{" ---> Running in 4b2", "Error: unable to locate config file"}, be.Output)
+	assert.Contains(t, log.String(), "Error: unable to locate config file")
+	assert.True(t, strings.HasPrefix(err.Error(), "build failed at Step 2/2 : RUN hugo --minify: The command"), err.Error())
+
+	id, err := ReadOutput(strings.NewReader(`{"stream":"Step 1/1 : FROM scratch\n"}
+{"aux":{"ID":"sha256:abc"}}
+{"stream":"Successfully built abc\n"}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc", id)
+}
+
+// vertex and vertexLog encode the parts of BuildKit's StatusResponse the
+// decoder reads, plus a varint field it must skip
+func vertex(digest, name, failure string) []byte {
+	var v []byte
+	v = protowire.AppendTag(v, vertexDigest, protowire.BytesType)
+	v = protowire.AppendString(v, digest)
+	v = protowire.AppendTag(v, vertexName, protowire.BytesType)
+	v = protowire.AppendString(v, name)
+	v = protowire.AppendTag(v, 4, protowire.VarintType) // cached
+	v = protowire.AppendVarint(v, 0)
+	if failure != "" {
+		v = protowire.AppendTag(v, vertexError, protowire.BytesType)
+		v = protowire.AppendString(v, failure)
+	}
+	return protowire.AppendBytes(protowire.AppendTag(nil, statusVertexes, protowire.BytesType), v)
+}
+
+func vertexLog(digest, msg string) []byte {
+	var v []byte
+	v = protowire.AppendTag(v, logVertex, protowire.BytesType)
+	v = protowire.AppendString(v, digest)
+	v = protowire.AppendTag(v, 3, protowire.VarintType) // stream
+	v = protowire.AppendVarint(v, 1)
+	v = protowire.AppendTag(v, logMsg, protowire.BytesType)
+	v = protowire.AppendBytes(v, []byte(msg))
+	return protowire.AppendBytes(protowire.AppendTag(nil, statusLogs, protowire.BytesType), v)
+}
+
+func traceLine(parts ...[]byte) string {
+	return `{"id":"moby.buildkit.trace","aux":"` + base64.StdEncoding.EncodeToString(bytes.Join(parts, nil)) + "\"}\n"
+}
+
+func TestReadOutputBuildKit(t *testing.T) {
+	out := traceLine(vertex("sha256:1", "[builder 1/3] FROM klakegg/hugo", ""), vertex("sha256:2", "[builder 3/3] RUN hugo --minify", "")) +
+		traceLine(vertexLog("sha256:1", "resolved\n"), vertexLog("sha256:2", "Start building sites …\nError: template: index.html:3: unexpected EOF\n")) +
+		traceLine(vertex("sha256:2", "[builder 3/3] RUN hugo --minify", `process "/bin/sh -c hugo --minify" did not complete successfully: exit code: 255`)) +
+		`{"errorDetail":{"message":"process \"/bin/sh -c hugo --minify\" did not complete successfully: exit code: 255"}}` + "\n"
+	var log bytes.Buffer
+	_, err := ReadOutput(strings.NewReader(out), &log)
+	var be *BuildError
+	require.True(t, errors.As(err, &be), "%v", err)
+	assert.Equal(t, "[builder 3/3] RUN hugo --minify", be.Step)
+	assert.Equal(t, []string{"Start building sites …", "Error: template: index.html:3: unexpected EOF"}, be.Output)
+	assert.Equal(t, "[builder 1/3] FROM klakegg/hugo\n[builder 3/3] RUN hugo --minify\nresolved\nStart building sites …\nError: template: index.html:3: unexpected EOF\n", log.String())
+
+	id, err := ReadOutput(strings.NewReader(traceLine(vertex("sha256:1", "[1/1] FROM nginx", ""))+
+		`{"id":"moby.image.id","aux":{"ID":"sha256:def"}}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:def", id)
+
+	_, err = ReadOutput(strings.NewReader(`{"id":"moby.buildkit.trace","aux":"`+base64.StdEncoding.EncodeToString([]byte{0x0a, 0x05})+`"}`), nil)
+	assert.ErrorContains(t, err, "reading BuildKit progress")
+}
+
+type fakeClient struct {
+	opts    types.ImageBuildOptions
+	context []byte
+	reply   string
+}
+
+func (f *fakeClient) ImageBuild(_ context.Context, r io.Reader, o types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+	f.opts = o
+	f.context, _ = io.ReadAll(r)
+	return types.ImageBuildResponse{Body: io.NopCloser(strings.NewReader(f.reply))}, nil
+}
+
+func TestBuild(t *testing.T) {
+	c := &fakeClient{reply: `{"id":"moby.image.id","aux":{"ID":"sha256:abc"}}`}
+	res, err := Build(context.Background(), c, Dockerfile("FROM scratch\n"), Options{
+		Tags:       []string{"resume:test"},
+		Labels:     map[string]string{"osyraa.run": "r1"},
+		Dockerfile: "Dockerfile",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc", res.ID)
+	assert.Equal(t, []string{"resume:test"}, c.opts.Tags)
+	assert.Equal(t, "r1", c.opts.Labels["osyraa.run"])
+	assert.Equal(t, types.BuilderBuildKit, c.opts.Version)
+	assert.Equal(t, []string{"Dockerfile"}, names(t, bytes.NewReader(c.context)))
+
+	assert.Empty(t, c.opts.Platform)
+
+	_, err = Build(context.Background(), c, Dockerfile("FROM scratch\n"), Options{Classic: true, Platform: "linux/arm64"})
+	require.NoError(t, err)
+	assert.Equal(t, types.BuilderV1, c.opts.Version)
+	assert.Equal(t, "linux/arm64", c.opts.Platform)
+}
@@ -0,0 +1,105 @@
+package imagebuild
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// BuildKit sends its progress as protobuf StatusResponse messages from
+// moby/buildkit's control API. Only the fields needed to name steps and
+// collect their output are read, which spares depending on BuildKit:
+//
+//	StatusResponse { repeated Vertex vertexes = 1; repeated VertexLog logs = 3; }
+//	Vertex         { string digest = 1; string name = 3; string error = 7; }
+//	VertexLog      { string vertex = 1; bytes msg = 4; }
+const (
+	statusVertexes = 1
+	statusLogs     = 3
+	vertexDigest   = 1
+	vertexName     = 3
+	vertexError    = 7
+	logVertex      = 1
+	logMsg         = 4
+)
+
+// trace records one StatusResponse and writes its output to log
+func (p *progress) trace(b []byte, log io.Writer) error {
+	return fields(b, func(num protowire.Number, v []byte) error {
+		switch num {
+		case statusVertexes:
+			var digest, name, failure string
+			err := fields(v, func(num protowire.Number, v []byte) error {
+				switch num {
+				case vertexDigest:
+					digest = string(v)
+				case vertexName:
+					name = string(v)
+				case vertexError:
+					failure = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if _, seen := p.names[digest]; !seen && name != "" {
+				fmt.Fprintln(log, name)
+			}
+			p.start(digest, name)
+			if failure != "" && p.errored == "" {
+				p.errored = digest
+			}
+		case statusLogs:
+			var vertex string
+			var msg []byte
+			err := fields(v, func(num protowire.Number, v []byte) error {
+				switch num {
+				case logVertex:
+					vertex = string(v)
+				case logMsg:
+					msg = v
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			log.Write(msg)
+			for _, line := range strings.Split(strings.TrimRight(string(msg), "\n"), "\n") {
+				p.output(vertex, line)
+			}
+		}
+		return nil
+	})
+}
+
+// fields calls fn with each length-delimited field of a message, skipping
+// fields of other wire types, which the ones read here never are
+func fields(b []byte, fn func(protowire.Number, []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := fn(num, v); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
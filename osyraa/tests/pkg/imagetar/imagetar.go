@@ -0,0 +1,233 @@
+// Package imagetar verifies image archives written by docker save, the
+// form the site is carried into air-gapped hosts in. Both the legacy
+// layout (manifest.json, <id>.json and <layer>/layer.tar) and the OCI
+// layout Docker 25 adds (blobs/sha256/<digest>) are understood.
+package imagetar
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// keepSize is the largest entry kept in memory; manifests and image
+// configs are far smaller, layers usually far larger
+const keepSize = 1 << 20
+
+// Archive is what a verified archive holds
+type Archive struct {
+	// ID is the image ID, the digest of its config, as "sha256:<hex>"
+	ID       string
+	RepoTags []string
+	// Layers counts the image's file system layers
+	Layers int
+	// Size is the archive's length in bytes
+	Size int64
+}
+
+// entry is what is recorded of a file in the archive
+type entry struct {
+	// digest is the hex SHA-256 of the entry as stored, and diffID of its
+	// content once decompressed, which differ only for gzipped layers
+	digest, diffID string
+	body           []byte
+}
+
+// manifest is an image's entry in manifest.json
+type manifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// config is the part of the image config that names its layers
+type config struct {
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+// Verify reads an archive of exactly one image and checks it is intact:
+// every content-addressed blob must hash to its name, the config to the
+// image ID, and each layer to the diff ID the config lists for it, in
+// order.
+func Verify(r io.Reader) (*Archive, error) {
+	counter := &countingReader{r: r}
+	entries, err := read(counter)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for name, e := range entries {
+		if want, ok := strings.CutPrefix(name, "blobs/sha256/"); ok && e.digest != want {
+			errs = append(errs, fmt.Errorf("%s: content hashes to sha256:%s", name, e.digest))
+		}
+	}
+
+	mf, ok := entries["manifest.json"]
+	if !ok || mf.body == nil {
+		return nil, errors.New("no manifest.json in the archive")
+	}
+	var manifests []manifest
+	if err := json.Unmarshal(mf.body, &manifests); err != nil {
+		return nil, fmt.Errorf("manifest.json: %w", err)
+	}
+	if len(manifests) != 1 {
+		return nil, fmt.Errorf("manifest.json lists %d images, want 1", len(manifests))
+	}
+	m := manifests[0]
+
+	cf, ok := entries[m.Config]
+	if !ok || cf.body == nil {
+		return nil, fmt.Errorf("config %s is missing from the archive", m.Config)
+	}
+	id := "sha256:" + cf.digest
+	// The legacy layout names the config after the image ID
+	if name := strings.TrimSuffix(path.Base(m.Config), ".json"); name != cf.digest {
+		errs = append(errs, fmt.Errorf("config %s hashes to %s", m.Config, id))
+	}
+	var c config
+	if err := json.Unmarshal(cf.body, &c); err != nil {
+		return nil, fmt.Errorf("config %s: %w", m.Config, err)
+	}
+	if len(c.RootFS.DiffIDs) != len(m.Layers) {
+		errs = append(errs, fmt.Errorf("manifest lists %d layers, config %d", len(m.Layers), len(c.RootFS.DiffIDs)))
+	}
+	for i, layer := range m.Layers {
+		e, ok := entries[layer]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("layer %d (%s) is missing from the archive", i+1, layer))
+		case i < len(c.RootFS.DiffIDs) && "sha256:"+e.diffID != c.RootFS.DiffIDs[i]:
+			errs = append(errs, fmt.Errorf("layer %d (%s) hashes to sha256:%s, config says %s", i+1, layer, e.diffID, c.RootFS.DiffIDs[i]))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &Archive{ID: id, RepoTags: m.RepoTags, Layers: len(m.Layers), Size: counter.n}, nil
+}
+
+// read hashes every file of the archive. Symbolic links, which the legacy
+// layout uses for layers shared between images, take their target's entry.
+func read(r io.Reader) (map[string]entry, error) {
+	entries := map[string]entry{}
+	links := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading archive: %w", err)
+		}
+		name := path.Clean(hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			e, err := hashEntry(tr, hdr.Size)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			entries[name] = e
+		case tar.TypeSymlink:
+			links[name] = path.Join(path.Dir(name), hdr.Linkname)
+		case tar.TypeLink:
+			links[name] = path.Clean(hdr.Linkname)
+		}
+	}
+	for name, target := range links {
+		if e, ok := entries[target]; ok {
+			entries[name] = e
+		}
+	}
+	return entries, nil
+}
+
+// hashEntry digests one file, decompressing it too when it is gzipped
+func hashEntry(r io.Reader, size int64) (entry, error) {
+	raw := sha256.New()
+	br := bufio.NewReader(io.TeeReader(r, raw))
+	var e entry
+	var body bytes.Buffer
+	var content io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return e, err
+		}
+		content = zr
+	}
+	diff := sha256.New()
+	w := io.Writer(diff)
+	if size <= keepSize {
+		w = io.MultiWriter(diff, &body)
+	}
+	if _, err := io.Copy(w, content); err != nil {
+		return e, err
+	}
+	// Drain what the gzip stream left, so the raw digest covers it all
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		return e, err
+	}
+	e.digest = hex.EncodeToString(raw.Sum(nil))
+	e.diffID = hex.EncodeToString(diff.Sum(nil))
+	if size <= keepSize {
+		e.body = body.Bytes()
+	}
+	return e, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// loadMessage is one line of the daemon's reply to an image load
+type loadMessage struct {
+	Stream      string `json:"stream"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// LoadResult reads the daemon's JSON reply to an image load and returns
+// its messages, such as "Loaded image: resume:test", or the error it
+// reported
+func LoadResult(r io.Reader) ([]string, error) {
+	var lines []string
+	dec := json.NewDecoder(r)
+	for {
+		var m loadMessage
+		if err := dec.Decode(&m); err == io.EOF {
+			return lines, nil
+		} else if err != nil {
+			return lines, fmt.Errorf("reading load output: %w", err)
+		}
+		if m.ErrorDetail.Message != "" {
+			return lines, errors.New(m.ErrorDetail.Message)
+		}
+		if m.Error != "" {
+			return lines, errors.New(m.Error)
+		}
+		if s := strings.TrimSpace(m.Stream); s != "" {
+			lines = append(lines, s)
+		}
+	}
+}
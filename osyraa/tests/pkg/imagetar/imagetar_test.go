@@ -0,0 +1,158 @@
+package imagetar
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// file is one entry of a test archive; a link is written as a symlink
+type file struct {
+	name, link string
+	body       []byte
+}
+
+func archive(t *testing.T, files []file) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		if f.link != "" {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeSymlink, Linkname: f.link}))
+			continue
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(f.body))}))
+		_, err := tw.Write(f.body)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+// image returns two layers and a config listing them
+func image(t *testing.T) (layers [][]byte, cfg []byte) {
+	layers = [][]byte{
+		archive(t, []file{{name: "etc/os-release", body: []byte("ID=alpine\n")}}),
+		archive(t, []file{{name: "usr/share/nginx/html/index.html", body: []byte("<h1>Resume</h1>")}}),
+	}
+	var c config
+	for _, l := range layers {
+		c.RootFS.DiffIDs = append(c.RootFS.DiffIDs, "sha256:"+digest(l))
+	}
+	cfg, err := json.Marshal(c)
+	require.NoError(t, err)
+	return layers, cfg
+}
+
+func manifestJSON(t *testing.T, m ...manifest) []byte {
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+	return b
+}
+
+func TestVerifyOCILayout(t *testing.T) {
+	layers, cfg := image(t)
+	m := manifest{Config: "blobs/sha256/" + digest(cfg), RepoTags: []string{"resume:test"}}
+	files := []file{{name: "oci-layout", body: []byte(`{"imageLayoutVersion":"1.0.0"}`)}, {name: m.Config, body: cfg}}
+	for _, l := range layers {
+		name := "blobs/sha256/" + digest(l)
+		m.Layers = append(m.Layers, name)
+		files = append(files, file{name: name, body: l})
+	}
+	files = append(files, file{name: "manifest.json", body: manifestJSON(t, m)})
+	tarball := archive(t, files)
+
+	a, err := Verify(bytes.NewReader(tarball))
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:"+digest(cfg), a.ID)
+	assert.Equal(t, []string{"resume:test"}, a.RepoTags)
+	assert.Equal(t, 2, a.Layers)
+	assert.Equal(t, int64(len(tarball)), a.Size)
+
+	// A flipped byte in a layer breaks both its blob name and its diff ID
+	corrupt := bytes.Clone(layers[1])
+	corrupt[600] ^= 0xff
+	files[3].body = corrupt
+	_, err = Verify(bytes.NewReader(archive(t, files)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), m.Layers[1]+": content hashes to sha256:"+digest(corrupt))
+	assert.Contains(t, err.Error(), "layer 2 ("+m.Layers[1]+") hashes to sha256:"+digest(corrupt)+", config says sha256:"+digest(layers[1]))
+}
+
+func TestVerifyLegacyLayout(t *testing.T) {
+	layers, cfg := image(t)
+	m := manifest{Config: digest(cfg) + ".json", RepoTags: []string{"resume:test"}, Layers: []string{"a/layer.tar", "b/layer.tar", "c/layer.tar"}}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(layers[1])
+	require.NoError(t, zw.Close())
+	// A layer repeated in the image is a symlink to the first copy
+	layers = append(layers, layers[0])
+	var c config
+	require.NoError(t, json.Unmarshal(cfg, &c))
+	c.RootFS.DiffIDs = append(c.RootFS.DiffIDs, c.RootFS.DiffIDs[0])
+	cfg, err := json.Marshal(c)
+	require.NoError(t, err)
+	m.Config = digest(cfg) + ".json"
+
+	files := []file{
+		{name: m.Config, body: cfg},
+		{name: "a/layer.tar", body: layers[0]},
+		// Compressed layers are checked by their content
+		{name: "b/layer.tar", body: gz.Bytes()},
+		{name: "c/layer.tar", link: "../a/layer.tar"},
+		{name: "manifest.json", body: manifestJSON(t, m)},
+	}
+	a, err := Verify(bytes.NewReader(archive(t, files)))
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:"+digest(cfg), a.ID)
+	assert.Equal(t, 3, a.Layers)
+
+	// A config edited after saving no longer matches its name
+	files[0].body = bytes.Replace(cfg, []byte("sha256:"), []byte("sha256:0"), 1)
+	_, err = Verify(bytes.NewReader(archive(t, files)))
+	assert.ErrorContains(t, err, "config "+m.Config+" hashes to sha256:"+digest(files[0].body))
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	_, err := Verify(strings.NewReader(""))
+	assert.EqualError(t, err, "no manifest.json in the archive")
+
+	_, cfg := image(t)
+	two := manifestJSON(t, manifest{Config: "x.json"}, manifest{Config: "y.json"})
+	_, err = Verify(bytes.NewReader(archive(t, []file{{name: "manifest.json", body: two}})))
+	assert.EqualError(t, err, "manifest.json lists 2 images, want 1")
+
+	m := manifest{Config: digest(cfg) + ".json", Layers: []string{"a/layer.tar"}}
+	_, err = Verify(bytes.NewReader(archive(t, []file{{name: "manifest.json", body: manifestJSON(t, m)}})))
+	assert.EqualError(t, err, "config "+m.Config+" is missing from the archive")
+
+	_, err = Verify(bytes.NewReader(archive(t, []file{{name: m.Config, body: cfg}, {name: "manifest.json", body: manifestJSON(t, m)}})))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "manifest lists 1 layers, config 2")
+	assert.Contains(t, err.Error(), "layer 1 (a/layer.tar) is missing from the archive")
+}
+
+func TestLoadResult(t *testing.T) {
+	lines, err := LoadResult(strings.NewReader(`{"stream":"Loaded image: resume:test\n"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Loaded image: resume:test"}, lines)
+
+	_, err = LoadResult(strings.NewReader(`{"stream":"Loading layer\n"}{"errorDetail":{"message":"unexpected EOF"},"error":"unexpected EOF"}`))
+	assert.EqualError(t, err, "unexpected EOF")
+
+	_, err = LoadResult(strings.NewReader(`not json`))
+	assert.ErrorContains(t, err, "reading load output")
+}
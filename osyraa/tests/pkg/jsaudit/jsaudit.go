@@ -0,0 +1,298 @@
+// Package jsaudit fingerprints third-party JavaScript shipped with the site
+// and reports library versions with known vulnerabilities. It reads the
+// retire.js repository format, so the embedded database can be swapped for
+// the full upstream jsrepository.json.
+package jsaudit
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+)
+
+//go:embed jsrepository.json
+var defaultRepository []byte
+
+// Repository maps library names to their advisories and fingerprints
+type Repository map[string]Library
+
+// Library is one entry of a retire.js repository
+type Library struct {
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	Extractors      Extractors      `json:"extractors"`
+}
+
+// Vulnerability applies to versions in [AtOrAbove, Below)
+type Vulnerability struct {
+	AtOrAbove   string `json:"atOrAbove"`
+	Below       string `json:"below"`
+	Severity    string `json:"severity"`
+	Identifiers struct {
+		CVE     []string `json:"CVE"`
+		Summary string   `json:"summary"`
+	} `json:"identifiers"`
+}
+
+// ID names the vulnerability by its first CVE, or its summary
+func (v Vulnerability) ID() string {
+	if len(v.Identifiers.CVE) > 0 {
+		return strings.Join(v.Identifiers.CVE, ", ")
+	}
+	return v.Identifiers.Summary
+}
+
+// Extractors are regular expressions with a §§version§§ placeholder
+type Extractors struct {
+	URI         []string `json:"uri"`
+	Filename    []string `json:"filename"`
+	FileContent []string `json:"filecontent"`
+}
+
+// DefaultRepository returns the embedded advisory database covering the
+// libraries Hugo themes commonly bundle
+func DefaultRepository() Repository {
+	repo, err := ParseRepository(defaultRepository)
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
+
+// LoadRepository reads a retire.js jsrepository.json file
+func LoadRepository(path string) (Repository, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRepository(data)
+}
+
+// ParseRepository decodes a retire.js repository
+func ParseRepository(data []byte) (Repository, error) {
+	var repo Repository
+	if err := json.Unmarshal(data, &repo); err != nil {
+		return nil, fmt.Errorf("parse JS repository: %w", err)
+	}
+	return repo, nil
+}
+
+// versionPattern is what retire.js substitutes for §§version§§
+const versionPattern = `[0-9][0-9.a-z_\-]+`
+
+var (
+	compiledMu sync.Mutex
+	compiled   = map[string]*regexp.Regexp{}
+)
+
+func extractor(pattern string) *regexp.Regexp {
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+	if re, ok := compiled[pattern]; ok {
+		return re
+	}
+	re, err := regexp.Compile(strings.ReplaceAll(pattern, "§§version§§", versionPattern))
+	if err != nil {
+		// Upstream patterns occasionally use syntax RE2 lacks; skip them
+		re = nil
+	}
+	compiled[pattern] = re
+	return re
+}
+
+// Detection is a library identified in a file or URL
+type Detection struct {
+	Library string
+	Version string
+	// Location is the file path or script URL
+	Location string
+}
+
+// detect runs the extractors of every library against s
+func (r Repository) detect(s string, pick func(Extractors) []string) []Detection {
+	var found []Detection
+	for name, lib := range r {
+		for _, pattern := range pick(lib.Extractors) {
+			re := extractor(pattern)
+			if re == nil {
+				continue
+			}
+			if m := re.FindStringSubmatch(s); len(m) > 1 {
+				found = append(found, Detection{Library: name, Version: strings.TrimSuffix(strings.TrimSuffix(m[1], ".min"), ".")})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// DetectURI identifies libraries referenced by a script URL
+func (r Repository) DetectURI(uri string) []Detection {
+	found := r.detect(uri, func(e Extractors) []string { return e.URI })
+	if u, err := url.Parse(uri); err == nil {
+		found = append(found, r.detect(path.Base(u.Path), func(e Extractors) []string { return e.Filename })...)
+	}
+	return withLocation(dedupe(found), uri)
+}
+
+// DetectFile identifies libraries by file name and content
+func (r Repository) DetectFile(name string, content []byte) []Detection {
+	found := r.detect(filepath.Base(name), func(e Extractors) []string { return e.Filename })
+	found = append(found, r.detect(string(content), func(e Extractors) []string { return e.FileContent })...)
+	return withLocation(dedupe(found), name)
+}
+
+func withLocation(ds []Detection, loc string) []Detection {
+	for i := range ds {
+		ds[i].Location = loc
+	}
+	return ds
+}
+
+func dedupe(ds []Detection) []Detection {
+	seen := map[string]bool{}
+	var out []Detection
+	for _, d := range ds {
+		key := d.Library + "@" + d.Version
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Vulnerabilities returns the advisories affecting a detection
+func (r Repository) Vulnerabilities(d Detection) []Vulnerability {
+	var vulns []Vulnerability
+	for _, v := range r[d.Library].Vulnerabilities {
+		if v.AtOrAbove != "" && CompareVersions(d.Version, v.AtOrAbove) < 0 {
+			continue
+		}
+		if v.Below != "" && CompareVersions(d.Version, v.Below) >= 0 {
+			continue
+		}
+		vulns = append(vulns, v)
+	}
+	return vulns
+}
+
+// Finding is a vulnerable library version shipped with the site
+type Finding struct {
+	Detection
+	Vulnerability Vulnerability
+}
+
+// Severities orders retire.js severities
+var Severities = map[string]int{"none": 0, "low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// Scan fingerprints every .js file under dir and every external script
+// referenced from its HTML, returning all detections and the vulnerable ones
+func (r Repository) Scan(dir string) ([]Detection, []Finding, error) {
+	var detections []Detection
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		switch filepath.Ext(p) {
+		case ".js", ".mjs":
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			detections = append(detections, r.DetectFile(filepath.ToSlash(rel), content)...)
+		case ".html":
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			doc, err := match.ParseBytes(content)
+			if err != nil {
+				return err
+			}
+			for _, n := range doc.Select("script[src]").Nodes() {
+				if src, _ := match.Attr(n, "src"); strings.Contains(src, "//") {
+					detections = append(detections, r.DetectURI(src)...)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var findings []Finding
+	for _, d := range detections {
+		for _, v := range r.Vulnerabilities(d) {
+			findings = append(findings, Finding{Detection: d, Vulnerability: v})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return Severities[findings[i].Vulnerability.Severity] > Severities[findings[j].Vulnerability.Severity]
+	})
+	return detections, findings, nil
+}
+
+// CompareVersions compares dotted versions numerically, treating a
+// pre-release suffix (1.9.0b1, 3.0.0-beta1) as lower than the release
+func CompareVersions(a, b string) int {
+	pa, pb := splitVersion(a), splitVersion(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y versionPart
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x.num != y.num {
+			if x.num < y.num {
+				return -1
+			}
+			return 1
+		}
+		if x.pre != y.pre {
+			switch {
+			case x.pre == "":
+				return 1
+			case y.pre == "":
+				return -1
+			case x.pre < y.pre:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+type versionPart struct {
+	num int
+	pre string
+}
+
+var partRe = regexp.MustCompile(`^(\d*)(.*)$`)
+
+func splitVersion(v string) []versionPart {
+	var parts []versionPart
+	for _, s := range strings.Split(v, ".") {
+		m := partRe.FindStringSubmatch(s)
+		n, _ := strconv.Atoi(m[1])
+		parts = append(parts, versionPart{num: n, pre: strings.TrimLeft(m[2], "-_")})
+	}
+	return parts
+}
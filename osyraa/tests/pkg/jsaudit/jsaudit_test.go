@@ -0,0 +1,62 @@
+package jsaudit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, -1, CompareVersions("3.4.1", "3.5.0"))
+	assert.Equal(t, 0, CompareVersions("3.4", "3.4.0"))
+	assert.Equal(t, 1, CompareVersions("3.10.0", "3.9.9"))
+	assert.Equal(t, -1, CompareVersions("3.0.0-beta1", "3.0.0"))
+	assert.Equal(t, 1, CompareVersions("1.9.1", "1.9.0b1"))
+}
+
+func TestDetect(t *testing.T) {
+	repo := DefaultRepository()
+
+	ds := repo.DetectURI("https://code.jquery.com/jquery-3.4.1.min.js")
+	require.Len(t, ds, 1)
+	assert.Equal(t, Detection{Library: "jquery", Version: "3.4.1", Location: "https://code.jquery.com/jquery-3.4.1.min.js"}, ds[0])
+
+	ds = repo.DetectURI("https://cdn.jsdelivr.net/npm/bootstrap@4.0.0/dist/js/bootstrap.min.js")
+	require.Len(t, ds, 1)
+	assert.Equal(t, "4.0.0", ds[0].Version)
+
+	ds = repo.DetectFile("js/vendor.js", []byte("/*! jQuery v3.6.0 | (c) OpenJS Foundation */\n!function(e){}"))
+	require.Len(t, ds, 1)
+	assert.Equal(t, "3.6.0", ds[0].Version)
+	assert.Empty(t, repo.Vulnerabilities(ds[0]))
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "js"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "js", "jquery-1.11.0.min.js"), []byte("/*! jQuery v1.11.0 */"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte(`<html><head>
+<script src="https://cdnjs.cloudflare.com/ajax/libs/lodash.js/4.17.20/lodash.min.js"></script>
+<script src="/js/site.js"></script></head></html>`), 0o644))
+
+	detections, findings, err := DefaultRepository().Scan(dir)
+	require.NoError(t, err)
+	assert.Len(t, detections, 2)
+
+	require.NotEmpty(t, findings)
+	assert.Equal(t, "lodash", findings[0].Library, "high severity first")
+	assert.Equal(t, "CVE-2021-23337", findings[0].Vulnerability.ID())
+
+	var jqueryIDs []string
+	for _, f := range findings {
+		if f.Library == "jquery" {
+			jqueryIDs = append(jqueryIDs, f.Vulnerability.ID())
+		}
+	}
+	assert.Contains(t, jqueryIDs, "CVE-2015-9251")
+	assert.Contains(t, jqueryIDs, "CVE-2020-11022, CVE-2020-11023")
+	assert.NotContains(t, jqueryIDs, "CVE-2012-6708")
+}
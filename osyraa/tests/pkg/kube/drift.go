@@ -0,0 +1,59 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/distribution/reference"
+)
+
+// ImageRefs returns the image of every container with the given name
+func ImageRefs(objs []Object, container string) []string {
+	var refs []string
+	for _, o := range objs {
+		for _, c := range o.Containers() {
+			if c["name"] == container {
+				if img, ok := c["image"].(string); ok {
+					refs = append(refs, img)
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// CheckReference fails when a manifest image reference names another
+// repository than published, the reference the tested image is published
+// under, or deploys the mutable "latest" tag
+func CheckReference(manifestRef, published string) error {
+	deployed, err := reference.ParseNormalizedNamed(manifestRef)
+	if err != nil {
+		return fmt.Errorf("manifest image %q: %w", manifestRef, err)
+	}
+	tested, err := reference.ParseNormalizedNamed(published)
+	if err != nil {
+		return fmt.Errorf("published image %q: %w", published, err)
+	}
+
+	if deployed.Name() != tested.Name() {
+		return fmt.Errorf("manifest deploys %s, but %s was tested", reference.FamiliarName(deployed), reference.FamiliarName(tested))
+	}
+	if _, ok := deployed.(reference.Digested); ok {
+		return nil
+	}
+	if tagged, ok := deployed.(reference.Tagged); !ok || tagged.Tag() == "latest" {
+		return errors.New("manifest uses the mutable latest tag; pin a tag or digest so the tested image is what deploys")
+	}
+	return nil
+}
+
+// CheckDrift fails when deployedID, the ID of the image the registry
+// serves for a manifest reference, is not testedID. Both are config
+// digests, which a registry keeps as pushed, so they only match when the
+// manifest deploys the very build the suite validated.
+func CheckDrift(manifestRef, deployedID, testedID string) error {
+	if deployedID != testedID {
+		return fmt.Errorf("manifest image %s is %s, but %s was tested", manifestRef, deployedID, testedID)
+	}
+	return nil
+}
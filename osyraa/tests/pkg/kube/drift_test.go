@@ -0,0 +1,28 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReference(t *testing.T) {
+	published := "myacr.azurecr.io/resume:main-abc1234"
+
+	assert.NoError(t, CheckReference("myacr.azurecr.io/resume:main-abc1234", published))
+	assert.NoError(t, CheckReference("myacr.azurecr.io/resume:main-0000000", published), "Tags are resolved against the registry, not compared")
+	assert.NoError(t, CheckReference("myacr.azurecr.io/resume@sha256:2222222222222222222222222222222222222222222222222222222222222222", published))
+
+	assert.ErrorContains(t, CheckReference("myacr.azurecr.io/resume:latest", published), "mutable latest")
+	assert.ErrorContains(t, CheckReference("myacr.azurecr.io/resume", published), "mutable latest")
+	assert.ErrorContains(t, CheckReference("ghcr.io/other/osyraa:main-abc1234", published), "ghcr.io/other/osyraa")
+}
+
+func TestCheckDrift(t *testing.T) {
+	tested := "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+	assert.NoError(t, CheckDrift("myacr.azurecr.io/resume:main-abc1234", tested, tested))
+	err := CheckDrift("myacr.azurecr.io/resume:main-abc1234", "sha256:3333333333333333333333333333333333333333333333333333333333333333", tested)
+	assert.ErrorContains(t, err, "myacr.azurecr.io/resume:main-abc1234 is sha256:3333")
+	assert.ErrorContains(t, err, tested+" was tested")
+}
@@ -0,0 +1,63 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Invariants are per-environment rules every rendered manifest set must obey
+type Invariants struct {
+	// MinReplicas and MaxReplicas bound Deployment replica counts; zero disables
+	MinReplicas int
+	MaxReplicas int
+	// RequireLimits demands cpu and memory requests and limits on every container
+	RequireLimits bool
+	// Container names the application container whose image is checked
+	Container string
+	// Image, when set, is the exact image reference the container must use
+	Image string
+}
+
+// CheckInvariants validates every workload in objs and reports all violations
+func CheckInvariants(objs []Object, inv Invariants) error {
+	var errs []error
+	workloads := 0
+	for _, o := range objs {
+		if o.Kind() != "Deployment" && o.Kind() != "StatefulSet" {
+			continue
+		}
+		workloads++
+		id := o.Kind() + "/" + o.Name()
+
+		replicas := 1
+		if r, ok := o.Field("spec", "replicas").(int); ok {
+			replicas = r
+		}
+		if inv.MinReplicas > 0 && replicas < inv.MinReplicas {
+			errs = append(errs, fmt.Errorf("%s: %d replicas, want at least %d", id, replicas, inv.MinReplicas))
+		}
+		if inv.MaxReplicas > 0 && replicas > inv.MaxReplicas {
+			errs = append(errs, fmt.Errorf("%s: %d replicas, want at most %d", id, replicas, inv.MaxReplicas))
+		}
+
+		for _, c := range o.Containers() {
+			name, _ := c["name"].(string)
+			if inv.RequireLimits {
+				for _, kind := range []string{"requests", "limits"} {
+					for _, res := range []string{"cpu", "memory"} {
+						if Object(c).Field("resources", kind, res) == nil {
+							errs = append(errs, fmt.Errorf("%s container %s: missing resources.%s.%s", id, name, kind, res))
+						}
+					}
+				}
+			}
+			if inv.Image != "" && name == inv.Container && c["image"] != inv.Image {
+				errs = append(errs, fmt.Errorf("%s container %s: image %v, want %s", id, name, c["image"], inv.Image))
+			}
+		}
+	}
+	if workloads == 0 {
+		errs = append(errs, errors.New("no Deployment or StatefulSet found"))
+	}
+	return errors.Join(errs...)
+}
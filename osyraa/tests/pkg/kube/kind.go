@@ -0,0 +1,238 @@
+package kube
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/cleanup"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/tracing"
+)
+
+// Available reports an error naming the first missing CLI the kind mode needs
+func Available() error {
+	for _, bin := range []string{"kind", "kubectl"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			return fmt.Errorf("%s not found in PATH", bin)
+		}
+	}
+	return nil
+}
+
+// Cluster is a kind cluster with its own kubeconfig, so tests never touch
+// the user's current context
+type Cluster struct {
+	Name       string
+	Kubeconfig string
+	dir        string
+}
+
+// kindConfig labels the single node ingress-ready, which the kind
+// ingress-nginx manifest's controller selects. Its hostPorts 80 and 443
+// are not mapped to the host: tests reach the controller through a
+// port-forward, and host ports would keep two clusters from running at
+// once.
+const kindConfig = `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+nodes:
+- role: control-plane
+  labels:
+    ingress-ready: "true"
+`
+
+// invalidClusterChars are what kind doesn't allow in a cluster name
+var invalidClusterChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// ClusterName makes name a valid kind cluster name: at most 32 lowercase
+// letters, digits, dots and dashes
+func ClusterName(name string) string {
+	name = invalidClusterChars.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(name[:min(len(name), 32)], ".-")
+}
+
+// CreateCluster creates a kind cluster and waits for the control plane.
+// The directory holding its kubeconfig is marked with labels and the
+// cluster's name first, so osyraa clean can find a cluster whose run died.
+func CreateCluster(ctx context.Context, name string, labels map[string]string) (*Cluster, error) {
+	dir, err := os.MkdirTemp("", "osyraa-kind-")
+	if err != nil {
+		return nil, err
+	}
+	c := &Cluster{Name: name, Kubeconfig: filepath.Join(dir, "kubeconfig"), dir: dir}
+	marker := maps.Clone(labels)
+	if marker == nil {
+		marker = map[string]string{}
+	}
+	marker[cleanup.LabelKindCluster] = name
+	if err := cleanup.MarkDir(dir, marker); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	config := filepath.Join(dir, "kind.yaml")
+	if err := os.WriteFile(config, []byte(kindConfig), 0o644); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if _, err := run(ctx, nil, "kind", "create", "cluster", "--name", name, "--config", config,
+		"--kubeconfig", c.Kubeconfig, "--wait", "120s"); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return c, nil
+}
+
+// Delete tears the cluster down and removes its kubeconfig
+func (c *Cluster) Delete(ctx context.Context) error {
+	_, err := run(ctx, nil, "kind", "delete", "cluster", "--name", c.Name, "--kubeconfig", c.Kubeconfig)
+	os.RemoveAll(c.dir)
+	return err
+}
+
+// LoadImage copies a locally built image into the cluster nodes
+func (c *Cluster) LoadImage(ctx context.Context, image string) error {
+	_, err := run(ctx, nil, "kind", "load", "docker-image", image, "--name", c.Name)
+	return err
+}
+
+// Kubectl runs kubectl against the cluster
+func (c *Cluster) Kubectl(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	args = append([]string{"--kubeconfig", c.Kubeconfig}, args...)
+	var in io.Reader
+	if stdin != nil {
+		in = bytes.NewReader(stdin)
+	}
+	return run(ctx, in, "kubectl", args...)
+}
+
+// Apply applies a rendered manifest stream
+func (c *Cluster) Apply(ctx context.Context, manifest []byte) error {
+	_, err := c.Kubectl(ctx, manifest, "apply", "-f", "-")
+	return err
+}
+
+// RolloutStatus waits for a deployment rollout to complete
+func (c *Cluster) RolloutStatus(ctx context.Context, namespace, deployment string, timeout time.Duration) error {
+	_, err := c.Kubectl(ctx, nil, "rollout", "status", "-n", namespace,
+		"deployment/"+deployment, "--timeout", timeout.String())
+	return err
+}
+
+// IngressNginxManifest is the kind-specific ingress-nginx deployment
+const IngressNginxManifest = "https://raw.githubusercontent.com/kubernetes/ingress-nginx/controller-v1.10.1/deploy/static/provider/kind/deploy.yaml"
+
+// InstallIngressNginx deploys the ingress-nginx controller and waits for
+// it. The manifest is fetched from GitHub, and the controller only runs on
+// a cluster from CreateCluster, whose node is labelled ingress-ready.
+func (c *Cluster) InstallIngressNginx(ctx context.Context, timeout time.Duration) error {
+	if _, err := c.Kubectl(ctx, nil, "apply", "-f", IngressNginxManifest); err != nil {
+		return err
+	}
+	return c.WaitReady(ctx, "ingress-nginx", "app.kubernetes.io/component=controller", timeout)
+}
+
+// WaitReady waits until the pods matching selector report Ready
+func (c *Cluster) WaitReady(ctx context.Context, namespace, selector string, timeout time.Duration) error {
+	_, err := c.Kubectl(ctx, nil, "wait", "-n", namespace, "--for=condition=ready", "pod",
+		"--selector", selector, "--timeout", timeout.String())
+	return err
+}
+
+// PortForward is a running kubectl port-forward
+type PortForward struct {
+	LocalPort int
+	cmd       *exec.Cmd
+}
+
+// URL returns the local http URL of the forwarded port
+func (p *PortForward) URL() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", p.LocalPort)
+}
+
+// Close stops the port-forward
+func (p *PortForward) Close() error {
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+	return p.cmd.Wait()
+}
+
+var forwardLine = regexp.MustCompile(`Forwarding from 127\.0\.0\.1:(\d+) ->`)
+
+// PortForward forwards an ephemeral local port to resource (e.g. svc/resume)
+// and returns once kubectl reports the tunnel is listening. It gives up
+// when ctx is done, when kubectl exits first or after 30 seconds, with
+// what kubectl wrote to stderr.
+func (c *Cluster) PortForward(ctx context.Context, namespace, resource string, remotePort int) (*PortForward, error) {
+	cmd := exec.CommandContext(ctx, "kubectl", "--kubeconfig", c.Kubeconfig,
+		"port-forward", "-n", namespace, "--address", "127.0.0.1", resource, fmt.Sprintf(":%d", remotePort))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// ports is closed once stdout ends, so kubectl exiting without
+	// forwarding ends the wait
+	ports := make(chan int, 1)
+	go func() {
+		defer close(ports)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if port, ok := parseForwardLine(scanner.Text()); ok {
+				ports <- port
+				break
+			}
+		}
+		io.Copy(io.Discard, stdout)
+	}()
+
+	var reason error
+	select {
+	case port, ok := <-ports:
+		if ok {
+			return &PortForward{LocalPort: port, cmd: cmd}, nil
+		}
+		reason = errors.New("kubectl exited before forwarding")
+	case <-ctx.Done():
+		reason = ctx.Err()
+	case <-time.After(30 * time.Second):
+		reason = errors.New("did not become ready")
+	}
+	cmd.Process.Kill()
+	cmd.Wait()
+	return nil, fmt.Errorf("port-forward to %s/%s: %w: %s", namespace, resource, reason, bytes.TrimSpace(stderr.Bytes()))
+}
+
+func parseForwardLine(line string) (int, bool) {
+	m := forwardLine.FindStringSubmatch(line)
+	if m == nil {
+		return 0, false
+	}
+	port, err := strconv.Atoi(m[1])
+	return port, err == nil
+}
+
+func run(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+	out, err := tracing.Run(ctx, name+" "+args[0], cmd)
+	if err != nil {
+		return out, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, bytes.TrimSpace(out))
+	}
+	return out, nil
+}
@@ -0,0 +1,129 @@
+package kube
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const manifests = `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: resume
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: resume
+  namespace: resume
+spec:
+  template:
+    spec:
+      containers:
+      - name: resume
+        image: ghcr.io/example/osyraa:latest
+      - name: nginx-exporter
+        image: nginx/nginx-prometheus-exporter:0.11.0
+`
+
+func TestDecodeAndSetImage(t *testing.T) {
+	objs, err := DecodeManifests([]byte(manifests))
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+
+	dep := Find(objs, "Deployment", "resume")
+	require.NotNil(t, dep)
+	assert.Equal(t, "resume", dep.Namespace())
+
+	assert.Equal(t, 1, SetImage(objs, "resume", "resume:test"))
+	out, err := EncodeManifests(objs)
+	require.NoError(t, err)
+
+	again, err := DecodeManifests(out)
+	require.NoError(t, err)
+	containers := Find(again, "Deployment", "resume").Containers()
+	require.Len(t, containers, 2)
+	assert.Equal(t, "resume:test", containers[0]["image"])
+	assert.Equal(t, "nginx/nginx-prometheus-exporter:0.11.0", containers[1]["image"])
+}
+
+func TestClusterName(t *testing.T) {
+	assert.Equal(t, "osyraa-4242-0a1b2c3d", ClusterName("osyraa-4242-0a1b2c3d"))
+	assert.Equal(t, "osyraa-ci-build-7", ClusterName("osyraa-CI_build-7"))
+	assert.Equal(t, "osyraa-a-very-long-run-id-that-k", ClusterName("osyraa-a-very-long-run-id-that-kind-rejects"))
+	assert.Equal(t, "osyraa-run", ClusterName("osyraa-run------------------------x"))
+}
+
+func TestParseForwardLine(t *testing.T) {
+	port, ok := parseForwardLine("Forwarding from 127.0.0.1:41235 -> 80")
+	assert.True(t, ok)
+	assert.Equal(t, 41235, port)
+
+	_, ok = parseForwardLine("Handling connection for 41235")
+	assert.False(t, ok)
+}
+
+func TestPortForwardKubectlExits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake kubectl is a shell script")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho 'error: services \"resume\" not found' >&2\nexit 1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "kubectl"), []byte(script), 0o755))
+	t.Setenv("PATH", bin)
+
+	_, err := (&Cluster{}).PortForward(context.Background(), "resume", "svc/resume", 80)
+	assert.ErrorContains(t, err, "kubectl exited before forwarding", "A kubectl that exits ends the wait at once")
+	assert.ErrorContains(t, err, `services "resume" not found`, "The error carries kubectl's stderr")
+}
+
+func TestCheckInvariants(t *testing.T) {
+	objs, err := DecodeManifests([]byte(manifests))
+	require.NoError(t, err)
+
+	err = CheckInvariants(objs, Invariants{MinReplicas: 2, RequireLimits: true, Container: "resume", Image: "resume:test"})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "1 replicas, want at least 2")
+	assert.ErrorContains(t, err, "container nginx-exporter: missing resources.limits.memory")
+	assert.ErrorContains(t, err, "image ghcr.io/example/osyraa:latest, want resume:test")
+
+	SetImage(objs, "resume", "resume:test")
+	assert.NoError(t, CheckInvariants(objs, Invariants{MaxReplicas: 1, Container: "resume", Image: "resume:test"}))
+
+	assert.ErrorContains(t, CheckInvariants(objs[:1], Invariants{}), "no Deployment")
+}
+
+func TestEnvironments(t *testing.T) {
+	root := t.TempDir()
+	deployment := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: resume\n---\nkind: Service\nmetadata:\n  name: resume\n"
+	for name, body := range map[string]string{
+		"dev/resume.yaml":         deployment,
+		"dev/notes.txt":           "not a manifest",
+		"prod/kustomization.yaml": "resources: []\n",
+		"README.md":               "",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(body), 0o644))
+	}
+
+	envs, err := Environments(root)
+	require.NoError(t, err)
+	require.Len(t, envs, 2)
+	assert.Equal(t, Overlay{Name: "dev", Dir: filepath.Join(root, "dev")}, envs[0])
+	assert.Equal(t, Overlay{Name: "prod", Dir: filepath.Join(root, "prod"), Kustomized: true}, envs[1])
+
+	rendered, objs, err := Render(context.Background(), envs[0])
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+	assert.Equal(t, "Deployment", objs[0].Kind())
+	assert.Contains(t, string(rendered), "kind: Service")
+
+	_, err = Environments(filepath.Join(root, "missing"))
+	assert.Error(t, err)
+}
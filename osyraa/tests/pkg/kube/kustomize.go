@@ -0,0 +1,93 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Overlay is a directory containing a kustomization file, or an
+// environment's directory of manifests
+type Overlay struct {
+	Name string
+	Dir  string
+	// Kustomized is set when Dir has a kustomization to build
+	Kustomized bool
+}
+
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// Environments returns each directory directly below root as an
+// environment named after it, e.g. gitops/applications/dev, whether it
+// holds a kustomization or plain manifests
+func Environments(root string) ([]Overlay, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var envs []Overlay
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		env := Overlay{Name: e.Name(), Dir: dir}
+		for _, name := range kustomizationFiles {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				env.Kustomized = true
+			}
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// Render returns what an environment deploys: built with kustomize when
+// it has a kustomization, or read from its YAML files as ArgoCD applies a
+// plain directory
+func Render(ctx context.Context, env Overlay) ([]byte, []Object, error) {
+	if env.Kustomized {
+		return Build(ctx, env.Dir)
+	}
+	objs, err := ReadManifestDir(env.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	rendered, err := EncodeManifests(objs)
+	return rendered, objs, err
+}
+
+// Build renders an overlay with kubectl's built-in kustomize; no cluster is needed
+func Build(ctx context.Context, dir string) ([]byte, []Object, error) {
+	out, err := run(ctx, nil, "kubectl", "kustomize", dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	objs, err := DecodeManifests(out)
+	return out, objs, err
+}
+
+// SchemaValidatorAvailable reports whether kubeconform is installed
+func SchemaValidatorAvailable() bool {
+	_, err := exec.LookPath("kubeconform")
+	return err == nil
+}
+
+// crdSchemas is where kubeconform finds the schemas of the custom
+// resources the repository deploys, such as cert-manager's and ArgoCD's
+const crdSchemas = "https://raw.githubusercontent.com/datreeio/CRDs-catalog/main/{{.Group}}/{{.ResourceKind}}_{{.ResourceAPIVersion}}.json"
+
+// ValidateSchemas checks rendered manifests against the Kubernetes OpenAPI
+// schemas for the given version (e.g. "1.29.0") using kubeconform, and
+// custom resources against the CRDs catalog. A kind with no schema in
+// either fails, rather than passing unchecked.
+func ValidateSchemas(ctx context.Context, manifest []byte, kubernetesVersion string) error {
+	args := []string{"-strict", "-summary", "-schema-location", "default", "-schema-location", crdSchemas}
+	if kubernetesVersion != "" {
+		args = append(args, "-kubernetes-version", kubernetesVersion)
+	}
+	_, err := run(ctx, bytes.NewReader(manifest), "kubeconform", append(args, "-")...)
+	return err
+}
@@ -0,0 +1,130 @@
+// Package kube drives throwaway kind clusters and reads the repository's
+// Kubernetes manifests so the suite can test the site the way it is
+// actually deployed.
+package kube
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Object is a decoded Kubernetes resource
+type Object map[string]any
+
+// Kind returns the resource kind
+func (o Object) Kind() string {
+	s, _ := o["kind"].(string)
+	return s
+}
+
+// Name returns metadata.name
+func (o Object) Name() string {
+	s, _ := o.Field("metadata", "name").(string)
+	return s
+}
+
+// Namespace returns metadata.namespace
+func (o Object) Namespace() string {
+	s, _ := o.Field("metadata", "namespace").(string)
+	return s
+}
+
+// Field walks nested maps and returns the value at path, or nil
+func (o Object) Field(path ...string) any {
+	var cur any = map[string]any(o)
+	for _, key := range path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[key]
+	}
+	return cur
+}
+
+// Containers returns the pod template containers of a workload
+func (o Object) Containers() []map[string]any {
+	list, _ := o.Field("spec", "template", "spec", "containers").([]any)
+	containers := make([]map[string]any, 0, len(list))
+	for _, c := range list {
+		if m, ok := c.(map[string]any); ok {
+			containers = append(containers, m)
+		}
+	}
+	return containers
+}
+
+// ReadManifests decodes every document in a multi-document YAML file
+func ReadManifests(path string) ([]Object, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeManifests(data)
+}
+
+// DecodeManifests decodes a multi-document YAML stream, skipping empty documents
+func DecodeManifests(data []byte) ([]Object, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var objs []Object
+	for {
+		// Decode into a plain map so nested values stay map[string]any
+		var obj map[string]any
+		err := dec.Decode(&obj)
+		if errors.Is(err, io.EOF) {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode manifest: %w", err)
+		}
+		if len(obj) > 0 {
+			objs = append(objs, Object(obj))
+		}
+	}
+}
+
+// EncodeManifests renders objects back into a multi-document YAML stream
+func EncodeManifests(objs []Object) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, obj := range objs {
+		if err := enc.Encode(map[string]any(obj)); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Find returns the first object with the given kind and name
+func Find(objs []Object, kind, name string) Object {
+	for _, o := range objs {
+		if o.Kind() == kind && o.Name() == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// SetImage points every container with the given name at image and returns
+// how many containers were changed
+func SetImage(objs []Object, container, image string) int {
+	changed := 0
+	for _, o := range objs {
+		for _, c := range o.Containers() {
+			if c["name"] == container {
+				c["image"] = image
+				changed++
+			}
+		}
+	}
+	return changed
+}
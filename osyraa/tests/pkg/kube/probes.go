@@ -0,0 +1,265 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Probe is a liveness, readiness or startup probe as declared in a manifest
+type Probe struct {
+	Kind             string
+	HTTPPath         string
+	HTTPScheme       string
+	HTTPHeaders      map[string]string
+	Port             int
+	Exec             []string
+	TCP              bool
+	InitialDelay     time.Duration
+	Period           time.Duration
+	Timeout          time.Duration
+	SuccessThreshold int
+	FailureThreshold int
+}
+
+// Window is the longest a probe may keep failing before kubelet gives up
+func (p Probe) Window() time.Duration {
+	return p.InitialDelay + time.Duration(p.FailureThreshold)*p.Period
+}
+
+// Probes extracts the probes declared for a container, resolving named ports
+// and applying the Kubernetes defaults for unset fields
+func Probes(obj Object, container string) ([]Probe, error) {
+	for _, c := range obj.Containers() {
+		if c["name"] != container {
+			continue
+		}
+		var probes []Probe
+		for _, kind := range []string{"startupProbe", "readinessProbe", "livenessProbe"} {
+			spec, ok := c[kind].(map[string]any)
+			if !ok {
+				continue
+			}
+			p, err := parseProbe(kind, spec, c)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", kind, err)
+			}
+			probes = append(probes, p)
+		}
+		return probes, nil
+	}
+	return nil, fmt.Errorf("container %s not found in %s/%s", container, obj.Kind(), obj.Name())
+}
+
+func parseProbe(kind string, spec, container map[string]any) (Probe, error) {
+	p := Probe{
+		Kind:             strings.TrimSuffix(kind, "Probe"),
+		Period:           seconds(spec["periodSeconds"], 10),
+		Timeout:          seconds(spec["timeoutSeconds"], 1),
+		InitialDelay:     seconds(spec["initialDelaySeconds"], 0),
+		SuccessThreshold: intOr(spec["successThreshold"], 1),
+		FailureThreshold: intOr(spec["failureThreshold"], 3),
+	}
+
+	switch {
+	case spec["httpGet"] != nil:
+		get, _ := spec["httpGet"].(map[string]any)
+		p.HTTPPath, _ = get["path"].(string)
+		if p.HTTPPath == "" {
+			p.HTTPPath = "/"
+		}
+		p.HTTPScheme = "http"
+		if s, ok := get["scheme"].(string); ok {
+			p.HTTPScheme = strings.ToLower(s)
+		}
+		if headers, ok := get["httpHeaders"].([]any); ok {
+			p.HTTPHeaders = map[string]string{}
+			for _, h := range headers {
+				if m, ok := h.(map[string]any); ok {
+					p.HTTPHeaders[fmt.Sprint(m["name"])] = fmt.Sprint(m["value"])
+				}
+			}
+		}
+		port, err := resolvePort(get["port"], container)
+		if err != nil {
+			return p, err
+		}
+		p.Port = port
+	case spec["tcpSocket"] != nil:
+		sock, _ := spec["tcpSocket"].(map[string]any)
+		port, err := resolvePort(sock["port"], container)
+		if err != nil {
+			return p, err
+		}
+		p.Port, p.TCP = port, true
+	case spec["exec"] != nil:
+		ex, _ := spec["exec"].(map[string]any)
+		cmd, _ := ex["command"].([]any)
+		for _, arg := range cmd {
+			p.Exec = append(p.Exec, fmt.Sprint(arg))
+		}
+	default:
+		return p, errors.New("no httpGet, tcpSocket or exec handler")
+	}
+	return p, nil
+}
+
+// resolvePort turns a numeric or named probe port into a container port
+func resolvePort(v any, container map[string]any) (int, error) {
+	switch port := v.(type) {
+	case int:
+		return port, nil
+	case string:
+		if n, err := strconv.Atoi(port); err == nil {
+			return n, nil
+		}
+		ports, _ := container["ports"].([]any)
+		for _, p := range ports {
+			if m, ok := p.(map[string]any); ok && m["name"] == port {
+				if n, ok := m["containerPort"].(int); ok {
+					return n, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("named port %q not declared on container", port)
+	}
+	return 0, fmt.Errorf("unsupported port %v", v)
+}
+
+func seconds(v any, def int) time.Duration {
+	return time.Duration(intOr(v, def)) * time.Second
+}
+
+func intOr(v any, def int) int {
+	if n, ok := v.(int); ok {
+		return n
+	}
+	return def
+}
+
+// ProbeEnv tells the prober how to reach the container under test
+type ProbeEnv struct {
+	// Addr maps a container port to a host:port reachable from the test
+	Addr func(containerPort int) (string, error)
+	// Exec runs a command inside the container and returns its exit code
+	Exec func(ctx context.Context, cmd []string) (int, error)
+	// Started is when the container started; InitialDelay counts from here
+	Started time.Time
+}
+
+// ProbeOutcome describes how a probe behaved
+type ProbeOutcome struct {
+	Attempts  int
+	Successes int
+	Slowest   time.Duration
+	Elapsed   time.Duration
+}
+
+// Run executes p the way kubelet would: waiting out the initial delay, then
+// probing every period with the declared timeout until SuccessThreshold
+// consecutive successes or FailureThreshold consecutive failures
+func (p Probe) Run(ctx context.Context, env ProbeEnv) (ProbeOutcome, error) {
+	var out ProbeOutcome
+	begin := time.Now()
+	if wait := time.Until(env.Started.Add(p.InitialDelay)); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return out, ctx.Err()
+		}
+	}
+
+	consecutiveOK, consecutiveFail := 0, 0
+	var lastErr error
+	for {
+		start := time.Now()
+		err := p.attempt(ctx, env)
+		took := time.Since(start)
+		out.Attempts++
+		if took > out.Slowest {
+			out.Slowest = took
+		}
+
+		if err == nil {
+			out.Successes++
+			consecutiveOK++
+			consecutiveFail = 0
+			if consecutiveOK >= p.SuccessThreshold {
+				out.Elapsed = time.Since(begin)
+				return out, nil
+			}
+		} else {
+			lastErr = err
+			consecutiveOK = 0
+			consecutiveFail++
+			if consecutiveFail >= p.FailureThreshold {
+				out.Elapsed = time.Since(begin)
+				return out, fmt.Errorf("%s probe failed %d times in a row: %w", p.Kind, consecutiveFail, lastErr)
+			}
+		}
+
+		select {
+		case <-time.After(p.Period):
+		case <-ctx.Done():
+			return out, ctx.Err()
+		}
+	}
+}
+
+func (p Probe) attempt(ctx context.Context, env ProbeEnv) error {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	switch {
+	case p.Exec != nil:
+		if env.Exec == nil {
+			return errors.New("exec probes need ProbeEnv.Exec")
+		}
+		code, err := env.Exec(ctx, p.Exec)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("command exited %d", code)
+		}
+		return nil
+	case p.TCP:
+		addr, err := env.Addr(p.Port)
+		if err != nil {
+			return err
+		}
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	default:
+		addr, err := env.Addr(p.Port)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.HTTPScheme+"://"+addr+p.HTTPPath, nil)
+		if err != nil {
+			return err
+		}
+		for k, v := range p.HTTPHeaders {
+			req.Header.Set(k, v)
+		}
+		// kubelet never follows redirects off-host and treats 2xx/3xx as success
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("GET %s returned %d", p.HTTPPath, resp.StatusCode)
+		}
+		return nil
+	}
+}
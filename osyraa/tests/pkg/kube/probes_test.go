@@ -0,0 +1,90 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const probed = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: resume
+spec:
+  template:
+    spec:
+      containers:
+      - name: resume
+        ports:
+        - containerPort: 80
+          name: http
+        readinessProbe:
+          httpGet:
+            path: /healthz
+            port: http
+          periodSeconds: 5
+          timeoutSeconds: 3
+        livenessProbe:
+          exec:
+            command: ["wget", "-q", "--spider", "http://localhost/"]
+`
+
+func TestProbesResolveNamedPorts(t *testing.T) {
+	objs, err := DecodeManifests([]byte(probed))
+	require.NoError(t, err)
+
+	probes, err := Probes(objs[0], "resume")
+	require.NoError(t, err)
+	require.Len(t, probes, 2)
+
+	readiness := probes[0]
+	assert.Equal(t, "readiness", readiness.Kind)
+	assert.Equal(t, 80, readiness.Port)
+	assert.Equal(t, "/healthz", readiness.HTTPPath)
+	assert.Equal(t, 3*time.Second, readiness.Timeout)
+	assert.Equal(t, 3, readiness.FailureThreshold, "unset thresholds take Kubernetes defaults")
+	assert.Equal(t, 15*time.Second, readiness.Window())
+
+	assert.Equal(t, []string{"wget", "-q", "--spider", "http://localhost/"}, probes[1].Exec)
+
+	_, err = Probes(objs[0], "sidecar")
+	assert.Error(t, err)
+}
+
+func TestProbeRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	env := ProbeEnv{
+		Addr:    func(int) (string, error) { return strings.TrimPrefix(srv.URL, "http://"), nil },
+		Started: time.Now(),
+	}
+	probe := Probe{Kind: "readiness", HTTPPath: "/", HTTPScheme: "http", Port: 80,
+		Period: 10 * time.Millisecond, Timeout: time.Second, SuccessThreshold: 2, FailureThreshold: 3}
+
+	out, err := probe.Run(context.Background(), env)
+	require.NoError(t, err)
+	assert.Equal(t, 2, out.Successes)
+
+	probe.HTTPPath = "/broken"
+	out, err = probe.Run(context.Background(), env)
+	assert.ErrorContains(t, err, "failed 3 times in a row")
+	assert.Equal(t, 3, out.Attempts)
+
+	exec := Probe{Kind: "liveness", Exec: []string{"true"}, Period: time.Millisecond, Timeout: time.Second,
+		SuccessThreshold: 1, FailureThreshold: 1}
+	env.Exec = func(context.Context, []string) (int, error) { return 1, nil }
+	_, err = exec.Run(context.Background(), env)
+	assert.ErrorContains(t, err, "command exited 1")
+}
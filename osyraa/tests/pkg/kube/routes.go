@@ -0,0 +1,231 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Route is a single host/path rule from an Ingress or Gateway API HTTPRoute
+type Route struct {
+	Source    string
+	Namespace string
+	Host      string
+	Path      string
+	PathType  string
+	Service   string
+	Port      any
+	TLSSecret string
+	Issuer    string
+}
+
+// Routes extracts every routing rule from Ingress and HTTPRoute objects
+func Routes(objs []Object) []Route {
+	var routes []Route
+	for _, o := range objs {
+		switch o.Kind() {
+		case "Ingress":
+			routes = append(routes, ingressRoutes(o)...)
+		case "HTTPRoute":
+			routes = append(routes, httpRoutes(o)...)
+		}
+	}
+	return routes
+}
+
+func ingressRoutes(o Object) []Route {
+	source := "Ingress/" + o.Name()
+	secrets := map[string]string{}
+	for _, t := range list(o.Field("spec", "tls")) {
+		if hosts, ok := t["hosts"].([]any); ok {
+			for _, h := range hosts {
+				secrets[fmt.Sprint(h)], _ = t["secretName"].(string)
+			}
+		}
+	}
+	issuer, _ := o.Field("metadata", "annotations", "cert-manager.io/cluster-issuer").(string)
+	if issuer == "" {
+		issuer, _ = o.Field("metadata", "annotations", "cert-manager.io/issuer").(string)
+	}
+
+	var routes []Route
+	for _, rule := range list(o.Field("spec", "rules")) {
+		host, _ := rule["host"].(string)
+		for _, p := range list(Object(rule).Field("http", "paths")) {
+			r := Route{Source: source, Namespace: o.Namespace(), Host: host, TLSSecret: secrets[host], Issuer: issuer}
+			r.Path, _ = p["path"].(string)
+			r.PathType, _ = p["pathType"].(string)
+			r.Service, _ = Object(p).Field("backend", "service", "name").(string)
+			if n := Object(p).Field("backend", "service", "port", "number"); n != nil {
+				r.Port = n
+			} else {
+				r.Port = Object(p).Field("backend", "service", "port", "name")
+			}
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+func httpRoutes(o Object) []Route {
+	source := "HTTPRoute/" + o.Name()
+	hosts := []string{""}
+	if hs, ok := o.Field("spec", "hostnames").([]any); ok && len(hs) > 0 {
+		hosts = hosts[:0]
+		for _, h := range hs {
+			hosts = append(hosts, fmt.Sprint(h))
+		}
+	}
+
+	var routes []Route
+	for _, rule := range list(o.Field("spec", "rules")) {
+		matches := list(rule["matches"])
+		if len(matches) == 0 {
+			matches = []map[string]any{{"path": map[string]any{"type": "PathPrefix", "value": "/"}}}
+		}
+		for _, backend := range list(rule["backendRefs"]) {
+			for _, m := range matches {
+				for _, host := range hosts {
+					r := Route{Source: source, Namespace: o.Namespace(), Host: host, Port: backend["port"]}
+					r.Service, _ = backend["name"].(string)
+					r.Path, _ = Object(m).Field("path", "value").(string)
+					r.PathType, _ = Object(m).Field("path", "type").(string)
+					routes = append(routes, r)
+				}
+			}
+		}
+	}
+	return routes
+}
+
+// Matches reports whether the route would serve a request for host and path
+func (r Route) Matches(host, path string) bool {
+	if r.Host != "" && !strings.EqualFold(r.Host, host) {
+		return false
+	}
+	switch r.PathType {
+	case "Exact":
+		return path == r.Path
+	case "ImplementationSpecific", "RegularExpression":
+		return strings.HasPrefix(path, r.Path)
+	default:
+		// Prefix and PathPrefix match whole path segments
+		prefix := strings.TrimSuffix(r.Path, "/")
+		return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+}
+
+// RouteExpectation is a host and path that must reach a service
+type RouteExpectation struct {
+	Host    string
+	Path    string
+	Service string
+}
+
+// CheckRoutes verifies each expectation is routed to the right service, that
+// every backend exists with the referenced port, and that every TLS secret is
+// either declared or issued by a declared cert-manager issuer. objs should
+// contain everything ArgoCD syncs, so cross-file references resolve.
+func CheckRoutes(objs []Object, expected []RouteExpectation) error {
+	routes := Routes(objs)
+	var errs []error
+
+	for _, want := range expected {
+		var matched *Route
+		for i := range routes {
+			if routes[i].Matches(want.Host, want.Path) {
+				matched = &routes[i]
+				break
+			}
+		}
+		switch {
+		case matched == nil:
+			errs = append(errs, fmt.Errorf("no route serves %s%s", want.Host, want.Path))
+		case matched.Service != want.Service:
+			errs = append(errs, fmt.Errorf("%s%s routes to service %s, want %s", want.Host, want.Path, matched.Service, want.Service))
+		}
+	}
+
+	for _, r := range routes {
+		svc := findNamespaced(objs, "Service", r.Service, r.Namespace)
+		if svc == nil {
+			errs = append(errs, fmt.Errorf("%s: backend service %s/%s not declared", r.Source, r.Namespace, r.Service))
+		} else if !servicePortExists(svc, r.Port) {
+			errs = append(errs, fmt.Errorf("%s: service %s has no port %v", r.Source, r.Service, r.Port))
+		}
+
+		if r.TLSSecret == "" {
+			continue
+		}
+		if findNamespaced(objs, "Secret", r.TLSSecret, r.Namespace) != nil || certificateFor(objs, r.TLSSecret, r.Namespace) {
+			continue
+		}
+		if r.Issuer != "" && Find(objs, "ClusterIssuer", r.Issuer) == nil && findNamespaced(objs, "Issuer", r.Issuer, r.Namespace) == nil {
+			errs = append(errs, fmt.Errorf("%s: TLS secret %s issued by %s, which is not declared", r.Source, r.TLSSecret, r.Issuer))
+		} else if r.Issuer == "" {
+			errs = append(errs, fmt.Errorf("%s: TLS secret %s is neither declared nor issued by cert-manager", r.Source, r.TLSSecret))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func servicePortExists(svc Object, port any) bool {
+	for _, p := range list(svc.Field("spec", "ports")) {
+		if p["port"] == port || (p["name"] != nil && p["name"] == port) {
+			return true
+		}
+	}
+	return false
+}
+
+func certificateFor(objs []Object, secret, namespace string) bool {
+	for _, o := range objs {
+		if o.Kind() == "Certificate" && o.Namespace() == namespace && o.Field("spec", "secretName") == secret {
+			return true
+		}
+	}
+	return false
+}
+
+func findNamespaced(objs []Object, kind, name, namespace string) Object {
+	for _, o := range objs {
+		if o.Kind() == kind && o.Name() == name && (namespace == "" || o.Namespace() == namespace) {
+			return o
+		}
+	}
+	return nil
+}
+
+// list converts a decoded YAML sequence of mappings into a slice of maps
+func list(v any) []map[string]any {
+	items, _ := v.([]any)
+	out := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]any); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// ReadManifestDir decodes every YAML file below dir
+func ReadManifestDir(dir string) ([]Object, error) {
+	var objs []Object
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		found, err := ReadManifests(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		objs = append(objs, found...)
+		return nil
+	})
+	return objs, err
+}
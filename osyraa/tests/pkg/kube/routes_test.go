@@ -0,0 +1,82 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const routed = `
+apiVersion: v1
+kind: Service
+metadata: {name: resume, namespace: resume}
+spec:
+  ports:
+  - {name: http, port: 80}
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: resume
+  namespace: resume
+  annotations:
+    cert-manager.io/cluster-issuer: letsencrypt-prod
+spec:
+  tls:
+  - hosts: [resume.example.com]
+    secretName: resume-tls
+  rules:
+  - host: resume.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: resume
+            port: {number: 80}
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata: {name: docs, namespace: resume}
+spec:
+  hostnames: [docs.example.com]
+  rules:
+  - matches:
+    - path: {type: PathPrefix, value: /guide}
+    backendRefs:
+    - {name: docs, port: 8080}
+`
+
+func TestRoutes(t *testing.T) {
+	objs, err := DecodeManifests([]byte(routed))
+	require.NoError(t, err)
+
+	routes := Routes(objs)
+	require.Len(t, routes, 2)
+	assert.Equal(t, "resume-tls", routes[0].TLSSecret)
+	assert.True(t, routes[0].Matches("resume.example.com", "/anything"))
+	assert.True(t, routes[1].Matches("docs.example.com", "/guide/install"))
+	assert.False(t, routes[1].Matches("docs.example.com", "/guidebook"))
+}
+
+func TestCheckRoutes(t *testing.T) {
+	objs, err := DecodeManifests([]byte(routed))
+	require.NoError(t, err)
+
+	err = CheckRoutes(objs, []RouteExpectation{
+		{Host: "resume.example.com", Path: "/", Service: "resume"},
+		{Host: "blog.example.com", Path: "/", Service: "blog"},
+	})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "no route serves blog.example.com/")
+	assert.ErrorContains(t, err, "backend service resume/docs not declared")
+	assert.ErrorContains(t, err, "issued by letsencrypt-prod, which is not declared")
+
+	issuer, err := DecodeManifests([]byte("kind: ClusterIssuer\nmetadata: {name: letsencrypt-prod}\n---\nkind: Service\nmetadata: {name: docs, namespace: resume}\nspec:\n  ports: [{port: 8080}]\n"))
+	require.NoError(t, err)
+	assert.NoError(t, CheckRoutes(append(objs, issuer...), []RouteExpectation{
+		{Host: "resume.example.com", Path: "/", Service: "resume"},
+	}))
+}
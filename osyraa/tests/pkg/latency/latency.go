@@ -0,0 +1,204 @@
+// Package latency measures time to first byte from several vantage points.
+// A vantage is the local machine, an HTTP/SOCKS proxy in another region, or
+// an SSH jump host used as a SOCKS proxy, so a CDN that only caches close to
+// the CI runner shows up as one slow region.
+package latency
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/httpclient"
+)
+
+// Vantage is a point requests are sent from
+type Vantage struct {
+	Name string
+	// Proxy routes requests through an http, https or socks5 proxy; nil
+	// probes directly
+	Proxy *url.URL
+	// SSH names a jump host ([user@]host[:port]) tunnelled as a SOCKS proxy
+	SSH string
+}
+
+// ParseVantages parses a comma-separated list of name=endpoint entries.
+// Endpoints are proxy URLs (http://, https://, socks5://) or ssh://[user@]host;
+// a bare name or "local" probes directly.
+func ParseVantages(spec string) ([]Vantage, error) {
+	var vantages []Vantage
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, endpoint, _ := strings.Cut(entry, "=")
+		v := Vantage{Name: name}
+		switch {
+		case endpoint == "" || endpoint == "local":
+		case strings.HasPrefix(endpoint, "ssh://"):
+			v.SSH = strings.TrimPrefix(endpoint, "ssh://")
+		default:
+			u, err := url.Parse(endpoint)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("vantage %s: invalid endpoint %q", name, endpoint)
+			}
+			switch u.Scheme {
+			case "http", "https", "socks5", "socks5h":
+			default:
+				return nil, fmt.Errorf("vantage %s: unsupported proxy scheme %q", name, u.Scheme)
+			}
+			v.Proxy = u
+		}
+		vantages = append(vantages, v)
+	}
+	if len(vantages) == 0 {
+		return nil, errors.New("no vantages configured")
+	}
+	return vantages, nil
+}
+
+// Timing breaks down one request. Behind a proxy, Connect and TLS cover
+// the hop to the proxy and the tunnelled handshake.
+type Timing struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	TTFB    time.Duration
+	Total   time.Duration
+}
+
+// Measure fetches url once with a fresh connection and times each phase
+func Measure(ctx context.Context, client *http.Client, target string) (Timing, error) {
+	var t Timing
+	var start, dnsStart, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.DNS = time.Since(dnsStart) },
+		ConnectStart:         func(string, string) { connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { t.Connect = time.Since(connectStart) },
+		TLSHandshakeStart:    func() { tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.TLS = time.Since(tlsStart) },
+		GotFirstResponseByte: func() { t.TTFB = time.Since(start) },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, target, nil)
+	if err != nil {
+		return t, err
+	}
+	req.Close = true
+	start = time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return t, err
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	t.Total = time.Since(start)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s returned %d", target, resp.StatusCode)
+	}
+	return t, err
+}
+
+// RegionResult aggregates the samples taken from one vantage
+type RegionResult struct {
+	Vantage string
+	TTFB    []time.Duration
+	Errors  []error
+}
+
+// Percentile returns the p-th percentile (0-100) TTFB by nearest rank
+func (r RegionResult) Percentile(p float64) time.Duration {
+	if len(r.TTFB) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), r.TTFB...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+// Probe takes samples of target from every vantage
+func Probe(ctx context.Context, vantages []Vantage, target string, samples int) ([]RegionResult, error) {
+	var results []RegionResult
+	for _, v := range vantages {
+		client, closeTunnel, err := v.client(ctx)
+		if err != nil {
+			return results, fmt.Errorf("vantage %s: %w", v.Name, err)
+		}
+		result := RegionResult{Vantage: v.Name}
+		for i := 0; i < samples; i++ {
+			timing, err := Measure(ctx, client, target)
+			if err != nil {
+				result.Errors = append(result.Errors, err)
+				continue
+			}
+			result.TTFB = append(result.TTFB, timing.TTFB)
+		}
+		closeTunnel()
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// client builds an HTTP client for the vantage, opening an SSH tunnel when
+// needed; the returned func closes it
+func (v Vantage) client(ctx context.Context) (*http.Client, func(), error) {
+	// A fresh connection per sample, so TTFB includes the handshakes
+	transport := httpclient.NewTransport(httpclient.Current())
+	transport.DisableKeepAlives = true
+	closeTunnel := func() {}
+	switch {
+	case v.SSH != "":
+		tunnel, err := openSSHTunnel(ctx, v.SSH)
+		if err != nil {
+			return nil, nil, err
+		}
+		transport.Proxy = http.ProxyURL(tunnel.proxy)
+		closeTunnel = tunnel.close
+	case v.Proxy != nil:
+		transport.Proxy = http.ProxyURL(v.Proxy)
+	}
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}, closeTunnel, nil
+}
+
+// CheckBudget fails regions whose p95 TTFB exceeds budget or which saw errors
+func CheckBudget(results []RegionResult, budget time.Duration) error {
+	var errs []error
+	for _, r := range results {
+		if len(r.Errors) > 0 {
+			errs = append(errs, fmt.Errorf("%s: %d of %d requests failed: %w", r.Vantage, len(r.Errors), len(r.Errors)+len(r.TTFB), r.Errors[0]))
+		}
+		if p95 := r.Percentile(95); len(r.TTFB) > 0 && p95 > budget {
+			errs = append(errs, fmt.Errorf("%s: p95 TTFB %v exceeds %v", r.Vantage, p95.Round(time.Millisecond), budget))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Outliers names regions whose median TTFB is more than factor times the
+// fastest region's, the signature of requests missing the CDN edge
+func Outliers(results []RegionResult, factor float64) []string {
+	var fastest time.Duration
+	for _, r := range results {
+		if p50 := r.Percentile(50); p50 > 0 && (fastest == 0 || p50 < fastest) {
+			fastest = p50
+		}
+	}
+	var outliers []string
+	for _, r := range results {
+		if p50 := r.Percentile(50); fastest > 0 && float64(p50) > factor*float64(fastest) {
+			outliers = append(outliers, r.Vantage)
+		}
+	}
+	return outliers
+}
@@ -0,0 +1,65 @@
+package latency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVantages(t *testing.T) {
+	vantages, err := ParseVantages("local, eu=socks5://eu.example.com:1080, ap=ssh://probe@ap.example.com")
+	require.NoError(t, err)
+	require.Len(t, vantages, 3)
+	assert.Equal(t, Vantage{Name: "local"}, vantages[0])
+	assert.Equal(t, "eu.example.com:1080", vantages[1].Proxy.Host)
+	assert.Equal(t, "probe@ap.example.com", vantages[2].SSH)
+
+	_, err = ParseVantages("x=ftp://proxy")
+	assert.ErrorContains(t, err, "unsupported proxy scheme")
+	_, err = ParseVantages("")
+	assert.Error(t, err)
+}
+
+func TestProbeThroughProxy(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer site.Close()
+
+	// A forward proxy adding delay stands in for a distant region
+	var proxied atomic.Int64
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		target, _ := url.Parse(r.URL.Scheme + "://" + r.URL.Host)
+		httputil.NewSingleHostReverseProxy(target).ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	results, err := Probe(context.Background(), []Vantage{{Name: "local"}, {Name: "far", Proxy: proxyURL}}, site.URL, 3)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.EqualValues(t, 3, proxied.Load())
+	assert.Len(t, results[1].TTFB, 3)
+	assert.Greater(t, results[1].Percentile(50), 30*time.Millisecond)
+
+	assert.Equal(t, []string{"far"}, Outliers(results, 3))
+	assert.NoError(t, CheckBudget(results, time.Second))
+	assert.ErrorContains(t, CheckBudget(results, 10*time.Millisecond), "far: p95 TTFB")
+}
+
+func TestPercentile(t *testing.T) {
+	r := RegionResult{TTFB: []time.Duration{5, 1, 4, 2, 3}}
+	assert.Equal(t, time.Duration(3), r.Percentile(50))
+	assert.Equal(t, time.Duration(5), r.Percentile(95))
+	assert.Equal(t, time.Duration(0), RegionResult{}.Percentile(50))
+}
@@ -0,0 +1,55 @@
+package latency
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/ready"
+)
+
+// sshTunnel is an `ssh -D` dynamic forward acting as a local SOCKS proxy
+type sshTunnel struct {
+	proxy *url.URL
+	cmd   *exec.Cmd
+}
+
+// openSSHTunnel starts ssh with a dynamic forward on a free local port and
+// waits for it to accept connections. Authentication relies on the agent or
+// ssh config; prompts are disabled.
+func openSSHTunnel(ctx context.Context, host string) (*sshTunnel, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	args := []string{"-N", "-o", "BatchMode=yes", "-o", "ExitOnForwardFailure=yes", "-D", fmt.Sprintf("127.0.0.1:%d", port)}
+	if h, p, ok := strings.Cut(host, ":"); ok && !strings.Contains(p, ":") {
+		host = h
+		args = append(args, "-p", p)
+	}
+	cmd := exec.CommandContext(ctx, "ssh", append(args, host)...)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	if err := ready.WaitFor(ctx, ready.TCP(addr), ready.DefaultOptions()); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, fmt.Errorf("ssh tunnel to %s did not come up: %w", host, err)
+	}
+	return &sshTunnel{proxy: &url.URL{Scheme: "socks5", Host: addr}, cmd: cmd}, nil
+}
+
+func (t *sshTunnel) close() {
+	if t.cmd.Process != nil {
+		t.cmd.Process.Kill()
+	}
+	t.cmd.Wait()
+}
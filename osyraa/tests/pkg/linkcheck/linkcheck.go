@@ -0,0 +1,171 @@
+// Package linkcheck verifies the outbound links that matter most on a
+// resume: social profiles and credential verification pages. A dead
+// credential link is worse than none, but LinkedIn and others answer
+// unknown clients with bot walls, so those are told apart from real
+// failures.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/contact"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/polite"
+)
+
+// Link kinds
+const (
+	Profile    = "profile"
+	Credential = "credential"
+)
+
+// CredentialHosts serve certification and badge verification pages
+var CredentialHosts = []string{
+	"credly.com", "youracclaim.com", "credential.net", "accredible.com",
+	"certmetrics.com", "cp.certmetrics.com", "verify.skilljar.com",
+	"learn.microsoft.com", "badgr.com", "openbadgepassport.com",
+	"ti-user-certificates.s3.amazonaws.com",
+}
+
+// Outcomes of a check
+const (
+	OK = "ok"
+	// Dead links return any other 4xx or 5xx, such as 404 or 410, or
+	// redirect to the host's home page in place of the requested one
+	Dead = "dead"
+	// Blocked links refused an automated client (LinkedIn's 999, a 403 or
+	// 429, or a redirect to a login wall); they may work in a browser
+	Blocked = "blocked"
+	// Unreachable links failed before any response, e.g. DNS or TLS
+	Unreachable = "unreachable"
+	// Disallowed links were not fetched because the host's robots.txt
+	// asks automated clients to stay away (see package polite)
+	Disallowed = "disallowed"
+)
+
+// Link is an outbound link to check
+type Link struct {
+	URL  string
+	Text string
+	Kind string
+}
+
+// Result is the outcome of checking a link
+type Result struct {
+	Link    Link
+	Outcome string
+	Status  int
+	// Final is the URL after redirects
+	Final string
+	Err   error
+}
+
+// String formats the result for test output
+func (r Result) String() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("%s %s: %s: %v", r.Link.Kind, r.Link.URL, r.Outcome, r.Err)
+	case r.Final != "" && r.Final != r.Link.URL:
+		return fmt.Sprintf("%s %s: %s (%d after redirect to %s)", r.Link.Kind, r.Link.URL, r.Outcome, r.Status, r.Final)
+	}
+	return fmt.Sprintf("%s %s: %s (%d)", r.Link.Kind, r.Link.URL, r.Outcome, r.Status)
+}
+
+// Find returns the profile and credential links on a page, once each
+func Find(doc *match.Document) []Link {
+	var links []Link
+	for _, n := range doc.Select("a[href]").Nodes() {
+		href, _ := match.Attr(n, "href")
+		u, err := url.Parse(href)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		kind := ""
+		if _, _, ok := contact.NormalizeProfile(href); ok {
+			kind = Profile
+		} else if isCredentialHost(u.Hostname()) {
+			kind = Credential
+		}
+		if kind == "" || slices.ContainsFunc(links, func(l Link) bool { return l.URL == href }) {
+			continue
+		}
+		links = append(links, Link{URL: href, Text: match.Text(n), Kind: kind})
+	}
+	return links
+}
+
+func isCredentialHost(host string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	for _, h := range CredentialHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// userAgent is a browser's, since several profile hosts turn away
+// anything else before looking at the path
+const userAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36"
+
+// loginWalls are path prefixes a host redirects to instead of the page
+// when it wants a signed-in visitor
+var loginWalls = []string{"/authwall", "/login", "/signin", "/uas/login", "/checkpoint", "/session"}
+
+// Check fetches a link with a browser's User-Agent, following redirects,
+// and classifies the response. A client whose transport is a
+// polite.Transport may refuse the request, which is reported as
+// Disallowed.
+func Check(ctx context.Context, client *http.Client, link Link) Result {
+	r := Result{Link: link}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+	if err != nil {
+		r.Outcome, r.Err = Unreachable, err
+		return r
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	resp, err := client.Do(req)
+	if errors.Is(err, polite.ErrDisallowed) {
+		r.Outcome, r.Err = Disallowed, err
+		return r
+	}
+	if err != nil {
+		r.Outcome, r.Err = Unreachable, err
+		return r
+	}
+	defer resp.Body.Close()
+	// Drain a little so the connection can be reused
+	io.CopyN(io.Discard, resp.Body, 64<<10)
+
+	r.Status, r.Final = resp.StatusCode, resp.Request.URL.String()
+	r.Outcome = classify(req.URL, resp.Request.URL, resp.StatusCode)
+	return r
+}
+
+func classify(requested, final *url.URL, status int) string {
+	switch {
+	case status == 999, status == http.StatusForbidden, status == http.StatusTooManyRequests,
+		status == http.StatusUnauthorized:
+		return Blocked
+	case status >= 400:
+		return Dead
+	}
+	for _, wall := range loginWalls {
+		if strings.HasPrefix(final.Path, wall) {
+			return Blocked
+		}
+	}
+	// A soft 404: the host sent a missing page to its home page
+	if strings.Trim(final.Path, "/") == "" && strings.Trim(requested.Path, "/") != "" {
+		return Dead
+	}
+	return OK
+}
@@ -0,0 +1,95 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/polite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	doc, err := match.ParseBytes([]byte(`<header>
+<a href="https://github.com/jane">GitHub</a><a href="https://www.linkedin.com/in/jane">LinkedIn</a>
+<a href="mailto:jane@example.com">Mail</a><a href="/contact.vcf">Card</a></header>
+<main><a href="https://www.credly.com/badges/abc/public_url">Verify CKA</a>
+<a href="https://example.com/blog">Blog</a><a href="https://github.com/jane">GitHub again</a></main>`))
+	require.NoError(t, err)
+	assert.Equal(t, []Link{
+		{URL: "https://github.com/jane", Text: "GitHub", Kind: Profile},
+		{URL: "https://www.linkedin.com/in/jane", Text: "LinkedIn", Kind: Profile},
+		{URL: "https://www.credly.com/badges/abc/public_url", Text: "Verify CKA", Kind: Credential},
+	}, Find(doc))
+}
+
+func TestCheck(t *testing.T) {
+	var agents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		switch r.URL.Path {
+		case "/in/jane", "/":
+			w.Write([]byte("profile"))
+		case "/moved":
+			http.Redirect(w, r, "/in/jane", http.StatusMovedPermanently)
+		case "/badges/gone":
+			http.Redirect(w, r, "/", http.StatusFound)
+		case "/in/private":
+			http.Redirect(w, r, "/authwall?trk=x", http.StatusFound)
+		case "/authwall":
+			w.Write([]byte("sign in"))
+		case "/in/bot":
+			w.WriteHeader(999)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for path, want := range map[string]string{
+		"/in/jane":     OK,
+		"/moved":       OK,
+		"/badges/gone": Dead,
+		"/missing":     Dead,
+		"/in/private":  Blocked,
+		"/in/bot":      Blocked,
+	} {
+		r := Check(context.Background(), srv.Client(), Link{URL: srv.URL + path, Kind: Profile})
+		assert.Equal(t, want, r.Outcome, path)
+		assert.NoError(t, r.Err, path)
+	}
+	for _, ua := range agents {
+		assert.True(t, strings.HasPrefix(ua, "Mozilla/5.0"), "requests should look like a browser's")
+	}
+
+	r := Check(context.Background(), srv.Client(), Link{URL: srv.URL + "/moved", Kind: Profile})
+	assert.Equal(t, "profile "+srv.URL+"/moved: ok (200 after redirect to "+srv.URL+"/in/jane)", r.String())
+
+	srv.Close()
+	r = Check(context.Background(), http.DefaultClient, Link{URL: srv.URL + "/in/jane", Kind: Credential})
+	assert.Equal(t, Unreachable, r.Outcome)
+	assert.Error(t, r.Err)
+}
+
+func TestCheckDisallowed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /in/\n"))
+			return
+		}
+		w.Write([]byte("profile"))
+	}))
+	defer srv.Close()
+
+	tr := polite.New(srv.Client().Transport)
+	tr.Interval = 0
+	client := &http.Client{Transport: tr}
+	r := Check(context.Background(), client, Link{URL: srv.URL + "/in/jane", Kind: Profile})
+	assert.Equal(t, Disallowed, r.Outcome)
+	r = Check(context.Background(), client, Link{URL: srv.URL + "/badges/abc", Kind: Credential})
+	assert.Equal(t, OK, r.Outcome)
+}
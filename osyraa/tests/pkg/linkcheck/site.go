@@ -0,0 +1,263 @@
+package linkcheck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"golang.org/x/net/html"
+)
+
+// SiteOptions tune a check of every link in a build
+type SiteOptions struct {
+	// BaseURL is the site's production URL; links to its host are checked
+	// against the build rather than fetched
+	BaseURL string
+	// Client fetches external links; nil leaves them unchecked
+	Client *http.Client
+	// Concurrency caps the external links fetched at once; zero means 8
+	Concurrency int
+	// Retries is how many more times a link that was unreachable or
+	// answered 429 or 5xx is fetched before it counts as broken
+	Retries int
+	// RetryDelay is the pause before the first retry, doubling after
+	// each; zero means a second
+	RetryDelay time.Duration
+	// Allow lists URLs that are never checked, such as hosts known to
+	// refuse automated clients
+	Allow []*regexp.Regexp
+}
+
+// Reference is a link found in the build
+type Reference struct {
+	// Page is the path of the HTML file the link is on, relative to the
+	// build
+	Page string
+	Tag  string
+	Attr string
+	// Raw is the link as written in the page
+	Raw string
+}
+
+func (r Reference) String() string {
+	return fmt.Sprintf("%s: <%s %s=%q>", r.Page, r.Tag, r.Attr, r.Raw)
+}
+
+// Broken is a link that doesn't resolve, or a warning about one that
+// couldn't be confirmed
+type Broken struct {
+	Reference
+	// URL is the resolved target
+	URL string
+	// External is set for links off the site
+	External bool
+	// Outcome is Dead or Unreachable for broken links; Blocked or
+	// Disallowed for warnings
+	Outcome string
+	Status  int
+	Reason  string
+}
+
+func (b Broken) String() string {
+	return fmt.Sprintf("%s -> %s: %s", b.Reference, b.URL, b.Reason)
+}
+
+// SiteReport is the outcome of checking every link in a build
+type SiteReport struct {
+	Pages    int
+	Internal int
+	// External counts the distinct external URLs fetched
+	External int
+	Allowed  int
+	Broken   []Broken
+	Warnings []Broken
+}
+
+// skipSchemes are links that name no fetchable resource
+var skipSchemes = map[string]bool{"mailto": true, "tel": true, "javascript": true, "data": true, "sms": true}
+
+// Site checks every anchor, image, stylesheet, script and media link on
+// every HTML page under dir, including pages nothing links to. Links
+// within the site must name a file or a directory with an index.html;
+// external links, when o.Client is set, must answer below 400.
+func Site(ctx context.Context, dir string, o SiteOptions) (*SiteReport, error) {
+	base, err := url.Parse(o.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("base URL: %w", err)
+	}
+	if base.Host == "" {
+		base = &url.URL{Scheme: "https", Host: "site.invalid"}
+	}
+
+	report := &SiteReport{}
+	external := map[string][]Reference{}
+	err = filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".html") {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, file)
+		rel = filepath.ToSlash(rel)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		doc, err := html.Parse(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		report.Pages++
+
+		pageURL := base.ResolveReference(&url.URL{Path: "/" + crawl.VisitorPath(rel)})
+		for _, link := range crawl.Links(doc, pageURL) {
+			ref := Reference{Page: rel, Tag: link.Tag, Attr: link.Attr, Raw: link.Raw}
+			switch {
+			case link.URL == nil:
+				report.Broken = append(report.Broken, Broken{Reference: ref, URL: link.Raw, Outcome: Dead, Reason: "malformed URL"})
+			case skipSchemes[link.URL.Scheme]:
+			case allowed(o.Allow, link.URL.String()):
+				report.Allowed++
+			case link.URL.Host == base.Host:
+				report.Internal++
+				if !resolves(dir, link.URL.Path) {
+					report.Broken = append(report.Broken, Broken{Reference: ref, URL: link.URL.Path, Outcome: Dead,
+						Status: http.StatusNotFound, Reason: "no such file in the build"})
+				}
+			case link.URL.Scheme == "http" || link.URL.Scheme == "https":
+				target := *link.URL
+				target.Fragment = ""
+				external[target.String()] = append(external[target.String()], ref)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Client != nil {
+		report.External = len(external)
+		for _, r := range checkExternal(ctx, o, external) {
+			for _, ref := range external[r.Link.URL] {
+				b := Broken{Reference: ref, URL: r.Link.URL, External: true, Outcome: r.Outcome, Status: r.Status, Reason: reason(r)}
+				switch r.Outcome {
+				case Dead, Unreachable:
+					report.Broken = append(report.Broken, b)
+				case Blocked, Disallowed:
+					report.Warnings = append(report.Warnings, b)
+				}
+			}
+		}
+	}
+	sortBroken(report.Broken)
+	sortBroken(report.Warnings)
+	return report, nil
+}
+
+func allowed(allow []*regexp.Regexp, u string) bool {
+	for _, re := range allow {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolves reports whether a site path names a file in dir, the way
+// nginx serves it: a directory by its index.html
+func resolves(dir, urlPath string) bool {
+	clean := path.Clean("/" + urlPath)
+	file := filepath.Join(dir, filepath.FromSlash(clean))
+	info, err := os.Stat(file)
+	if err == nil && info.IsDir() {
+		info, err = os.Stat(filepath.Join(file, "index.html"))
+	}
+	return err == nil && !info.IsDir()
+}
+
+// checkExternal fetches each URL once, at most o.Concurrency at a time
+func checkExternal(ctx context.Context, o SiteOptions, urls map[string][]Reference) []Result {
+	workers := o.Concurrency
+	if workers <= 0 {
+		workers = 8
+	}
+	jobs := make(chan string)
+	results := make(chan Result)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range jobs {
+				results <- checkWithRetries(ctx, o, Link{URL: u})
+			}
+		}()
+	}
+	go func() {
+		for u := range urls {
+			jobs <- u
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	var out []Result
+	for r := range results {
+		out = append(out, r)
+	}
+	return out
+}
+
+// checkWithRetries checks a link, retrying answers that may be
+// transient with a doubling delay
+func checkWithRetries(ctx context.Context, o SiteOptions, link Link) Result {
+	delay := o.RetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	r := Check(ctx, o.Client, link)
+	for attempt := 0; attempt < o.Retries && transient(r); attempt++ {
+		select {
+		case <-ctx.Done():
+			return r
+		case <-time.After(delay):
+		}
+		delay *= 2
+		r = Check(ctx, o.Client, link)
+	}
+	return r
+}
+
+func transient(r Result) bool {
+	return r.Outcome == Unreachable || r.Status == http.StatusTooManyRequests || r.Status >= 500
+}
+
+func reason(r Result) string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("%s: %v", r.Outcome, r.Err)
+	case r.Final != "" && r.Final != r.Link.URL:
+		return fmt.Sprintf("%s (%d after redirect to %s)", r.Outcome, r.Status, r.Final)
+	}
+	return fmt.Sprintf("%s (%d)", r.Outcome, r.Status)
+}
+
+func sortBroken(b []Broken) {
+	sort.SliceStable(b, func(i, j int) bool {
+		if b[i].Page != b[j].Page {
+			return b[i].Page < b[j].Page
+		}
+		return b[i].URL < b[j].URL
+	})
+}
@@ -0,0 +1,122 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func build(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestSiteInternalLinks(t *testing.T) {
+	dir := build(t, map[string]string{
+		"index.html": `<link rel="stylesheet" href="/css/site.css"><script src="js/app.js"></script>
+<a href="/about/">About</a><a href="about/#team">Team</a><a href="https://resume.example.com/posts/">Posts</a>
+<img src="/img/me.png" srcset="/img/me.png 1x, /img/me@2x.png 2x"><a href="mailto:jane@example.com">Mail</a>`,
+		"about/index.html": `<a href="../">Home</a><a href="missing.html">Gone</a>`,
+		// Nothing links here, but its links are checked all the same
+		"orphan.html": `<a href="/nowhere/">Nowhere</a>`,
+		// Not a directory index, so the link resolves against its own name
+		"blog/myindex.html": `<a href="?page=2">Next</a>`,
+		"css/site.css":      `body{}`,
+		"js/app.js":         `1`,
+		"img/me.png":        `png`,
+	})
+	report, err := Site(context.Background(), dir, SiteOptions{BaseURL: "https://resume.example.com/"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Pages)
+
+	var broken []string
+	for _, b := range report.Broken {
+		broken = append(broken, b.Page+" "+b.URL)
+	}
+	assert.Equal(t, []string{
+		"about/index.html /about/missing.html",
+		"index.html /img/me@2x.png",
+		"index.html /posts/",
+		"orphan.html /nowhere/",
+	}, broken)
+	assert.Equal(t, 0, report.External, "external links are left alone without a client")
+}
+
+func TestSiteExternalLinks(t *testing.T) {
+	var flaky atomic.Int32
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		switch r.URL.Path {
+		case "/ok":
+		case "/flaky":
+			if flaky.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := build(t, map[string]string{
+		"index.html": `<a href="` + srv.URL + `/ok">OK</a><a href="` + srv.URL + `/ok#again">OK again</a>
+<a href="` + srv.URL + `/flaky">Flaky</a><a href="` + srv.URL + `/gone">Gone</a>
+<a href="` + srv.URL + `/forbidden">Forbidden</a><a href="` + srv.URL + `/skipped">Skipped</a>`,
+	})
+	report, err := Site(context.Background(), dir, SiteOptions{
+		Client:      srv.Client(),
+		Concurrency: 2,
+		Retries:     1,
+		RetryDelay:  time.Millisecond,
+		Allow:       []*regexp.Regexp{regexp.MustCompile(`/skipped$`)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.External, "the same URL is fetched once")
+	assert.Equal(t, 1, report.Allowed)
+	assert.EqualValues(t, 5, fetches.Load(), "the 503 is retried")
+	require.Len(t, report.Broken, 1)
+	assert.Equal(t, srv.URL+"/gone", report.Broken[0].URL)
+	assert.Equal(t, Dead, report.Broken[0].Outcome)
+	require.Len(t, report.Warnings, 1)
+	assert.Equal(t, Blocked, report.Warnings[0].Outcome)
+}
+
+func TestSiteFixture(t *testing.T) {
+	report, err := Site(context.Background(), fixture.New(t, fixture.Valid).Public, SiteOptions{BaseURL: fixture.BaseURL})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Pages)
+	assert.Empty(t, report.Broken)
+
+	report, err = Site(context.Background(), fixture.New(t, fixture.BrokenLinks).Public, SiteOptions{BaseURL: fixture.BaseURL})
+	require.NoError(t, err)
+	var broken []string
+	for _, b := range report.Broken {
+		broken = append(broken, b.Reference.String())
+	}
+	// Both pages carry the broken links
+	for _, page := range []string{"index.html", "about/index.html"} {
+		assert.Contains(t, broken, page+`: <link href="/css/missing.css">`)
+		assert.Contains(t, broken, page+`: <a href="/blog/">`)
+		assert.Contains(t, broken, page+`: <img src="/img/missing.png">`)
+	}
+	assert.Len(t, broken, 6)
+}
@@ -0,0 +1,281 @@
+// Package match provides DOM-based content assertions for generated pages.
+// Queries use CSS selectors or XPath against the parsed document, and text
+// comparisons collapse whitespace so minification doesn't break them.
+//
+//	doc.Select("h1").TextEquals("Princeton A. Strong")
+//	doc.Exists("meta[name=description]")
+//	doc.XPath("//h2[text()='Certifications']").Count(1)
+package match
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// Document wraps a parsed HTML tree for querying
+type Document struct {
+	root *html.Node
+}
+
+// Parse reads and parses an HTML document
+func Parse(r io.Reader) (*Document, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+	return &Document{root: root}, nil
+}
+
+// ParseBytes parses an in-memory HTML document
+func ParseBytes(b []byte) (*Document, error) {
+	return Parse(bytes.NewReader(b))
+}
+
+// FromNode wraps an already parsed tree, such as crawl.Page.Doc
+func FromNode(n *html.Node) *Document {
+	return &Document{root: n}
+}
+
+// Root returns the underlying document node
+func (d *Document) Root() *html.Node {
+	return d.root
+}
+
+// Select returns the elements matching a CSS selector, or any selector of
+// a comma-separated group, in document order
+func (d *Document) Select(selector string) *Selection {
+	sel, err := cascadia.ParseGroup(selector)
+	if err != nil {
+		return &Selection{query: selector, err: fmt.Errorf("invalid selector %q: %w", selector, err)}
+	}
+	return &Selection{query: selector, nodes: cascadia.QueryAll(d.root, sel)}
+}
+
+// XPath returns the nodes matching an XPath expression
+func (d *Document) XPath(expr string) *Selection {
+	nodes, err := htmlquery.QueryAll(d.root, expr)
+	if err != nil {
+		return &Selection{query: expr, err: fmt.Errorf("invalid xpath %q: %w", expr, err)}
+	}
+	return &Selection{query: expr, nodes: nodes}
+}
+
+// Exists is shorthand for Select(selector).Exists()
+func (d *Document) Exists(selector string) error {
+	return d.Select(selector).Exists()
+}
+
+// HasDoctype reports whether the document starts with a <!DOCTYPE html>
+func (d *Document) HasDoctype() bool {
+	for n := d.root.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == html.DoctypeNode {
+			return strings.EqualFold(n.Data, "html")
+		}
+	}
+	return false
+}
+
+// Selection is the result of a query. Assertion methods return nil on
+// success and a descriptive error otherwise, so they plug into require.NoError.
+type Selection struct {
+	query string
+	nodes []*html.Node
+	err   error
+}
+
+// Nodes returns the matched nodes
+func (s *Selection) Nodes() []*html.Node {
+	return s.nodes
+}
+
+// Len returns the number of matched nodes
+func (s *Selection) Len() int {
+	return len(s.nodes)
+}
+
+// Err returns the query error, if any
+func (s *Selection) Err() error {
+	return s.err
+}
+
+// Text returns the normalized text of the first match
+func (s *Selection) Text() string {
+	if len(s.nodes) == 0 {
+		return ""
+	}
+	return Text(s.nodes[0])
+}
+
+// Texts returns the normalized text of every match
+func (s *Selection) Texts() []string {
+	texts := make([]string, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		texts = append(texts, Text(n))
+	}
+	return texts
+}
+
+// Attr returns an attribute of the first match
+func (s *Selection) Attr(name string) (string, bool) {
+	if len(s.nodes) == 0 {
+		return "", false
+	}
+	return Attr(s.nodes[0], name)
+}
+
+// Exists fails when nothing matched
+func (s *Selection) Exists() error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.nodes) == 0 {
+		return fmt.Errorf("%s: no matching element", s.query)
+	}
+	return nil
+}
+
+// Absent fails when anything matched
+func (s *Selection) Absent() error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.nodes) > 0 {
+		return fmt.Errorf("%s: expected no match, found %d", s.query, len(s.nodes))
+	}
+	return nil
+}
+
+// Count fails unless exactly n elements matched
+func (s *Selection) Count(n int) error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.nodes) != n {
+		return fmt.Errorf("%s: expected %d matches, found %d", s.query, n, len(s.nodes))
+	}
+	return nil
+}
+
+// TextEquals passes when any match has exactly the given normalized text
+func (s *Selection) TextEquals(want string) error {
+	want = Normalize(want)
+	return s.anyText(fmt.Sprintf("text equal to %q", want), func(got string) bool {
+		return got == want
+	})
+}
+
+// TextContains passes when any match contains the given normalized text
+func (s *Selection) TextContains(want string) error {
+	want = Normalize(want)
+	return s.anyText(fmt.Sprintf("text containing %q", want), func(got string) bool {
+		return strings.Contains(got, want)
+	})
+}
+
+// TextMatches passes when any match satisfies the regular expression
+func (s *Selection) TextMatches(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return s.anyText(fmt.Sprintf("text matching /%s/", pattern), re.MatchString)
+}
+
+// AttrEquals passes when any match carries the attribute with the given value
+func (s *Selection) AttrEquals(name, want string) error {
+	if err := s.Exists(); err != nil {
+		return err
+	}
+	for _, n := range s.nodes {
+		if got, ok := Attr(n, name); ok && got == want {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: no match with %s=%q", s.query, name, want)
+}
+
+func (s *Selection) anyText(desc string, ok func(string) bool) error {
+	if err := s.Exists(); err != nil {
+		return err
+	}
+	texts := s.Texts()
+	for _, t := range texts {
+		if ok(t) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: no match with %s (found %s)", s.query, desc, summarize(texts))
+}
+
+// Text returns the whitespace-normalized text content of n
+func Text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.ElementNode:
+			if n.Data == "script" || n.Data == "style" {
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode && isBlock(n.Data) {
+			b.WriteByte(' ')
+		}
+	}
+	walk(n)
+	return Normalize(b.String())
+}
+
+// Attr looks up an attribute on n
+func Attr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// Normalize collapses runs of whitespace and trims the result
+func Normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// isBlock lists elements whose boundaries separate words even when
+// minification has removed the whitespace between them
+func isBlock(tag string) bool {
+	switch tag {
+	case "p", "div", "li", "ul", "ol", "h1", "h2", "h3", "h4", "h5", "h6",
+		"br", "header", "footer", "main", "section", "article", "nav", "td", "th", "tr":
+		return true
+	}
+	return false
+}
+
+func summarize(texts []string) string {
+	const max = 3
+	quoted := make([]string, 0, max)
+	for i, t := range texts {
+		if i == max {
+			quoted = append(quoted, fmt.Sprintf("... %d more", len(texts)-max))
+			break
+		}
+		if len(t) > 60 {
+			t = t[:57] + "..."
+		}
+		quoted = append(quoted, fmt.Sprintf("%q", t))
+	}
+	return strings.Join(quoted, ", ")
+}
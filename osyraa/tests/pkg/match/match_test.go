@@ -0,0 +1,54 @@
+package match
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const minified = `<!doctype html><html lang=en><meta charset=utf-8><meta name=description content="Resume"><title>Resume</title>` +
+	`<h1>Princeton   A.
+Strong</h1><h2>Certifications</h2><ul><li><strong>Certified Kubernetes Administrator</strong> (CKA)<li>AWS</ul>`
+
+func TestSelectors(t *testing.T) {
+	doc, err := ParseBytes([]byte(minified))
+	require.NoError(t, err)
+
+	assert.True(t, doc.HasDoctype())
+	assert.NoError(t, doc.Select("h1").TextEquals("Princeton A. Strong"))
+	assert.NoError(t, doc.Exists("meta[name=description]"))
+	assert.NoError(t, doc.Select("meta[name=description]").AttrEquals("content", "Resume"))
+	assert.NoError(t, doc.Select("li").TextContains("Certified Kubernetes Administrator"))
+	assert.NoError(t, doc.Select("li").Count(2))
+	assert.NoError(t, doc.Select("script").Absent())
+	assert.NoError(t, doc.Select("h1").TextMatches(`^Princeton\b`))
+	assert.NoError(t, doc.Select("h1, li").Count(3), "a selector group matches any of its selectors")
+}
+
+func TestXPath(t *testing.T) {
+	doc, err := ParseBytes([]byte(minified))
+	require.NoError(t, err)
+
+	assert.NoError(t, doc.XPath("//h2[text()='Certifications']").Count(1))
+	assert.NoError(t, doc.XPath("//h2/following-sibling::ul/li").TextEquals("AWS"))
+}
+
+func TestFailuresDescribeTheMismatch(t *testing.T) {
+	doc, err := ParseBytes([]byte(minified))
+	require.NoError(t, err)
+
+	err = doc.Select("h1").TextEquals("Someone Else")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"Princeton A. Strong"`)
+
+	assert.ErrorContains(t, doc.Exists("nav"), "no matching element")
+	assert.ErrorContains(t, doc.Select("h1[").Exists(), "invalid selector")
+	assert.ErrorContains(t, doc.XPath("//[").Exists(), "invalid xpath")
+}
+
+func TestTextSeparatesBlocks(t *testing.T) {
+	doc, err := ParseBytes([]byte(`<ul><li>one</li><li>two</li></ul>`))
+	require.NoError(t, err)
+	assert.Equal(t, "one two", doc.Select("ul").Text())
+}
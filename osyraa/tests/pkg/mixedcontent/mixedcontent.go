@@ -0,0 +1,175 @@
+// Package mixedcontent finds http:// resources referenced from pages meant to
+// be served over HTTPS. The static scan reads markup, inline styles and
+// stylesheets; the browser scan records the requests a rendered page makes,
+// catching URLs built by scripts or hidden in computed styles.
+package mixedcontent
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"golang.org/x/net/html"
+)
+
+// Finding is an insecure reference on a page
+type Finding struct {
+	Page string
+	// Source says where the reference was found, e.g. "<img src>",
+	// "inline style" or "request"
+	Source string
+	URL    string
+}
+
+// String formats the finding for test output
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s references %s", f.Page, f.Source, f.URL)
+}
+
+// subresources maps selectors to the attribute that loads a subresource.
+// Plain links (<a href>) are navigation, not mixed content.
+var subresources = []struct{ selector, attr string }{
+	{"img[src]", "src"}, {"img[srcset]", "srcset"},
+	{"source[src]", "src"}, {"source[srcset]", "srcset"},
+	{"script[src]", "src"}, {"iframe[src]", "src"},
+	{"video[src]", "src"}, {"video[poster]", "poster"},
+	{"audio[src]", "src"}, {"track[src]", "src"},
+	{"object[data]", "data"}, {"embed[src]", "src"},
+	{"link[href]", "href"}, {"form[action]", "action"},
+}
+
+// loadingRels are the link relations that make the browser fetch href
+var loadingRels = []string{"stylesheet", "icon", "apple-touch-icon", "preload", "modulepreload", "prefetch", "manifest", "mask-icon"}
+
+var (
+	cssURLRe = regexp.MustCompile(`(?i)url\(\s*['"]?\s*(http://[^'")\s]+)`)
+	importRe = regexp.MustCompile(`(?i)@import\s+['"]\s*(http://[^'"\s]+)`)
+)
+
+// CSS returns the http:// URLs in url() and @import rules of a stylesheet
+func CSS(page, source, css string) []Finding {
+	var found []Finding
+	for _, re := range []*regexp.Regexp{cssURLRe, importRe} {
+		for _, m := range re.FindAllStringSubmatch(css, -1) {
+			found = append(found, Finding{Page: page, Source: source, URL: m[1]})
+		}
+	}
+	return found
+}
+
+// HTML returns the insecure subresources referenced by doc, including
+// inline style attributes and <style> elements
+func HTML(doc *html.Node, page string) []Finding {
+	d := match.FromNode(doc)
+	var found []Finding
+	for _, s := range subresources {
+		for _, n := range d.Select(s.selector).Nodes() {
+			if n.Data == "link" && !loads(n) {
+				continue
+			}
+			val, _ := match.Attr(n, s.attr)
+			for _, ref := range refs(s.attr, val) {
+				if isInsecure(ref) {
+					found = append(found, Finding{Page: page, Source: fmt.Sprintf("<%s %s>", n.Data, s.attr), URL: ref})
+				}
+			}
+		}
+	}
+	for _, n := range d.Select("[style]").Nodes() {
+		style, _ := match.Attr(n, "style")
+		found = append(found, CSS(page, "inline style", style)...)
+	}
+	for _, n := range d.Select("style").Nodes() {
+		if n.FirstChild != nil {
+			found = append(found, CSS(page, "<style>", n.FirstChild.Data)...)
+		}
+	}
+	return found
+}
+
+func loads(n *html.Node) bool {
+	rel, _ := match.Attr(n, "rel")
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if slices.Contains(loadingRels, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// refs splits srcset candidates; other attributes hold a single URL
+func refs(attr, val string) []string {
+	if attr != "srcset" {
+		return []string{strings.TrimSpace(val)}
+	}
+	var out []string
+	for _, candidate := range strings.Split(val, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			out = append(out, fields[0])
+		}
+	}
+	return out
+}
+
+func isInsecure(ref string) bool {
+	return strings.HasPrefix(strings.ToLower(ref), "http://")
+}
+
+// Static scans the crawled HTML pages and stylesheets
+func Static(pages []*crawl.Page) []Finding {
+	var found []Finding
+	for _, p := range pages {
+		switch {
+		case p.Doc != nil:
+			found = append(found, HTML(p.Doc, p.URL.Path)...)
+		case strings.HasPrefix(p.Header.Get("Content-Type"), "text/css"):
+			found = append(found, CSS(p.URL.Path, "stylesheet", string(p.Body))...)
+		}
+	}
+	return found
+}
+
+// Browse loads pageURL in the browser tab ctx and returns every http://
+// request the page made to another host. The page itself is usually served
+// over plain HTTP in tests, so its own origin is exempt.
+func Browse(ctx context.Context, pageURL string) ([]Finding, error) {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	var found []Finding
+	chromedp.ListenTarget(ctx, func(ev any) {
+		req, ok := ev.(*network.EventRequestWillBeSent)
+		if !ok {
+			return
+		}
+		u, err := url.Parse(req.Request.URL)
+		if err != nil || u.Scheme != "http" || u.Host == page.Host {
+			return
+		}
+		mu.Lock()
+		found = append(found, Finding{Page: page.Path, Source: "request (" + string(req.Type) + ")", URL: req.Request.URL})
+		mu.Unlock()
+	})
+	err = chromedp.Run(ctx,
+		network.Enable(),
+		chromedp.Navigate(pageURL),
+		chromedp.WaitReady("body"),
+		// Scroll to the end so lazily loaded images are requested too
+		chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil),
+		chromedp.Sleep(500*time.Millisecond),
+	)
+	mu.Lock()
+	defer mu.Unlock()
+	return found, err
+}
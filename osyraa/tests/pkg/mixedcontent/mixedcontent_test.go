@@ -0,0 +1,73 @@
+package mixedcontent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/browser"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+const page = `<html><head>
+<link rel="stylesheet" href="http://cdn.example.com/site.css">
+<link rel="canonical" href="http://resume.example.com/">
+<style>body { background: url("http://img.example.com/bg.png") }</style>
+</head><body>
+<a href="http://example.com/">plain links are fine</a>
+<img src="https://secure.example.com/ok.png" srcset="/a.png 1x, http://img.example.com/b.png 2x">
+<div style="background-image: url(http://img.example.com/hero.jpg)"></div>
+</body></html>`
+
+func TestStatic(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+	pages := []*crawl.Page{
+		{URL: &url.URL{Path: "/"}, Doc: doc},
+		{URL: &url.URL{Path: "/css/site.css"}, Header: http.Header{"Content-Type": {"text/css"}},
+			Body: []byte(`@import "http://fonts.example.com/font.css"; .x { background: url('/local.png') }`)},
+	}
+
+	var got []string
+	for _, f := range Static(pages) {
+		got = append(got, f.Source+" "+f.URL)
+	}
+	assert.ElementsMatch(t, []string{
+		"<link href> http://cdn.example.com/site.css",
+		"<img srcset> http://img.example.com/b.png",
+		"inline style http://img.example.com/hero.jpg",
+		"<style> http://img.example.com/bg.png",
+		"stylesheet http://fonts.example.com/font.css",
+	}, got)
+}
+
+func TestBrowse(t *testing.T) {
+	b := browser.NewForTest(t)
+
+	// The image URL is assembled by script, invisible to the static scan
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><script>
+var img = document.createElement("img");
+img.src = "http://" + "insecure.invalid/pixel.gif";
+document.body.appendChild(img);
+</script></body></html>`))
+	}))
+	defer site.Close()
+
+	ctx, cancel := b.NewTab()
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelTimeout()
+	found, err := Browse(ctx, site.URL+"/")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "http://insecure.invalid/pixel.gif", found[0].URL)
+}
@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Alert statuses
+const (
+	Firing   = "firing"
+	Resolved = "resolved"
+)
+
+// Alert reports a check changing state
+type Alert struct {
+	Check  string    `json:"check"`
+	Target string    `json:"target"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Alerter delivers alerts
+type Alerter interface {
+	Alert(ctx context.Context, a Alert) error
+}
+
+// Webhook posts each alert as JSON to URL
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhook creates a webhook alerter with a bounded client
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Alert sends the alert, treating any non-2xx response as a failure
+func (w *Webhook) Alert(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s returned %d", w.URL, resp.StatusCode)
+	}
+	return nil
+}
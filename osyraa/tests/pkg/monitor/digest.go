@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mailer sends an email; notify.Mailer is one
+type Mailer interface {
+	Send(ctx context.Context, subject, body string) error
+}
+
+// Digest is an alerter that collects alerts and mails them in batches,
+// so a flapping check sends one email an interval rather than one per
+// state change
+type Digest struct {
+	Mailer Mailer
+	Target string
+
+	mu     sync.Mutex
+	alerts []Alert
+	since  time.Time
+	every  chan time.Duration
+}
+
+// SetInterval changes the interval of a running Run
+func (d *Digest) SetInterval(interval time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	every := d.intervals()
+	// Replace a change Run has not picked up yet
+	select {
+	case <-every:
+	default:
+	}
+	every <- interval
+}
+
+// intervals must be called with mu held
+func (d *Digest) intervals() chan time.Duration {
+	if d.every == nil {
+		d.every = make(chan time.Duration, 1)
+	}
+	return d.every
+}
+
+// Alert queues a for the next digest
+func (d *Digest) Alert(_ context.Context, a Alert) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.alerts) == 0 && d.since.IsZero() {
+		d.since = a.Time
+	}
+	d.alerts = append(d.alerts, a)
+	return nil
+}
+
+// Flush mails the queued alerts, if any, covering the time up to now. The
+// alerts stay queued if sending fails, for the next flush to retry.
+func (d *Digest) Flush(ctx context.Context, now time.Time) error {
+	d.mu.Lock()
+	alerts, since := d.alerts, d.since
+	d.mu.Unlock()
+	if len(alerts) == 0 {
+		return nil
+	}
+	subject, body := DigestMessage(d.Target, alerts, since, now)
+	if err := d.Mailer.Send(ctx, subject, body); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.alerts = d.alerts[len(alerts):]
+	d.since = now
+	d.mu.Unlock()
+	return nil
+}
+
+// Run flushes every interval until ctx is cancelled, then once more so
+// alerts raised just before shutdown are not lost
+func (d *Digest) Run(ctx context.Context, interval time.Duration, logf func(string, ...any)) {
+	d.mu.Lock()
+	every := d.intervals()
+	d.mu.Unlock()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case interval = <-every:
+			ticker.Reset(interval)
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := d.Flush(final, time.Now()); err != nil {
+				logf("digest delivery failed: %v", err)
+			}
+			return
+		case now := <-ticker.C:
+			if err := d.Flush(ctx, now); err != nil {
+				logf("digest delivery failed: %v", err)
+			}
+		}
+	}
+}
+
+// DigestMessage summarises alerts from since to now: which checks are
+// still failing, which recovered, and every state change in order
+func DigestMessage(target string, alerts []Alert, since, now time.Time) (subject, body string) {
+	last := map[string]Alert{}
+	for _, a := range alerts {
+		last[a.Check] = a
+	}
+	var failing, recovered []string
+	for check, a := range last {
+		if a.Status == Firing {
+			failing = append(failing, check)
+		} else {
+			recovered = append(recovered, check)
+		}
+	}
+	sort.Strings(failing)
+	sort.Strings(recovered)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Monitoring %s from %s to %s\n", target, since.UTC().Format(time.DateTime), now.UTC().Format(time.DateTime+" MST"))
+	if len(failing) > 0 {
+		b.WriteString("\nFailing now:\n")
+		for _, check := range failing {
+			fmt.Fprintf(&b, "  %s: %s\n", check, last[check].Error)
+		}
+	}
+	if len(recovered) > 0 {
+		b.WriteString("\nRecovered:\n")
+		for _, check := range recovered {
+			fmt.Fprintf(&b, "  %s at %s\n", check, last[check].Time.UTC().Format(time.DateTime))
+		}
+	}
+	b.WriteString("\nChanges:\n")
+	for _, a := range alerts {
+		fmt.Fprintf(&b, "  %s %-8s %s", a.Time.UTC().Format(time.DateTime), a.Status, a.Check)
+		if a.Error != "" {
+			fmt.Fprintf(&b, ": %s", a.Error)
+		}
+		b.WriteString("\n")
+	}
+
+	switch {
+	case len(failing) > 0:
+		subject = fmt.Sprintf("[osyraa] %s: %d checks failing", target, len(failing))
+	default:
+		subject = fmt.Sprintf("[osyraa] %s: %d checks recovered", target, len(recovered))
+	}
+	return subject, b.String()
+}
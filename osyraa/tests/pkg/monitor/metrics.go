@@ -0,0 +1,165 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// Metrics accumulates check results and serves them in the Prometheus text
+// exposition format
+type Metrics struct {
+	mu     sync.Mutex
+	checks map[string]*checkMetrics
+	gauges map[string]*gauge
+}
+
+// gauge is a free-form gauge family keyed by its label set
+type gauge struct {
+	help   string
+	series map[string]float64
+}
+
+type checkMetrics struct {
+	success  bool
+	duration time.Duration
+	runs     int
+	failures int
+	lastRun  time.Time
+}
+
+// NewMetrics creates an empty metrics set
+func NewMetrics() *Metrics {
+	return &Metrics{checks: map[string]*checkMetrics{}, gauges: map[string]*gauge{}}
+}
+
+// SetGauge sets a gauge outside the per-check families; labels is the
+// rendered label set such as `window="1h"`, or empty
+func (m *Metrics) SetGauge(name, help, labels string, v float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, ok := m.gauges[name]
+	if !ok {
+		g = &gauge{help: help, series: map[string]float64{}}
+		m.gauges[name] = g
+	}
+	g.series[labels] = v
+}
+
+// Record adds the outcome of one check run
+func (m *Metrics) Record(r battery.Result, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.checks[r.Check]
+	if !ok {
+		c = &checkMetrics{}
+		m.checks[r.Check] = c
+	}
+	c.success = r.Passed()
+	c.duration = r.Duration
+	c.runs++
+	if !c.success {
+		c.failures++
+	}
+	c.lastRun = at
+}
+
+// Forget drops a check's series, for a check no longer run
+func (m *Metrics) Forget(check string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.checks, check)
+}
+
+// WriteTo writes every metric in the text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.checks))
+	for name := range m.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := []struct {
+		name, kind, help string
+		value            func(*checkMetrics) float64
+	}{
+		{"osyraa_check_success", "gauge", "Whether the last run of the check passed",
+			func(c *checkMetrics) float64 { return boolValue(c.success) }},
+		{"osyraa_check_duration_seconds", "gauge", "Duration of the last run of the check",
+			func(c *checkMetrics) float64 { return c.duration.Seconds() }},
+		{"osyraa_check_runs_total", "counter", "Runs of the check",
+			func(c *checkMetrics) float64 { return float64(c.runs) }},
+		{"osyraa_check_failures_total", "counter", "Failed runs of the check",
+			func(c *checkMetrics) float64 { return float64(c.failures) }},
+		{"osyraa_check_last_run_timestamp_seconds", "gauge", "Unix time of the last run of the check",
+			func(c *checkMetrics) float64 { return float64(c.lastRun.UnixNano()) / 1e9 }},
+	}
+
+	var total int64
+	for _, f := range families {
+		n, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+		for _, name := range names {
+			n, err := fmt.Fprintf(w, "%s{check=%q} %g\n", f.name, name, f.value(m.checks[name]))
+			total += int64(n)
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+
+	gaugeNames := make([]string, 0, len(m.gauges))
+	for name := range m.gauges {
+		gaugeNames = append(gaugeNames, name)
+	}
+	sort.Strings(gaugeNames)
+	for _, name := range gaugeNames {
+		g := m.gauges[name]
+		n, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, g.help, name)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+		labels := make([]string, 0, len(g.series))
+		for l := range g.series {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			series := name
+			if l != "" {
+				series += "{" + l + "}"
+			}
+			n, err := fmt.Fprintf(w, "%s %g\n", series, g.series[l])
+			total += int64(n)
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// ServeHTTP exposes the metrics for scraping
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
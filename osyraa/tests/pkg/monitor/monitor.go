@@ -0,0 +1,254 @@
+// Package monitor runs a subset of the battery against production on an
+// interval, exposing results as Prometheus metrics and sending webhook
+// alerts when a check starts or stops failing.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/securitytxt"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/tlscheck"
+)
+
+// Checks are the checks monitor mode can run, by name
+var Checks = map[string]battery.Check{
+	"availability":     {Name: "availability", Run: battery.CheckEndpoint},
+	"content":          {Name: "content", Run: battery.CheckContent},
+	"content-hash":     {Name: "content-hash", Run: battery.CheckContentHashes},
+	"cert-expiry":      {Name: "cert-expiry", Run: tlscheck.BatteryCheck(tlscheck.DefaultOptions()).Run},
+	"security-headers": {Name: "security-headers", Run: battery.CheckSecurityHeaders},
+	"framing":          {Name: "framing", Run: battery.CheckFraming},
+	"cookies":          {Name: "cookies", Run: battery.CheckCookies},
+	"sensitive-files":  {Name: "sensitive-files", Run: battery.CheckSensitiveFiles},
+	"response-time":    {Name: "response-time", Run: battery.CheckResponseTime},
+	"security-txt":     securitytxt.BatteryCheck(),
+}
+
+// SelectChecks looks up checks by name
+func SelectChecks(names []string) ([]battery.Check, error) {
+	var checks []battery.Check
+	for _, name := range names {
+		check, ok := Checks[strings.TrimSpace(name)]
+		if !ok {
+			known := make([]string, 0, len(Checks))
+			for k := range Checks {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown check %q (known: %s)", name, strings.Join(known, ", "))
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// Monitor periodically runs checks against a target
+type Monitor struct {
+	Target   *battery.Target
+	Checks   []battery.Check
+	Interval time.Duration
+	Metrics  *Metrics
+	Alerters []Alerter
+	// SLO records each round as one sample when set; a round succeeds when
+	// every check passes
+	SLO    *SLO
+	Logger *log.Logger
+	// Now defaults to time.Now
+	Now func() time.Time
+
+	failing map[string]bool
+
+	mu      sync.Mutex
+	pending *Settings
+	wake    chan struct{}
+}
+
+// Settings are the parts of a Monitor that can change while it runs
+type Settings struct {
+	Checks   []battery.Check
+	Interval time.Duration
+	// MaxResponseTime replaces the target's response time budget
+	MaxResponseTime time.Duration
+	// Objective replaces the SLO's objective; it is ignored when the
+	// monitor does not track an SLO
+	Objective float64
+}
+
+// Reconfigure applies s at the start of the next round, which it starts
+// at once rather than waiting out the old interval
+func (m *Monitor) Reconfigure(s Settings) {
+	m.mu.Lock()
+	m.pending = &s
+	wake := m.wakeup()
+	m.mu.Unlock()
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// wakeup must be called with mu held
+func (m *Monitor) wakeup() chan struct{} {
+	if m.wake == nil {
+		m.wake = make(chan struct{}, 1)
+	}
+	return m.wake
+}
+
+// apply switches to pending settings, if any. A check that was dropped
+// while failing no longer alerts, and its metrics are removed.
+func (m *Monitor) apply() {
+	m.mu.Lock()
+	s := m.pending
+	m.pending = nil
+	m.mu.Unlock()
+	if s == nil {
+		return
+	}
+	kept := map[string]bool{}
+	for _, c := range s.Checks {
+		kept[c.Name] = true
+	}
+	for _, c := range m.Checks {
+		if !kept[c.Name] {
+			delete(m.failing, c.Name)
+			if m.Metrics != nil {
+				m.Metrics.Forget(c.Name)
+			}
+		}
+	}
+	m.Checks = s.Checks
+	if s.Interval > 0 {
+		m.Interval = s.Interval
+	}
+	m.Target.Expect.MaxResponseTime = s.MaxResponseTime
+	if m.SLO != nil && s.Objective > 0 {
+		m.SLO.Objective = s.Objective
+	}
+	names := make([]string, len(s.Checks))
+	for i, c := range s.Checks {
+		names[i] = c.Name
+	}
+	m.logf("now running %s every %v", strings.Join(names, ","), m.Interval)
+}
+
+// Run executes rounds of checks until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) error {
+	m.mu.Lock()
+	wake := m.wakeup()
+	m.mu.Unlock()
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		interval := m.Interval
+		m.RunOnce(ctx)
+		if m.Interval != interval {
+			ticker.Reset(m.Interval)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// RunOnce executes every check once, records metrics and alerts on checks
+// whose pass/fail state changed. The first failure of a check alerts; a
+// check that was never failing does not send a resolved alert.
+func (m *Monitor) RunOnce(ctx context.Context) []battery.Result {
+	now := time.Now
+	if m.Now != nil {
+		now = m.Now
+	}
+	if m.failing == nil {
+		m.failing = map[string]bool{}
+	}
+	m.apply()
+
+	results := battery.Run(ctx, m.Target, m.Checks)
+	at := now()
+	for _, r := range results {
+		if m.Metrics != nil {
+			m.Metrics.Record(r, at)
+		}
+		wasFailing := m.failing[r.Check]
+		m.failing[r.Check] = !r.Passed()
+		if wasFailing == !r.Passed() {
+			continue
+		}
+		alert := Alert{Check: r.Check, Target: m.Target.BaseURL, Status: Resolved, Time: at}
+		if !r.Passed() {
+			alert.Status = Firing
+			alert.Error = r.Err.Error()
+		}
+		m.alert(ctx, alert)
+	}
+	if m.SLO != nil {
+		m.trackSLO(ctx, len(battery.Failed(results)) == 0, at)
+	}
+	return results
+}
+
+// sloWindows are the rolling windows availability is reported over
+var sloWindows = []struct {
+	label  string
+	window time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// trackSLO records the round, publishes availability and alerts when the
+// objective comes at risk or recovers
+func (m *Monitor) trackSLO(ctx context.Context, ok bool, at time.Time) {
+	if err := m.SLO.Record(Sample{At: at, OK: ok}); err != nil {
+		m.logf("saving SLO state: %v", err)
+	}
+	if m.Metrics != nil {
+		for _, w := range sloWindows {
+			avail, _ := m.SLO.Availability(w.window, at)
+			m.Metrics.SetGauge("osyraa_availability_ratio", "Fraction of successful rounds over a rolling window",
+				fmt.Sprintf("window=%q", w.label), avail)
+		}
+		m.Metrics.SetGauge("osyraa_slo_objective_ratio", "Availability objective", "", m.SLO.Objective)
+		m.Metrics.SetGauge("osyraa_slo_error_budget_remaining_ratio", "Unspent fraction of the error budget", "", m.SLO.BudgetRemaining(at))
+	}
+
+	reason := m.SLO.AtRisk(at)
+	wasAtRisk := m.failing["slo"]
+	m.failing["slo"] = reason != ""
+	switch {
+	case reason != "" && !wasAtRisk:
+		m.alert(ctx, Alert{Check: "slo", Target: m.Target.BaseURL, Status: Firing, Error: reason, Time: at})
+	case reason == "" && wasAtRisk:
+		m.alert(ctx, Alert{Check: "slo", Target: m.Target.BaseURL, Status: Resolved, Time: at})
+	}
+}
+
+// alert sends a to every alerter, logging delivery failures rather than
+// stopping the monitor
+func (m *Monitor) alert(ctx context.Context, a Alert) {
+	m.logf("%s %s: %s %s", a.Status, a.Check, a.Target, a.Error)
+	for _, alerter := range m.Alerters {
+		if err := alerter.Alert(ctx, a); err != nil {
+			m.logf("alert delivery failed: %v", err)
+		}
+	}
+}
+
+func (m *Monitor) logf(format string, args ...any) {
+	if m.Logger != nil {
+		m.Logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,207 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectChecks(t *testing.T) {
+	checks, err := SelectChecks([]string{"availability", " cert-expiry"})
+	require.NoError(t, err)
+	assert.Equal(t, "availability", checks[0].Name)
+	assert.Equal(t, "cert-expiry", checks[1].Name)
+
+	_, err = SelectChecks([]string{"nope"})
+	assert.ErrorContains(t, err, `unknown check "nope"`)
+}
+
+func TestMonitorAlertsOnStateChange(t *testing.T) {
+	var down atomic.Bool
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("<h1>Princeton A. Strong</h1>"))
+	}))
+	defer site.Close()
+
+	var mu sync.Mutex
+	var alerts []Alert
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&a))
+		mu.Lock()
+		alerts = append(alerts, a)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	checks, err := SelectChecks([]string{"availability"})
+	require.NoError(t, err)
+	m := &Monitor{
+		Target:   battery.NewTarget(site.URL),
+		Checks:   checks,
+		Metrics:  NewMetrics(),
+		Alerters: []Alerter{NewWebhook(hook.URL)},
+	}
+	ctx := context.Background()
+
+	m.RunOnce(ctx)
+	down.Store(true)
+	m.RunOnce(ctx)
+	m.RunOnce(ctx)
+	down.Store(false)
+	m.RunOnce(ctx)
+
+	require.Len(t, alerts, 2, "one alert when failing starts, one when it resolves")
+	assert.Equal(t, Firing, alerts[0].Status)
+	assert.Contains(t, alerts[0].Error, "502")
+	assert.Equal(t, Resolved, alerts[1].Status)
+
+	var out strings.Builder
+	_, err = m.Metrics.WriteTo(&out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `osyraa_check_success{check="availability"} 1`)
+	assert.Contains(t, out.String(), `osyraa_check_runs_total{check="availability"} 4`)
+	assert.Contains(t, out.String(), `osyraa_check_failures_total{check="availability"} 2`)
+}
+
+func TestWebhookRejectsErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	assert.ErrorContains(t, NewWebhook(srv.URL).Alert(context.Background(), Alert{}), "returned 500")
+}
+
+// fakeMailer records sends, failing while err is set
+type fakeMailer struct {
+	subjects, bodies []string
+	err              error
+}
+
+func (f *fakeMailer) Send(_ context.Context, subject, body string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.subjects = append(f.subjects, subject)
+	f.bodies = append(f.bodies, body)
+	return nil
+}
+
+func TestDigest(t *testing.T) {
+	mailer := &fakeMailer{}
+	d := &Digest{Mailer: mailer, Target: "https://resume.example.com"}
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, d.Flush(ctx, at))
+	assert.Empty(t, mailer.subjects, "nothing to report sends nothing")
+
+	d.Alert(ctx, Alert{Check: "availability", Status: Firing, Error: "502", Time: at.Add(time.Minute)})
+	d.Alert(ctx, Alert{Check: "cert-expiry", Status: Firing, Error: "expires in 5 days", Time: at.Add(2 * time.Minute)})
+	d.Alert(ctx, Alert{Check: "availability", Status: Resolved, Time: at.Add(3 * time.Minute)})
+
+	mailer.err = errors.New("connection refused")
+	assert.Error(t, d.Flush(ctx, at.Add(time.Hour)))
+	mailer.err = nil
+	require.NoError(t, d.Flush(ctx, at.Add(time.Hour)), "alerts stay queued after a failed send")
+	require.Len(t, mailer.subjects, 1)
+	assert.Equal(t, "[osyraa] https://resume.example.com: 1 checks failing", mailer.subjects[0])
+	assert.Equal(t, `Monitoring https://resume.example.com from 2026-03-01 10:01:00 to 2026-03-01 11:00:00 UTC
+
+Failing now:
+  cert-expiry: expires in 5 days
+
+Recovered:
+  availability at 2026-03-01 10:03:00
+
+Changes:
+  2026-03-01 10:01:00 firing   availability: 502
+  2026-03-01 10:02:00 firing   cert-expiry: expires in 5 days
+  2026-03-01 10:03:00 resolved availability
+`, mailer.bodies[0])
+
+	require.NoError(t, d.Flush(ctx, at.Add(2*time.Hour)))
+	assert.Len(t, mailer.subjects, 1, "a flushed alert is sent once")
+
+	d.Alert(ctx, Alert{Check: "cert-expiry", Status: Resolved, Time: at.Add(150 * time.Minute)})
+	require.NoError(t, d.Flush(ctx, at.Add(3*time.Hour)))
+	assert.Equal(t, "[osyraa] https://resume.example.com: 1 checks recovered", mailer.subjects[1])
+	assert.Contains(t, mailer.bodies[1], "from 2026-03-01 11:00:00 to", "the next digest starts where the last ended")
+}
+
+func TestReconfigure(t *testing.T) {
+	var down atomic.Bool
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("<h1>Princeton A. Strong</h1>"))
+	}))
+	defer site.Close()
+
+	checks, err := SelectChecks([]string{"availability", "content"})
+	require.NoError(t, err)
+	slo, err := NewSLO(0.999, time.Hour, "")
+	require.NoError(t, err)
+	m := &Monitor{Target: battery.NewTarget(site.URL), Checks: checks, Interval: time.Hour, Metrics: NewMetrics(), SLO: slo}
+	ctx := context.Background()
+
+	down.Store(true)
+	m.RunOnce(ctx)
+	assert.True(t, m.failing["content"])
+
+	availability, err := SelectChecks([]string{"availability"})
+	require.NoError(t, err)
+	m.Reconfigure(Settings{Checks: availability, Interval: time.Minute, MaxResponseTime: 5 * time.Second, Objective: 0.99})
+	assert.Len(t, m.Checks, 2, "settings apply at the next round")
+	results := m.RunOnce(ctx)
+	require.Len(t, results, 1)
+	assert.Equal(t, time.Minute, m.Interval)
+	assert.Equal(t, 5*time.Second, m.Target.Expect.MaxResponseTime)
+	assert.Equal(t, 0.99, m.SLO.Objective)
+	assert.NotContains(t, m.failing, "content", "a dropped check no longer alerts")
+
+	var out strings.Builder
+	_, err = m.Metrics.WriteTo(&out)
+	require.NoError(t, err)
+	assert.NotContains(t, out.String(), `check="content"`)
+	assert.Contains(t, out.String(), `osyraa_check_runs_total{check="availability"} 2`)
+}
+
+func TestReconfigureWakesRun(t *testing.T) {
+	var rounds atomic.Int32
+	site := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		rounds.Add(1)
+	}))
+	defer site.Close()
+
+	checks, err := SelectChecks([]string{"availability"})
+	require.NoError(t, err)
+	m := &Monitor{Target: battery.NewTarget(site.URL), Checks: checks, Interval: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return rounds.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	m.Reconfigure(Settings{Checks: checks, Interval: 20 * time.Millisecond})
+	require.Eventually(t, func() bool { return rounds.Load() >= 3 }, 5*time.Second, 10*time.Millisecond,
+		"the new interval applies without waiting out the old one")
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
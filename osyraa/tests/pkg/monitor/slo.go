@@ -0,0 +1,194 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sample is the outcome of one probe interval
+type Sample struct {
+	At time.Time `json:"at"`
+	OK bool      `json:"ok"`
+}
+
+// SLO tracks availability against an objective over a rolling window,
+// optionally persisting samples so a restarted daemon keeps its history
+type SLO struct {
+	// Objective is the target availability, e.g. 0.999
+	Objective float64
+	// Window is the compliance period the error budget covers
+	Window time.Duration
+	// StatePath appends samples as JSON lines when set
+	StatePath string
+
+	mu      sync.Mutex
+	samples []Sample
+}
+
+// NewSLO creates a tracker and loads any samples saved at statePath,
+// sorted and without those older than window before the newest
+func NewSLO(objective float64, window time.Duration, statePath string) (*SLO, error) {
+	if objective <= 0 || objective >= 1 {
+		return nil, fmt.Errorf("SLO objective %v must be between 0 and 1", objective)
+	}
+	s := &SLO{Objective: objective, Window: window, StatePath: statePath}
+	if statePath == "" {
+		return s, nil
+	}
+	f, err := os.Open(statePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var sample Sample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			return nil, fmt.Errorf("%s: %w", statePath, err)
+		}
+		s.samples = append(s.samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// Record keeps samples in order and within the window; a file written
+	// by hand, or by a run with a longer window, may not be
+	sort.SliceStable(s.samples, func(i, j int) bool { return s.samples[i].At.Before(s.samples[j].At) })
+	if n := len(s.samples); n > 0 {
+		cutoff := s.samples[n-1].At.Add(-window)
+		i := sort.Search(n, func(i int) bool { return !s.samples[i].At.Before(cutoff) })
+		s.samples = s.samples[i:]
+	}
+	return s, nil
+}
+
+// Record adds a sample, dropping samples older than the window
+func (s *SLO) Record(sample Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, sample)
+	cutoff := sample.At.Add(-s.Window)
+	i := 0
+	for i < len(s.samples) && s.samples[i].At.Before(cutoff) {
+		i++
+	}
+	s.samples = s.samples[i:]
+
+	if s.StatePath == "" {
+		return nil
+	}
+	line, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	if i > 0 {
+		return s.rewrite()
+	}
+	f, err := os.OpenFile(s.StatePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// rewrite replaces the state file with the samples still in the window
+func (s *SLO) rewrite() error {
+	tmp := s.StatePath + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, sample := range s.samples {
+		if err := enc.Encode(sample); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.StatePath)
+}
+
+// Availability returns the fraction of successful samples in the window
+// ending at now, and the number of samples it is based on
+func (s *SLO) Availability(window time.Duration, now time.Time) (float64, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cutoff := now.Add(-window)
+	ok, total := 0, 0
+	for _, sample := range s.samples {
+		if sample.At.Before(cutoff) || sample.At.After(now) {
+			continue
+		}
+		total++
+		if sample.OK {
+			ok++
+		}
+	}
+	if total == 0 {
+		return 1, 0
+	}
+	return float64(ok) / float64(total), total
+}
+
+// BurnRate is how fast the error budget is spent over window: 1 spends it
+// exactly over the SLO window, higher values exhaust it early
+func (s *SLO) BurnRate(window time.Duration, now time.Time) float64 {
+	avail, _ := s.Availability(window, now)
+	return (1 - avail) / (1 - s.Objective)
+}
+
+// BudgetRemaining is the unspent fraction of the error budget over the SLO
+// window; negative once the objective is missed
+func (s *SLO) BudgetRemaining(now time.Time) float64 {
+	avail, _ := s.Availability(s.Window, now)
+	return 1 - (1-avail)/(1-s.Objective)
+}
+
+// burnAlerts are the fractions of the error budget whose spending within
+// an alert window puts the SLO at risk: 2% of it in an hour, or 5% in six
+// hours. Over a 30-day window these are the usual 14.4x and 6x burn rates.
+var burnAlerts = []struct {
+	window time.Duration
+	budget float64
+}{
+	{time.Hour, 0.02},
+	{6 * time.Hour, 0.05},
+}
+
+// burnThreshold is the burn rate that spends budget, a fraction of the
+// error budget, within window
+func (s *SLO) burnThreshold(window time.Duration, budget float64) float64 {
+	return budget * float64(s.Window) / float64(window)
+}
+
+// AtRisk explains why the SLO is at risk, or returns "" when it isn't
+func (s *SLO) AtRisk(now time.Time) string {
+	if remaining := s.BudgetRemaining(now); remaining <= 0 {
+		return fmt.Sprintf("error budget exhausted (%.1f%% remaining) for %.3f%% over %v", remaining*100, s.Objective*100, s.Window)
+	}
+	for _, b := range burnAlerts {
+		if b.window >= s.Window {
+			// The exhausted budget above already covers the whole window
+			continue
+		}
+		if rate := s.BurnRate(b.window, now); rate >= s.burnThreshold(b.window, b.budget) {
+			return fmt.Sprintf("burning error budget %.1fx over the last %v", rate, b.window)
+		}
+	}
+	return ""
+}
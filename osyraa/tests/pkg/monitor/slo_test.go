@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestSLOAccounting(t *testing.T) {
+	slo, err := NewSLO(0.99, 24*time.Hour, "")
+	require.NoError(t, err)
+
+	// 100 one-minute samples, 1 failure: exactly the objective
+	for i := 0; i < 100; i++ {
+		require.NoError(t, slo.Record(Sample{At: epoch.Add(time.Duration(i) * time.Minute), OK: i != 50}))
+	}
+	now := epoch.Add(99 * time.Minute)
+	avail, n := slo.Availability(24*time.Hour, now)
+	assert.Equal(t, 100, n)
+	assert.InDelta(t, 0.99, avail, 1e-9)
+	assert.InDelta(t, 0, slo.BudgetRemaining(now), 1e-9)
+	assert.Contains(t, slo.AtRisk(now), "exhausted")
+
+	avail, n = slo.Availability(10*time.Minute, now)
+	assert.Equal(t, 11, n)
+	assert.Equal(t, 1.0, avail)
+
+	_, err = NewSLO(1.5, time.Hour, "")
+	assert.Error(t, err)
+}
+
+func TestSLOBurnRate(t *testing.T) {
+	slo, err := NewSLO(0.999, 30*24*time.Hour, "")
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, slo.Record(Sample{At: epoch.Add(time.Duration(i) * time.Minute), OK: true}))
+	}
+	now := epoch.Add(999 * time.Minute)
+	assert.Empty(t, slo.AtRisk(now))
+
+	// One failure in the last hour out of 1000 leaves budget, but burns fast
+	require.NoError(t, slo.Record(Sample{At: now.Add(time.Minute), OK: false}))
+	reason := slo.AtRisk(now.Add(time.Minute))
+	assert.Contains(t, reason, "over the last 1h0m0s")
+}
+
+func TestSLOBurnThresholdsFollowWindow(t *testing.T) {
+	month, err := NewSLO(0.999, 30*24*time.Hour, "")
+	require.NoError(t, err)
+	assert.InDelta(t, 14.4, month.burnThreshold(time.Hour, 0.02), 1e-9)
+	assert.InDelta(t, 6, month.burnThreshold(6*time.Hour, 0.05), 1e-9)
+
+	week, err := NewSLO(0.99, 7*24*time.Hour, "")
+	require.NoError(t, err)
+	assert.InDelta(t, 3.36, week.burnThreshold(time.Hour, 0.02), 1e-9)
+	assert.InDelta(t, 1.4, week.burnThreshold(6*time.Hour, 0.05), 1e-9)
+
+	// A week of one-minute samples ending in 3 failures burns the last
+	// hour's budget 4.9x: past a week's 3.36x, short of a month's 14.4x
+	monthly, err := NewSLO(0.99, 30*24*time.Hour, "")
+	require.NoError(t, err)
+	const n = 7 * 24 * 60
+	for i := 0; i < n; i++ {
+		sample := Sample{At: epoch.Add(time.Duration(i) * time.Minute), OK: i < n-3}
+		require.NoError(t, week.Record(sample))
+		require.NoError(t, monthly.Record(sample))
+	}
+	now := epoch.Add((n - 1) * time.Minute)
+	assert.InDelta(t, 4.9, week.BurnRate(time.Hour, now), 0.1)
+	assert.Contains(t, week.AtRisk(now), "over the last 1h0m0s")
+	assert.Empty(t, monthly.AtRisk(now))
+}
+
+func TestSLOLoadSortsAndPrunes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slo.jsonl")
+	lines := []string{
+		`{"at":"2026-01-01T02:00:00Z","ok":true}`,
+		`{"at":"2026-01-01T00:00:00Z","ok":false}`,
+		`{"at":"2026-01-01T01:30:00Z","ok":false}`,
+	}
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+
+	slo, err := NewSLO(0.9, time.Hour, path)
+	require.NoError(t, err)
+	require.Len(t, slo.samples, 2, "the sample two hours before the newest is outside the window")
+	assert.Equal(t, epoch.Add(90*time.Minute), slo.samples[0].At)
+	assert.Equal(t, epoch.Add(2*time.Hour), slo.samples[1].At)
+}
+
+func TestSLOPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slo.jsonl")
+	slo, err := NewSLO(0.9, time.Hour, path)
+	require.NoError(t, err)
+	require.NoError(t, slo.Record(Sample{At: epoch, OK: true}))
+	require.NoError(t, slo.Record(Sample{At: epoch.Add(time.Minute), OK: false}))
+
+	reloaded, err := NewSLO(0.9, time.Hour, path)
+	require.NoError(t, err)
+	avail, n := reloaded.Availability(time.Hour, epoch.Add(time.Minute))
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 0.5, avail)
+
+	// Samples falling out of the window are dropped from the file too
+	require.NoError(t, reloaded.Record(Sample{At: epoch.Add(2 * time.Hour), OK: true}))
+	again, err := NewSLO(0.9, time.Hour, path)
+	require.NoError(t, err)
+	_, n = again.Availability(24*time.Hour, epoch.Add(2*time.Hour))
+	assert.Equal(t, 1, n)
+}
+
+func TestMonitorTracksSLO(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer site.Close()
+
+	slo, err := NewSLO(0.999, 30*24*time.Hour, "")
+	require.NoError(t, err)
+	var alerts []Alert
+	m := &Monitor{
+		Target:   battery.NewTarget(site.URL),
+		Checks:   []battery.Check{Checks["availability"]},
+		Metrics:  NewMetrics(),
+		SLO:      slo,
+		Alerters: []Alerter{alerterFunc(func(a Alert) { alerts = append(alerts, a) })},
+		Now:      func() time.Time { return epoch },
+	}
+	m.RunOnce(context.Background())
+
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "slo", alerts[1].Check)
+	assert.Equal(t, Firing, alerts[1].Status)
+
+	var out strings.Builder
+	_, err = m.Metrics.WriteTo(&out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `osyraa_availability_ratio{window="1h"} 0`)
+	assert.Contains(t, out.String(), `osyraa_slo_objective_ratio 0.999`)
+}
+
+type alerterFunc func(Alert)
+
+func (f alerterFunc) Alert(_ context.Context, a Alert) error {
+	f(a)
+	return nil
+}
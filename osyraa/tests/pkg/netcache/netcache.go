@@ -0,0 +1,219 @@
+// Package netcache keeps responses from external hosts on disk between
+// runs, so checks that reach out to third parties (profile and credential
+// links, externally hosted scripts) don't request the same URL on every
+// local run. Entries expire after a TTL; answers that may be transient,
+// such as a 429 or a 5xx, expire sooner than good ones.
+package netcache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HitHeader is set to "hit" on responses served from the cache
+const HitHeader = "X-Osyraa-Cache"
+
+// Cache is a directory of cached responses, one file per request
+type Cache struct {
+	Dir string
+	// TTL is how long a response below 400, or a definite 404 or 410, is
+	// reused
+	TTL time.Duration
+	// ErrorTTL is how long any other error response is reused; zero
+	// stores none of them
+	ErrorTTL time.Duration
+	// MaxBody is the largest body stored; bigger responses are passed
+	// through uncached
+	MaxBody int64
+	// Now returns the current time; nil means time.Now
+	Now func() time.Time
+}
+
+// New returns a cache in dir that keeps good responses for a day and
+// errors for an hour
+func New(dir string) *Cache {
+	return &Cache{Dir: dir, TTL: 24 * time.Hour, ErrorTTL: time.Hour, MaxBody: 4 << 20}
+}
+
+// DefaultDir is osyraa/netcache under the user's cache directory, or under
+// the temporary directory when there is none
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "osyraa", "netcache")
+}
+
+// entry is a stored response
+type entry struct {
+	URL     string      `json:"url"`
+	Expires time.Time   `json:"expires"`
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header"`
+	Body    []byte      `json:"body"`
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func (c *Cache) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String()))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+// ttl is how long a response with status may be reused
+func (c *Cache) ttl(status int) time.Duration {
+	if status < 400 || status == http.StatusNotFound || status == http.StatusGone {
+		return c.TTL
+	}
+	return c.ErrorTTL
+}
+
+// Transport returns a RoundTripper that answers GET and HEAD requests from
+// the cache when it can and stores what next returns otherwise. Requests
+// with other methods, or with a body, always go to next. A nil next is
+// http.DefaultTransport.
+func (c *Cache) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{cache: c, next: next}
+}
+
+type transport struct {
+	cache *Cache
+	next  http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if (req.Method != http.MethodGet && req.Method != http.MethodHead) || req.Body != nil && req.Body != http.NoBody {
+		return t.next.RoundTrip(req)
+	}
+	path := t.cache.path(req)
+	if e, err := t.cache.load(path); err == nil && t.cache.now().Before(e.Expires) {
+		return e.response(req), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	ttl := t.cache.ttl(resp.StatusCode)
+	if ttl <= 0 {
+		return resp, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.cache.MaxBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > t.cache.MaxBody {
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	e := entry{URL: req.URL.String(), Expires: t.cache.now().Add(ttl), Status: resp.StatusCode, Header: resp.Header, Body: body}
+	// A cache that can't be written only costs the next run a request
+	_ = t.cache.store(path, e)
+	return resp, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (e entry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(HitHeader, "hit")
+	return &http.Response{
+		Status:        http.StatusText(e.Status),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func (c *Cache) load(path string) (entry, error) {
+	var e entry
+	f, err := os.Open(path)
+	if err != nil {
+		return e, err
+	}
+	defer f.Close()
+	return e, json.NewDecoder(bufio.NewReader(f)).Decode(&e)
+}
+
+// store writes the entry through a temporary file so concurrent runs never
+// read a partial one
+func (c *Cache) store(path string, e entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(tmp).Encode(e); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Prune deletes expired entries and returns how many it removed
+func (c *Cache) Prune() (int, error) {
+	removed := 0
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+		if e, err := c.load(path); err != nil || !c.now().Before(e.Expires) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// Clear deletes every entry
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
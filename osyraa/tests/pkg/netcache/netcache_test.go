@@ -0,0 +1,123 @@
+package netcache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// server counts requests per path and answers /ok with 200, /gone with
+// 410, /busy with 429, /big with a large body and /moved with a redirect
+func server(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/ok":
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, "hello")
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/busy":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/big":
+			io.WriteString(w, strings.Repeat("x", 2048))
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestTransport(t *testing.T) {
+	srv, hits := server(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(t.TempDir())
+	cache.MaxBody = 1024
+	cache.Now = func() time.Time { return now }
+	client := &http.Client{Transport: cache.Transport(nil)}
+
+	resp, body := get(t, client, srv.URL+"/ok")
+	assert.Equal(t, "hello", body)
+	assert.Empty(t, resp.Header.Get(HitHeader))
+	resp, body = get(t, client, srv.URL+"/ok")
+	assert.Equal(t, "hello", body)
+	assert.Equal(t, "hit", resp.Header.Get(HitHeader))
+	assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	assert.Equal(t, int32(1), hits.Load())
+
+	// Redirect hops are cached one by one
+	resp, _ = get(t, client, srv.URL+"/moved")
+	assert.Equal(t, srv.URL+"/ok", resp.Request.URL.String())
+	assert.Equal(t, int32(2), hits.Load())
+	get(t, client, srv.URL+"/moved")
+	assert.Equal(t, int32(2), hits.Load())
+
+	get(t, client, srv.URL+"/big")
+	get(t, client, srv.URL+"/big")
+	assert.Equal(t, int32(4), hits.Load(), "bodies over MaxBody are not stored")
+
+	resp, _ = get(t, client, srv.URL+"/gone")
+	get(t, client, srv.URL+"/busy")
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+
+	now = now.Add(2 * time.Hour)
+	resp, _ = get(t, client, srv.URL+"/gone")
+	assert.Equal(t, "hit", resp.Header.Get(HitHeader), "a 410 lasts the full TTL")
+	resp, _ = get(t, client, srv.URL+"/busy")
+	assert.Empty(t, resp.Header.Get(HitHeader), "a 429 expires after ErrorTTL")
+
+	now = now.Add(23 * time.Hour)
+	before := hits.Load()
+	get(t, client, srv.URL+"/ok")
+	assert.Equal(t, before+1, hits.Load(), "entries expire after TTL")
+}
+
+func TestTransportSkipsOtherMethods(t *testing.T) {
+	srv, hits := server(t)
+	client := &http.Client{Transport: New(t.TempDir()).Transport(nil)}
+	for range 2 {
+		resp, err := client.Post(srv.URL+"/ok", "text/plain", strings.NewReader("x"))
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestPrune(t *testing.T) {
+	srv, _ := server(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(t.TempDir())
+	cache.Now = func() time.Time { return now }
+	client := &http.Client{Transport: cache.Transport(nil)}
+	get(t, client, srv.URL+"/ok")
+	get(t, client, srv.URL+"/busy")
+
+	now = now.Add(2 * time.Hour)
+	removed, err := cache.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	require.NoError(t, cache.Clear())
+	removed, err = cache.Prune()
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
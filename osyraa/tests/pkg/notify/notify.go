@@ -0,0 +1,169 @@
+// Package notify sends the harness's reports by email, for people who read
+// their inbox rather than a chat channel. Mail always travels over TLS:
+// implicit TLS on port 465, STARTTLS elsewhere, and a server that offers
+// neither is refused rather than sent credentials and results in clear.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// ErrNoTLS is returned when the server does not offer STARTTLS
+var ErrNoTLS = errors.New("SMTP server does not offer STARTTLS")
+
+// Mailer sends plain-text email through an SMTP server
+type Mailer struct {
+	// Addr is the server's host:port
+	Addr string
+	From string
+	To   []string
+	// Username and Password authenticate with PLAIN auth when set
+	Username, Password string
+	// ImplicitTLS connects with TLS from the start instead of STARTTLS;
+	// NewMailer sets it for port 465
+	ImplicitTLS bool
+	// TLSConfig defaults to verifying the server's certificate for its
+	// host name
+	TLSConfig *tls.Config
+	// Timeout bounds the whole exchange; zero is 30 seconds
+	Timeout time.Duration
+}
+
+// NewMailer validates the addresses and picks the TLS mode from the port
+func NewMailer(addr, from string, to []string) (*Mailer, error) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("SMTP address: %w", err)
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("from address %q: %w", from, err)
+	}
+	if len(to) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	for _, a := range to {
+		if _, err := mail.ParseAddress(a); err != nil {
+			return nil, fmt.Errorf("recipient %q: %w", a, err)
+		}
+	}
+	return &Mailer{Addr: addr, From: from, To: to, ImplicitTLS: port == "465"}, nil
+}
+
+// Message formats an email: UTF-8 text, quoted-printable so long lines
+// and non-ASCII survive any relay
+func (m *Mailer) Message(subject, body string, at time.Time) []byte {
+	var b bytes.Buffer
+	id := make([]byte, 12)
+	rand.Read(id)
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(m.From); err == nil {
+		if _, d, ok := strings.Cut(addr.Address, "@"); ok {
+			domain = d
+		}
+	}
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", at.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	qp.Close()
+	return b.Bytes()
+}
+
+// Send mails subject and body to every recipient
+func (m *Mailer) Send(ctx context.Context, subject, body string) error {
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return err
+	}
+	config := &tls.Config{ServerName: host}
+	if m.TLSConfig != nil {
+		config = m.TLSConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName = host
+		}
+	}
+
+	var conn net.Conn
+	if m.ImplicitTLS {
+		conn, err = (&tls.Dialer{Config: config}).DialContext(ctx, "tcp", m.Addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", m.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", m.Addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("%s: %w", m.Addr, err)
+	}
+	defer c.Close()
+
+	if !m.ImplicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s: %w", m.Addr, ErrNoTLS)
+		}
+		if err := c.StartTLS(config); err != nil {
+			return fmt.Errorf("%s: STARTTLS: %w", m.Addr, err)
+		}
+	}
+	if m.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+			return fmt.Errorf("%s: auth: %w", m.Addr, err)
+		}
+	}
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("%s: MAIL FROM: %w", m.Addr, err)
+	}
+	for _, to := range m.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return err
+		}
+		if err := c.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("%s: RCPT TO %s: %w", m.Addr, addr.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("%s: DATA: %w", m.Addr, err)
+	}
+	if _, err := w.Write(m.Message(subject, body, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("%s: sending: %w", m.Addr, err)
+	}
+	return c.Quit()
+}
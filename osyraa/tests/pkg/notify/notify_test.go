@@ -0,0 +1,219 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// selfSigned is a certificate for 127.0.0.1 and a pool trusting it
+func selfSigned(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+// received is what the fake server saw
+type received struct {
+	auth, from string
+	to         []string
+	data       string
+	tls        bool
+}
+
+// fakeSMTP serves one session, offering STARTTLS when cert is set
+func fakeSMTP(t *testing.T, cert *tls.Certificate) (string, <-chan received) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	done := make(chan received, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var got received
+		r, w := bufio.NewReader(conn), conn
+		io.WriteString(w, "220 fake ESMTP\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				done <- got
+				return
+			}
+			cmd := strings.TrimSpace(line)
+			switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+			case "EHLO":
+				io.WriteString(w, "250-fake\r\n")
+				if cert != nil && !got.tls {
+					io.WriteString(w, "250-STARTTLS\r\n")
+				}
+				io.WriteString(w, "250 AUTH PLAIN\r\n")
+			case "STARTTLS":
+				io.WriteString(w, "220 go ahead\r\n")
+				tc := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{*cert}})
+				if tc.Handshake() != nil {
+					done <- got
+					return
+				}
+				r, w, got.tls = bufio.NewReader(tc), tc, true
+			case "AUTH":
+				creds, _ := base64.StdEncoding.DecodeString(strings.Fields(cmd)[2])
+				got.auth = string(creds)
+				io.WriteString(w, "235 ok\r\n")
+			case "MAIL":
+				got.from = cmd
+				io.WriteString(w, "250 ok\r\n")
+			case "RCPT":
+				got.to = append(got.to, cmd)
+				io.WriteString(w, "250 ok\r\n")
+			case "DATA":
+				io.WriteString(w, "354 go\r\n")
+				var data strings.Builder
+				for {
+					l, _ := r.ReadString('\n')
+					if l == ".\r\n" || l == "" {
+						break
+					}
+					data.WriteString(l)
+				}
+				got.data = data.String()
+				io.WriteString(w, "250 queued\r\n")
+			case "QUIT":
+				io.WriteString(w, "221 bye\r\n")
+				done <- got
+				return
+			default:
+				io.WriteString(w, "502 unknown\r\n")
+			}
+		}
+	}()
+	return ln.Addr().String(), done
+}
+
+func TestSend(t *testing.T) {
+	cert, pool := selfSigned(t)
+	addr, done := fakeSMTP(t, &cert)
+	m, err := NewMailer(addr, "Osyraa <osyraa@example.com>", []string{"ops@example.com", "Jane <jane@example.com>"})
+	require.NoError(t, err)
+	assert.False(t, m.ImplicitTLS)
+	m.Username, m.Password = "osyraa", "secret"
+	m.TLSConfig = &tls.Config{RootCAs: pool}
+
+	body := "2 checks failing on résumé\n" + strings.Repeat("long line ", 20) + "\n"
+	require.NoError(t, m.Send(context.Background(), "[osyraa] 2 failing ✗", body))
+	got := <-done
+	assert.True(t, got.tls, "mail goes over STARTTLS")
+	assert.Equal(t, "\x00osyraa\x00secret", got.auth)
+	assert.True(t, strings.HasPrefix(got.from, "MAIL FROM:<osyraa@example.com>"), got.from)
+	assert.Equal(t, []string{"RCPT TO:<ops@example.com>", "RCPT TO:<jane@example.com>"}, got.to)
+
+	msg, err := mail.ReadMessage(strings.NewReader(got.data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "[osyraa] 2 failing ✗", subject)
+	assert.Equal(t, "ops@example.com, Jane <jane@example.com>", msg.Header.Get("To"))
+	assert.Contains(t, msg.Header.Get("Message-ID"), "@example.com>")
+	decoded, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(body, "\n", "\r\n"), string(decoded))
+}
+
+func TestSendRequiresTLS(t *testing.T) {
+	addr, _ := fakeSMTP(t, nil)
+	m, err := NewMailer(addr, "osyraa@example.com", []string{"ops@example.com"})
+	require.NoError(t, err)
+	err = m.Send(context.Background(), "s", "b")
+	assert.ErrorIs(t, err, ErrNoTLS)
+}
+
+func TestImplicitTLS(t *testing.T) {
+	cert, pool := selfSigned(t)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	require.NoError(t, err)
+	defer ln.Close()
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		io.WriteString(conn, "220 fake\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				io.WriteString(conn, "250 fake\r\n")
+			case strings.HasPrefix(line, "DATA"):
+				io.WriteString(conn, "354 go\r\n")
+				for l, _ := r.ReadString('\n'); l != ".\r\n" && l != ""; l, _ = r.ReadString('\n') {
+				}
+				io.WriteString(conn, "250 ok\r\n")
+			case strings.HasPrefix(line, "QUIT"):
+				io.WriteString(conn, "221 bye\r\n")
+				return
+			default:
+				io.WriteString(conn, "250 ok\r\n")
+			}
+		}
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	m := &Mailer{Addr: ln.Addr().String(), From: "osyraa@example.com", To: []string{"ops@example.com"},
+		ImplicitTLS: true, TLSConfig: &tls.Config{RootCAs: pool}}
+	assert.NoError(t, m.Send(context.Background(), "s", "b"))
+
+	m.TLSConfig = nil
+	assert.Error(t, m.Send(context.Background(), "s", "b"), "an untrusted certificate is refused")
+}
+
+func TestNewMailer(t *testing.T) {
+	m, err := NewMailer("smtp.example.com:465", "osyraa@example.com", []string{"ops@example.com"})
+	require.NoError(t, err)
+	assert.True(t, m.ImplicitTLS)
+
+	_, err = NewMailer("smtp.example.com", "osyraa@example.com", []string{"ops@example.com"})
+	assert.Error(t, err, "the port is required")
+	_, err = NewMailer("smtp.example.com:587", "not an address", []string{"ops@example.com"})
+	assert.Error(t, err)
+	_, err = NewMailer("smtp.example.com:587", "osyraa@example.com", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,240 @@
+// Package parity checks the resume says the same thing in every format
+// the site publishes: the HTML page, the printed PDF and the JSON Resume
+// export. Each is reduced to its core content (name, sections, jobs,
+// schools, certifications, skills and projects) and compared with the
+// page, so one format cannot silently drift from the others.
+package parity
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/resume"
+)
+
+// Content is the core of the resume as one format presents it
+type Content struct {
+	Format         string
+	Name           string
+	Label          string
+	Sections       []string
+	Positions      []string
+	Employers      []string
+	Education      []string
+	Certifications []string
+	Skills         []string
+	Projects       []string
+	// Text is set for formats only readable as flat text, such as a PDF;
+	// entries are searched for in it instead of compared as lists
+	Text string
+}
+
+// FromHTML reads the content of the rendered home page: the name and
+// tagline from the header, the rest from the resume in <main>
+func FromHTML(doc *match.Document) (Content, error) {
+	r, err := resume.FromHTML(doc)
+	if err != nil {
+		return Content{}, err
+	}
+	c := FromResume("HTML", r)
+	c.Name = doc.Select("h1").Text()
+	c.Label = doc.Select(".tagline").Text()
+	return c, nil
+}
+
+// FromResume reduces a parsed resume to its core content
+func FromResume(format string, r *resume.Resume) Content {
+	c := Content{Format: format}
+	for _, s := range r.Sections {
+		c.Sections = append(c.Sections, s.Name)
+	}
+	for _, j := range r.Experience {
+		c.Positions = append(c.Positions, j.Title)
+		c.Employers = append(c.Employers, j.Company)
+	}
+	for _, e := range r.Education {
+		c.Education = append(c.Education, e.Credential)
+	}
+	for _, cert := range r.Certifications {
+		c.Certifications = append(c.Certifications, cert.Name)
+	}
+	for _, g := range r.Skills {
+		for _, s := range g.Skills {
+			c.Skills = append(c.Skills, s.Label)
+		}
+	}
+	for _, p := range r.Projects {
+		c.Projects = append(c.Projects, p.Name)
+	}
+	return c
+}
+
+// FromText wraps the extracted text of a format such as a PDF
+func FromText(format, text string) Content {
+	return Content{Format: format, Text: text}
+}
+
+// jsonResume is the part of the JSON Resume schema the site exports
+type jsonResume struct {
+	Basics struct {
+		Name    string `json:"name"`
+		Label   string `json:"label"`
+		Summary string `json:"summary"`
+	} `json:"basics"`
+	Work []struct {
+		Name     string `json:"name"`
+		Position string `json:"position"`
+	} `json:"work"`
+	Education []struct {
+		Institution string `json:"institution"`
+		StudyType   string `json:"studyType"`
+	} `json:"education"`
+	Certificates []struct {
+		Name string `json:"name"`
+	} `json:"certificates"`
+	Skills []struct {
+		Name string `json:"name"`
+	} `json:"skills"`
+	Projects []struct {
+		Name string `json:"name"`
+	} `json:"projects"`
+}
+
+// FromJSONResume reads a JSON Resume document. It has no headings, so a
+// section counts as present when its part of the document is non-empty.
+func FromJSONResume(data []byte) (Content, error) {
+	var j jsonResume
+	if err := json.Unmarshal(data, &j); err != nil {
+		return Content{}, fmt.Errorf("parse JSON Resume: %w", err)
+	}
+	c := Content{Format: "JSON Resume", Name: j.Basics.Name, Label: j.Basics.Label}
+	section := func(name string, present bool) {
+		if present {
+			c.Sections = append(c.Sections, name)
+		}
+	}
+	section(resume.SectionSummary, j.Basics.Summary != "")
+	section(resume.SectionExperience, len(j.Work) > 0)
+	section(resume.SectionEducation, len(j.Education) > 0)
+	section(resume.SectionCertifications, len(j.Certificates) > 0)
+	section(resume.SectionSkills, len(j.Skills) > 0)
+	section(resume.SectionProjects, len(j.Projects) > 0)
+	for _, w := range j.Work {
+		c.Positions = append(c.Positions, w.Position)
+		c.Employers = append(c.Employers, w.Name)
+	}
+	for _, e := range j.Education {
+		c.Education = append(c.Education, e.StudyType)
+	}
+	for _, cert := range j.Certificates {
+		c.Certifications = append(c.Certifications, cert.Name)
+	}
+	for _, s := range j.Skills {
+		c.Skills = append(c.Skills, s.Name)
+	}
+	for _, p := range j.Projects {
+		c.Projects = append(c.Projects, p.Name)
+	}
+	return c, nil
+}
+
+// fields are the parts of Content compared, with the name each is
+// reported under
+var fields = []struct {
+	kind string
+	get  func(Content) []string
+}{
+	{"name", func(c Content) []string { return nonEmpty(c.Name) }},
+	{"tagline", func(c Content) []string { return nonEmpty(c.Label) }},
+	{"section", func(c Content) []string { return c.Sections }},
+	{"position", func(c Content) []string { return c.Positions }},
+	{"employer", func(c Content) []string { return c.Employers }},
+	{"education", func(c Content) []string { return c.Education }},
+	{"certification", func(c Content) []string { return c.Certifications }},
+	{"skill", func(c Content) []string { return c.Skills }},
+	{"project", func(c Content) []string { return c.Projects }},
+}
+
+func nonEmpty(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// Compare reports where each of the other formats differs from want:
+// entries it lacks, and for structured formats entries want lacks
+func Compare(want Content, others ...Content) []string {
+	var problems []string
+	for _, got := range others {
+		text := squash(got.Text)
+		for _, f := range fields {
+			if got.Text != "" {
+				for _, item := range f.get(want) {
+					if !strings.Contains(text, squash(item)) {
+						problems = append(problems, fmt.Sprintf("%s %q is in %s but not %s", f.kind, key(item), want.Format, got.Format))
+					}
+				}
+				continue
+			}
+			missing, extra := diff(f.get(want), f.get(got))
+			for _, item := range missing {
+				problems = append(problems, fmt.Sprintf("%s %q is in %s but not %s", f.kind, item, want.Format, got.Format))
+			}
+			for _, item := range extra {
+				problems = append(problems, fmt.Sprintf("%s %q is in %s but not %s", f.kind, item, got.Format, want.Format))
+			}
+		}
+	}
+	return problems
+}
+
+// diff returns the keys of want missing from got and of got missing from
+// want, in their original order
+func diff(want, got []string) (missing, extra []string) {
+	count := map[string]int{}
+	for _, g := range got {
+		count[key(g)]++
+	}
+	for _, w := range want {
+		if k := key(w); count[k] > 0 {
+			count[k]--
+		} else {
+			missing = append(missing, k)
+		}
+	}
+	for _, g := range got {
+		if k := key(g); count[k] > 0 {
+			count[k]--
+			extra = append(extra, k)
+		}
+	}
+	return missing, extra
+}
+
+// typographer undoes the substitutions Markdown rendering makes, and
+// Markdown emphasis left in raw text
+var typographer = strings.NewReplacer(
+	"**", "", "__", "", "`", "",
+	"‘", "'", "’", "'", "“", `"`, "”", `"`,
+	"–", "-", "—", "-", "…", "...", " ", " ",
+)
+
+// key is the comparable form of an entry
+func key(s string) string {
+	return match.Normalize(typographer.Replace(s))
+}
+
+// squash is key without case or whitespace, since text pulled from a PDF
+// wraps lines anywhere and often loses the spaces between words
+func squash(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, typographer.Replace(s))
+}
@@ -0,0 +1,75 @@
+package parity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+)
+
+const page = `<!doctype html><html><body><header><h1>Jane Doe</h1><p class=tagline>Platform Engineer</p></header><main>` +
+	`<h2>Professional Summary</h2><p>Builds platforms.</p>` +
+	`<h2>Experience</h2><h3>Staff Engineer</h3><p><strong>Acme Corp</strong> | March 2021 - Present | Remote</p><ul><li>Built things</li></ul>` +
+	`<h2>Education</h2><p><strong>B.Sc. Computer Science</strong> | State University</p>` +
+	`<h2>Certifications</h2><ul><li><strong>Certified Kubernetes Administrator</strong> (CKA)</li></ul>` +
+	`<h2>Skills</h2><h3>Cloud</h3><ul><li><strong>AWS</strong>: EC2, S3</li><li><strong>Let’s Encrypt</strong>: cert-manager</li></ul>` +
+	`<h2>Projects</h2><h3>Site</h3><p>A static site.</p>` +
+	`</main></body></html>`
+
+const export = `{
+  "basics": {"name": "Jane Doe", "label": "Platform Engineer", "summary": "Builds platforms."},
+  "work": [{"name": "Acme Corp", "position": "Staff Engineer", "startDate": "2021-03"}],
+  "education": [{"studyType": "B.Sc. Computer Science", "institution": "State University"}],
+  "certificates": [{"name": "Certified Kubernetes Administrator"}],
+  "skills": [{"name": "AWS", "keywords": ["EC2", "S3"]}, {"name": "Let's Encrypt"}],
+  "projects": [{"name": "Site"}]
+}`
+
+func html(t *testing.T) Content {
+	t.Helper()
+	doc, err := match.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+	c, err := FromHTML(doc)
+	require.NoError(t, err)
+	return c
+}
+
+func TestFromHTML(t *testing.T) {
+	c := html(t)
+	assert.Equal(t, "Jane Doe", c.Name)
+	assert.Equal(t, "Platform Engineer", c.Label)
+	assert.Equal(t, []string{"Professional Summary", "Experience", "Education", "Certifications", "Skills", "Projects"}, c.Sections)
+	assert.Equal(t, []string{"Staff Engineer"}, c.Positions)
+	assert.Equal(t, []string{"Acme Corp"}, c.Employers)
+	assert.Equal(t, []string{"AWS", "Let’s Encrypt"}, c.Skills)
+}
+
+func TestCompareJSONResume(t *testing.T) {
+	j, err := FromJSONResume([]byte(export))
+	require.NoError(t, err)
+	assert.Empty(t, Compare(html(t), j), "the export matches the page, curly quotes aside")
+
+	drifted := strings.NewReplacer(`"Acme Corp"`, `"Acme Inc"`, `{"name": "Site"}`, ``, `"summary": "Builds platforms."`, `"summary": ""`).Replace(export)
+	j, err = FromJSONResume([]byte(drifted))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`section "Professional Summary" is in HTML but not JSON Resume`,
+		`section "Projects" is in HTML but not JSON Resume`,
+		`employer "Acme Corp" is in HTML but not JSON Resume`,
+		`employer "Acme Inc" is in JSON Resume but not HTML`,
+		`project "Site" is in HTML but not JSON Resume`,
+	}, Compare(html(t), j))
+
+	_, err = FromJSONResume([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestCompareText(t *testing.T) {
+	// PDF text extraction often runs words together and wraps anywhere
+	text := "JaneDoe\nPlatform Engineer\nProfessional Summary Builds platforms. Experience Staff\nEngineer ACME CORP " +
+		"Education B.Sc. Computer Science Certifications Certified Kubernetes Administrator Skills AWS Let's Encrypt Projects"
+	assert.Equal(t, []string{`project "Site" is in HTML but not PDF`}, Compare(html(t), FromText("PDF", text)))
+}
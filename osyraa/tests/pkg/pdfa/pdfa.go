@@ -0,0 +1,190 @@
+// Package pdfa checks a PDF against the PDF/A-2b (ISO 19005-2, level B)
+// requirements that matter for archiving a resume and feeding it to
+// applicant tracking systems: no encryption, every font embedded, XMP
+// metadata with the PDF/A identification, an output intent, and no
+// JavaScript or foreign attachments. It is not a full conformance
+// validator such as veraPDF; it catches the ways a printed resume
+// usually falls short.
+package pdfa
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Requirements a problem falls under
+const (
+	FileStructure  = "file structure"
+	Encryption     = "encryption"
+	Fonts          = "fonts"
+	Metadata       = "metadata"
+	Identification = "identification"
+	OutputIntent   = "output intent"
+	Actions        = "actions"
+	Attachments    = "attachments"
+)
+
+// Problem is a PDF/A-2b requirement the file does not meet
+type Problem struct {
+	Requirement string
+	Message     string
+}
+
+func (p Problem) String() string {
+	return p.Requirement + ": " + p.Message
+}
+
+var (
+	partRe        = regexp.MustCompile(`pdfaid:part(?:="|>)\s*(\d)`)
+	conformanceRe = regexp.MustCompile(`pdfaid:conformance(?:="|>)\s*([A-Za-z])`)
+	titleRe       = regexp.MustCompile(`(?s)<dc:title>.*?<rdf:li[^>]*>\s*\S`)
+)
+
+// Check reads a PDF and returns the PDF/A-2b requirements it misses. An
+// error means the file could not be read at all.
+func Check(data []byte) ([]Problem, error) {
+	var problems []Problem
+	add := func(req, format string, args ...any) {
+		problems = append(problems, Problem{Requirement: req, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// The header must be followed by a comment of at least four bytes
+	// above 127, marking the file as binary (6.1.2)
+	if _, rest, ok := bytes.Cut(data, []byte("\n")); !ok || !binaryComment(rest) {
+		add(FileStructure, "the header is not followed by a binary comment")
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if errors.Is(err, pdf.ErrInvalidPassword) {
+		add(Encryption, "the file is encrypted with a password")
+		return problems, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read PDF: %w", err)
+	}
+	trailer := r.Trailer()
+	if !trailer.Key("Encrypt").IsNull() {
+		add(Encryption, "the file is encrypted")
+	}
+	if trailer.Key("ID").Len() != 2 {
+		add(FileStructure, "the trailer has no file identifier (ID)")
+	}
+	root := trailer.Key("Root")
+
+	for _, name := range unembeddedFonts(r) {
+		add(Fonts, "font %s is not embedded", name)
+	}
+
+	xmp := readAll(root.Key("Metadata"))
+	if len(xmp) == 0 {
+		add(Identification, "the catalog has no XMP metadata stream to declare PDF/A in")
+	} else {
+		part, conformance := partRe.FindSubmatch(xmp), conformanceRe.FindSubmatch(xmp)
+		switch {
+		case part == nil || conformance == nil:
+			add(Identification, "the XMP metadata does not declare pdfaid:part and pdfaid:conformance")
+		case string(part[1]) != "2":
+			add(Identification, "the file declares PDF/A-%s, not PDF/A-2", part[1])
+		case !bytes.ContainsAny(conformance[1], "ABUabu"):
+			add(Identification, "conformance level %q is not A, B or U", conformance[1])
+		}
+	}
+	if !titleRe.Match(xmp) && trailer.Key("Info").Key("Title").Text() == "" {
+		add(Metadata, "the document has no title")
+	}
+
+	intents := root.Key("OutputIntents")
+	found := false
+	for i := 0; i < intents.Len(); i++ {
+		intent := intents.Index(i)
+		if intent.Key("S").Name() == "GTS_PDFA1" && !intent.Key("DestOutputProfile").IsNull() {
+			found = true
+		}
+	}
+	if !found {
+		add(OutputIntent, "no GTS_PDFA1 output intent with an ICC profile")
+	}
+
+	if !root.Key("Names").Key("JavaScript").IsNull() {
+		add(Actions, "the document has JavaScript")
+	}
+	if s := root.Key("OpenAction").Key("S").Name(); s == "JavaScript" || s == "Launch" {
+		add(Actions, "the document opens with a %s action", s)
+	}
+	if !root.Key("Names").Key("EmbeddedFiles").IsNull() {
+		add(Attachments, "the document has embedded files, which PDF/A-2 only allows when they are PDF/A themselves")
+	}
+	return problems, nil
+}
+
+func binaryComment(line []byte) bool {
+	if len(line) < 5 || line[0] != '%' {
+		return false
+	}
+	for _, b := range line[1:5] {
+		if b < 128 {
+			return false
+		}
+	}
+	return true
+}
+
+func readAll(stream pdf.Value) []byte {
+	if stream.Kind() != pdf.Stream {
+		return nil
+	}
+	rc := stream.Reader()
+	defer rc.Close()
+	b, _ := io.ReadAll(rc)
+	return b
+}
+
+// unembeddedFonts returns the names of fonts used on any page, or in the
+// forms they draw, that lack an embedded font program (6.2.11.4)
+func unembeddedFonts(r *pdf.Reader) []string {
+	missing := map[string]bool{}
+	var walk func(resources pdf.Value, depth int)
+	walk = func(resources pdf.Value, depth int) {
+		fonts := resources.Key("Font")
+		for _, key := range fonts.Keys() {
+			font := fonts.Key(key)
+			if !embedded(font) {
+				missing[font.Key("BaseFont").Name()] = true
+			}
+		}
+		// Forms can nest; a bound guards against reference cycles
+		xobjects := resources.Key("XObject")
+		for _, key := range xobjects.Keys() {
+			if x := xobjects.Key(key); x.Key("Subtype").Name() == "Form" && depth < 8 {
+				walk(x.Key("Resources"), depth+1)
+			}
+		}
+	}
+	for i := 1; i <= r.NumPage(); i++ {
+		walk(r.Page(i).Resources(), 0)
+	}
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func embedded(font pdf.Value) bool {
+	switch font.Key("Subtype").Name() {
+	case "Type3":
+		// Type 3 glyphs are drawn by content streams in the font itself
+		return true
+	case "Type0":
+		font = font.Key("DescendantFonts").Index(0)
+	}
+	d := font.Key("FontDescriptor")
+	return !d.Key("FontFile").IsNull() || !d.Key("FontFile2").IsNull() || !d.Key("FontFile3").IsNull()
+}
@@ -0,0 +1,91 @@
+package pdfa
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// build writes objs, numbered from 1 with the catalog first, as a PDF
+// with the given header lines and extra trailer entries
+func build(header string, objs []string, trailer string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R %s >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, trailer, xref)
+	return buf.Bytes()
+}
+
+func stream(data string) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(data), data)
+}
+
+const xmp = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" pdfaid:part="2" pdfaid:conformance="B"/>
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title><rdf:Alt><rdf:li xml:lang="x-default">Resume</rdf:li></rdf:Alt></dc:title></rdf:Description>
+</rdf:RDF></x:xmpmeta>`
+
+func TestCheckPasses(t *testing.T) {
+	data := build("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n", []string{
+		"<< /Type /Catalog /Pages 2 0 R /Metadata 3 0 R /OutputIntents [<< /Type /OutputIntent /S /GTS_PDFA1 /DestOutputProfile 4 0 R >>] >>",
+		"<< /Type /Pages /Kids [5 0 R] /Count 1 >>",
+		stream(xmp),
+		stream("icc profile"),
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 6 0 R /F2 8 0 R >> >> >>",
+		"<< /Type /Font /Subtype /TrueType /BaseFont /ABCDEF+Inter /FontDescriptor 7 0 R >>",
+		"<< /Type /FontDescriptor /FontName /ABCDEF+Inter /FontFile2 9 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /GHIJKL+Inter /DescendantFonts [<< /Type /Font /Subtype /CIDFontType2 /FontDescriptor 7 0 R >>] >>",
+		stream("font program"),
+	}, "/ID [<01> <01>]")
+
+	problems, err := Check(data)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestCheckReports(t *testing.T) {
+	data := build("%PDF-1.4\n", []string{
+		"<< /Type /Catalog /Pages 2 0 R /Names << /JavaScript 5 0 R /EmbeddedFiles 5 0 R >> /OpenAction << /S /JavaScript /JS (app.alert(1)) >> /Metadata 6 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> /XObject << /X1 7 0 R >> >> >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Names [] >>",
+		stream(`<x:xmpmeta><rdf:Description pdfaid:part="1" pdfaid:conformance="B"/></x:xmpmeta>`),
+		"<< /Type /XObject /Subtype /Form /Length 0 /Resources << /Font << /F9 8 0 R >> >> >>\nstream\n\nendstream",
+		"<< /Type /Font /Subtype /TrueType /BaseFont /Arial /FontDescriptor << /FontName /Arial >> >>",
+	}, "")
+
+	problems, err := Check(data)
+	require.NoError(t, err)
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		"file structure: the header is not followed by a binary comment",
+		"file structure: the trailer has no file identifier (ID)",
+		"fonts: font Arial is not embedded",
+		"fonts: font Helvetica is not embedded",
+		"identification: the file declares PDF/A-1, not PDF/A-2",
+		"metadata: the document has no title",
+		"output intent: no GTS_PDFA1 output intent with an ICC profile",
+		"actions: the document has JavaScript",
+		"actions: the document opens with a JavaScript action",
+		"attachments: the document has embedded files, which PDF/A-2 only allows when they are PDF/A themselves",
+	}, got)
+
+	_, err = Check([]byte("not a pdf"))
+	assert.Error(t, err)
+}
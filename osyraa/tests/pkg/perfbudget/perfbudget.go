@@ -0,0 +1,306 @@
+// Package perfbudget holds page loads to Lighthouse-style performance
+// budgets: Largest Contentful Paint, Total Blocking Time, Cumulative
+// Layout Shift, page weight and request count. Budgets are read from a
+// YAML file and measured from a Chrome performance trace, and every
+// exceeded budget is reported with the amount it was exceeded by.
+package perfbudget
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/browser"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"gopkg.in/yaml.v3"
+)
+
+// Metrics
+const (
+	LCP        = "lcp"
+	TBT        = "tbt"
+	CLS        = "cls"
+	PageWeight = "page-weight"
+	Requests   = "requests"
+)
+
+// Budget limits the pages whose path matches Path. Zero leaves a metric
+// unbudgeted.
+type Budget struct {
+	// Path is a glob as in crawl scopes: /posts/** or / for every page
+	Path       string        `yaml:"path"`
+	LCP        time.Duration `yaml:"lcp"`
+	TBT        time.Duration `yaml:"tbt"`
+	CLS        float64       `yaml:"cls"`
+	PageWeight Size          `yaml:"page-weight"`
+	Requests   int           `yaml:"requests"`
+
+	pattern crawl.Pattern
+}
+
+// File is a budgets file
+type File struct {
+	Budgets []Budget `yaml:"budgets"`
+}
+
+// Size is a byte count, written in YAML as a number of bytes or with a
+// unit: 500KB, 1.5MB, 512KiB
+type Size int64
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"KB", 1e3}, {"MB", 1e6}, {"B", 1},
+}
+
+// ParseSize reads a byte count with an optional unit
+func ParseSize(s string) (Size, error) {
+	raw := s
+	s = strings.TrimSpace(s)
+	scale := 1.0
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, scale = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("size %q should be a byte count such as 500KB or 1.5MB", raw)
+	}
+	return Size(n * scale), nil
+}
+
+// UnmarshalYAML accepts a plain number of bytes or a size with a unit
+func (s *Size) UnmarshalYAML(node *yaml.Node) error {
+	size, err := ParseSize(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*s = size
+	return nil
+}
+
+// String formats the size in the largest unit that keeps it above one
+func (s Size) String() string {
+	switch {
+	case s >= 1<<20:
+		return fmt.Sprintf("%.1fMiB", float64(s)/(1<<20))
+	case s >= 1<<10:
+		return fmt.Sprintf("%.1fKiB", float64(s)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", int64(s))
+}
+
+// Parse decodes a budgets file, rejecting unknown keys so a misspelt
+// metric is an error rather than an unenforced budget
+func Parse(data []byte) (*File, error) {
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for i := range f.Budgets {
+		b := &f.Budgets[i]
+		if b.Path == "" {
+			b.Path = "/"
+		}
+		var err error
+		if b.pattern, err = crawl.CompilePattern(b.Path); err != nil {
+			return nil, fmt.Errorf("budgets[%d]: %w", i, err)
+		}
+		if b.LCP < 0 || b.TBT < 0 || b.CLS < 0 || b.PageWeight < 0 || b.Requests < 0 {
+			return nil, fmt.Errorf("budgets[%d] (%s): budgets must not be negative", i, b.Path)
+		}
+	}
+	return &f, nil
+}
+
+// Load reads and parses the budgets file at path
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// For returns the budget for a page path. Every entry whose Path matches
+// applies in file order, so a later, narrower entry overrides the limits
+// it sets and inherits the rest. ok is false when no entry matches.
+func (f *File) For(pagePath string) (b Budget, ok bool) {
+	for _, entry := range f.Budgets {
+		if !entry.pattern.Match(pagePath) {
+			continue
+		}
+		ok = true
+		b.Path = entry.Path
+		if entry.LCP > 0 {
+			b.LCP = entry.LCP
+		}
+		if entry.TBT > 0 {
+			b.TBT = entry.TBT
+		}
+		if entry.CLS > 0 {
+			b.CLS = entry.CLS
+		}
+		if entry.PageWeight > 0 {
+			b.PageWeight = entry.PageWeight
+		}
+		if entry.Requests > 0 {
+			b.Requests = entry.Requests
+		}
+	}
+	return b, ok
+}
+
+// Metrics are what a page load measured
+type Metrics struct {
+	LCP time.Duration
+	// NoLCP is set when the trace holds no LCP candidate: the page painted
+	// none, or Chrome invalidated the last. An LCP budget then fails
+	// rather than passing on a zero LCP.
+	NoLCP      bool
+	TBT        time.Duration
+	CLS        float64
+	PageWeight Size
+	Requests   int
+}
+
+// Measure reads the budgeted metrics from a page load's trace. Page weight
+// is the bytes transferred, compressed as sent.
+func Measure(t *browser.Trace) Metrics {
+	var m Metrics
+	var ok bool
+	m.LCP, ok = t.LCP()
+	m.NoLCP = !ok
+	m.TBT = browser.TotalBlockingTime(t.LongTasks())
+	m.CLS = browser.CLS(t.LayoutShifts())
+	for _, r := range t.Waterfall() {
+		m.PageWeight += Size(r.Size)
+		m.Requests++
+	}
+	return m
+}
+
+// subresources are the selectors and attributes of what a page loads
+// along with it; anchors and links such as canonical load nothing
+var subresources = []struct{ selector, attr string }{
+	{"img[src]", "src"}, {"img[srcset]", "srcset"}, {"source[src]", "src"}, {"source[srcset]", "srcset"},
+	{"script[src]", "src"}, {"video[src]", "src"}, {"video[poster]", "poster"}, {"audio[src]", "src"},
+	{"iframe[src]", "src"}, {`link[rel~="stylesheet"][href]`, "href"}, {`link[rel~="icon"][href]`, "href"},
+}
+
+// Weigh measures a page of a crawled build without a browser: its page
+// weight is the uncompressed size of the page and every distinct
+// subresource it loads from the build, and each counts as a request.
+// Subresources on other hosts count as requests of unknown weight.
+func Weigh(site *crawl.Result, page *crawl.Page) Metrics {
+	m := Metrics{PageWeight: Size(len(page.Body)), Requests: 1}
+	if page.Doc == nil {
+		return m
+	}
+	doc := match.FromNode(page.Doc)
+	seen := map[string]bool{}
+	for _, r := range subresources {
+		for _, n := range doc.Select(r.selector).Nodes() {
+			val, _ := match.Attr(n, r.attr)
+			for _, ref := range refs(r.attr, val) {
+				u, err := page.URL.Parse(ref)
+				if err != nil || u.Scheme == "data" || seen[u.String()] {
+					continue
+				}
+				seen[u.String()] = true
+				m.Requests++
+				if u.Host != page.URL.Host {
+					continue
+				}
+				if loaded := site.Page(u.Path); loaded != nil {
+					m.PageWeight += Size(len(loaded.Body))
+				}
+			}
+		}
+	}
+	return m
+}
+
+// refs splits a srcset into its URLs; other attributes hold one
+func refs(attr, val string) []string {
+	if attr != "srcset" {
+		return []string{strings.TrimSpace(val)}
+	}
+	var out []string
+	for _, candidate := range strings.Split(val, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			out = append(out, fields[0])
+		}
+	}
+	return out
+}
+
+// Violation is a budget a page load exceeded, or could not be held to
+type Violation struct {
+	Metric string
+	// Budget and Actual are formatted in the metric's unit
+	Budget, Actual string
+	// Over is how much Actual exceeds Budget, in the same unit
+	Over string
+	// Percent is Over as a percentage of Budget
+	Percent float64
+	// Unmeasured says why the metric has no value to compare, in which
+	// case Actual, Over and Percent are unset
+	Unmeasured string
+}
+
+func (v Violation) String() string {
+	if v.Unmeasured != "" {
+		return fmt.Sprintf("%s has no value to hold to the %s budget: %s", v.Metric, v.Budget, v.Unmeasured)
+	}
+	return fmt.Sprintf("%s %s exceeds the %s budget by %s (%.0f%%)", v.Metric, v.Actual, v.Budget, v.Over, v.Percent)
+}
+
+// Check compares a page load's metrics with the budget
+func (b Budget) Check(m Metrics) []Violation {
+	var out []Violation
+	duration := func(metric string, limit, actual time.Duration) {
+		if limit > 0 && actual > limit {
+			out = append(out, Violation{Metric: metric, Budget: limit.String(), Actual: actual.Round(time.Millisecond).String(),
+				Over: (actual - limit).Round(time.Millisecond).String(), Percent: percent(float64(actual), float64(limit))})
+		}
+	}
+	if b.LCP > 0 && m.NoLCP {
+		out = append(out, Violation{Metric: LCP, Budget: b.LCP.String(), Unmeasured: "the trace has no LCP candidate"})
+	} else {
+		duration(LCP, b.LCP, m.LCP)
+	}
+	duration(TBT, b.TBT, m.TBT)
+	if b.CLS > 0 && m.CLS > b.CLS {
+		out = append(out, Violation{Metric: CLS, Budget: fmt.Sprintf("%.3f", b.CLS), Actual: fmt.Sprintf("%.3f", m.CLS),
+			Over: fmt.Sprintf("%.3f", m.CLS-b.CLS), Percent: percent(m.CLS, b.CLS)})
+	}
+	if b.PageWeight > 0 && m.PageWeight > b.PageWeight {
+		out = append(out, Violation{Metric: PageWeight, Budget: b.PageWeight.String(), Actual: m.PageWeight.String(),
+			Over: (m.PageWeight - b.PageWeight).String(), Percent: percent(float64(m.PageWeight), float64(b.PageWeight))})
+	}
+	if b.Requests > 0 && m.Requests > b.Requests {
+		out = append(out, Violation{Metric: Requests, Budget: strconv.Itoa(b.Requests), Actual: strconv.Itoa(m.Requests),
+			Over: strconv.Itoa(m.Requests - b.Requests), Percent: percent(float64(m.Requests), float64(b.Requests))})
+	}
+	return out
+}
+
+func percent(actual, limit float64) float64 {
+	return (actual - limit) / limit * 100
+}
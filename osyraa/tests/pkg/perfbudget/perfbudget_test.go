@@ -0,0 +1,149 @@
+package perfbudget
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/browser"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const budgets = `
+budgets:
+  - path: /
+    lcp: 2.5s
+    tbt: 200ms
+    cls: 0.1
+    page-weight: 1MiB
+    requests: 40
+  - path: /posts/**
+    page-weight: 2MB
+`
+
+func TestFor(t *testing.T) {
+	f, err := Parse([]byte(budgets))
+	require.NoError(t, err)
+
+	home, ok := f.For("/")
+	require.True(t, ok)
+	assert.Equal(t, 2500*time.Millisecond, home.LCP)
+	assert.Equal(t, Size(1<<20), home.PageWeight)
+
+	post, ok := f.For("/posts/hello/")
+	require.True(t, ok)
+	assert.Equal(t, Size(2e6), post.PageWeight, "a later entry overrides the limits it sets")
+	assert.Equal(t, 40, post.Requests, "and inherits the rest")
+
+	none, err := Parse([]byte("budgets:\n  - path: /posts/\n    requests: 10\n"))
+	require.NoError(t, err)
+	_, ok = none.For("/")
+	assert.False(t, ok)
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown metric": "budgets:\n  - path: /\n    fcp: 1s\n",
+		"bad size":       "budgets:\n  - page-weight: lots\n",
+		"bad path":       "budgets:\n  - path: posts\n",
+		"negative":       "budgets:\n  - requests: -1\n",
+	} {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, name)
+	}
+	f, err := Parse(nil)
+	require.NoError(t, err, "an empty file sets no budgets")
+	assert.Empty(t, f.Budgets)
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]Size{"512": 512, "500KB": 500e3, "1.5MB": 1.5e6, "512KiB": 512 << 10, "2 MiB": 2 << 20, "10B": 10} {
+		got, err := ParseSize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	assert.Equal(t, "1.5MiB", Size(3<<19).String())
+	assert.Equal(t, "2.0KiB", Size(2048).String())
+	assert.Equal(t, "100B", Size(100).String())
+}
+
+func TestCheck(t *testing.T) {
+	b := Budget{LCP: 2500 * time.Millisecond, TBT: 200 * time.Millisecond, CLS: 0.1, PageWeight: 1 << 20, Requests: 40}
+	assert.Empty(t, b.Check(Metrics{LCP: time.Second, CLS: 0.05, PageWeight: 1 << 19, Requests: 12}))
+
+	violations := b.Check(Metrics{LCP: 3100 * time.Millisecond, TBT: 150 * time.Millisecond, CLS: 0.25, PageWeight: 3 << 19, Requests: 50})
+	require.Len(t, violations, 4)
+	assert.Equal(t, "lcp 3.1s exceeds the 2.5s budget by 600ms (24%)", violations[0].String())
+	assert.Equal(t, "cls 0.250 exceeds the 0.100 budget by 0.150 (150%)", violations[1].String())
+	assert.Equal(t, "page-weight 1.5MiB exceeds the 1.0MiB budget by 512.0KiB (50%)", violations[2].String())
+	assert.Equal(t, "requests 50 exceeds the 40 budget by 10 (25%)", violations[3].String())
+
+	assert.Empty(t, Budget{}.Check(Metrics{LCP: time.Hour, Requests: 1000}), "zero budgets are not enforced")
+
+	violations = b.Check(Metrics{NoLCP: true, Requests: 12})
+	require.Len(t, violations, 1, "a missing LCP does not pass the LCP budget")
+	assert.Equal(t, "lcp has no value to hold to the 2.5s budget: the trace has no LCP candidate", violations[0].String())
+	assert.Empty(t, Budget{Requests: 40}.Check(Metrics{NoLCP: true, Requests: 12}), "nor fails without one")
+}
+
+func TestMeasure(t *testing.T) {
+	tr, err := browser.ParseTrace([]byte(`{"traceEvents":[
+{"name":"thread_name","ph":"M","pid":1,"tid":1,"ts":0,"args":{"name":"CrRendererMain"}},
+{"name":"navigationStart","ph":"R","pid":1,"tid":1,"ts":1000000,"args":{}},
+{"name":"ResourceSendRequest","ph":"I","pid":1,"tid":1,"ts":1001000,"args":{"data":{"requestId":"1","url":"https://example.com/"}}},
+{"name":"ResourceFinish","ph":"I","pid":1,"tid":1,"ts":1050000,"args":{"data":{"requestId":"1","encodedDataLength":4096}}},
+{"name":"ResourceSendRequest","ph":"I","pid":1,"tid":1,"ts":1060000,"args":{"data":{"requestId":"2","url":"https://example.com/app.js"}}},
+{"name":"ResourceFinish","ph":"I","pid":1,"tid":1,"ts":1090000,"args":{"data":{"requestId":"2","encodedDataLength":1024}}},
+{"name":"RunTask","ph":"X","pid":1,"tid":1,"ts":1100000,"dur":150000,"args":{}},
+{"name":"largestContentfulPaint::Candidate","ph":"R","pid":1,"tid":1,"ts":1800000,"args":{}},
+{"name":"LayoutShift","ph":"I","pid":1,"tid":1,"ts":1300000,"args":{"data":{"score":0.02}}}
+]}`))
+	require.NoError(t, err)
+	assert.Equal(t, Metrics{LCP: 800 * time.Millisecond, TBT: 100 * time.Millisecond, CLS: 0.02, PageWeight: 5120, Requests: 2}, Measure(tr))
+
+	tr.Events = slices.DeleteFunc(tr.Events, func(ev browser.TraceEvent) bool { return ev.Name == "largestContentfulPaint::Candidate" })
+	m := Measure(tr)
+	assert.True(t, m.NoLCP)
+	assert.Zero(t, m.LCP)
+}
+
+func TestWeigh(t *testing.T) {
+	f, err := Parse([]byte(budgets))
+	require.NoError(t, err)
+	budget, _ := f.For("/")
+
+	weigh := func(defect fixture.Defect) Metrics {
+		s := fixture.New(t, defect)
+		site, err := crawl.Dir(context.Background(), s.Public)
+		require.NoError(t, err)
+		return Weigh(site, site.Page("/"))
+	}
+	file := func(s *fixture.Site, name string) Size {
+		info, err := os.Stat(filepath.Join(s.Public, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		return Size(info.Size())
+	}
+
+	valid := weigh(fixture.Valid)
+	s := fixture.New(t, fixture.Valid)
+	assert.Equal(t, 3, valid.Requests, "the page, its stylesheet and its photo")
+	assert.Equal(t, file(s, "index.html")+file(s, "css/site.css")+file(s, "img/photo.png"), valid.PageWeight)
+	assert.Empty(t, budget.Check(valid))
+
+	huge := weigh(fixture.HugeImages)
+	assert.Equal(t, 4, huge.Requests)
+	violations := budget.Check(huge)
+	require.Len(t, violations, 1)
+	assert.Equal(t, PageWeight, violations[0].Metric)
+
+	broken := weigh(fixture.BrokenLinks)
+	assert.Equal(t, 5, broken.Requests, "missing subresources are still requested")
+	b := fixture.New(t, fixture.BrokenLinks)
+	assert.Equal(t, file(b, "index.html")+file(b, "css/site.css")+file(b, "img/photo.png"), broken.PageWeight, "but weigh nothing")
+}
@@ -0,0 +1,222 @@
+// Package platform names the OS and CPU architectures images are built for
+// and works out which of them the engine can run, natively or under QEMU
+// user-mode emulation registered with binfmt_misc.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Platform is an image platform such as linux/arm64 or linux/arm/v7
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// Parse reads an os/arch[/variant] platform, normalising architecture
+// aliases such as x86_64 and aarch64 to Go's names
+func Parse(s string) (Platform, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("platform %q should be os/arch[/variant], e.g. linux/arm64", s)
+	}
+	p := Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p.normalize(), nil
+}
+
+// ParseList reads comma-separated platforms
+func ParseList(s string) ([]Platform, error) {
+	var out []Platform
+	for _, field := range strings.Split(s, ",") {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		p, err := Parse(field)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (p Platform) normalize() Platform {
+	switch p.Architecture {
+	case "x86_64", "x86-64":
+		p.Architecture = "amd64"
+	case "aarch64":
+		p.Architecture = "arm64"
+	case "i386", "i686":
+		p.Architecture = "386"
+	case "armhf", "armv7l":
+		p.Architecture, p.Variant = "arm", "v7"
+	case "armel", "armv6l":
+		p.Architecture, p.Variant = "arm", "v6"
+	}
+	// arm64 images are v8; naming the variant doesn't make them different
+	if p.Architecture == "arm64" && p.Variant == "v8" {
+		p.Variant = ""
+	}
+	return p
+}
+
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// Tag is the platform as a tag suffix: amd64, arm64, armv7
+func (p Platform) Tag() string {
+	return p.Architecture + p.Variant
+}
+
+// OCI is the platform as the engine's API takes it
+func (p Platform) OCI() *ocispec.Platform {
+	return &ocispec.Platform{OS: p.OS, Architecture: p.Architecture, Variant: p.Variant}
+}
+
+// Machine is what uname -m prints in a container of the platform
+func (p Platform) Machine() string {
+	switch p.Architecture {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "i686"
+	case "arm":
+		if p.Variant == "v6" {
+			return "armv6l"
+		}
+		return "armv7l"
+	}
+	return p.Architecture
+}
+
+// qemuName is the binfmt_misc entry QEMU registers for the platform
+func (p Platform) qemuName() string {
+	switch p.Architecture {
+	case "amd64":
+		return "qemu-x86_64"
+	case "arm64":
+		return "qemu-aarch64"
+	case "386":
+		return "qemu-i386"
+	case "arm":
+		return "qemu-arm"
+	}
+	return "qemu-" + p.Architecture
+}
+
+// Native is the engine's own platform, from the OSType and Architecture
+// of its info response
+func Native(osType, arch string) Platform {
+	return Platform{OS: strings.ToLower(osType), Architecture: strings.ToLower(arch)}.normalize()
+}
+
+// Support is the platforms an engine can build and run
+type Support struct {
+	Native Platform
+	// Emulated are the platforms QEMU runs
+	Emulated []Platform
+	// Source says how the emulated platforms were found
+	Source string
+}
+
+// Runs reports whether the engine can run p, and how
+func (s Support) Runs(p Platform) (how string, ok bool) {
+	switch {
+	case p == s.Native:
+		return "native", true
+	// x86-64 CPUs run 32-bit code; not every arm64 CPU runs 32-bit arm
+	case p.OS == s.Native.OS && s.Native.Architecture == "amd64" && p.Architecture == "386":
+		return "native", true
+	case slices.Contains(s.Emulated, p):
+		return "emulated", true
+	}
+	return "", false
+}
+
+// BinfmtImage lists the platforms the kernel it runs on supports, and
+// registers QEMU for more
+const BinfmtImage = "tonistiigi/binfmt"
+
+// Detect finds the platforms the engine can run. binfmt runs BinfmtImage
+// privileged on the engine and returns its output, which describes the
+// kernel the engine runs on wherever that is; when it fails, binfmtDir
+// (normally /proc/sys/fs/binfmt_misc) is read instead, which only
+// describes the engine when it shares this host's kernel.
+func Detect(ctx context.Context, native Platform, binfmt func(context.Context) ([]byte, error), binfmtDir string) Support {
+	s := Support{Native: native}
+	if out, err := binfmt(ctx); err == nil {
+		if supported, err := BinfmtPlatforms(out); err == nil {
+			for _, p := range supported {
+				if _, ok := s.Runs(p); !ok {
+					s.Emulated = append(s.Emulated, p)
+				}
+			}
+			s.Source = BinfmtImage
+			return s
+		}
+	}
+	for _, p := range []Platform{
+		{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"},
+		{OS: "linux", Architecture: "arm", Variant: "v7"}, {OS: "linux", Architecture: "386"},
+		{OS: "linux", Architecture: "ppc64le"}, {OS: "linux", Architecture: "s390x"},
+		{OS: "linux", Architecture: "riscv64"},
+	} {
+		if _, ok := s.Runs(p); !ok && binfmtEnabled(binfmtDir, p) {
+			s.Emulated = append(s.Emulated, p)
+		}
+	}
+	s.Source = binfmtDir
+	return s
+}
+
+// BinfmtPlatforms reads the supported platforms BinfmtImage prints
+func BinfmtPlatforms(output []byte) ([]Platform, error) {
+	var report struct {
+		Supported []string `json:"supported"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("parse binfmt output: %w", err)
+	}
+	var out []Platform
+	for _, field := range report.Supported {
+		p, err := Parse(field)
+		if err == nil && !slices.Contains(out, p) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// binfmtEnabled reports whether QEMU is registered for p
+func binfmtEnabled(dir string, p Platform) bool {
+	data, err := os.ReadFile(filepath.Join(dir, p.qemuName()))
+	return err == nil && strings.HasPrefix(string(data), "enabled")
+}
+
+// InstallHint is the command that registers QEMU for the platforms
+func InstallHint(ps ...Platform) string {
+	var arches []string
+	for _, p := range ps {
+		arches = append(arches, p.Architecture)
+	}
+	return "docker run --privileged --rm " + BinfmtImage + " --install " + strings.Join(arches, ",")
+}
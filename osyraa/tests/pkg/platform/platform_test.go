@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]string{
+		"linux/amd64":     "linux/amd64",
+		"Linux/x86_64":    "linux/amd64",
+		"linux/aarch64":   "linux/arm64",
+		"linux/arm64/v8":  "linux/arm64",
+		"linux/arm/v7":    "linux/arm/v7",
+		"linux/armhf":     "linux/arm/v7",
+		" linux/ppc64le ": "linux/ppc64le",
+	} {
+		p, err := Parse(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, p.String(), in)
+	}
+	for _, in := range []string{"arm64", "linux/", "/amd64", "linux/arm/v7/x"} {
+		_, err := Parse(in)
+		assert.Error(t, err, in)
+	}
+
+	ps, err := ParseList("linux/amd64, linux/arm/v7,")
+	require.NoError(t, err)
+	assert.Equal(t, []Platform{{"linux", "amd64", ""}, {"linux", "arm", "v7"}}, ps)
+	assert.Equal(t, "armv7", ps[1].Tag())
+	assert.Equal(t, "armv7l", ps[1].Machine())
+	assert.Equal(t, "x86_64", ps[0].Machine())
+}
+
+func TestRuns(t *testing.T) {
+	amd64, _ := Parse("linux/amd64")
+	arm64, _ := Parse("linux/arm64")
+	i386, _ := Parse("linux/386")
+	s := Support{Native: Native("linux", "x86_64")}
+
+	how, ok := s.Runs(amd64)
+	assert.True(t, ok)
+	assert.Equal(t, "native", how)
+	_, ok = s.Runs(i386)
+	assert.True(t, ok, "x86-64 runs 386 without emulation")
+	_, ok = s.Runs(arm64)
+	assert.False(t, ok)
+
+	s.Emulated = []Platform{arm64}
+	how, ok = s.Runs(arm64)
+	assert.True(t, ok)
+	assert.Equal(t, "emulated", how)
+}
+
+// binfmtOutput is what BinfmtImage prints with no arguments
+const binfmtOutput = `{
+  "supported": ["linux/amd64", "linux/arm64", "linux/riscv64", "linux/386", "linux/arm/v7", "linux/arm/v6"],
+  "emulators": ["qemu-aarch64", "qemu-arm", "qemu-riscv64"]
+}`
+
+func TestBinfmtPlatforms(t *testing.T) {
+	got, err := BinfmtPlatforms([]byte(binfmtOutput))
+	require.NoError(t, err)
+	var names []string
+	for _, p := range got {
+		names = append(names, p.String())
+	}
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64", "linux/riscv64", "linux/386", "linux/arm/v7", "linux/arm/v6"}, names)
+	_, err = BinfmtPlatforms([]byte("exec format error"))
+	assert.Error(t, err)
+}
+
+func TestDetect(t *testing.T) {
+	binfmt := func(context.Context) ([]byte, error) { return []byte(binfmtOutput), nil }
+	s := Detect(t.Context(), Native("linux", "x86_64"), binfmt, t.TempDir())
+	assert.Equal(t, BinfmtImage, s.Source)
+	assert.Equal(t, []Platform{{"linux", "arm64", ""}, {"linux", "riscv64", ""}, {"linux", "arm", "v7"}, {"linux", "arm", "v6"}}, s.Emulated,
+		"The native platform and what it runs natively aren't emulated")
+}
+
+func TestDetectBinfmt(t *testing.T) {
+	noEngine := func(context.Context) ([]byte, error) { return nil, errors.New("no engine") }
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "qemu-aarch64"), []byte("enabled\ninterpreter /usr/bin/qemu-aarch64-static\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "qemu-riscv64"), []byte("disabled\n"), 0o644))
+
+	s := Detect(t.Context(), Native("linux", "x86_64"), noEngine, dir)
+	assert.Equal(t, dir, s.Source)
+	assert.Equal(t, []Platform{{"linux", "arm64", ""}}, s.Emulated)
+	assert.Equal(t, "docker run --privileged --rm tonistiigi/binfmt --install arm64,riscv64",
+		InstallHint(Platform{"linux", "arm64", ""}, Platform{"linux", "riscv64", ""}))
+}
@@ -0,0 +1,180 @@
+// Package polite makes outbound checks behave like a well-mannered
+// crawler when they reach third-party sites: requests to each host are
+// spaced out, carry a configurable User-Agent, and skip paths the host's
+// robots.txt disallows.
+package polite
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Agent is the robots.txt token the harness identifies as by default
+const Agent = "osyraa"
+
+// ErrDisallowed is returned for requests robots.txt disallows
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// Transport is a RoundTripper that rate limits and checks robots.txt per
+// host before passing requests to Next
+type Transport struct {
+	// Next makes the requests; nil means http.DefaultTransport
+	Next http.RoundTripper
+	// UserAgent replaces the requests' User-Agent when set
+	UserAgent string
+	// Agent is the token matched against robots.txt User-agent lines;
+	// empty means the Agent constant
+	Agent string
+	// Interval is the least time between requests to one host. A longer
+	// Crawl-delay in robots.txt wins.
+	Interval time.Duration
+	// IgnoreRobots skips robots.txt
+	IgnoreRobots bool
+
+	mu     sync.Mutex
+	hosts  map[string]*host
+	sleep  func(time.Duration)
+	nowFor func() time.Time
+}
+
+type host struct {
+	// mu serializes requests to the host so each waits its turn
+	mu     sync.Mutex
+	robots *Robots
+	last   time.Time
+}
+
+// New returns a transport that waits a second between requests to a host
+// and respects robots.txt for the Agent token
+func New(next http.RoundTripper) *Transport {
+	return &Transport{Next: next, Interval: time.Second}
+}
+
+func (t *Transport) next() http.RoundTripper {
+	if t.Next != nil {
+		return t.Next
+	}
+	return http.DefaultTransport
+}
+
+func (t *Transport) now() time.Time {
+	if t.nowFor != nil {
+		return t.nowFor()
+	}
+	return time.Now()
+}
+
+func (t *Transport) host(key string) *host {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.hosts == nil {
+		t.hosts = map[string]*host{}
+	}
+	h, ok := t.hosts[key]
+	if !ok {
+		h = &host{}
+		t.hosts[key] = h
+	}
+	return h
+}
+
+// wait blocks until the host's interval has passed since its last request,
+// or the request is cancelled
+func (t *Transport) wait(req *http.Request, h *host) error {
+	interval := t.Interval
+	if h.robots != nil {
+		interval = max(interval, h.robots.CrawlDelay)
+	}
+	if h.last.IsZero() {
+		return nil
+	}
+	d := h.last.Add(interval).Sub(t.now())
+	if d <= 0 {
+		return nil
+	}
+	if t.sleep != nil {
+		t.sleep(d)
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// do sends req to its host once the interval allows
+func (t *Transport) do(req *http.Request, h *host) (*http.Response, error) {
+	if err := t.wait(req, h); err != nil {
+		return nil, err
+	}
+	if t.UserAgent != "" {
+		req.Header.Set("User-Agent", t.UserAgent)
+	}
+	resp, err := t.next().RoundTrip(req)
+	h.last = t.now()
+	return resp, err
+}
+
+// loadRobots fetches the host's robots.txt once. A 4xx means there is no
+// policy; a 5xx means the host can't say, which RFC 9309 treats as a
+// refusal.
+func (t *Transport) loadRobots(req *http.Request, h *host) error {
+	if h.robots != nil {
+		return nil
+	}
+	robotsURL := *req.URL
+	robotsURL.Path, robotsURL.RawPath, robotsURL.RawQuery, robotsURL.Fragment = "/robots.txt", "", "", ""
+	robotsReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	robotsReq.Header.Set("User-Agent", req.Header.Get("User-Agent"))
+	resp, err := t.do(robotsReq, h)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", robotsURL.String(), err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		h.robots = DisallowAll
+	case resp.StatusCode >= 400:
+		h.robots = AllowAll
+	default:
+		agent := t.Agent
+		if agent == "" {
+			agent = Agent
+		}
+		robots, err := ParseRobots(resp.Body, agent)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", robotsURL.String(), err)
+		}
+		h.robots = robots
+	}
+	return nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := t.host(strings.ToLower(req.URL.Scheme + "://" + req.URL.Host))
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !t.IgnoreRobots && req.URL.Path != "/robots.txt" {
+		if err := t.loadRobots(req, h); err != nil {
+			return nil, err
+		}
+		if !h.robots.Allowed(req.URL.RequestURI()) {
+			return nil, fmt.Errorf("%s: %w", req.URL.String(), ErrDisallowed)
+		}
+	}
+	// The request is the caller's; headers are changed on a copy
+	req = req.Clone(req.Context())
+	return t.do(req, h)
+}
@@ -0,0 +1,140 @@
+package polite
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const robotsTxt = `# comments are ignored
+User-agent: *
+Disallow: /private
+Allow: /private/ok
+Disallow: /*.pdf$
+
+User-agent: osyraa
+User-agent: other
+Disallow: /osyraa-only
+Crawl-delay: 2
+
+User-agent: badbot
+Disallow: /
+`
+
+func TestParseRobots(t *testing.T) {
+	everyone, err := ParseRobots(strings.NewReader(robotsTxt), "somebot/1.0")
+	require.NoError(t, err)
+	assert.False(t, everyone.Allowed("/private/page"))
+	assert.True(t, everyone.Allowed("/private/ok/page"), "the longer Allow wins")
+	assert.False(t, everyone.Allowed("/files/cv.pdf"))
+	assert.True(t, everyone.Allowed("/files/cv.pdf?download=1"), "$ anchors the end")
+	assert.True(t, everyone.Allowed("/osyraa-only"))
+	assert.True(t, everyone.Allowed(""))
+
+	us, err := ParseRobots(strings.NewReader(robotsTxt), "osyraa/1.0 (+https://example.com)")
+	require.NoError(t, err)
+	assert.False(t, us.Allowed("/osyraa-only"))
+	assert.True(t, us.Allowed("/private"), "a named group replaces the * group")
+	assert.Equal(t, 2*time.Second, us.CrawlDelay)
+
+	bad, err := ParseRobots(strings.NewReader(robotsTxt), "BadBot")
+	require.NoError(t, err)
+	assert.False(t, bad.Allowed("/"))
+
+	empty, err := ParseRobots(strings.NewReader("User-agent: *\nDisallow:\n"), Agent)
+	require.NoError(t, err)
+	assert.True(t, empty.Allowed("/anything"))
+}
+
+// site serves robotsTxt (or the given status for it) and records every
+// request's path and User-Agent
+type site struct {
+	mu       sync.Mutex
+	requests []string
+	agents   []string
+}
+
+func newSite(t *testing.T, robotsStatus int) (*httptest.Server, *site) {
+	s := &site{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.Path)
+		s.agents = append(s.agents, r.UserAgent())
+		s.mu.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(robotsStatus)
+			if robotsStatus == http.StatusOK {
+				io.WriteString(w, robotsTxt)
+			}
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, s
+}
+
+// fakeClock advances only when the transport sleeps
+func fakeClock(tr *Transport) *[]time.Duration {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	tr.nowFor = func() time.Time { return now }
+	tr.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+	return &slept
+}
+
+func TestTransport(t *testing.T) {
+	srv, s := newSite(t, http.StatusOK)
+	tr := New(nil)
+	tr.UserAgent = "osyraa/1.0 (+https://example.com/bot)"
+	slept := fakeClock(tr)
+	client := &http.Client{Transport: tr}
+
+	for _, path := range []string{"/a", "/b"} {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	_, err := client.Get(srv.URL + "/osyraa-only/page")
+	assert.True(t, errors.Is(err, ErrDisallowed), "got %v", err)
+
+	assert.Equal(t, []string{"/robots.txt", "/a", "/b"}, s.requests, "robots.txt is read once and a disallowed path never requested")
+	for _, ua := range s.agents {
+		assert.Equal(t, tr.UserAgent, ua)
+	}
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second}, *slept, "the longer Crawl-delay spaces the requests")
+}
+
+func TestTransportRobotsStatus(t *testing.T) {
+	srv, _ := newSite(t, http.StatusNotFound)
+	tr := New(nil)
+	fakeClock(tr)
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL + "/osyraa-only")
+	require.NoError(t, err, "no robots.txt allows everything")
+	resp.Body.Close()
+
+	srv, s := newSite(t, http.StatusServiceUnavailable)
+	tr = New(nil)
+	fakeClock(tr)
+	_, err = (&http.Client{Transport: tr}).Get(srv.URL + "/a")
+	assert.ErrorIs(t, err, ErrDisallowed, "an unreadable robots.txt refuses everything")
+
+	tr = New(nil)
+	tr.IgnoreRobots = true
+	fakeClock(tr)
+	resp, err = (&http.Client{Transport: tr}).Get(srv.URL + "/a")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"/robots.txt", "/a"}, s.requests)
+}
@@ -0,0 +1,147 @@
+package polite
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Robots is the part of a robots.txt (RFC 9309) that applies to one agent
+type Robots struct {
+	rules []rule
+	// CrawlDelay is the group's Crawl-delay, a common extension; zero when
+	// absent
+	CrawlDelay time.Duration
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// AllowAll is the policy when a host has no robots.txt
+var AllowAll = &Robots{}
+
+// DisallowAll is the policy when a host's robots.txt can't be read
+// because of a server error
+var DisallowAll = &Robots{rules: []rule{{allow: false, pattern: "/"}}}
+
+type group struct {
+	agents []string
+	robots Robots
+}
+
+// ParseRobots reads a robots.txt and keeps the group for agent: the one
+// whose User-agent is the longest match of agent's product token, or the
+// * group, merged when the file repeats it
+func ParseRobots(r io.Reader, agent string) (*Robots, error) {
+	token := strings.ToLower(agent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var groups []*group
+	var current *group
+	inAgents := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			// An empty Disallow allows everything, like no rule at all
+			if current != nil && value != "" {
+				current.robots.rules = append(current.robots.rules, rule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			inAgents = false
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && current != nil {
+				current.robots.CrawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	matched := &Robots{}
+	best := -1
+	for _, g := range groups {
+		for _, a := range g.agents {
+			length := -1
+			switch {
+			case a == "*":
+				length = 0
+			case token != "" && strings.HasPrefix(token, a):
+				length = len(a)
+			}
+			if length < 0 || length < best {
+				continue
+			}
+			if length > best {
+				matched, best = &Robots{}, length
+			}
+			matched.rules = append(matched.rules, g.robots.rules...)
+			matched.CrawlDelay = max(matched.CrawlDelay, g.robots.CrawlDelay)
+		}
+	}
+	return matched, nil
+}
+
+// Allowed reports whether path (with its query) may be fetched: the
+// longest matching rule decides, and Allow wins a tie
+func (r *Robots) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	allowed, longest := true, -1
+	for _, rl := range r.rules {
+		if !matchPattern(rl.pattern, path) {
+			continue
+		}
+		if n := len(rl.pattern); n > longest || n == longest && rl.allow {
+			allowed, longest = rl.allow, n
+		}
+	}
+	return allowed
+}
+
+// matchPattern matches a robots.txt path pattern, where * is any run of
+// characters and a trailing $ anchors the end
+func matchPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
@@ -0,0 +1,210 @@
+// Package ready waits for something to become ready by polling it with
+// backoff, instead of sleeping for a fixed time that is too long on a fast
+// machine and too short on a loaded CI runner.
+package ready
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Predicate reports whether what it watches is ready. An error wrapped
+// with Stop ends the wait at once; any other error is remembered as the
+// reason it isn't ready yet and polling goes on.
+type Predicate struct {
+	Name  string
+	Ready func(ctx context.Context) (bool, error)
+}
+
+// Options tune the polling
+type Options struct {
+	// Timeout bounds the wait; zero means the context's deadline only
+	Timeout time.Duration
+	// Interval is the first pause between polls, doubling up to
+	// MaxInterval
+	Interval    time.Duration
+	MaxInterval time.Duration
+}
+
+// DefaultOptions poll from every 100ms up to every 2s, for 30s
+func DefaultOptions() Options {
+	return Options{Timeout: 30 * time.Second, Interval: 100 * time.Millisecond, MaxInterval: 2 * time.Second}
+}
+
+// stopError ends a wait early
+type stopError struct{ err error }
+
+func (e *stopError) Error() string { return e.err.Error() }
+func (e *stopError) Unwrap() error { return e.err }
+
+// Stop marks err as final: waiting longer can't make the predicate pass
+func Stop(err error) error {
+	return &stopError{err}
+}
+
+// TimeoutError is a wait that ran out of time
+type TimeoutError struct {
+	Name    string
+	Waited  time.Duration
+	Polls   int
+	LastErr error
+}
+
+func (e *TimeoutError) Error() string {
+	msg := fmt.Sprintf("%s not ready after %v (%d polls)", e.Name, e.Waited.Round(time.Millisecond), e.Polls)
+	if e.LastErr != nil {
+		msg += ": " + e.LastErr.Error()
+	}
+	return msg
+}
+
+func (e *TimeoutError) Unwrap() error { return e.LastErr }
+
+// WaitFor polls p until it is ready, it returns an error wrapped with
+// Stop, or the wait times out. It polls once straight away, so something
+// already ready costs no wait.
+func WaitFor(ctx context.Context, p Predicate, o Options) error {
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultOptions().Interval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	start := time.Now()
+	timeout := &TimeoutError{Name: p.Name}
+	interval := o.Interval
+	for {
+		timeout.Polls++
+		ok, err := p.Ready(ctx)
+		if ok {
+			return nil
+		}
+		var stop *stopError
+		if errors.As(err, &stop) {
+			return fmt.Errorf("%s: %w", p.Name, stop.err)
+		}
+		if err != nil && ctx.Err() == nil {
+			// An error caused by the deadline says nothing about p
+			timeout.LastErr = err
+		}
+		select {
+		case <-ctx.Done():
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return ctx.Err()
+			}
+			timeout.Waited = time.Since(start)
+			return timeout
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, o.MaxInterval)
+	}
+}
+
+// HTTPOK is ready once url answers 200
+func HTTPOK(client *http.Client, url string) Predicate {
+	return Predicate{Name: url, Ready: func(ctx context.Context) (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false, Stop(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return false, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return true, nil
+	}}
+}
+
+// TCP is ready once addr accepts connections
+func TCP(addr string) Predicate {
+	return Predicate{Name: addr, Ready: func(ctx context.Context) (bool, error) {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return false, err
+		}
+		conn.Close()
+		return true, nil
+	}}
+}
+
+// Inspector is the part of the Docker client the container predicates
+// need
+type Inspector interface {
+	ContainerInspect(ctx context.Context, id string) (types.ContainerJSON, error)
+}
+
+// ContainerRunning is ready once the container is running. A container
+// that has already exited never will be.
+func ContainerRunning(c Inspector, id string) Predicate {
+	return Predicate{Name: "container " + short(id) + " running", Ready: func(ctx context.Context) (bool, error) {
+		state, err := inspectState(ctx, c, id)
+		if err != nil {
+			return false, err
+		}
+		if state.Running {
+			return true, nil
+		}
+		return false, fmt.Errorf("status %s", state.Status)
+	}}
+}
+
+// ContainerHealthy is ready once the container's health check passes.
+// A container without a health check, or one that has exited, never
+// will be.
+func ContainerHealthy(c Inspector, id string) Predicate {
+	return Predicate{Name: "container " + short(id) + " healthy", Ready: func(ctx context.Context) (bool, error) {
+		state, err := inspectState(ctx, c, id)
+		if err != nil {
+			return false, err
+		}
+		if state.Health == nil {
+			return false, Stop(errors.New("the container has no health check"))
+		}
+		if state.Health.Status == types.Healthy {
+			return true, nil
+		}
+		err = fmt.Errorf("health %s", state.Health.Status)
+		if n := len(state.Health.Log); n > 0 {
+			err = fmt.Errorf("%w, last check exited %d: %s", err, state.Health.Log[n-1].ExitCode, state.Health.Log[n-1].Output)
+		}
+		return false, err
+	}}
+}
+
+func inspectState(ctx context.Context, c Inspector, id string) (*types.ContainerState, error) {
+	inspect, err := c.ContainerInspect(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	state := inspect.State
+	if state == nil {
+		return nil, errors.New("no container state")
+	}
+	if state.Status == "exited" || state.Status == "dead" {
+		return nil, Stop(fmt.Errorf("the container %s with code %d", state.Status, state.ExitCode))
+	}
+	return state, nil
+}
+
+func short(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
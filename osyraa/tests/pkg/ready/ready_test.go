@@ -0,0 +1,109 @@
+package ready
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fast = Options{Timeout: time.Second, Interval: time.Millisecond, MaxInterval: 10 * time.Millisecond}
+
+func TestWaitForPollsUntilReady(t *testing.T) {
+	var polls atomic.Int32
+	p := Predicate{Name: "third poll", Ready: func(context.Context) (bool, error) {
+		if polls.Add(1) < 3 {
+			return false, errors.New("not yet")
+		}
+		return true, nil
+	}}
+	require.NoError(t, WaitFor(context.Background(), p, fast))
+	assert.EqualValues(t, 3, polls.Load())
+}
+
+func TestWaitForTimesOutWithLastError(t *testing.T) {
+	p := Predicate{Name: "never", Ready: func(context.Context) (bool, error) {
+		return false, errors.New("still starting")
+	}}
+	o := fast
+	o.Timeout = 50 * time.Millisecond
+	err := WaitFor(context.Background(), p, o)
+	var timeout *TimeoutError
+	require.ErrorAs(t, err, &timeout)
+	assert.Greater(t, timeout.Polls, 1)
+	assert.ErrorContains(t, err, "never not ready after")
+	assert.ErrorContains(t, err, "still starting")
+}
+
+func TestWaitForStops(t *testing.T) {
+	var polls atomic.Int32
+	p := Predicate{Name: "broken", Ready: func(context.Context) (bool, error) {
+		polls.Add(1)
+		return false, Stop(errors.New("gave up"))
+	}}
+	err := WaitFor(context.Background(), p, fast)
+	assert.EqualError(t, err, "broken: gave up")
+	assert.EqualValues(t, 1, polls.Load())
+}
+
+func TestWaitForCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := Predicate{Name: "never", Ready: func(context.Context) (bool, error) { return false, nil }}
+	assert.ErrorIs(t, WaitFor(ctx, p, fast), context.Canceled)
+}
+
+func TestHTTPOK(t *testing.T) {
+	var up atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			up.Store(true)
+		}
+	}))
+	defer srv.Close()
+	require.NoError(t, WaitFor(context.Background(), HTTPOK(srv.Client(), srv.URL), fast))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	require.NoError(t, WaitFor(context.Background(), TCP(ln.Addr().String()), fast))
+}
+
+type fakeInspector []*types.ContainerState
+
+func (f *fakeInspector) ContainerInspect(context.Context, string) (types.ContainerJSON, error) {
+	state := (*f)[0]
+	if len(*f) > 1 {
+		*f = (*f)[1:]
+	}
+	return types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{State: state}}, nil
+}
+
+func TestContainerPredicates(t *testing.T) {
+	starting := &types.ContainerState{Status: "running", Running: true, Health: &types.Health{Status: types.Starting}}
+	healthy := &types.ContainerState{Status: "running", Running: true, Health: &types.Health{Status: types.Healthy}}
+	created := &types.ContainerState{Status: "created"}
+	exited := &types.ContainerState{Status: "exited", ExitCode: 1}
+
+	running := &fakeInspector{created, starting}
+	assert.NoError(t, WaitFor(context.Background(), ContainerRunning(running, "0123456789abcdef"), fast))
+
+	health := &fakeInspector{starting, starting, healthy}
+	assert.NoError(t, WaitFor(context.Background(), ContainerHealthy(health, "0123456789abcdef"), fast))
+
+	err := WaitFor(context.Background(), ContainerRunning(&fakeInspector{exited}, "0123456789abcdef"), fast)
+	assert.EqualError(t, err, "container 0123456789ab running: the container exited with code 1")
+
+	noCheck := &fakeInspector{{Status: "running", Running: true}}
+	err = WaitFor(context.Background(), ContainerHealthy(noCheck, "abc"), fast)
+	assert.ErrorContains(t, err, "no health check")
+}
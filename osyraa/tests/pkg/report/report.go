@@ -0,0 +1,164 @@
+// Package report collects the results of a harness run into a
+// self-contained HTML report with tables and image artifacts, so checks that
+// produce more than pass/fail (latency matrices, screenshots, traces) have
+// somewhere to put it.
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Section statuses
+const (
+	Pass = "pass"
+	Warn = "warn"
+	Fail = "fail"
+)
+
+// Report is the output of one harness run
+type Report struct {
+	Title   string
+	Created time.Time
+	// Dir receives index.html and any artifacts sections reference
+	Dir string
+	// Run is the run ID. When set, the report is also written as
+	// report-<Run>.html and artifacts go under artifacts/<Run>/, so runs
+	// sharing Dir keep their own files.
+	Run string
+
+	mu       sync.Mutex
+	sections []Section
+}
+
+// Section is one block of the report
+type Section struct {
+	Title   string
+	Status  string
+	Summary string
+	Table   *Table
+	Images  []Image
+}
+
+// Table is a simple grid of text cells
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Image is an artifact stored in the report directory
+type Image struct {
+	Caption string
+	// Path is relative to the report directory
+	Path string
+}
+
+// New creates an empty report writing to dir
+func New(title, dir string) *Report {
+	return &Report{Title: title, Created: time.Now(), Dir: dir}
+}
+
+// Add appends a section; it is safe for concurrent use
+func (r *Report) Add(s Section) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sections = append(r.sections, s)
+}
+
+// Sections returns a copy of the sections added so far
+func (r *Report) Sections() []Section {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Section(nil), r.sections...)
+}
+
+// ArtifactPath returns where to store an artifact named name, creating the
+// report directory, and the path to reference it by from a section
+func (r *Report) ArtifactPath(name string) (abs, rel string, err error) {
+	rel = filepath.ToSlash(filepath.Join("artifacts", r.Run, name))
+	abs = filepath.Join(r.Dir, "artifacts", r.Run, name)
+	return abs, rel, os.MkdirAll(filepath.Dir(abs), 0o755)
+}
+
+// Artifacts lists the run's stored artifacts, relative to the report
+// directory
+func (r *Report) Artifacts() ([]string, error) {
+	root := filepath.Join(r.Dir, "artifacts", r.Run)
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == root {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(r.Dir, path)
+		paths = append(paths, filepath.ToSlash(rel))
+		return err
+	})
+	return paths, err
+}
+
+var page = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; margin: 2rem; color: #222; }
+section { border-left: 4px solid #999; padding: 0 1rem; margin-bottom: 2rem; }
+section.pass { border-color: #2e7d32; } section.warn { border-color: #f9a825; } section.fail { border-color: #c62828; }
+table { border-collapse: collapse; } th, td { border: 1px solid #ddd; padding: .3rem .6rem; text-align: left; }
+figure { display: inline-block; margin: .5rem; } img { max-width: 480px; border: 1px solid #ddd; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Generated {{.Created.Format "2006-01-02 15:04:05 MST"}}{{with .Run}} by run {{.}}{{end}}</p>
+{{range .Sections}}<section class="{{.Status}}">
+<h2>{{.Title}}{{with .Status}} ({{.}}){{end}}</h2>
+{{with .Summary}}<p>{{.}}</p>{{end}}
+{{with .Table}}<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>{{end}}
+{{range .Images}}<figure><a href="{{.Path}}"><img src="{{.Path}}" alt="{{.Caption}}"></a><figcaption>{{.Caption}}</figcaption></figure>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML renders the report to index.html in the report directory and
+// returns its path. With a Run, index.html is the latest run's report and
+// report-<Run>.html, whose path is returned, keeps it.
+func (r *Report) WriteHTML() (string, error) {
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return "", err
+	}
+	data := struct {
+		Title    string
+		Created  time.Time
+		Run      string
+		Sections []Section
+	}{r.Title, r.Created, r.Run, r.Sections()}
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render report: %w", err)
+	}
+	path := filepath.Join(r.Dir, "index.html")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	if r.Run == "" {
+		return path, nil
+	}
+	path = filepath.Join(r.Dir, "report-"+r.Run+".html")
+	return path, os.WriteFile(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHTML(t *testing.T) {
+	r := New("Harness run", t.TempDir())
+	r.Add(Section{
+		Title:   "Latency",
+		Status:  Warn,
+		Summary: "ap-southeast is <slow>",
+		Table:   &Table{Header: []string{"Region", "p50"}, Rows: [][]string{{"eu", "120ms"}}},
+	})
+	abs, rel, err := r.ArtifactPath("shot.png")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(abs, []byte("png"), 0o644))
+	r.Add(Section{Title: "Screenshots", Status: Pass, Images: []Image{{Caption: "home", Path: rel}}})
+
+	path, err := r.WriteHTML()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(r.Dir, "index.html"), path)
+
+	body, err := os.ReadFile(path)
+	require.NoError(t, err)
+	doc, err := match.ParseBytes(body)
+	require.NoError(t, err)
+	assert.NoError(t, doc.Select("h2").TextContains("Latency (warn)"))
+	assert.NoError(t, doc.Select("section.warn p").TextEquals("ap-southeast is <slow>"), "text is escaped")
+	assert.NoError(t, doc.Select("td").TextContains("120ms"))
+	assert.NoError(t, doc.Select("img").AttrEquals("src", "artifacts/shot.png"))
+}
+
+func TestWriteHTMLRun(t *testing.T) {
+	r := New("Harness run", t.TempDir())
+	r.Run = "ci-42"
+	_, rel, err := r.ArtifactPath("zap/zap.html")
+	require.NoError(t, err)
+	assert.Equal(t, "artifacts/ci-42/zap/zap.html", rel)
+	r.Add(Section{Title: "Smoke", Status: Pass})
+
+	path, err := r.WriteHTML()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(r.Dir, "report-ci-42.html"), path)
+	body, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "by run ci-42")
+	latest, err := os.ReadFile(filepath.Join(r.Dir, "index.html"))
+	require.NoError(t, err)
+	assert.Equal(t, body, latest, "index.html is the latest run's report")
+}
+
+func TestArtifacts(t *testing.T) {
+	r := New("Harness run", t.TempDir())
+	r.Run = "ci-42"
+	paths, err := r.Artifacts()
+	require.NoError(t, err)
+	assert.Empty(t, paths, "no artifacts directory yet")
+
+	for _, name := range []string{"zap/zap.html", "sbom.cdx.json"} {
+		abs, _, err := r.ArtifactPath(name)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(abs, nil, 0o644))
+	}
+	paths, err = r.Artifacts()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"artifacts/ci-42/sbom.cdx.json", "artifacts/ci-42/zap/zap.html"}, paths)
+}
@@ -0,0 +1,424 @@
+// Package results collects the outcome of every test in a run from
+// go test -json and writes it as JUnit XML and a JSON summary, so CI gets
+// structured results without a converter such as gotestsum in between.
+//
+// A Recorder reads the events go test -json prints, which mark each
+// test's output and its failure messages unambiguously even when tests
+// run in parallel, and passes the output on as go test -v prints it.
+package results
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Test statuses
+const (
+	Pass = "pass"
+	Fail = "fail"
+	Skip = "skip"
+)
+
+// Test is the outcome of one test or subtest
+type Test struct {
+	// Name is the full name, e.g. TestHugoSuite/TestSiteCrawl
+	Name   string
+	Status string
+	// Duration is what the test binary reported
+	Duration time.Duration
+	// Output is everything the test logged, failures included
+	Output string
+	// Failure is the messages the test failed with, e.g. from t.Error
+	Failure string
+}
+
+// Suite is the top-level test a test belongs to, e.g. TestHugoSuite
+func (t Test) Suite() string {
+	suite, _, _ := strings.Cut(t.Name, "/")
+	return suite
+}
+
+// event is one line of go test -json
+type event struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+	// OutputType is "frame" for the === and --- lines, and "error" and
+	// "error-continue" for the lines of a failure message
+	OutputType string
+}
+
+// logPattern is the prefix of the harness's own log lines, which name
+// the run
+var logPattern = regexp.MustCompile(`^osyraa run=([a-z0-9_.-]+): `)
+
+// Recorder is a writer for go test -json output. It records each test's
+// outcome and passes the output on to Out.
+type Recorder struct {
+	// Out receives the output, as go test -v prints it
+	Out io.Writer
+
+	mu       sync.Mutex
+	partial  []byte
+	tests    map[string]*Test
+	order    []string
+	id       string
+	pkg      string
+	started  time.Time
+	duration time.Duration
+	status   string
+}
+
+// NewRecorder returns a recorder writing to out
+func NewRecorder(out io.Writer) *Recorder {
+	return &Recorder{Out: out, tests: map[string]*Test{}}
+}
+
+// Write records and forwards complete lines; a partial line waits for the
+// rest of it, or for Close
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.partial = append(r.partial, p...)
+	for {
+		i := bytes.IndexByte(r.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := r.partial[:i+1]
+		if err := r.line(line); err != nil {
+			return len(p), err
+		}
+		r.partial = r.partial[i+1:]
+	}
+}
+
+// Close handles a final line without a newline
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.partial) == 0 {
+		return nil
+	}
+	err := r.line(r.partial)
+	r.partial = nil
+	return err
+}
+
+func (r *Recorder) line(line []byte) error {
+	var e event
+	if err := json.Unmarshal(line, &e); err != nil || e.Action == "" {
+		// Not an event, such as a build error go test printed itself
+		_, err := r.Out.Write(line)
+		return err
+	}
+	if e.Output != "" {
+		if _, err := io.WriteString(r.Out, e.Output); err != nil {
+			return err
+		}
+	}
+	if e.Test == "" {
+		r.packageEvent(e)
+		return nil
+	}
+	test, ok := r.tests[e.Test]
+	if !ok {
+		test = &Test{Name: e.Test}
+		r.tests[e.Test] = test
+		r.order = append(r.order, e.Test)
+	}
+	switch e.Action {
+	case "output":
+		if e.OutputType == "frame" {
+			return nil
+		}
+		test.Output += e.Output
+		if e.OutputType == "error" || e.OutputType == "error-continue" {
+			test.Failure += e.Output
+		}
+	case Pass, Fail, Skip:
+		test.Status = e.Action
+		test.Duration = time.Duration(e.Elapsed * float64(time.Second)).Round(time.Millisecond)
+	}
+	return nil
+}
+
+// packageEvent records the run itself: when it started, what it is
+// called, and how it ended
+func (r *Recorder) packageEvent(e event) {
+	if r.pkg == "" {
+		r.pkg = e.Package
+	}
+	switch e.Action {
+	case "start":
+		if r.started.IsZero() {
+			r.started = e.Time
+		}
+	case "output":
+		if m := logPattern.FindStringSubmatch(e.Output); m != nil && r.id == "" {
+			r.id = m[1]
+		}
+	case Pass, Fail, Skip:
+		r.duration = time.Duration(e.Elapsed * float64(time.Second)).Round(time.Millisecond)
+		if r.status != Fail {
+			r.status = e.Action
+		}
+	}
+}
+
+// Tests returns the tests seen so far, in the order they started. A test
+// that started but never reported an outcome, e.g. because the run was
+// cut short, is returned as failed.
+func (r *Recorder) Tests() []Test {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tests := make([]Test, 0, len(r.order))
+	for _, name := range r.order {
+		t := *r.tests[name]
+		if t.Status == "" {
+			t.Status = Fail
+			t.Failure += "the test did not finish\n"
+		}
+		tests = append(tests, t)
+	}
+	return tests
+}
+
+// Run is the run recorded so far. Its ID is the one the harness logged;
+// a run whose package never reported an outcome, e.g. because it timed
+// out or failed to build, has exit code 1.
+func (r *Recorder) Run() Run {
+	tests := r.Tests()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run := Run{ID: r.id, Package: r.pkg, Started: r.started, Duration: r.duration, Tests: tests}
+	if r.status != Pass && r.status != Skip {
+		run.ExitCode = 1
+	}
+	return run
+}
+
+// Run is a finished test run
+type Run struct {
+	// ID is the run ID
+	ID string
+	// Package names the test package, as JUnit's classname prefix
+	Package  string
+	Started  time.Time
+	Duration time.Duration
+	// ExitCode is the test binary's; a run can fail outside any test,
+	// e.g. in TestMain
+	ExitCode int
+	Tests    []Test
+	// Artifacts are files the run left for CI to keep, such as the HTML
+	// report
+	Artifacts []string
+}
+
+// Cases are the tests reported on their own: every test without subtests,
+// and a parent that failed with messages of its own, e.g. from a suite's
+// setup. A parent that only failed because a subtest did is left out, so
+// each failure counts once.
+func (r Run) Cases() []Test {
+	parents := map[string]bool{}
+	for _, t := range r.Tests {
+		if i := strings.LastIndex(t.Name, "/"); i >= 0 {
+			parents[t.Name[:i]] = true
+		}
+	}
+	var cases []Test
+	for _, t := range r.Tests {
+		if !parents[t.Name] || (t.Status == Fail && t.Failure != "") {
+			cases = append(cases, t)
+		}
+	}
+	return cases
+}
+
+// Counts tallies test cases by outcome
+type Counts struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+func (c *Counts) add(t Test) {
+	c.Total++
+	switch t.Status {
+	case Pass:
+		c.Passed++
+	case Fail:
+		c.Failed++
+	case Skip:
+		c.Skipped++
+	}
+}
+
+// SuiteSummary is one top-level test's share of the run
+type SuiteSummary struct {
+	Name     string        `json:"name"`
+	Counts   Counts        `json:"counts"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Summary is the JSON summary of a run
+type Summary struct {
+	Run      string         `json:"run,omitempty"`
+	Started  time.Time      `json:"started"`
+	Duration time.Duration  `json:"duration_ns"`
+	Passed   bool           `json:"passed"`
+	Counts   Counts         `json:"counts"`
+	Suites   []SuiteSummary `json:"suites"`
+	// Failures name the failed test cases
+	Failures  []string `json:"failures"`
+	Artifacts []string `json:"artifacts"`
+}
+
+// Summary tallies the run's test cases, overall and per suite
+func (r Run) Summary() Summary {
+	s := Summary{Run: r.ID, Started: r.Started, Duration: r.Duration, Passed: r.ExitCode == 0,
+		Suites: []SuiteSummary{}, Failures: []string{}, Artifacts: slices.Clone(r.Artifacts)}
+	if s.Artifacts == nil {
+		s.Artifacts = []string{}
+	}
+	index := map[string]int{}
+	for _, t := range r.Tests {
+		if !strings.Contains(t.Name, "/") {
+			index[t.Name] = len(s.Suites)
+			s.Suites = append(s.Suites, SuiteSummary{Name: t.Name, Duration: t.Duration})
+		}
+	}
+	for _, t := range r.Cases() {
+		s.Counts.add(t)
+		if i, ok := index[t.Suite()]; ok {
+			s.Suites[i].Counts.add(t)
+		}
+		if t.Status == Fail {
+			s.Failures = append(s.Failures, t.Name)
+			s.Passed = false
+		}
+	}
+	return s
+}
+
+// WriteSummary writes the run's summary as indented JSON
+func (r Run) WriteSummary(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Summary())
+}
+
+// JUnit XML, in the schema Jenkins, GitLab and GitHub's test reporters
+// read
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr,omitempty"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// firstLine is a message's first non-empty line, trimmed
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// WriteJUnit writes the run as JUnit XML: a testsuite per top-level test,
+// holding its test cases
+func (r Run) WriteJUnit(w io.Writer) error {
+	summary := r.Summary()
+	doc := junitSuites{Name: r.Package, Tests: summary.Counts.Total, Failures: summary.Counts.Failed,
+		Skipped: summary.Counts.Skipped, Time: seconds(r.Duration)}
+	index := map[string]int{}
+	for _, s := range summary.Suites {
+		index[s.Name] = len(doc.Suites)
+		doc.Suites = append(doc.Suites, junitSuite{Name: s.Name, Tests: s.Counts.Total, Failures: s.Counts.Failed,
+			Skipped: s.Counts.Skipped, Time: seconds(s.Duration)})
+		if !r.Started.IsZero() {
+			doc.Suites[len(doc.Suites)-1].Timestamp = r.Started.UTC().Format(time.RFC3339)
+		}
+	}
+	for _, t := range r.Cases() {
+		classname := t.Suite()
+		if r.Package != "" {
+			classname = r.Package + "." + classname
+		}
+		c := junitCase{Name: t.Name, Classname: classname, Time: seconds(t.Duration), SystemOut: t.Output}
+		switch t.Status {
+		case Fail:
+			failure := t.Failure
+			if failure == "" {
+				failure = t.Output
+			}
+			c.Failure = &junitMessage{Message: firstLine(failure), Text: failure}
+		case Skip:
+			c.Skipped = &junitMessage{Message: firstLine(t.Output)}
+		}
+		i, ok := index[t.Suite()]
+		if !ok {
+			i = len(doc.Suites)
+			index[t.Suite()] = i
+			doc.Suites = append(doc.Suites, junitSuite{Name: t.Suite(), Time: seconds(0)})
+		}
+		doc.Suites[i].Cases = append(doc.Suites[i].Cases, c)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,162 @@
+package results
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// record feeds the go test -json output of a run to a recorder in small
+// writes, as a pipe delivers it
+func record(t *testing.T) (*Recorder, string) {
+	t.Helper()
+	data, err := os.ReadFile("testdata/gotest.json")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	r := NewRecorder(&out)
+	for chunk := range slicesOf(data, 7) {
+		_, err := r.Write(chunk)
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Close())
+	return r, out.String()
+}
+
+func slicesOf(data []byte, n int) func(func([]byte) bool) {
+	return func(yield func([]byte) bool) {
+		for len(data) > 0 {
+			chunk := data[:min(n, len(data))]
+			data = data[len(chunk):]
+			if !yield(chunk) {
+				return
+			}
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r, out := record(t)
+	assert.NotContains(t, out, `"Action"`)
+	assert.Contains(t, out, "    osyraa_test.go:3: Hugo build failed\n        exit status 1\n--- FAIL: TestHugoSuite/TestHugoBuild (0.00s)\n",
+		"the output is passed on as go test -v prints it")
+
+	tests := r.Tests()
+	var names []string
+	for _, test := range tests {
+		names = append(names, test.Name)
+	}
+	assert.Equal(t, []string{"TestHugoSuite", "TestHugoSuite/TestHugoBuild", "TestHugoSuite/TestOutput",
+		"TestDNSRecords", "TestTLSCertificate", "TestSiteLinks"}, names)
+
+	build := tests[1]
+	assert.Equal(t, Fail, build.Status)
+	assert.Equal(t, "    osyraa_test.go:3: Hugo build failed\n        exit status 1\n", build.Failure)
+	assert.Equal(t, "TestHugoSuite", build.Suite())
+	assert.Equal(t, "    osyraa_test.go:3: building ../site\n", tests[0].Output)
+	assert.Empty(t, tests[0].Failure, "the suite only failed through its subtest")
+	assert.Equal(t, Skip, tests[3].Status)
+	assert.Equal(t, 10*time.Millisecond, tests[4].Duration)
+	assert.Equal(t, "    osyraa_test.go:5: certificate valid for 60 days\n", tests[4].Output, "parallel tests keep their own output")
+	assert.Equal(t, "    osyraa_test.go:6: checked 42 links\n", tests[5].Output)
+}
+
+func TestRecorderRun(t *testing.T) {
+	r, _ := record(t)
+	run := r.Run()
+	assert.Equal(t, "ci-42", run.ID, "the run ID comes from the harness's log lines")
+	assert.Equal(t, "github.com/spider-2y-banana/osyraa/tests", run.Package)
+	assert.Equal(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), run.Started)
+	assert.Equal(t, 3*time.Second, run.Duration)
+	assert.Equal(t, 1, run.ExitCode)
+	assert.Len(t, run.Tests, 6)
+}
+
+func TestRecorderUnfinished(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRecorder(&out)
+	r.Write([]byte("# github.com/spider-2y-banana/osyraa/tests\n"))
+	r.Write([]byte(`{"Action":"run","Test":"TestDockerSuite"}` + "\n" + `{"Action":"output","Test":"TestDockerSuite","Output":"    osyraa_test.go:12: starting\n"}`))
+	require.NoError(t, r.Close())
+	assert.Equal(t, "# github.com/spider-2y-banana/osyraa/tests\n    osyraa_test.go:12: starting\n", out.String(), "lines that aren't events pass through")
+	tests := r.Tests()
+	require.Len(t, tests, 1)
+	assert.Equal(t, Fail, tests[0].Status)
+	assert.Equal(t, "the test did not finish\n", tests[0].Failure)
+	assert.Equal(t, 1, r.Run().ExitCode, "a run that never finished failed")
+}
+
+func run(t *testing.T) Run {
+	r, _ := record(t)
+	return Run{ID: "ci-42", Package: "tests", Started: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Duration: 3 * time.Second, ExitCode: 1, Tests: r.Tests(), Artifacts: []string{"report-ci-42.html"}}
+}
+
+func TestSummary(t *testing.T) {
+	s := run(t).Summary()
+	assert.False(t, s.Passed)
+	assert.Equal(t, Counts{Total: 5, Passed: 3, Failed: 1, Skipped: 1}, s.Counts, "the suite's failure counts once, by its subtest")
+	require.Len(t, s.Suites, 4)
+	assert.Equal(t, SuiteSummary{Name: "TestHugoSuite", Counts: Counts{Total: 2, Passed: 1, Failed: 1}}, s.Suites[0])
+	assert.Equal(t, []string{"TestHugoSuite/TestHugoBuild"}, s.Failures)
+
+	var buf bytes.Buffer
+	require.NoError(t, run(t).WriteSummary(&buf))
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "ci-42", doc["run"])
+	assert.Equal(t, float64(3e9), doc["duration_ns"])
+	assert.Equal(t, map[string]any{"total": 5.0, "passed": 3.0, "failed": 1.0, "skipped": 1.0}, doc["counts"])
+	assert.Equal(t, []any{"report-ci-42.html"}, doc["artifacts"])
+
+	passed := Run{}.Summary()
+	assert.True(t, passed.Passed)
+	assert.NotNil(t, passed.Failures, "empty lists are written as []")
+}
+
+func TestSummaryCountsSetupFailure(t *testing.T) {
+	r := Run{ExitCode: 1, Tests: []Test{
+		{Name: "TestDockerSuite", Status: Fail, Failure: "    osyraa_test.go:40: Docker is not running\n"},
+		{Name: "TestDockerSuite/TestBuild", Status: Skip},
+	}}
+	s := r.Summary()
+	assert.Equal(t, Counts{Total: 2, Failed: 1, Skipped: 1}, s.Counts)
+	assert.Equal(t, []string{"TestDockerSuite"}, s.Failures)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, run(t).WriteJUnit(&buf))
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header))
+
+	var doc junitSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, 5, doc.Tests)
+	assert.Equal(t, 1, doc.Failures)
+	assert.Equal(t, "3.000", doc.Time)
+	require.Len(t, doc.Suites, 4)
+
+	hugo := doc.Suites[0]
+	assert.Equal(t, "TestHugoSuite", hugo.Name)
+	assert.Equal(t, "2026-03-01T12:00:00Z", hugo.Timestamp)
+	require.Len(t, hugo.Cases, 2)
+	build := hugo.Cases[0]
+	assert.Equal(t, "TestHugoSuite/TestHugoBuild", build.Name)
+	assert.Equal(t, "tests.TestHugoSuite", build.Classname)
+	require.NotNil(t, build.Failure)
+	assert.Equal(t, "osyraa_test.go:3: Hugo build failed", build.Failure.Message)
+	assert.Contains(t, build.Failure.Text, "exit status 1")
+	assert.Nil(t, hugo.Cases[1].Failure)
+
+	dns := doc.Suites[1]
+	require.Len(t, dns.Cases, 1)
+	require.NotNil(t, dns.Cases[0].Skipped)
+	assert.Equal(t, "osyraa_test.go:4: set OSYRAA_DNS=1 to check DNS records", dns.Cases[0].Skipped.Message)
+	assert.Equal(t, "0.010", doc.Suites[2].Cases[0].Time)
+}
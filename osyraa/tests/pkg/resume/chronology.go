@@ -0,0 +1,113 @@
+package resume
+
+import (
+	"fmt"
+	"time"
+)
+
+// Orders entries may be listed in
+const (
+	NewestFirst = "newest-first"
+	OldestFirst = "oldest-first"
+)
+
+// ChronologyOptions configures CheckChronology
+type ChronologyOptions struct {
+	// Order is NewestFirst, the convention for resumes, or OldestFirst
+	Order string
+	// MaxCurrent is how many jobs may end in "Present"
+	MaxCurrent int
+}
+
+// DefaultChronology expects reverse chronological order and at most one
+// current role
+func DefaultChronology() ChronologyOptions {
+	return ChronologyOptions{Order: NewestFirst, MaxCurrent: 1}
+}
+
+// dated is an entry with the period it covers
+type dated struct {
+	name   string
+	line   int
+	period Period
+}
+
+// CheckChronology reports jobs and education entries out of order and more
+// current jobs than expected. Entries whose dates do not parse are left to
+// Validate.
+func CheckChronology(r *Resume, opts ChronologyOptions) []Problem {
+	var problems []Problem
+	if opts.Order != NewestFirst && opts.Order != OldestFirst {
+		return []Problem{{Message: fmt.Sprintf("unknown order %q; use %q or %q", opts.Order, NewestFirst, OldestFirst)}}
+	}
+
+	var jobs, education []dated
+	var current []string
+	for _, j := range r.Experience {
+		if p, err := ParsePeriod(j.Dates); err == nil {
+			jobs = append(jobs, dated{j.Title, j.Line, p})
+			if p.Current() {
+				current = append(current, j.Title)
+			}
+		}
+	}
+	for _, e := range r.Education {
+		if p, ok := educationPeriod(e.Dates); ok {
+			education = append(education, dated{e.Credential, e.Line, p})
+		}
+	}
+	problems = append(problems, checkOrder("job", jobs, opts.Order)...)
+	problems = append(problems, checkOrder("education entry", education, opts.Order)...)
+
+	if len(current) > opts.MaxCurrent {
+		s, _ := r.Section(SectionExperience)
+		problems = append(problems, Problem{Line: s.Line, Message: fmt.Sprintf(
+			"%d jobs end in \"Present\" (%q), expected at most %d", len(current), current, opts.MaxCurrent)})
+	}
+	return problems
+}
+
+// educationPeriod reads an education entry's dates: a period, or the
+// single month or year it was completed
+func educationPeriod(s string) (Period, bool) {
+	if p, err := ParsePeriod(s); err == nil {
+		return p, true
+	}
+	if t, err := ParseMonth(s); err == nil {
+		return Period{Start: t, End: t}, true
+	}
+	return Period{}, false
+}
+
+// end is when a period finishes, with ongoing periods after every other
+func (d dated) end() time.Time {
+	if d.period.Current() {
+		return time.Date(9999, time.December, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return d.period.End
+}
+
+// newer reports whether a comes after b: it ends later, or ends at the
+// same time and started later
+func newer(a, b dated) bool {
+	if !a.end().Equal(b.end()) {
+		return a.end().After(b.end())
+	}
+	return a.period.Start.After(b.period.Start)
+}
+
+func checkOrder(kind string, entries []dated, order string) []Problem {
+	var problems []Problem
+	for i := 1; i < len(entries); i++ {
+		prev, cur := entries[i-1], entries[i]
+		switch {
+		case order == NewestFirst && newer(cur, prev):
+			problems = append(problems, Problem{Line: cur.line, Message: fmt.Sprintf(
+				"%s %q is newer than %q above it; list entries newest first", kind, cur.name, prev.name)})
+		case order == OldestFirst && newer(prev, cur):
+			problems = append(problems, Problem{Line: cur.line, Message: fmt.Sprintf(
+				"%s %q is older than %q above it; list entries oldest first", kind, cur.name, prev.name)})
+		}
+	}
+	return problems
+}
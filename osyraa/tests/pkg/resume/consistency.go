@@ -0,0 +1,219 @@
+package resume
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+)
+
+// FromHTML reads the resume back out of the rendered page's <main>. The
+// elements are turned back into the Markdown shapes Parse understands, so
+// both sides of a comparison go through the same reader.
+func FromHTML(doc *match.Document) (*Resume, error) {
+	mains := doc.Select("main").Nodes()
+	if len(mains) == 0 {
+		return nil, fmt.Errorf("page has no <main>")
+	}
+	var b strings.Builder
+	for c := mains[0].FirstChild; c != nil; c = c.NextSibling {
+		writeBlock(&b, c)
+	}
+	return Parse([]byte(b.String()))
+}
+
+func writeBlock(b *strings.Builder, n *html.Node) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	switch n.Data {
+	case "h2":
+		fmt.Fprintf(b, "## %s\n\n", inline(n))
+	case "h3":
+		fmt.Fprintf(b, "### %s\n", inline(n))
+	case "p":
+		fmt.Fprintf(b, "%s\n\n", inline(n))
+	case "ul", "ol":
+		for li := n.FirstChild; li != nil; li = li.NextSibling {
+			if li.Type != html.ElementNode || li.Data != "li" {
+				continue
+			}
+			fmt.Fprintf(b, "- %s\n", inline(li))
+			for c := li.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && (c.Data == "ul" || c.Data == "ol") {
+					writeBlock(b, c)
+				}
+			}
+		}
+		b.WriteString("\n")
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeBlock(b, c)
+		}
+	}
+}
+
+// inline renders an element's phrasing content as Markdown, keeping only
+// the bold markers Parse relies on
+func inline(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case n.Type != html.ElementNode:
+			return
+		case n.Data == "ul" || n.Data == "ol":
+			return
+		case n.Data == "strong" || n.Data == "b":
+			b.WriteString("**")
+			defer b.WriteString("**")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+	return match.Normalize(b.String())
+}
+
+var (
+	mdLinkRe    = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	typographer = strings.NewReplacer(
+		"**", "", "__", "", "`", "",
+		"‘", "'", "’", "'", "“", `"`, "”", `"`,
+		"–", "-", "—", "-", "…", "...", " ", " ",
+	)
+)
+
+// plain reduces Markdown or rendered text to comparable words: link
+// targets, emphasis and the typographer's curly quotes and dashes go
+func plain(s string) string {
+	return match.Normalize(typographer.Replace(mdLinkRe.ReplaceAllString(s, "$1")))
+}
+
+// Compare reports entries of data that the rendered resume lacks or shows
+// differently, and rendered entries with no counterpart in data
+func Compare(data, rendered *Resume) []string {
+	var problems []string
+	diff := func(kind string, want, got []string) {
+		missing, extra := diffKeys(want, got)
+		for _, k := range missing {
+			problems = append(problems, fmt.Sprintf("%s %q is not rendered", kind, k))
+		}
+		for _, k := range extra {
+			problems = append(problems, fmt.Sprintf("rendered %s %q has no data source", kind, k))
+		}
+	}
+	field := func(kind, key, name, want, got string) {
+		if plain(want) != plain(got) {
+			problems = append(problems, fmt.Sprintf("%s %q: %s is %q in data but %q rendered", kind, key, name, plain(want), plain(got)))
+		}
+	}
+
+	diff("section", keys(data.Sections, func(s Section) string { return s.Name }),
+		keys(rendered.Sections, func(s Section) string { return s.Name }))
+
+	jobs := byKey(rendered.Experience, func(j Job) string { return j.Title })
+	diff("job", keys(data.Experience, func(j Job) string { return j.Title }), keys(rendered.Experience, func(j Job) string { return j.Title }))
+	for _, j := range data.Experience {
+		got, ok := jobs[plain(j.Title)]
+		if !ok {
+			continue
+		}
+		field("job", j.Title, "company", j.Company, got.Company)
+		field("job", j.Title, "dates", j.Dates, got.Dates)
+		field("job", j.Title, "location", j.Location, got.Location)
+		diff(fmt.Sprintf("%q bullet", plain(j.Title)), j.Highlights, got.Highlights)
+	}
+
+	educations := byKey(rendered.Education, func(e Education) string { return e.Credential })
+	diff("education", keys(data.Education, func(e Education) string { return e.Credential }),
+		keys(rendered.Education, func(e Education) string { return e.Credential }))
+	for _, e := range data.Education {
+		if got, ok := educations[plain(e.Credential)]; ok {
+			field("education", e.Credential, "institution", e.Institution, got.Institution)
+			field("education", e.Credential, "dates", e.Dates, got.Dates)
+		}
+	}
+
+	certs := byKey(rendered.Certifications, func(c Certification) string { return c.Name })
+	diff("certification", keys(data.Certifications, func(c Certification) string { return c.Name }),
+		keys(rendered.Certifications, func(c Certification) string { return c.Name }))
+	for _, c := range data.Certifications {
+		if got, ok := certs[plain(c.Name)]; ok {
+			field("certification", c.Name, "abbreviation", c.Abbrev, got.Abbrev)
+			field("certification", c.Name, "details", c.Details, got.Details)
+		}
+	}
+
+	groups := byKey(rendered.Skills, func(g SkillGroup) string { return g.Category })
+	diff("skill group", keys(data.Skills, func(g SkillGroup) string { return g.Category }),
+		keys(rendered.Skills, func(g SkillGroup) string { return g.Category }))
+	for _, g := range data.Skills {
+		got, ok := groups[plain(g.Category)]
+		if !ok {
+			continue
+		}
+		skills := byKey(got.Skills, func(s Skill) string { return s.Label })
+		diff(fmt.Sprintf("%q skill", plain(g.Category)), keys(g.Skills, func(s Skill) string { return s.Label }),
+			keys(got.Skills, func(s Skill) string { return s.Label }))
+		for _, s := range g.Skills {
+			if rs, ok := skills[plain(s.Label)]; ok {
+				diff(fmt.Sprintf("%q item", plain(s.Label)), s.Items, rs.Items)
+			}
+		}
+	}
+
+	diff("project", keys(data.Projects, func(p Project) string { return p.Name }),
+		keys(rendered.Projects, func(p Project) string { return p.Name }))
+	return problems
+}
+
+func keys[T any](items []T, key func(T) string) []string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = key(item)
+	}
+	return out
+}
+
+func byKey[T any](items []T, key func(T) string) map[string]T {
+	m := make(map[string]T, len(items))
+	for _, item := range items {
+		m[plain(key(item))] = item
+	}
+	return m
+}
+
+// diffKeys returns the plain forms of want missing from got and of got
+// missing from want, in their original order
+func diffKeys(want, got []string) (missing, extra []string) {
+	count := map[string]int{}
+	for _, g := range got {
+		count[plain(g)]++
+	}
+	for _, w := range want {
+		k := plain(w)
+		if count[k] > 0 {
+			count[k]--
+		} else {
+			missing = append(missing, k)
+		}
+	}
+	for _, g := range got {
+		k := plain(g)
+		if count[k] > 0 {
+			count[k]--
+			extra = append(extra, k)
+		}
+	}
+	return missing, extra
+}
@@ -0,0 +1,65 @@
+package resume
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+)
+
+// sampleHTML is sample as Hugo renders it: minified, with the
+// typographer's curly quotes
+const sampleHTML = `<!doctype html><html><body><header><h1>Jane Doe</h1></header><main>` +
+	`<h2 id=professional-summary>Professional Summary</h2><p>Platform engineer.</p>` +
+	`<h2 id=experience>Experience</h2><h3 id=staff-engineer>Staff Engineer</h3>` +
+	`<p><strong>Acme Corp</strong> | March 2021 - Present | Remote</p><ul><li>Built things</li><li>Ran things</li></ul>` +
+	`<h3 id=engineer>Engineer</h3><p><strong>Initech</strong> | Jan 2018 - February 2021</p><ul><li>Fixed things</li></ul>` +
+	`<h2 id=education>Education</h2><p><strong>B.Sc. Computer Science</strong> | State University | 2010 - 2014</p>` +
+	`<h2 id=certifications>Certifications</h2><ul><li><strong>Certified Kubernetes Administrator</strong> (CKA) | Expires March 2027</li>` +
+	`<li><strong>AWS Solutions Architect Associate</strong></li></ul>` +
+	`<h2 id=skills>Skills</h2><h3 id=cloud>Cloud</h3><ul><li><strong>AWS</strong>: EC2, S3, Terraform (Cloud, Enterprise)</li></ul>` +
+	`<h2 id=projects>Projects</h2><h3 id=site>Site</h3><p>A static site.</p>` +
+	`</main></body></html>`
+
+func rendered(t *testing.T, page string) *Resume {
+	t.Helper()
+	doc, err := match.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+	r, err := FromHTML(doc)
+	require.NoError(t, err)
+	return r
+}
+
+func TestCompare(t *testing.T) {
+	data, err := Parse([]byte(sample))
+	require.NoError(t, err)
+
+	assert.Empty(t, Compare(data, rendered(t, sampleHTML)))
+
+	// A dropped bullet, a template that mangles dates and a certification
+	// hard-coded in the layout
+	broken := strings.NewReplacer(
+		"<li>Ran things</li>", "",
+		"Jan 2018 - February 2021", "Jan 2018",
+		"<li><strong>AWS Solutions", "<li><strong>Hard-coded Cert</strong></li><li><strong>AWS Solutions",
+	).Replace(sampleHTML)
+	assert.Equal(t, []string{
+		`"Staff Engineer" bullet "Ran things" is not rendered`,
+		`job "Engineer": dates is "Jan 2018 - February 2021" in data but "Jan 2018" rendered`,
+		`rendered certification "Hard-coded Cert" has no data source`,
+	}, Compare(data, rendered(t, broken)))
+
+	// A section dropped by the template
+	noSkills := strings.NewReplacer(`<h2 id=skills>Skills</h2><h3 id=cloud>Cloud</h3><ul><li><strong>AWS</strong>: EC2, S3, Terraform (Cloud, Enterprise)</li></ul>`, "").Replace(sampleHTML)
+	assert.Equal(t, []string{
+		`section "Skills" is not rendered`,
+		`skill group "Cloud" is not rendered`,
+	}, Compare(data, rendered(t, noSkills)))
+}
+
+func TestPlain(t *testing.T) {
+	assert.Equal(t, "Let's Encrypt - see github.com/x", plain("Let’s **Encrypt** – see [github.com/x](https://github.com/x)"))
+}
@@ -0,0 +1,65 @@
+package resume
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// monthLayouts are the accepted spellings of a month and year
+var monthLayouts = []string{"January 2006", "Jan 2006", "Jan. 2006", "2006-01", "01/2006"}
+
+// currentWords end a period that is still ongoing
+var currentWords = []string{"present", "current", "now", "today"}
+
+// periodSep splits "start - end", with a hyphen, en or em dash or "to"
+var periodSep = regexp.MustCompile(`\s+(?:-|–|—|to)\s+`)
+
+// ParseMonth reads a month and year such as "December 2020" or "2020-12",
+// or a bare year, which stands for January
+func ParseMonth(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range monthLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse("2006", s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a month and year like \"December 2020\"", s)
+}
+
+// Period is a span of months; a zero End means it is ongoing
+type Period struct {
+	Start, End time.Time
+}
+
+// Current reports whether the period is ongoing
+func (p Period) Current() bool {
+	return p.End.IsZero()
+}
+
+// ParsePeriod reads "December 2020 - October 2022" or
+// "May 2023 - Present"
+func ParsePeriod(s string) (Period, error) {
+	parts := periodSep.Split(strings.TrimSpace(s), -1)
+	if len(parts) != 2 {
+		return Period{}, fmt.Errorf("%q is not a period like \"December 2020 - October 2022\"", s)
+	}
+	start, err := ParseMonth(parts[0])
+	if err != nil {
+		return Period{}, err
+	}
+	p := Period{Start: start}
+	for _, w := range currentWords {
+		if strings.EqualFold(strings.TrimSpace(parts[1]), w) {
+			return p, nil
+		}
+	}
+	if p.End, err = ParseMonth(parts[1]); err != nil {
+		return Period{}, err
+	}
+	return p, nil
+}
@@ -0,0 +1,83 @@
+package resume
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultExpiryWindow is how far ahead an expiring certification is
+// flagged, enough time to sit the renewal exam
+const DefaultExpiryWindow = 60 * 24 * time.Hour
+
+// expiryRe finds the end of validity in a certification's details, as in
+// "Expires March 2027" or "Valid until 2027-03-15"
+var expiryRe = regexp.MustCompile(`(?i)\b(?:expires|expiry|expiration|valid until|valid through|valid thru)\s*:?\s*([^|;(]+)`)
+
+// dayLayouts are the accepted spellings of an exact date
+var dayLayouts = []string{"2006-01-02", "January 2, 2006", "Jan 2, 2006", "2 January 2006", "01/02/2006"}
+
+// Expires returns the moment the certification lapses: the day after an
+// exact date, or the start of the month after a month and year. ok is
+// false when the details give no expiry; a date that does not parse is an
+// error.
+func (c Certification) Expires() (ends time.Time, ok bool, err error) {
+	if m := expiryRe.FindStringSubmatch(c.Details); m != nil {
+		s := strings.TrimSpace(m[1])
+		for _, layout := range dayLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.AddDate(0, 0, 1), true, nil
+			}
+		}
+		t, err := ParseMonth(s)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("certification %q: expiry %w", c.Name, err)
+		}
+		return t.AddDate(0, 1, 0), true, nil
+	}
+	// "May 2024 - May 2027" gives the validity as a period
+	for _, part := range strings.Split(c.Details, "|") {
+		if p, err := ParsePeriod(part); err == nil && !p.Current() {
+			return p.End.AddDate(0, 1, 0), true, nil
+		}
+	}
+	return time.Time{}, false, nil
+}
+
+// Expiry is a certification that has lapsed or lapses within the window
+type Expiry struct {
+	Cert    Certification
+	Ends    time.Time
+	Expired bool
+}
+
+func (e Expiry) String() string {
+	last := e.Ends.AddDate(0, 0, -1).Format("2 January 2006")
+	if e.Expired {
+		return fmt.Sprintf("line %d: %q expired on %s", e.Cert.Line, e.Cert.Name, last)
+	}
+	return fmt.Sprintf("line %d: %q expires on %s", e.Cert.Line, e.Cert.Name, last)
+}
+
+// CheckExpiry returns the certifications that have expired by now or
+// expire within window, and how many list an expiry at all
+func CheckExpiry(certs []Certification, now time.Time, window time.Duration) (expiring []Expiry, dated int, err error) {
+	for _, c := range certs {
+		ends, ok, err := c.Expires()
+		if err != nil {
+			return nil, dated, err
+		}
+		if !ok {
+			continue
+		}
+		dated++
+		switch {
+		case !now.Before(ends):
+			expiring = append(expiring, Expiry{Cert: c, Ends: ends, Expired: true})
+		case ends.Sub(now) <= window:
+			expiring = append(expiring, Expiry{Cert: c, Ends: ends})
+		}
+	}
+	return expiring, dated, nil
+}
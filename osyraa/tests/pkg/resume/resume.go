@@ -0,0 +1,260 @@
+// Package resume reads the structured resume out of the site's content
+// file. The resume lives in content/_index.md as Markdown with a fixed
+// shape: "## Section" headings, "### Role" entries with a
+// "**Company** | dates | location" line and bulleted highlights,
+// "- **Certification** (ABBR)" items and "- **Label**: a, b" skills.
+package resume
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Section names used by the resume
+const (
+	SectionSummary        = "Professional Summary"
+	SectionExperience     = "Experience"
+	SectionEducation      = "Education"
+	SectionCertifications = "Certifications"
+	SectionSkills         = "Skills"
+	SectionProjects       = "Projects"
+)
+
+// Resume is the parsed content file
+type Resume struct {
+	FrontMatter    map[string]any
+	Sections       []Section
+	Summary        string
+	Experience     []Job
+	Education      []Education
+	Certifications []Certification
+	Skills         []SkillGroup
+	Projects       []Project
+}
+
+// Section is a "## " heading
+type Section struct {
+	Name string
+	Line int
+}
+
+// Job is an "### " entry under Experience
+type Job struct {
+	Title    string
+	Company  string
+	Dates    string
+	Location string
+	// Highlights are the bullets below the company line
+	Highlights []string
+	Line       int
+}
+
+// Education is a "**Credential** | Institution" line under Education
+type Education struct {
+	Credential  string
+	Institution string
+	Dates       string
+	Line        int
+}
+
+// Certification is a bullet under Certifications
+type Certification struct {
+	Name   string
+	Abbrev string
+	// Details is the text after the name and abbreviation, without the
+	// separating "|"
+	Details string
+	Line    int
+}
+
+// SkillGroup is an "### " category under Skills
+type SkillGroup struct {
+	Category string
+	Skills   []Skill
+	Line     int
+}
+
+// Skill is a "- **Label**: a, b" bullet
+type Skill struct {
+	Label string
+	Items []string
+	Line  int
+}
+
+// Project is an "### " entry under Projects
+type Project struct {
+	Name        string
+	Description string
+	Highlights  []string
+	Line        int
+}
+
+var (
+	companyRe = regexp.MustCompile(`^\*\*(.+?)\*\*\s*(?:\|\s*(.*?))?\s*(?:\|\s*(.*?))?\s*$`)
+	certRe    = regexp.MustCompile(`^\*\*(.+?)\*\*\s*(?:\((.+?)\))?\s*(?:\|\s*)?(.*)$`)
+	skillRe   = regexp.MustCompile(`^\*\*(.+?)\*\*\s*:\s*(.*)$`)
+)
+
+// Load reads and parses a content file
+func Load(path string) (*Resume, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Parse reads the front matter and Markdown body of a content file.
+// Unrecognised lines are skipped; Validate reports what is missing.
+func Parse(data []byte) (*Resume, error) {
+	r := &Resume{}
+	body, offset, err := splitFrontMatter(data, &r.FrontMatter)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		section string
+		job     *Job
+		group   *SkillGroup
+		project *Project
+		summary []string
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for n := offset + 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "## "):
+			section = strings.TrimSpace(line[3:])
+			r.Sections = append(r.Sections, Section{Name: section, Line: n})
+			job, group, project = nil, nil, nil
+			continue
+		}
+
+		bullet, isBullet := strings.CutPrefix(line, "- ")
+		bullet = strings.TrimSpace(bullet)
+		heading, isHeading := strings.CutPrefix(line, "### ")
+		heading = strings.TrimSpace(heading)
+
+		switch section {
+		case SectionSummary:
+			summary = append(summary, line)
+		case SectionExperience:
+			switch {
+			case isHeading:
+				r.Experience = append(r.Experience, Job{Title: heading, Line: n})
+				job = &r.Experience[len(r.Experience)-1]
+			case job == nil:
+			case isBullet:
+				job.Highlights = append(job.Highlights, bullet)
+			case job.Company == "":
+				if m := companyRe.FindStringSubmatch(line); m != nil {
+					job.Company, job.Dates, job.Location = m[1], m[2], m[3]
+				}
+			}
+		case SectionEducation:
+			if m := companyRe.FindStringSubmatch(strings.TrimPrefix(line, "- ")); m != nil {
+				r.Education = append(r.Education, Education{Credential: m[1], Institution: m[2], Dates: m[3], Line: n})
+			}
+		case SectionCertifications:
+			if !isBullet {
+				continue
+			}
+			c := Certification{Name: bullet, Line: n}
+			if m := certRe.FindStringSubmatch(bullet); m != nil {
+				c.Name, c.Abbrev, c.Details = m[1], m[2], strings.TrimSpace(m[3])
+			}
+			r.Certifications = append(r.Certifications, c)
+		case SectionSkills:
+			switch {
+			case isHeading:
+				r.Skills = append(r.Skills, SkillGroup{Category: heading, Line: n})
+				group = &r.Skills[len(r.Skills)-1]
+			case group != nil && isBullet:
+				s := Skill{Label: strings.Trim(bullet, "* "), Line: n}
+				if m := skillRe.FindStringSubmatch(bullet); m != nil {
+					s.Label, s.Items = m[1], splitList(m[2])
+				}
+				group.Skills = append(group.Skills, s)
+			}
+		case SectionProjects:
+			switch {
+			case isHeading:
+				r.Projects = append(r.Projects, Project{Name: heading, Line: n})
+				project = &r.Projects[len(r.Projects)-1]
+			case project == nil:
+			case isBullet:
+				project.Highlights = append(project.Highlights, bullet)
+			case project.Description == "":
+				project.Description = line
+			}
+		}
+	}
+	r.Summary = strings.Join(summary, " ")
+	return r, scanner.Err()
+}
+
+// Section returns the named section, if the resume has it
+func (r *Resume) Section(name string) (Section, bool) {
+	for _, s := range r.Sections {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+// splitFrontMatter decodes a leading "---" YAML block into fm and returns
+// the body with the number of lines before it
+func splitFrontMatter(data []byte, fm *map[string]any) ([]byte, int, error) {
+	rest, ok := bytes.CutPrefix(data, []byte("---\n"))
+	if !ok {
+		return data, 0, nil
+	}
+	end := bytes.Index(rest, []byte("\n---"))
+	if end < 0 {
+		return nil, 0, fmt.Errorf("front matter is not closed")
+	}
+	if err := yaml.Unmarshal(rest[:end], fm); err != nil {
+		return nil, 0, fmt.Errorf("front matter: %w", err)
+	}
+	body := rest[end+len("\n---"):]
+	body, _ = bytes.CutPrefix(body, []byte("\n"))
+	return body, bytes.Count(data[:len(data)-len(body)], []byte("\n")), nil
+}
+
+// splitList splits a comma-separated list, keeping commas inside
+// parentheses, as in "Terraform (Cloud, Enterprise)"
+func splitList(s string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(items) > 0 {
+		items = append(items, last)
+	}
+	return items
+}
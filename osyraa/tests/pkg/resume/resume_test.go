@@ -0,0 +1,244 @@
+package resume
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `---
+title: "Jane Doe - Resume"
+draft: false
+---
+
+## Professional Summary
+
+Platform engineer.
+
+## Experience
+
+### Staff Engineer
+**Acme Corp** | March 2021 - Present | Remote
+
+- Built things
+- Ran things
+
+### Engineer
+**Initech** | Jan 2018 - February 2021
+
+- Fixed things
+
+## Education
+
+**B.Sc. Computer Science** | State University | 2010 - 2014
+
+## Certifications
+
+- **Certified Kubernetes Administrator** (CKA) | Expires March 2027
+- **AWS Solutions Architect Associate**
+
+## Skills
+
+### Cloud
+- **AWS**: EC2, S3, Terraform (Cloud, Enterprise)
+
+## Projects
+
+### Site
+A static site.
+`
+
+func TestParse(t *testing.T) {
+	r, err := Parse([]byte(sample))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Jane Doe - Resume", r.FrontMatter["title"])
+	assert.Equal(t, []Section{
+		{"Professional Summary", 6}, {"Experience", 10}, {"Education", 23},
+		{"Certifications", 27}, {"Skills", 32}, {"Projects", 37},
+	}, r.Sections)
+	assert.Equal(t, "Platform engineer.", r.Summary)
+
+	require.Len(t, r.Experience, 2)
+	assert.Equal(t, Job{Title: "Staff Engineer", Company: "Acme Corp", Dates: "March 2021 - Present", Location: "Remote",
+		Highlights: []string{"Built things", "Ran things"}, Line: 12}, r.Experience[0])
+	assert.Equal(t, "Jan 2018 - February 2021", r.Experience[1].Dates)
+	assert.Empty(t, r.Experience[1].Location)
+
+	assert.Equal(t, []Education{{Credential: "B.Sc. Computer Science", Institution: "State University", Dates: "2010 - 2014", Line: 25}}, r.Education)
+	assert.Equal(t, []Certification{
+		{Name: "Certified Kubernetes Administrator", Abbrev: "CKA", Details: "Expires March 2027", Line: 29},
+		{Name: "AWS Solutions Architect Associate", Line: 30},
+	}, r.Certifications)
+	require.Len(t, r.Skills, 1)
+	assert.Equal(t, []Skill{{Label: "AWS", Items: []string{"EC2", "S3", "Terraform (Cloud, Enterprise)"}, Line: 35}}, r.Skills[0].Skills)
+	assert.Equal(t, []Project{{Name: "Site", Description: "A static site.", Line: 39}}, r.Projects)
+
+	_, err = Parse([]byte("---\ntitle: x\n"))
+	assert.ErrorContains(t, err, "not closed")
+}
+
+func TestParsePeriod(t *testing.T) {
+	p, err := ParsePeriod("December 2020 - October 2022")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC), p.Start)
+	assert.Equal(t, time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC), p.End)
+
+	p, err = ParsePeriod("2023-05 – Present")
+	require.NoError(t, err)
+	assert.True(t, p.Current())
+
+	_, err = ParsePeriod("Dcember 2020 - October 2022")
+	assert.ErrorContains(t, err, `"Dcember 2020" is not a month and year`)
+	_, err = ParsePeriod("2020")
+	assert.ErrorContains(t, err, "is not a period")
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2026, time.June, 15, 0, 0, 0, 0, time.UTC)
+	r, err := Parse([]byte(sample))
+	require.NoError(t, err)
+	assert.Empty(t, Validate(r, now))
+
+	r, err = Parse([]byte(`## Professional Summary
+
+## Experience
+
+### Engineer
+**Acme** | May 2022 - March 2021
+
+### Intern
+**Globex** | June 2026 - Sometime
+
+- Coffee
+
+### Future
+**Hooli** | Jan 2027 - Present
+
+- Plans
+
+## Education
+
+**B.Sc.** | State University | 2014 - 2010
+
+## Certifications
+
+- **CKA**
+- **cka**
+
+## Skills
+
+### Cloud
+- **AWS**
+`))
+	require.NoError(t, err)
+	var got []string
+	for _, p := range Validate(r, now) {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		`line 1: section "Professional Summary" is empty`,
+		`line 5: "Engineer" ends before it starts (May 2022 - March 2021)`,
+		`line 5: "Engineer" has no description bullets`,
+		`line 8: "Intern": "Sometime" is not a month and year like "December 2020"`,
+		`line 13: "Future" has dates in the future (Jan 2027 - Present)`,
+		`line 20: "B.Sc." ends before it starts (2014 - 2010)`,
+		`line 25: certification "cka" is already listed on line 24`,
+		`line 30: skill "AWS" lists nothing; use "- **AWS**: a, b"`,
+	}, got)
+}
+
+func TestExpires(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	for details, want := range map[string]time.Time{
+		"Expires March 2027":                   day(2027, time.April, 1),
+		"Issued May 2024 | Expiry: 2027-05-14": day(2027, time.May, 15),
+		"valid until Jan 2, 2026 (renewing)":   day(2026, time.January, 3),
+		"May 2024 - May 2027":                  day(2027, time.June, 1),
+	} {
+		ends, ok, err := Certification{Details: details}.Expires()
+		require.NoError(t, err, details)
+		assert.True(t, ok, details)
+		assert.Equal(t, want, ends, details)
+	}
+
+	_, ok, err := Certification{Details: "Issued May 2024"}.Expires()
+	assert.NoError(t, err)
+	assert.False(t, ok, "an issue date alone sets no expiry")
+	_, _, err = Certification{Name: "CKA", Details: "Expires soon"}.Expires()
+	assert.ErrorContains(t, err, `certification "CKA": expiry "soon" is not a month and year`)
+}
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	certs := []Certification{
+		{Name: "CKA", Details: "Expires February 2026", Line: 1},
+		{Name: "CKAD", Details: "Expires 2026-04-20", Line: 2},
+		{Name: "LFCS", Details: "Expires 2027-01-01", Line: 3},
+		{Name: "AZ-104", Line: 4},
+	}
+	expiring, dated, err := CheckExpiry(certs, now, DefaultExpiryWindow)
+	require.NoError(t, err)
+	assert.Equal(t, 3, dated)
+	var got []string
+	for _, e := range expiring {
+		got = append(got, e.String())
+	}
+	assert.Equal(t, []string{
+		`line 1: "CKA" expired on 28 February 2026`,
+		`line 2: "CKAD" expires on 20 April 2026`,
+	}, got)
+	assert.True(t, expiring[0].Expired)
+	assert.False(t, expiring[1].Expired)
+}
+
+func TestCheckChronology(t *testing.T) {
+	r, err := Parse([]byte(sample))
+	require.NoError(t, err)
+	assert.Empty(t, CheckChronology(r, DefaultChronology()))
+
+	r, err = Parse([]byte(`## Experience
+
+### Intern
+**Initech** | June 2015 - August 2015
+
+### Staff Engineer
+**Acme** | March 2021 - Present
+
+### Contractor
+**Globex** | May 2022 - Present
+
+### Engineer
+**Hooli** | Jan 2018 - February 2021
+
+## Education
+
+**B.Sc.** | State University | 2010 - 2014
+
+**M.Sc.** | State University | 2016
+`))
+	require.NoError(t, err)
+	var got []string
+	for _, p := range CheckChronology(r, DefaultChronology()) {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		`line 6: job "Staff Engineer" is newer than "Intern" above it; list entries newest first`,
+		`line 9: job "Contractor" is newer than "Staff Engineer" above it; list entries newest first`,
+		`line 19: education entry "M.Sc." is newer than "B.Sc." above it; list entries newest first`,
+		`line 1: 2 jobs end in "Present" (["Staff Engineer" "Contractor"]), expected at most 1`,
+	}, got)
+
+	got = nil
+	for _, p := range CheckChronology(r, ChronologyOptions{Order: OldestFirst, MaxCurrent: 2}) {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		`line 12: job "Engineer" is older than "Contractor" above it; list entries oldest first`,
+	}, got)
+
+	assert.Equal(t, []Problem{{Message: `unknown order "sideways"; use "newest-first" or "oldest-first"`}},
+		CheckChronology(r, ChronologyOptions{Order: "sideways"}))
+}
@@ -0,0 +1,135 @@
+package resume
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RequiredSections must be present and non-empty
+var RequiredSections = []string{SectionExperience, SectionEducation, SectionCertifications, SectionSkills}
+
+// Problem is a validation failure at a line of the content file
+type Problem struct {
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// Validate checks the resume has every required section with entries,
+// that dates parse and are not in the future relative to now, and that no
+// entry has an empty title or description
+func Validate(r *Resume, now time.Time) []Problem {
+	var problems []Problem
+	add := func(line int, format string, args ...any) {
+		problems = append(problems, Problem{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	entries := map[string]int{
+		SectionExperience:     len(r.Experience),
+		SectionEducation:      len(r.Education),
+		SectionCertifications: len(r.Certifications),
+		SectionSkills:         len(r.Skills),
+	}
+	for _, name := range RequiredSections {
+		s, ok := r.Section(name)
+		switch {
+		case !ok:
+			add(0, "missing required section \"## %s\"", name)
+		case entries[name] == 0:
+			add(s.Line, "section %q has no entries", name)
+		}
+	}
+	if s, ok := r.Section(SectionSummary); ok && strings.TrimSpace(r.Summary) == "" {
+		add(s.Line, "section %q is empty", SectionSummary)
+	}
+
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, j := range r.Experience {
+		if j.Title == "" {
+			add(j.Line, "job has no title")
+		}
+		if j.Company == "" {
+			add(j.Line, "%q has no \"**Company** | dates | location\" line", j.Title)
+		} else if p, err := ParsePeriod(j.Dates); err != nil {
+			add(j.Line, "%q: %v", j.Title, err)
+		} else {
+			if !p.Current() && p.End.Before(p.Start) {
+				add(j.Line, "%q ends before it starts (%s)", j.Title, j.Dates)
+			}
+			if p.Start.After(thisMonth) || p.End.After(thisMonth) {
+				add(j.Line, "%q has dates in the future (%s)", j.Title, j.Dates)
+			}
+		}
+		if len(j.Highlights) == 0 {
+			add(j.Line, "%q has no description bullets", j.Title)
+		}
+		for _, h := range j.Highlights {
+			if strings.Trim(h, " *_") == "" {
+				add(j.Line, "%q has an empty bullet", j.Title)
+			}
+		}
+	}
+
+	for _, e := range r.Education {
+		if strings.TrimSpace(e.Credential) == "" || strings.TrimSpace(e.Institution) == "" {
+			add(e.Line, "education entries need \"**Credential** | Institution\"")
+		}
+		if e.Dates != "" {
+			if p, err := ParsePeriod(e.Dates); err != nil {
+				if _, err := ParseMonth(e.Dates); err != nil {
+					add(e.Line, "%q: %v", e.Credential, err)
+				}
+			} else if !p.Current() && p.End.Before(p.Start) {
+				add(e.Line, "%q ends before it starts (%s)", e.Credential, e.Dates)
+			}
+		}
+	}
+
+	seen := map[string]int{}
+	for _, c := range r.Certifications {
+		name := strings.Trim(c.Name, " *_")
+		if name == "" {
+			add(c.Line, "certification has no name")
+			continue
+		}
+		if _, _, err := c.Expires(); err != nil {
+			add(c.Line, "%v", err)
+		}
+		if first, dup := seen[strings.ToLower(name)]; dup {
+			add(c.Line, "certification %q is already listed on line %d", name, first)
+			continue
+		}
+		seen[strings.ToLower(name)] = c.Line
+	}
+
+	for _, g := range r.Skills {
+		if len(g.Skills) == 0 {
+			add(g.Line, "skill group %q is empty", g.Category)
+		}
+		for _, s := range g.Skills {
+			if len(s.Items) == 0 {
+				add(s.Line, "skill %q lists nothing; use \"- **%s**: a, b\"", s.Label, s.Label)
+			}
+			for _, item := range s.Items {
+				if item == "" {
+					add(s.Line, "skill %q has an empty item", s.Label)
+					break
+				}
+			}
+		}
+	}
+
+	for _, p := range r.Projects {
+		if p.Description == "" && len(p.Highlights) == 0 {
+			add(p.Line, "project %q has no description", p.Name)
+		}
+	}
+	return problems
+}
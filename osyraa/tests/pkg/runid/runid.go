@@ -0,0 +1,69 @@
+// Package runid identifies one invocation of the harness. The ID goes on
+// everything the run produces (container labels, log lines, report files
+// and the requests it sends), so any artifact or server log entry can be
+// traced back to the run that made it.
+package runid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Header carries the run ID on every request
+const Header = "X-Osyraa-Run"
+
+var invalidChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// New returns id made safe for image tags, container names and file names
+// (lowercase letters, digits, _ . and -), or a fresh ID from the process ID
+// and random bytes when id is empty
+func New(id string) string {
+	if id = strings.Trim(invalidChars.ReplaceAllString(strings.ToLower(id), "-"), "-."); id != "" {
+		return id
+	}
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%d-%s", os.Getpid(), hex.EncodeToString(b))
+}
+
+// UserAgent is the User-Agent of requests that don't set their own
+func UserAgent(id string) string {
+	return "osyraa/" + id
+}
+
+// Transport adds the run ID to requests: always as Header, and as the
+// User-Agent unless the request already has one, so checks that imitate a
+// browser keep doing so
+type Transport struct {
+	ID string
+	// Next sends the request; nil is http.DefaultTransport
+	Next http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, t.ID)
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent(t.ID))
+	}
+	return next.RoundTrip(req)
+}
+
+// Install routes every client that uses http.DefaultTransport, which is
+// any client without a Transport of its own, through a Transport for id.
+// It returns a func restoring the previous default.
+func Install(id string) (restore func()) {
+	prev := http.DefaultTransport
+	http.DefaultTransport = &Transport{ID: id, Next: prev}
+	return func() { http.DefaultTransport = prev }
+}
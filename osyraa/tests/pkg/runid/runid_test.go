@@ -0,0 +1,51 @@
+package runid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	assert.Equal(t, "ci-build_42.1", New("CI Build_42.1"))
+	assert.Equal(t, "pr-17", New("--PR#17."))
+	assert.Regexp(t, regexp.MustCompile(`^[0-9]+-[0-9a-f]{8}$`), New(""))
+	assert.NotEqual(t, New(""), New(""))
+}
+
+func TestTransport(t *testing.T) {
+	var got []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Clone())
+	}))
+	defer srv.Close()
+	restore := Install("ci-42")
+	defer restore()
+
+	client := &http.Client{}
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, req.Header.Get(Header), "the caller's request is not modified")
+
+	require.Len(t, got, 2)
+	assert.Equal(t, "osyraa/ci-42", got[0].Get("User-Agent"))
+	assert.Equal(t, "ci-42", got[0].Get(Header))
+	assert.Equal(t, "Mozilla/5.0", got[1].Get("User-Agent"), "an explicit User-Agent is kept")
+	assert.Equal(t, "ci-42", got[1].Get(Header))
+
+	restore()
+	_, ok := http.DefaultTransport.(*http.Transport)
+	assert.True(t, ok)
+}
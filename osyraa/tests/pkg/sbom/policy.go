@@ -0,0 +1,280 @@
+package sbom
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is what an image's packages must satisfy. Package patterns are
+// type:name globs in path.Match syntax, e.g. apk:* or *:*-dev.
+type Policy struct {
+	Licenses struct {
+		// Deny lists SPDX license IDs no package may be under
+		Deny []string `yaml:"deny"`
+		// Unknown fails packages with no license information
+		Unknown bool `yaml:"fail-unknown"`
+	} `yaml:"licenses"`
+	Packages struct {
+		// Allow lists the packages expected in the image; anything else is
+		// unexpected. Empty expects anything.
+		Allow []string `yaml:"allow"`
+		// Deny lists packages that must not be in the image even when
+		// Allow matches them, such as build tools
+		Deny []string `yaml:"deny"`
+	} `yaml:"packages"`
+}
+
+// Policy rules a violation can break
+const (
+	DisallowedLicense = "disallowed-license"
+	UnknownLicense    = "unknown-license"
+	UnexpectedPackage = "unexpected-package"
+	DeniedPackage     = "denied-package"
+)
+
+// Violation is a package that breaks the policy
+type Violation struct {
+	Package Package
+	Rule    string
+	Detail  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.Package, v.Rule, v.Detail)
+}
+
+// ParsePolicy decodes a policy file, rejecting unknown keys and malformed
+// patterns
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for _, pattern := range append(p.Packages.Allow, p.Packages.Deny...) {
+		if !strings.Contains(pattern, ":") {
+			return nil, fmt.Errorf("package pattern %q should be type:name, e.g. apk:musl or *:*-dev", pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("package pattern %q: %w", pattern, err)
+		}
+	}
+	return &p, nil
+}
+
+// LoadPolicy reads and parses the policy file at path
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return p, nil
+}
+
+// Check returns every policy violation in the SBOM, in package order
+func (p *Policy) Check(s *SBOM) []Violation {
+	var out []Violation
+	for _, pkg := range s.Packages {
+		if pattern, ok := matchAny(p.Packages.Deny, pkg); ok {
+			out = append(out, Violation{pkg, DeniedPackage, "denied by " + pattern})
+		} else if _, ok := matchAny(p.Packages.Allow, pkg); !ok && len(p.Packages.Allow) > 0 {
+			out = append(out, Violation{pkg, UnexpectedPackage, "matches no allowed pattern"})
+		}
+		if len(pkg.Licenses) == 0 {
+			if p.Licenses.Unknown {
+				out = append(out, Violation{pkg, UnknownLicense, "no license information"})
+			}
+			continue
+		}
+		for _, license := range pkg.Licenses {
+			denied, err := deniedLicenses(license, p.Licenses.Deny)
+			switch {
+			case err != nil:
+				out = append(out, Violation{pkg, UnknownLicense, fmt.Sprintf("cannot read %q: %v", license, err)})
+			case len(denied) > 0:
+				out = append(out, Violation{pkg, DisallowedLicense, license + " requires " + strings.Join(denied, ", ")})
+			}
+		}
+	}
+	return out
+}
+
+func matchAny(patterns []string, pkg Package) (string, bool) {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, pkg.ID()); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// deniedLicenses returns the denied licenses an SPDX expression commits to.
+// An OR offers a choice, so it's only a problem when every alternative
+// includes a denied license; AND requires all its terms.
+func deniedLicenses(expr string, deny []string) ([]string, error) {
+	if len(deny) == 0 {
+		return nil, nil
+	}
+	l, err := parseLicense(expr)
+	if err != nil {
+		return nil, err
+	}
+	return l.denied(deny), nil
+}
+
+// license is a parsed SPDX license expression: a license ID, or the terms
+// an AND or OR combines
+type license struct {
+	id    string
+	op    string
+	terms []*license
+}
+
+// denied returns the denied licenses l commits to, none when it can be
+// used without any
+func (l *license) denied(deny []string) []string {
+	switch l.op {
+	case "AND":
+		var all []string
+		for _, t := range l.terms {
+			for _, d := range t.denied(deny) {
+				if !slices.Contains(all, d) {
+					all = append(all, d)
+				}
+			}
+		}
+		return all
+	case "OR":
+		// the least encumbered alternative, the first of equals
+		var best []string
+		for i, t := range l.terms {
+			d := t.denied(deny)
+			if len(d) == 0 {
+				return nil
+			}
+			if i == 0 || len(d) < len(best) {
+				best = d
+			}
+		}
+		return best
+	}
+	for _, d := range deny {
+		if strings.EqualFold(l.id, d) {
+			return []string{d}
+		}
+	}
+	return nil
+}
+
+// parseLicense parses an SPDX license expression. WITH binds tightest,
+// then AND, then OR, and parentheses group; operators match in any case.
+func parseLicense(expr string) (*license, error) {
+	p := &licenseParser{tokens: strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))}
+	l, err := p.binary("OR")
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok != "" {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+	return l, nil
+}
+
+type licenseParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *licenseParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// binary parses terms joined by op, each term the next tighter operator
+func (p *licenseParser) binary(op string) (*license, error) {
+	term := p.with
+	if op == "OR" {
+		term = func() (*license, error) { return p.binary("AND") }
+	}
+	first, err := term()
+	if err != nil {
+		return nil, err
+	}
+	l := &license{op: strings.ToUpper(op), terms: []*license{first}}
+	for strings.EqualFold(p.peek(), op) {
+		p.pos++
+		next, err := term()
+		if err != nil {
+			return nil, err
+		}
+		l.terms = append(l.terms, next)
+	}
+	if len(l.terms) == 1 {
+		return first, nil
+	}
+	return l, nil
+}
+
+// with parses a license or group with an optional WITH exception, which
+// only grants permissions and so is dropped
+func (p *licenseParser) with() (*license, error) {
+	l, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.pos++
+		if tok := p.peek(); tok == "" || tok == "(" || tok == ")" || isLicenseOperator(tok) {
+			return nil, errors.New("WITH needs an exception")
+		}
+		p.pos++
+	}
+	return l, nil
+}
+
+func (p *licenseParser) primary() (*license, error) {
+	switch tok := p.peek(); {
+	case tok == "":
+		return nil, errors.New("unexpected end of expression")
+	case tok == "(":
+		p.pos++
+		l, err := p.binary("OR")
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return l, nil
+	case tok == ")" || isLicenseOperator(tok):
+		return nil, fmt.Errorf("unexpected %q", tok)
+	default:
+		p.pos++
+		return &license{id: tok}, nil
+	}
+}
+
+func isLicenseOperator(tok string) bool {
+	for _, op := range []string{"AND", "OR", "WITH"} {
+		if strings.EqualFold(tok, op) {
+			return true
+		}
+	}
+	return false
+}
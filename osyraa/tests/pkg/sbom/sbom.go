@@ -0,0 +1,155 @@
+// Package sbom generates a software bill of materials for a container
+// image with syft and checks it against a policy of disallowed licenses
+// and expected packages. Like the vulnerability scan, syft runs from its
+// container image against an archive written by docker save, rather than
+// as a library: that keeps syft's several hundred modules out of this
+// module's dependencies and pins it by image tag as Trivy and ZAP are.
+package sbom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dockerrun"
+)
+
+// DefaultImage is the syft release the SBOM is generated with
+const DefaultImage = "anchore/syft:v1.4.1"
+
+// Files syft writes next to the archive: CycloneDX, which the policy reads,
+// and SPDX for tools that want it
+const (
+	CycloneDXFile = "sbom.cdx.json"
+	SPDXFile      = "sbom.spdx.json"
+)
+
+// Package is one package syft found in the image
+type Package struct {
+	// Type is the package URL type: apk, deb, npm, golang, generic for
+	// binaries syft recognised, or empty when syft gave no package URL
+	Type    string
+	Name    string
+	Version string
+	PURL    string
+	// Licenses are SPDX IDs or expressions, or names syft couldn't map
+	Licenses []string
+}
+
+// ID is the package as policies name it, type:name
+func (p Package) ID() string {
+	return p.Type + ":" + p.Name
+}
+
+func (p Package) String() string {
+	return p.ID() + "@" + p.Version
+}
+
+// SBOM is the packages of an image
+type SBOM struct {
+	Packages []Package
+}
+
+type cycloneDX struct {
+	BOMFormat  string `json:"bomFormat"`
+	Components []struct {
+		Type     string `json:"type"`
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		PURL     string `json:"purl"`
+		Licenses []struct {
+			License struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"license"`
+			Expression string `json:"expression"`
+		} `json:"licenses"`
+	} `json:"components"`
+}
+
+// ParseCycloneDX reads the packages from a CycloneDX JSON document, sorted
+// by ID. Components that aren't packages, such as the operating system
+// and files, are left out.
+func ParseCycloneDX(data []byte) (*SBOM, error) {
+	var doc cycloneDX
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse CycloneDX SBOM: %w", err)
+	}
+	if doc.BOMFormat != "CycloneDX" {
+		return nil, fmt.Errorf("parse CycloneDX SBOM: bomFormat is %q", doc.BOMFormat)
+	}
+	s := &SBOM{}
+	for _, c := range doc.Components {
+		if c.Type != "library" && c.Type != "application" && c.Type != "framework" {
+			continue
+		}
+		p := Package{Type: purlType(c.PURL), Name: c.Name, Version: c.Version, PURL: c.PURL}
+		for _, l := range c.Licenses {
+			switch {
+			case l.Expression != "":
+				p.Licenses = append(p.Licenses, l.Expression)
+			case l.License.ID != "":
+				p.Licenses = append(p.Licenses, l.License.ID)
+			case l.License.Name != "":
+				p.Licenses = append(p.Licenses, l.License.Name)
+			}
+		}
+		s.Packages = append(s.Packages, p)
+	}
+	sort.Slice(s.Packages, func(i, j int) bool { return s.Packages[i].String() < s.Packages[j].String() })
+	return s, nil
+}
+
+// purlType is the type of a package URL, pkg:<type>/<namespace>/<name>
+func purlType(purl string) string {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return ""
+	}
+	typ, _, _ := strings.Cut(rest, "/")
+	typ, _ = url.PathUnescape(typ)
+	return strings.ToLower(typ)
+}
+
+// Options configure SBOM generation
+type Options struct {
+	// Image defaults to DefaultImage
+	Image string
+	// RunOptions name, label and pull the syft container
+	dockerrun.RunOptions
+}
+
+// Generate runs syft on the image archive in dir/name, writing
+// CycloneDXFile and SPDXFile to dir, and returns the parsed SBOM
+func Generate(ctx context.Context, docker dockerrun.Client, dir, name string, opts Options) (*SBOM, error) {
+	if opts.Image == "" {
+		opts.Image = DefaultImage
+	}
+	workDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	res, err := dockerrun.Run(ctx, docker, dockerrun.Container{
+		Image: opts.Image,
+		Cmd: []string{"docker-archive:/work/" + name, "--quiet",
+			"--output", "cyclonedx-json=/work/" + CycloneDXFile, "--output", "spdx-json=/work/" + SPDXFile},
+		Mounts:     []dockerrun.Mount{{Source: workDir, Target: "/work"}},
+		RunOptions: opts.RunOptions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("syft: %w", err)
+	}
+	if err := res.ErrExit("syft"); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, CycloneDXFile))
+	if err != nil {
+		return nil, fmt.Errorf("syft wrote no SBOM: %w", err)
+	}
+	return ParseCycloneDX(data)
+}
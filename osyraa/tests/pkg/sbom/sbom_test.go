@@ -0,0 +1,108 @@
+package sbom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sample is trimmed from syft's cyclonedx-json output for an nginx image
+const sample = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "components": [
+    {"type": "library", "name": "musl", "version": "1.2.4_git20230717-r4", "purl": "pkg:apk/alpine/musl@1.2.4_git20230717-r4?arch=x86_64",
+     "licenses": [{"license": {"id": "MIT"}}]},
+    {"type": "library", "name": "busybox", "version": "1.36.1-r15", "purl": "pkg:apk/alpine/busybox@1.36.1-r15",
+     "licenses": [{"license": {"id": "GPL-2.0-only"}}]},
+    {"type": "library", "name": "libgcc", "version": "13.2.1-r2", "purl": "pkg:apk/alpine/libgcc@13.2.1-r2",
+     "licenses": [{"expression": "GPL-2.0-or-later AND LGPL-2.1-or-later"}]},
+    {"type": "library", "name": "lodash", "version": "4.17.20", "purl": "pkg:npm/lodash@4.17.20",
+     "licenses": [{"license": {"name": "Custom"}}]},
+    {"type": "application", "name": "nginx", "version": "1.25.4", "purl": "pkg:generic/nginx@1.25.4"},
+    {"type": "operating-system", "name": "alpine", "version": "3.19.1"},
+    {"type": "file", "name": "/etc/nginx/nginx.conf"}
+  ]
+}`
+
+func TestParseCycloneDX(t *testing.T) {
+	s, err := ParseCycloneDX([]byte(sample))
+	require.NoError(t, err)
+	var ids []string
+	for _, p := range s.Packages {
+		ids = append(ids, p.String())
+	}
+	assert.Equal(t, []string{"apk:busybox@1.36.1-r15", "apk:libgcc@13.2.1-r2", "apk:musl@1.2.4_git20230717-r4",
+		"generic:nginx@1.25.4", "npm:lodash@4.17.20"}, ids)
+	assert.Equal(t, []string{"GPL-2.0-or-later AND LGPL-2.1-or-later"}, s.Packages[1].Licenses)
+	assert.Equal(t, []string{"Custom"}, s.Packages[4].Licenses)
+
+	_, err = ParseCycloneDX([]byte(`{"spdxVersion": "SPDX-2.3"}`))
+	assert.ErrorContains(t, err, "bomFormat")
+}
+
+func TestPolicyCheck(t *testing.T) {
+	s, err := ParseCycloneDX([]byte(sample))
+	require.NoError(t, err)
+	p, err := ParsePolicy([]byte(`
+licenses:
+  deny: [GPL-2.0-or-later, AGPL-3.0-only]
+  fail-unknown: true
+packages:
+  allow: ["apk:*", "generic:nginx"]
+  deny: ["apk:busybox"]
+`))
+	require.NoError(t, err)
+	var got []string
+	for _, v := range p.Check(s) {
+		got = append(got, v.String())
+	}
+	assert.Equal(t, []string{
+		"apk:busybox@1.36.1-r15: denied-package: denied by apk:busybox",
+		"apk:libgcc@13.2.1-r2: disallowed-license: GPL-2.0-or-later AND LGPL-2.1-or-later requires GPL-2.0-or-later",
+		"generic:nginx@1.25.4: unknown-license: no license information",
+		"npm:lodash@4.17.20: unexpected-package: matches no allowed pattern",
+	}, got)
+}
+
+func TestDeniedLicenses(t *testing.T) {
+	deny := []string{"AGPL-3.0-only", "GPL-3.0-only"}
+	for expr, want := range map[string][]string{
+		"MIT":                                   nil,
+		"agpl-3.0-only":                         {"AGPL-3.0-only"},
+		"MIT OR AGPL-3.0-only":                  nil,
+		"AGPL-3.0-only OR GPL-3.0-only":         {"AGPL-3.0-only"},
+		"(MIT AND GPL-3.0-only)":                {"GPL-3.0-only"},
+		"GPL-3.0-only WITH GCC-exception-3.1":   {"GPL-3.0-only"},
+		"GPL-2.0-only WITH Classpath-exception": nil,
+		// grouping and precedence: AND binds tighter than OR
+		"(GPL-2.0-only OR MIT) AND AGPL-3.0-only":                   {"AGPL-3.0-only"},
+		"MIT OR GPL-3.0-only AND AGPL-3.0-only":                     nil,
+		"GPL-3.0-only AND (MIT OR AGPL-3.0-only)":                   {"GPL-3.0-only"},
+		"(MIT OR GPL-3.0-only) AND (AGPL-3.0-only OR GPL-3.0-only)": {"AGPL-3.0-only"},
+		"GPL-3.0-only and (AGPL-3.0-only or MIT)":                   {"GPL-3.0-only"},
+	} {
+		got, err := deniedLicenses(expr, deny)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, got, expr)
+	}
+
+	got, err := deniedLicenses("(GPL-2.0-only OR MIT) AND AGPL-3.0-only", []string{"AGPL-3.0-only"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AGPL-3.0-only"}, got, "the OR inside parentheses does not make AGPL optional")
+
+	for _, expr := range []string{"", "MIT AND", "(MIT OR GPL-3.0-only", "MIT)", "MIT GPL-3.0-only", "GPL-3.0-only WITH"} {
+		_, err := deniedLicenses(expr, deny)
+		assert.Error(t, err, "%q is malformed", expr)
+	}
+}
+
+func TestParsePolicyRejects(t *testing.T) {
+	_, err := ParsePolicy([]byte("packages:\n  allow: [musl]\n"))
+	assert.ErrorContains(t, err, "type:name")
+	_, err = ParsePolicy([]byte("packages:\n  allow: [\"apk:[\"]\n"))
+	assert.Error(t, err)
+	_, err = ParsePolicy([]byte("license:\n  deny: [MIT]\n"))
+	assert.Error(t, err, "unknown keys are rejected")
+}
@@ -0,0 +1,258 @@
+// Package schedule reads the publishing dates out of a Hugo content tree
+// and says which pages a build at a given moment should publish. Hugo
+// leaves out drafts, pages whose publishDate (or date) is still to come
+// and pages whose expiryDate has passed, so a build's output depends on
+// when it runs; building with a fixed clock at each page's boundaries
+// checks scheduled content appears, and expired content goes, on time.
+package schedule
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Page is one content file's schedule
+type Page struct {
+	// File is relative to the content directory, with forward slashes
+	File string
+	// URL is the page's path on the site, e.g. "/posts/launch/"
+	URL         string
+	Draft       bool
+	Date        time.Time
+	PublishDate time.Time
+	ExpiryDate  time.Time
+}
+
+// Published is when Hugo starts publishing the page: its publishDate,
+// falling back to its date
+func (p Page) Published() time.Time {
+	if !p.PublishDate.IsZero() {
+		return p.PublishDate
+	}
+	return p.Date
+}
+
+// Live reports whether a build at the instant at publishes the page
+func (p Page) Live(at time.Time) bool {
+	if p.Draft || p.Published().After(at) {
+		return false
+	}
+	return p.ExpiryDate.IsZero() || !p.ExpiryDate.Before(at)
+}
+
+// Scheduled reports whether the page's presence depends on the build
+// date, or whether it is a draft that no build may publish. The home page
+// is always rendered, so it never is.
+func (p Page) Scheduled() bool {
+	return p.URL != "/" && (p.Draft || !p.Published().IsZero() || !p.ExpiryDate.IsZero())
+}
+
+// Load reads the front matter of every Markdown file under dir. Only YAML
+// front matter is understood; a file with TOML or JSON front matter is an
+// error rather than silently unscheduled.
+func Load(dir string) ([]Page, error) {
+	var pages []Page
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != ".md" {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		page, err := Parse(filepath.ToSlash(rel), data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		pages = append(pages, page)
+		return nil
+	})
+	return pages, err
+}
+
+// Parse reads the schedule from a content file's front matter; file is its
+// path relative to the content directory
+func Parse(file string, data []byte) (Page, error) {
+	page := Page{File: file}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	var fm map[string]any
+	if rest, ok := bytes.CutPrefix(data, []byte("---\n")); ok {
+		end := bytes.Index(rest, []byte("\n---"))
+		if end < 0 {
+			return page, fmt.Errorf("front matter is not closed")
+		}
+		if err := yaml.Unmarshal(rest[:end], &fm); err != nil {
+			return page, fmt.Errorf("front matter: %w", err)
+		}
+	} else if bytes.HasPrefix(data, []byte("+++")) || bytes.HasPrefix(data, []byte("{")) {
+		return page, fmt.Errorf("only YAML front matter is supported")
+	}
+
+	page.Draft, _ = fm["draft"].(bool)
+	for key, dst := range map[string]*time.Time{"date": &page.Date, "publishDate": &page.PublishDate, "expiryDate": &page.ExpiryDate} {
+		t, err := frontMatterTime(fm[key])
+		if err != nil {
+			return page, fmt.Errorf("%s: %w", key, err)
+		}
+		*dst = t
+	}
+	slug, _ := fm["slug"].(string)
+	permalink, _ := fm["url"].(string)
+	page.URL = pageURL(file, slug, permalink)
+	return page, nil
+}
+
+// Layouts Hugo accepts for dates written as strings; unquoted YAML dates
+// arrive as time.Time already
+var layouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+func frontMatterTime(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognized date %q", v)
+	}
+	return time.Time{}, fmt.Errorf("unexpected %T", v)
+}
+
+// pageURL follows Hugo's default permalinks: _index.md and index.md name
+// their directory, other files a directory of their own, all lowercased.
+// A url in front matter replaces the path and a slug its last element.
+func pageURL(file, slug, permalink string) string {
+	if permalink != "" {
+		if !strings.HasPrefix(permalink, "/") {
+			permalink = "/" + permalink
+		}
+		return permalink
+	}
+	dir, base := path.Split(strings.TrimSuffix(file, ".md"))
+	if base != "_index" && base != "index" {
+		if slug != "" {
+			base = slug
+		}
+		dir += base + "/"
+	}
+	return strings.ToLower("/" + dir)
+}
+
+// Instants lists the moments worth building at, oldest first: now, the
+// second before and of each scheduled page's publish date, and the second
+// of and after its expiry date (a page is still published at the exact
+// moment it expires)
+func Instants(pages []Page, now time.Time) []time.Time {
+	seen := map[time.Time]bool{}
+	var out []time.Time
+	add := func(t time.Time) {
+		if t.IsZero() || seen[t] {
+			return
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	add(now.Truncate(time.Second))
+	for _, p := range pages {
+		if !p.Scheduled() || p.Draft {
+			continue
+		}
+		if t := p.Published(); !t.IsZero() {
+			add(t.Add(-time.Second))
+			add(t)
+		}
+		if t := p.ExpiryDate; !t.IsZero() {
+			add(t)
+			add(t.Add(time.Second))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// SitemapEntry is one <url> of a sitemap
+type SitemapEntry struct {
+	Loc     string `xml:"loc"`
+	Lastmod string `xml:"lastmod"`
+}
+
+// ParseSitemap reads the entries of a sitemap.xml
+func ParseSitemap(data []byte) ([]SitemapEntry, error) {
+	var set struct {
+		URLs []SitemapEntry `xml:"url"`
+	}
+	if err := xml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("sitemap: %w", err)
+	}
+	return set.URLs, nil
+}
+
+// Verify compares a sitemap built at the instant at with what pages says
+// it should hold, and describes each difference: a live scheduled page
+// missing, a page listed before its publish date or after its expiry, or
+// a lastmod later than the build, which leaks a future date
+func Verify(pages []Page, at time.Time, sitemap []SitemapEntry) []string {
+	listed := map[string]SitemapEntry{}
+	var problems []string
+	for _, e := range sitemap {
+		u, err := url.Parse(e.Loc)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("unparseable loc %q", e.Loc))
+			continue
+		}
+		listed[u.Path] = e
+		if e.Lastmod == "" {
+			continue
+		}
+		mod, err := frontMatterTime(e.Lastmod)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: lastmod %q is not a date", u.Path, e.Lastmod))
+		} else if mod.After(at) {
+			problems = append(problems, fmt.Sprintf("%s: lastmod %s is after the build date", u.Path, e.Lastmod))
+		}
+	}
+	for _, p := range pages {
+		if !p.Scheduled() {
+			continue
+		}
+		_, ok := listed[p.URL]
+		switch live := p.Live(at); {
+		case live && !ok:
+			problems = append(problems, fmt.Sprintf("%s (%s) is published but not in the sitemap", p.URL, p.File))
+		case !live && ok:
+			problems = append(problems, fmt.Sprintf("%s (%s) is in the sitemap but %s", p.URL, p.File, p.state(at)))
+		}
+	}
+	return problems
+}
+
+// state says why a page is not live at at
+func (p Page) state(at time.Time) string {
+	switch {
+	case p.Draft:
+		return "a draft"
+	case p.Published().After(at):
+		return "not published until " + p.Published().Format(time.RFC3339)
+	}
+	return "expired at " + p.ExpiryDate.Format(time.RFC3339)
+}
@@ -0,0 +1,129 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func writeContent(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"_index.md":          "---\ntitle: Home\ndate: 2024-01-01\n---\nbody\n",
+		"talks/_index.md":    "---\ntitle: Talks\n---\n",
+		"talks/KubeCon.md":   "---\ndate: 2026-03-01\npublishDate: \"2026-03-20T09:00:00Z\"\n---\n",
+		"talks/old/index.md": "---\ndate: 2020-05-01\nexpiryDate: 2026-06-30\n---\n",
+		"talks/wip.md":       "---\ndraft: true\nslug: Work-In-Progress\n---\n",
+		"about.md":           "---\nurl: /about-me/\n---\n",
+		"notes/no-matter.md": "just text\n",
+		"talks/not-markdown": "---\ndate: nonsense\n---\n",
+	})
+	pages, err := Load(dir)
+	require.NoError(t, err)
+
+	byFile := map[string]Page{}
+	for _, p := range pages {
+		byFile[p.File] = p
+	}
+	assert.Len(t, byFile, 7, "only .md files are read")
+	assert.Equal(t, "/", byFile["_index.md"].URL)
+	assert.Equal(t, "/talks/", byFile["talks/_index.md"].URL)
+	assert.Equal(t, "/talks/kubecon/", byFile["talks/KubeCon.md"].URL)
+	assert.Equal(t, "/talks/old/", byFile["talks/old/index.md"].URL)
+	assert.Equal(t, "/talks/work-in-progress/", byFile["talks/wip.md"].URL)
+	assert.Equal(t, "/about-me/", byFile["about.md"].URL)
+	assert.Equal(t, "/notes/no-matter/", byFile["notes/no-matter.md"].URL)
+
+	talk := byFile["talks/KubeCon.md"]
+	assert.Equal(t, time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC), talk.Published(), "publishDate wins over date")
+	assert.False(t, byFile["_index.md"].Scheduled(), "the home page is always rendered")
+	assert.False(t, byFile["talks/_index.md"].Scheduled())
+	assert.True(t, byFile["talks/old/index.md"].Scheduled())
+	assert.True(t, byFile["talks/wip.md"].Scheduled(), "a draft is never published")
+}
+
+func TestParseRejects(t *testing.T) {
+	_, err := Parse("a.md", []byte("+++\ndate = 2024-01-01\n+++\n"))
+	assert.ErrorContains(t, err, "only YAML")
+	_, err = Parse("a.md", []byte("---\ndate: soon\n---\n"))
+	assert.ErrorContains(t, err, `date: unrecognized date "soon"`)
+	_, err = Parse("a.md", []byte("---\ndate: 2024-01-01\n"))
+	assert.ErrorContains(t, err, "not closed")
+}
+
+func TestLive(t *testing.T) {
+	p := Page{URL: "/x/", PublishDate: day("2026-03-20"), ExpiryDate: day("2026-06-30")}
+	assert.False(t, p.Live(day("2026-03-20").Add(-time.Second)))
+	assert.True(t, p.Live(day("2026-03-20")))
+	assert.True(t, p.Live(day("2026-06-30")), "still published at the moment it expires")
+	assert.False(t, p.Live(day("2026-06-30").Add(time.Second)))
+	p.Draft = true
+	assert.False(t, p.Live(day("2026-04-01")))
+}
+
+func TestInstants(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 500, time.UTC)
+	pages := []Page{
+		{URL: "/", Date: day("2024-01-01")},
+		{URL: "/talk/", PublishDate: day("2026-03-20")},
+		{URL: "/old/", Date: day("2020-05-01"), ExpiryDate: day("2026-06-30")},
+		{URL: "/wip/", Draft: true, Date: day("2026-02-01")},
+	}
+	sec := time.Second
+	assert.Equal(t, []time.Time{
+		day("2020-05-01").Add(-sec), day("2020-05-01"),
+		time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		day("2026-03-20").Add(-sec), day("2026-03-20"),
+		day("2026-06-30"), day("2026-06-30").Add(sec),
+	}, Instants(pages, now))
+}
+
+func TestVerify(t *testing.T) {
+	sitemap, err := ParseSitemap([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/</loc><lastmod>2026-02-01T00:00:00+00:00</lastmod></url>
+  <url><loc>https://example.com/talk/</loc><lastmod>2026-03-20T00:00:00+00:00</lastmod></url>
+  <url><loc>https://example.com/wip/</loc></url>
+</urlset>`))
+	require.NoError(t, err)
+	require.Len(t, sitemap, 3)
+
+	pages := []Page{
+		{File: "_index.md", URL: "/", Date: day("2024-01-01")},
+		{File: "talk.md", URL: "/talk/", PublishDate: day("2026-03-20")},
+		{File: "old.md", URL: "/old/", Date: day("2020-05-01"), ExpiryDate: day("2026-06-30")},
+		{File: "wip.md", URL: "/wip/", Draft: true, Date: day("2026-02-01")},
+	}
+	assert.Equal(t, []string{
+		"/: lastmod 2026-02-01T00:00:00+00:00 is after the build date",
+		"/talk/: lastmod 2026-03-20T00:00:00+00:00 is after the build date",
+		"/talk/ (talk.md) is in the sitemap but not published until 2026-03-20T00:00:00Z",
+		"/old/ (old.md) is published but not in the sitemap",
+		"/wip/ (wip.md) is in the sitemap but a draft",
+	}, Verify(pages, day("2026-01-15"), sitemap))
+	assert.Equal(t, []string{
+		"/old/ (old.md) is published but not in the sitemap",
+		"/wip/ (wip.md) is in the sitemap but a draft",
+	}, Verify(pages, day("2026-04-01"), sitemap))
+}
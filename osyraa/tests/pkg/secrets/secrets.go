@@ -0,0 +1,159 @@
+// Package secrets scans the generated site for credentials that templates or
+// data files rendered into it, in the manner of gitleaks. Files are scanned
+// as text, so inline scripts and JSON embedded in HTML are covered too.
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule is one kind of secret. When Pattern has a group named "secret",
+// only that group is reported and measured.
+type Rule struct {
+	ID      string
+	Pattern *regexp.Regexp
+	// MinEntropy is the Shannon entropy in bits per character the secret
+	// must reach; zero disables the check. It keeps generic rules from
+	// firing on placeholders such as "your-api-key-here".
+	MinEntropy float64
+}
+
+// DefaultRules cover provider tokens with a recognisable shape, private
+// key blocks, JWTs, credentials in URLs and generic key assignments
+var DefaultRules = []Rule{
+	{ID: "private-key", Pattern: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{ID: "aws-access-key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{ID: "github-token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{ID: "gitlab-token", Pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20}\b`)},
+	{ID: "slack-token", Pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{ID: "slack-webhook", Pattern: regexp.MustCompile(`https://hooks\.slack\.com/services/T[A-Z0-9]+/B[A-Z0-9]+/[A-Za-z0-9]+`)},
+	{ID: "stripe-secret-key", Pattern: regexp.MustCompile(`\b(?:sk|rk)_live_[A-Za-z0-9]{24,}\b`)},
+	{ID: "google-api-key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{ID: "npm-token", Pattern: regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`)},
+	{ID: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{ID: "url-credentials", Pattern: regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^/\s:@"'<>]+:(?P<secret>[^/\s:@"'<>]{3,})@[\w.-]+`)},
+	{
+		ID: "generic-secret",
+		Pattern: regexp.MustCompile(`(?i)\b(?:api[_-]?key|api[_-]?secret|client[_-]?secret|secret[_-]?key|access[_-]?token|auth[_-]?token|password|passwd)` +
+			`["']?\s*[:=]\s*["'](?P<secret>[A-Za-z0-9_\-+/=.]{16,})["']`),
+		MinEntropy: 3.5,
+	},
+}
+
+// Finding is a secret found in a file
+type Finding struct {
+	File   string
+	Line   int
+	Rule   string
+	Secret string
+}
+
+// Redacted shows enough of the secret to find it without publishing it
+// again in test output
+func (f Finding) Redacted() string {
+	if len(f.Secret) <= 8 {
+		return strings.Repeat("*", len(f.Secret))
+	}
+	return fmt.Sprintf("%s…(%d chars)", f.Secret[:4], len(f.Secret))
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s %s", f.File, f.Line, f.Rule, f.Redacted())
+}
+
+// Entropy is the Shannon entropy of s in bits per character
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// ScanText applies rules to text, naming findings after file. Matches of
+// any allow pattern are skipped.
+func ScanText(file string, text []byte, rules []Rule, allow []*regexp.Regexp) []Finding {
+	var findings []Finding
+	for _, rule := range rules {
+		group := rule.Pattern.SubexpIndex("secret")
+		for _, loc := range rule.Pattern.FindAllSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if group > 0 && loc[2*group] >= 0 {
+				start, end = loc[2*group], loc[2*group+1]
+			}
+			secret := string(text[start:end])
+			if rule.MinEntropy > 0 && Entropy(secret) < rule.MinEntropy {
+				continue
+			}
+			if allowed(string(text[loc[0]:loc[1]]), allow) {
+				continue
+			}
+			findings = append(findings, Finding{
+				File:   file,
+				Line:   bytes.Count(text[:start], []byte("\n")) + 1,
+				Rule:   rule.ID,
+				Secret: secret,
+			})
+		}
+	}
+	return findings
+}
+
+func allowed(match string, allow []*regexp.Regexp) bool {
+	for _, re := range allow {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan walks dir and scans every text file. Binary files, recognised by a
+// NUL byte in their first 8KB, are skipped. Findings are sorted by file
+// and line, with paths relative to dir.
+func Scan(dir string, rules []Rule, allow []*regexp.Regexp) ([]Finding, error) {
+	var findings []Finding
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(data[:min(len(data), 8<<10)], 0) >= 0 {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, ScanText(filepath.ToSlash(rel), data, rules, allow)...)
+		return nil
+	})
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, err
+}
@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Tokens are assembled at run time so the source itself does not trip
+// secret scanners
+var (
+	awsKey    = "AKIA" + "Q3EXAMPLE7KEY2ZZ"
+	githubPAT = "ghp_" + strings.Repeat("aB3d", 9)
+	jwt       = "eyJ" + "hbGciOiJIUzI1NiJ9" + ".eyJ" + "zdWIiOiIxMjM0NTY3ODkwIn0" + ".dozjgNryP4J3jVmNHl0w5N_XgL0n3I9PlFUP0THsR8U"
+	pemHeader = "-----BEGIN " + "RSA PRIVATE KEY-----"
+)
+
+func TestScanText(t *testing.T) {
+	page := []byte(`<html>
+<script>
+  const config = {"apiKey": "` + "Zt8q" + `Xw2LmPv9RkY4nBs7"};
+  const token = "` + jwt + `";
+</script>
+<p>` + awsKey + `</p>
+<a href="https://deploy:` + "hunter2hunter2" + `@ci.example.com/">ci</a>
+</html>`)
+
+	findings := ScanText("index.html", page, DefaultRules, nil)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Rule)
+	}
+	assert.ElementsMatch(t, []string{"generic-secret", "jwt", "aws-access-key", "url-credentials"}, got)
+	for _, f := range findings {
+		switch f.Rule {
+		case "generic-secret":
+			assert.Equal(t, 3, f.Line)
+			assert.Equal(t, "Zt8qXw2LmPv9RkY4nBs7", f.Secret)
+		case "aws-access-key":
+			assert.Equal(t, 6, f.Line)
+		case "url-credentials":
+			assert.Equal(t, "hunter2hunter2", f.Secret)
+		}
+	}
+}
+
+func TestScanTextIgnoresPlaceholders(t *testing.T) {
+	text := []byte(`api_key = "aaaaaaaaaaaaaaaaaaaa"
+password: "your-password-here"
+<a href="https://example.com/">x</a>
+<a href="mailto:me@example.com">mail</a>`)
+	assert.Empty(t, ScanText("config.js", text, DefaultRules, nil))
+}
+
+func TestScanTextAllow(t *testing.T) {
+	text := []byte(awsKey + " " + githubPAT)
+	allow := []*regexp.Regexp{regexp.MustCompile(`^AKIA`)}
+	findings := ScanText("f", text, DefaultRules, allow)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "github-token", findings[0].Rule)
+	assert.Equal(t, "f:1: github-token ghp_…(40 chars)", findings[0].String())
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "js"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>clean</h1>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "js", "app.js"), []byte("x\n\n"+pemHeader+"\nMIIE..."), 0o644))
+	// Binary files are skipped even when they contain a match
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "img.png"), []byte("\x89PNG\x00"+awsKey), 0o644))
+
+	findings, err := Scan(dir, DefaultRules, nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, Finding{File: "js/app.js", Line: 3, Rule: "private-key", Secret: pemHeader}, findings[0])
+}
+
+func TestEntropy(t *testing.T) {
+	assert.Zero(t, Entropy("aaaa"))
+	assert.InDelta(t, 1.0, Entropy("abab"), 1e-9)
+	assert.Greater(t, Entropy("Zt8qXw2LmPv9RkY4nBs7"), 3.5)
+}
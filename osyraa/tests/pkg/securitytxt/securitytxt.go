@@ -0,0 +1,163 @@
+// Package securitytxt fetches and validates /.well-known/security.txt as
+// specified by RFC 9116.
+package securitytxt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// Path is where RFC 9116 places the file
+const Path = "/.well-known/security.txt"
+
+// File is a parsed security.txt
+type File struct {
+	// Fields maps canonical field names to their values in file order
+	Fields  map[string][]string
+	Contact []string
+	Expires time.Time
+	// Signed is set when the file is an OpenPGP cleartext signed message
+	Signed bool
+}
+
+// Parse reads security.txt, unwrapping an OpenPGP cleartext signature.
+// Syntax errors are returned; semantic rules are checked by Validate.
+func Parse(data []byte) (*File, error) {
+	f := &File{Fields: map[string][]string{}}
+	body := data
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP SIGNED MESSAGE-----")) {
+		f.Signed = true
+		start := bytes.Index(data, []byte("\n\n"))
+		end := bytes.Index(data, []byte("-----BEGIN PGP SIGNATURE-----"))
+		if start < 0 || end < start {
+			return nil, errors.New("malformed PGP signed message")
+		}
+		body = data[start+2 : end]
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		// Dash-escaped lines in signed messages start with "- "
+		line = strings.TrimPrefix(line, "- ")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected \"Field: value\", got %q", n, line)
+		}
+		name = http.CanonicalHeaderKey(name)
+		f.Fields[name] = append(f.Fields[name], strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	f.Contact = f.Fields["Contact"]
+	if exp := f.Fields["Expires"]; len(exp) > 0 {
+		t, err := time.Parse(time.RFC3339, exp[0])
+		if err != nil {
+			return nil, fmt.Errorf("Expires %q is not an RFC 3339 timestamp", exp[0])
+		}
+		f.Expires = t
+	}
+	return f, nil
+}
+
+// Validate applies the RFC 9116 requirements. The returned warning notes
+// an Expires more than a year out, which the RFC advises against.
+func (f *File) Validate(now time.Time) (warning string, err error) {
+	var errs []error
+	if len(f.Contact) == 0 {
+		errs = append(errs, errors.New("no Contact field"))
+	}
+	for _, c := range f.Contact {
+		if err := checkURI("Contact", c, "mailto", "tel", "https"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch n := len(f.Fields["Expires"]); {
+	case n == 0:
+		errs = append(errs, errors.New("no Expires field"))
+	case n > 1:
+		errs = append(errs, errors.New("Expires must appear once"))
+	case !f.Expires.After(now):
+		errs = append(errs, fmt.Errorf("expired on %s", f.Expires.Format(time.RFC3339)))
+	case f.Expires.After(now.AddDate(1, 0, 0)):
+		warning = fmt.Sprintf("Expires %s is more than a year away", f.Expires.Format(time.RFC3339))
+	}
+	if len(f.Fields["Preferred-Languages"]) > 1 {
+		errs = append(errs, errors.New("Preferred-Languages must appear at most once"))
+	}
+	for _, field := range []string{"Canonical", "Encryption", "Policy", "Acknowledgments", "Hiring", "Csaf"} {
+		schemes := []string{"https"}
+		if field == "Encryption" {
+			// Only a key may be found through DNS or by fingerprint
+			schemes = append(schemes, "dns", "openpgp4fpr")
+		}
+		for _, v := range f.Fields[field] {
+			if err := checkURI(field, v, schemes...); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return warning, errors.Join(errs...)
+}
+
+func checkURI(field, value string, schemes ...string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return fmt.Errorf("%s %q is not a URI", field, value)
+	}
+	for _, s := range schemes {
+		if strings.EqualFold(u.Scheme, s) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %q should use one of %v", field, value, schemes)
+}
+
+// Fetch retrieves security.txt from the target, requiring a 200 served as
+// text/plain with charset utf-8
+func Fetch(ctx context.Context, t *battery.Target) (*File, error) {
+	resp, body, err := t.Get(ctx, Path)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %d", Path, resp.StatusCode)
+	}
+	ct := resp.Header.Get("Content-Type")
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil || mediaType != "text/plain" {
+		return nil, fmt.Errorf("%s served as %q, want text/plain", Path, ct)
+	}
+	if !strings.EqualFold(params["charset"], "utf-8") {
+		return nil, fmt.Errorf("%s served as %q, want charset=utf-8", Path, ct)
+	}
+	return Parse(body)
+}
+
+// BatteryCheck fetches and validates security.txt, failing once it has
+// expired so monitoring catches a lapsed file
+func BatteryCheck() battery.Check {
+	return battery.Check{Name: "security-txt", Run: func(ctx context.Context, t *battery.Target) error {
+		f, err := Fetch(ctx, t)
+		if err != nil {
+			return err
+		}
+		_, err = f.Validate(time.Now())
+		return err
+	}}
+}
@@ -0,0 +1,108 @@
+package securitytxt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const valid = `# Security contact for the resume site
+Contact: mailto:security@example.com
+Contact: https://example.com/security
+Expires: 2026-06-30T23:00:00Z
+Preferred-Languages: en
+Canonical: https://resume.example.com/.well-known/security.txt
+`
+
+func TestParseAndValidate(t *testing.T) {
+	f, err := Parse([]byte(valid))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mailto:security@example.com", "https://example.com/security"}, f.Contact)
+	assert.Equal(t, time.Date(2026, 6, 30, 23, 0, 0, 0, time.UTC), f.Expires)
+
+	warning, err := f.Validate(now)
+	assert.NoError(t, err)
+	assert.Empty(t, warning)
+
+	_, err = f.Validate(f.Expires)
+	assert.ErrorContains(t, err, "expired")
+	warning, err = f.Validate(now.AddDate(-1, 0, 0))
+	assert.NoError(t, err)
+	assert.Contains(t, warning, "more than a year")
+}
+
+func TestValidateRejects(t *testing.T) {
+	f, err := Parse([]byte("Contact: http://example.com/report\nExpires: 2026-06-30T23:00:00Z\nExpires: 2026-07-30T23:00:00Z\n"))
+	require.NoError(t, err)
+	_, err = f.Validate(now)
+	assert.ErrorContains(t, err, `Contact "http://example.com/report" should use one of`)
+	assert.ErrorContains(t, err, "Expires must appear once")
+
+	f, err = Parse([]byte("# empty\n"))
+	require.NoError(t, err)
+	_, err = f.Validate(now)
+	assert.ErrorContains(t, err, "no Contact field")
+	assert.ErrorContains(t, err, "no Expires field")
+
+	keys := valid + "Encryption: dns:5d2d3ceb7abe552344276d47._openpgpkey.example.com?type=OPENPGPKEY\n" +
+		"Encryption: openpgp4fpr:5f2de5521c63a801ab59ccb603d49de44b29100f\n"
+	f, err = Parse([]byte(keys))
+	require.NoError(t, err)
+	_, err = f.Validate(now)
+	assert.NoError(t, err, "Encryption may name a key by DNS or fingerprint")
+	for _, field := range []string{"Canonical", "Policy", "Acknowledgments", "Hiring", "Csaf"} {
+		for _, uri := range []string{"dns:security.example.com", "openpgp4fpr:5f2de5521c63a801ab59ccb603d49de44b29100f", "http://example.com/" + field} {
+			f, err = Parse([]byte(valid + field + ": " + uri + "\n"))
+			require.NoError(t, err)
+			_, err = f.Validate(now)
+			assert.ErrorContains(t, err, field+" \""+uri+"\" should use one of [https]")
+		}
+	}
+
+	_, err = Parse([]byte("Expires: next year\n"))
+	assert.ErrorContains(t, err, "RFC 3339")
+	_, err = Parse([]byte("not a field\n"))
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestParseSigned(t *testing.T) {
+	signed := "-----BEGIN PGP SIGNED MESSAGE-----\nHash: SHA256\n\n" + valid +
+		"-----BEGIN PGP SIGNATURE-----\n\niQIzBAEBCAAdFiEE\n-----END PGP SIGNATURE-----\n"
+	f, err := Parse([]byte(signed))
+	require.NoError(t, err)
+	assert.True(t, f.Signed)
+	assert.Len(t, f.Contact, 2)
+}
+
+func TestFetch(t *testing.T) {
+	contentType := "text/plain; charset=utf-8"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != Path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(valid))
+	}))
+	defer srv.Close()
+
+	f, err := Fetch(context.Background(), battery.NewTarget(srv.URL))
+	require.NoError(t, err)
+	assert.Len(t, f.Contact, 2)
+
+	// nginx's try_files fallback serves index.html for missing files
+	contentType = "text/html"
+	_, err = Fetch(context.Background(), battery.NewTarget(srv.URL))
+	assert.ErrorContains(t, err, "want text/plain")
+	contentType = "text/plain"
+	_, err = Fetch(context.Background(), battery.NewTarget(srv.URL))
+	assert.ErrorContains(t, err, "charset=utf-8")
+}
@@ -0,0 +1,322 @@
+// Package sitediff compares two crawls of the site, normally production and
+// the fresh local build, and describes what a deploy would change: pages
+// added and removed, and for each page present in both its title, its
+// sections' text and its response headers. Sections are split at h1 and h2
+// headings and compared line by line, so the diff reads like the resume
+// rather than like its markup.
+package sitediff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/snapshot"
+	"golang.org/x/net/html"
+)
+
+// Page is what the diff compares of one crawled page
+type Page struct {
+	Path   string
+	Status int
+	Title  string
+	// Sections are set for HTML pages, in document order
+	Sections []Section
+	Header   http.Header
+	// Hash is the SHA-256 of the body, to catch changes to other files
+	Hash string
+}
+
+// Section is the text under one heading, one line per block element.
+// Text before the first heading is in a section with an empty Heading.
+type Section struct {
+	Heading string
+	Lines   []string
+}
+
+// FromCrawl indexes a crawl's pages by path
+func FromCrawl(r *crawl.Result) map[string]*Page {
+	pages := map[string]*Page{}
+	for _, cp := range r.Pages {
+		p := &Page{Path: cp.URL.Path, Status: cp.Status, Header: cp.Header, Hash: battery.ContentHash(cp.Body)}
+		if p.Path == "" {
+			p.Path = "/"
+		}
+		if cp.Doc != nil {
+			doc := match.FromNode(cp.Doc)
+			p.Title = doc.Select("title").Text()
+			p.Sections = Sections(cp.Doc)
+		}
+		pages[p.Path] = p
+	}
+	return pages
+}
+
+// skipped elements hold no visible content
+var skipped = map[string]bool{"head": true, "script": true, "style": true, "noscript": true, "template": true, "svg": true}
+
+// lineTags are the elements whose text becomes one line
+var lineTags = map[string]bool{
+	"p": true, "li": true, "dt": true, "dd": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"td": true, "th": true, "blockquote": true, "pre": true, "figcaption": true, "summary": true,
+}
+
+// Sections splits a document's body at its h1 and h2 headings
+func Sections(doc *html.Node) []Section {
+	sections := []Section{{}}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case skipped[n.Data]:
+				return
+			case n.Data == "h1" || n.Data == "h2":
+				sections = append(sections, Section{Heading: match.Normalize(match.Text(n))})
+				return
+			case lineTags[n.Data]:
+				if text := match.Normalize(match.Text(n)); text != "" {
+					last := &sections[len(sections)-1]
+					last.Lines = append(last.Lines, text)
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	if len(sections[0].Lines) == 0 {
+		sections = sections[1:]
+	}
+	return sections
+}
+
+// VolatileHeaders change on every response, or with the cache, and are
+// left out of header comparisons
+var VolatileHeaders = []string{
+	"Age", "Cf-Cache-Status", "Cf-Ray", "Content-Length", "Date", "Etag", "Expires", "Last-Modified",
+	"Nel", "Report-To", "Server-Timing", "Set-Cookie", "Via", "X-Amz-Cf-Id", "X-Amz-Cf-Pop", "X-Cache",
+	"X-Cache-Hits", "X-Served-By", "X-Timer", "X-Request-Id", "X-Github-Request-Id", "X-Fastly-Request-Id",
+	"X-Osyraa-Cache",
+}
+
+// Options control Compare
+type Options struct {
+	// Headers compares response headers; leave it off when one side was
+	// read from disk and has none of the server's
+	Headers bool
+	// IgnoreHeaders are skipped in addition to VolatileHeaders
+	IgnoreHeaders []string
+	// Names label the two crawls in section diffs; empty is "before" and
+	// "after"
+	BeforeName, AfterName string
+}
+
+// Change is a value before and after
+type Change struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SectionDiff describes one changed section
+type SectionDiff struct {
+	Heading string `json:"heading"`
+	// Kind is "added", "removed" or "changed"
+	Kind string `json:"kind"`
+	// Diff is a unified diff of the section's lines
+	Diff string `json:"diff"`
+}
+
+// HeaderDiff describes one changed header; an empty side is absent
+type HeaderDiff struct {
+	Name string `json:"name"`
+	Change
+}
+
+// PageDiff is everything that changed on one page
+type PageDiff struct {
+	Path     string        `json:"path"`
+	Status   *Change       `json:"status,omitempty"`
+	Title    *Change       `json:"title,omitempty"`
+	Sections []SectionDiff `json:"sections,omitempty"`
+	Headers  []HeaderDiff  `json:"headers,omitempty"`
+	// Body is set when the content differs but not in a way the fields
+	// above show, such as markup, styles or a non-HTML file
+	Body bool `json:"body,omitempty"`
+}
+
+// Diff is the difference between two crawls
+type Diff struct {
+	// Added are only in the after crawl, Removed only in the before one
+	Added   []string   `json:"added"`
+	Removed []string   `json:"removed"`
+	Changed []PageDiff `json:"changed"`
+}
+
+// Empty reports whether the crawls match
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare describes how the after crawl differs from the before one
+func Compare(before, after map[string]*Page, opts Options) *Diff {
+	d := &Diff{Added: []string{}, Removed: []string{}, Changed: []PageDiff{}}
+	if opts.BeforeName == "" {
+		opts.BeforeName = "before"
+	}
+	if opts.AfterName == "" {
+		opts.AfterName = "after"
+	}
+	for _, path := range sortedKeys(after) {
+		if _, ok := before[path]; !ok {
+			d.Added = append(d.Added, path)
+		}
+	}
+	ignore := map[string]bool{}
+	for _, h := range append(slices.Clone(VolatileHeaders), opts.IgnoreHeaders...) {
+		ignore[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, path := range sortedKeys(before) {
+		n, ok := after[path]
+		if !ok {
+			d.Removed = append(d.Removed, path)
+			continue
+		}
+		o := before[path]
+		pd := PageDiff{Path: path}
+		if o.Status != n.Status {
+			pd.Status = &Change{fmt.Sprint(o.Status), fmt.Sprint(n.Status)}
+		}
+		if o.Title != n.Title {
+			pd.Title = &Change{o.Title, n.Title}
+		}
+		pd.Sections = compareSections(o.Sections, n.Sections, opts.BeforeName, opts.AfterName)
+		if opts.Headers {
+			pd.Headers = compareHeaders(o.Header, n.Header, ignore)
+		}
+		pd.Body = o.Hash != n.Hash && pd.Title == nil && len(pd.Sections) == 0
+		if pd.Status != nil || pd.Title != nil || len(pd.Sections) > 0 || len(pd.Headers) > 0 || pd.Body {
+			d.Changed = append(d.Changed, pd)
+		}
+	}
+	return d
+}
+
+func sortedKeys(m map[string]*Page) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compareSections matches sections by heading, in the after page's order
+// with removed sections last
+func compareSections(before, after []Section, beforeName, afterName string) []SectionDiff {
+	oldByHeading := map[string]Section{}
+	for _, s := range before {
+		oldByHeading[s.Heading] = s
+	}
+	var diffs []SectionDiff
+	seen := map[string]bool{}
+	for _, s := range after {
+		seen[s.Heading] = true
+		o, ok := oldByHeading[s.Heading]
+		text := joinLines(s.Lines)
+		switch {
+		case !ok:
+			diffs = append(diffs, SectionDiff{Heading: s.Heading, Kind: "added", Diff: snapshot.Diff(beforeName, afterName, "", text)})
+		case !slices.Equal(o.Lines, s.Lines):
+			diffs = append(diffs, SectionDiff{Heading: s.Heading, Kind: "changed", Diff: snapshot.Diff(beforeName, afterName, joinLines(o.Lines), text)})
+		}
+	}
+	for _, s := range before {
+		if !seen[s.Heading] {
+			diffs = append(diffs, SectionDiff{Heading: s.Heading, Kind: "removed", Diff: snapshot.Diff(beforeName, afterName, joinLines(s.Lines), "")})
+		}
+	}
+	return diffs
+}
+
+// joinLines ends every line with a newline, so diffs don't flag the last
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func compareHeaders(before, after http.Header, ignore map[string]bool) []HeaderDiff {
+	names := map[string]bool{}
+	for name := range before {
+		names[http.CanonicalHeaderKey(name)] = true
+	}
+	for name := range after {
+		names[http.CanonicalHeaderKey(name)] = true
+	}
+	var diffs []HeaderDiff
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		if ignore[name] {
+			continue
+		}
+		o, n := strings.Join(before.Values(name), ", "), strings.Join(after.Values(name), ", ")
+		if o != n {
+			diffs = append(diffs, HeaderDiff{Name: name, Change: Change{o, n}})
+		}
+	}
+	return diffs
+}
+
+// WriteText writes the diff for review, as a summary followed by each
+// changed page
+func (d *Diff) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "%d pages added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed))
+	for _, p := range d.Added {
+		fmt.Fprintf(w, "+ %s\n", p)
+	}
+	for _, p := range d.Removed {
+		fmt.Fprintf(w, "- %s\n", p)
+	}
+	for _, pd := range d.Changed {
+		fmt.Fprintf(w, "\n~ %s\n", pd.Path)
+		if pd.Status != nil {
+			fmt.Fprintf(w, "  status: %s -> %s\n", pd.Status.From, pd.Status.To)
+		}
+		if pd.Title != nil {
+			fmt.Fprintf(w, "  title: %q -> %q\n", pd.Title.From, pd.Title.To)
+		}
+		for _, h := range pd.Headers {
+			fmt.Fprintf(w, "  header %s: %q -> %q\n", h.Name, h.From, h.To)
+		}
+		if pd.Body {
+			fmt.Fprintln(w, "  content changed (markup or file contents only)")
+		}
+		for _, s := range pd.Sections {
+			heading := s.Heading
+			if heading == "" {
+				heading = "(before the first heading)"
+			}
+			fmt.Fprintf(w, "  section %q %s:\n", heading, s.Kind)
+			for _, line := range strings.Split(strings.TrimRight(s.Diff, "\n"), "\n") {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+	}
+}
+
+// WriteJSON writes the diff as indented JSON
+func (d *Diff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -0,0 +1,127 @@
+package sitediff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+const home = `<!DOCTYPE html><html><head><title>%s</title><style>p{}</style></head><body>
+<header><h1>Jane Doe</h1><p class="tagline">Platform Engineer</p></header>
+<main>
+<h2>Experience</h2>
+<h3>Staff Engineer</h3><p><strong>Acme</strong> | 2020 - 2024</p>
+<ul><li>Ran the platform</li>%s</ul>
+%s
+<h2>Skills</h2><ul><li>Go, Terraform</li></ul>
+</main><script>track()</script>
+<a href="/contact.vcf">vCard</a>%s
+</body></html>`
+
+func writeSite(t *testing.T, files map[string]string) map[string]*Page {
+	dir := t.TempDir()
+	for name, body := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+	}
+	start, _ := url.Parse("https://resume.example.com/")
+	result, err := crawl.New(crawl.NewDirFetcher(dir)).Run(context.Background(), start)
+	require.NoError(t, err)
+	return FromCrawl(result)
+}
+
+// fill completes the home page with a title, an extra highlight, an extra
+// section and an extra link
+func fill(title, item, section, link string) string {
+	return fmt.Sprintf(home, title, item, section, link)
+}
+
+func TestSections(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head><title>x</title></head><body>
+<p>Intro</p><h1>Name</h1><p>tag <em>line</em></p><h2>Experience</h2><ul><li>One</li><li> Two  lines </li></ul>
+<script>ignored()</script><h2>Empty</h2></body></html>`))
+	require.NoError(t, err)
+	assert.Equal(t, []Section{
+		{Heading: "", Lines: []string{"Intro"}},
+		{Heading: "Name", Lines: []string{"tag line"}},
+		{Heading: "Experience", Lines: []string{"One", "Two lines"}},
+		{Heading: "Empty"},
+	}, Sections(doc))
+}
+
+func TestCompare(t *testing.T) {
+	prod := writeSite(t, map[string]string{
+		"index.html":     fill("Jane Doe - Resume", "", "<h2>Projects</h2><ul><li>Old project</li></ul>", `<a href="/old/">old</a>`),
+		"contact.vcf":    "BEGIN:VCARD\nFN:Jane Doe\nEND:VCARD\n",
+		"old/index.html": "<html><head><title>Old</title></head><body><p>gone soon</p></body></html>",
+	})
+	local := writeSite(t, map[string]string{
+		"index.html":       fill("Jane Doe | Resume", "<li>Cut costs 30%</li>", "<h2>Education</h2><ul><li>BSc</li></ul>", `<a href="/talks/">talks</a>`),
+		"contact.vcf":      "BEGIN:VCARD\nFN:Jane Doe\nTEL:+1 555 0100\nEND:VCARD\n",
+		"talks/index.html": "<html><head><title>Talks</title></head><body><p>new</p></body></html>",
+	})
+
+	d := Compare(prod, local, Options{BeforeName: "production", AfterName: "local"})
+	assert.Equal(t, []string{"/talks/"}, d.Added)
+	assert.Equal(t, []string{"/old/"}, d.Removed)
+	require.Len(t, d.Changed, 2)
+
+	vcf := d.Changed[1]
+	assert.Equal(t, "/contact.vcf", vcf.Path)
+	assert.True(t, vcf.Body, "a non-HTML file shows as changed content")
+
+	idx := d.Changed[0]
+	assert.Equal(t, "/", idx.Path)
+	assert.Equal(t, &Change{"Jane Doe - Resume", "Jane Doe | Resume"}, idx.Title)
+	assert.False(t, idx.Body, "the sections already explain the change")
+	require.Len(t, idx.Sections, 3)
+	assert.Equal(t, "Experience", idx.Sections[0].Heading)
+	assert.Equal(t, "changed", idx.Sections[0].Kind)
+	assert.Contains(t, idx.Sections[0].Diff, "--- production\n+++ local\n")
+	assert.Contains(t, idx.Sections[0].Diff, "+Cut costs 30%")
+	assert.Equal(t, SectionDiff{Heading: "Education", Kind: "added", Diff: idx.Sections[1].Diff}, idx.Sections[1])
+	assert.Equal(t, "Projects", idx.Sections[2].Heading)
+	assert.Equal(t, "removed", idx.Sections[2].Kind)
+	assert.Empty(t, idx.Headers, "headers are only compared when asked")
+
+	var text bytes.Buffer
+	d.WriteText(&text)
+	assert.Contains(t, text.String(), "1 pages added, 1 removed, 2 changed\n+ /talks/\n- /old/\n")
+	assert.Contains(t, text.String(), "  section \"Projects\" removed:\n")
+
+	var out bytes.Buffer
+	require.NoError(t, d.WriteJSON(&out))
+	var decoded Diff
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, d, &decoded)
+
+	assert.True(t, Compare(prod, prod, Options{}).Empty())
+}
+
+func TestCompareHeaders(t *testing.T) {
+	before := map[string]*Page{"/": {Path: "/", Status: 200, Header: http.Header{
+		"Date": {"Mon"}, "X-Frame-Options": {"SAMEORIGIN"}, "Content-Security-Policy": {"default-src 'self'"}, "Server": {"nginx"},
+	}}}
+	after := map[string]*Page{"/": {Path: "/", Status: 200, Header: http.Header{
+		"Date": {"Tue"}, "X-Frame-Options": {"DENY"}, "Server": {"nginx/1.25"}, "Permissions-Policy": {"camera=()"},
+	}}}
+	d := Compare(before, after, Options{Headers: true, IgnoreHeaders: []string{"server"}})
+	require.Len(t, d.Changed, 1)
+	assert.Equal(t, []HeaderDiff{
+		{Name: "Content-Security-Policy", Change: Change{"default-src 'self'", ""}},
+		{Name: "Permissions-Policy", Change: Change{"", "camera=()"}},
+		{Name: "X-Frame-Options", Change: Change{"SAMEORIGIN", "DENY"}},
+	}, d.Changed[0].Headers, "volatile and ignored headers are left out")
+}
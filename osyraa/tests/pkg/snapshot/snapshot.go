@@ -0,0 +1,119 @@
+// Package snapshot stores normalized renderings of pages and reports a
+// readable diff when a template changes its output. Normalizing keeps the
+// snapshots to what a reviewer cares about: one element per line with
+// sorted attributes and collapsed text, and fingerprint hashes, SRI
+// digests, nonces and the generator version replaced by placeholders so a
+// rebuild or a Hugo upgrade alone never changes them.
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/golden"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"golang.org/x/net/html"
+)
+
+var (
+	// fingerprintRe matches the content hash Hugo's fingerprint puts in
+	// asset names, e.g. style.min.<sha256>.css
+	fingerprintRe = regexp.MustCompile(`\.[0-9a-f]{16,}\.`)
+	digestRe      = regexp.MustCompile(`\b(sha256|sha384|sha512)-[A-Za-z0-9+/]+=*`)
+	versionRe     = regexp.MustCompile(`([?&]v=)[0-9A-Za-z]{6,}`)
+)
+
+// volatileAttrs have values that change on every build
+var volatileAttrs = map[string]bool{"nonce": true}
+
+// stripHashes replaces fingerprints, digests and cache-busting versions
+func stripHashes(s string) string {
+	s = fingerprintRe.ReplaceAllString(s, ".<hash>.")
+	s = digestRe.ReplaceAllString(s, "$1-<hash>")
+	return versionRe.ReplaceAllString(s, "${1}<hash>")
+}
+
+// Normalize renders an HTML document one node per line, indented by
+// depth, with comments dropped, text whitespace collapsed, attributes
+// sorted and hashes stripped
+func Normalize(page []byte) (string, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return "", fmt.Errorf("parse HTML: %w", err)
+	}
+	var b strings.Builder
+	var walk func(n *html.Node, depth int)
+	walk = func(n *html.Node, depth int) {
+		indent := strings.Repeat("  ", depth)
+		switch n.Type {
+		case html.DoctypeNode:
+			fmt.Fprintf(&b, "<!DOCTYPE %s>\n", n.Data)
+			return
+		case html.TextNode:
+			if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+				fmt.Fprintf(&b, "%s%s\n", indent, stripHashes(text))
+			}
+			return
+		case html.ElementNode:
+			attrs := make([]string, 0, len(n.Attr))
+			for _, a := range n.Attr {
+				val := stripHashes(a.Val)
+				if volatileAttrs[a.Key] {
+					val = "<" + a.Key + ">"
+				}
+				if name, _ := match.Attr(n, "name"); n.Data == "meta" && a.Key == "content" && name == "generator" {
+					// Hugo names its version, which a Hugo upgrade alone changes
+					val = "<generator>"
+				}
+				attrs = append(attrs, fmt.Sprintf(" %s=%q", a.Key, val))
+			}
+			sort.Strings(attrs)
+			fmt.Fprintf(&b, "%s<%s%s>\n", indent, n.Data, strings.Join(attrs, ""))
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c, depth+1)
+			}
+			return
+		case html.DocumentNode:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c, depth)
+			}
+		}
+	}
+	walk(doc, 0)
+	return b.String(), nil
+}
+
+// Store is a directory of snapshots named <name>.html
+type Store struct {
+	Dir string
+	// Update overwrites snapshots with the new rendering instead of
+	// comparing, to accept an intended change or add a page
+	Update bool
+}
+
+// Result is the outcome of Store.Check
+type Result struct {
+	// Updated is set when Update was set and the rendering was stored as
+	// the new snapshot
+	Updated bool
+	// Diff is a unified diff from the snapshot to the rendering, empty
+	// when they match
+	Diff string
+}
+
+// Check normalizes page and compares it with the snapshot called name. A
+// missing snapshot is an error wrapping golden.ErrMissing.
+func (s Store) Check(name string, page []byte) (Result, error) {
+	got, err := Normalize(page)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", name, err)
+	}
+	want, updated, err := golden.Dir{Path: s.Dir, Update: s.Update}.Load(name+".html", []byte(got))
+	if err != nil || updated {
+		return Result{Updated: updated}, err
+	}
+	return Result{Diff: Diff(name+".html (snapshot)", name+".html (built)", string(want), got)}, nil
+}
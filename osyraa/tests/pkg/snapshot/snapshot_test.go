@@ -0,0 +1,108 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const page = `<!DOCTYPE html>
+<html lang="en"><head><!-- built 2026-01-01 --><meta name="generator" content="Hugo 0.111.3">
+<link rel="stylesheet" href="/css/style.min.0123456789abcdef0123.css" integrity="sha256-AbC+/dEf=" crossorigin="anonymous">
+<script nonce="r4nd0m" src="/js/app.js?v=1a2b3c4d"></script>
+</head><body><h1 class="name title">Jane   Doe</h1>
+<p>Engineer</p></body></html>`
+
+func TestNormalize(t *testing.T) {
+	got, err := Normalize([]byte(page))
+	require.NoError(t, err)
+	assert.Equal(t, `<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta content="<generator>" name="generator">
+    <link crossorigin="anonymous" href="/css/style.min.<hash>.css" integrity="sha256-<hash>" rel="stylesheet">
+    <script nonce="<nonce>" src="/js/app.js?v=<hash>">
+  <body>
+    <h1 class="name title">
+      Jane Doe
+    <p>
+      Engineer
+`, got)
+
+	// Minified output with other hashes and attribute order is the same
+	rebuilt := strings.NewReplacer(
+		"0123456789abcdef0123", "fedcba9876543210fedc",
+		"AbC+/dEf=", "XyZ/+==",
+		"r4nd0m", "0th3r",
+		"1a2b3c4d", "9f8e7d6c",
+		"Hugo 0.111.3", "Hugo 0.139.0",
+		` rel="stylesheet" href`, ` href`,
+		`crossorigin="anonymous">`, `crossorigin="anonymous" rel="stylesheet">`,
+		"\n", "",
+	).Replace(page)
+	again, err := Normalize([]byte(rebuilt))
+	require.NoError(t, err)
+	assert.Equal(t, got, again)
+}
+
+func TestDiff(t *testing.T) {
+	assert.Empty(t, Diff("a", "b", "x\ny\n", "x\ny\n"))
+
+	want := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	got := strings.Replace(strings.Replace(want, "2\n", "two\n", 1), "14\n", "", 1)
+	assert.Equal(t, `--- want
++++ got
+@@ -1,5 +1,5 @@
+ 1
+-2
++two
+ 3
+ 4
+ 5
+@@ -11,5 +11,4 @@
+ 11
+ 12
+ 13
+-14
+ 15
+`, Diff("want", "got", want, got))
+}
+
+func TestStore(t *testing.T) {
+	store := Store{Dir: filepath.Join(t.TempDir(), "snapshots")}
+
+	_, err := store.Check("home", []byte(page))
+	assert.ErrorIs(t, err, golden.ErrMissing, "a missing snapshot fails")
+	assert.NoFileExists(t, filepath.Join(store.Dir, "home.html"))
+
+	store.Update = true
+	res, err := store.Check("home", []byte(page))
+	require.NoError(t, err)
+	assert.True(t, res.Updated, "Update stores the snapshot")
+	_, err = os.Stat(filepath.Join(store.Dir, "home.html"))
+	require.NoError(t, err)
+	store.Update = false
+
+	res, err = store.Check("home", []byte(page))
+	require.NoError(t, err)
+	assert.Equal(t, Result{}, res)
+
+	changed := strings.Replace(page, "Engineer", "Senior Engineer", 1)
+	res, err = store.Check("home", []byte(changed))
+	require.NoError(t, err)
+	assert.Contains(t, res.Diff, "-      Engineer\n+      Senior Engineer\n")
+
+	store.Update = true
+	res, err = store.Check("home", []byte(changed))
+	require.NoError(t, err)
+	assert.True(t, res.Updated)
+	store.Update = false
+	res, err = store.Check("home", []byte(changed))
+	require.NoError(t, err)
+	assert.Empty(t, res.Diff, "the update is the new snapshot")
+}
@@ -0,0 +1,239 @@
+// Package sri enforces Subresource Integrity on externally hosted scripts
+// and stylesheets: every such tag must carry an integrity attribute and a
+// crossorigin setting, and the hashes must match what the CDN serves.
+package sri
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"golang.org/x/net/html"
+)
+
+// Resource is an externally hosted script or stylesheet referenced by a page
+type Resource struct {
+	Page string
+	Tag  string
+	URL  *url.URL
+	// Integrity is the raw integrity attribute, empty when absent
+	Integrity string
+	// CrossOrigin is the crossorigin attribute; HasCrossOrigin distinguishes
+	// the bare attribute (anonymous) from its absence
+	CrossOrigin    string
+	HasCrossOrigin bool
+}
+
+// Problem is an SRI violation on one resource
+type Problem struct {
+	Resource
+	Reason string
+}
+
+// String formats the problem for test output
+func (p Problem) String() string {
+	return fmt.Sprintf("<%s> %s on %s: %s", p.Tag, p.URL, p.Page, p.Reason)
+}
+
+// stylesheetRels are the link relations that load a subresource SRI applies to
+var stylesheetRels = []string{"stylesheet", "preload", "modulepreload"}
+
+// Resources returns the scripts and stylesheets in doc hosted on another
+// origin than page
+func Resources(doc *html.Node, page *url.URL) []Resource {
+	var found []Resource
+	d := match.FromNode(doc)
+	for _, n := range append(d.Select("script[src]").Nodes(), d.Select("link[href]").Nodes()...) {
+		ref := "src"
+		if n.Data == "link" {
+			if !hasRel(n) {
+				continue
+			}
+			ref = "href"
+		}
+		raw, _ := match.Attr(n, ref)
+		parsed, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			continue
+		}
+		u := page.ResolveReference(parsed)
+		if (u.Scheme != "http" && u.Scheme != "https") || (u.Scheme == page.Scheme && u.Host == page.Host) {
+			continue
+		}
+		r := Resource{Page: page.Path, Tag: n.Data, URL: u}
+		r.Integrity, _ = match.Attr(n, "integrity")
+		r.CrossOrigin, r.HasCrossOrigin = match.Attr(n, "crossorigin")
+		found = append(found, r)
+	}
+	return found
+}
+
+func hasRel(n *html.Node) bool {
+	rel, _ := match.Attr(n, "rel")
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if slices.Contains(stylesheetRels, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Digest is one hash-source of an integrity attribute
+type Digest struct {
+	Algorithm string
+	Value     string
+}
+
+// algorithms maps the hash algorithms browsers accept to their strength
+var algorithms = map[string]int{"sha256": 1, "sha384": 2, "sha512": 3}
+
+// ParseIntegrity returns the well-formed digests in an integrity attribute.
+// Unknown algorithms are skipped, as browsers do.
+func ParseIntegrity(attr string) []Digest {
+	var digests []Digest
+	for _, token := range strings.Fields(attr) {
+		token, _, _ = strings.Cut(token, "?")
+		alg, value, ok := strings.Cut(token, "-")
+		if !ok || algorithms[alg] == 0 {
+			continue
+		}
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			continue
+		}
+		digests = append(digests, Digest{Algorithm: alg, Value: value})
+	}
+	return digests
+}
+
+// strongest keeps only the digests using the strongest algorithm present,
+// the only ones a browser compares against
+func strongest(digests []Digest) []Digest {
+	best := 0
+	for _, d := range digests {
+		best = max(best, algorithms[d.Algorithm])
+	}
+	var out []Digest
+	for _, d := range digests {
+		if algorithms[d.Algorithm] == best {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Hash returns the integrity value of body for an algorithm
+func Hash(algorithm string, body []byte) string {
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		h = sha512.New384()
+	}
+	h.Write(body)
+	return algorithm + "-" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Lint checks the attributes of r without fetching it
+func Lint(r Resource) []string {
+	var reasons []string
+	switch {
+	case strings.TrimSpace(r.Integrity) == "":
+		reasons = append(reasons, "missing integrity attribute")
+	case len(ParseIntegrity(r.Integrity)) == 0:
+		reasons = append(reasons, fmt.Sprintf("integrity %q has no sha256/384/512 digest", r.Integrity))
+	}
+	// Cross-origin integrity checks need a CORS request, so the attribute is mandatory
+	switch strings.ToLower(r.CrossOrigin) {
+	case "", "anonymous":
+		if !r.HasCrossOrigin {
+			reasons = append(reasons, "missing crossorigin attribute")
+		}
+	case "use-credentials":
+		reasons = append(reasons, `crossorigin="use-credentials" sends cookies to a third party; use "anonymous"`)
+	default:
+		reasons = append(reasons, fmt.Sprintf("invalid crossorigin %q", r.CrossOrigin))
+	}
+	return reasons
+}
+
+// Verify fetches r and compares it with its integrity attribute. It also
+// requires a CORS header, without which the browser refuses the resource.
+func Verify(ctx context.Context, client *http.Client, r Resource, origin string) error {
+	digests := strongest(ParseIntegrity(r.Integrity))
+	if len(digests) == 0 {
+		return fmt.Errorf("no usable integrity digest")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Origin", origin)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET returned %d", resp.StatusCode)
+	}
+	if acao := resp.Header.Get("Access-Control-Allow-Origin"); acao != "*" && acao != origin {
+		return fmt.Errorf("no Access-Control-Allow-Origin for %s (got %q); the browser will block it", origin, acao)
+	}
+	actual := Hash(digests[0].Algorithm, body)
+	for _, d := range digests {
+		if d.Algorithm+"-"+d.Value == actual {
+			return nil
+		}
+	}
+	return fmt.Errorf("integrity mismatch: served content hashes to %s", actual)
+}
+
+// Audit lints every external script and stylesheet on the crawled pages and
+// verifies each distinct URL once against what its host serves. origin is
+// the site's public origin, sent as the CORS Origin header.
+func Audit(ctx context.Context, client *http.Client, pages []*crawl.Page, origin string) ([]Resource, []Problem) {
+	var resources []Resource
+	var problems []Problem
+	verified := map[string]error{}
+	for _, page := range pages {
+		if page.Doc == nil {
+			continue
+		}
+		for _, r := range Resources(page.Doc, page.URL) {
+			resources = append(resources, r)
+			reasons := Lint(r)
+			if len(ParseIntegrity(r.Integrity)) > 0 {
+				key := r.URL.String() + " " + r.Integrity
+				err, done := verified[key]
+				if !done {
+					err = Verify(ctx, client, r, origin)
+					verified[key] = err
+				}
+				if err != nil {
+					reasons = append(reasons, err.Error())
+				}
+			}
+			for _, reason := range reasons {
+				problems = append(problems, Problem{Resource: r, Reason: reason})
+			}
+		}
+	}
+	return resources, problems
+}
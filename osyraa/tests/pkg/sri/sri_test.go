@@ -0,0 +1,62 @@
+package sri
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/crawl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+const script = "console.log('vendored');"
+
+func TestAudit(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/no-cors.js" {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		w.Write([]byte(script))
+	}))
+	defer cdn.Close()
+
+	good := Hash("sha384", []byte(script))
+	markup := `<html><head>
+<script src="` + cdn.URL + `/ok.js" integrity="sha256-AAAA ` + good + `" crossorigin="anonymous"></script>
+<script src="` + cdn.URL + `/ok.js" integrity="` + good + `" crossorigin></script>
+<script src="` + cdn.URL + `/tampered.js" integrity="` + Hash("sha384", []byte("other")) + `" crossorigin="anonymous"></script>
+<script src="` + cdn.URL + `/no-cors.js" integrity="` + good + `" crossorigin="anonymous"></script>
+<link rel="stylesheet" href="` + cdn.URL + `/site.css">
+<link rel="icon" href="` + cdn.URL + `/favicon.ico">
+<script src="/js/local.js"></script>
+</head></html>`
+	doc, err := html.Parse(strings.NewReader(markup))
+	require.NoError(t, err)
+	page := &crawl.Page{URL: &url.URL{Scheme: "http", Host: "localhost", Path: "/"}, Doc: doc}
+
+	resources, problems := Audit(context.Background(), cdn.Client(), []*crawl.Page{page}, "https://resume.example.com")
+	assert.Len(t, resources, 5, "local scripts and icons are out of scope")
+
+	reasons := map[string][]string{}
+	for _, p := range problems {
+		reasons[p.URL.Path] = append(reasons[p.URL.Path], p.Reason)
+	}
+	assert.NotContains(t, reasons, "/ok.js", "the strongest digest matches; bare crossorigin means anonymous")
+	require.Len(t, reasons["/tampered.js"], 1)
+	assert.Contains(t, reasons["/tampered.js"][0], "integrity mismatch")
+	require.Len(t, reasons["/no-cors.js"], 1)
+	assert.Contains(t, reasons["/no-cors.js"][0], "Access-Control-Allow-Origin")
+	assert.Equal(t, []string{"missing integrity attribute", "missing crossorigin attribute"}, reasons["/site.css"])
+}
+
+func TestParseIntegrity(t *testing.T) {
+	digests := ParseIntegrity("md5-AAAA sha512-AAAA?opt sha256-!!! ")
+	assert.Equal(t, []Digest{{Algorithm: "sha512", Value: "AAAA"}}, digests)
+	assert.Equal(t, []string{`integrity "md5-AAAA" has no sha256/384/512 digest`, `crossorigin="use-credentials" sends cookies to a third party; use "anonymous"`},
+		Lint(Resource{Integrity: "md5-AAAA", CrossOrigin: "use-credentials", HasCrossOrigin: true}))
+}
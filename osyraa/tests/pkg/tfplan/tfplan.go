@@ -0,0 +1,233 @@
+// Package tfplan enforces policies on Terraform/OpenTofu plans. It reads the
+// machine-readable plan (`terraform show -json`) so policies see exactly the
+// changes that would be applied.
+package tfplan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Plan is the subset of the JSON plan format the policies need
+type Plan struct {
+	FormatVersion   string           `json:"format_version"`
+	ResourceChanges []ResourceChange `json:"resource_changes"`
+}
+
+// ResourceChange is one planned change to a resource
+type ResourceChange struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Mode    string `json:"mode"`
+	Change  Change `json:"change"`
+}
+
+// Change holds the planned actions and the resource state either side
+type Change struct {
+	Actions []string       `json:"actions"`
+	Before  map[string]any `json:"before"`
+	After   map[string]any `json:"after"`
+	// AfterUnknown marks attributes only known after apply
+	AfterUnknown map[string]any `json:"after_unknown"`
+}
+
+// Deletes reports whether the change destroys the resource, including
+// replacements
+func (c Change) Deletes() bool {
+	return slices.Contains(c.Actions, "delete")
+}
+
+// Removed reports whether the resource is gone after the change
+func (c Change) Removed() bool {
+	return c.Deletes() && !slices.Contains(c.Actions, "create")
+}
+
+// Parse decodes a JSON plan
+func Parse(data []byte) (*Plan, error) {
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+	if plan.FormatVersion == "" {
+		return nil, errors.New("parse plan: not a terraform show -json document")
+	}
+	return &plan, nil
+}
+
+// Load reads a plan from path. JSON files are parsed directly; binary plan
+// files are converted with `show -json` using terraform or, failing that, tofu,
+// run from dir so the plan's providers are available.
+func Load(ctx context.Context, dir, path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if json.Valid(data) {
+		return Parse(data)
+	}
+	bin, err := Binary()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, bin, "show", "-json", path)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s show -json %s: %w: %s", bin, path, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return Parse(out)
+}
+
+// Binary returns terraform if installed, else tofu
+func Binary() (string, error) {
+	for _, bin := range []string{"terraform", "tofu"} {
+		if _, err := exec.LookPath(bin); err == nil {
+			return bin, nil
+		}
+	}
+	return "", errors.New("neither terraform nor tofu found in PATH")
+}
+
+// Policy is a named rule applied to every resource change
+type Policy struct {
+	Name  string
+	Check func(rc ResourceChange) error
+}
+
+// Violation is a policy failure on one resource
+type Violation struct {
+	Policy  string
+	Address string
+	Err     error
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %v", v.Policy, v.Address, v.Err)
+}
+
+// Evaluate applies each policy to every managed resource change
+func Evaluate(plan *Plan, policies []Policy) []Violation {
+	var violations []Violation
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode != "" && rc.Mode != "managed" {
+			continue
+		}
+		for _, p := range policies {
+			if err := p.Check(rc); err != nil {
+				violations = append(violations, Violation{Policy: p.Name, Address: rc.Address, Err: err})
+			}
+		}
+	}
+	return violations
+}
+
+// Default returns the site's policies: no public buckets, the tags every
+// resource carries in variables.tf, and no destroying the CDN or DNS zone
+func Default() []Policy {
+	return []Policy{
+		NoPublicBuckets(),
+		RequiredTags("Project", "ManagedBy"),
+		NoDestroy(CDNTypes...),
+	}
+}
+
+// CDNTypes are resource types whose loss takes the site offline
+var CDNTypes = []string{
+	"aws_cloudfront_distribution",
+	"azurerm_cdn_profile",
+	"azurerm_cdn_endpoint",
+	"azurerm_cdn_frontdoor_profile",
+	"azurerm_cdn_frontdoor_endpoint",
+	"azurerm_dns_zone",
+}
+
+// publicACLs are canned S3 ACLs granting access to everyone
+var publicACLs = []string{"public-read", "public-read-write", "authenticated-read"}
+
+// NoPublicBuckets rejects public S3 ACLs, disabled S3 public access blocks,
+// and Azure storage that allows anonymous blob access
+func NoPublicBuckets() Policy {
+	return Policy{Name: "no-public-buckets", Check: func(rc ResourceChange) error {
+		after := rc.Change.After
+		if after == nil {
+			return nil
+		}
+		switch rc.Type {
+		case "aws_s3_bucket", "aws_s3_bucket_acl":
+			if acl, _ := after["acl"].(string); slices.Contains(publicACLs, acl) {
+				return fmt.Errorf("acl %q makes the bucket public", acl)
+			}
+		case "aws_s3_bucket_public_access_block", "aws_s3_account_public_access_block":
+			var open []string
+			for _, attr := range []string{"block_public_acls", "block_public_policy", "ignore_public_acls", "restrict_public_buckets"} {
+				if v, ok := after[attr].(bool); ok && !v {
+					open = append(open, attr)
+				}
+			}
+			if len(open) > 0 {
+				return fmt.Errorf("public access not blocked: %s disabled", strings.Join(open, ", "))
+			}
+		case "azurerm_storage_account":
+			if v, _ := after["allow_nested_items_to_be_public"].(bool); v {
+				return errors.New("allow_nested_items_to_be_public permits anonymous blob access")
+			}
+		case "azurerm_storage_container":
+			if v, _ := after["container_access_type"].(string); v != "" && v != "private" {
+				return fmt.Errorf("container_access_type %q permits anonymous access", v)
+			}
+		}
+		return nil
+	}}
+}
+
+// RequiredTags requires the given tag keys on every resource that supports
+// tags. Tags only known after apply can't be checked and pass.
+func RequiredTags(keys ...string) Policy {
+	return Policy{Name: "required-tags", Check: func(rc ResourceChange) error {
+		after := rc.Change.After
+		if after == nil {
+			return nil
+		}
+		raw, taggable := after["tags"]
+		if !taggable {
+			return nil
+		}
+		if unknown, _ := rc.Change.AfterUnknown["tags"].(bool); unknown {
+			return nil
+		}
+		tags, _ := raw.(map[string]any)
+		var missing []string
+		for _, key := range keys {
+			if _, ok := tags[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("missing tags %s", strings.Join(missing, ", "))
+		}
+		return nil
+	}}
+}
+
+// NoDestroy rejects deleting or replacing resources of the given types
+func NoDestroy(types ...string) Policy {
+	return Policy{Name: "no-destroy", Check: func(rc ResourceChange) error {
+		if !slices.Contains(types, rc.Type) || !rc.Change.Deletes() {
+			return nil
+		}
+		if rc.Change.Removed() {
+			return errors.New("plan destroys this resource")
+		}
+		return errors.New("plan replaces this resource (destroy then create)")
+	}}
+}
@@ -0,0 +1,63 @@
+package tfplan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const planJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "module.dns.azurerm_dns_zone.main", "mode": "managed", "type": "azurerm_dns_zone", "name": "main",
+     "change": {"actions": ["delete"], "before": {"name": "example.com"}, "after": null}},
+    {"address": "aws_s3_bucket_acl.site", "mode": "managed", "type": "aws_s3_bucket_acl", "name": "site",
+     "change": {"actions": ["create"], "after": {"acl": "public-read"}}},
+    {"address": "aws_s3_bucket_public_access_block.site", "mode": "managed", "type": "aws_s3_bucket_public_access_block", "name": "site",
+     "change": {"actions": ["update"], "after": {"block_public_acls": true, "block_public_policy": false, "ignore_public_acls": true, "restrict_public_buckets": true}}},
+    {"address": "azurerm_resource_group.main", "mode": "managed", "type": "azurerm_resource_group", "name": "main",
+     "change": {"actions": ["create"], "after": {"tags": {"Project": "spider-2y-banana"}}}},
+    {"address": "module.acr.azurerm_container_registry.main", "mode": "managed", "type": "azurerm_container_registry", "name": "main",
+     "change": {"actions": ["create"], "after": {}, "after_unknown": {"tags": true}}},
+    {"address": "random_string.suffix", "mode": "managed", "type": "random_string", "name": "suffix",
+     "change": {"actions": ["delete", "create"], "after": {"length": 6}}},
+    {"address": "data.azurerm_client_config.current", "mode": "data", "type": "azurerm_client_config", "name": "current",
+     "change": {"actions": ["read"], "after": {"tags": {}}}}
+  ]
+}`
+
+func TestEvaluate(t *testing.T) {
+	plan, err := Parse([]byte(planJSON))
+	require.NoError(t, err)
+
+	var got []string
+	for _, v := range Evaluate(plan, Default()) {
+		got = append(got, v.Policy+" "+v.Address)
+	}
+	assert.ElementsMatch(t, []string{
+		"no-destroy module.dns.azurerm_dns_zone.main",
+		"no-public-buckets aws_s3_bucket_acl.site",
+		"no-public-buckets aws_s3_bucket_public_access_block.site",
+		"required-tags azurerm_resource_group.main",
+	}, got)
+}
+
+func TestNoDestroyReplacement(t *testing.T) {
+	rc := ResourceChange{Type: "aws_cloudfront_distribution", Change: Change{Actions: []string{"delete", "create"}}}
+	assert.ErrorContains(t, NoDestroy(CDNTypes...).Check(rc), "replaces")
+}
+
+func TestLoadJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(planJSON), 0o644))
+	plan, err := Load(context.Background(), ".", path)
+	require.NoError(t, err)
+	assert.Len(t, plan.ResourceChanges, 7)
+
+	_, err = Parse([]byte(`{"resource_changes": []}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package tlscheck
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Versions are the protocol versions a scan probes, oldest first
+var Versions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// Suite is a cipher suite the server accepted for a protocol version
+type Suite struct {
+	Version uint16
+	ID      uint16
+	Name    string
+}
+
+// Weak reports whether the suite falls outside Mozilla's intermediate
+// profile: anything without forward secrecy or an AEAD cipher
+func (s Suite) Weak() bool {
+	if s.Version == tls.VersionTLS13 {
+		return false
+	}
+	forwardSecret := strings.HasPrefix(s.Name, "TLS_ECDHE_")
+	aead := strings.Contains(s.Name, "_GCM_") || strings.Contains(s.Name, "CHACHA20")
+	return !forwardSecret || !aead
+}
+
+// Matrix is the handshake capability of a server
+type Matrix struct {
+	// Versions lists the protocol versions the server negotiated
+	Versions []uint16
+	// Suites lists every accepted suite per version. TLS 1.3 suites can't
+	// be offered selectively, so only the negotiated one is recorded.
+	Suites []Suite
+}
+
+// Supports reports whether the server negotiated version
+func (m *Matrix) Supports(version uint16) bool {
+	for _, v := range m.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Problems lists legacy protocol versions and weak suites the server accepts
+func (m *Matrix) Problems() []string {
+	var problems []string
+	for _, v := range m.Versions {
+		if v < tls.VersionTLS12 {
+			problems = append(problems, tls.VersionName(v)+" is enabled")
+		}
+	}
+	for _, s := range m.Suites {
+		if s.Weak() && s.Version >= tls.VersionTLS12 {
+			problems = append(problems, fmt.Sprintf("weak cipher suite %s accepted over %s", s.Name, tls.VersionName(s.Version)))
+		}
+	}
+	return problems
+}
+
+// Scan enumerates the protocol versions and cipher suites addr accepts by
+// attempting one handshake per combination. Only the suites Go implements
+// can be probed; servers offering others (DHE, CCM) are not fully covered.
+func Scan(ctx context.Context, addr, serverName string) (*Matrix, error) {
+	suites := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	// Offer every suite when probing versions; Go's defaults omit the legacy
+	// suites old protocol versions are usually configured with
+	all := make([]uint16, len(suites))
+	for i, s := range suites {
+		all[i] = s.ID
+	}
+	m := &Matrix{}
+	var lastErr error
+	for _, v := range Versions {
+		state, err := handshake(ctx, addr, &tls.Config{ServerName: serverName, MinVersion: v, MaxVersion: v, CipherSuites: all})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		m.Versions = append(m.Versions, v)
+		if v == tls.VersionTLS13 {
+			m.Suites = append(m.Suites, Suite{Version: v, ID: state.CipherSuite, Name: tls.CipherSuiteName(state.CipherSuite)})
+			continue
+		}
+		for _, s := range suites {
+			if !supportsVersion(s, v) {
+				continue
+			}
+			cfg := &tls.Config{ServerName: serverName, MinVersion: v, MaxVersion: v, CipherSuites: []uint16{s.ID}}
+			if _, err := handshake(ctx, addr, cfg); err == nil {
+				m.Suites = append(m.Suites, Suite{Version: v, ID: s.ID, Name: s.Name})
+			}
+		}
+	}
+	if len(m.Versions) == 0 {
+		return nil, fmt.Errorf("no TLS handshake with %s succeeded: %w", addr, lastErr)
+	}
+	return m, nil
+}
+
+func supportsVersion(s *tls.CipherSuite, v uint16) bool {
+	for _, sv := range s.SupportedVersions {
+		if sv == v {
+			return true
+		}
+	}
+	return false
+}
+
+// handshake connects once without verifying the chain; Check covers that
+func handshake(ctx context.Context, addr string, cfg *tls.Config) (tls.ConnectionState, error) {
+	cfg.InsecureSkipVerify = true
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	dialer := &tls.Dialer{Config: cfg}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState(), nil
+}
@@ -0,0 +1,58 @@
+package tlscheck
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scanServer(t *testing.T, cfg *tls.Config) *Matrix {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = cfg
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	m, err := Scan(context.Background(), strings.TrimPrefix(srv.URL, "https://"), "example.com")
+	require.NoError(t, err)
+	return m
+}
+
+func TestScanModern(t *testing.T) {
+	m := scanServer(t, &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305},
+	})
+
+	assert.Equal(t, []uint16{tls.VersionTLS12, tls.VersionTLS13}, m.Versions)
+	var tls12 []string
+	for _, s := range m.Suites {
+		if s.Version == tls.VersionTLS12 {
+			tls12 = append(tls12, s.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"}, tls12)
+	assert.Empty(t, m.Problems())
+}
+
+func TestScanLegacy(t *testing.T) {
+	m := scanServer(t, &tls.Config{
+		MinVersion:   tls.VersionTLS10,
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_RSA_WITH_AES_128_CBC_SHA},
+	})
+
+	assert.True(t, m.Supports(tls.VersionTLS10))
+	assert.False(t, m.Supports(tls.VersionTLS13))
+	assert.Contains(t, m.Problems(), "TLS 1.0 is enabled")
+	assert.Contains(t, m.Problems(), "weak cipher suite TLS_RSA_WITH_AES_128_CBC_SHA accepted over TLS 1.2")
+}
@@ -0,0 +1,123 @@
+// Package tlscheck inspects the certificate chain served by the site: it
+// validates the chain exactly as served (no intermediate fetching) and
+// flags leaf certificates close to expiry.
+package tlscheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+)
+
+// Options tune the expiry windows and trust roots
+type Options struct {
+	// Warn flags leaves expiring within this window
+	Warn time.Duration
+	// Fail rejects leaves expiring within this window
+	Fail time.Duration
+	// Roots defaults to the system pool
+	Roots *x509.CertPool
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// DefaultOptions warns three weeks and fails one week before expiry, in line
+// with Let's Encrypt renewing 30 days ahead
+func DefaultOptions() Options {
+	return Options{Warn: 21 * 24 * time.Hour, Fail: 7 * 24 * time.Hour}
+}
+
+// Report describes the served chain
+type Report struct {
+	Leaf      *x509.Certificate
+	Chain     []*x509.Certificate
+	ExpiresIn time.Duration
+	// Warning is set when the leaf expires inside the warn window
+	Warning string
+	// Err is set when the chain doesn't verify or the leaf expires inside
+	// the fail window
+	Err error
+}
+
+// ErrIncompleteChain is reported when the server omits intermediates
+var ErrIncompleteChain = errors.New("incomplete certificate chain")
+
+// Check connects to addr ("host:port"), verifies the served chain for
+// serverName and evaluates leaf expiry
+func Check(ctx context.Context, addr, serverName string, opts Options) (*Report, error) {
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: true}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect %s: %w", addr, err)
+	}
+	defer conn.Close()
+	return Evaluate(conn.(*tls.Conn).ConnectionState().PeerCertificates, serverName, opts), nil
+}
+
+// Evaluate verifies a served chain (leaf first) without a network connection
+func Evaluate(served []*x509.Certificate, serverName string, opts Options) *Report {
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	report := &Report{Chain: served}
+	if len(served) == 0 {
+		report.Err = errors.New("server sent no certificates")
+		return report
+	}
+	report.Leaf = served[0]
+	report.ExpiresIn = report.Leaf.NotAfter.Sub(now())
+
+	intermediates := x509.NewCertPool()
+	for _, c := range served[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := report.Leaf.Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   now(),
+	})
+	var unknown x509.UnknownAuthorityError
+	switch {
+	case errors.As(err, &unknown) && report.Leaf.Issuer.String() != report.Leaf.Subject.String():
+		report.Err = fmt.Errorf("%w: no path from %q to a trusted root", ErrIncompleteChain, report.Leaf.Issuer.CommonName)
+	case err != nil:
+		report.Err = err
+	case report.ExpiresIn < opts.Fail:
+		report.Err = fmt.Errorf("certificate for %s expires in %v (%s)", serverName, report.ExpiresIn.Round(time.Hour), report.Leaf.NotAfter.Format(time.DateOnly))
+	case report.ExpiresIn < opts.Warn:
+		report.Warning = fmt.Sprintf("certificate for %s expires in %v (%s)", serverName, report.ExpiresIn.Round(time.Hour), report.Leaf.NotAfter.Format(time.DateOnly))
+	}
+	return report
+}
+
+// BatteryCheck returns a battery check verifying the target's certificate;
+// warnings pass. Plain HTTP targets fail the check.
+func BatteryCheck(opts Options) battery.Check {
+	return battery.Check{Name: "tls-certificate", Run: func(ctx context.Context, t *battery.Target) error {
+		u, err := url.Parse(t.BaseURL)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "https" {
+			return fmt.Errorf("%s is not served over HTTPS", t.BaseURL)
+		}
+		port := u.Port()
+		if port == "" {
+			port = "443"
+		}
+		report, err := Check(ctx, net.JoinHostPort(u.Hostname(), port), u.Hostname(), opts)
+		if err != nil {
+			return err
+		}
+		return report.Err
+	}}
+}
@@ -0,0 +1,107 @@
+package tlscheck
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type issued struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue signs a certificate with parent, or self-signs when parent is nil
+func issue(t *testing.T, name string, parent *issued, ca bool, notAfter time.Time) *issued {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  ca,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if !ca {
+		tmpl.DNSNames = []string{name}
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &issued{cert: cert, key: key}
+}
+
+func chain(t *testing.T, leafExpiry time.Time) (root, inter, leaf *issued, roots *x509.CertPool) {
+	year := time.Now().AddDate(1, 0, 0)
+	root = issue(t, "Test Root", nil, true, year)
+	inter = issue(t, "Test Intermediate", root, true, year)
+	leaf = issue(t, "resume.example.com", inter, false, leafExpiry)
+	roots = x509.NewCertPool()
+	roots.AddCert(root.cert)
+	return root, inter, leaf, roots
+}
+
+func TestEvaluate(t *testing.T) {
+	_, inter, leaf, roots := chain(t, time.Now().Add(60*24*time.Hour))
+	opts := DefaultOptions()
+	opts.Roots = roots
+
+	report := Evaluate([]*x509.Certificate{leaf.cert, inter.cert}, "resume.example.com", opts)
+	assert.NoError(t, report.Err)
+	assert.Empty(t, report.Warning)
+
+	report = Evaluate([]*x509.Certificate{leaf.cert}, "resume.example.com", opts)
+	assert.ErrorIs(t, report.Err, ErrIncompleteChain)
+
+	report = Evaluate([]*x509.Certificate{leaf.cert, inter.cert}, "other.example.com", opts)
+	assert.Error(t, report.Err, "hostname mismatch")
+
+	opts.Now = func() time.Time { return time.Now().Add(45 * 24 * time.Hour) }
+	report = Evaluate([]*x509.Certificate{leaf.cert, inter.cert}, "resume.example.com", opts)
+	assert.NoError(t, report.Err)
+	assert.Contains(t, report.Warning, "expires in")
+
+	opts.Now = func() time.Time { return time.Now().Add(55 * 24 * time.Hour) }
+	report = Evaluate([]*x509.Certificate{leaf.cert, inter.cert}, "resume.example.com", opts)
+	assert.ErrorContains(t, report.Err, "expires in")
+}
+
+func TestCheckAgainstServer(t *testing.T) {
+	_, inter, leaf, roots := chain(t, time.Now().Add(90*24*time.Hour))
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{leaf.cert.Raw, inter.cert.Raw},
+		PrivateKey:  leaf.key,
+	}}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.Roots = roots
+	report, err := Check(context.Background(), srv.Listener.Addr().String(), "resume.example.com", opts)
+	require.NoError(t, err)
+	assert.NoError(t, report.Err)
+	assert.Len(t, report.Chain, 2)
+	assert.Equal(t, "resume.example.com", report.Leaf.Subject.CommonName)
+}
@@ -0,0 +1,152 @@
+// Package tracing records the harness's runs as OpenTelemetry spans and
+// exports them over OTLP, so a slow CI run can be opened in an existing
+// tracing backend to see where the time went: the site build, the image,
+// each suite and check, and the HTTP calls they make.
+//
+// It is configured by the standard OTEL_* environment variables and is
+// off, at no cost, unless OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dockerrun"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Scope names the harness's tracer
+const Scope = "github.com/spider-2y-banana/osyraa/tests"
+
+// Enabled reports whether an OTLP endpoint is configured
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup installs a tracer provider that batches spans to the OTLP/HTTP
+// endpoint, and W3C trace context propagation so outgoing requests carry
+// the trace. The returned shutdown flushes what is left; call it before
+// the process exits. When tracing is not Enabled, Setup installs nothing
+// and shutdown does nothing.
+func Setup(ctx context.Context, service string, attrs ...attribute.KeyValue) (shutdown func(context.Context) error, err error) {
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The environment (OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES)
+	// overrides the defaults given here
+	res, err := resource.Merge(
+		resource.NewSchemaless(append([]attribute.KeyValue{semconv.ServiceName(service)}, attrs...)...),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// FromEnv continues the trace named by TRACEPARENT, as CI systems and
+// tools like otel-cli export it, so the run nests under the job's span
+func FromEnv(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{}
+	if v := os.Getenv("TRACEPARENT"); v != "" {
+		carrier["traceparent"] = v
+	}
+	if v := os.Getenv("TRACESTATE"); v != "" {
+		carrier["tracestate"] = v
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// Start begins a span of the harness's tracer
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(Scope).Start(ctx, name, opts...)
+}
+
+// End ends span, marking it failed with err when err is not nil
+func End(span trace.Span, err error, opts ...trace.SpanEndOption) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(opts...)
+}
+
+// Transport gives every request through next a client span and the trace
+// context headers
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(next)
+}
+
+// Run runs cmd in a span named name and returns its combined output, as
+// cmd.CombinedOutput does. The span carries the command line, and
+// TRACEPARENT is passed on so traced tools can join the trace.
+func Run(ctx context.Context, name string, cmd *exec.Cmd) ([]byte, error) {
+	ctx, span := Start(ctx, name, trace.WithAttributes(
+		attribute.String("process.command", cmd.Path),
+		attribute.String("process.command_line", strings.Join(cmd.Args, " ")),
+	))
+	if tp := traceparent(ctx); tp != "" {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, "TRACEPARENT="+tp)
+	}
+	output, err := cmd.CombinedOutput()
+	if cmd.ProcessState != nil {
+		span.SetAttributes(attribute.Int("process.exit.code", cmd.ProcessState.ExitCode()))
+	}
+	End(span, err)
+	return output, err
+}
+
+// RunContainer runs c through the engine in a span named name and returns
+// its output, as Run does for a command: a non-zero exit is an error, and
+// TRACEPARENT is set in the container.
+func RunContainer(ctx context.Context, name string, docker dockerrun.Client, c dockerrun.Container) ([]byte, error) {
+	ctx, span := Start(ctx, name, trace.WithAttributes(
+		attribute.String("container.image.name", c.Image),
+		attribute.String("process.command_line", strings.Join(c.Cmd, " ")),
+	))
+	if tp := traceparent(ctx); tp != "" {
+		c.Env = append(slices.Clip(c.Env), "TRACEPARENT="+tp)
+	}
+	res, err := dockerrun.Run(ctx, docker, c)
+	if err == nil {
+		span.SetAttributes(attribute.Int("process.exit.code", res.ExitCode))
+		if res.ExitCode != 0 {
+			err = fmt.Errorf("%s: exit status %d", c.Image, res.ExitCode)
+		}
+	}
+	End(span, err)
+	return []byte(res.Output), err
+}
+
+// traceparent is the W3C trace context header of ctx's span, empty when
+// there is none
+func traceparent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier["traceparent"]
+}
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record installs a provider keeping finished spans in memory
+func record(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	prev, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	return recorder
+}
+
+func TestSetupDisabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	assert.False(t, Enabled())
+	shutdown, err := Setup(context.Background(), "osyraa-tests")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	_, span := Start(context.Background(), "noop")
+	assert.False(t, span.SpanContext().IsValid(), "spans are no-ops without an endpoint")
+}
+
+func TestStartEnd(t *testing.T) {
+	recorder := record(t)
+	ctx, parent := Start(context.Background(), "run")
+	_, child := Start(ctx, "check")
+	End(child, errors.New("boom"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "check", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+	assert.Len(t, spans[0].Events(), 1, "the error is recorded as an event")
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestTransport(t *testing.T) {
+	recorder := record(t)
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+	}))
+	defer srv.Close()
+
+	ctx, parent := Start(context.Background(), "suite")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/", nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: Transport(http.DefaultTransport)}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	client := spans[0]
+	assert.Equal(t, trace.SpanKindClient, client.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), client.Parent().SpanID())
+	assert.Contains(t, traceparent, client.SpanContext().TraceID().String(), "the server sees the trace")
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	recorder := record(t)
+	ctx, parent := Start(context.Background(), "build")
+	output, err := Run(ctx, "hugo build", exec.Command("sh", "-c", `echo "$TRACEPARENT"; exit 3`))
+	assert.Error(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "hugo build", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("process.exit.code", 3))
+	assert.Contains(t, string(output), spans[0].SpanContext().SpanID().String(), "the command can join the trace")
+}
+
+func TestFromEnv(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	t.Setenv("TRACEPARENT", tp)
+	recorder := record(t)
+	_, span := Start(FromEnv(context.Background()), "run")
+	span.End()
+	got := recorder.Ended()[0]
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", got.Parent().SpanID().String())
+
+	os.Unsetenv("TRACEPARENT")
+	assert.False(t, trace.SpanContextFromContext(FromEnv(context.Background())).IsValid())
+}
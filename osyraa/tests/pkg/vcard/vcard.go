@@ -0,0 +1,221 @@
+// Package vcard parses and validates the site's contact card as specified
+// by RFC 6350 (vCard 4.0), and fetches it the way a browser downloading
+// it would.
+package vcard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+)
+
+// MediaType is the registered type for vCards (RFC 6350 10.1)
+const MediaType = "text/vcard"
+
+// maxLine is the line length, in octets, RFC 6350 3.2 says to fold at
+const maxLine = 75
+
+// Property is a content line: [group "."] name *(";" param) ":" value
+type Property struct {
+	Group  string
+	Name   string
+	Params map[string][]string
+	Value  string
+	Line   int
+}
+
+// Card is a parsed vCard
+type Card struct {
+	Properties []Property
+	// BareLF is set when lines end in LF rather than the required CRLF
+	BareLF bool
+	// LongLines are the lines, before unfolding, longer than 75 octets
+	LongLines []int
+}
+
+// Parse reads a single vCard, unfolding continuation lines. Syntax errors
+// are returned; the RFC's rules are checked by Validate.
+func Parse(data []byte) (*Card, error) {
+	c := &Card{}
+	lines := bytes.Split(data, []byte("\n"))
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	var logical []string
+	var starts []int
+	for i, raw := range lines {
+		line, crlf := bytes.CutSuffix(raw, []byte("\r"))
+		if !crlf {
+			c.BareLF = true
+		}
+		if len(line) > maxLine {
+			c.LongLines = append(c.LongLines, i+1)
+		}
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(logical) > 0 {
+			logical[len(logical)-1] += string(line[1:])
+			continue
+		}
+		logical = append(logical, string(line))
+		starts = append(starts, i+1)
+	}
+
+	for i, line := range logical {
+		if line == "" {
+			continue
+		}
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", starts[i], err)
+		}
+		p.Line = starts[i]
+		c.Properties = append(c.Properties, p)
+	}
+	return c, nil
+}
+
+func parseLine(line string) (Property, error) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return Property{}, fmt.Errorf("expected \"NAME:value\", got %q", line)
+	}
+	parts := strings.Split(head, ";")
+	p := Property{Name: parts[0], Value: value}
+	if group, name, ok := strings.Cut(p.Name, "."); ok {
+		p.Group, p.Name = group, name
+	}
+	p.Name = strings.ToUpper(p.Name)
+	if p.Name == "" {
+		return Property{}, fmt.Errorf("property has no name in %q", line)
+	}
+	for _, param := range parts[1:] {
+		k, v, ok := strings.Cut(param, "=")
+		if !ok {
+			return Property{}, fmt.Errorf("%s parameter %q has no value", p.Name, param)
+		}
+		if p.Params == nil {
+			p.Params = map[string][]string{}
+		}
+		k = strings.ToUpper(k)
+		p.Params[k] = append(p.Params[k], strings.Split(strings.Trim(v, `"`), ",")...)
+	}
+	return p, nil
+}
+
+// Get returns the properties with the given name, in order
+func (c *Card) Get(name string) []Property {
+	var out []Property
+	for _, p := range c.Properties {
+		if p.Name == strings.ToUpper(name) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Value returns the first value of the named property, or ""
+func (c *Card) Value(name string) string {
+	if ps := c.Get(name); len(ps) > 0 {
+		return ps[0].Value
+	}
+	return ""
+}
+
+// singular properties may appear at most once (RFC 6350 section 6)
+var singular = []string{"N", "BDAY", "ANNIVERSARY", "GENDER", "PRODID", "REV", "UID", "KIND"}
+
+// Validate applies RFC 6350: BEGIN and END around the card, VERSION:4.0
+// straight after BEGIN, at least one FN, CRLF line endings and folded
+// lines, and well-formed EMAIL and URL values
+func (c *Card) Validate() error {
+	var errs []error
+	n := len(c.Properties)
+	if n == 0 || c.Properties[0].Name != "BEGIN" || !strings.EqualFold(c.Properties[0].Value, "VCARD") {
+		errs = append(errs, errors.New("does not start with BEGIN:VCARD"))
+	}
+	if n == 0 || c.Properties[n-1].Name != "END" || !strings.EqualFold(c.Properties[n-1].Value, "VCARD") {
+		errs = append(errs, errors.New("does not end with END:VCARD"))
+	}
+	switch {
+	case n < 2 || c.Properties[1].Name != "VERSION":
+		errs = append(errs, errors.New("VERSION must come straight after BEGIN:VCARD"))
+	case c.Properties[1].Value != "4.0":
+		errs = append(errs, fmt.Errorf("VERSION is %q, want 4.0", c.Properties[1].Value))
+	}
+	if len(c.Get("VERSION")) > 1 {
+		errs = append(errs, errors.New("VERSION must appear once"))
+	}
+	if fn := c.Get("FN"); len(fn) == 0 || strings.TrimSpace(fn[0].Value) == "" {
+		errs = append(errs, errors.New("no FN property"))
+	}
+	for _, name := range singular {
+		if len(c.Get(name)) > 1 {
+			errs = append(errs, fmt.Errorf("%s must appear at most once", name))
+		}
+	}
+	if c.BareLF {
+		errs = append(errs, errors.New("lines must end in CRLF"))
+	}
+	for _, line := range c.LongLines {
+		errs = append(errs, fmt.Errorf("line %d is longer than %d octets and should be folded", line, maxLine))
+	}
+	for _, p := range c.Get("EMAIL") {
+		if !strings.Contains(p.Value, "@") {
+			errs = append(errs, fmt.Errorf("line %d: EMAIL %q is not an address", p.Line, p.Value))
+		}
+	}
+	for _, name := range []string{"URL", "PHOTO", "LOGO", "SOURCE", "X-SOCIALPROFILE"} {
+		for _, p := range c.Get(name) {
+			if u, err := url.Parse(p.Value); err != nil || u.Scheme == "" {
+				errs = append(errs, fmt.Errorf("line %d: %s %q is not a URI", p.Line, name, p.Value))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Links returns the vCards a page offers: links to .vcf files or typed
+// text/vcard
+func Links(doc *match.Document) []string {
+	var out []string
+	for _, n := range doc.Select("a[href]").Nodes() {
+		href, _ := match.Attr(n, "href")
+		typ, _ := match.Attr(n, "type")
+		u, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		if strings.HasSuffix(strings.ToLower(u.Path), ".vcf") || strings.EqualFold(typ, MediaType) {
+			out = append(out, href)
+		}
+	}
+	return out
+}
+
+// Fetch retrieves a vCard from the target, requiring a 200 served as
+// text/vcard; a charset, if given, must be utf-8
+func Fetch(ctx context.Context, t *battery.Target, path string) (*Card, error) {
+	resp, body, err := t.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %d", path, resp.StatusCode)
+	}
+	ct := resp.Header.Get("Content-Type")
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil || mediaType != MediaType {
+		return nil, fmt.Errorf("%s served as %q, want %s", path, ct, MediaType)
+	}
+	if cs, ok := params["charset"]; ok && !strings.EqualFold(cs, "utf-8") {
+		return nil, fmt.Errorf("%s served as %q, want charset=utf-8", path, ct)
+	}
+	return Parse(body)
+}
@@ -0,0 +1,115 @@
+package vcard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/match"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func card(lines ...string) []byte {
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+var valid = card(
+	"BEGIN:VCARD",
+	"VERSION:4.0",
+	"KIND:individual",
+	"FN:Jane Doe",
+	"N:Doe;Jane;;;",
+	"TITLE:Platform Engineer\\, SRE",
+	"EMAIL;TYPE=work:jane@example.com",
+	"TEL;TYPE=cell,voice:206-666-5568",
+	"item1.URL:https://github.com/jane",
+	"NOTE:A note long enough that it has to be folded onto a second line to ",
+	" stay under 75 octets",
+	"END:VCARD",
+)
+
+func TestParse(t *testing.T) {
+	c, err := Parse(valid)
+	require.NoError(t, err)
+	require.NoError(t, c.Validate())
+
+	assert.Equal(t, "Jane Doe", c.Value("fn"))
+	tel := c.Get("TEL")
+	require.Len(t, tel, 1)
+	assert.Equal(t, []string{"cell", "voice"}, tel[0].Params["TYPE"])
+	assert.Equal(t, 8, tel[0].Line)
+	url := c.Get("URL")
+	require.Len(t, url, 1)
+	assert.Equal(t, "item1", url[0].Group)
+	assert.Equal(t, "A note long enough that it has to be folded onto a second line to stay under 75 octets", c.Value("NOTE"))
+	assert.Equal(t, 12, c.Get("END")[0].Line)
+
+	_, err = Parse(card("BEGIN:VCARD", "no colon here"))
+	assert.ErrorContains(t, err, "line 2")
+	_, err = Parse(card("BEGIN:VCARD", "TEL;cell:1"))
+	assert.ErrorContains(t, err, `TEL parameter "cell" has no value`)
+}
+
+func TestValidateRejects(t *testing.T) {
+	c, err := Parse([]byte("BEGIN:VCARD\nFN:\nVERSION:3.0\nN:a\nN:b\nEMAIL:jane\nURL:github.com/jane\nNOTE:" +
+		strings.Repeat("x", 80) + "\n"))
+	require.NoError(t, err)
+	err = c.Validate()
+	for _, want := range []string{
+		"does not end with END:VCARD",
+		"VERSION must come straight after BEGIN:VCARD",
+		"no FN property",
+		"N must appear at most once",
+		"lines must end in CRLF",
+		"line 8 is longer than 75 octets",
+		`line 6: EMAIL "jane" is not an address`,
+		`line 7: URL "github.com/jane" is not a URI`,
+	} {
+		assert.ErrorContains(t, err, want)
+	}
+
+	c, err = Parse(card("BEGIN:VCARD", "VERSION:3.0", "FN:Jane", "END:VCARD"))
+	require.NoError(t, err)
+	assert.EqualError(t, c.Validate(), `VERSION is "3.0", want 4.0`)
+	c, err = Parse(nil)
+	require.NoError(t, err)
+	assert.ErrorContains(t, c.Validate(), "does not start with BEGIN:VCARD")
+}
+
+func TestLinks(t *testing.T) {
+	doc, err := match.ParseBytes([]byte(`<a href="/contact.vcf" download>Save</a>
+<a href="/card?id=1" type="text/vcard">Card</a><a href="/cv.pdf">PDF</a>`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/contact.vcf", "/card?id=1"}, Links(doc))
+}
+
+func TestFetch(t *testing.T) {
+	contentType := "text/vcard; charset=utf-8"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/contact.vcf" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(valid)
+	}))
+	defer srv.Close()
+	target := battery.NewTarget(srv.URL)
+
+	c, err := Fetch(context.Background(), target, "/contact.vcf")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", c.Value("FN"))
+
+	_, err = Fetch(context.Background(), target, "/missing.vcf")
+	assert.ErrorContains(t, err, "returned 404")
+	contentType = "text/plain"
+	_, err = Fetch(context.Background(), target, "/contact.vcf")
+	assert.ErrorContains(t, err, `served as "text/plain", want text/vcard`)
+	contentType = "text/vcard; charset=iso-8859-1"
+	_, err = Fetch(context.Background(), target, "/contact.vcf")
+	assert.ErrorContains(t, err, "want charset=utf-8")
+}
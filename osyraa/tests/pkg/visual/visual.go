@@ -0,0 +1,192 @@
+// Package visual compares page screenshots against stored baselines. The
+// comparison is perceptual in the manner of pixelmatch: colours are
+// compared in YIQ space, so differences the eye barely sees (font
+// smoothing, JPEG-like noise) fall under the threshold while real layout
+// shifts do not.
+package visual
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/golden"
+)
+
+// DefaultThreshold is the per-pixel colour distance, from 0 to 1, above
+// which two pixels differ
+const DefaultThreshold = 0.1
+
+// DefaultTolerance is the fraction of pixels allowed to differ before a
+// page counts as changed
+const DefaultTolerance = 0.001
+
+// maxDelta is the largest possible YIQ distance between two colours
+const maxDelta = 35215.0
+
+// Diff is the outcome of comparing a screenshot with its baseline
+type Diff struct {
+	// Changed pixels out of Total; pixels outside either image count as
+	// changed when the sizes differ
+	Changed, Total int
+	// Bounds encloses every changed pixel
+	Bounds image.Rectangle
+	// SizeChanged reports that the images have different dimensions
+	SizeChanged bool
+	// Image shows the baseline faded with changed pixels in red and
+	// Bounds outlined
+	Image *image.RGBA
+}
+
+// Ratio is the fraction of pixels that changed
+func (d Diff) Ratio() float64 {
+	if d.Total == 0 {
+		return 0
+	}
+	return float64(d.Changed) / float64(d.Total)
+}
+
+// Within reports whether the change is small enough to ignore
+func (d Diff) Within(tolerance float64) bool {
+	return !d.SizeChanged && d.Ratio() <= tolerance
+}
+
+func (d Diff) String() string {
+	s := fmt.Sprintf("%d of %d pixels changed (%.3f%%)", d.Changed, d.Total, 100*d.Ratio())
+	if d.SizeChanged {
+		s += ", size changed"
+	}
+	if d.Changed > 0 {
+		s += fmt.Sprintf(" within %v", d.Bounds)
+	}
+	return s
+}
+
+var (
+	changedColor = color.RGBA{R: 255, A: 255}
+	boundsColor  = color.RGBA{R: 255, B: 255, A: 255}
+)
+
+// Compare diffs actual against baseline. Pixels differ when their
+// perceptual distance exceeds threshold.
+func Compare(baseline, actual image.Image, threshold float64) Diff {
+	bb, ab := baseline.Bounds(), actual.Bounds()
+	w, h := max(bb.Dx(), ab.Dx()), max(bb.Dy(), ab.Dy())
+	d := Diff{
+		Total:       w * h,
+		SizeChanged: bb.Size() != ab.Size(),
+		Image:       image.NewRGBA(image.Rect(0, 0, w, h)),
+	}
+	limit := maxDelta * threshold * threshold
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			inBase := x < bb.Dx() && y < bb.Dy()
+			inActual := x < ab.Dx() && y < ab.Dy()
+			changed := !inBase || !inActual
+			if !changed {
+				changed = colorDelta(baseline.At(bb.Min.X+x, bb.Min.Y+y), actual.At(ab.Min.X+x, ab.Min.Y+y)) > limit
+			}
+			if changed {
+				d.Changed++
+				d.Bounds = d.Bounds.Union(image.Rect(x, y, x+1, y+1))
+				d.Image.SetRGBA(x, y, changedColor)
+				continue
+			}
+			d.Image.SetRGBA(x, y, faded(baseline.At(bb.Min.X+x, bb.Min.Y+y)))
+		}
+	}
+	if d.Changed > 0 {
+		outline(d.Image, d.Bounds.Inset(-2), boundsColor)
+	}
+	return d
+}
+
+// yiq converts a colour, blended onto white, to YIQ
+func yiq(c color.Color) (y, i, q float64) {
+	r16, g16, b16, a16 := c.RGBA()
+	a := float64(a16) / 0xffff
+	blend := func(v uint32) float64 { return 255 + (float64(v)/0xffff*255-255)*a }
+	r, g, b := blend(r16), blend(g16), blend(b16)
+	y = r*0.29889531 + g*0.58662247 + b*0.11448223
+	i = r*0.59597799 - g*0.27417610 - b*0.32180189
+	q = r*0.21147017 - g*0.52261711 + b*0.31114694
+	return y, i, q
+}
+
+func colorDelta(a, b color.Color) float64 {
+	y1, i1, q1 := yiq(a)
+	y2, i2, q2 := yiq(b)
+	dy, di, dq := y1-y2, i1-i2, q1-q2
+	return 0.5053*dy*dy + 0.299*di*di + 0.1957*dq*dq
+}
+
+// faded turns an unchanged pixel into pale grey so changes stand out
+func faded(c color.Color) color.RGBA {
+	y, _, _ := yiq(c)
+	v := uint8(255 + (y-255)*0.1)
+	return color.RGBA{R: v, G: v, B: v, A: 255}
+}
+
+func outline(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for x := r.Min.X; x < r.Max.X; x++ {
+		img.SetRGBA(x, r.Min.Y, c)
+		img.SetRGBA(x, r.Max.Y-1, c)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		img.SetRGBA(r.Min.X, y, c)
+		img.SetRGBA(r.Max.X-1, y, c)
+	}
+}
+
+// Baselines is a directory of reference screenshots named <name>.png
+type Baselines struct {
+	Dir string
+	// Update overwrites baselines with the new screenshots instead of
+	// comparing, to accept an intended change or add a page
+	Update bool
+}
+
+// Result is the outcome of Baselines.Check
+type Result struct {
+	// Updated is set when Update was set and the screenshot was stored as
+	// the new baseline
+	Updated  bool
+	Baseline image.Image
+	Actual   image.Image
+	Diff     Diff
+}
+
+// Check compares a PNG screenshot with the baseline called name. A
+// missing baseline is an error wrapping golden.ErrMissing.
+func (b Baselines) Check(name string, screenshot []byte, threshold float64) (Result, error) {
+	actual, err := png.Decode(bytes.NewReader(screenshot))
+	if err != nil {
+		return Result{}, fmt.Errorf("decode screenshot %s: %w", name, err)
+	}
+	data, updated, err := golden.Dir{Path: b.Dir, Update: b.Update}.Load(name+".png", screenshot)
+	if err != nil || updated {
+		return Result{Updated: updated, Actual: actual}, err
+	}
+	baseline, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, fmt.Errorf("decode baseline %s.png: %w", name, err)
+	}
+	return Result{Baseline: baseline, Actual: actual, Diff: Compare(baseline, actual, threshold)}, nil
+}
+
+// WritePNG encodes img to path
+func WritePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
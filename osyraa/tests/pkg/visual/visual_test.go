@@ -0,0 +1,106 @@
+package visual
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func solid(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encode(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestCompare(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	base := solid(20, 10, white)
+
+	same := Compare(base, solid(20, 10, white), DefaultThreshold)
+	assert.Zero(t, same.Changed)
+	assert.True(t, same.Within(0))
+
+	// A barely different shade is below the perceptual threshold
+	nearly := Compare(base, solid(20, 10, color.RGBA{250, 250, 250, 255}), DefaultThreshold)
+	assert.Zero(t, nearly.Changed)
+
+	changed := solid(20, 10, white)
+	for y := 2; y < 4; y++ {
+		for x := 5; x < 8; x++ {
+			changed.Set(x, y, color.RGBA{0, 0, 0, 255})
+		}
+	}
+	d := Compare(base, changed, DefaultThreshold)
+	assert.Equal(t, 6, d.Changed)
+	assert.Equal(t, 200, d.Total)
+	assert.Equal(t, image.Rect(5, 2, 8, 4), d.Bounds)
+	assert.Equal(t, changedColor, d.Image.RGBAAt(6, 3))
+	assert.Equal(t, boundsColor, d.Image.RGBAAt(3, 0), "the change should be outlined")
+	assert.False(t, d.Within(0.01))
+	assert.True(t, d.Within(0.05))
+	assert.Equal(t, "6 of 200 pixels changed (3.000%) within (5,2)-(8,4)", d.String())
+
+	taller := Compare(base, solid(20, 12, white), DefaultThreshold)
+	assert.True(t, taller.SizeChanged)
+	assert.Equal(t, 40, taller.Changed)
+	assert.False(t, taller.Within(1))
+}
+
+func TestBaselines(t *testing.T) {
+	b := Baselines{Dir: filepath.Join(t.TempDir(), "baselines")}
+	shot := encode(t, solid(4, 4, color.RGBA{0, 0, 255, 255}))
+
+	_, err := b.Check("home-desktop", shot, DefaultThreshold)
+	assert.ErrorIs(t, err, golden.ErrMissing, "a missing baseline fails")
+	assert.NoFileExists(t, filepath.Join(b.Dir, "home-desktop.png"))
+
+	b.Update = true
+	res, err := b.Check("home-desktop", shot, DefaultThreshold)
+	require.NoError(t, err)
+	assert.True(t, res.Updated, "Update stores the baseline")
+	assert.FileExists(t, filepath.Join(b.Dir, "home-desktop.png"))
+	b.Update = false
+
+	res, err = b.Check("home-desktop", encode(t, solid(4, 4, color.RGBA{255, 0, 0, 255})), DefaultThreshold)
+	require.NoError(t, err)
+	assert.False(t, res.Updated)
+	assert.Equal(t, 16, res.Diff.Changed)
+
+	b.Update = true
+	red := encode(t, solid(4, 4, color.RGBA{255, 0, 0, 255}))
+	res, err = b.Check("home-desktop", red, DefaultThreshold)
+	require.NoError(t, err)
+	assert.True(t, res.Updated)
+	stored, err := os.ReadFile(filepath.Join(b.Dir, "home-desktop.png"))
+	require.NoError(t, err)
+	assert.Equal(t, red, stored)
+}
+
+func TestWritePNG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "diff.png")
+	require.NoError(t, WritePNG(path, solid(2, 2, color.White)))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	img, err := png.Decode(f)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 2, 2), img.Bounds())
+}
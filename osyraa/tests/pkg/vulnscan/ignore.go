@@ -0,0 +1,73 @@
+package vulnscan
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// Ignore is a list of accepted vulnerabilities in .trivyignore format, so
+// a manual trivy run reads the same file: one ID per line, # comments, and
+// an optional exp:YYYY-MM-DD after the ID after which the entry stops
+// applying.
+type Ignore struct {
+	entries map[string]time.Time
+	// Expired lists the entries past their expiry, for reporting
+	Expired []string
+}
+
+// LoadIgnore reads an ignore file as of now. A missing file accepts
+// nothing.
+func LoadIgnore(path string, now time.Time) (*Ignore, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Ignore{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ig := &Ignore{entries: map[string]time.Time{}}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var expires time.Time
+		for _, field := range fields[1:] {
+			date, ok := strings.CutPrefix(field, "exp:")
+			if !ok {
+				continue
+			}
+			if expires, err = time.Parse(time.DateOnly, date); err != nil {
+				return nil, fmt.Errorf("%s:%d: expiry %q should be a date such as 2026-12-31", path, n, date)
+			}
+		}
+		// an entry lasts to the end of its expiry day
+		if !expires.IsZero() && !now.Before(expires.AddDate(0, 0, 1)) {
+			ig.Expired = append(ig.Expired, fields[0]+" (expired "+expires.Format(time.DateOnly)+")")
+			continue
+		}
+		ig.entries[fields[0]] = expires
+	}
+	return ig, scanner.Err()
+}
+
+// Accepts reports whether a vulnerability ID is accepted. A nil Ignore
+// accepts nothing.
+func (ig *Ignore) Accepts(id string) bool {
+	if ig == nil {
+		return false
+	}
+	_, ok := ig.entries[id]
+	return ok
+}
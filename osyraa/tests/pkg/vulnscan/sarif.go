@@ -0,0 +1,85 @@
+package vulnscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SARIF renders findings as a SARIF 2.1.0 log that code-scanning
+// dashboards can import: one rule per vulnerability ID and one result per
+// affected package, at error level for failing findings and note level for
+// accepted ones.
+func SARIF(image, version string, failing, accepted []Vulnerability) ([]byte, error) {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type rule struct {
+		ID               string            `json:"id"`
+		ShortDescription message           `json:"shortDescription"`
+		HelpURI          string            `json:"helpUri,omitempty"`
+		Properties       map[string]string `json:"properties"`
+	}
+	type location struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+		} `json:"physicalLocation"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+
+	var rules []rule
+	var results []result
+	seen := map[string]bool{}
+	add := func(v Vulnerability, level string) {
+		if !seen[v.ID] {
+			seen[v.ID] = true
+			desc := v.Title
+			if desc == "" {
+				desc = v.ID
+			}
+			rules = append(rules, rule{ID: v.ID, ShortDescription: message{desc}, HelpURI: v.PrimaryURL,
+				Properties: map[string]string{"severity": strings.ToLower(v.Severity)}})
+		}
+		var loc location
+		loc.PhysicalLocation.ArtifactLocation.URI = image + "/" + v.Target
+		results = append(results, result{RuleID: v.ID, Level: level, Message: message{v.String()}, Locations: []location{loc}})
+	}
+	for _, v := range failing {
+		add(v, "error")
+	}
+	for _, v := range accepted {
+		add(v, "note")
+	}
+	if rules == nil {
+		rules = []rule{}
+	}
+	if results == nil {
+		results = []result{}
+	}
+
+	log := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{map[string]any{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":           "Trivy",
+				"version":        version,
+				"informationUri": "https://github.com/aquasecurity/trivy",
+				"rules":          rules,
+			}},
+			"results": results,
+		}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode SARIF: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,174 @@
+// Package vulnscan scans a container image for known vulnerabilities with
+// Trivy and gates on the findings. Trivy runs from its container image
+// against an archive written by docker save, so only Docker is required
+// and the scanner never needs the engine's socket.
+package vulnscan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dockerrun"
+)
+
+// DefaultImage is the Trivy release the scan runs from
+const DefaultImage = "aquasec/trivy:0.50.1"
+
+// Severities in increasing order, as Trivy names them
+var Severities = []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+// SeverityLevel returns the rank of a severity in Severities, or -1 for a
+// name Trivy doesn't use
+func SeverityLevel(name string) int {
+	return slices.Index(Severities, strings.ToUpper(strings.TrimSpace(name)))
+}
+
+// Report is the subset of Trivy's JSON report the gate needs
+type Report struct {
+	ArtifactName string   `json:"ArtifactName"`
+	Results      []Result `json:"Results"`
+}
+
+// Result is the findings for one target in the image: the OS packages or
+// one language's lock file
+type Result struct {
+	Target          string          `json:"Target"`
+	Class           string          `json:"Class"`
+	Type            string          `json:"Type"`
+	Vulnerabilities []Vulnerability `json:"Vulnerabilities"`
+}
+
+// Vulnerability is one advisory affecting one installed package
+type Vulnerability struct {
+	ID               string `json:"VulnerabilityID"`
+	PkgName          string `json:"PkgName"`
+	InstalledVersion string `json:"InstalledVersion"`
+	// FixedVersion is empty when no fix has been released
+	FixedVersion string `json:"FixedVersion"`
+	Severity     string `json:"Severity"`
+	Title        string `json:"Title"`
+	PrimaryURL   string `json:"PrimaryURL"`
+	// Target is filled in from the enclosing result
+	Target string `json:"-"`
+}
+
+func (v Vulnerability) String() string {
+	fix := "no fix released"
+	if v.FixedVersion != "" {
+		fix = "fixed in " + v.FixedVersion
+	}
+	return fmt.Sprintf("%s %s in %s %s (%s, %s)", v.Severity, v.ID, v.PkgName, v.InstalledVersion, v.Target, fix)
+}
+
+// ParseReport decodes a Trivy JSON report
+func ParseReport(data []byte) (*Report, error) {
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse Trivy report: %w", err)
+	}
+	for i := range r.Results {
+		for j := range r.Results[i].Vulnerabilities {
+			r.Results[i].Vulnerabilities[j].Target = r.Results[i].Target
+		}
+	}
+	return &r, nil
+}
+
+// Gate selects the findings that fail a scan
+type Gate struct {
+	// MinSeverity is the lowest severity that fails, e.g. CRITICAL
+	MinSeverity string
+	// IgnoreUnfixed passes vulnerabilities with no released fix
+	IgnoreUnfixed bool
+	// Ignore lists accepted vulnerabilities
+	Ignore *Ignore
+}
+
+// Findings returns the vulnerabilities the gate fails on, most severe
+// first, and the ones it passed only because the ignore file accepts them
+func (r *Report) Findings(g Gate) (failing, accepted []Vulnerability) {
+	min := SeverityLevel(g.MinSeverity)
+	seen := map[string]bool{}
+	for _, res := range r.Results {
+		for _, v := range res.Vulnerabilities {
+			key := v.ID + " " + v.PkgName + " " + v.Target
+			if seen[key] || SeverityLevel(v.Severity) < min || g.IgnoreUnfixed && v.FixedVersion == "" {
+				continue
+			}
+			seen[key] = true
+			if g.Ignore.Accepts(v.ID) {
+				accepted = append(accepted, v)
+			} else {
+				failing = append(failing, v)
+			}
+		}
+	}
+	bySeverity := func(s []Vulnerability) {
+		sort.SliceStable(s, func(i, j int) bool { return SeverityLevel(s[i].Severity) > SeverityLevel(s[j].Severity) })
+	}
+	bySeverity(failing)
+	bySeverity(accepted)
+	return failing, accepted
+}
+
+// Options configure a scan
+type Options struct {
+	// Image defaults to DefaultImage
+	Image string
+	// RunOptions name, label and pull the Trivy container
+	dockerrun.RunOptions
+	// CacheDir keeps Trivy's vulnerability database between runs; empty
+	// downloads it every time
+	CacheDir string
+	// Offline scans with the database already in CacheDir, without
+	// updating it
+	Offline bool
+}
+
+// Scan runs Trivy on the image archive in dir/name, writing its JSON
+// report to dir/trivy.json, and returns the parsed report. Trivy's exit
+// code is ignored and the caller gates on the findings instead.
+func Scan(ctx context.Context, docker dockerrun.Client, dir, name string, opts Options) (*Report, error) {
+	if opts.Image == "" {
+		opts.Image = DefaultImage
+	}
+	workDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	c := dockerrun.Container{
+		Image: opts.Image,
+		Cmd: []string{"image", "--input", "/work/" + name, "--scanners", "vuln",
+			"--format", "json", "--output", "/work/trivy.json", "--quiet"},
+		Mounts:     []dockerrun.Mount{{Source: workDir, Target: "/work"}},
+		RunOptions: opts.RunOptions,
+	}
+	if opts.CacheDir != "" {
+		cacheDir, err := filepath.Abs(opts.CacheDir)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+			return nil, err
+		}
+		c.Mounts = append(c.Mounts, dockerrun.Mount{Source: cacheDir, Target: "/root/.cache/trivy"})
+	}
+	if opts.Offline {
+		c.Cmd = append(c.Cmd, "--skip-db-update")
+	}
+	res, err := dockerrun.Run(ctx, docker, c)
+	if err != nil {
+		return nil, fmt.Errorf("Trivy: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, "trivy.json"))
+	if err != nil {
+		return nil, fmt.Errorf("Trivy produced no report (exit status %d): %s", res.ExitCode, dockerrun.LastLines(res.Output, 20))
+	}
+	return ParseReport(data)
+}
@@ -0,0 +1,124 @@
+package vulnscan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sample is trimmed from a trivy image --format json report
+const sample = `{
+  "SchemaVersion": 2,
+  "ArtifactName": "/work/image.tar",
+  "Results": [
+    {"Target": "resume:test (alpine 3.19.1)", "Class": "os-pkgs", "Type": "alpine",
+     "Vulnerabilities": [
+       {"VulnerabilityID": "CVE-2024-0727", "PkgName": "libcrypto3", "InstalledVersion": "3.1.4-r2", "FixedVersion": "3.1.4-r5",
+        "Severity": "MEDIUM", "Title": "openssl: denial of service via null dereference"},
+       {"VulnerabilityID": "CVE-2024-2511", "PkgName": "libssl3", "InstalledVersion": "3.1.4-r2",
+        "Severity": "HIGH", "PrimaryURL": "https://avd.aquasec.com/nvd/cve-2024-2511"},
+       {"VulnerabilityID": "CVE-2023-9999", "PkgName": "busybox", "InstalledVersion": "1.36.1-r15", "FixedVersion": "1.36.1-r16",
+        "Severity": "CRITICAL", "Title": "busybox: out-of-bounds write"}
+     ]},
+    {"Target": "Node.js", "Class": "lang-pkgs", "Type": "node-pkg"}
+  ]
+}`
+
+func TestFindings(t *testing.T) {
+	r, err := ParseReport([]byte(sample))
+	require.NoError(t, err)
+
+	failing, accepted := r.Findings(Gate{MinSeverity: "critical"})
+	require.Len(t, failing, 1)
+	assert.Empty(t, accepted)
+	assert.Equal(t, "CRITICAL CVE-2023-9999 in busybox 1.36.1-r15 (resume:test (alpine 3.19.1), fixed in 1.36.1-r16)", failing[0].String())
+
+	failing, _ = r.Findings(Gate{MinSeverity: "MEDIUM"})
+	require.Len(t, failing, 3)
+	assert.Equal(t, []string{"CVE-2023-9999", "CVE-2024-2511", "CVE-2024-0727"}, ids(failing), "most severe first")
+
+	failing, _ = r.Findings(Gate{MinSeverity: "MEDIUM", IgnoreUnfixed: true})
+	assert.Equal(t, []string{"CVE-2023-9999", "CVE-2024-0727"}, ids(failing))
+
+	ig := &Ignore{entries: map[string]time.Time{"CVE-2023-9999": {}}}
+	failing, accepted = r.Findings(Gate{MinSeverity: "HIGH", Ignore: ig})
+	assert.Equal(t, []string{"CVE-2024-2511"}, ids(failing))
+	assert.Equal(t, []string{"CVE-2023-9999"}, ids(accepted))
+}
+
+func ids(vs []Vulnerability) []string {
+	var out []string
+	for _, v := range vs {
+		out = append(out, v.ID)
+	}
+	return out
+}
+
+func TestParseReportRejectsGarbage(t *testing.T) {
+	_, err := ParseReport([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestLoadIgnore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".trivyignore")
+	require.NoError(t, os.WriteFile(path, []byte(`# accepted until the base image moves
+CVE-2024-2511 exp:2026-10-16
+CVE-2024-0727 exp:2026-01-31 # expired
+
+CVE-2023-9999
+`), 0o644))
+	ig, err := LoadIgnore(path, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, ig.Accepts("CVE-2024-2511"), "entries last to the end of their expiry day")
+	assert.True(t, ig.Accepts("CVE-2023-9999"))
+	assert.False(t, ig.Accepts("CVE-2024-0727"))
+	assert.Equal(t, []string{"CVE-2024-0727 (expired 2026-01-31)"}, ig.Expired)
+
+	ig, err = LoadIgnore(filepath.Join(t.TempDir(), "missing"), time.Now())
+	require.NoError(t, err)
+	assert.False(t, ig.Accepts("CVE-2023-9999"))
+
+	require.NoError(t, os.WriteFile(path, []byte("CVE-1 exp:soon\n"), 0o644))
+	_, err = LoadIgnore(path, time.Now())
+	assert.ErrorContains(t, err, ":1: expiry")
+}
+
+func TestSARIF(t *testing.T) {
+	r, err := ParseReport([]byte(sample))
+	require.NoError(t, err)
+	ig := &Ignore{entries: map[string]time.Time{"CVE-2024-0727": {}}}
+	failing, accepted := r.Findings(Gate{MinSeverity: "MEDIUM", Ignore: ig})
+
+	data, err := SARIF("resume:test", "0.50.1", failing, accepted)
+	require.NoError(t, err)
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string           `json:"name"`
+					Rules []map[string]any `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "Trivy", run.Tool.Driver.Name)
+	assert.Len(t, run.Tool.Driver.Rules, 3)
+	require.Len(t, run.Results, 3)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "CVE-2024-0727", run.Results[2].RuleID)
+	assert.Equal(t, "note", run.Results[2].Level)
+}
@@ -0,0 +1,152 @@
+// Package zap runs the OWASP ZAP baseline scan against a served copy of the
+// site and gates on the alerts it raises. ZAP runs from its container image,
+// so only Docker is required.
+package zap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/dockerrun"
+)
+
+// DefaultImage is the ZAP release the scan runs from
+const DefaultImage = "ghcr.io/zaproxy/zaproxy:stable"
+
+// Risk levels as reported in riskcode
+const (
+	Informational = iota
+	Low
+	Medium
+	High
+)
+
+// RiskName returns the ZAP name of a risk level
+func RiskName(risk int) string {
+	switch risk {
+	case Low:
+		return "Low"
+	case Medium:
+		return "Medium"
+	case High:
+		return "High"
+	}
+	return "Informational"
+}
+
+// Report is the subset of ZAP's JSON report (-J) the gate needs
+type Report struct {
+	Sites []struct {
+		Name   string  `json:"@name"`
+		Alerts []Alert `json:"alerts"`
+	} `json:"site"`
+}
+
+// Alert is one finding type with the URLs it was seen on
+type Alert struct {
+	PluginID  string `json:"pluginid"`
+	Name      string `json:"name"`
+	RiskCode  string `json:"riskcode"`
+	RiskDesc  string `json:"riskdesc"`
+	Solution  string `json:"solution"`
+	Instances []struct {
+		URI string `json:"uri"`
+	} `json:"instances"`
+}
+
+// Risk returns the alert's risk level
+func (a Alert) Risk() int {
+	risk, _ := strconv.Atoi(a.RiskCode)
+	return risk
+}
+
+// ParseReport decodes a ZAP JSON report
+func ParseReport(data []byte) (*Report, error) {
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse ZAP report: %w", err)
+	}
+	return &r, nil
+}
+
+// Findings returns alerts at or above minRisk, skipping ignored plugin IDs,
+// highest risk first
+func (r *Report) Findings(minRisk int, ignore []string) []Alert {
+	var findings []Alert
+	for _, site := range r.Sites {
+		for _, a := range site.Alerts {
+			if a.Risk() >= minRisk && !slices.Contains(ignore, a.PluginID) {
+				findings = append(findings, a)
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Risk() > findings[j].Risk() })
+	return findings
+}
+
+// Options configure a baseline scan
+type Options struct {
+	// Image defaults to DefaultImage
+	Image string
+	// Network is the container's network mode; "host" lets ZAP reach a
+	// container published on localhost
+	Network string
+	// RunOptions name, label and pull the ZAP container
+	dockerrun.RunOptions
+	// Minutes bounds the spider (-m)
+	Minutes int
+	// WorkDir receives the JSON and HTML reports; a temp dir when empty
+	WorkDir string
+}
+
+// Baseline runs zap-baseline.py against target and returns its report.
+// The script's exit code reflects ZAP's own thresholds, so it is ignored
+// and the caller gates on the parsed alerts instead.
+func Baseline(ctx context.Context, docker dockerrun.Client, target string, opts Options) (*Report, error) {
+	if opts.Image == "" {
+		opts.Image = DefaultImage
+	}
+	if opts.Minutes == 0 {
+		opts.Minutes = 1
+	}
+	if opts.WorkDir == "" {
+		dir, err := os.MkdirTemp("", "osyraa-zap-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		opts.WorkDir = dir
+	}
+	workDir, err := filepath.Abs(opts.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	// ZAP runs as an unprivileged user and must be able to write its reports
+	if err := os.Chmod(workDir, 0o777); err != nil {
+		return nil, err
+	}
+
+	res, err := dockerrun.Run(ctx, docker, dockerrun.Container{
+		Image: opts.Image,
+		Cmd: []string{"zap-baseline.py", "-t", target,
+			"-m", strconv.Itoa(opts.Minutes), "-J", "zap.json", "-r", "zap.html", "-I"},
+		Mounts:     []dockerrun.Mount{{Source: workDir, Target: "/zap/wrk"}},
+		Network:    opts.Network,
+		RunOptions: opts.RunOptions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ZAP: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(workDir, "zap.json"))
+	if err != nil {
+		return nil, fmt.Errorf("ZAP produced no report (exit status %d): %s", res.ExitCode, dockerrun.LastLines(res.Output, 20))
+	}
+	return ParseReport(data)
+}
@@ -0,0 +1,45 @@
+package zap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sample is trimmed from a zap-baseline.py -J report
+const sample = `{
+  "@version": "2.14.0",
+  "site": [{
+    "@name": "http://localhost:8080",
+    "alerts": [
+      {"pluginid": "10038", "name": "Content Security Policy (CSP) Header Not Set", "riskcode": "2", "riskdesc": "Medium (High)",
+       "instances": [{"uri": "http://localhost:8080/"}, {"uri": "http://localhost:8080/sitemap.xml"}]},
+      {"pluginid": "10036", "name": "Server Leaks Version Information", "riskcode": "1", "riskdesc": "Low (High)",
+       "instances": [{"uri": "http://localhost:8080/"}]},
+      {"pluginid": "10020", "name": "Missing Anti-clickjacking Header", "riskcode": "3", "riskdesc": "High (Medium)",
+       "instances": [{"uri": "http://localhost:8080/"}]},
+      {"pluginid": "10109", "name": "Modern Web Application", "riskcode": "0", "riskdesc": "Informational (Medium)"}
+    ]
+  }]
+}`
+
+func TestFindings(t *testing.T) {
+	r, err := ParseReport([]byte(sample))
+	require.NoError(t, err)
+
+	findings := r.Findings(Medium, nil)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "10020", findings[0].PluginID, "highest risk first")
+	assert.Equal(t, "10038", findings[1].PluginID)
+	assert.Len(t, findings[1].Instances, 2)
+
+	assert.Len(t, r.Findings(Medium, []string{"10038"}), 1)
+	assert.Len(t, r.Findings(Informational, nil), 4)
+	assert.Equal(t, "Medium", RiskName(findings[1].Risk()))
+}
+
+func TestParseReportRejectsGarbage(t *testing.T) {
+	_, err := ParseReport([]byte("not json"))
+	assert.Error(t, err)
+}
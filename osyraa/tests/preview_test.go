@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/battery"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreviewDeployment validates a Netlify or Vercel preview deployment at
+// OSYRAA_PREVIEW_URL with the HTTP battery and a diff against public/.
+// OSYRAA_PREVIEW_BYPASS passes Vercel deployment protection.
+func TestPreviewDeployment(t *testing.T) {
+	requireNetwork(t)
+	url := os.Getenv("OSYRAA_PREVIEW_URL")
+	if url == "" {
+		t.Skip("set OSYRAA_PREVIEW_URL to validate a preview deployment")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	local, err := deploy.LocalTree(publicDir(t))
+	require.NoError(t, err, "Failed to hash local public/")
+	require.NotEmpty(t, local, "public/ should be built before checking a preview")
+
+	target := deploy.PreviewTarget(url, os.Getenv("OSYRAA_PREVIEW_BYPASS"))
+	target.Expect.ContentHashes = localContentHashes(t)
+	target.Expect.MaxResponseTime = 3 * time.Second
+
+	report, err := deploy.CheckPreview(ctx, target, battery.Default(), local)
+	require.NoError(t, err, "Failed to check preview deployment")
+
+	for _, result := range report.Results {
+		assert.NoError(t, result.Err, "Check %s on %s preview", result.Check, report.Provider)
+	}
+	assert.Empty(t, report.Parity.Missing, "Preview should serve every built file")
+	assert.Empty(t, report.Parity.Stale, "Preview should serve the current build")
+	for path, diffs := range report.Parity.Headers {
+		t.Logf("%s: %v", path, diffs)
+	}
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/resume"
+	"github.com/stretchr/testify/require"
+)
+
+// contentFile is the resume's source, relative to the tests directory
+var contentFile = filepath.Join("..", "content", "_index.md")
+
+// TestResumeData validates the resume's Markdown before Hugo renders it:
+// required sections with entries, parseable dates that are not in the
+// future, and no entry without a description. It needs neither Hugo nor
+// Docker, so CI runs it ahead of the image build.
+func TestResumeData(t *testing.T) {
+	t.Parallel()
+	r, err := resume.Load(contentFile)
+	require.NoError(t, err, "The resume content file should parse")
+
+	for _, p := range resume.Validate(r, time.Now()) {
+		t.Errorf("%s: %s", contentFile, p)
+	}
+	t.Logf("%d jobs, %d education entries, %d certifications, %d skill groups, %d projects",
+		len(r.Experience), len(r.Education), len(r.Certifications), len(r.Skills), len(r.Projects))
+}
+
+// TestResumeChronology checks jobs and education entries are listed in
+// OSYRAA_RESUME_ORDER (newest-first, the default, or oldest-first) and
+// that no more than OSYRAA_RESUME_MAX_CURRENT jobs (default 1) end in
+// "Present"
+func TestResumeChronology(t *testing.T) {
+	t.Parallel()
+	opts := resume.DefaultChronology()
+	if v := os.Getenv("OSYRAA_RESUME_ORDER"); v != "" {
+		opts.Order = v
+	}
+	if v := os.Getenv("OSYRAA_RESUME_MAX_CURRENT"); v != "" {
+		n, err := strconv.Atoi(v)
+		require.NoError(t, err, "OSYRAA_RESUME_MAX_CURRENT should be a number")
+		opts.MaxCurrent = n
+	}
+
+	r, err := resume.Load(contentFile)
+	require.NoError(t, err, "The resume content file should parse")
+	for _, p := range resume.CheckChronology(r, opts) {
+		t.Errorf("%s: %s", contentFile, p)
+	}
+}
+
+// TestResumeCertificationExpiry fails when a listed certification has
+// expired and warns when one expires within OSYRAA_CERT_WARN_DAYS (default
+// 60), so the published resume never shows a lapsed credential. Expiry
+// comes from the certification's details, e.g.
+// "- **Certified Kubernetes Administrator** (CKA) | Expires March 2027".
+func TestResumeCertificationExpiry(t *testing.T) {
+	t.Parallel()
+	window := resume.DefaultExpiryWindow
+	if v := os.Getenv("OSYRAA_CERT_WARN_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		require.NoError(t, err, "OSYRAA_CERT_WARN_DAYS should be a number of days")
+		window = time.Duration(days) * 24 * time.Hour
+	}
+
+	r, err := resume.Load(contentFile)
+	require.NoError(t, err, "The resume content file should parse")
+	expiring, dated, err := resume.CheckExpiry(r.Certifications, time.Now(), window)
+	require.NoError(t, err, "Certification expiry dates should parse")
+	if dated == 0 {
+		t.Logf("None of the %d certifications lists an expiry date", len(r.Certifications))
+		return
+	}
+
+	section := report.Section{Title: "Certification expiry", Status: report.Pass,
+		Summary: fmt.Sprintf("%d of %d certifications list an expiry; none lapse within %d days",
+			dated, len(r.Certifications), int(window.Hours()/24))}
+	if len(expiring) > 0 {
+		section.Table = &report.Table{Header: []string{"Certification", "Expires", "Status"}}
+		section.Status = report.Warn
+		section.Summary = fmt.Sprintf("%d of %d dated certifications expired or expiring", len(expiring), dated)
+	}
+	for _, e := range expiring {
+		status := "expiring"
+		if e.Expired {
+			status = "expired"
+			section.Status = report.Fail
+			t.Errorf("%s: %s; renew it or remove it from the resume", contentFile, e)
+		} else {
+			t.Logf("Warning: %s: %s", contentFile, e)
+		}
+		section.Table.Rows = append(section.Table.Rows,
+			[]string{e.Cert.Name, e.Ends.AddDate(0, 0, -1).Format("2006-01-02"), status})
+	}
+	harnessReport.Add(section)
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/deploy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestS3Deployment verifies a sync of public/ to S3 and its CloudFront
+// distribution. Configure with OSYRAA_S3_BUCKET (required), OSYRAA_S3_PREFIX,
+// OSYRAA_CLOUDFRONT_ID, OSYRAA_CLOUDFRONT_URL and OSYRAA_INVALIDATION_ID.
+func TestS3Deployment(t *testing.T) {
+	requireNetwork(t)
+	site := &deploy.S3Site{
+		Bucket:         os.Getenv("OSYRAA_S3_BUCKET"),
+		Prefix:         os.Getenv("OSYRAA_S3_PREFIX"),
+		DistributionID: os.Getenv("OSYRAA_CLOUDFRONT_ID"),
+		CDNURL:         os.Getenv("OSYRAA_CLOUDFRONT_URL"),
+	}
+	if site.Bucket == "" {
+		t.Skip("set OSYRAA_S3_BUCKET to verify an S3 deployment")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+	defer cancel()
+
+	local, err := deploy.LocalTree(publicDir(t))
+	require.NoError(t, err, "Failed to hash local public/")
+	require.NotEmpty(t, local, "public/ should be built before verifying a deployment")
+
+	remote, err := site.List(ctx)
+	require.NoError(t, err, "Failed to list bucket")
+
+	t.Run("Checksums", func(t *testing.T) {
+		keys := make([]string, 0, len(remote))
+		for k := range remote {
+			keys = append(keys, k)
+		}
+		diff := deploy.CompareTrees(local, keys, func(lf deploy.LocalFile, p string) bool {
+			return deploy.SameAsLocal(remote[p], lf)
+		})
+		assert.Empty(t, diff.Missing, "Every built file should be uploaded")
+		assert.Empty(t, diff.Changed, "Uploaded files should match the local build")
+		if len(diff.Extra) > 0 {
+			t.Logf("Objects not in the local build (stale uploads?): %v", diff.Extra)
+		}
+	})
+
+	t.Run("Headers", func(t *testing.T) {
+		policy := deploy.DefaultCachePolicy()
+		for rel, obj := range remote {
+			if _, ok := local[rel]; !ok {
+				continue
+			}
+			head, err := site.Head(ctx, obj.Key)
+			if !assert.NoError(t, err, "Failed to read metadata for %s", obj.Key) {
+				continue
+			}
+			assert.NoError(t, deploy.CheckObjectHeaders(head, policy))
+		}
+	})
+
+	t.Run("CloudFront", func(t *testing.T) {
+		if site.CDNURL == "" {
+			t.Skip("set OSYRAA_CLOUDFRONT_URL to verify CDN content")
+		}
+		if id := os.Getenv("OSYRAA_INVALIDATION_ID"); id != "" && site.DistributionID != "" {
+			require.NoError(t, site.WaitInvalidation(ctx, id, 15*time.Second), "Invalidation should complete")
+		}
+
+		paths := []string{"index.html"}
+		client := &http.Client{Timeout: 15 * time.Second}
+		stale, err := deploy.VerifyCDN(ctx, client, site.CDNURL, local, paths)
+		require.NoError(t, err, "Failed to fetch through CloudFront")
+		assert.Empty(t, stale, "CloudFront should serve the new build")
+	})
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/sbom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImageSBOM generates a CycloneDX and an SPDX SBOM for the built image
+// with syft (OSYRAA_SBOM=1), keeps both as artifacts, and fails on
+// disallowed licenses and unexpected packages under
+// testdata/sbom-policy.yaml or the file OSYRAA_SBOM_POLICY names.
+func (suite *DockerTestSuite) TestImageSBOM() {
+	t := suite.T()
+	if os.Getenv("OSYRAA_SBOM") != "1" {
+		t.Skip("set OSYRAA_SBOM=1 to generate and check the image's SBOM")
+	}
+	policyPath := os.Getenv("OSYRAA_SBOM_POLICY")
+	if policyPath == "" {
+		policyPath = filepath.Join("testdata", "sbom-policy.yaml")
+	}
+	policy, err := sbom.LoadPolicy(policyPath)
+	require.NoError(t, err, "The SBOM policy should load")
+
+	workDir, relDir, err := harnessReport.ArtifactPath("sbom")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(workDir, 0o755))
+	ctx, cancel := context.WithTimeout(suite.ctx, 10*time.Minute)
+	defer cancel()
+	archive := filepath.Join(workDir, "image.tar")
+	require.NoError(t, saveImage(ctx, suite.client, suite.imageTag, archive), "TestImageSBOM needs the suite's image")
+	defer os.Remove(archive)
+
+	name := "osyraa-syft-" + runID
+	gen := suite.cleanups.Add("syft container "+name, func(ctx context.Context) error { return removeContainer(ctx, suite.client, name) })
+	defer gen.Run(context.Background())
+	bom, err := sbom.Generate(ctx, suite.client, workDir, "image.tar", sbom.Options{RunOptions: runOptions(name)})
+	require.NoError(t, err, "syft should generate the SBOM")
+	require.NotEmpty(t, bom.Packages, "The SBOM should list the image's packages")
+	t.Logf("SBOM lists %d packages", len(bom.Packages))
+
+	violations := policy.Check(bom)
+	table := &report.Table{Header: []string{"Package", "Version", "Licenses", "Rule", "Detail"}}
+	for _, v := range violations {
+		table.Rows = append(table.Rows, []string{v.Package.ID(), v.Package.Version, strings.Join(v.Package.Licenses, ", "), v.Rule, v.Detail})
+		assert.Fail(t, "SBOM policy violation", v.String())
+	}
+	section := report.Section{Title: "Image SBOM", Status: report.Pass, Table: table,
+		Summary: fmt.Sprintf("%d packages, %d policy violations under %s; SBOMs in %s/%s and %s/%s",
+			len(bom.Packages), len(violations), policyPath, relDir, sbom.CycloneDXFile, relDir, sbom.SPDXFile)}
+	if len(violations) > 0 {
+		section.Status = report.Fail
+	}
+	harnessReport.Add(section)
+}
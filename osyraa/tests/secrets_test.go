@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/secrets"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPublishedSecrets scans the built public/ for API keys, private key
+// blocks, JWTs and other credentials. OSYRAA_SECRETS_ALLOW is a regular
+// expression for matches that are meant to be public, such as a
+// referrer-restricted browser API key.
+func TestPublishedSecrets(t *testing.T) {
+	public := publicDir(t)
+
+	var allow []*regexp.Regexp
+	if v := os.Getenv("OSYRAA_SECRETS_ALLOW"); v != "" {
+		re, err := regexp.Compile(v)
+		require.NoError(t, err, "OSYRAA_SECRETS_ALLOW should be a regular expression")
+		allow = append(allow, re)
+	}
+
+	findings, err := secrets.Scan(public, secrets.DefaultRules, allow)
+	require.NoError(t, err, "Failed to scan public/")
+
+	section := report.Section{Title: "Published secrets", Status: report.Pass, Summary: "No secrets found in public/"}
+	if len(findings) > 0 {
+		table := &report.Table{Header: []string{"File", "Line", "Rule", "Match"}}
+		for _, f := range findings {
+			t.Errorf("Possible secret in public/%s", f)
+			table.Rows = append(table.Rows, []string{f.File, strconv.Itoa(f.Line), f.Rule, f.Redacted()})
+		}
+		section.Status = report.Fail
+		section.Summary = fmt.Sprintf("%d possible secrets in public/", len(findings))
+		section.Table = table
+	}
+	harnessReport.Add(section)
+}
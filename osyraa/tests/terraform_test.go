@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/tfplan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// terraformDir holds the site's Azure infrastructure
+var terraformDir = filepath.Join("..", "..", "terraform-infrastructure")
+
+// TestTerraformPlanPolicies enforces the infrastructure policies on the plan
+// at OSYRAA_TF_PLAN, either `terraform show -json` output or a binary plan
+// file created in terraform-infrastructure/
+func TestTerraformPlanPolicies(t *testing.T) {
+	path := os.Getenv("OSYRAA_TF_PLAN")
+	if path == "" {
+		t.Skip("set OSYRAA_TF_PLAN to check a Terraform/OpenTofu plan")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	plan, err := tfplan.Load(ctx, terraformDir, path)
+	require.NoError(t, err, "Failed to load plan")
+
+	for _, v := range tfplan.Evaluate(plan, tfplan.Default()) {
+		assert.Fail(t, "Plan violates policy", v.String())
+	}
+	t.Logf("Checked %d resource changes", len(plan.ResourceChanges))
+}
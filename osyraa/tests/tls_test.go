@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/tlscheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tlsOptions reads the expiry windows from OSYRAA_TLS_WARN_DAYS and
+// OSYRAA_TLS_FAIL_DAYS
+func tlsOptions(t *testing.T) tlscheck.Options {
+	opts := tlscheck.DefaultOptions()
+	for env, window := range map[string]*time.Duration{
+		"OSYRAA_TLS_WARN_DAYS": &opts.Warn,
+		"OSYRAA_TLS_FAIL_DAYS": &opts.Fail,
+	} {
+		if v := os.Getenv(env); v != "" {
+			days, err := strconv.Atoi(v)
+			require.NoError(t, err, "%s should be a number of days", env)
+			*window = time.Duration(days) * 24 * time.Hour
+		}
+	}
+	return opts
+}
+
+// TestTLSCertificate validates the certificate chain served on each of the
+// site's domains and its remaining lifetime. Enable with OSYRAA_TLS=1.
+func TestTLSCertificate(t *testing.T) {
+	requireNetwork(t)
+	if os.Getenv("OSYRAA_TLS") != "1" {
+		t.Skip("set OSYRAA_TLS=1 to check production certificates")
+	}
+	opts := tlsOptions(t)
+
+	for _, domain := range siteDomains(t) {
+		t.Run(domain, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+
+			report, err := tlscheck.Check(ctx, net.JoinHostPort(domain, "443"), domain, opts)
+			require.NoError(t, err, "Failed to connect to %s", domain)
+			assert.NoError(t, report.Err, "Certificate for %s", domain)
+			if report.Warning != "" {
+				t.Logf("WARNING: %s", report.Warning)
+			}
+			t.Logf("%s: issued by %q, expires in %v", domain, report.Leaf.Issuer.CommonName, report.ExpiresIn.Round(time.Hour))
+		})
+	}
+}
+
+// TestTLSProtocols enumerates the protocol versions and cipher suites each
+// domain accepts, fails on TLS 1.0/1.1 or suites outside Mozilla's
+// intermediate profile, and adds the capability matrix to the report.
+// Enable with OSYRAA_TLS=1.
+func TestTLSProtocols(t *testing.T) {
+	requireNetwork(t)
+	if os.Getenv("OSYRAA_TLS") != "1" {
+		t.Skip("set OSYRAA_TLS=1 to scan production TLS configuration")
+	}
+
+	for _, domain := range siteDomains(t) {
+		t.Run(domain, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			matrix, err := tlscheck.Scan(ctx, net.JoinHostPort(domain, "443"), domain)
+			require.NoError(t, err, "Failed to scan %s", domain)
+
+			table := &report.Table{Header: []string{"Protocol", "Cipher suite", "Weak"}}
+			for _, s := range matrix.Suites {
+				weak := ""
+				if s.Weak() {
+					weak = "yes"
+				}
+				table.Rows = append(table.Rows, []string{tls.VersionName(s.Version), s.Name, weak})
+			}
+			var versions []string
+			for _, v := range matrix.Versions {
+				versions = append(versions, tls.VersionName(v))
+			}
+			problems := matrix.Problems()
+			section := report.Section{Title: "TLS handshake matrix: " + domain, Status: report.Pass, Table: table,
+				Summary: "Protocols: " + strings.Join(versions, ", ")}
+			if len(problems) > 0 {
+				section.Status = report.Fail
+				section.Summary += "; " + strings.Join(problems, "; ")
+			}
+			harnessReport.Add(section)
+
+			for _, p := range problems {
+				t.Errorf("%s: %s", domain, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spider-2y-banana/osyraa/tests/pkg/report"
+	"github.com/spider-2y-banana/osyraa/tests/pkg/vulnscan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImageVulnerabilities scans the built image with Trivy
+// (OSYRAA_VULN_SCAN=1) and fails on vulnerabilities at
+// OSYRAA_VULN_MIN_SEVERITY (default critical) or above. Accepted CVEs are
+// listed in .trivyignore or the file OSYRAA_VULN_IGNORE names, and
+// OSYRAA_VULN_IGNORE_UNFIXED=1 passes those with no released fix. Trivy's
+// JSON report and a SARIF log of the gated findings are kept as artifacts.
+func (suite *DockerTestSuite) TestImageVulnerabilities() {
+	t := suite.T()
+	if os.Getenv("OSYRAA_VULN_SCAN") != "1" {
+		t.Skip("set OSYRAA_VULN_SCAN=1 to scan the image with Trivy")
+	}
+	minSeverity := os.Getenv("OSYRAA_VULN_MIN_SEVERITY")
+	if minSeverity == "" {
+		minSeverity = "critical"
+	}
+	if vulnscan.SeverityLevel(minSeverity) < 0 {
+		t.Fatalf("unsupported OSYRAA_VULN_MIN_SEVERITY %q; use one of %s", minSeverity, strings.Join(vulnscan.Severities, ", "))
+	}
+	ignorePath := os.Getenv("OSYRAA_VULN_IGNORE")
+	if ignorePath == "" {
+		ignorePath = ".trivyignore"
+	}
+	ignore, err := vulnscan.LoadIgnore(ignorePath, time.Now())
+	require.NoError(t, err, "The vulnerability ignore file should parse")
+	for _, entry := range ignore.Expired {
+		t.Logf("%s: %s no longer applies", ignorePath, entry)
+	}
+	// Offline runs scan with the database a previous run left in the cache
+	opts := vulnscan.Options{RunOptions: runOptions("osyraa-trivy-" + runID), Offline: *offline}
+	if dir, err := os.UserCacheDir(); err == nil {
+		opts.CacheDir = filepath.Join(dir, "osyraa", "trivy")
+	}
+
+	workDir, relDir, err := harnessReport.ArtifactPath("vulnscan")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(workDir, 0o755))
+	ctx, cancel := context.WithTimeout(suite.ctx, 15*time.Minute)
+	defer cancel()
+	archive := filepath.Join(workDir, "image.tar")
+	require.NoError(t, saveImage(ctx, suite.client, suite.imageTag, archive), "TestImageVulnerabilities needs the suite's image")
+	// The archive is only Trivy's input; the image itself is the artifact
+	defer os.Remove(archive)
+
+	scan := suite.cleanups.Add("Trivy container "+opts.Name, func(ctx context.Context) error { return removeContainer(ctx, suite.client, opts.Name) })
+	defer scan.Run(context.Background())
+	result, err := vulnscan.Scan(ctx, suite.client, workDir, "image.tar", opts)
+	require.NoError(t, err, "The Trivy scan should complete")
+
+	failing, accepted := result.Findings(vulnscan.Gate{
+		MinSeverity:   minSeverity,
+		IgnoreUnfixed: os.Getenv("OSYRAA_VULN_IGNORE_UNFIXED") == "1",
+		Ignore:        ignore,
+	})
+	version := vulnscan.DefaultImage[strings.LastIndex(vulnscan.DefaultImage, ":")+1:]
+	sarif, err := vulnscan.SARIF(suite.imageTag, version, failing, accepted)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "trivy.sarif"), sarif, 0o644))
+
+	table := &report.Table{Header: []string{"Severity", "Vulnerability", "Package", "Installed", "Fixed", "Status"}}
+	row := func(v vulnscan.Vulnerability, status string) {
+		table.Rows = append(table.Rows, []string{v.Severity, v.ID, v.PkgName, v.InstalledVersion, v.FixedVersion, status})
+	}
+	for _, v := range failing {
+		row(v, "failing")
+		assert.Fail(t, "Image vulnerability", v.String())
+	}
+	for _, v := range accepted {
+		row(v, "accepted")
+		t.Logf("Accepted in %s: %s", ignorePath, v)
+	}
+	section := report.Section{Title: "Image vulnerability scan", Status: report.Pass, Table: table,
+		Summary: fmt.Sprintf("%d vulnerabilities at %s or above, %d accepted; reports in %s/trivy.json and %s/trivy.sarif",
+			len(failing), strings.ToUpper(minSeverity), len(accepted), relDir, relDir)}
+	switch {
+	case len(failing) > 0:
+		section.Status = report.Fail
+	case len(ignore.Expired) > 0:
+		section.Status = report.Warn
+		section.Summary += fmt.Sprintf("; %d expired entries in %s", len(ignore.Expired), ignorePath)
+	}
+	harnessReport.Add(section)
+}